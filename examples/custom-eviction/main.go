@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/obcache"
+)
+
+// PriorityStrategy evicts low-priority keys first and, within a priority,
+// the least recently used key
+type PriorityStrategy struct {
+	priority func(key string) int
+	lastUse  map[string]uint64
+	clock    uint64
+}
+
+// NewPriorityStrategy creates a strategy that ranks keys with priority
+// Keys with a higher priority are kept longer
+func NewPriorityStrategy(priority func(key string) int) *PriorityStrategy {
+	return &PriorityStrategy{
+		priority: priority,
+		lastUse:  make(map[string]uint64),
+	}
+}
+
+// OnAdd records a stored key as just used
+func (p *PriorityStrategy) OnAdd(key string, _ any) {
+	p.clock++
+	p.lastUse[key] = p.clock
+}
+
+// OnAccess records a read of key
+func (p *PriorityStrategy) OnAccess(key string) {
+	p.clock++
+	p.lastUse[key] = p.clock
+}
+
+// OnRemove forgets key
+func (p *PriorityStrategy) OnRemove(key string) {
+	delete(p.lastUse, key)
+}
+
+// Victims returns up to n keys, lowest priority and least recently used first
+func (p *PriorityStrategy) Victims(n int) []string {
+	keys := make([]string, 0, len(p.lastUse))
+	for key := range p.lastUse {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		pi, pj := p.priority(keys[i]), p.priority(keys[j])
+		if pi != pj {
+			return pi < pj
+		}
+		return p.lastUse[keys[i]] < p.lastUse[keys[j]]
+	})
+	if n < len(keys) {
+		keys = keys[:n]
+	}
+	return keys
+}
+
+// customerPriority keeps premium customers' data in cache the longest
+func customerPriority(key string) int {
+	if strings.HasPrefix(key, "premium:") {
+		return 1
+	}
+	return 0
+}
+
+func main() {
+	config := obcache.NewDefaultConfig().
+		WithMaxEntries(3).
+		WithEvictionStrategyFactory(func(_ int) obcache.EvictionStrategy {
+			return NewPriorityStrategy(customerPriority)
+		})
+
+	cache, err := obcache.New(config)
+	if err != nil {
+		panic(err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	_ = cache.Set("premium:alice", "profile", time.Hour)
+	_ = cache.Set("free:bob", "profile", time.Hour)
+	_ = cache.Set("free:carol", "profile", time.Hour)
+
+	// The cache is full; a new entry evicts the least recently used free customer
+	_ = cache.Set("premium:dave", "profile", time.Hour)
+
+	for _, key := range []string{"premium:alice", "free:bob", "free:carol", "premium:dave"} {
+		fmt.Printf("%-14s cached: %v\n", key, cache.Has(key))
+	}
+	fmt.Printf("Evictions: %d\n", cache.Stats().Evictions())
+}
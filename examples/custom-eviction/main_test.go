@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/obcache"
+)
+
+func TestPriorityStrategyVictims(t *testing.T) {
+	strategy := NewPriorityStrategy(customerPriority)
+	strategy.OnAdd("premium:a", nil)
+	strategy.OnAdd("free:b", nil)
+	strategy.OnAdd("free:c", nil)
+	strategy.OnAccess("free:b")
+
+	victims := strategy.Victims(3)
+	expected := []string{"free:c", "free:b", "premium:a"}
+	for i, key := range expected {
+		if victims[i] != key {
+			t.Fatalf("Expected victims %v, got %v", expected, victims)
+		}
+	}
+
+	strategy.OnRemove("free:c")
+	if victims := strategy.Victims(1); len(victims) != 1 || victims[0] != "free:b" {
+		t.Fatalf("Expected free:b after removing free:c, got %v", victims)
+	}
+}
+
+func TestPriorityStrategyWithCache(t *testing.T) {
+	var evicted []string
+	hooks := obcache.NewHooks()
+	hooks.AddOnEvict(func(_ context.Context, key string, _ any, reason obcache.EvictReason) {
+		if reason == obcache.EvictReasonCapacity {
+			evicted = append(evicted, key)
+		}
+	})
+
+	config := obcache.NewDefaultConfig().
+		WithMaxEntries(2).
+		WithHooks(hooks).
+		WithEvictionStrategyFactory(func(_ int) obcache.EvictionStrategy {
+			return NewPriorityStrategy(customerPriority)
+		})
+
+	cache, err := obcache.New(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	_ = cache.Set("free:old", "value", time.Hour)
+	_ = cache.Set("premium:vip", "value", time.Hour)
+	cache.Get("free:old") // Recency alone would now protect free:old
+
+	if candidates := cache.EvictionCandidates(2); len(candidates) != 2 || candidates[0] != "free:old" {
+		t.Fatalf("Expected free:old to be the next eviction candidate, got %v", candidates)
+	}
+
+	_ = cache.Set("free:new", "value", time.Hour)
+
+	if cache.Has("free:old") || !cache.Has("premium:vip") || !cache.Has("free:new") {
+		t.Fatal("Expected the free entry to be evicted before the premium one")
+	}
+	if len(evicted) != 1 || evicted[0] != "free:old" {
+		t.Errorf("Expected OnEvict for free:old, got %v", evicted)
+	}
+	if cache.Stats().Evictions() != 1 {
+		t.Errorf("Expected 1 eviction in stats, got %d", cache.Stats().Evictions())
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+	"github.com/1mb-dev/obcache-go/v2/pkg/obcache"
+	"github.com/1mb-dev/obcache-go/v2/pkg/store"
+)
+
+// MapStore is a minimal map-backed store
+// A backend for a remote KV service would follow the same shape, replacing the
+// map with client calls and serializing entries on the way in and out
+type MapStore struct {
+	mu              sync.RWMutex
+	data            map[string]*entry.Entry
+	cleanupCallback store.EvictCallback
+}
+
+// NewMapStore creates an empty MapStore
+func NewMapStore() *MapStore {
+	return &MapStore{data: make(map[string]*entry.Entry)}
+}
+
+// Get returns the entry for key, reporting expired entries as not found
+func (s *MapStore) Get(key string) (*entry.Entry, bool) {
+	e, found := s.Peek(key)
+	if found {
+		e.Touch()
+	}
+	return e, found
+}
+
+// Peek returns the entry for key without updating its access time
+func (s *MapStore) Peek(key string) (*entry.Entry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	e, found := s.data[key]
+	if !found || e.IsExpired() {
+		return nil, false
+	}
+	return e, true
+}
+
+// Set stores the entry for key
+func (s *MapStore) Set(key string, e *entry.Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data[key] = e
+	return nil
+}
+
+// Delete removes key
+func (s *MapStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.data, key)
+	return nil
+}
+
+// Keys returns the keys of all live entries
+func (s *MapStore) Keys() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]string, 0, len(s.data))
+	for key, e := range s.data {
+		if !e.IsExpired() {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Len returns the number of live entries
+func (s *MapStore) Len() int {
+	return len(s.Keys())
+}
+
+// Clear removes all entries
+func (s *MapStore) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data = make(map[string]*entry.Entry)
+	return nil
+}
+
+// Close releases the store; a map holds nothing to release
+func (s *MapStore) Close() error {
+	return nil
+}
+
+// Cleanup removes expired entries, reporting each one to the cleanup callback
+// The callback runs after the lock is released so hooks may use the cache
+func (s *MapStore) Cleanup() int {
+	s.mu.Lock()
+	expired := make(map[string]any)
+	for key, e := range s.data {
+		if e.IsExpired() {
+			expired[key] = e.Value
+			delete(s.data, key)
+		}
+	}
+	callback := s.cleanupCallback
+	s.mu.Unlock()
+
+	if callback != nil {
+		for key, value := range expired {
+			callback(key, value)
+		}
+	}
+	return len(expired)
+}
+
+// SetCleanupCallback sets the callback Cleanup reports expired entries to
+func (s *MapStore) SetCleanupCallback(callback store.EvictCallback) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cleanupCallback = callback
+}
+
+// UpdateTTL changes the expiration of a live entry; ttl <= 0 removes it
+func (s *MapStore) UpdateTTL(key string, ttl time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, found := s.data[key]
+	if !found || e.IsExpired() {
+		return false
+	}
+	e.UpdateExpiry(ttl)
+	return true
+}
+
+// Ensure MapStore implements the required interfaces
+var (
+	_ store.Store    = (*MapStore)(nil)
+	_ store.TTLStore = (*MapStore)(nil)
+)
+
+func main() {
+	cache, err := obcache.New(obcache.NewDefaultConfig().WithCustomStore(NewMapStore()))
+	if err != nil {
+		panic(err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	_ = cache.Set("user:1", "alice", time.Hour)
+	_ = cache.Set("session:1", "token", 10*time.Millisecond)
+
+	if value, found := cache.Get("user:1"); found {
+		fmt.Println("user:1 =", value)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	fmt.Printf("Expired entries removed: %d\n", cache.ClearExpired())
+	fmt.Printf("Entries: %d, hits: %d\n", cache.Len(), cache.Stats().Hits())
+}
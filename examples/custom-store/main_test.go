@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/obcache"
+)
+
+func newMapStoreCache(t *testing.T, hooks *obcache.Hooks) *obcache.Cache {
+	t.Helper()
+	config := obcache.NewDefaultConfig().WithCustomStore(NewMapStore())
+	if hooks != nil {
+		config.WithHooks(hooks)
+	}
+	cache, err := obcache.New(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	t.Cleanup(func() { _ = cache.Close() })
+	return cache
+}
+
+func TestMapStoreWithCache(t *testing.T) {
+	cache := newMapStoreCache(t, nil)
+
+	if err := cache.Set("key", "value", time.Hour); err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+	if value, found := cache.Get("key"); !found || value != "value" {
+		t.Fatalf("Expected value, got %v (found=%v)", value, found)
+	}
+	if !cache.SetTTL("key", time.Minute) {
+		t.Fatal("Expected SetTTL to reach the store")
+	}
+	if ttl, ok := cache.TTL("key"); !ok || ttl > time.Minute {
+		t.Fatalf("Expected a TTL of at most a minute, got %v", ttl)
+	}
+
+	if err := cache.Delete("key"); err != nil {
+		t.Fatalf("Failed to delete key: %v", err)
+	}
+	if cache.Has("key") {
+		t.Fatal("Expected key to be deleted")
+	}
+}
+
+func TestMapStoreReportsExpirations(t *testing.T) {
+	var expired []string
+	hooks := obcache.NewHooks()
+	hooks.AddOnEvict(func(_ context.Context, key string, _ any, reason obcache.EvictReason) {
+		if reason == obcache.EvictReasonTTL {
+			expired = append(expired, key)
+		}
+	})
+	cache := newMapStoreCache(t, hooks)
+
+	_ = cache.Set("short", "value", 10*time.Millisecond)
+	_ = cache.Set("long", "value", time.Hour)
+	time.Sleep(20 * time.Millisecond)
+
+	if _, found := cache.Get("short"); found {
+		t.Fatal("Expected the expired entry to be a miss")
+	}
+	if removed := cache.ClearExpired(); removed != 1 {
+		t.Fatalf("Expected 1 expired entry to be removed, got %d", removed)
+	}
+	if len(expired) != 1 || expired[0] != "short" {
+		t.Fatalf("Expected an OnEvict call for short, got %v", expired)
+	}
+	if cache.Stats().Evictions() != 1 {
+		t.Fatalf("Expected 1 eviction, got %d", cache.Stats().Evictions())
+	}
+}
+
+func TestMapStoreConcurrentAccess(t *testing.T) {
+	cache := newMapStoreCache(t, nil)
+
+	var wg sync.WaitGroup
+	for w := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 100 {
+				key := fmt.Sprintf("w%d-%d", w, i%10)
+				_ = cache.Set(key, i, time.Hour)
+				cache.Get(key)
+				cache.ClearExpired()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if cache.Len() != 80 {
+		t.Fatalf("Expected 80 keys, got %d", cache.Len())
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/codec"
+	"github.com/1mb-dev/obcache-go/v2/pkg/obcache"
+)
+
+// MsgpackCodec encodes cached values with MessagePack, which is more compact
+// and faster to decode than JSON for most payloads
+type MsgpackCodec struct{}
+
+// Marshal encodes v as MessagePack
+func (MsgpackCodec) Marshal(v any) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+// Unmarshal decodes MessagePack data into v
+func (MsgpackCodec) Unmarshal(data []byte, v any) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+// Name identifies the codec in stored entries
+func (MsgpackCodec) Name() string {
+	return "msgpack"
+}
+
+func init() {
+	// Registering lets instances configured with another codec still read
+	// entries this one writes, e.g. during a rolling deployment
+	codec.Register(MsgpackCodec{})
+}
+
+// Product is a cached value
+type Product struct {
+	SKU   string  `msgpack:"sku"`
+	Name  string  `msgpack:"name"`
+	Price float64 `msgpack:"price"`
+}
+
+func main() {
+	config := obcache.NewRedisConfig("localhost:6379").
+		WithDefaultTTL(10 * time.Minute)
+	config.Redis.KeyPrefix = "msgpack-example:"
+	config.Redis.Codec = MsgpackCodec{}
+
+	cache, err := obcache.New(config)
+	if err != nil {
+		log.Fatalf("Failed to create cache (is Redis running?): %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	product := Product{SKU: "A-100", Name: "Widget", Price: 9.99}
+	if err := cache.Set("product:A-100", product, time.Hour); err != nil {
+		log.Fatalf("Failed to cache product: %v", err)
+	}
+
+	// Values decoded into an interface come back as MessagePack maps;
+	// use codec.Gob to get the original Go types back instead
+	if value, found := cache.Get("product:A-100"); found {
+		fmt.Printf("Cached product: %v\n", value)
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/codec"
+)
+
+func TestMsgpackCodecRoundTrip(t *testing.T) {
+	c, err := codec.Lookup("msgpack")
+	if err != nil {
+		t.Fatalf("Expected msgpack codec to be registered: %v", err)
+	}
+
+	data, err := c.Marshal(Product{SKU: "A-100", Name: "Widget", Price: 9.99})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var product Product
+	if err := c.Unmarshal(data, &product); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if product.SKU != "A-100" || product.Price != 9.99 {
+		t.Errorf("Unexpected product: %+v", product)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/codec"
+	"github.com/1mb-dev/obcache-go/v2/pkg/compression"
+	"github.com/1mb-dev/obcache-go/v2/pkg/obcache"
+)
+
+func TestProtobufCodecRoundTrip(t *testing.T) {
+	c, err := codec.Lookup("protobuf")
+	if err != nil {
+		t.Fatalf("Expected protobuf codec to be registered: %v", err)
+	}
+
+	original := wrapperspb.String("widget")
+	data, err := c.Marshal(original)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var decoded any
+	if err := c.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal into *any failed: %v", err)
+	}
+	if !proto.Equal(decoded.(proto.Message), original) {
+		t.Errorf("Expected %v, got %v", original, decoded)
+	}
+
+	var message wrapperspb.StringValue
+	if err := c.Unmarshal(data, &message); err != nil || message.GetValue() != "widget" {
+		t.Errorf("Expected to decode into the message, got %v (err=%v)", message.GetValue(), err)
+	}
+
+	var wrongType *timestamppb.Timestamp
+	if err := c.Unmarshal(data, &wrongType); err == nil {
+		t.Error("Expected decoding into another message type to fail")
+	}
+	if _, err := c.Marshal("not a message"); err == nil {
+		t.Error("Expected marshaling a non-message to fail")
+	}
+}
+
+func TestProtobufCodecWithCache(t *testing.T) {
+	cache, err := obcache.New(obcache.NewDefaultConfig().
+		WithCompression(compression.NewDefaultConfig().WithEnabled(true).WithMinSize(0).WithCodec(ProtobufCodec{})))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	now := timestamppb.New(time.Unix(1700000000, 0))
+	_ = cache.Set("ts", now, time.Hour)
+
+	value, found := cache.Get("ts")
+	if !found || !proto.Equal(value.(proto.Message), now) {
+		t.Fatalf("Expected the timestamp message back, got %T %v", value, value)
+	}
+	if typed, found := obcache.NewTyped[*timestamppb.Timestamp](cache).Get("ts"); !found || typed.GetSeconds() != 1700000000 {
+		t.Errorf("Expected a typed read to decode the message, got %v (found=%v)", typed, found)
+	}
+	if _, info, _ := cache.GetEntry("ts"); info.Codec != "protobuf" {
+		t.Errorf("Expected the entry to record the protobuf codec, got %q", info.Codec)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/obcache"
+)
+
+// newMux serves the application under / and cache statistics under /admin/cache
+func newMux(cache *obcache.Cache) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/admin/cache", obcache.StatsHandler(cache))
+	mux.HandleFunc("/greet", func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		greeting, found := cache.Get("greeting:" + name)
+		if !found {
+			greeting = fmt.Sprintf("Hello, %s!", name)
+			_ = cache.Set("greeting:"+name, greeting, time.Minute)
+		}
+		_, _ = fmt.Fprintln(w, greeting)
+	})
+	return mux
+}
+
+func main() {
+	cache, err := obcache.New(obcache.NewDefaultConfig().WithMaxEntries(1000))
+	if err != nil {
+		log.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	server := &http.Server{
+		Addr:              ":8080",
+		Handler:           newMux(cache),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// Try: curl 'localhost:8080/greet?name=ada' then curl 'localhost:8080/admin/cache?top=10'
+	fmt.Println("Listening on :8080")
+	log.Fatal(server.ListenAndServe())
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/obcache"
+)
+
+func TestStatsEndpoint(t *testing.T) {
+	cache, err := obcache.New(obcache.NewDefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	server := httptest.NewServer(newMux(cache))
+	defer server.Close()
+
+	for range 3 {
+		resp, err := http.Get(server.URL + "/greet?name=ada")
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		_ = resp.Body.Close()
+	}
+
+	resp, err := http.Get(server.URL + "/admin/cache?top=5")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var document obcache.StatsDocument
+	if err := json.NewDecoder(resp.Body).Decode(&document); err != nil {
+		t.Fatalf("Failed to decode stats: %v", err)
+	}
+	if document.Hits != 2 || document.Misses != 1 || document.KeyCount != 1 {
+		t.Errorf("Expected 2 hits, 1 miss and 1 key, got %+v", document)
+	}
+	if document.TopKeys == nil || len(document.TopKeys.BySize) != 1 || document.TopKeys.BySize[0].Key != "greeting:ada" {
+		t.Errorf("Expected the greeting among the top keys, got %+v", document.TopKeys)
+	}
+}
@@ -0,0 +1,170 @@
+// Package envelope encodes cache entries as the JSON documents stored by the
+// Redis, Bolt, etcd and blob stores, sent between distributed peers and written
+// to snapshots.
+//
+// Values encoded with codec.JSON are stored inline in "value"; values from other
+// codecs are stored in "data" along with the codec name. []byte and
+// codec.RawValue values, which include values serialized by the compression
+// step, skip the codec and are stored in "data" with "raw" set. Documents written
+// by earlier versions of the non-Redis stores, which kept raw bytes in "value"
+// and named the compression codec in "codec", are still read.
+package envelope
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/codec"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+)
+
+// Entry is an entry as stored by the serializing stores
+type Entry struct {
+	Value      json.RawMessage `json:"value,omitempty"`
+	Data       []byte          `json:"data,omitempty"`
+	Codec      string          `json:"codec,omitempty"`
+	Raw        bool            `json:"raw,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	ExpiresAt  *time.Time      `json:"expires_at,omitempty"`
+	LastAccess time.Time       `json:"last_access"`
+	ValueSize  int             `json:"value_size,omitempty"`
+	Version    int64           `json:"version,omitempty"`
+
+	// Compression metadata of entries written with compression enabled; ValueCodec
+	// is the codec the compression step serialized the value with
+	IsCompressed   bool   `json:"compressed,omitempty"`
+	CompressorName string `json:"compressor,omitempty"`
+	ValueCodec     string `json:"value_codec,omitempty"`
+	DictionaryID   uint32 `json:"dictionary,omitempty"`
+	OriginalSize   int    `json:"original_size,omitempty"`
+	CompressedSize int    `json:"compressed_size,omitempty"`
+}
+
+// New converts e to its stored form, encoding its value with c
+// A nil c selects codec.JSON
+func New(e *entry.Entry, c codec.Codec) (*Entry, error) {
+	serialized := &Entry{
+		CreatedAt:      e.CreatedAt,
+		ExpiresAt:      e.Expiry(),
+		LastAccess:     e.LastAccess(),
+		ValueSize:      e.ValueSize,
+		Version:        e.Version,
+		IsCompressed:   e.IsCompressed,
+		CompressorName: e.CompressorName,
+		ValueCodec:     e.CodecName,
+		DictionaryID:   e.DictionaryID,
+		OriginalSize:   e.OriginalSize,
+		CompressedSize: e.CompressedSize,
+	}
+
+	if data, ok := codec.RawBytes(e.Value); ok {
+		serialized.Data = data
+		serialized.Raw = true
+		return serialized, nil
+	}
+
+	c = codec.OrDefault(c)
+	valueBytes, err := c.Marshal(e.Value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal entry value: %w", err)
+	}
+	if _, ok := c.(codec.JSON); ok {
+		serialized.Value = valueBytes // Inline, as written before codecs were configurable
+	} else {
+		serialized.Data = valueBytes
+		serialized.Codec = c.Name()
+	}
+	return serialized, nil
+}
+
+// Entry converts the stored form back to an entry, decoding the value with c if
+// it was written with c's name and with the registered codec of that name
+// otherwise. Returns an error wrapping codec.ErrUnknownCodec if there is none
+func (s *Entry) Entry(c codec.Codec) (*entry.Entry, error) {
+	value, err := s.decodeValue(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entry.Entry{
+		Value:          value,
+		ExpiresAt:      s.ExpiresAt,
+		CreatedAt:      s.CreatedAt,
+		AccessedAt:     s.LastAccess,
+		ValueSize:      s.ValueSize,
+		Version:        s.Version,
+		IsCompressed:   s.IsCompressed,
+		CompressorName: s.CompressorName,
+		CodecName:      s.compressionCodec(),
+		DictionaryID:   s.DictionaryID,
+		OriginalSize:   s.OriginalSize,
+		CompressedSize: s.CompressedSize,
+	}, nil
+}
+
+// decodeValue decodes the value with the codec named in the stored form
+func (s *Entry) decodeValue(c codec.Codec) (any, error) {
+	var value any
+	switch {
+	case s.Raw || s.IsCompressed:
+		if s.Data == nil && len(s.Value) > 0 {
+			// Earlier layout: the bytes are a base64 string in Value
+			var data []byte
+			if err := json.Unmarshal(s.Value, &data); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal raw entry value: %w", err)
+			}
+			return data, nil
+		}
+		if s.Data == nil {
+			return []byte{}, nil
+		}
+		return s.Data, nil
+	case s.Codec == "":
+		if err := json.Unmarshal(s.Value, &value); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal entry value: %w", err)
+		}
+		return value, nil
+	}
+
+	if c == nil || c.Name() != s.Codec {
+		var err error
+		if c, err = codec.Lookup(s.Codec); err != nil {
+			return nil, fmt.Errorf("failed to decode entry value: %w", err)
+		}
+	}
+	if err := c.Unmarshal(s.Data, &value); err != nil {
+		return nil, fmt.Errorf("failed to decode %s entry value: %w", s.Codec, err)
+	}
+	return value, nil
+}
+
+// compressionCodec returns the codec the compression step serialized the value
+// with. Raw values never carry a value codec, so a codec name on one comes from
+// the earlier layout, where it named the compression codec
+func (s *Entry) compressionCodec() string {
+	if s.ValueCodec == "" && (s.Raw || s.IsCompressed) {
+		return s.Codec
+	}
+	return s.ValueCodec
+}
+
+// Marshal encodes e as a JSON document, encoding its value with c
+// A nil c selects codec.JSON
+func Marshal(e *entry.Entry, c codec.Codec) ([]byte, error) {
+	serialized, err := New(e, c)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(serialized)
+}
+
+// Unmarshal decodes a document written by Marshal, preferring c for values
+// written with its name
+func Unmarshal(data []byte, c codec.Codec) (*entry.Entry, error) {
+	var serialized Entry
+	if err := json.Unmarshal(data, &serialized); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal serialized entry: %w", err)
+	}
+	return serialized.Entry(c)
+}
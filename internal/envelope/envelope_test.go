@@ -0,0 +1,71 @@
+package envelope
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/codec"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+)
+
+func TestRoundTrip(t *testing.T) {
+	original := entry.New(map[string]any{"name": "alice"}, time.Hour)
+	original.Version = 3
+	original.ValueSize = 12
+
+	data, err := Marshal(original, nil)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if !strings.Contains(string(data), `"value":{"name":"alice"}`) {
+		t.Errorf("Expected JSON values inline, got %s", data)
+	}
+
+	e, err := Unmarshal(data, nil)
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if e.Value.(map[string]any)["name"] != "alice" || e.Version != 3 || e.ValueSize != 12 {
+		t.Errorf("Unexpected entry: %+v", e)
+	}
+	if !e.HasExpiry() || !e.Expiry().Equal(*original.Expiry()) {
+		t.Errorf("Expected expiry %v, got %v", original.Expiry(), e.Expiry())
+	}
+
+	// Gob keeps the concrete type; bytes skip the codec
+	data, _ = Marshal(entry.NewWithoutTTL(42), codec.Gob{})
+	if e, err := Unmarshal(data, nil); err != nil || e.Value != 42 {
+		t.Errorf("Expected the gob codec to keep an int, got %#v (%v)", e, err)
+	}
+	data, _ = Marshal(entry.NewWithoutTTL([]byte{1, 2, 3}), codec.Gob{})
+	if e, err := Unmarshal(data, codec.Gob{}); err != nil || !slices.Equal(e.Value.([]byte), []byte{1, 2, 3}) {
+		t.Errorf("Expected raw bytes back, got %#v (%v)", e, err)
+	}
+}
+
+func TestUnmarshalEarlierLayout(t *testing.T) {
+	// Raw bytes as a base64 value with the compression codec in "codec"
+	const stored = `{"value":"AQID","raw":true,"compressed":true,"compressor":"gzip","codec":"gob","created_at":"2024-01-01T00:00:00Z","last_access":"2024-01-01T00:00:00Z"}`
+
+	e, err := Unmarshal([]byte(stored), nil)
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if !slices.Equal(e.Value.([]byte), []byte{1, 2, 3}) {
+		t.Errorf("Expected the raw bytes, got %v", e.Value)
+	}
+	if !e.IsCompressed || e.CompressorName != "gzip" || e.CodecName != "gob" {
+		t.Errorf("Expected the compression metadata, got %+v", e)
+	}
+}
+
+func TestUnmarshalUnknownCodec(t *testing.T) {
+	const stored = `{"data":"AQID","codec":"protobuf","created_at":"2024-01-01T00:00:00Z","last_access":"2024-01-01T00:00:00Z"}`
+
+	if _, err := Unmarshal([]byte(stored), nil); !errors.Is(err, codec.ErrUnknownCodec) {
+		t.Errorf("Expected ErrUnknownCodec, got %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package eviction
+
+import (
+	"sync"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+)
+
+// MultiEvictor is implemented by strategies whose inserts may evict several entries
+type MultiEvictor interface {
+	// AddEvicting adds an entry and returns every entry evicted to make room for it
+	AddEvicting(key string, entry *entry.Entry) []Evicted
+}
+
+// batchStrategy frees several entries at once when the base strategy is full
+type batchStrategy struct {
+	base      Strategy
+	batchSize int
+	mutex     sync.Mutex
+}
+
+// NewBatchStrategy wraps base so that hitting capacity evicts batchSize entries,
+// in the base policy's order, instead of one
+func NewBatchStrategy(base Strategy, batchSize int) Strategy {
+	return &batchStrategy{
+		base:      base,
+		batchSize: max(batchSize, 1),
+	}
+}
+
+// Add adds an entry, evicting a batch if capacity is exceeded
+// Only the first eviction is reported; use AddEvicting to observe all of them
+func (b *batchStrategy) Add(key string, entry *entry.Entry) (string, *entry.Entry, bool) {
+	evicted := b.AddEvicting(key, entry)
+	if len(evicted) == 0 {
+		return "", nil, false
+	}
+	return evicted[0].Key, evicted[0].Entry, true
+}
+
+// AddEvicting adds an entry and returns the whole batch evicted for it
+func (b *batchStrategy) AddEvicting(key string, entry *entry.Entry) []Evicted {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	var evicted []Evicted
+	if !b.base.Contains(key) && b.base.Len() >= b.base.Capacity() {
+		for _, victim := range b.base.Victims(b.batchSize) {
+			victimEntry, _ := b.base.Peek(victim)
+			if b.base.Remove(victim) {
+				evicted = append(evicted, Evicted{Key: victim, Entry: victimEntry})
+			}
+		}
+	}
+
+	// The batch normally made room; report anything the base still evicts
+	if evictKey, evictedEntry, ok := b.base.Add(key, entry); ok {
+		evicted = append(evicted, Evicted{Key: evictKey, Entry: evictedEntry})
+	}
+	return evicted
+}
+
+// Get retrieves an entry and lets the base policy record the access
+func (b *batchStrategy) Get(key string) (*entry.Entry, bool) {
+	return b.base.Get(key)
+}
+
+// Remove removes an entry from the tracker
+func (b *batchStrategy) Remove(key string) bool {
+	return b.base.Remove(key)
+}
+
+// Contains checks if a key exists in the tracker
+func (b *batchStrategy) Contains(key string) bool {
+	return b.base.Contains(key)
+}
+
+// Keys returns all keys currently tracked
+func (b *batchStrategy) Keys() []string {
+	return b.base.Keys()
+}
+
+// Len returns the number of entries currently tracked
+func (b *batchStrategy) Len() int {
+	return b.base.Len()
+}
+
+// Clear removes all entries from the tracker
+func (b *batchStrategy) Clear() {
+	b.base.Clear()
+}
+
+// Capacity returns the maximum number of entries this strategy can hold
+func (b *batchStrategy) Capacity() int {
+	return b.base.Capacity()
+}
+
+// Peek retrieves an entry without updating its position in the eviction order
+func (b *batchStrategy) Peek(key string) (*entry.Entry, bool) {
+	return b.base.Peek(key)
+}
+
+// Victims returns up to n keys in the base policy's eviction order
+func (b *batchStrategy) Victims(n int) []string {
+	return b.base.Victims(n)
+}
+
+// Base returns the wrapped strategy
+func (b *batchStrategy) Base() Strategy {
+	return b.base
+}
+
+// Reindex forwards deadline changes to an expiry-aware base strategy
+func (b *batchStrategy) Reindex(key string) {
+	if tracker, ok := b.base.(ExpiryTracker); ok {
+		tracker.Reindex(key)
+	}
+}
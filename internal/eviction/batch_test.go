@@ -0,0 +1,64 @@
+package eviction
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBatchStrategy(t *testing.T) {
+	strategy := NewStrategy(Config{Type: LRU, Capacity: 10, EvictionBatchSize: 4})
+	multi, ok := strategy.(MultiEvictor)
+	if !ok {
+		t.Fatal("Expected batch strategy to implement MultiEvictor")
+	}
+
+	for i := 0; i < 10; i++ {
+		if evicted := multi.AddEvicting(fmt.Sprintf("key%d", i), createTestEntry("value")); len(evicted) != 0 {
+			t.Fatalf("Expected no eviction below capacity, got %v", evicted)
+		}
+	}
+	strategy.Get("key0") // key0 becomes most recently used
+
+	evicted := multi.AddEvicting("new", createTestEntry("new"))
+	expected := []string{"key1", "key2", "key3", "key4"}
+	if len(evicted) != len(expected) {
+		t.Fatalf("Expected a batch of %d evictions, got %v", len(expected), evicted)
+	}
+	for i, key := range expected {
+		if evicted[i].Key != key || evicted[i].Entry == nil || evicted[i].Entry.Value != "value" {
+			t.Errorf("Expected eviction %d to be %s with its entry, got %+v", i, key, evicted[i])
+		}
+	}
+	if strategy.Len() != 7 {
+		t.Errorf("Expected 7 entries after batch eviction, got %d", strategy.Len())
+	}
+
+	// The freed room absorbs the next inserts without further evictions
+	for i := 0; i < 3; i++ {
+		if evicted := multi.AddEvicting(fmt.Sprintf("more%d", i), createTestEntry("value")); len(evicted) != 0 {
+			t.Fatalf("Expected no eviction while batch headroom remains, got %v", evicted)
+		}
+	}
+
+	// Updating an existing key never evicts
+	if evicted := multi.AddEvicting("new", createTestEntry("updated")); len(evicted) != 0 {
+		t.Fatalf("Expected no eviction when updating, got %v", evicted)
+	}
+}
+
+func TestBatchWithWeight(t *testing.T) {
+	strategy := NewStrategy(Config{Type: FIFO, Capacity: 4, EvictionBatchSize: 2, MaxWeight: 1000})
+	weighted := strategy.(WeightedStrategy)
+
+	for _, key := range []string{"a", "b", "c", "d"} {
+		weighted.AddWeighted(key, createTestEntry(key), 10)
+	}
+
+	evicted := weighted.AddWeighted("e", createTestEntry("e"), 10)
+	if len(evicted) != 2 || evicted[0].Key != "a" || evicted[1].Key != "b" {
+		t.Fatalf("Expected a and b evicted as a batch, got %v", evicted)
+	}
+	if weighted.Weight() != 30 {
+		t.Errorf("Expected weight 30 after batch eviction, got %d", weighted.Weight())
+	}
+}
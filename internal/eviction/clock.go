@@ -0,0 +1,232 @@
+package eviction
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+)
+
+// clockItem is a tracked key, its entry and its reference bit
+type clockItem struct {
+	key        string
+	entry      *entry.Entry
+	slot       int
+	referenced atomic.Bool
+}
+
+// ClockStrategy implements the CLOCK (second-chance) eviction strategy, an
+// approximation of LRU. Entries sit in a circular buffer with a reference bit
+// that Get sets atomically under a read lock, so concurrent reads never
+// contend on a write lock. On eviction the clock hand sweeps the buffer,
+// clearing set bits and evicting the first entry whose bit was already clear.
+type ClockStrategy struct {
+	index    map[string]*clockItem
+	ring     []*clockItem // nil slots are free
+	free     []int        // Indexes of free slots in ring
+	hand     int
+	capacity int
+	mutex    sync.RWMutex
+	instr    *Instrumentation
+}
+
+// NewClockStrategy creates a new CLOCK eviction strategy
+func NewClockStrategy(capacity int) *ClockStrategy {
+	return &ClockStrategy{
+		index:    make(map[string]*clockItem),
+		ring:     make([]*clockItem, 0, capacity),
+		capacity: capacity,
+	}
+}
+
+// Add adds an entry to the CLOCK tracker
+func (c *ClockStrategy) Add(key string, entry *entry.Entry) (string, *entry.Entry, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// If key already exists, update it and count the write as a reference
+	if item, exists := c.index[key]; exists {
+		item.entry = entry
+		item.referenced.Store(true)
+		return "", nil, false
+	}
+
+	item := &clockItem{key: key, entry: entry}
+	c.index[key] = item
+	c.instr.admit(key)
+
+	// Reuse a slot freed by Remove
+	if n := len(c.free); n > 0 {
+		item.slot = c.free[n-1]
+		c.free = c.free[:n-1]
+		c.ring[item.slot] = item
+		return "", nil, false
+	}
+
+	// If we're at capacity, replace the entry under the clock hand
+	if len(c.ring) >= c.capacity && c.capacity > 0 {
+		start := c.instr.selectStart()
+		victim := c.ring[c.advance()]
+		c.instr.selectDone(start)
+		delete(c.index, victim.key)
+
+		item.slot = victim.slot
+		c.ring[item.slot] = item
+		c.hand = (c.hand + 1) % len(c.ring)
+		return victim.key, victim.entry, true
+	}
+
+	// Add new entry
+	item.slot = len(c.ring)
+	c.ring = append(c.ring, item)
+	return "", nil, false
+}
+
+// Get retrieves an entry and sets its reference bit
+func (c *ClockStrategy) Get(key string) (*entry.Entry, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	item, found := c.index[key]
+	if !found {
+		return nil, false
+	}
+	// Skip the store when the bit is already set to keep the cache line shared
+	if !item.referenced.Load() && !item.referenced.Swap(true) {
+		c.instr.promote(key)
+	}
+	return item.entry, true
+}
+
+// SetInstrumentation installs instrumentation callbacks
+func (c *ClockStrategy) SetInstrumentation(instr *Instrumentation) {
+	c.instr = instr
+}
+
+// Remove removes an entry from the CLOCK tracker
+func (c *ClockStrategy) Remove(key string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	item, exists := c.index[key]
+	if !exists {
+		return false
+	}
+	delete(c.index, key)
+	c.ring[item.slot] = nil
+	c.free = append(c.free, item.slot)
+	return true
+}
+
+// Contains checks if a key exists in the CLOCK tracker
+func (c *ClockStrategy) Contains(key string) bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	_, exists := c.index[key]
+	return exists
+}
+
+// Keys returns all keys currently tracked by the CLOCK strategy
+func (c *ClockStrategy) Keys() []string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	keys := make([]string, 0, len(c.index))
+	for _, item := range c.ring {
+		if item != nil {
+			keys = append(keys, item.key)
+		}
+	}
+	return keys
+}
+
+// Len returns the number of entries currently tracked
+func (c *ClockStrategy) Len() int {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return len(c.index)
+}
+
+// Clear removes all entries from the CLOCK tracker
+func (c *ClockStrategy) Clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.index = make(map[string]*clockItem)
+	c.ring = make([]*clockItem, 0, c.capacity)
+	c.free = nil
+	c.hand = 0
+}
+
+// Capacity returns the maximum number of entries this strategy can hold
+func (c *ClockStrategy) Capacity() int {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.capacity
+}
+
+// Resize sets the maximum number of entries and compacts the buffer, keeping
+// the entries in clock order starting at the hand
+func (c *ClockStrategy) Resize(capacity int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	ring := make([]*clockItem, 0, max(capacity, len(c.index)))
+	for i := range c.ring {
+		if item := c.ring[(c.hand+i)%len(c.ring)]; item != nil {
+			item.slot = len(ring)
+			ring = append(ring, item)
+		}
+	}
+
+	c.ring = ring
+	c.free = nil
+	c.hand = 0
+	c.capacity = capacity
+}
+
+// Peek retrieves an entry without setting its reference bit
+func (c *ClockStrategy) Peek(key string) (*entry.Entry, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	item, found := c.index[key]
+	if !found {
+		return nil, false
+	}
+	return item.entry, true
+}
+
+// Victims returns up to n keys in the order successive sweeps would evict
+// them: unreferenced entries from the hand onwards, followed by referenced
+// entries, which lose their second chance on the first pass
+func (c *ClockStrategy) Victims(n int) []string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	n = min(max(n, 0), len(c.index))
+	victims := make([]string, 0, n)
+	for _, referenced := range []bool{false, true} {
+		for i := 0; i < len(c.ring) && len(victims) < n; i++ {
+			item := c.ring[(c.hand+i)%len(c.ring)]
+			if item != nil && item.referenced.Load() == referenced {
+				victims = append(victims, item.key)
+			}
+		}
+	}
+	return victims
+}
+
+// advance moves the hand to the next entry without a reference bit, clearing
+// the bits it passes, and returns its slot (internal method, assumes lock is held)
+func (c *ClockStrategy) advance() int {
+	for {
+		if item := c.ring[c.hand]; item != nil && !item.referenced.Swap(false) {
+			return c.hand
+		}
+		c.hand = (c.hand + 1) % len(c.ring)
+	}
+}
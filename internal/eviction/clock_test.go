@@ -0,0 +1,103 @@
+package eviction
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestClockStrategy(t *testing.T) {
+	strategy := NewClockStrategy(3)
+	for _, key := range []string{"key1", "key2", "key3"} {
+		strategy.Add(key, createTestEntry(key))
+	}
+
+	t.Run("SecondChance", func(t *testing.T) {
+		strategy.Get("key1")
+
+		// key1 is referenced, so the hand passes it and evicts key2
+		evictKey, _, evicted := strategy.Add("key4", createTestEntry("key4"))
+		if !evicted || evictKey != "key2" {
+			t.Fatalf("Expected key2 to be evicted, got %q (evicted=%v)", evictKey, evicted)
+		}
+
+		// key1 used its second chance on the previous sweep
+		evictKey, _, evicted = strategy.Add("key5", createTestEntry("key5"))
+		if !evicted || evictKey != "key3" {
+			t.Fatalf("Expected key3 to be evicted, got %q (evicted=%v)", evictKey, evicted)
+		}
+		evictKey, _, evicted = strategy.Add("key6", createTestEntry("key6"))
+		if !evicted || evictKey != "key1" {
+			t.Fatalf("Expected key1 to be evicted once its bit was cleared, got %q (evicted=%v)", evictKey, evicted)
+		}
+	})
+
+	t.Run("PeekDoesNotReference", func(t *testing.T) {
+		strategy.Peek("key4")
+		if victims := strategy.Victims(1); len(victims) != 1 || victims[0] != "key4" {
+			t.Errorf("Expected key4 to remain the next victim, got %v", victims)
+		}
+	})
+
+	t.Run("RemoveFreesSlot", func(t *testing.T) {
+		if !strategy.Remove("key5") {
+			t.Fatal("Expected key5 to be removed")
+		}
+		if _, _, evicted := strategy.Add("key7", createTestEntry("key7")); evicted {
+			t.Error("Expected freed slot to be reused without eviction")
+		}
+		if strategy.Len() != 3 || !strategy.Contains("key7") {
+			t.Errorf("Expected key7 tracked at capacity, len=%d", strategy.Len())
+		}
+	})
+}
+
+func TestClockVictimsMatchEvictionOrder(t *testing.T) {
+	strategy := NewClockStrategy(4)
+	for _, key := range []string{"key1", "key2", "key3", "key4"} {
+		strategy.Add(key, createTestEntry(key))
+	}
+	strategy.Get("key1")
+	strategy.Get("key3")
+
+	victims := strategy.Victims(4)
+	expected := []string{"key2", "key4", "key1", "key3"}
+	for i, key := range expected {
+		if victims[i] != key {
+			t.Fatalf("Expected victims %v, got %v", expected, victims)
+		}
+	}
+
+	for _, key := range expected {
+		strategy.Remove(key)
+		if _, _, evicted := strategy.Add(key, createTestEntry(key)); evicted {
+			t.Fatalf("Expected re-adding %s into its free slot not to evict", key)
+		}
+	}
+}
+
+// benchmarkStrategyGetHitRate measures parallel Get throughput at a 90% hit rate
+func benchmarkStrategyGetHitRate(b *testing.B, strategy Strategy) {
+	keys := make([]string, strategy.Capacity()*10/9)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key%d", i)
+		if i < strategy.Capacity() {
+			strategy.Add(keys[i], createTestEntry("value"))
+		}
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			strategy.Get(keys[i%len(keys)])
+			i++
+		}
+	})
+}
+
+func BenchmarkClockGet(b *testing.B) { benchmarkStrategyGet(b, NewClockStrategy(10000)) }
+func BenchmarkClockAdd(b *testing.B) { benchmarkStrategyAdd(b, NewClockStrategy(10000)) }
+func BenchmarkClockGetHitRate90(b *testing.B) {
+	benchmarkStrategyGetHitRate(b, NewClockStrategy(10000))
+}
+func BenchmarkLRUGetHitRate90(b *testing.B) { benchmarkStrategyGetHitRate(b, NewLRUStrategy(10000)) }
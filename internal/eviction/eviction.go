@@ -1,7 +1,9 @@
 package eviction
 
 import (
-	"github.com/1mb-dev/obcache-go/v2/internal/entry"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
 )
 
 // Strategy defines the interface for eviction strategies
@@ -33,6 +35,16 @@ type Strategy interface {
 
 	// Peek retrieves an entry without updating its position in the eviction order
 	Peek(key string) (*entry.Entry, bool)
+
+	// Victims returns up to n keys in the order Add would evict them, without removing them
+	Victims(n int) []string
+}
+
+// Resizer is implemented by strategies whose capacity can change at runtime
+type Resizer interface {
+	// Resize sets the maximum number of entries. Shrinking does not evict:
+	// callers remove the overflow first, in Victims order
+	Resize(capacity int)
 }
 
 // EvictionType represents the type of eviction strategy
@@ -47,25 +59,89 @@ const (
 
 	// FIFO - First In, First Out eviction
 	FIFO EvictionType = "fifo"
+
+	// TinyLFU - W-TinyLFU eviction with frequency sketch admission
+	TinyLFU EvictionType = "tinylfu"
+
+	// Random - uniformly random eviction
+	Random EvictionType = "random"
+
+	// TTLFirst - evicts entries closest to expiration first, LRU for entries without TTL
+	TTLFirst EvictionType = "ttlfirst"
+
+	// Clock - CLOCK (second-chance) eviction, an approximate LRU with lock-free reads
+	Clock EvictionType = "clock"
+
+	// GDSF - Greedy-Dual-Size-Frequency eviction, preferring to evict large, rarely used entries
+	GDSF EvictionType = "gdsf"
 )
 
 // Config holds configuration for eviction strategies
 type Config struct {
 	Type     EvictionType
 	Capacity int
+
+	// MaxWeight bounds the total weight of entries when positive
+	MaxWeight int64
+
+	// LFUAgingInterval halves LFU access frequencies once per interval so that
+	// keys which are no longer popular decay. 0 disables aging
+	LFUAgingInterval time.Duration
+
+	// EvictionBatchSize is the number of entries freed at once when capacity
+	// is exceeded. Values <= 1 evict one entry per insert
+	EvictionBatchSize int
+
+	// Instrumentation receives internal strategy events when non-nil
+	Instrumentation *Instrumentation
 }
 
 // NewStrategy creates a new eviction strategy based on the given config
 func NewStrategy(config Config) Strategy {
+	return WithLimits(newBaseStrategy(config), config)
+}
+
+// WithLimits wraps strategy with pinning support and the batch eviction and
+// weight limits in config. An EvictionBatchSize above 1 adds a batch wrapper
+// and a positive MaxWeight adds a WeightedStrategy on top
+func WithLimits(strategy Strategy, config Config) Strategy {
+	strategy = NewPinnedStrategy(strategy)
+	if config.EvictionBatchSize > 1 {
+		strategy = NewBatchStrategy(strategy, config.EvictionBatchSize)
+	}
+	if config.MaxWeight > 0 {
+		strategy = NewWeightedStrategy(strategy, config.MaxWeight)
+	}
+	return strategy
+}
+
+// newBaseStrategy creates the count-bounded strategy for config.Type
+func newBaseStrategy(config Config) Strategy {
+	var strategy Strategy
 	switch config.Type {
 	case LRU:
-		return NewLRUStrategy(config.Capacity)
+		strategy = NewLRUStrategy(config.Capacity)
 	case LFU:
-		return NewLFUStrategy(config.Capacity)
+		strategy = NewLFUStrategyWithAging(config.Capacity, config.LFUAgingInterval)
 	case FIFO:
-		return NewFIFOStrategy(config.Capacity)
+		strategy = NewFIFOStrategy(config.Capacity)
+	case TinyLFU:
+		strategy = NewTinyLFUStrategy(config.Capacity)
+	case Random:
+		strategy = NewRandomStrategy(config.Capacity)
+	case TTLFirst:
+		strategy = NewTTLFirstStrategy(NewLRUStrategy(config.Capacity))
+	case Clock:
+		strategy = NewClockStrategy(config.Capacity)
+	case GDSF:
+		strategy = NewGDSFStrategy(config.Capacity)
 	default:
 		// Default to LRU
-		return NewLRUStrategy(config.Capacity)
+		strategy = NewLRUStrategy(config.Capacity)
+	}
+
+	if instrumented, ok := strategy.(Instrumented); ok && config.Instrumentation != nil {
+		instrumented.SetInstrumentation(config.Instrumentation)
 	}
+	return strategy
 }
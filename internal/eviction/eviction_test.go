@@ -1,9 +1,13 @@
 package eviction
 
 import (
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
-	"github.com/1mb-dev/obcache-go/v2/internal/entry"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
 )
 
 // Helper function to create a test entry
@@ -203,6 +207,9 @@ func TestStrategyFactory(t *testing.T) {
 		{"LRU", LRU, 10},
 		{"LFU", LFU, 10},
 		{"FIFO", FIFO, 10},
+		{"TinyLFU", TinyLFU, 10},
+		{"Random", Random, 10},
+		{"TTLFirst", TTLFirst, 10},
 	}
 
 	for _, tc := range testCases {
@@ -265,6 +272,9 @@ func TestCapacityLimits(t *testing.T) {
 		{"LRU", NewLRUStrategy(1)},
 		{"LFU", NewLFUStrategy(1)},
 		{"FIFO", NewFIFOStrategy(1)},
+		{"TinyLFU", NewTinyLFUStrategy(1)},
+		{"Random", NewRandomStrategy(1)},
+		{"TTLFirst", NewTTLFirstStrategy(NewLRUStrategy(1))},
 	}
 
 	for _, tc := range testCases {
@@ -291,3 +301,209 @@ func TestCapacityLimits(t *testing.T) {
 		})
 	}
 }
+
+func TestVictimsMatchEvictionOrder(t *testing.T) {
+	testCases := []struct {
+		name     string
+		strategy Strategy
+		touch    func(s Strategy)
+		expected []string
+	}{
+		{
+			name:     "LRU",
+			strategy: NewLRUStrategy(3),
+			touch:    func(s Strategy) { s.Get("key1") },
+			expected: []string{"key2", "key3"},
+		},
+		{
+			name:     "LFU",
+			strategy: NewLFUStrategy(3),
+			touch: func(s Strategy) {
+				s.Get("key1")
+				s.Get("key1")
+				s.Get("key3")
+			},
+			expected: []string{"key2", "key3"},
+		},
+		{
+			name:     "FIFO",
+			strategy: NewFIFOStrategy(3),
+			touch:    func(s Strategy) { s.Get("key1") },
+			expected: []string{"key1", "key2"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, key := range []string{"key1", "key2", "key3"} {
+				tc.strategy.Add(key, createTestEntry(key))
+			}
+			tc.touch(tc.strategy)
+
+			victims := tc.strategy.Victims(2)
+			if len(victims) != len(tc.expected) {
+				t.Fatalf("Expected %d victims, got %v", len(tc.expected), victims)
+			}
+			for i, key := range tc.expected {
+				if victims[i] != key {
+					t.Errorf("Expected victim %d to be %s, got %s", i, key, victims[i])
+				}
+			}
+
+			// Victims must not remove anything
+			if tc.strategy.Len() != 3 {
+				t.Errorf("Expected length 3 after Victims, got %d", tc.strategy.Len())
+			}
+
+			// The first victim is what Add would evict
+			evictedKey, _, evicted := tc.strategy.Add("key4", createTestEntry("key4"))
+			if !evicted || evictedKey != tc.expected[0] {
+				t.Errorf("Expected Add to evict %s, got %q (evicted=%v)", tc.expected[0], evictedKey, evicted)
+			}
+
+			if all := tc.strategy.Victims(10); len(all) != 3 {
+				t.Errorf("Expected Victims to cap at store size, got %v", all)
+			}
+		})
+	}
+}
+
+func TestLFUAging(t *testing.T) {
+	clock := time.Now()
+	strategy := NewLFUStrategyWithAging(2, time.Minute)
+	strategy.now = func() time.Time { return clock }
+
+	strategy.Add("oldHot", createTestEntry("value"))
+	for i := 0; i < 100; i++ {
+		strategy.Get("oldHot")
+	}
+	strategy.Add("newHot", createTestEntry("value"))
+
+	// Without decay the old heavy hitter always wins
+	if victims := strategy.Victims(1); victims[0] != "newHot" {
+		t.Fatalf("Expected newHot to be the victim before aging, got %v", victims)
+	}
+
+	// Ten intervals later the old counter has halved ten times
+	clock = clock.Add(10 * time.Minute)
+	for i := 0; i < 3; i++ {
+		strategy.Get("newHot")
+	}
+
+	evictKey, _, evicted := strategy.Add("another", createTestEntry("value"))
+	if !evicted || evictKey != "oldHot" {
+		t.Fatalf("Expected decayed oldHot to be evicted, got %q", evictKey)
+	}
+	if !strategy.Contains("newHot") {
+		t.Error("Expected newly hot key to survive")
+	}
+}
+
+func TestLFUWithoutAging(t *testing.T) {
+	clock := time.Now()
+	strategy := NewLFUStrategy(2)
+	strategy.now = func() time.Time { return clock }
+
+	strategy.Add("oldHot", createTestEntry("value"))
+	for i := 0; i < 10; i++ {
+		strategy.Get("oldHot")
+	}
+	strategy.Add("other", createTestEntry("value"))
+
+	clock = clock.Add(24 * time.Hour)
+	evictKey, _, _ := strategy.Add("another", createTestEntry("value"))
+	if evictKey != "other" {
+		t.Fatalf("Expected counters not to decay without aging, got %q evicted", evictKey)
+	}
+}
+
+func TestResize(t *testing.T) {
+	types := []EvictionType{LRU, LFU, FIFO, TinyLFU, Random, TTLFirst, Clock, GDSF}
+
+	for _, evictionType := range types {
+		t.Run(string(evictionType), func(t *testing.T) {
+			strategy := newBaseStrategy(Config{Type: evictionType, Capacity: 200})
+			resizer, ok := strategy.(Resizer)
+			if !ok {
+				t.Fatalf("Expected %s strategy to implement Resizer", evictionType)
+			}
+
+			for i := range 200 {
+				key := fmt.Sprintf("key%d", i)
+				strategy.Add(key, createTestEntry(key))
+			}
+
+			// Shrink: callers remove the overflow first
+			for _, key := range strategy.Victims(150) {
+				strategy.Remove(key)
+			}
+			resizer.Resize(50)
+			if strategy.Capacity() != 50 || strategy.Len() != 50 {
+				t.Fatalf("Expected capacity and length 50, got %d and %d", strategy.Capacity(), strategy.Len())
+			}
+
+			for i := range 100 {
+				key := fmt.Sprintf("new%d", i)
+				strategy.Add(key, createTestEntry(key))
+				if strategy.Len() > 50 {
+					t.Fatalf("Expected length to stay within 50 after shrinking, got %d", strategy.Len())
+				}
+			}
+
+			// Grow: new entries fit without evictions
+			resizer.Resize(80)
+			for i := range 30 {
+				key := fmt.Sprintf("grow%d", i)
+				if _, _, evicted := strategy.Add(key, createTestEntry(key)); evicted {
+					t.Fatalf("Expected no eviction while growing, evicted on %s", key)
+				}
+			}
+			if strategy.Len() != 80 {
+				t.Errorf("Expected length 80 after growing, got %d", strategy.Len())
+			}
+		})
+	}
+}
+
+func TestLRUConcurrentAddReturnsMatchingEviction(t *testing.T) {
+	const (
+		workers   = 8
+		perWorker = 2000
+		capacity  = 100
+	)
+	strategy := NewLRUStrategy(capacity)
+
+	var wg sync.WaitGroup
+	var evictions, mismatches atomic.Int64
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range perWorker {
+				key := fmt.Sprintf("w%d-%d", w, i)
+				evictKey, evictedEntry, evicted := strategy.Add(key, createTestEntry(key))
+				if !evicted {
+					continue
+				}
+				evictions.Add(1)
+				if evictedEntry == nil || evictedEntry.Value != evictKey {
+					mismatches.Add(1)
+				}
+				if w == 0 && i%100 == 0 {
+					strategy.Resize(capacity) // Concurrent resizes must not disturb the handoff
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := mismatches.Load(); n > 0 {
+		t.Errorf("Expected every evicted entry to match its key, got %d mismatches", n)
+	}
+	if n := evictions.Load(); n != workers*perWorker-capacity {
+		t.Errorf("Expected %d evictions, got %d", workers*perWorker-capacity, n)
+	}
+	if strategy.Len() != capacity {
+		t.Errorf("Expected length %d, got %d", capacity, strategy.Len())
+	}
+}
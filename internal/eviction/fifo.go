@@ -3,7 +3,7 @@ package eviction
 import (
 	"sync"
 
-	"github.com/1mb-dev/obcache-go/v2/internal/entry"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
 )
 
 // FIFOStrategy implements the FIFO (First In, First Out) eviction strategy
@@ -12,6 +12,7 @@ type FIFOStrategy struct {
 	order    []string // Keys in insertion order
 	capacity int
 	mutex    sync.RWMutex
+	instr    *Instrumentation
 }
 
 // NewFIFOStrategy creates a new FIFO eviction strategy
@@ -33,10 +34,13 @@ func (f *FIFOStrategy) Add(key string, entry *entry.Entry) (string, *entry.Entry
 		f.data[key] = entry
 		return "", nil, false
 	}
+	f.instr.admit(key)
 
 	// If we're at capacity, evict the first item (oldest)
 	if len(f.data) >= f.capacity && f.capacity > 0 {
+		start := f.instr.selectStart()
 		evictKey := f.order[0]
+		f.instr.selectDone(start)
 		evictedEntry := f.data[evictKey]
 		f.order = f.order[1:] // Remove first element
 		delete(f.data, evictKey)
@@ -61,6 +65,11 @@ func (f *FIFOStrategy) Get(key string) (*entry.Entry, bool) {
 	return entry, found
 }
 
+// SetInstrumentation installs instrumentation callbacks
+func (f *FIFOStrategy) SetInstrumentation(instr *Instrumentation) {
+	f.instr = instr
+}
+
 // Remove removes an entry from the FIFO tracker
 func (f *FIFOStrategy) Remove(key string) bool {
 	f.mutex.Lock()
@@ -120,9 +129,20 @@ func (f *FIFOStrategy) Clear() {
 
 // Capacity returns the maximum number of entries this strategy can hold
 func (f *FIFOStrategy) Capacity() int {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
 	return f.capacity
 }
 
+// Resize sets the maximum number of entries
+func (f *FIFOStrategy) Resize(capacity int) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.capacity = capacity
+}
+
 // Peek retrieves an entry without any side effects
 func (f *FIFOStrategy) Peek(key string) (*entry.Entry, bool) {
 	f.mutex.RLock()
@@ -131,3 +151,14 @@ func (f *FIFOStrategy) Peek(key string) (*entry.Entry, bool) {
 	entry, found := f.data[key]
 	return entry, found
 }
+
+// Victims returns up to n keys starting from the first inserted
+func (f *FIFOStrategy) Victims(n int) []string {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	n = min(max(n, 0), len(f.order))
+	victims := make([]string, n)
+	copy(victims, f.order[:n])
+	return victims
+}
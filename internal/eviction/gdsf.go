@@ -0,0 +1,239 @@
+package eviction
+
+import (
+	"container/heap"
+	"sort"
+	"sync"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+)
+
+// GDSFStrategy implements Greedy-Dual-Size-Frequency eviction
+//
+// Each entry has priority L + frequency/size, where size is the entry's stored
+// size (compressed size, or the size reported by a Sizer) and L is an aging
+// factor raised to the priority of every evicted entry. Large, rarely used
+// entries are evicted first, so one big value goes before many small ones of
+// similar popularity, and L lets entries that are no longer accessed sink
+// below newer ones over time. Entries without a known size count as 1 byte.
+type GDSFStrategy struct {
+	items     map[string]*gdsfItem
+	queue     gdsfHeap
+	inflation float64 // L: priority of the most recently evicted entry
+	capacity  int
+	mutex     sync.Mutex
+	instr     *Instrumentation
+}
+
+// NewGDSFStrategy creates a new GDSF eviction strategy
+func NewGDSFStrategy(capacity int) *GDSFStrategy {
+	return &GDSFStrategy{
+		items:    make(map[string]*gdsfItem),
+		queue:    make(gdsfHeap, 0, capacity),
+		capacity: capacity,
+	}
+}
+
+// Add adds an entry to the GDSF tracker
+func (g *GDSFStrategy) Add(key string, entry *entry.Entry) (string, *entry.Entry, bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	// If key already exists, update its size and count the write as an access
+	if item, exists := g.items[key]; exists {
+		item.entry = entry
+		item.size = max(entry.Size(), 1)
+		item.frequency++
+		g.prioritize(item)
+		return "", nil, false
+	}
+	g.instr.admit(key)
+
+	var victim *gdsfItem
+	if len(g.items) >= g.capacity && g.capacity > 0 {
+		start := g.instr.selectStart()
+		victim = heap.Pop(&g.queue).(*gdsfItem)
+		g.instr.selectDone(start)
+
+		delete(g.items, victim.key)
+		g.inflation = victim.priority
+	}
+
+	item := &gdsfItem{key: key, entry: entry, frequency: 1, size: max(entry.Size(), 1)}
+	item.priority = g.inflation + float64(item.frequency)/float64(item.size)
+	g.items[key] = item
+	heap.Push(&g.queue, item)
+
+	if victim != nil {
+		return victim.key, victim.entry, true
+	}
+	return "", nil, false
+}
+
+// Get retrieves an entry and raises its priority
+func (g *GDSFStrategy) Get(key string) (*entry.Entry, bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	item, found := g.items[key]
+	if !found {
+		return nil, false
+	}
+	item.frequency++
+	g.prioritize(item)
+	g.instr.promote(key)
+	return item.entry, true
+}
+
+// Remove removes an entry from the GDSF tracker
+func (g *GDSFStrategy) Remove(key string) bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	item, exists := g.items[key]
+	if !exists {
+		return false
+	}
+	heap.Remove(&g.queue, item.index)
+	delete(g.items, key)
+	return true
+}
+
+// Contains checks if a key exists in the GDSF tracker
+func (g *GDSFStrategy) Contains(key string) bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	_, exists := g.items[key]
+	return exists
+}
+
+// Keys returns all keys currently tracked by the GDSF strategy
+func (g *GDSFStrategy) Keys() []string {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	keys := make([]string, 0, len(g.items))
+	for key := range g.items {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Len returns the number of entries currently tracked
+func (g *GDSFStrategy) Len() int {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	return len(g.items)
+}
+
+// Clear removes all entries and resets the aging factor
+func (g *GDSFStrategy) Clear() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.items = make(map[string]*gdsfItem)
+	g.queue = make(gdsfHeap, 0, g.capacity)
+	g.inflation = 0
+}
+
+// Capacity returns the maximum number of entries this strategy can hold
+func (g *GDSFStrategy) Capacity() int {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	return g.capacity
+}
+
+// Resize sets the maximum number of entries
+func (g *GDSFStrategy) Resize(capacity int) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.capacity = capacity
+}
+
+// SetInstrumentation installs instrumentation callbacks
+func (g *GDSFStrategy) SetInstrumentation(instr *Instrumentation) {
+	g.instr = instr
+}
+
+// Peek retrieves an entry without raising its priority
+func (g *GDSFStrategy) Peek(key string) (*entry.Entry, bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	item, found := g.items[key]
+	if !found {
+		return nil, false
+	}
+	return item.entry, true
+}
+
+// Victims returns up to n keys starting from the lowest priority
+func (g *GDSFStrategy) Victims(n int) []string {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	ordered := make(gdsfHeap, len(g.queue))
+	copy(ordered, g.queue)
+	sort.Slice(ordered, func(i, j int) bool { return ordered.Less(i, j) })
+
+	n = min(max(n, 0), len(ordered))
+	victims := make([]string, n)
+	for i := range victims {
+		victims[i] = ordered[i].key
+	}
+	return victims
+}
+
+// prioritize recomputes the priority of item against the current aging factor
+// (internal method, assumes lock is held)
+func (g *GDSFStrategy) prioritize(item *gdsfItem) {
+	item.priority = g.inflation + float64(item.frequency)/float64(item.size)
+	heap.Fix(&g.queue, item.index)
+}
+
+// gdsfItem is a heap entry for a tracked key
+type gdsfItem struct {
+	key       string
+	entry     *entry.Entry
+	frequency int
+	size      int
+	priority  float64
+	index     int
+}
+
+// gdsfHeap is a min-heap of keys ordered by priority, then key
+type gdsfHeap []*gdsfItem
+
+func (h gdsfHeap) Len() int { return len(h) }
+
+func (h gdsfHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority < h[j].priority
+	}
+	return h[i].key < h[j].key
+}
+
+func (h gdsfHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *gdsfHeap) Push(x any) {
+	item := x.(*gdsfItem)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *gdsfHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return item
+}
@@ -0,0 +1,118 @@
+package eviction
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+)
+
+// sizedEntry creates an entry whose stored size is size bytes
+func sizedEntry(size int) *entry.Entry {
+	e := entry.NewWithoutTTL("value")
+	e.ValueSize = size
+	return e
+}
+
+func TestGDSFStrategy(t *testing.T) {
+	strategy := NewGDSFStrategy(3)
+	strategy.Add("small", sizedEntry(10))
+	strategy.Add("large", sizedEntry(1000))
+	strategy.Add("medium", sizedEntry(100))
+
+	t.Run("EvictsLargestFirst", func(t *testing.T) {
+		victims := strategy.Victims(3)
+		expected := []string{"large", "medium", "small"}
+		for i, key := range expected {
+			if victims[i] != key {
+				t.Fatalf("Expected victims %v, got %v", expected, victims)
+			}
+		}
+
+		evictKey, _, evicted := strategy.Add("new", sizedEntry(10))
+		if !evicted || evictKey != "large" {
+			t.Fatalf("Expected large to be evicted, got %q (evicted=%v)", evictKey, evicted)
+		}
+	})
+
+	t.Run("FrequencyOutweighsSize", func(t *testing.T) {
+		// medium (100 bytes) accessed 20 times beats small (10 bytes) accessed once
+		for range 20 {
+			strategy.Get("medium")
+		}
+		if victims := strategy.Victims(1); victims[0] == "medium" {
+			t.Errorf("Expected frequently used medium not to be the next victim, got %v", victims)
+		}
+	})
+
+	t.Run("AgingLetsIdleEntriesSink", func(t *testing.T) {
+		// Every eviction raises the aging factor, so new entries eventually outrank
+		// an entry that stopped being accessed, however popular it once was
+		for i := range 1000 {
+			key := fmt.Sprintf("churn%d", i)
+			strategy.Add(key, sizedEntry(10))
+			strategy.Get(key)
+			strategy.Get(key)
+		}
+		if strategy.Contains("medium") {
+			t.Error("Expected idle medium entry to be evicted eventually")
+		}
+	})
+
+	t.Run("SizeUpdates", func(t *testing.T) {
+		strategy.Clear()
+		strategy.Add("a", sizedEntry(10))
+		strategy.Add("b", sizedEntry(10))
+		strategy.Add("a", sizedEntry(10000))
+		if victims := strategy.Victims(1); victims[0] != "a" {
+			t.Errorf("Expected resized a to become the next victim, got %v", victims)
+		}
+	})
+}
+
+// byteHitRate replays a trace against a weight-bounded strategy and returns
+// the fraction of requested bytes that were served from the cache
+func byteHitRate(strategy Strategy, trace []string, sizes map[string]int) float64 {
+	weighted := strategy.(WeightedStrategy)
+	var hitBytes, totalBytes int
+	for _, key := range trace {
+		totalBytes += sizes[key]
+		if _, found := strategy.Get(key); found {
+			hitBytes += sizes[key]
+			continue
+		}
+		weighted.AddWeighted(key, sizedEntry(sizes[key]), int64(sizes[key]))
+	}
+	return float64(hitBytes) / float64(totalBytes)
+}
+
+func TestGDSFByteHitRate(t *testing.T) {
+	// A working set of small and medium values with skewed popularity, polluted
+	// by a stream of large values that are each requested once
+	rng := rand.New(rand.NewPCG(1, 2))
+	zipf := rand.NewZipf(rng, 1.1, 1, 499)
+	sizes := make(map[string]int)
+	for i := range 500 {
+		sizes[fmt.Sprintf("hot%d", i)] = 512 + rng.IntN(4096)
+	}
+
+	trace := make([]string, 0, 24000)
+	for i := range 20000 {
+		trace = append(trace, fmt.Sprintf("hot%d", zipf.Uint64()))
+		if i%5 == 0 {
+			key := fmt.Sprintf("blob%d", i)
+			sizes[key] = 64 << 10
+			trace = append(trace, key)
+		}
+	}
+
+	const maxWeight = 512 << 10
+	gdsf := byteHitRate(NewStrategy(Config{Type: GDSF, Capacity: 100000, MaxWeight: maxWeight}), trace, sizes)
+	lru := byteHitRate(NewStrategy(Config{Type: LRU, Capacity: 100000, MaxWeight: maxWeight}), trace, sizes)
+
+	t.Logf("byte hit rate: GDSF %.3f, LRU %.3f", gdsf, lru)
+	if gdsf <= lru {
+		t.Errorf("Expected GDSF byte hit rate %.3f to beat LRU %.3f", gdsf, lru)
+	}
+}
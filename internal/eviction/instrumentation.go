@@ -0,0 +1,81 @@
+package eviction
+
+import "time"
+
+// Instrumentation holds optional callbacks that strategies invoke at internal
+// events. Nil callbacks are skipped, and a strategy without instrumentation
+// pays a single nil check per event. Callbacks may run concurrently and while
+// the strategy holds its lock, so they must be fast and must not call back
+// into the strategy.
+type Instrumentation struct {
+	// OnAdmit is called when a new key is admitted. For TinyLFU this is when a
+	// key leaves the admission window for the main region
+	OnAdmit func(key string)
+
+	// OnEvictSelect is called with the time spent choosing an eviction victim
+	OnEvictSelect func(d time.Duration)
+
+	// OnPromote is called when an access improves a key's standing: an LRU or
+	// TinyLFU recency bump, an LFU frequency increment, a TinyLFU move to the
+	// protected segment or a CLOCK reference bit being set
+	OnPromote func(key string)
+
+	// OnDemote is called when a TinyLFU key drops from the protected segment
+	// back to probation
+	OnDemote func(key string)
+
+	// OnAgeReset is called when frequency data is aged: a TinyLFU sketch
+	// halving or an LFU aging interval elapsing
+	OnAgeReset func()
+}
+
+// Instrumented is implemented by strategies that accept Instrumentation
+type Instrumented interface {
+	// SetInstrumentation installs the callbacks; it must be called before the
+	// strategy is used. A nil value disables instrumentation
+	SetInstrumentation(instr *Instrumentation)
+}
+
+// admit reports an admitted key
+func (i *Instrumentation) admit(key string) {
+	if i != nil && i.OnAdmit != nil {
+		i.OnAdmit(key)
+	}
+}
+
+// promote reports a promoted key
+func (i *Instrumentation) promote(key string) {
+	if i != nil && i.OnPromote != nil {
+		i.OnPromote(key)
+	}
+}
+
+// demote reports a demoted key
+func (i *Instrumentation) demote(key string) {
+	if i != nil && i.OnDemote != nil {
+		i.OnDemote(key)
+	}
+}
+
+// ageReset reports that frequency data was aged
+func (i *Instrumentation) ageReset() {
+	if i != nil && i.OnAgeReset != nil {
+		i.OnAgeReset()
+	}
+}
+
+// selectStart returns the start time of a victim selection, or the zero time
+// when OnEvictSelect is unset so untimed strategies never read the clock
+func (i *Instrumentation) selectStart() time.Time {
+	if i == nil || i.OnEvictSelect == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// selectDone reports the duration of a victim selection begun at start
+func (i *Instrumentation) selectDone(start time.Time) {
+	if !start.IsZero() {
+		i.OnEvictSelect(time.Since(start))
+	}
+}
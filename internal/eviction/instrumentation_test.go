@@ -0,0 +1,100 @@
+package eviction
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// eventCounts records instrumentation events for assertions
+type eventCounts struct {
+	admits, selects, promotes, demotes, ageResets int
+}
+
+func (c *eventCounts) instrumentation() *Instrumentation {
+	return &Instrumentation{
+		OnAdmit:       func(string) { c.admits++ },
+		OnEvictSelect: func(time.Duration) { c.selects++ },
+		OnPromote:     func(string) { c.promotes++ },
+		OnDemote:      func(string) { c.demotes++ },
+		OnAgeReset:    func() { c.ageResets++ },
+	}
+}
+
+func TestInstrumentation(t *testing.T) {
+	for _, evictionType := range []EvictionType{LRU, LFU, FIFO, Random, TTLFirst, Clock, GDSF} {
+		t.Run(string(evictionType), func(t *testing.T) {
+			var counts eventCounts
+			strategy := NewStrategy(Config{Type: evictionType, Capacity: 2, Instrumentation: counts.instrumentation()})
+
+			strategy.Add("key1", createTestEntry("key1"))
+			strategy.Add("key2", createTestEntry("key2"))
+			strategy.Add("key2", createTestEntry("key2")) // Update, not an admission
+			strategy.Get("key1")
+			strategy.Add("key3", createTestEntry("key3"))
+
+			if counts.admits != 3 {
+				t.Errorf("Expected 3 admissions, got %d", counts.admits)
+			}
+			if counts.selects != 1 {
+				t.Errorf("Expected 1 victim selection, got %d", counts.selects)
+			}
+			wantPromotes := 1
+			if evictionType == FIFO || evictionType == Random {
+				wantPromotes = 0 // Reads never reorder these strategies
+			}
+			if counts.promotes != wantPromotes {
+				t.Errorf("Expected %d promotions, got %d", wantPromotes, counts.promotes)
+			}
+		})
+	}
+}
+
+func TestTinyLFUInstrumentation(t *testing.T) {
+	var counts eventCounts
+	strategy := NewTinyLFUStrategy(100)
+	strategy.SetInstrumentation(counts.instrumentation())
+
+	for i := range 100 {
+		key := fmt.Sprintf("key%d", i)
+		strategy.Add(key, createTestEntry(key))
+	}
+	// The window holds one entry, so every other key was admitted to the main region
+	if counts.admits != 99 {
+		t.Errorf("Expected 99 admissions, got %d", counts.admits)
+	}
+
+	// Promote more probation entries than the protected segment holds
+	for i := range 90 {
+		strategy.Get(fmt.Sprintf("key%d", i))
+	}
+	if counts.promotes != 90 || counts.demotes == 0 {
+		t.Errorf("Expected 90 promotions and some demotions, got %d and %d", counts.promotes, counts.demotes)
+	}
+
+	// The sketch ages after 10*capacity increments
+	for i := range 1000 {
+		strategy.Get(fmt.Sprintf("miss%d", i))
+	}
+	if counts.ageResets == 0 {
+		t.Error("Expected the sketch to report aging")
+	}
+}
+
+func TestLFUAgingInstrumentation(t *testing.T) {
+	var counts eventCounts
+	strategy := NewLFUStrategyWithAging(10, time.Minute)
+	strategy.SetInstrumentation(counts.instrumentation())
+
+	now := time.Now()
+	strategy.start = now
+	strategy.now = func() time.Time { return now }
+
+	strategy.Add("key1", createTestEntry("key1"))
+	now = now.Add(90 * time.Second)
+	strategy.Get("key1")
+
+	if counts.ageResets != 1 {
+		t.Errorf("Expected 1 age reset after one interval, got %d", counts.ageResets)
+	}
+}
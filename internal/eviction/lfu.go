@@ -1,9 +1,11 @@
 package eviction
 
 import (
+	"sort"
 	"sync"
+	"time"
 
-	"github.com/1mb-dev/obcache-go/v2/internal/entry"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
 )
 
 // LFUStrategy implements the LFU (Least Frequently Used) eviction strategy
@@ -12,14 +14,36 @@ type LFUStrategy struct {
 	frequencies map[string]int
 	capacity    int
 	mutex       sync.RWMutex
+
+	// Aging halves every frequency once per agingInterval. Rather than
+	// rewriting all counters, each key remembers the epoch its counter was last
+	// written in and the missed halvings are applied lazily when it is read
+	agingInterval time.Duration
+	epochs        map[string]int64
+	lastEpoch     int64
+	start         time.Time
+	now           func() time.Time
+
+	instr *Instrumentation
 }
 
 // NewLFUStrategy creates a new LFU eviction strategy
 func NewLFUStrategy(capacity int) *LFUStrategy {
+	return NewLFUStrategyWithAging(capacity, 0)
+}
+
+// NewLFUStrategyWithAging creates an LFU strategy whose frequencies halve every
+// agingInterval, so formerly popular keys eventually become evictable.
+// An agingInterval <= 0 disables aging
+func NewLFUStrategyWithAging(capacity int, agingInterval time.Duration) *LFUStrategy {
 	return &LFUStrategy{
-		data:        make(map[string]*entry.Entry),
-		frequencies: make(map[string]int),
-		capacity:    capacity,
+		data:          make(map[string]*entry.Entry),
+		frequencies:   make(map[string]int),
+		capacity:      capacity,
+		agingInterval: agingInterval,
+		epochs:        make(map[string]int64),
+		start:         time.Now(),
+		now:           time.Now,
 	}
 }
 
@@ -31,26 +55,30 @@ func (l *LFUStrategy) Add(key string, entry *entry.Entry) (string, *entry.Entry,
 	// If key already exists, update it
 	if _, exists := l.data[key]; exists {
 		l.data[key] = entry
-		l.frequencies[key]++
+		l.increment(key)
 		return "", nil, false
 	}
+	l.instr.admit(key)
 
 	// If we're at capacity, evict the least frequently used item
 	if len(l.data) >= l.capacity {
+		start := l.instr.selectStart()
 		evictKey := l.findLFU()
+		l.instr.selectDone(start)
 		if evictKey != "" {
 			evictedEntry := l.data[evictKey]
 			delete(l.data, evictKey)
 			delete(l.frequencies, evictKey)
+			delete(l.epochs, evictKey)
 			l.data[key] = entry
-			l.frequencies[key] = 1
+			l.setFrequency(key, 1)
 			return evictKey, evictedEntry, true
 		}
 	}
 
 	// Add new entry
 	l.data[key] = entry
-	l.frequencies[key] = 1
+	l.setFrequency(key, 1)
 	return "", nil, false
 }
 
@@ -61,11 +89,17 @@ func (l *LFUStrategy) Get(key string) (*entry.Entry, bool) {
 
 	entry, found := l.data[key]
 	if found {
-		l.frequencies[key]++
+		l.increment(key)
+		l.instr.promote(key)
 	}
 	return entry, found
 }
 
+// SetInstrumentation installs instrumentation callbacks
+func (l *LFUStrategy) SetInstrumentation(instr *Instrumentation) {
+	l.instr = instr
+}
+
 // Remove removes an entry from the LFU tracker
 func (l *LFUStrategy) Remove(key string) bool {
 	l.mutex.Lock()
@@ -74,6 +108,7 @@ func (l *LFUStrategy) Remove(key string) bool {
 	if _, exists := l.data[key]; exists {
 		delete(l.data, key)
 		delete(l.frequencies, key)
+		delete(l.epochs, key)
 		return true
 	}
 	return false
@@ -115,13 +150,25 @@ func (l *LFUStrategy) Clear() {
 
 	l.data = make(map[string]*entry.Entry)
 	l.frequencies = make(map[string]int)
+	l.epochs = make(map[string]int64)
 }
 
 // Capacity returns the maximum number of entries this strategy can hold
 func (l *LFUStrategy) Capacity() int {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
 	return l.capacity
 }
 
+// Resize sets the maximum number of entries
+func (l *LFUStrategy) Resize(capacity int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.capacity = capacity
+}
+
 // Peek retrieves an entry without updating its frequency
 func (l *LFUStrategy) Peek(key string) (*entry.Entry, bool) {
 	l.mutex.RLock()
@@ -140,7 +187,9 @@ func (l *LFUStrategy) findLFU() string {
 	var lfuKey string
 	minFreq := -1
 
-	for key, freq := range l.frequencies {
+	epoch := l.epoch()
+	for key := range l.frequencies {
+		freq := l.frequency(key, epoch)
 		if minFreq == -1 || freq < minFreq {
 			minFreq = freq
 			lfuKey = key
@@ -149,3 +198,84 @@ func (l *LFUStrategy) findLFU() string {
 
 	return lfuKey
 }
+
+// Victims returns up to n keys starting from the least frequently used
+// Keys with equal frequency are ordered by key so the result is deterministic
+func (l *LFUStrategy) Victims(n int) []string {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	epoch := l.epoch()
+	frequencies := make(map[string]int, len(l.frequencies))
+	keys := make([]string, 0, len(l.frequencies))
+	for key := range l.frequencies {
+		keys = append(keys, key)
+		frequencies[key] = l.frequency(key, epoch)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		fi, fj := frequencies[keys[i]], frequencies[keys[j]]
+		if fi != fj {
+			return fi < fj
+		}
+		return keys[i] < keys[j]
+	})
+
+	if n < len(keys) {
+		keys = keys[:max(n, 0)]
+	}
+	return keys
+}
+
+// Frequency returns the aged access count of key without recording an access
+func (l *LFUStrategy) Frequency(key string) (int64, bool) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	if _, exists := l.data[key]; !exists {
+		return 0, false
+	}
+	return int64(l.frequency(key, l.epoch())), true
+}
+
+// ColdestKeys returns up to n keys starting from the least frequently used
+func (l *LFUStrategy) ColdestKeys(n int) []string {
+	return l.Victims(n)
+}
+
+// epoch returns the number of aging intervals elapsed (internal method)
+func (l *LFUStrategy) epoch() int64 {
+	if l.agingInterval <= 0 {
+		return 0
+	}
+	return int64(l.now().Sub(l.start) / l.agingInterval)
+}
+
+// frequency returns the aged frequency of key (internal method, assumes lock is held)
+func (l *LFUStrategy) frequency(key string, epoch int64) int {
+	halvings := epoch - l.epochs[key]
+	if halvings <= 0 {
+		return l.frequencies[key]
+	}
+	if halvings >= 63 {
+		return 0
+	}
+	return l.frequencies[key] >> halvings
+}
+
+// setFrequency stores the frequency of key as of the current epoch (internal method, assumes lock is held)
+func (l *LFUStrategy) setFrequency(key string, freq int) {
+	l.frequencies[key] = freq
+	if l.agingInterval > 0 {
+		epoch := l.epoch()
+		l.epochs[key] = epoch
+		if epoch > l.lastEpoch {
+			l.lastEpoch = epoch
+			l.instr.ageReset()
+		}
+	}
+}
+
+// increment records an access to key (internal method, assumes lock is held)
+func (l *LFUStrategy) increment(key string) {
+	l.setFrequency(key, l.frequency(key, l.epoch())+1)
+}
@@ -3,52 +3,56 @@ package eviction
 import (
 	"sync"
 
-	"github.com/1mb-dev/obcache-go/v2/internal/entry"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
 	lru "github.com/hashicorp/golang-lru/v2"
 )
 
 // LRUStrategy implements the LRU (Least Recently Used) eviction strategy
 type LRUStrategy struct {
-	cache        *lru.Cache[string, *entry.Entry]
-	capacity     int
-	mutex        sync.RWMutex
-	evictedKey   string
-	evictedValue *entry.Entry
+	cache    *lru.Cache[string, *entry.Entry]
+	capacity int
+	mutex    sync.RWMutex
+	instr    *Instrumentation
 }
 
 // NewLRUStrategy creates a new LRU eviction strategy
 func NewLRUStrategy(capacity int) *LRUStrategy {
-	s := &LRUStrategy{
-		capacity: capacity,
-	}
-
-	cache, err := lru.NewWithEvict[string, *entry.Entry](capacity, func(key string, value *entry.Entry) {
-		// Store evicted key and value so Add() can return them
-		s.evictedKey = key
-		s.evictedValue = value
-	})
+	cache, err := lru.New[string, *entry.Entry](capacity)
 	if err != nil {
 		// This should not happen with valid capacity, but fallback gracefully
 		panic("failed to create LRU cache: " + err.Error())
 	}
 
-	s.cache = cache
-	return s
+	return &LRUStrategy{
+		cache:    cache,
+		capacity: capacity,
+	}
 }
 
 // Add adds an entry to the LRU tracker
+// The victim is removed explicitly before inserting, so the evicted pair is
+// returned from this call rather than handed over through an eviction callback
 func (l *LRUStrategy) Add(key string, entry *entry.Entry) (string, *entry.Entry, bool) {
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
 
-	// Clear previous eviction state
-	l.evictedKey = ""
-	l.evictedValue = nil
+	if l.cache.Contains(key) {
+		l.cache.Add(key, entry)
+		return "", nil, false
+	}
+	l.instr.admit(key)
+
+	if l.cache.Len() < l.capacity {
+		l.cache.Add(key, entry)
+		return "", nil, false
+	}
 
-	evicted := l.cache.Add(key, entry)
+	start := l.instr.selectStart()
+	evictKey, evictedEntry, evicted := l.cache.RemoveOldest()
+	l.instr.selectDone(start)
 
-	// Return the evicted key and value if an eviction occurred
-	return l.evictedKey, l.evictedValue, evicted
+	l.cache.Add(key, entry)
+	return evictKey, evictedEntry, evicted
 }
 
 // Get retrieves an entry and marks it as recently used
@@ -56,7 +60,16 @@ func (l *LRUStrategy) Get(key string) (*entry.Entry, bool) {
 	l.mutex.RLock()
 	defer l.mutex.RUnlock()
 
-	return l.cache.Get(key)
+	entry, found := l.cache.Get(key)
+	if found {
+		l.instr.promote(key)
+	}
+	return entry, found
+}
+
+// SetInstrumentation installs instrumentation callbacks
+func (l *LRUStrategy) SetInstrumentation(instr *Instrumentation) {
+	l.instr = instr
 }
 
 // Remove removes an entry from the LRU tracker
@@ -101,9 +114,21 @@ func (l *LRUStrategy) Clear() {
 
 // Capacity returns the maximum number of entries this strategy can hold
 func (l *LRUStrategy) Capacity() int {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
 	return l.capacity
 }
 
+// Resize sets the maximum number of entries
+func (l *LRUStrategy) Resize(capacity int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.capacity = capacity
+	l.cache.Resize(capacity)
+}
+
 // Peek retrieves an entry without marking it as recently used
 func (l *LRUStrategy) Peek(key string) (*entry.Entry, bool) {
 	l.mutex.RLock()
@@ -111,3 +136,33 @@ func (l *LRUStrategy) Peek(key string) (*entry.Entry, bool) {
 
 	return l.cache.Peek(key)
 }
+
+// Victims returns up to n keys starting from the least recently used
+func (l *LRUStrategy) Victims(n int) []string {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	keys := l.cache.Keys() // Ordered from oldest to newest
+	if n < len(keys) {
+		keys = keys[:n]
+	}
+	return keys
+}
+
+// Recency returns the number of keys used less recently than key
+func (l *LRUStrategy) Recency(key string) (int, bool) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	for i, k := range l.cache.Keys() { // Ordered from oldest to newest
+		if k == key {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// ColdestKeys returns up to n keys starting from the least recently used
+func (l *LRUStrategy) ColdestKeys(n int) []string {
+	return l.Victims(n)
+}
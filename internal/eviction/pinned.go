@@ -0,0 +1,191 @@
+package eviction
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+)
+
+// ErrAllPinned is returned when a new entry cannot be stored because every
+// entry in a full cache is pinned
+var ErrAllPinned = errors.New("cache is full and every entry is pinned")
+
+// Pinner is implemented by strategies that can protect keys from eviction
+type Pinner interface {
+	// Pin protects a tracked key from eviction. Returns false if the key is not tracked
+	Pin(key string) bool
+
+	// Unpin removes the protection from key. Returns false if it was not pinned
+	Unpin(key string) bool
+
+	// PinnedCount returns the number of pinned keys
+	PinnedCount() int
+
+	// HasRoom reports whether key can be added without evicting a pinned entry
+	HasRoom(key string) bool
+}
+
+// pinnedStrategy skips pinned keys when the base strategy chooses victims
+type pinnedStrategy struct {
+	base   Strategy
+	pinned map[string]struct{}
+	mutex  sync.Mutex
+}
+
+// NewPinnedStrategy wraps base so that pinned keys are never chosen as victims
+// Pins are dropped when their key is removed
+func NewPinnedStrategy(base Strategy) Strategy {
+	return &pinnedStrategy{
+		base:   base,
+		pinned: make(map[string]struct{}),
+	}
+}
+
+// Add adds an entry, evicting the first unpinned victim if capacity is exceeded
+func (p *pinnedStrategy) Add(key string, entry *entry.Entry) (string, *entry.Entry, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if len(p.pinned) == 0 || p.base.Contains(key) || p.base.Len() < p.base.Capacity() {
+		return p.base.Add(key, entry)
+	}
+
+	for _, victim := range p.base.Victims(p.base.Len()) {
+		if _, isPinned := p.pinned[victim]; isPinned || victim == key {
+			continue
+		}
+		victimEntry, _ := p.base.Peek(victim)
+		p.base.Remove(victim)
+		p.base.Add(key, entry)
+		return victim, victimEntry, true
+	}
+
+	// Every entry is pinned; callers are expected to check HasRoom first
+	evictKey, evictedEntry, evicted := p.base.Add(key, entry)
+	if evicted {
+		delete(p.pinned, evictKey)
+	}
+	return evictKey, evictedEntry, evicted
+}
+
+// Get retrieves an entry and lets the base policy record the access
+func (p *pinnedStrategy) Get(key string) (*entry.Entry, bool) {
+	return p.base.Get(key)
+}
+
+// Remove removes an entry and drops its pin
+func (p *pinnedStrategy) Remove(key string) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	delete(p.pinned, key)
+	return p.base.Remove(key)
+}
+
+// Contains checks if a key exists in the tracker
+func (p *pinnedStrategy) Contains(key string) bool {
+	return p.base.Contains(key)
+}
+
+// Keys returns all keys currently tracked
+func (p *pinnedStrategy) Keys() []string {
+	return p.base.Keys()
+}
+
+// Len returns the number of entries currently tracked
+func (p *pinnedStrategy) Len() int {
+	return p.base.Len()
+}
+
+// Clear removes all entries and pins
+func (p *pinnedStrategy) Clear() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.base.Clear()
+	p.pinned = make(map[string]struct{})
+}
+
+// Capacity returns the maximum number of entries this strategy can hold
+func (p *pinnedStrategy) Capacity() int {
+	return p.base.Capacity()
+}
+
+// Peek retrieves an entry without updating its position in the eviction order
+func (p *pinnedStrategy) Peek(key string) (*entry.Entry, bool) {
+	return p.base.Peek(key)
+}
+
+// Victims returns up to n unpinned keys in the base policy's eviction order
+func (p *pinnedStrategy) Victims(n int) []string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if len(p.pinned) == 0 {
+		return p.base.Victims(n)
+	}
+
+	victims := make([]string, 0, max(n, 0))
+	for _, key := range p.base.Victims(n + len(p.pinned)) {
+		if len(victims) >= n {
+			break
+		}
+		if _, isPinned := p.pinned[key]; !isPinned {
+			victims = append(victims, key)
+		}
+	}
+	return victims
+}
+
+// Pin protects a tracked key from eviction
+func (p *pinnedStrategy) Pin(key string) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if !p.base.Contains(key) {
+		return false
+	}
+	p.pinned[key] = struct{}{}
+	return true
+}
+
+// Unpin removes the protection from key
+func (p *pinnedStrategy) Unpin(key string) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if _, isPinned := p.pinned[key]; !isPinned {
+		return false
+	}
+	delete(p.pinned, key)
+	return true
+}
+
+// PinnedCount returns the number of pinned keys
+func (p *pinnedStrategy) PinnedCount() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return len(p.pinned)
+}
+
+// HasRoom reports whether key can be added without evicting a pinned entry
+func (p *pinnedStrategy) HasRoom(key string) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.base.Contains(key) || p.base.Len() < p.base.Capacity() || len(p.pinned) < p.base.Len()
+}
+
+// Base returns the wrapped strategy
+func (p *pinnedStrategy) Base() Strategy {
+	return p.base
+}
+
+// Reindex forwards deadline changes to an expiry-aware base strategy
+func (p *pinnedStrategy) Reindex(key string) {
+	if tracker, ok := p.base.(ExpiryTracker); ok {
+		tracker.Reindex(key)
+	}
+}
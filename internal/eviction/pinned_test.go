@@ -0,0 +1,49 @@
+package eviction
+
+import (
+	"testing"
+)
+
+func TestPinnedStrategy(t *testing.T) {
+	strategy := NewPinnedStrategy(NewLRUStrategy(3))
+	pinner := strategy.(Pinner)
+
+	for _, key := range []string{"a", "b", "c"} {
+		strategy.Add(key, createTestEntry(key))
+	}
+
+	if !pinner.Pin("a") || pinner.Pin("missing") {
+		t.Fatal("Expected Pin to succeed only for tracked keys")
+	}
+
+	// a is least recently used but pinned, so b goes first
+	evictKey, _, evicted := strategy.Add("d", createTestEntry("d"))
+	if !evicted || evictKey != "b" {
+		t.Fatalf("Expected b to be evicted, got %q", evictKey)
+	}
+	if victims := strategy.Victims(3); len(victims) != 2 || victims[0] != "c" || victims[1] != "d" {
+		t.Fatalf("Expected pinned key to be excluded from victims, got %v", victims)
+	}
+
+	pinner.Pin("c")
+	pinner.Pin("d")
+	if pinner.PinnedCount() != 3 || pinner.HasRoom("e") {
+		t.Fatal("Expected no room when every entry is pinned")
+	}
+	if !pinner.HasRoom("a") {
+		t.Error("Expected updates to pinned keys to have room")
+	}
+
+	if !pinner.Unpin("c") || pinner.Unpin("c") {
+		t.Fatal("Expected Unpin to succeed once")
+	}
+	evictKey, _, _ = strategy.Add("e", createTestEntry("e"))
+	if evictKey != "c" {
+		t.Fatalf("Expected unpinned c to be evicted, got %q", evictKey)
+	}
+
+	strategy.Remove("a")
+	if pinner.PinnedCount() != 1 {
+		t.Errorf("Expected pin to be dropped with its key, got %d pinned", pinner.PinnedCount())
+	}
+}
@@ -0,0 +1,182 @@
+package eviction
+
+import (
+	"math/rand/v2"
+	"sync"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+)
+
+// randomItem is a tracked key and its entry
+type randomItem struct {
+	key   string
+	entry *entry.Entry
+}
+
+// RandomStrategy implements random eviction
+// Entries are kept in a dense slice indexed by a map, so insert, remove and
+// victim selection are all O(1) and reads never reorder anything
+type RandomStrategy struct {
+	index    map[string]int // Key to position in items
+	items    []randomItem
+	capacity int
+	mutex    sync.RWMutex
+	instr    *Instrumentation
+}
+
+// NewRandomStrategy creates a new random eviction strategy
+func NewRandomStrategy(capacity int) *RandomStrategy {
+	return &RandomStrategy{
+		index:    make(map[string]int),
+		items:    make([]randomItem, 0, capacity),
+		capacity: capacity,
+	}
+}
+
+// Add adds an entry to the random tracker
+func (r *RandomStrategy) Add(key string, entry *entry.Entry) (string, *entry.Entry, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// If key already exists, update it in place
+	if i, exists := r.index[key]; exists {
+		r.items[i].entry = entry
+		return "", nil, false
+	}
+	r.instr.admit(key)
+
+	// If we're at capacity, replace a uniformly random victim
+	if len(r.items) >= r.capacity && r.capacity > 0 {
+		start := r.instr.selectStart()
+		i := rand.IntN(len(r.items))
+		r.instr.selectDone(start)
+		evicted := r.items[i]
+		delete(r.index, evicted.key)
+
+		r.items[i] = randomItem{key: key, entry: entry}
+		r.index[key] = i
+		return evicted.key, evicted.entry, true
+	}
+
+	// Add new entry
+	r.index[key] = len(r.items)
+	r.items = append(r.items, randomItem{key: key, entry: entry})
+	return "", nil, false
+}
+
+// Get retrieves an entry (no ordering change in random eviction)
+func (r *RandomStrategy) Get(key string) (*entry.Entry, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if i, found := r.index[key]; found {
+		return r.items[i].entry, true
+	}
+	return nil, false
+}
+
+// Remove removes an entry from the random tracker
+func (r *RandomStrategy) Remove(key string) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	i, exists := r.index[key]
+	if !exists {
+		return false
+	}
+
+	// Move the last item into the hole to keep the slice dense
+	last := len(r.items) - 1
+	if i != last {
+		r.items[i] = r.items[last]
+		r.index[r.items[i].key] = i
+	}
+	r.items[last] = randomItem{}
+	r.items = r.items[:last]
+	delete(r.index, key)
+	return true
+}
+
+// Contains checks if a key exists in the random tracker
+func (r *RandomStrategy) Contains(key string) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	_, exists := r.index[key]
+	return exists
+}
+
+// SetInstrumentation installs instrumentation callbacks
+func (r *RandomStrategy) SetInstrumentation(instr *Instrumentation) {
+	r.instr = instr
+}
+
+// Keys returns all keys currently tracked by the random strategy
+func (r *RandomStrategy) Keys() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	keys := make([]string, len(r.items))
+	for i, item := range r.items {
+		keys[i] = item.key
+	}
+	return keys
+}
+
+// Len returns the number of entries currently tracked
+func (r *RandomStrategy) Len() int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return len(r.items)
+}
+
+// Clear removes all entries from the random tracker
+func (r *RandomStrategy) Clear() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.index = make(map[string]int)
+	r.items = make([]randomItem, 0, r.capacity)
+}
+
+// Capacity returns the maximum number of entries this strategy can hold
+func (r *RandomStrategy) Capacity() int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.capacity
+}
+
+// Resize sets the maximum number of entries
+func (r *RandomStrategy) Resize(capacity int) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.capacity = capacity
+}
+
+// Peek retrieves an entry (identical to Get for random eviction)
+func (r *RandomStrategy) Peek(key string) (*entry.Entry, bool) {
+	return r.Get(key)
+}
+
+// Victims returns up to n distinct keys chosen uniformly at random
+// Since Add picks victims at random too, this is a sample rather than a prediction
+func (r *RandomStrategy) Victims(n int) []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	keys := make([]string, len(r.items))
+	for i, item := range r.items {
+		keys[i] = item.key
+	}
+
+	// Partial Fisher-Yates shuffle of the first n positions
+	n = min(max(n, 0), len(keys))
+	for i := 0; i < n; i++ {
+		j := i + rand.IntN(len(keys)-i)
+		keys[i], keys[j] = keys[j], keys[i]
+	}
+	return keys[:n]
+}
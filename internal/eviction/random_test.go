@@ -0,0 +1,95 @@
+package eviction
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRandomStrategy(t *testing.T) {
+	strategy := NewRandomStrategy(10)
+	for i := 0; i < 10; i++ {
+		strategy.Add(fmt.Sprintf("key%d", i), createTestEntry("value"))
+	}
+
+	t.Run("EvictsTrackedKey", func(t *testing.T) {
+		evictKey, evictedEntry, evicted := strategy.Add("new", createTestEntry("new"))
+		if !evicted || evictedEntry == nil {
+			t.Fatal("Expected eviction when exceeding capacity")
+		}
+		if evictKey == "new" || strategy.Contains(evictKey) {
+			t.Errorf("Expected an existing key to be evicted, got %q", evictKey)
+		}
+		if !strategy.Contains("new") || strategy.Len() != 10 {
+			t.Errorf("Expected new key tracked at capacity, len=%d", strategy.Len())
+		}
+	})
+
+	t.Run("RemoveKeepsIndexConsistent", func(t *testing.T) {
+		keys := strategy.Keys()
+		for _, key := range keys[:5] {
+			if !strategy.Remove(key) {
+				t.Fatalf("Expected %s to be removed", key)
+			}
+		}
+		for _, key := range keys[5:] {
+			if entry, found := strategy.Get(key); !found || entry == nil {
+				t.Errorf("Expected %s to still be retrievable", key)
+			}
+		}
+		if strategy.Len() != 5 {
+			t.Errorf("Expected length 5, got %d", strategy.Len())
+		}
+	})
+
+	t.Run("Victims", func(t *testing.T) {
+		victims := strategy.Victims(3)
+		if len(victims) != 3 {
+			t.Fatalf("Expected 3 victims, got %v", victims)
+		}
+		seen := make(map[string]bool)
+		for _, key := range victims {
+			if seen[key] || !strategy.Contains(key) {
+				t.Errorf("Expected distinct tracked victims, got %v", victims)
+			}
+			seen[key] = true
+		}
+		if all := strategy.Victims(100); len(all) != strategy.Len() {
+			t.Errorf("Expected Victims to cap at %d, got %d", strategy.Len(), len(all))
+		}
+	})
+}
+
+func benchmarkStrategyAdd(b *testing.B, strategy Strategy) {
+	keys := make([]string, 4*strategy.Capacity())
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key%d", i)
+	}
+	e := createTestEntry("value")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		strategy.Add(keys[i%len(keys)], e)
+	}
+}
+
+func benchmarkStrategyGet(b *testing.B, strategy Strategy) {
+	keys := make([]string, strategy.Capacity())
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key%d", i)
+		strategy.Add(keys[i], createTestEntry("value"))
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			strategy.Get(keys[i%len(keys)])
+			i++
+		}
+	})
+}
+
+func BenchmarkRandomAdd(b *testing.B) { benchmarkStrategyAdd(b, NewRandomStrategy(10000)) }
+func BenchmarkLRUAdd(b *testing.B)    { benchmarkStrategyAdd(b, NewLRUStrategy(10000)) }
+func BenchmarkRandomGet(b *testing.B) { benchmarkStrategyGet(b, NewRandomStrategy(10000)) }
+func BenchmarkLRUGet(b *testing.B)    { benchmarkStrategyGet(b, NewLRUStrategy(10000)) }
@@ -0,0 +1,9 @@
+package eviction
+
+import "github.com/1mb-dev/obcache-go/v2/pkg/store"
+
+// FrequencyReporter is implemented by strategies that count accesses per key
+type FrequencyReporter = store.FrequencyReporter
+
+// RecencyReporter is implemented by strategies that order keys by last access
+type RecencyReporter = store.RecencyReporter
@@ -0,0 +1,423 @@
+package eviction
+
+import (
+	"container/list"
+	"hash/maphash"
+	"sync"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+)
+
+// tinyLFU segment identifiers
+const (
+	segmentWindow = iota
+	segmentProbation
+	segmentProtected
+)
+
+// tinyLFUItem is the list element payload for a tracked key
+type tinyLFUItem struct {
+	key     string
+	entry   *entry.Entry
+	segment int
+}
+
+// TinyLFUStrategy implements the W-TinyLFU eviction strategy
+//
+// New entries land in a small LRU admission window (1% of capacity). When the
+// window overflows, its least recently used entry becomes a candidate for the
+// main region and only displaces the main region's victim if the frequency
+// sketch estimates it has been accessed more often. The main region is a
+// segmented LRU: entries start in probation and move to the protected segment
+// (80% of the main region) when accessed again.
+//
+// Frequencies are tracked in a count-min sketch of 4-bit-saturating byte
+// counters, 4 rows wide by the next power of two >= capacity. The sketch costs
+// between 4 and 8 bytes per unit of capacity on top of the per-entry list and
+// map overhead, and is halved every 10*capacity increments so stale
+// popularity fades.
+type TinyLFUStrategy struct {
+	data      map[string]*list.Element
+	window    *list.List
+	probation *list.List
+	protected *list.List
+	sketch    *countMinSketch
+
+	capacity     int
+	windowCap    int
+	protectedCap int
+	mutex        sync.Mutex
+	instr        *Instrumentation
+}
+
+// NewTinyLFUStrategy creates a new W-TinyLFU eviction strategy
+func NewTinyLFUStrategy(capacity int) *TinyLFUStrategy {
+	windowCap := max(1, capacity/100)
+	mainCap := max(0, capacity-windowCap)
+
+	return &TinyLFUStrategy{
+		data:         make(map[string]*list.Element),
+		window:       list.New(),
+		probation:    list.New(),
+		protected:    list.New(),
+		sketch:       newCountMinSketch(capacity),
+		capacity:     capacity,
+		windowCap:    windowCap,
+		protectedCap: mainCap * 8 / 10,
+	}
+}
+
+// Add adds an entry to the TinyLFU tracker
+func (t *TinyLFUStrategy) Add(key string, entry *entry.Entry) (string, *entry.Entry, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.increment(key)
+
+	// If key already exists, update it and count the write as an access
+	if elem, exists := t.data[key]; exists {
+		elem.Value.(*tinyLFUItem).entry = entry
+		t.touch(elem)
+		return "", nil, false
+	}
+
+	item := &tinyLFUItem{key: key, entry: entry, segment: segmentWindow}
+	t.data[key] = t.window.PushFront(item)
+
+	if t.window.Len() <= t.windowCap {
+		return "", nil, false
+	}
+
+	// The window overflowed; its LRU entry competes for a place in the main region
+	candidate := t.window.Back()
+	candidateItem := candidate.Value.(*tinyLFUItem)
+	if t.mainLen() < t.capacity-t.windowCap {
+		t.moveTo(candidate, t.window, t.probation, segmentProbation)
+		t.instr.admit(candidateItem.key)
+		return "", nil, false
+	}
+
+	start := t.instr.selectStart()
+	victim := t.mainVictim()
+	admitted := victim != nil && t.sketch.estimate(candidateItem.key) > t.sketch.estimate(victim.Value.(*tinyLFUItem).key)
+	t.instr.selectDone(start)
+	if !admitted {
+		return t.evict(candidate, t.window)
+	}
+
+	evictKey, evictedEntry, evicted := t.evict(victim, t.listFor(victim.Value.(*tinyLFUItem).segment))
+	t.moveTo(candidate, t.window, t.probation, segmentProbation)
+	t.instr.admit(candidateItem.key)
+	return evictKey, evictedEntry, evicted
+}
+
+// Get retrieves an entry and records the access
+// Misses are recorded too, so keys that are requested often are admitted once cached
+func (t *TinyLFUStrategy) Get(key string) (*entry.Entry, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.increment(key)
+
+	elem, found := t.data[key]
+	if !found {
+		return nil, false
+	}
+	t.touch(elem)
+	return elem.Value.(*tinyLFUItem).entry, true
+}
+
+// SetInstrumentation installs instrumentation callbacks
+func (t *TinyLFUStrategy) SetInstrumentation(instr *Instrumentation) {
+	t.instr = instr
+}
+
+// Remove removes an entry from the TinyLFU tracker
+func (t *TinyLFUStrategy) Remove(key string) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	elem, exists := t.data[key]
+	if !exists {
+		return false
+	}
+	t.listFor(elem.Value.(*tinyLFUItem).segment).Remove(elem)
+	delete(t.data, key)
+	return true
+}
+
+// Contains checks if a key exists in the TinyLFU tracker
+func (t *TinyLFUStrategy) Contains(key string) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	_, exists := t.data[key]
+	return exists
+}
+
+// Keys returns all keys currently tracked by the TinyLFU strategy
+func (t *TinyLFUStrategy) Keys() []string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	keys := make([]string, 0, len(t.data))
+	for key := range t.data {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Len returns the number of entries currently tracked
+func (t *TinyLFUStrategy) Len() int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return len(t.data)
+}
+
+// Clear removes all entries and resets the frequency sketch
+func (t *TinyLFUStrategy) Clear() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.data = make(map[string]*list.Element)
+	t.window.Init()
+	t.probation.Init()
+	t.protected.Init()
+	t.sketch.clear()
+}
+
+// Capacity returns the maximum number of entries this strategy can hold
+func (t *TinyLFUStrategy) Capacity() int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.capacity
+}
+
+// Resize sets the maximum number of entries and rebalances the segments to
+// the new window and protected sizes. The frequency sketch keeps its width,
+// so its estimates get less precise if the cache grows far beyond its
+// original capacity
+func (t *TinyLFUStrategy) Resize(capacity int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.capacity = capacity
+	t.windowCap = max(1, capacity/100)
+	mainCap := max(0, capacity-t.windowCap)
+	t.protectedCap = mainCap * 8 / 10
+	t.sketch.sampleSize = 10 * max(capacity, 1)
+
+	// At most one of the regions can be over its new size; move its coldest
+	// entries to the other so Add keeps the total within capacity
+	for t.mainLen() > mainCap && t.window.Len() < t.windowCap {
+		victim := t.mainVictim()
+		item := victim.Value.(*tinyLFUItem)
+		t.moveTo(victim, t.listFor(item.segment), t.window, segmentWindow)
+		t.window.MoveToBack(t.data[item.key])
+	}
+	for t.window.Len() > t.windowCap && t.mainLen() < mainCap {
+		t.moveTo(t.window.Back(), t.window, t.probation, segmentProbation)
+	}
+	for t.protected.Len() > t.protectedCap {
+		t.moveTo(t.protected.Back(), t.protected, t.probation, segmentProbation)
+	}
+}
+
+// Peek retrieves an entry without recording an access
+func (t *TinyLFUStrategy) Peek(key string) (*entry.Entry, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	elem, found := t.data[key]
+	if !found {
+		return nil, false
+	}
+	return elem.Value.(*tinyLFUItem).entry, true
+}
+
+// Victims returns up to n keys in eviction order
+// The window's LRU entries and the main region's victims are merged by estimated
+// frequency, the same comparison Add uses to decide which of the two to evict
+func (t *TinyLFUStrategy) Victims(n int) []string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	n = min(max(n, 0), len(t.data))
+	victims := make([]string, 0, n)
+
+	windowNext := t.window.Back()
+	mainNext, mainList := t.probation.Back(), t.probation
+	if mainNext == nil {
+		mainNext, mainList = t.protected.Back(), t.protected
+	}
+
+	for len(victims) < n {
+		takeWindow := mainNext == nil
+		if windowNext != nil && mainNext != nil {
+			windowKey := windowNext.Value.(*tinyLFUItem).key
+			mainKey := mainNext.Value.(*tinyLFUItem).key
+			takeWindow = t.sketch.estimate(windowKey) <= t.sketch.estimate(mainKey)
+		}
+
+		if takeWindow {
+			victims = append(victims, windowNext.Value.(*tinyLFUItem).key)
+			windowNext = windowNext.Prev()
+			continue
+		}
+
+		victims = append(victims, mainNext.Value.(*tinyLFUItem).key)
+		mainNext = mainNext.Prev()
+		if mainNext == nil && mainList == t.probation {
+			mainNext, mainList = t.protected.Back(), t.protected
+		}
+	}
+
+	return victims
+}
+
+// touch records a hit on a tracked element (internal method, assumes lock is held)
+func (t *TinyLFUStrategy) touch(elem *list.Element) {
+	item := elem.Value.(*tinyLFUItem)
+	switch item.segment {
+	case segmentWindow:
+		t.window.MoveToFront(elem)
+	case segmentProtected:
+		t.protected.MoveToFront(elem)
+	case segmentProbation:
+		// A second access promotes the entry; demote protected overflow back to probation
+		t.moveTo(elem, t.probation, t.protected, segmentProtected)
+		if t.protected.Len() > t.protectedCap {
+			demoted := t.protected.Back()
+			t.moveTo(demoted, t.protected, t.probation, segmentProbation)
+			t.instr.demote(demoted.Value.(*tinyLFUItem).key)
+		}
+	}
+	t.instr.promote(item.key)
+}
+
+// increment records an access in the sketch (internal method, assumes lock is held)
+func (t *TinyLFUStrategy) increment(key string) {
+	if t.sketch.increment(key) {
+		t.instr.ageReset()
+	}
+}
+
+// moveTo moves elem to the front of another segment (internal method, assumes lock is held)
+func (t *TinyLFUStrategy) moveTo(elem *list.Element, from, to *list.List, segment int) {
+	item := from.Remove(elem).(*tinyLFUItem)
+	item.segment = segment
+	t.data[item.key] = to.PushFront(item)
+}
+
+// evict removes elem and reports it as evicted (internal method, assumes lock is held)
+func (t *TinyLFUStrategy) evict(elem *list.Element, from *list.List) (string, *entry.Entry, bool) {
+	item := from.Remove(elem).(*tinyLFUItem)
+	delete(t.data, item.key)
+	return item.key, item.entry, true
+}
+
+// mainVictim returns the main region's next eviction victim (internal method, assumes lock is held)
+func (t *TinyLFUStrategy) mainVictim() *list.Element {
+	if victim := t.probation.Back(); victim != nil {
+		return victim
+	}
+	return t.protected.Back()
+}
+
+// mainLen returns the number of entries in the main region (internal method, assumes lock is held)
+func (t *TinyLFUStrategy) mainLen() int {
+	return t.probation.Len() + t.protected.Len()
+}
+
+// listFor returns the list backing a segment (internal method, assumes lock is held)
+func (t *TinyLFUStrategy) listFor(segment int) *list.List {
+	switch segment {
+	case segmentProbation:
+		return t.probation
+	case segmentProtected:
+		return t.protected
+	default:
+		return t.window
+	}
+}
+
+// sketchDepth is the number of hash rows in the count-min sketch
+const sketchDepth = 4
+
+// sketchMaxCount is the value at which sketch counters saturate
+const sketchMaxCount = 15
+
+// countMinSketch estimates access frequencies in constant memory
+type countMinSketch struct {
+	rows       [sketchDepth][]uint8
+	seeds      [sketchDepth]maphash.Seed
+	mask       uint64
+	additions  int
+	sampleSize int
+}
+
+// newCountMinSketch creates a sketch sized for the given cache capacity
+func newCountMinSketch(capacity int) *countMinSketch {
+	width := 1
+	for width < max(capacity, 16) {
+		width <<= 1
+	}
+
+	s := &countMinSketch{
+		mask:       uint64(width - 1),
+		sampleSize: 10 * max(capacity, 1),
+	}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+		s.seeds[i] = maphash.MakeSeed()
+	}
+	return s
+}
+
+// increment records one access to key, aging the sketch when the sample is full
+// Returns true if the sketch was aged
+func (s *countMinSketch) increment(key string) bool {
+	for i := range s.rows {
+		idx := maphash.String(s.seeds[i], key) & s.mask
+		if s.rows[i][idx] < sketchMaxCount {
+			s.rows[i][idx]++
+		}
+	}
+
+	s.additions++
+	if s.additions >= s.sampleSize {
+		s.age()
+		return true
+	}
+	return false
+}
+
+// estimate returns the estimated access count for key
+func (s *countMinSketch) estimate(key string) uint8 {
+	minCount := uint8(sketchMaxCount)
+	for i := range s.rows {
+		idx := maphash.String(s.seeds[i], key) & s.mask
+		minCount = min(minCount, s.rows[i][idx])
+	}
+	return minCount
+}
+
+// age halves every counter so old popularity decays
+func (s *countMinSketch) age() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	s.additions /= 2
+}
+
+// clear resets all counters
+func (s *countMinSketch) clear() {
+	for i := range s.rows {
+		clear(s.rows[i])
+	}
+	s.additions = 0
+}
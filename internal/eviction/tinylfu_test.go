@@ -0,0 +1,142 @@
+package eviction
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestTinyLFUStrategy(t *testing.T) {
+	t.Run("AdmitsFrequentKeys", func(t *testing.T) {
+		strategy := NewTinyLFUStrategy(100)
+		for i := 0; i < 100; i++ {
+			strategy.Add(fmt.Sprintf("key%d", i), createTestEntry("value"))
+		}
+		if strategy.Len() != 100 {
+			t.Fatalf("Expected length 100, got %d", strategy.Len())
+		}
+
+		// Make key0..key9 popular
+		for round := 0; round < 5; round++ {
+			for i := 0; i < 10; i++ {
+				strategy.Get(fmt.Sprintf("key%d", i))
+			}
+		}
+
+		// A scan of one-hit keys must not flush the popular ones
+		for i := 0; i < 1000; i++ {
+			_, _, _ = strategy.Add(fmt.Sprintf("scan%d", i), createTestEntry("value"))
+			if strategy.Len() > 100 {
+				t.Fatalf("Length %d exceeds capacity", strategy.Len())
+			}
+		}
+		for i := 0; i < 10; i++ {
+			if !strategy.Contains(fmt.Sprintf("key%d", i)) {
+				t.Errorf("Expected popular key%d to survive scan", i)
+			}
+		}
+	})
+
+	t.Run("AddAlwaysKeepsNewKey", func(t *testing.T) {
+		strategy := NewTinyLFUStrategy(10)
+		for i := 0; i < 50; i++ {
+			key := fmt.Sprintf("key%d", i)
+			evictKey, evictedEntry, evicted := strategy.Add(key, createTestEntry(key))
+			if !strategy.Contains(key) {
+				t.Fatalf("Expected newly added %s to be tracked", key)
+			}
+			if evicted && (evictKey == key || evictedEntry == nil || strategy.Contains(evictKey)) {
+				t.Fatalf("Invalid eviction of %q when adding %s", evictKey, key)
+			}
+		}
+	})
+
+	t.Run("PeekDoesNotCountAccess", func(t *testing.T) {
+		strategy := NewTinyLFUStrategy(10)
+		strategy.Add("key", createTestEntry("value"))
+		before := strategy.sketch.estimate("key")
+		for i := 0; i < 5; i++ {
+			strategy.Peek("key")
+		}
+		if after := strategy.sketch.estimate("key"); after != before {
+			t.Errorf("Expected Peek to leave frequency at %d, got %d", before, after)
+		}
+	})
+
+	t.Run("RemoveAndClear", func(t *testing.T) {
+		strategy := NewTinyLFUStrategy(10)
+		strategy.Add("key1", createTestEntry("value1"))
+		strategy.Add("key2", createTestEntry("value2"))
+		strategy.Get("key2")
+
+		if !strategy.Remove("key2") || strategy.Contains("key2") {
+			t.Error("Expected key2 to be removed")
+		}
+		if strategy.Remove("key2") {
+			t.Error("Expected second remove to report false")
+		}
+
+		strategy.Clear()
+		if strategy.Len() != 0 || len(strategy.Victims(10)) != 0 {
+			t.Error("Expected strategy to be empty after Clear")
+		}
+	})
+}
+
+func TestCountMinSketchAging(t *testing.T) {
+	sketch := newCountMinSketch(16)
+	for i := 0; i < 10; i++ {
+		sketch.increment("hot")
+	}
+	if est := sketch.estimate("hot"); est < 10 {
+		t.Fatalf("Expected estimate of at least 10, got %d", est)
+	}
+
+	sketch.age()
+	if est := sketch.estimate("hot"); est < 5 || est > 7 {
+		t.Errorf("Expected aging to halve the estimate, got %d", est)
+	}
+}
+
+// hitRate replays trace against strategy, adding keys on miss
+func hitRate(strategy Strategy, trace []string) float64 {
+	hits := 0
+	for _, key := range trace {
+		if _, found := strategy.Get(key); found {
+			hits++
+			continue
+		}
+		strategy.Add(key, createTestEntry(key))
+	}
+	return float64(hits) / float64(len(trace))
+}
+
+func TestTinyLFUHitRateZipf(t *testing.T) {
+	const (
+		capacity = 500
+		keySpace = 50000
+		accesses = 200000
+	)
+
+	// Zipfian popularity whose hot set changes halfway through, so a policy
+	// also has to let go of keys that used to be popular
+	rng := rand.New(rand.NewSource(42))
+	zipf := rand.NewZipf(rng, 1.01, 1, keySpace-1)
+	trace := make([]string, accesses)
+	for i := range trace {
+		phase := i * 2 / accesses
+		trace[i] = fmt.Sprintf("phase%d-key%d", phase, zipf.Uint64())
+	}
+
+	tinyLFU := hitRate(NewTinyLFUStrategy(capacity), trace)
+	lfu := hitRate(NewLFUStrategy(capacity), trace)
+	lru := hitRate(NewLRUStrategy(capacity), trace)
+	t.Logf("Zipf hit rates: TinyLFU=%.3f LFU=%.3f LRU=%.3f", tinyLFU, lfu, lru)
+
+	if tinyLFU <= lfu {
+		t.Errorf("Expected TinyLFU hit rate %.3f to beat LFU %.3f", tinyLFU, lfu)
+	}
+	if tinyLFU <= lru {
+		t.Errorf("Expected TinyLFU hit rate %.3f to beat LRU %.3f", tinyLFU, lru)
+	}
+}
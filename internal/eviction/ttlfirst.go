@@ -0,0 +1,243 @@
+package eviction
+
+import (
+	"container/heap"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+)
+
+// ExpiryTracker is implemented by strategies that order entries by expiration
+// Callers that change an entry's ExpiresAt in place must call Reindex afterwards
+type ExpiryTracker interface {
+	// Reindex re-reads the expiration of key and updates its eviction position
+	Reindex(key string)
+}
+
+// TTLFirstStrategy evicts the entry closest to expiration first
+// Expired and soonest-to-expire entries are evicted before anything else;
+// entries without a TTL are only evicted, by the base policy, once no entry
+// with a TTL is left
+type TTLFirstStrategy struct {
+	base      Strategy
+	deadlines deadlineHeap
+	items     map[string]*deadlineItem
+	mutex     sync.Mutex
+	instr     *Instrumentation
+}
+
+// NewTTLFirstStrategy creates an expiry-aware strategy on top of base
+// base decides the order among entries that never expire
+func NewTTLFirstStrategy(base Strategy) *TTLFirstStrategy {
+	return &TTLFirstStrategy{
+		base:  base,
+		items: make(map[string]*deadlineItem),
+	}
+}
+
+// Add adds an entry, evicting the soonest-to-expire entry if capacity is exceeded
+func (t *TTLFirstStrategy) Add(key string, entry *entry.Entry) (string, *entry.Entry, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.base.Contains(key) {
+		t.base.Add(key, entry)
+		t.track(key, entry)
+		return "", nil, false
+	}
+
+	if t.base.Len() >= t.base.Capacity() && t.deadlines.Len() > 0 {
+		start := t.instr.selectStart()
+		victim := t.deadlines[0].key
+		t.instr.selectDone(start)
+		evictedEntry, _ := t.base.Peek(victim)
+		t.base.Remove(victim)
+		t.untrack(victim)
+
+		t.base.Add(key, entry)
+		t.track(key, entry)
+		return victim, evictedEntry, true
+	}
+
+	evictKey, evictedEntry, evicted := t.base.Add(key, entry)
+	if evicted {
+		t.untrack(evictKey)
+	}
+	t.track(key, entry)
+	return evictKey, evictedEntry, evicted
+}
+
+// SetInstrumentation installs instrumentation callbacks on this strategy and
+// its base; the base reports admissions, promotions and its own victim selection
+func (t *TTLFirstStrategy) SetInstrumentation(instr *Instrumentation) {
+	t.instr = instr
+	if instrumented, ok := t.base.(Instrumented); ok {
+		instrumented.SetInstrumentation(instr)
+	}
+}
+
+// Get retrieves an entry and lets the base policy record the access
+func (t *TTLFirstStrategy) Get(key string) (*entry.Entry, bool) {
+	return t.base.Get(key)
+}
+
+// Remove removes an entry from the tracker
+func (t *TTLFirstStrategy) Remove(key string) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.untrack(key)
+	return t.base.Remove(key)
+}
+
+// Contains checks if a key exists in the tracker
+func (t *TTLFirstStrategy) Contains(key string) bool {
+	return t.base.Contains(key)
+}
+
+// Keys returns all keys currently tracked
+func (t *TTLFirstStrategy) Keys() []string {
+	return t.base.Keys()
+}
+
+// Len returns the number of entries currently tracked
+func (t *TTLFirstStrategy) Len() int {
+	return t.base.Len()
+}
+
+// Clear removes all entries from the tracker
+func (t *TTLFirstStrategy) Clear() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.base.Clear()
+	t.deadlines = nil
+	t.items = make(map[string]*deadlineItem)
+}
+
+// Capacity returns the maximum number of entries this strategy can hold
+func (t *TTLFirstStrategy) Capacity() int {
+	return t.base.Capacity()
+}
+
+// Resize sets the maximum number of entries on the base strategy
+// It is a no-op if the base strategy cannot be resized
+func (t *TTLFirstStrategy) Resize(capacity int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if resizer, ok := t.base.(Resizer); ok {
+		resizer.Resize(capacity)
+	}
+}
+
+// Peek retrieves an entry without updating its position in the eviction order
+func (t *TTLFirstStrategy) Peek(key string) (*entry.Entry, bool) {
+	return t.base.Peek(key)
+}
+
+// Victims returns up to n keys in eviction order: entries with a TTL by
+// deadline, followed by entries without a TTL in the base policy's order
+func (t *TTLFirstStrategy) Victims(n int) []string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if n <= 0 {
+		return []string{}
+	}
+
+	expiring := make(deadlineHeap, len(t.deadlines))
+	copy(expiring, t.deadlines)
+	sort.Slice(expiring, func(i, j int) bool { return expiring[i].deadline.Before(expiring[j].deadline) })
+
+	victims := make([]string, 0, min(n, t.base.Len()))
+	for _, item := range expiring {
+		if len(victims) == n {
+			return victims
+		}
+		victims = append(victims, item.key)
+	}
+
+	for _, key := range t.base.Victims(n + len(expiring)) {
+		if len(victims) == n {
+			break
+		}
+		if _, hasTTL := t.items[key]; !hasTTL {
+			victims = append(victims, key)
+		}
+	}
+	return victims
+}
+
+// Reindex re-reads the expiration of key after it was changed in place
+func (t *TTLFirstStrategy) Reindex(key string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if entry, found := t.base.Peek(key); found {
+		t.track(key, entry)
+	}
+}
+
+// track records or updates the deadline of key (internal method, assumes lock is held)
+func (t *TTLFirstStrategy) track(key string, entry *entry.Entry) {
+	expiresAt := entry.Expiry()
+	if expiresAt == nil {
+		t.untrack(key)
+		return
+	}
+
+	if item, exists := t.items[key]; exists {
+		item.deadline = *expiresAt
+		heap.Fix(&t.deadlines, item.index)
+		return
+	}
+
+	item := &deadlineItem{key: key, deadline: *expiresAt}
+	t.items[key] = item
+	heap.Push(&t.deadlines, item)
+}
+
+// untrack forgets the deadline of key (internal method, assumes lock is held)
+func (t *TTLFirstStrategy) untrack(key string) {
+	if item, exists := t.items[key]; exists {
+		heap.Remove(&t.deadlines, item.index)
+		delete(t.items, key)
+	}
+}
+
+// deadlineItem is a heap entry for a key with an expiration
+type deadlineItem struct {
+	key      string
+	deadline time.Time
+	index    int
+}
+
+// deadlineHeap is a min-heap of keys ordered by expiration
+type deadlineHeap []*deadlineItem
+
+func (h deadlineHeap) Len() int           { return len(h) }
+func (h deadlineHeap) Less(i, j int) bool { return h[i].deadline.Before(h[j].deadline) }
+
+func (h deadlineHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *deadlineHeap) Push(x any) {
+	item := x.(*deadlineItem)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *deadlineHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return item
+}
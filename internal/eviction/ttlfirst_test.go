@@ -0,0 +1,99 @@
+package eviction
+
+import (
+	"testing"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+)
+
+func TestTTLFirstStrategy(t *testing.T) {
+	t.Run("EvictsSoonestToExpire", func(t *testing.T) {
+		strategy := NewTTLFirstStrategy(NewLRUStrategy(3))
+		strategy.Add("forever", entry.NewWithoutTTL("value"))
+		strategy.Add("long", entry.New("value", time.Hour))
+		strategy.Add("short", entry.New("value", time.Minute))
+
+		evictKey, _, evicted := strategy.Add("new", entry.New("value", 30*time.Minute))
+		if !evicted || evictKey != "short" {
+			t.Fatalf("Expected short to be evicted, got %q (evicted=%v)", evictKey, evicted)
+		}
+
+		evictKey, _, _ = strategy.Add("new2", entry.New("value", 2*time.Hour))
+		if evictKey != "new" {
+			t.Fatalf("Expected new to be evicted next, got %q", evictKey)
+		}
+	})
+
+	t.Run("ExpiredBeforeLive", func(t *testing.T) {
+		strategy := NewTTLFirstStrategy(NewLRUStrategy(2))
+		strategy.Add("live", entry.New("value", time.Hour))
+		strategy.Add("expired", entry.New("value", time.Nanosecond))
+		time.Sleep(time.Millisecond)
+
+		evictKey, _, _ := strategy.Add("new", entry.NewWithoutTTL("value"))
+		if evictKey != "expired" {
+			t.Fatalf("Expected expired entry to be evicted, got %q", evictKey)
+		}
+	})
+
+	t.Run("FallsBackToBasePolicy", func(t *testing.T) {
+		strategy := NewTTLFirstStrategy(NewLRUStrategy(2))
+		strategy.Add("key1", entry.NewWithoutTTL("value"))
+		strategy.Add("key2", entry.NewWithoutTTL("value"))
+		strategy.Get("key1")
+
+		evictKey, _, _ := strategy.Add("key3", entry.NewWithoutTTL("value"))
+		if evictKey != "key2" {
+			t.Fatalf("Expected LRU fallback to evict key2, got %q", evictKey)
+		}
+	})
+
+	t.Run("ReindexAfterDeadlineChange", func(t *testing.T) {
+		strategy := NewTTLFirstStrategy(NewLRUStrategy(3))
+		strategy.Add("a", entry.New("value", time.Minute))
+		strategy.Add("b", entry.New("value", time.Hour))
+		strategy.Add("c", entry.NewWithoutTTL("value"))
+
+		// Push a's deadline past b's
+		e, _ := strategy.Peek("a")
+		e.UpdateExpiry(2 * time.Hour)
+		strategy.Reindex("a")
+
+		victims := strategy.Victims(3)
+		expected := []string{"b", "a", "c"}
+		for i, key := range expected {
+			if victims[i] != key {
+				t.Fatalf("Expected victims %v, got %v", expected, victims)
+			}
+		}
+
+		// Removing the TTL moves the entry behind everything that expires
+		e, _ = strategy.Peek("b")
+		e.UpdateExpiry(0)
+		strategy.Reindex("b")
+
+		evictKey, _, _ := strategy.Add("d", entry.NewWithoutTTL("value"))
+		if evictKey != "a" {
+			t.Fatalf("Expected a to be evicted after b lost its TTL, got %q", evictKey)
+		}
+	})
+
+	t.Run("RemoveAndClear", func(t *testing.T) {
+		strategy := NewTTLFirstStrategy(NewLRUStrategy(2))
+		strategy.Add("a", entry.New("value", time.Minute))
+		strategy.Add("b", entry.New("value", time.Hour))
+
+		strategy.Remove("a")
+		strategy.Add("c", entry.NewWithoutTTL("value"))
+		evictKey, _, _ := strategy.Add("d", entry.NewWithoutTTL("value"))
+		if evictKey != "b" {
+			t.Fatalf("Expected b to be evicted after a was removed, got %q", evictKey)
+		}
+
+		strategy.Clear()
+		if strategy.Len() != 0 || len(strategy.Victims(5)) != 0 {
+			t.Error("Expected strategy to be empty after Clear")
+		}
+	})
+}
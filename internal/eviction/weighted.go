@@ -0,0 +1,206 @@
+package eviction
+
+import (
+	"sync"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+)
+
+// weightedVictimBatch is the number of victims first requested when an entry
+// exceeds the weight limit; each further request doubles it
+const weightedVictimBatch = 8
+
+// Evicted is an entry removed by a strategy to make room for another
+type Evicted struct {
+	Key   string
+	Entry *entry.Entry
+}
+
+// WeightedStrategy extends Strategy with a bound on the total weight of entries
+type WeightedStrategy interface {
+	Strategy
+
+	// AddWeighted adds an entry with the given weight and evicts entries, in the
+	// base policy's order, until both the entry count and the total weight fit.
+	// Returns every evicted entry. The added entry itself is never evicted, so an
+	// entry heavier than MaxWeight is kept on its own
+	AddWeighted(key string, entry *entry.Entry, weight int64) []Evicted
+
+	// Weight returns the total weight of the tracked entries
+	Weight() int64
+
+	// MaxWeight returns the maximum total weight
+	MaxWeight() int64
+}
+
+// weightedStrategy bounds the total weight of entries tracked by a base strategy
+type weightedStrategy struct {
+	base      Strategy
+	weights   map[string]int64
+	total     int64
+	maxWeight int64
+	mutex     sync.Mutex
+}
+
+// NewWeightedStrategy wraps base so the total weight of its entries stays within maxWeight
+// base still enforces its own entry-count capacity
+func NewWeightedStrategy(base Strategy, maxWeight int64) WeightedStrategy {
+	return &weightedStrategy{
+		base:      base,
+		weights:   make(map[string]int64),
+		maxWeight: maxWeight,
+	}
+}
+
+// Add adds an entry weighted by its stored size
+// Only the first eviction is reported; use AddWeighted to observe all of them
+func (w *weightedStrategy) Add(key string, entry *entry.Entry) (string, *entry.Entry, bool) {
+	evicted := w.AddWeighted(key, entry, int64(entry.Size()))
+	if len(evicted) == 0 {
+		return "", nil, false
+	}
+	return evicted[0].Key, evicted[0].Entry, true
+}
+
+// AddWeighted adds an entry and evicts until the weight limit is met
+func (w *weightedStrategy) AddWeighted(key string, entry *entry.Entry, weight int64) []Evicted {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.total -= w.weights[key] // Replacing an entry releases its old weight
+
+	var evicted []Evicted
+	if multi, ok := w.base.(MultiEvictor); ok {
+		evicted = multi.AddEvicting(key, entry)
+	} else if evictKey, evictedEntry, ok := w.base.Add(key, entry); ok {
+		evicted = append(evicted, Evicted{Key: evictKey, Entry: evictedEntry})
+	}
+	for _, e := range evicted {
+		w.forget(e.Key)
+	}
+
+	w.weights[key] = weight
+	w.total += weight
+	if w.total <= w.maxWeight {
+		return evicted
+	}
+
+	// Victims are requested in growing batches, so making room for one entry
+	// costs about as much as the entries it evicts rather than the whole order.
+	// Keys that stay, the added one and any the base refuses to remove, are
+	// requested again in front of each batch
+	kept := map[string]struct{}{key: {}}
+	for batch := weightedVictimBatch; w.total > w.maxWeight; batch *= 2 {
+		want := len(kept) + batch
+		victims := w.base.Victims(want)
+		for _, victim := range victims {
+			if w.total <= w.maxWeight {
+				break
+			}
+			if _, ok := kept[victim]; ok {
+				continue
+			}
+			victimEntry, _ := w.base.Peek(victim)
+			if w.base.Remove(victim) {
+				w.forget(victim)
+				evicted = append(evicted, Evicted{Key: victim, Entry: victimEntry})
+			} else {
+				kept[victim] = struct{}{}
+			}
+		}
+		if len(victims) < want {
+			break // Nothing left to evict
+		}
+	}
+	return evicted
+}
+
+// AddEvicting adds an entry weighted by its stored size and returns all evictions
+func (w *weightedStrategy) AddEvicting(key string, entry *entry.Entry) []Evicted {
+	return w.AddWeighted(key, entry, int64(entry.Size()))
+}
+
+// Get retrieves an entry and lets the base policy record the access
+func (w *weightedStrategy) Get(key string) (*entry.Entry, bool) {
+	return w.base.Get(key)
+}
+
+// Remove removes an entry and releases its weight
+func (w *weightedStrategy) Remove(key string) bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.forget(key)
+	return w.base.Remove(key)
+}
+
+// Contains checks if a key exists in the tracker
+func (w *weightedStrategy) Contains(key string) bool {
+	return w.base.Contains(key)
+}
+
+// Keys returns all keys currently tracked
+func (w *weightedStrategy) Keys() []string {
+	return w.base.Keys()
+}
+
+// Len returns the number of entries currently tracked
+func (w *weightedStrategy) Len() int {
+	return w.base.Len()
+}
+
+// Clear removes all entries and resets the total weight
+func (w *weightedStrategy) Clear() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.base.Clear()
+	w.weights = make(map[string]int64)
+	w.total = 0
+}
+
+// Capacity returns the maximum number of entries this strategy can hold
+func (w *weightedStrategy) Capacity() int {
+	return w.base.Capacity()
+}
+
+// Peek retrieves an entry without updating its position in the eviction order
+func (w *weightedStrategy) Peek(key string) (*entry.Entry, bool) {
+	return w.base.Peek(key)
+}
+
+// Victims returns up to n keys in the base policy's eviction order
+func (w *weightedStrategy) Victims(n int) []string {
+	return w.base.Victims(n)
+}
+
+// Weight returns the total weight of the tracked entries
+func (w *weightedStrategy) Weight() int64 {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.total
+}
+
+// MaxWeight returns the maximum total weight
+func (w *weightedStrategy) MaxWeight() int64 {
+	return w.maxWeight
+}
+
+// Base returns the wrapped strategy
+func (w *weightedStrategy) Base() Strategy {
+	return w.base
+}
+
+// Reindex forwards deadline changes to an expiry-aware base strategy
+func (w *weightedStrategy) Reindex(key string) {
+	if tracker, ok := w.base.(ExpiryTracker); ok {
+		tracker.Reindex(key)
+	}
+}
+
+// forget releases the weight of key (internal method, assumes lock is held)
+func (w *weightedStrategy) forget(key string) {
+	w.total -= w.weights[key]
+	delete(w.weights, key)
+}
@@ -0,0 +1,168 @@
+package eviction
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+)
+
+func TestWeightedStrategy(t *testing.T) {
+	testCases := []struct {
+		name     string
+		base     Strategy
+		touch    func(s Strategy)
+		expected []string
+	}{
+		{
+			name:     "LRU",
+			base:     NewLRUStrategy(100),
+			touch:    func(s Strategy) { s.Get("a") },
+			expected: []string{"b", "c"},
+		},
+		{
+			name: "LFU",
+			base: NewLFUStrategy(100),
+			touch: func(s Strategy) {
+				s.Get("a")
+				s.Get("c")
+				s.Get("c")
+			},
+			expected: []string{"b", "a"},
+		},
+		{
+			name:     "FIFO",
+			base:     NewFIFOStrategy(100),
+			touch:    func(s Strategy) { s.Get("a") },
+			expected: []string{"a", "b"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			strategy := NewWeightedStrategy(tc.base, 100)
+			for _, key := range []string{"a", "b", "c"} {
+				if evicted := strategy.AddWeighted(key, createTestEntry(key), 30); len(evicted) != 0 {
+					t.Fatalf("Expected no eviction below MaxWeight, got %v", evicted)
+				}
+			}
+			tc.touch(strategy)
+
+			if strategy.Weight() != 90 {
+				t.Fatalf("Expected weight 90, got %d", strategy.Weight())
+			}
+
+			// A heavy entry must push out several lighter ones
+			evicted := strategy.AddWeighted("heavy", createTestEntry("heavy"), 60)
+			if len(evicted) != len(tc.expected) {
+				t.Fatalf("Expected %d evictions, got %v", len(tc.expected), evicted)
+			}
+			for i, key := range tc.expected {
+				if evicted[i].Key != key || evicted[i].Entry == nil {
+					t.Errorf("Expected eviction %d to be %s, got %+v", i, key, evicted[i])
+				}
+				if strategy.Contains(key) {
+					t.Errorf("Expected %s to be removed", key)
+				}
+			}
+
+			if !strategy.Contains("heavy") || strategy.Weight() != 90 {
+				t.Errorf("Expected heavy entry tracked with total weight 90, got %d", strategy.Weight())
+			}
+		})
+	}
+}
+
+func TestWeightedStrategyReplaceAndRemove(t *testing.T) {
+	strategy := NewWeightedStrategy(NewLRUStrategy(100), 100)
+	strategy.AddWeighted("a", createTestEntry("a"), 40)
+	strategy.AddWeighted("b", createTestEntry("b"), 40)
+
+	// Replacing a releases its previous weight before checking the limit
+	if evicted := strategy.AddWeighted("a", createTestEntry("a2"), 60); len(evicted) != 0 {
+		t.Fatalf("Expected replacement to fit, got %v", evicted)
+	}
+	if strategy.Weight() != 100 {
+		t.Fatalf("Expected weight 100, got %d", strategy.Weight())
+	}
+
+	strategy.Remove("b")
+	if strategy.Weight() != 60 {
+		t.Fatalf("Expected weight 60 after remove, got %d", strategy.Weight())
+	}
+
+	// An oversized entry evicts everything else but is kept itself
+	evicted := strategy.AddWeighted("huge", createTestEntry("huge"), 500)
+	if len(evicted) != 1 || evicted[0].Key != "a" || !strategy.Contains("huge") {
+		t.Fatalf("Expected only a to be evicted for oversized entry, got %v", evicted)
+	}
+
+	strategy.Clear()
+	if strategy.Weight() != 0 || strategy.Len() != 0 {
+		t.Error("Expected empty strategy after Clear")
+	}
+}
+
+func TestWeightedStrategyCountLimit(t *testing.T) {
+	strategy := NewStrategy(Config{Type: FIFO, Capacity: 2, MaxWeight: 1000})
+	weighted, ok := strategy.(WeightedStrategy)
+	if !ok {
+		t.Fatal("Expected NewStrategy to return a WeightedStrategy when MaxWeight is set")
+	}
+
+	weighted.AddWeighted("a", createTestEntry("a"), 10)
+	weighted.AddWeighted("b", createTestEntry("b"), 10)
+	evicted := weighted.AddWeighted("c", createTestEntry("c"), 10)
+	if len(evicted) != 1 || evicted[0].Key != "a" {
+		t.Fatalf("Expected count limit to evict a, got %v", evicted)
+	}
+	if weighted.Weight() != 20 {
+		t.Errorf("Expected weight 20, got %d", weighted.Weight())
+	}
+}
+
+// victimCounter records the sizes of the Victims requests made to a strategy
+type victimCounter struct {
+	Strategy
+	requested []int
+}
+
+func (v *victimCounter) Victims(n int) []string {
+	v.requested = append(v.requested, n)
+	return v.Strategy.Victims(n)
+}
+
+func TestWeightedStrategyRequestsVictimsInBatches(t *testing.T) {
+	base := &victimCounter{Strategy: NewLRUStrategy(10000)}
+	strategy := NewWeightedStrategy(base, 5000)
+	for i := range 5000 {
+		strategy.AddWeighted(fmt.Sprintf("key%d", i), entry.New(i, 0), 1)
+	}
+
+	// Making room for one entry only looks at the front of the order
+	base.requested = nil
+	evicted := strategy.AddWeighted("heavy", entry.New("x", 0), 3)
+	if len(evicted) != 3 || evicted[0].Key != "key0" || evicted[2].Key != "key2" {
+		t.Fatalf("Expected the 3 oldest entries evicted, got %v", evicted)
+	}
+	if len(base.requested) != 1 || base.requested[0] > 2*weightedVictimBatch {
+		t.Errorf("Expected one small Victims request, got %v", base.requested)
+	}
+
+	// Larger overflows grow the batches until the weight fits
+	base.requested = nil
+	evicted = strategy.AddWeighted("heavier", entry.New("x", 0), 100)
+	if len(evicted) != 100 || strategy.Weight() != 5000 {
+		t.Errorf("Expected 100 evictions and a full weight, got %d and %d", len(evicted), strategy.Weight())
+	}
+	if len(base.requested) < 2 || slices.Max(base.requested) > 2*128 {
+		t.Errorf("Expected growing batches, got %v", base.requested)
+	}
+
+	// An entry heavier than the limit evicts everything else and stays
+	evicted = strategy.AddWeighted("huge", entry.New("x", 0), 6000)
+	if !strategy.Contains("huge") || strategy.Len() != 1 || len(evicted) == 0 {
+		t.Errorf("Expected only the heavy entry to remain, got %d entries", strategy.Len())
+	}
+}
@@ -0,0 +1,80 @@
+// Package hashring implements a consistent hash ring that maps keys to nodes.
+//
+// Each node is placed on the ring at several points (virtual nodes) so keys
+// spread evenly, and adding or removing a node only moves the keys between it
+// and its neighbours.
+package hashring
+
+import (
+	"hash/crc32"
+	"slices"
+	"strconv"
+)
+
+// DefaultReplicas is the number of ring points per node used when none is given
+const DefaultReplicas = 128
+
+// Ring maps keys to nodes by consistent hashing
+// A Ring is immutable, so it is safe for concurrent use; build a new one when
+// the node set changes
+type Ring struct {
+	points []uint32
+	owners map[uint32]string
+	nodes  []string
+}
+
+// New builds a ring of nodes with replicas points per node
+// Duplicate nodes are ignored, and replicas <= 0 uses DefaultReplicas
+func New(replicas int, nodes ...string) *Ring {
+	if replicas <= 0 {
+		replicas = DefaultReplicas
+	}
+
+	unique := slices.Clone(nodes)
+	slices.Sort(unique)
+	unique = slices.Compact(unique)
+
+	r := &Ring{
+		points: make([]uint32, 0, len(unique)*replicas),
+		owners: make(map[uint32]string, len(unique)*replicas),
+		nodes:  unique,
+	}
+	for _, node := range unique {
+		for i := range replicas {
+			point := hash(strconv.Itoa(i) + node)
+			// On a collision the node that sorts first keeps the point, so every
+			// ring built from the same nodes agrees
+			if _, taken := r.owners[point]; taken {
+				continue
+			}
+			r.owners[point] = node
+			r.points = append(r.points, point)
+		}
+	}
+	slices.Sort(r.points)
+	return r
+}
+
+// Get returns the node that owns key, or "" if the ring is empty
+func (r *Ring) Get(key string) string {
+	if len(r.points) == 0 {
+		return ""
+	}
+
+	point := hash(key)
+	i, _ := slices.BinarySearch(r.points, point)
+	if i == len(r.points) {
+		i = 0 // Wrap around to the first point
+	}
+	return r.owners[r.points[i]]
+}
+
+// Nodes returns the ring's nodes in sorted order
+func (r *Ring) Nodes() []string {
+	return slices.Clone(r.nodes)
+}
+
+// hash places s on the ring
+func hash(s string) uint32 {
+	return crc32.ChecksumIEEE([]byte(s))
+}
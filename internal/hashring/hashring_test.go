@@ -0,0 +1,60 @@
+package hashring
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRingIsDeterministic(t *testing.T) {
+	a := New(0, "node-a", "node-b", "node-c")
+	b := New(0, "node-c", "node-a", "node-b", "node-a")
+
+	for i := range 1000 {
+		key := fmt.Sprintf("key-%d", i)
+		if a.Get(key) != b.Get(key) {
+			t.Fatalf("Expected rings with the same nodes to agree on %s", key)
+		}
+	}
+	if nodes := b.Nodes(); len(nodes) != 3 {
+		t.Errorf("Expected duplicate nodes to be ignored, got %v", nodes)
+	}
+}
+
+func TestRingSpreadsKeys(t *testing.T) {
+	r := New(0, "node-a", "node-b", "node-c")
+
+	counts := make(map[string]int)
+	for i := range 30000 {
+		counts[r.Get(fmt.Sprintf("key-%d", i))]++
+	}
+	for _, node := range r.Nodes() {
+		if counts[node] < 7000 || counts[node] > 13000 {
+			t.Errorf("Expected about a third of the keys on %s, got %d", node, counts[node])
+		}
+	}
+}
+
+func TestRingMovesFewKeysWhenNodeAdded(t *testing.T) {
+	before := New(0, "node-a", "node-b", "node-c")
+	after := New(0, "node-a", "node-b", "node-c", "node-d")
+
+	moved := 0
+	for i := range 10000 {
+		key := fmt.Sprintf("key-%d", i)
+		if owner := after.Get(key); owner != before.Get(key) {
+			if owner != "node-d" {
+				t.Fatalf("Expected %s to move only to the new node, moved to %s", key, owner)
+			}
+			moved++
+		}
+	}
+	if moved < 1500 || moved > 3500 {
+		t.Errorf("Expected about a quarter of the keys to move, got %d", moved)
+	}
+}
+
+func TestEmptyRing(t *testing.T) {
+	if owner := New(0).Get("key"); owner != "" {
+		t.Errorf("Expected no owner on an empty ring, got %q", owner)
+	}
+}
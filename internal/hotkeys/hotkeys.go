@@ -0,0 +1,189 @@
+// Package hotkeys estimates the most frequently accessed cache keys in bounded space
+package hotkeys
+
+import (
+	"cmp"
+	"hash/maphash"
+	"math/bits"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// sketchDepth is the number of count-min sketch rows
+	sketchDepth = 4
+
+	// minSketchWidth and widthPerKey size the sketch rows: wider rows make
+	// collisions, which only ever overestimate counts, rarer
+	minSketchWidth = 1024
+	widthPerKey    = 64
+
+	// agingFactor sets how many recordings, in multiples of the sketch width,
+	// pass before all counts are halved so past popularity fades
+	agingFactor = 10
+
+	// candidatesPerKey is how many keys are tracked per key reported, so keys
+	// near the cut-off are not lost to estimation noise
+	candidatesPerKey = 2
+)
+
+// Count is a key with its estimated number of recent accesses
+type Count struct {
+	Key   string
+	Count int64
+}
+
+// Tracker estimates the top keys by access count. Accesses are counted in a
+// count-min sketch with atomic counters; a small candidate set keeps the keys
+// with the highest estimates. Recording a key that is already a candidate or
+// too cold to become one takes no lock
+type Tracker struct {
+	size     int
+	rows     [sketchDepth][]uint32
+	seeds    [sketchDepth]maphash.Seed
+	mask     uint64
+	agingAt  uint64
+	recorded atomic.Uint64
+
+	// threshold is the lowest candidate estimate once the candidate set is full;
+	// keys estimated at or below it are not admitted
+	threshold atomic.Uint32
+	members   sync.Map // candidate key -> struct{}
+
+	mu         sync.Mutex
+	candidates []string
+}
+
+// New creates a tracker able to report the top size keys
+func New(size int) *Tracker {
+	size = max(size, 1)
+	width := uint64(1) << bits.Len(uint(max(minSketchWidth, size*widthPerKey)-1))
+
+	t := &Tracker{
+		size:       size,
+		mask:       width - 1,
+		agingAt:    width * agingFactor,
+		candidates: make([]string, 0, size*candidatesPerKey),
+	}
+	for i := range t.rows {
+		t.rows[i] = make([]uint32, width)
+		t.seeds[i] = maphash.MakeSeed()
+	}
+	return t
+}
+
+// Size returns the number of keys the tracker reports at most
+func (t *Tracker) Size() int {
+	return t.size
+}
+
+// Record counts one access to key
+func (t *Tracker) Record(key string) {
+	estimate := ^uint32(0)
+	for i := range t.rows {
+		idx := maphash.String(t.seeds[i], key) & t.mask
+		estimate = min(estimate, atomic.AddUint32(&t.rows[i][idx], 1))
+	}
+
+	if t.recorded.Add(1)%t.agingAt == 0 {
+		t.age()
+	}
+
+	if estimate <= t.threshold.Load() {
+		return
+	}
+	if _, ok := t.members.Load(key); ok {
+		return
+	}
+	t.admit(key, estimate)
+}
+
+// admit makes key a candidate, displacing the coldest one if the set is full
+func (t *Tracker) admit(key string, estimate uint32) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.members.Load(key); ok {
+		return
+	}
+	if len(t.candidates) < cap(t.candidates) {
+		t.candidates = append(t.candidates, key)
+		t.members.Store(key, struct{}{})
+		if len(t.candidates) == cap(t.candidates) {
+			_, coldest := t.coldest()
+			t.threshold.Store(coldest)
+		}
+		return
+	}
+
+	i, coldest := t.coldest()
+	if estimate > coldest {
+		t.members.Delete(t.candidates[i])
+		t.candidates[i] = key
+		t.members.Store(key, struct{}{})
+		_, coldest = t.coldest()
+	}
+	t.threshold.Store(coldest)
+}
+
+// coldest returns the index and estimate of the candidate with the lowest
+// estimate (assumes t.mu is held and the candidate set is not empty)
+func (t *Tracker) coldest() (int, uint32) {
+	index, lowest := 0, ^uint32(0)
+	for i, key := range t.candidates {
+		if estimate := t.estimate(key); estimate < lowest {
+			index, lowest = i, estimate
+		}
+	}
+	return index, lowest
+}
+
+// estimate returns the sketch's count for key, which may overestimate it
+func (t *Tracker) estimate(key string) uint32 {
+	estimate := ^uint32(0)
+	for i := range t.rows {
+		idx := maphash.String(t.seeds[i], key) & t.mask
+		estimate = min(estimate, atomic.LoadUint32(&t.rows[i][idx]))
+	}
+	return estimate
+}
+
+// age halves every count. Concurrent recordings may be lost, which only
+// makes the estimates slightly lower
+func (t *Tracker) age() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i := range t.rows {
+		for j := range t.rows[i] {
+			atomic.StoreUint32(&t.rows[i][j], atomic.LoadUint32(&t.rows[i][j])/2)
+		}
+	}
+	if len(t.candidates) == cap(t.candidates) {
+		_, coldest := t.coldest()
+		t.threshold.Store(coldest)
+	}
+}
+
+// Top returns up to n keys with the highest estimated counts, most accessed first
+// n is capped at the tracker's size
+func (t *Tracker) Top(n int) []Count {
+	t.mu.Lock()
+	counts := make([]Count, 0, len(t.candidates))
+	for _, key := range t.candidates {
+		if estimate := t.estimate(key); estimate > 0 {
+			counts = append(counts, Count{Key: key, Count: int64(estimate)})
+		}
+	}
+	t.mu.Unlock()
+
+	slices.SortFunc(counts, func(a, b Count) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Key, b.Key)
+	})
+	return counts[:min(max(n, 0), t.size, len(counts))]
+}
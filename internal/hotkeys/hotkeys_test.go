@@ -0,0 +1,114 @@
+package hotkeys
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// zipfKeys draws n keys from a Zipfian distribution over keys keys, returning
+// the draws and the true count of each key
+func zipfKeys(n, keys int) ([]string, map[string]int) {
+	zipf := rand.NewZipf(rand.New(rand.NewPCG(1, 2)), 1.1, 1, uint64(keys-1))
+	draws := make([]string, n)
+	counts := make(map[string]int)
+	for i := range draws {
+		draws[i] = fmt.Sprintf("key%d", zipf.Uint64())
+		counts[draws[i]]++
+	}
+	return draws, counts
+}
+
+// trueTop returns the n keys with the highest counts
+func trueTop(counts map[string]int, n int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b string) int { return counts[b] - counts[a] })
+	return keys[:n]
+}
+
+func TestTrackerZipf(t *testing.T) {
+	draws, counts := zipfKeys(50000, 10000)
+	tracker := New(10)
+	for _, key := range draws {
+		tracker.Record(key)
+	}
+
+	top := tracker.Top(10)
+	if len(top) != 10 {
+		t.Fatalf("Expected 10 keys, got %d", len(top))
+	}
+	reported := make([]string, len(top))
+	for i, c := range top {
+		reported[i] = c.Key
+		if i > 0 && c.Count > top[i-1].Count {
+			t.Errorf("Expected keys in descending count order, got %v", top)
+		}
+	}
+	if want := trueTop(counts, 1)[0]; reported[0] != want {
+		t.Errorf("Expected %s to be the hottest key, got %v", want, reported)
+	}
+	for _, key := range trueTop(counts, 5) {
+		if !slices.Contains(reported, key) {
+			t.Errorf("Expected true top key %s in %v", key, reported)
+		}
+	}
+
+	if n := len(tracker.Top(3)); n != 3 {
+		t.Errorf("Expected 3 keys, got %d", n)
+	}
+	if n := len(tracker.Top(100)); n != 10 {
+		t.Errorf("Expected Top to be capped at the tracker size, got %d", n)
+	}
+}
+
+func TestTrackerAging(t *testing.T) {
+	tracker := New(2)
+	for range 1000 {
+		tracker.Record("old")
+	}
+
+	// Enough new traffic to halve the counts several times
+	for i := range int(tracker.agingAt) * 4 {
+		tracker.Record("new")
+		if i%2 == 0 {
+			tracker.Record(fmt.Sprintf("cold%d", i%64))
+		}
+	}
+
+	top := tracker.Top(1)
+	if len(top) != 1 || top[0].Key != "new" {
+		t.Fatalf("Expected new to be the hottest key, got %v", top)
+	}
+	for _, c := range tracker.Top(2) {
+		if c.Key == "old" && c.Count >= 1000 {
+			t.Errorf("Expected the old key's count to have decayed, got %d", c.Count)
+		}
+	}
+}
+
+func TestTrackerConcurrent(t *testing.T) {
+	draws, counts := zipfKeys(40000, 1000)
+	tracker := New(5)
+
+	var wg sync.WaitGroup
+	for w := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := w; i < len(draws); i += 4 {
+				tracker.Record(draws[i])
+			}
+		}()
+	}
+	wg.Wait()
+
+	top := tracker.Top(5)
+	if want := trueTop(counts, 1)[0]; len(top) == 0 || top[0].Key != want {
+		t.Errorf("Expected %s to be the hottest key, got %v", want, top)
+	}
+}
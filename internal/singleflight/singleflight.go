@@ -65,6 +65,14 @@ func (g *Group[K, V]) Do(key K, fn func() (V, error)) (v V, err error, shared bo
 //
 // The returned channel will not be closed.
 func (g *Group[K, V]) DoChan(key K, fn func() (V, error)) <-chan Result[V] {
+	ch, _ := g.DoChanJoined(key, fn)
+	return ch
+}
+
+// DoChanJoined is like DoChan but also reports whether the caller joined
+// an execution that was already in flight for key. When joined is true,
+// fn will not be called.
+func (g *Group[K, V]) DoChanJoined(key K, fn func() (V, error)) (<-chan Result[V], bool) {
 	ch := make(chan Result[V], 1)
 	g.mu.Lock()
 	if g.m == nil {
@@ -74,7 +82,7 @@ func (g *Group[K, V]) DoChan(key K, fn func() (V, error)) <-chan Result[V] {
 		c.dups++
 		c.chans = append(c.chans, ch)
 		g.mu.Unlock()
-		return ch
+		return ch, true
 	}
 	c := &call[V]{chans: []chan<- Result[V]{ch}}
 	c.wg.Add(1)
@@ -83,7 +91,7 @@ func (g *Group[K, V]) DoChan(key K, fn func() (V, error)) <-chan Result[V] {
 
 	go g.doCall(c, key, fn)
 
-	return ch
+	return ch, false
 }
 
 // doCall handles the single call for a key.
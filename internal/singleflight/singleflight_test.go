@@ -195,6 +195,35 @@ func TestSingleflightDoChan(t *testing.T) {
 	}
 }
 
+func TestSingleflightDoChanJoined(t *testing.T) {
+	g := &Group[string, int]{}
+
+	release := make(chan struct{})
+	first, joined := g.DoChanJoined("key", func() (int, error) {
+		<-release
+		return 1, nil
+	})
+	if joined {
+		t.Fatal("Expected first caller to start a new execution")
+	}
+
+	second, joined := g.DoChanJoined("key", func() (int, error) {
+		t.Error("Joined caller's function should not be called")
+		return 2, nil
+	})
+	if !joined {
+		t.Fatal("Expected second caller to join the in-flight execution")
+	}
+
+	close(release)
+	for _, ch := range []<-chan Result[int]{first, second} {
+		result := <-ch
+		if result.Val != 1 || !result.Shared {
+			t.Fatalf("Expected shared value 1, got %d (shared=%v)", result.Val, result.Shared)
+		}
+	}
+}
+
 func TestSingleflightDoContext(t *testing.T) {
 	g := &Group[string, int]{}
 
@@ -0,0 +1,171 @@
+// Package snapshot encodes cache entries into a checksummed file format used to
+// persist in-memory stores across restarts.
+//
+// A snapshot starts with a fixed header: an 8-byte magic and version, the body
+// length as a big-endian uint64 and a CRC-32 (Castagnoli) of the body as a
+// big-endian uint32. The body is a JSON array of entries in the layout of package
+// envelope, each with its key. Values are encoded as JSON by default, so like the
+// Bolt store they are restored as JSON types, with byte slices and compressed
+// values kept as []byte. WriteCodec encodes them with another codec instead,
+// whose name is stored with each value.
+package snapshot
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/1mb-dev/obcache-go/v2/internal/envelope"
+	"github.com/1mb-dev/obcache-go/v2/pkg/codec"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+)
+
+// magic identifies a snapshot; the last byte is the format version
+var magic = [8]byte{'O', 'B', 'C', 'S', 'N', 'A', 'P', 1}
+
+// headerSize is the size of the magic, body length and checksum
+const headerSize = len(magic) + 8 + 4
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ErrCorrupt is returned when a snapshot has an unknown header, is truncated or
+// fails its checksum
+var ErrCorrupt = errors.New("snapshot is corrupt")
+
+// Record is a cache entry and its key
+type Record struct {
+	Key   string
+	Entry *entry.Entry
+}
+
+// serializedRecord is a Record as stored in the snapshot body
+type serializedRecord struct {
+	Key string `json:"key"`
+	envelope.Entry
+}
+
+// Write encodes records to w and returns the number written
+// Records whose values cannot be encoded as JSON are skipped
+func Write(w io.Writer, records []Record) (int, error) {
+	return WriteCodec(w, records, nil)
+}
+
+// WriteCodec is like Write but encodes values with c, or as JSON if c is nil
+// Byte slices and compressed values are stored as they are
+func WriteCodec(w io.Writer, records []Record, c codec.Codec) (int, error) {
+	serialized := make([]serializedRecord, 0, len(records))
+	for _, record := range records {
+		e, err := envelope.New(record.Entry, c)
+		if err != nil {
+			continue
+		}
+		serialized = append(serialized, serializedRecord{Key: record.Key, Entry: *e})
+	}
+
+	body, err := json.Marshal(serialized)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	header := make([]byte, 0, headerSize)
+	header = append(header, magic[:]...)
+	header = binary.BigEndian.AppendUint64(header, uint64(len(body)))
+	header = binary.BigEndian.AppendUint32(header, crc32.Checksum(body, crcTable))
+
+	if _, err := w.Write(header); err != nil {
+		return 0, fmt.Errorf("failed to write snapshot header: %w", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return 0, fmt.Errorf("failed to write snapshot body: %w", err)
+	}
+	return len(serialized), nil
+}
+
+// Read decodes the records in a snapshot written by Write or WriteCodec
+// Returns an error wrapping ErrCorrupt if the snapshot is damaged
+func Read(r io.Reader) ([]Record, error) {
+	return ReadCodec(r, nil)
+}
+
+// ReadCodec is like Read but decodes values written with c's name using c,
+// which need not be registered. Other codecs are looked up by name
+func ReadCodec(r io.Reader, c codec.Codec) ([]Record, error) {
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("%w: short header: %v", ErrCorrupt, err)
+	}
+	if !bytes.Equal(header[:len(magic)], magic[:]) {
+		return nil, fmt.Errorf("%w: unknown header", ErrCorrupt)
+	}
+	length := binary.BigEndian.Uint64(header[len(magic):])
+	checksum := binary.BigEndian.Uint32(header[len(magic)+8:])
+
+	body, err := io.ReadAll(io.LimitReader(r, int64(min(length, 1<<62))))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot body: %w", err)
+	}
+	if uint64(len(body)) != length {
+		return nil, fmt.Errorf("%w: body is %d bytes, header says %d", ErrCorrupt, len(body), length)
+	}
+	if crc32.Checksum(body, crcTable) != checksum {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrCorrupt)
+	}
+
+	var serialized []serializedRecord
+	if err := json.Unmarshal(body, &serialized); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+
+	records := make([]Record, 0, len(serialized))
+	for _, s := range serialized {
+		e, err := s.Entry.Entry(c)
+		if err != nil {
+			return nil, fmt.Errorf("%w: key %q: %v", ErrCorrupt, s.Key, err)
+		}
+		records = append(records, Record{Key: s.Key, Entry: e})
+	}
+	return records, nil
+}
+
+// WriteFile writes records to a temporary file next to path, syncs it and
+// renames it over path, so readers see either the old or the new snapshot
+func WriteFile(path string, records []Record) (int, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }() // No-op once renamed
+
+	n, err := Write(tmp, records)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, fmt.Errorf("failed to replace snapshot file: %w", err)
+	}
+	return n, nil
+}
+
+// ReadFile reads the snapshot at path
+func ReadFile(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	return Read(f)
+}
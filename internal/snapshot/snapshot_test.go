@@ -0,0 +1,120 @@
+package snapshot
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	records := []Record{
+		{Key: "user:1", Entry: entry.New("alice", time.Hour)},
+		{Key: "raw", Entry: entry.NewWithoutTTL([]byte{0, 1, 2})},
+		{Key: "count", Entry: entry.NewWithoutTTL(42)},
+	}
+	records[0].Entry.Version = 7
+
+	var buf bytes.Buffer
+	n, err := Write(&buf, records)
+	if err != nil {
+		t.Fatalf("Failed to write snapshot: %v", err)
+	}
+	if n != len(records) {
+		t.Errorf("Expected %d records written, got %d", len(records), n)
+	}
+
+	decoded, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Failed to read snapshot: %v", err)
+	}
+	if len(decoded) != len(records) {
+		t.Fatalf("Expected %d records, got %d", len(records), len(decoded))
+	}
+
+	if decoded[0].Key != "user:1" || decoded[0].Entry.Value != "alice" || decoded[0].Entry.Version != 7 {
+		t.Errorf("Unexpected first record: %q %v", decoded[0].Key, decoded[0].Entry)
+	}
+	if !decoded[0].Entry.HasExpiry() || decoded[0].Entry.TTL() <= 50*time.Minute {
+		t.Errorf("Expected expiry to survive, got %v", decoded[0].Entry.ExpiresAt)
+	}
+	if raw, ok := decoded[1].Entry.Value.([]byte); !ok || !bytes.Equal(raw, []byte{0, 1, 2}) {
+		t.Errorf("Expected byte slice to stay []byte, got %T %v", decoded[1].Entry.Value, decoded[1].Entry.Value)
+	}
+	if decoded[2].Entry.Value != float64(42) {
+		t.Errorf("Expected number to decode as float64, got %T %v", decoded[2].Entry.Value, decoded[2].Entry.Value)
+	}
+}
+
+func TestSnapshotSkipsUnencodableValues(t *testing.T) {
+	records := []Record{
+		{Key: "ok", Entry: entry.NewWithoutTTL("value")},
+		{Key: "func", Entry: entry.NewWithoutTTL(func() {})},
+	}
+
+	var buf bytes.Buffer
+	n, err := Write(&buf, records)
+	if err != nil {
+		t.Fatalf("Failed to write snapshot: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 record written, got %d", n)
+	}
+}
+
+func TestSnapshotDetectsCorruption(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := Write(&buf, []Record{{Key: "key", Entry: entry.NewWithoutTTL("value")}}); err != nil {
+		t.Fatalf("Failed to write snapshot: %v", err)
+	}
+	data := buf.Bytes()
+
+	flipped := bytes.Clone(data)
+	flipped[len(flipped)-2] ^= 0xff
+
+	tests := map[string][]byte{
+		"empty":     nil,
+		"bad magic": append([]byte("NOTASNAP"), data[len(magic):]...),
+		"truncated": data[:len(data)-3],
+		"checksum":  flipped,
+	}
+	for name, corrupt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Read(bytes.NewReader(corrupt)); !errors.Is(err, ErrCorrupt) {
+				t.Errorf("Expected ErrCorrupt, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSnapshotWriteFileReplacesAtomically(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cache.snap")
+
+	if _, err := WriteFile(path, []Record{{Key: "a", Entry: entry.NewWithoutTTL("1")}}); err != nil {
+		t.Fatalf("Failed to write snapshot file: %v", err)
+	}
+	if _, err := WriteFile(path, []Record{{Key: "b", Entry: entry.NewWithoutTTL("2")}}); err != nil {
+		t.Fatalf("Failed to rewrite snapshot file: %v", err)
+	}
+
+	records, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read snapshot file: %v", err)
+	}
+	if len(records) != 1 || records[0].Key != "b" {
+		t.Errorf("Expected the second snapshot, got %v", records)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to list directory: %v", err)
+	}
+	if len(files) != 1 {
+		t.Errorf("Expected temporary files to be removed, found %d files", len(files))
+	}
+}
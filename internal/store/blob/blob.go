@@ -0,0 +1,454 @@
+// Package blob implements a cache store over an S3-compatible object store,
+// for values too large for Redis or process memory.
+//
+// The store talks to the bucket through the small Client interface, so any
+// SDK (minio-go, the AWS SDK, or an in-process fake) can back it with a thin
+// adapter. Requests take hundreds of milliseconds, so the store is best used
+// behind a local tier that serves repeated reads.
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/internal/envelope"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+	"github.com/1mb-dev/obcache-go/v2/pkg/store"
+)
+
+const (
+	// DefaultKeyPrefix is the object key prefix used when none is configured
+	DefaultKeyPrefix = "obcache/"
+
+	// DefaultRequestTimeout bounds each object request
+	DefaultRequestTimeout = 30 * time.Second
+
+	// DefaultDeleteBatchSize is the number of objects removed per DeleteObjects
+	// request, the most S3 accepts
+	DefaultDeleteBatchSize = 1000
+
+	// ExpiresMetadataKey is the user metadata key holding an object's expiration
+	// in Unix milliseconds, so Cleanup can find expired objects without downloading them
+	ExpiresMetadataKey = "obcache-expires-at"
+)
+
+// ErrNotFound is returned, possibly wrapped, by Client methods for objects that do not exist
+var ErrNotFound = errors.New("blob object not found")
+
+// Client is the subset of an S3-compatible API the store uses
+type Client interface {
+	// PutObject uploads data under key with the given user metadata
+	PutObject(ctx context.Context, key string, data []byte, metadata map[string]string) error
+
+	// GetObject downloads the object under key, or returns ErrNotFound
+	GetObject(ctx context.Context, key string) ([]byte, error)
+
+	// StatObject returns the user metadata of the object under key, or ErrNotFound
+	StatObject(ctx context.Context, key string) (map[string]string, error)
+
+	// DeleteObjects removes the objects under keys; missing objects are not an error
+	DeleteObjects(ctx context.Context, keys []string) error
+
+	// ListObjects calls fn with each page of object keys starting with prefix,
+	// stopping with fn's error if it returns one
+	ListObjects(ctx context.Context, prefix string, fn func(keys []string) error) error
+}
+
+// Store implements a cache store over an S3-compatible object store
+// Each entry is one object holding the serialized entry; its expiration is
+// also kept in the object's metadata for Cleanup
+type Store struct {
+	client              Client
+	prefix              string
+	timeout             time.Duration
+	deleteBatchSize     int
+	lifecycleExpiration bool
+	ctx                 context.Context
+	cancel              context.CancelFunc
+
+	mu              sync.RWMutex
+	cleanupCallback store.EvictCallback
+	cleanupDone     chan struct{}
+}
+
+// Config holds blob store configuration
+type Config struct {
+	// Client is the object store client to use
+	Client Client
+
+	// KeyPrefix is prepended to every cache key to form the object key
+	// Default: "obcache/"
+	KeyPrefix string
+
+	// RequestTimeout bounds each object request; listing is bounded only by
+	// the caller's context
+	// Default: 30 seconds
+	RequestTimeout time.Duration
+
+	// DeleteBatchSize is the number of objects removed per DeleteObjects request
+	// Default: 1000
+	DeleteBatchSize int
+
+	// LifecycleExpiration leaves removing expired objects to bucket lifecycle
+	// rules configured for KeyPrefix, so Cleanup does nothing. Expired entries
+	// are still reported as misses until the bucket removes them
+	LifecycleExpiration bool
+
+	// CleanupInterval sets how often expired objects are listed and removed
+	// Default: 0 (no automatic cleanup)
+	CleanupInterval time.Duration
+}
+
+// New creates a blob store
+func New(config *Config) (*Store, error) {
+	if config.Client == nil {
+		return nil, fmt.Errorf("blob client is required")
+	}
+
+	prefix := config.KeyPrefix
+	if prefix == "" {
+		prefix = DefaultKeyPrefix
+	}
+	timeout := config.RequestTimeout
+	if timeout <= 0 {
+		timeout = DefaultRequestTimeout
+	}
+	deleteBatchSize := config.DeleteBatchSize
+	if deleteBatchSize <= 0 {
+		deleteBatchSize = DefaultDeleteBatchSize
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Store{
+		client:              config.Client,
+		prefix:              prefix,
+		timeout:             timeout,
+		deleteBatchSize:     deleteBatchSize,
+		lifecycleExpiration: config.LifecycleExpiration,
+		ctx:                 ctx,
+		cancel:              cancel,
+	}
+
+	if config.CleanupInterval > 0 && !config.LifecycleExpiration {
+		s.startCleanup(config.CleanupInterval)
+	}
+
+	return s, nil
+}
+
+// Get retrieves an entry by key
+func (s *Store) Get(key string) (*entry.Entry, bool) {
+	return s.GetContext(s.ctx, key)
+}
+
+// GetContext downloads the entry for key, reporting a miss if ctx is done first
+// Access times are not written back, since that would upload the whole object
+func (s *Store) GetContext(ctx context.Context, key string) (*entry.Entry, bool) {
+	e, found := s.load(ctx, key)
+	if found {
+		e.Touch()
+	}
+	return e, found
+}
+
+// Peek retrieves an entry by key
+func (s *Store) Peek(key string) (*entry.Entry, bool) {
+	return s.load(s.ctx, key)
+}
+
+// load downloads and deserializes the entry for key
+// Entries past their expiration are misses even if their object still exists
+func (s *Store) load(ctx context.Context, key string) (*entry.Entry, bool) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	data, err := s.client.GetObject(ctx, s.buildKey(key))
+	if err != nil {
+		return nil, false
+	}
+	e, err := envelope.Unmarshal(data, nil)
+	if err != nil || e.IsExpired() {
+		return nil, false
+	}
+	return e, true
+}
+
+// Set stores an entry with the given key
+func (s *Store) Set(key string, e *entry.Entry) error {
+	return s.SetContext(s.ctx, key, e)
+}
+
+// SetContext uploads the entry for key, or returns ctx.Err() if ctx is done first
+func (s *Store) SetContext(ctx context.Context, key string, e *entry.Entry) error {
+	data, err := envelope.Marshal(e, nil)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	if err := s.client.PutObject(ctx, s.buildKey(key), data, objectMetadata(e)); err != nil {
+		return fmt.Errorf("failed to upload blob entry: %w", err)
+	}
+	return nil
+}
+
+// Delete removes an entry by key
+func (s *Store) Delete(key string) error {
+	return s.DeleteContext(s.ctx, key)
+}
+
+// DeleteContext removes the object for key, or returns ctx.Err() if ctx is done first
+func (s *Store) DeleteContext(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	if err := s.client.DeleteObjects(ctx, []string{s.buildKey(key)}); err != nil {
+		return fmt.Errorf("failed to delete blob entry: %w", err)
+	}
+	return nil
+}
+
+// Keys returns all keys under the prefix
+// Expired objects not yet removed by Cleanup are included
+func (s *Store) Keys() []string {
+	keys, err := s.KeysContext(s.ctx)
+	if err != nil {
+		return []string{}
+	}
+	return keys
+}
+
+// KeysContext lists all keys under the prefix, or returns ctx.Err() if ctx is done first
+func (s *Store) KeysContext(ctx context.Context) ([]string, error) {
+	keys := []string{}
+	err := s.client.ListObjects(ctx, s.prefix, func(objectKeys []string) error {
+		for _, objectKey := range objectKeys {
+			keys = append(keys, s.extractKey(objectKey))
+		}
+		return ctx.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Len returns the number of objects under the prefix, listing them all
+func (s *Store) Len() int {
+	return len(s.Keys())
+}
+
+// Clear removes every object under the prefix
+func (s *Store) Clear() error {
+	return s.ClearContext(s.ctx, nil)
+}
+
+// ClearContext removes the objects under the prefix one listing page at a time,
+// in batches of at most DeleteBatchSize, and calls fn (if not nil) with the keys
+// of each batch. When ctx is done it stops with ctx.Err(), leaving the objects
+// not yet listed in place
+func (s *Store) ClearContext(ctx context.Context, fn func(keys []string)) error {
+	return s.client.ListObjects(ctx, s.prefix, func(objectKeys []string) error {
+		for batch := range slices.Chunk(objectKeys, s.deleteBatchSize) {
+			if err := s.deleteObjects(ctx, batch); err != nil {
+				return err
+			}
+			if fn != nil {
+				fn(s.extractKeys(batch))
+			}
+		}
+		return nil
+	})
+}
+
+// Cleanup removes expired objects and returns the number removed
+// It does nothing when expiration is left to bucket lifecycle rules
+func (s *Store) Cleanup() int {
+	removed, _ := s.CleanupContext(s.ctx)
+	return removed
+}
+
+// CleanupContext lists the objects under the prefix, reads each one's
+// expiration from its metadata and removes the expired ones in batches of at
+// most DeleteBatchSize. It returns the number removed before any error,
+// including ctx.Err() when ctx is done first
+func (s *Store) CleanupContext(ctx context.Context) (int, error) {
+	if s.lifecycleExpiration {
+		return 0, nil
+	}
+
+	s.mu.RLock()
+	callback := s.cleanupCallback
+	s.mu.RUnlock()
+
+	removed := 0
+	var expired []string
+	flush := func() error {
+		if len(expired) == 0 {
+			return nil
+		}
+		if err := s.deleteObjects(ctx, expired); err != nil {
+			return err
+		}
+		removed += len(expired)
+		if callback != nil {
+			// The value is not downloaded, so the callback receives nil
+			for _, key := range s.extractKeys(expired) {
+				callback(key, nil)
+			}
+		}
+		expired = expired[:0]
+		return nil
+	}
+
+	err := s.client.ListObjects(ctx, s.prefix, func(objectKeys []string) error {
+		now := time.Now()
+		for _, objectKey := range objectKeys {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if s.isExpired(ctx, objectKey, now) {
+				expired = append(expired, objectKey)
+			}
+			if len(expired) == s.deleteBatchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return removed, err
+	}
+	return removed, flush()
+}
+
+// isExpired reports whether the object's metadata records an expiration before now
+// Objects that cannot be inspected are left alone
+func (s *Store) isExpired(ctx context.Context, objectKey string, now time.Time) bool {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	metadata, err := s.client.StatObject(ctx, objectKey)
+	if err != nil {
+		return false
+	}
+	millis, err := strconv.ParseInt(metadata[ExpiresMetadataKey], 10, 64)
+	if err != nil {
+		return false // No expiration
+	}
+	return now.After(time.UnixMilli(millis))
+}
+
+// UpdateTTL downloads an existing entry and uploads it again with a new expiration
+func (s *Store) UpdateTTL(key string, ttl time.Duration) bool {
+	e, found := s.load(s.ctx, key)
+	if !found {
+		return false
+	}
+	e.UpdateExpiry(ttl)
+	return s.Set(key, e) == nil
+}
+
+// SetCleanupCallback sets the callback for objects removed by Cleanup
+func (s *Store) SetCleanupCallback(callback store.EvictCallback) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cleanupCallback = callback
+}
+
+// Ping checks that the bucket answers a request for an object
+func (s *Store) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	_, err := s.client.StatObject(ctx, s.prefix)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// Close stops automatic cleanup and cancels requests in flight
+// Objects are kept, since other instances share them
+func (s *Store) Close() error {
+	s.cancel()
+	if s.cleanupDone != nil {
+		<-s.cleanupDone
+	}
+	return nil
+}
+
+// startCleanup removes expired objects every interval until Close
+func (s *Store) startCleanup(interval time.Duration) {
+	s.cleanupDone = make(chan struct{})
+
+	go func() {
+		defer close(s.cleanupDone)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.Cleanup()
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// deleteObjects removes objectKeys in one request bounded by the request timeout
+func (s *Store) deleteObjects(ctx context.Context, objectKeys []string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	if err := s.client.DeleteObjects(ctx, objectKeys); err != nil {
+		return fmt.Errorf("failed to delete blob entries: %w", err)
+	}
+	return nil
+}
+
+// buildKey creates the object key with prefix
+func (s *Store) buildKey(key string) string {
+	return s.prefix + key
+}
+
+// extractKey removes the prefix from an object key
+func (s *Store) extractKey(objectKey string) string {
+	return strings.TrimPrefix(objectKey, s.prefix)
+}
+
+// extractKeys removes the prefix from each object key
+func (s *Store) extractKeys(objectKeys []string) []string {
+	keys := make([]string, len(objectKeys))
+	for i, objectKey := range objectKeys {
+		keys[i] = s.extractKey(objectKey)
+	}
+	return keys
+}
+
+// objectMetadata returns the user metadata stored with e's object
+func objectMetadata(e *entry.Entry) map[string]string {
+	if e.ExpiresAt == nil {
+		return nil
+	}
+	return map[string]string{ExpiresMetadataKey: strconv.FormatInt(e.ExpiresAt.UnixMilli(), 10)}
+}
+
+var (
+	_ store.Store               = (*Store)(nil)
+	_ store.TTLStore            = (*Store)(nil)
+	_ store.ContextStore        = (*Store)(nil)
+	_ store.RequestContextStore = (*Store)(nil)
+	_ store.HealthChecker       = (*Store)(nil)
+)
@@ -0,0 +1,310 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+)
+
+// fakeClient is an in-memory bucket that lists two keys per page
+type fakeClient struct {
+	mu       sync.Mutex
+	objects  map[string][]byte
+	metadata map[string]map[string]string
+	stats    int
+	deletes  [][]string
+	block    bool // Requests wait for their context instead of answering
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{objects: make(map[string][]byte), metadata: make(map[string]map[string]string)}
+}
+
+func (c *fakeClient) wait(ctx context.Context) error {
+	c.mu.Lock()
+	block := c.block
+	c.mu.Unlock()
+	if block {
+		<-ctx.Done()
+	}
+	return ctx.Err()
+}
+
+func (c *fakeClient) PutObject(ctx context.Context, key string, data []byte, metadata map[string]string) error {
+	if err := c.wait(ctx); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.objects[key] = slices.Clone(data)
+	c.metadata[key] = maps.Clone(metadata)
+	return nil
+}
+
+func (c *fakeClient) GetObject(ctx context.Context, key string) ([]byte, error) {
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := c.objects[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return data, nil
+}
+
+func (c *fakeClient) StatObject(ctx context.Context, key string) (map[string]string, error) {
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats++
+	if _, ok := c.objects[key]; !ok {
+		return nil, ErrNotFound
+	}
+	return c.metadata[key], nil
+}
+
+func (c *fakeClient) DeleteObjects(ctx context.Context, keys []string) error {
+	if err := c.wait(ctx); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deletes = append(c.deletes, slices.Clone(keys))
+	for _, key := range keys {
+		delete(c.objects, key)
+		delete(c.metadata, key)
+	}
+	return nil
+}
+
+func (c *fakeClient) ListObjects(ctx context.Context, prefix string, fn func(keys []string) error) error {
+	if err := c.wait(ctx); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	var keys []string
+	for key := range c.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	c.mu.Unlock()
+	slices.Sort(keys)
+
+	for page := range slices.Chunk(keys, 2) {
+		if err := fn(page); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newTestStore(t *testing.T, client *fakeClient, config Config) *Store {
+	t.Helper()
+	config.Client = client
+	s, err := New(&config)
+	if err != nil {
+		t.Fatalf("Failed to create blob store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+// expired returns an entry whose expiration has passed
+func expired(value any) *entry.Entry {
+	e := entry.New(value, time.Hour)
+	past := time.Now().Add(-time.Minute)
+	e.ExpiresAt = &past
+	return e
+}
+
+func TestBlobStoreBasicOperations(t *testing.T) {
+	client := newFakeClient()
+	s := newTestStore(t, client, Config{KeyPrefix: "exports/"})
+
+	if err := s.Set("report", entry.New("rendered", time.Hour)); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := s.Set("raw", entry.New([]byte{0, 1, 2}, time.Hour)); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if _, ok := client.objects["exports/report"]; !ok {
+		t.Error("Expected the entry to be stored under the key prefix")
+	}
+	if client.metadata["exports/report"][ExpiresMetadataKey] == "" {
+		t.Error("Expected the expiration to be recorded in the object metadata")
+	}
+
+	if e, found := s.Get("report"); !found || e.Value != "rendered" {
+		t.Errorf("Expected rendered, got %v (found=%v)", e, found)
+	}
+	if e, found := s.Peek("raw"); !found || !slices.Equal(e.Value.([]byte), []byte{0, 1, 2}) {
+		t.Errorf("Expected byte values to round trip, got %v (found=%v)", e, found)
+	}
+
+	keys := s.Keys()
+	slices.Sort(keys)
+	if !slices.Equal(keys, []string{"raw", "report"}) || s.Len() != 2 {
+		t.Errorf("Expected keys [raw report], got %v", keys)
+	}
+
+	if err := s.Delete("report"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, found := s.Get("report"); found {
+		t.Error("Expected deleted entry to be gone")
+	}
+	if _, found := s.Get("missing"); found {
+		t.Error("Expected a missing object to be a miss")
+	}
+}
+
+func TestBlobStoreExpiredEntryIsMiss(t *testing.T) {
+	client := newFakeClient()
+	s := newTestStore(t, client, Config{})
+
+	_ = s.Set("stale", expired("value"))
+	if _, found := s.Get("stale"); found {
+		t.Error("Expected an expired entry to be a miss before Cleanup removes it")
+	}
+	if s.UpdateTTL("stale", time.Hour) {
+		t.Error("Expected UpdateTTL to fail for an expired entry")
+	}
+}
+
+func TestBlobStoreCleanup(t *testing.T) {
+	client := newFakeClient()
+	s := newTestStore(t, client, Config{DeleteBatchSize: 2})
+
+	for _, key := range []string{"a", "b", "c"} {
+		_ = s.Set(key, expired(key))
+	}
+	_ = s.Set("live", entry.New("value", time.Hour))
+	_ = s.Set("forever", entry.New("value", 0))
+
+	var cleaned []string
+	s.SetCleanupCallback(func(key string, value any) {
+		if value != nil {
+			t.Errorf("Expected no value for %s, got %v", key, value)
+		}
+		cleaned = append(cleaned, key)
+	})
+
+	if removed := s.Cleanup(); removed != 3 {
+		t.Errorf("Expected 3 expired objects removed, got %d", removed)
+	}
+	slices.Sort(cleaned)
+	if !slices.Equal(cleaned, []string{"a", "b", "c"}) {
+		t.Errorf("Expected cleanup callbacks for a, b and c, got %v", cleaned)
+	}
+	for _, batch := range client.deletes {
+		if len(batch) > 2 {
+			t.Errorf("Expected batches of at most 2 objects, got %v", batch)
+		}
+	}
+	if keys := s.Keys(); len(keys) != 2 {
+		t.Errorf("Expected live and forever to remain, got %v", keys)
+	}
+}
+
+func TestBlobStoreLifecycleExpiration(t *testing.T) {
+	client := newFakeClient()
+	s := newTestStore(t, client, Config{LifecycleExpiration: true})
+
+	_ = s.Set("stale", expired("value"))
+	if removed := s.Cleanup(); removed != 0 || client.stats != 0 {
+		t.Errorf("Expected Cleanup to leave expiration to the bucket, removed %d with %d stats", removed, client.stats)
+	}
+	if _, found := s.Get("stale"); found {
+		t.Error("Expected an expired entry to be a miss")
+	}
+}
+
+func TestBlobStoreRespectsContext(t *testing.T) {
+	client := newFakeClient()
+	s := newTestStore(t, client, Config{})
+	_ = s.Set("report", entry.New("rendered", time.Hour))
+	client.block = true
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, found := s.GetContext(ctx, "report"); found {
+		t.Error("Expected a read cut short by its context to be a miss")
+	}
+	if err := s.SetContext(ctx, "report", entry.New("new", time.Hour)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected SetContext to fail with the context error, got %v", err)
+	}
+	if err := s.DeleteContext(ctx, "report"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected DeleteContext to fail with the context error, got %v", err)
+	}
+	if _, err := s.KeysContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected KeysContext to fail with the context error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected requests to stop with their context, took %v", elapsed)
+	}
+
+	// Requests without a caller deadline are bounded by RequestTimeout
+	s.timeout = 20 * time.Millisecond
+	if _, found := s.Get("report"); found {
+		t.Error("Expected a read past the request timeout to be a miss")
+	}
+}
+
+func TestBlobStoreClearContext(t *testing.T) {
+	client := newFakeClient()
+	s := newTestStore(t, client, Config{})
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		_ = s.Set(key, entry.New(key, time.Hour))
+	}
+	client.objects["other/x"] = []byte("{}")
+
+	// Cancel after the first page; the remaining pages stay in place
+	ctx, cancel := context.WithCancel(context.Background())
+	var cleared []string
+	err := s.ClearContext(ctx, func(keys []string) {
+		cleared = append(cleared, keys...)
+		cancel()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected ClearContext to stop with context.Canceled, got %v", err)
+	}
+	if !slices.Equal(cleared, []string{"a", "b"}) || s.Len() != 3 {
+		t.Errorf("Expected only the first page cleared, got %v with %d left", cleared, s.Len())
+	}
+
+	if err := s.Clear(); err != nil {
+		t.Fatalf("Clear failed: %v", err)
+	}
+	if s.Len() != 0 {
+		t.Errorf("Expected every entry cleared, got %d", s.Len())
+	}
+	if _, ok := client.objects["other/x"]; !ok {
+		t.Error("Expected objects outside the prefix to be left alone")
+	}
+}
+
+func TestBlobStorePing(t *testing.T) {
+	client := newFakeClient()
+	s := newTestStore(t, client, Config{RequestTimeout: 20 * time.Millisecond})
+
+	if err := s.Ping(context.Background()); err != nil {
+		t.Errorf("Expected a reachable bucket to answer, got %v", err)
+	}
+	client.block = true
+	if err := s.Ping(context.Background()); err == nil {
+		t.Error("Expected Ping to fail when the bucket does not answer")
+	}
+}
@@ -0,0 +1,513 @@
+package bolt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	bbolt "go.etcd.io/bbolt"
+
+	"github.com/1mb-dev/obcache-go/v2/internal/envelope"
+	"github.com/1mb-dev/obcache-go/v2/internal/eviction"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+	"github.com/1mb-dev/obcache-go/v2/pkg/store"
+)
+
+// entriesBucket is the Bolt bucket holding serialized entries keyed by cache key
+var entriesBucket = []byte("entries")
+
+// Store implements a cache store persisted in an embedded Bolt database
+// Values live on disk; only keys and their expiration metadata are kept in
+// memory, in an eviction strategy that enforces the entry capacity
+type Store struct {
+	db              *bbolt.DB
+	noSync          bool
+	strategy        eviction.Strategy
+	mu              sync.RWMutex
+	evictCallback   store.EvictCallback
+	cleanupCallback store.EvictCallback
+	cleanupTicker   *time.Ticker
+	stopCleanup     chan struct{}
+}
+
+// Config holds Bolt store configuration
+type Config struct {
+	// Path is the database file, created if it does not exist
+	Path string
+
+	// Capacity is the maximum number of entries kept in the database
+	Capacity int
+
+	// EvictionType selects which entry is removed when the store is full
+	// Default: LRU
+	EvictionType eviction.EvictionType
+
+	// NoSync skips fsync after each write. Writes are faster but the most recent
+	// ones may be lost on a crash; Close still flushes everything to disk
+	NoSync bool
+
+	// Timeout bounds how long New waits for the file lock held by another process
+	// Default: 0 (wait indefinitely)
+	Timeout time.Duration
+
+	// CleanupInterval sets how often expired entries are removed
+	// Default: 0 (no automatic cleanup)
+	CleanupInterval time.Duration
+}
+
+// New opens or creates the Bolt database at config.Path
+// Entries already in the database are loaded back in last-access order;
+// expired ones and any overflow beyond Capacity are removed
+func New(config *Config) (*Store, error) {
+	if config.Path == "" {
+		return nil, fmt.Errorf("bolt database path is required")
+	}
+	if config.Capacity <= 0 {
+		return nil, fmt.Errorf("bolt store capacity must be positive, got %d", config.Capacity)
+	}
+
+	evictionType := config.EvictionType
+	if evictionType == "" {
+		evictionType = eviction.LRU
+	}
+
+	db, err := bbolt.Open(config.Path, 0o600, &bbolt.Options{
+		Timeout: config.Timeout,
+		NoSync:  config.NoSync,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bolt database: %w", err)
+	}
+
+	s := &Store{
+		db:     db,
+		noSync: config.NoSync,
+		strategy: eviction.NewStrategy(eviction.Config{
+			Type:     evictionType,
+			Capacity: config.Capacity,
+		}),
+		stopCleanup: make(chan struct{}),
+	}
+
+	if err := s.recover(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	if config.CleanupInterval > 0 {
+		s.startCleanup(config.CleanupInterval)
+	}
+
+	return s, nil
+}
+
+// recover rebuilds the in-memory key index from the database
+func (s *Store) recover() error {
+	type stored struct {
+		key  string
+		meta *entry.Entry
+	}
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(entriesBucket)
+		if err != nil {
+			return fmt.Errorf("failed to create bolt bucket: %w", err)
+		}
+
+		var live []stored
+		var expired [][]byte
+		err = bucket.ForEach(func(k, v []byte) error {
+			e, err := envelope.Unmarshal(v, nil)
+			if err != nil || e.IsExpired() {
+				// Corrupted and expired entries are dropped on startup
+				expired = append(expired, k)
+				return nil
+			}
+			live = append(live, stored{key: string(k), meta: metadata(e)})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, k := range expired {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		// Replay oldest access first so the eviction order survives the restart
+		sort.SliceStable(live, func(i, j int) bool {
+			return live[i].meta.AccessedAt.Before(live[j].meta.AccessedAt)
+		})
+		for _, item := range live {
+			if evictedKey, _, evicted := s.strategy.Add(item.key, item.meta); evicted {
+				if err := bucket.Delete([]byte(evictedKey)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Get retrieves an entry by key
+func (s *Store) Get(key string) (*entry.Entry, bool) {
+	s.mu.RLock()
+	meta, found := s.strategy.Get(key)
+	if !found {
+		s.mu.RUnlock()
+		return nil, false
+	}
+	if meta.IsExpired() {
+		s.mu.RUnlock()
+		s.expire(key)
+		return nil, false
+	}
+
+	e, found := s.load(key)
+	s.mu.RUnlock()
+	if !found {
+		return nil, false
+	}
+
+	e.Touch()
+	return e, true
+}
+
+// Peek retrieves an entry without affecting its eviction position
+func (s *Store) Peek(key string) (*entry.Entry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	meta, found := s.strategy.Peek(key)
+	if !found || meta.IsExpired() {
+		return nil, false
+	}
+	return s.load(key)
+}
+
+// load reads and deserializes the entry stored under key
+func (s *Store) load(key string) (*entry.Entry, bool) {
+	var e *entry.Entry
+	_ = s.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket(entriesBucket).Get([]byte(key))
+		if data == nil {
+			return nil
+		}
+		var err error
+		e, err = envelope.Unmarshal(data, nil)
+		return err
+	})
+	return e, e != nil
+}
+
+// expire removes key if it is still expired and reports it through the cleanup callback
+func (s *Store) expire(key string) {
+	s.mu.Lock()
+	meta, found := s.strategy.Peek(key)
+	if !found || !meta.IsExpired() {
+		s.mu.Unlock()
+		return
+	}
+	values, err := s.remove([]string{key})
+	callback := s.cleanupCallback
+	s.mu.Unlock()
+
+	if callback != nil && err == nil {
+		callback(key, values[0])
+	}
+}
+
+// remove deletes keys from the database and the key index in one transaction
+// and returns the value each one held (assumes lock is held)
+func (s *Store) remove(keys []string) ([]any, error) {
+	values := make([]any, len(keys))
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(entriesBucket)
+		for i, key := range keys {
+			if data := bucket.Get([]byte(key)); data != nil {
+				if e, err := envelope.Unmarshal(data, nil); err == nil {
+					values[i] = e.Value
+				}
+			}
+			if err := bucket.Delete([]byte(key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	for _, key := range keys {
+		s.strategy.Remove(key)
+	}
+	return values, err
+}
+
+// Set stores an entry with the given key
+// If the store is full, the strategy's victim is removed in the same transaction.
+// A failed write leaves both the rows and the key index as they were
+func (s *Store) Set(key string, e *entry.Entry) error {
+	data, err := envelope.Marshal(e, nil)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.strategy.Peek(key)
+	var added bool
+	var victim string
+	var victimMeta *entry.Entry
+	var evictedKey string
+	var evictedValue any
+	var evictedExpired bool
+	err = s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(entriesBucket)
+		if err := bucket.Put([]byte(key), data); err != nil {
+			return err
+		}
+
+		var evicted bool
+		victim, victimMeta, evicted = s.strategy.Add(key, metadata(e))
+		added = true
+		if !evicted || victim == "" {
+			return nil
+		}
+		if old := bucket.Get([]byte(victim)); old != nil {
+			if victimEntry, err := envelope.Unmarshal(old, nil); err == nil {
+				evictedKey, evictedValue = victim, victimEntry.Value
+				evictedExpired = victimMeta.IsExpired()
+			}
+		}
+		return bucket.Delete([]byte(victim))
+	})
+	if err != nil {
+		if added {
+			s.revertAdd(key, previous, existed, victim, victimMeta)
+		}
+		return fmt.Errorf("failed to write bolt entry: %w", err)
+	}
+
+	if evictedKey == "" {
+		return nil
+	}
+	if evictedExpired {
+		if s.cleanupCallback != nil {
+			s.cleanupCallback(evictedKey, evictedValue)
+		}
+		return nil
+	}
+	if s.evictCallback != nil {
+		s.evictCallback(evictedKey, evictedValue)
+	}
+	return nil
+}
+
+// revertAdd undoes a strategy Add whose transaction was rolled back, so the key
+// index again matches the rows: key gets back its previous metadata, if it had
+// any, and the victim the Add evicted is tracked again (assumes lock is held)
+func (s *Store) revertAdd(key string, previous *entry.Entry, existed bool, victim string, victimMeta *entry.Entry) {
+	if existed {
+		s.strategy.Add(key, previous)
+	} else {
+		s.strategy.Remove(key) // Frees the slot the victim is put back into
+	}
+	if victim != "" && victimMeta != nil {
+		s.strategy.Add(victim, victimMeta)
+	}
+}
+
+// Delete removes an entry by key
+func (s *Store) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.remove([]string{key})
+	return err
+}
+
+// Keys returns all non-expired keys currently in the store
+func (s *Store) Keys() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := s.strategy.Keys()
+	validKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if meta, found := s.strategy.Peek(key); found && !meta.IsExpired() {
+			validKeys = append(validKeys, key)
+		}
+	}
+	return validKeys
+}
+
+// Len returns the number of non-expired entries in the store
+func (s *Store) Len() int {
+	return len(s.Keys())
+}
+
+// Clear removes all entries from the store
+func (s *Store) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		if err := tx.DeleteBucket(entriesBucket); err != nil && !errors.Is(err, bbolt.ErrBucketNotFound) {
+			return err
+		}
+		_, err := tx.CreateBucket(entriesBucket)
+		return err
+	})
+	s.strategy.Clear()
+	return err
+}
+
+// Ping checks that the database is open by starting a read transaction
+func (s *Store) Ping(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.db.View(func(*bbolt.Tx) error { return nil })
+}
+
+// Close stops automatic cleanup, flushes pending writes and releases the database
+// Unlike the memory and Redis stores, entries are kept so they survive a restart
+func (s *Store) Close() error {
+	if s.cleanupTicker != nil {
+		s.cleanupTicker.Stop()
+	}
+	close(s.stopCleanup)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.noSync {
+		if err := s.db.Sync(); err != nil {
+			_ = s.db.Close()
+			return fmt.Errorf("failed to sync bolt database: %w", err)
+		}
+	}
+	return s.db.Close()
+}
+
+// SetEvictCallback sets the callback for capacity evictions
+func (s *Store) SetEvictCallback(callback store.EvictCallback) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evictCallback = callback
+}
+
+// SetCleanupCallback sets the callback for TTL cleanup
+func (s *Store) SetCleanupCallback(callback store.EvictCallback) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cleanupCallback = callback
+}
+
+// Capacity returns the maximum number of entries the store can hold
+func (s *Store) Capacity() int {
+	return s.strategy.Capacity()
+}
+
+// Cleanup removes expired entries and returns the number of entries removed
+func (s *Store) Cleanup() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expired []string
+	for _, key := range s.strategy.Keys() {
+		if meta, found := s.strategy.Peek(key); found && meta.IsExpired() {
+			expired = append(expired, key)
+		}
+	}
+	if len(expired) == 0 {
+		return 0
+	}
+
+	values, err := s.remove(expired)
+	if err != nil {
+		return 0
+	}
+	if s.cleanupCallback != nil {
+		for i, value := range values {
+			s.cleanupCallback(expired[i], value)
+		}
+	}
+	return len(expired)
+}
+
+// UpdateTTL rewrites the stored expiration of an existing entry
+func (s *Store) UpdateTTL(key string, ttl time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	meta, found := s.strategy.Peek(key)
+	if !found || meta.IsExpired() {
+		return false
+	}
+
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(entriesBucket)
+		data := bucket.Get([]byte(key))
+		if data == nil {
+			return fmt.Errorf("bolt entry %q is missing", key)
+		}
+		e, err := envelope.Unmarshal(data, nil)
+		if err != nil {
+			return err
+		}
+		e.UpdateExpiry(ttl)
+		if data, err = envelope.Marshal(e, nil); err != nil {
+			return err
+		}
+		return bucket.Put([]byte(key), data)
+	})
+	if err != nil {
+		return false
+	}
+
+	meta.UpdateExpiry(ttl)
+	if tracker, ok := s.strategy.(eviction.ExpiryTracker); ok {
+		tracker.Reindex(key)
+	}
+	return true
+}
+
+// startCleanup starts the automatic cleanup goroutine
+func (s *Store) startCleanup(interval time.Duration) {
+	s.cleanupTicker = time.NewTicker(interval)
+
+	go func() {
+		for {
+			select {
+			case <-s.cleanupTicker.C:
+				s.Cleanup()
+			case <-s.stopCleanup:
+				return
+			}
+		}
+	}()
+}
+
+// metadata returns a value-less copy of e for the in-memory key index
+func metadata(e *entry.Entry) *entry.Entry {
+	return &entry.Entry{
+		ExpiresAt:  e.ExpiresAt,
+		CreatedAt:  e.CreatedAt,
+		AccessedAt: e.LastAccess(),
+		ValueSize:  e.ValueSize,
+		Version:    e.Version,
+	}
+}
+
+// Ensure Store implements the required interfaces
+var (
+	_ store.Store         = (*Store)(nil)
+	_ store.LRUStore      = (*Store)(nil)
+	_ store.TTLStore      = (*Store)(nil)
+	_ store.HealthChecker = (*Store)(nil)
+)
@@ -0,0 +1,195 @@
+package bolt
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.etcd.io/bbolt"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+)
+
+func newTestStore(t *testing.T, path string, capacity int) *Store {
+	t.Helper()
+	s, err := New(&Config{Path: path, Capacity: capacity})
+	if err != nil {
+		t.Fatalf("Failed to open bolt store: %v", err)
+	}
+	return s
+}
+
+func TestBoltStoreBasicOperations(t *testing.T) {
+	s := newTestStore(t, filepath.Join(t.TempDir(), "cache.db"), 10)
+	defer func() { _ = s.Close() }()
+
+	if err := s.Set("key1", entry.New("value1", time.Hour)); err != nil {
+		t.Fatalf("Failed to set entry: %v", err)
+	}
+
+	e, found := s.Get("key1")
+	if !found || e.Value != "value1" {
+		t.Fatalf("Expected value1, got %v (found=%v)", e, found)
+	}
+	if s.Len() != 1 {
+		t.Errorf("Expected length 1, got %d", s.Len())
+	}
+
+	if err := s.Delete("key1"); err != nil {
+		t.Fatalf("Failed to delete entry: %v", err)
+	}
+	if _, found := s.Get("key1"); found {
+		t.Error("Expected key1 to be deleted")
+	}
+
+	_ = s.Set("a", entry.NewWithoutTTL("1"))
+	_ = s.Set("b", entry.NewWithoutTTL("2"))
+	if err := s.Clear(); err != nil {
+		t.Fatalf("Failed to clear store: %v", err)
+	}
+	if s.Len() != 0 {
+		t.Errorf("Expected empty store after Clear, got %d entries", s.Len())
+	}
+}
+
+func TestBoltStoreRecovery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+
+	s := newTestStore(t, path, 10)
+	persistent := entry.NewWithoutTTL("forever")
+	persistent.Version = 7
+	if err := s.Set("persistent", persistent); err != nil {
+		t.Fatalf("Failed to set entry: %v", err)
+	}
+	timed := entry.New(map[string]any{"n": 1.0}, time.Hour)
+	if err := s.Set("timed", timed); err != nil {
+		t.Fatalf("Failed to set entry: %v", err)
+	}
+	if err := s.Set("short", entry.New("gone", 20*time.Millisecond)); err != nil {
+		t.Fatalf("Failed to set entry: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Failed to close store: %v", err)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+
+	s = newTestStore(t, path, 10)
+	defer func() { _ = s.Close() }()
+
+	e, found := s.Get("persistent")
+	if !found || e.Value != "forever" || e.Version != 7 || e.HasExpiry() {
+		t.Fatalf("Expected persistent entry to survive reopen, got %+v (found=%v)", e, found)
+	}
+
+	e, found = s.Get("timed")
+	if !found {
+		t.Fatal("Expected timed entry to survive reopen")
+	}
+	if value, ok := e.Value.(map[string]any); !ok || value["n"] != 1.0 {
+		t.Errorf("Expected map value to round-trip, got %#v", e.Value)
+	}
+	if e.ExpiresAt == nil || !e.ExpiresAt.Equal(*timed.ExpiresAt) {
+		t.Errorf("Expected expiration %v to survive reopen, got %v", timed.ExpiresAt, e.ExpiresAt)
+	}
+
+	if _, found := s.Get("short"); found {
+		t.Error("Expected entry that expired while closed to be gone")
+	}
+	if s.Len() != 2 {
+		t.Errorf("Expected 2 entries after reopen, got %d", s.Len())
+	}
+}
+
+func TestBoltStoreCapacity(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+	s := newTestStore(t, path, 3)
+
+	var evicted []string
+	s.SetEvictCallback(func(key string, value any) {
+		if value != "value-"+key {
+			t.Errorf("Expected evicted value for %s to match, got %v", key, value)
+		}
+		evicted = append(evicted, key)
+	})
+
+	for i := range 3 {
+		key := fmt.Sprintf("key%d", i)
+		_ = s.Set(key, entry.NewWithoutTTL("value-"+key))
+	}
+	s.Get("key0") // key1 becomes least recently used
+	_ = s.Set("key3", entry.NewWithoutTTL("value-key3"))
+
+	if len(evicted) != 1 || evicted[0] != "key1" {
+		t.Fatalf("Expected key1 to be evicted, got %v", evicted)
+	}
+	if s.Len() != 3 {
+		t.Errorf("Expected length 3, got %d", s.Len())
+	}
+	_ = s.Close()
+
+	// Reopening with a smaller capacity drops the overflow
+	s = newTestStore(t, path, 2)
+	defer func() { _ = s.Close() }()
+	if s.Len() != 2 {
+		t.Errorf("Expected length 2 after reopening with capacity 2, got %d", s.Len())
+	}
+}
+
+func TestBoltStoreFailedSetKeepsIndex(t *testing.T) {
+	s := newTestStore(t, filepath.Join(t.TempDir(), "cache.db"), 2)
+	defer func() { _ = s.Close() }()
+
+	_ = s.Set("a", entry.NewWithoutTTL("1"))
+	_ = s.Set("b", entry.NewWithoutTTL("2"))
+
+	// A nested bucket cannot be deleted as a value, so evicting a rolls back
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(entriesBucket)
+		if err := bucket.Delete([]byte("a")); err != nil {
+			return err
+		}
+		_, err := bucket.CreateBucket([]byte("a"))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Failed to replace a: %v", err)
+	}
+
+	if err := s.Set("c", entry.NewWithoutTTL("3")); err == nil {
+		t.Fatal("Expected Set to fail when the victim cannot be removed")
+	}
+	if !s.strategy.Contains("a") || !s.strategy.Contains("b") || s.strategy.Contains("c") {
+		t.Errorf("Expected the key index to keep a and b without c, got %v", s.strategy.Keys())
+	}
+}
+
+func TestBoltStoreTTL(t *testing.T) {
+	s := newTestStore(t, filepath.Join(t.TempDir(), "cache.db"), 10)
+	defer func() { _ = s.Close() }()
+
+	var cleaned []string
+	s.SetCleanupCallback(func(key string, _ any) {
+		cleaned = append(cleaned, key)
+	})
+
+	_ = s.Set("short", entry.New("v", 10*time.Millisecond))
+	_ = s.Set("long", entry.New("v", time.Hour))
+	time.Sleep(20 * time.Millisecond)
+
+	if removed := s.Cleanup(); removed != 1 {
+		t.Errorf("Expected 1 expired entry to be removed, got %d", removed)
+	}
+	if len(cleaned) != 1 || cleaned[0] != "short" {
+		t.Errorf("Expected cleanup callback for short, got %v", cleaned)
+	}
+
+	if !s.UpdateTTL("long", 10*time.Millisecond) {
+		t.Fatal("Expected UpdateTTL to succeed")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, found := s.Peek("long"); found {
+		t.Error("Expected long to expire after UpdateTTL")
+	}
+}
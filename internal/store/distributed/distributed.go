@@ -0,0 +1,626 @@
+package distributed
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/internal/envelope"
+	"github.com/1mb-dev/obcache-go/v2/internal/hashring"
+	"github.com/1mb-dev/obcache-go/v2/internal/singleflight"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+	"github.com/1mb-dev/obcache-go/v2/pkg/store"
+)
+
+const (
+	// BasePath is the URL path under which peers serve entries
+	BasePath = "/_obcache/v1/entries/"
+
+	// DefaultRequestTimeout bounds each request to a peer
+	DefaultRequestTimeout = time.Second
+
+	// DefaultHotCacheTTL caps how long a copy of another peer's entry is served
+	DefaultHotCacheTTL = time.Minute
+
+	// DefaultDiscoveryInterval is how often Discovery is polled for peers
+	DefaultDiscoveryInterval = 10 * time.Second
+
+	// maxEntrySize bounds the body a peer accepts or reads back
+	maxEntrySize = 64 << 20
+)
+
+// Discovery finds the peers sharing the cache
+type Discovery interface {
+	// Peers returns the base URLs of all peers, e.g. "http://10.0.0.1:7946"
+	Peers(ctx context.Context) ([]string, error)
+}
+
+// Store shards entries across peers with a consistent hash ring, groupcache-style
+// Each key is owned by one peer and kept in that peer's local store; other peers
+// read and write it over HTTP, optionally keeping hot copies of what they read.
+// Keys, Len and the evict and cleanup callbacks cover the entries this peer owns
+type Store struct {
+	self     string
+	local    store.Store
+	hot      store.Store
+	hotTTL   time.Duration
+	replicas int
+	ring     atomic.Pointer[hashring.Ring]
+	client   *http.Client
+	fetches  singleflight.Group[string, *entry.Entry]
+
+	server    *http.Server
+	serveDone chan struct{}
+
+	discovery         Discovery
+	discoveryInterval time.Duration
+	stopDiscovery     chan struct{}
+	discoveryWg       sync.WaitGroup
+	closeOnce         sync.Once
+}
+
+// Config holds distributed store configuration
+type Config struct {
+	// Self is this peer's base URL as other peers reach it, e.g. "http://10.0.0.1:7946"
+	Self string
+
+	// Peers are the base URLs of all peers. Self is added if missing
+	Peers []string
+
+	// Discovery finds peers instead of, or in addition to, Peers
+	Discovery Discovery
+
+	// DiscoveryInterval sets how often Discovery is polled
+	// Default: 10 seconds
+	DiscoveryInterval time.Duration
+
+	// Local holds the entries this peer owns
+	Local store.Store
+
+	// HotCache holds copies of entries read from other peers
+	// Default: nil (always read from the owner)
+	HotCache store.Store
+
+	// HotCacheTTL caps how long a hot copy is served, bounding how long writes
+	// through other peers can go unseen
+	// Default: 1 minute
+	HotCacheTTL time.Duration
+
+	// Listener accepts peer requests
+	// Default: a listener on ListenAddr
+	Listener net.Listener
+
+	// ListenAddr is the address to listen on when Listener is nil
+	// Default: the host and port of Self
+	ListenAddr string
+
+	// RequestTimeout bounds each request to a peer
+	// Default: 1 second
+	RequestTimeout time.Duration
+
+	// Replicas is the number of ring points per peer
+	// Default: 128
+	Replicas int
+}
+
+// New creates a distributed store and starts serving peer requests
+func New(config *Config) (*Store, error) {
+	if config.Self == "" {
+		return nil, fmt.Errorf("distributed store requires the Self address")
+	}
+	if config.Local == nil {
+		return nil, fmt.Errorf("distributed store requires a local store")
+	}
+	self, err := normalizePeer(config.Self)
+	if err != nil {
+		return nil, err
+	}
+
+	listener := config.Listener
+	if listener == nil {
+		addr := config.ListenAddr
+		if addr == "" {
+			u, _ := url.Parse(self) // Validated by normalizePeer
+			addr = u.Host
+		}
+		if listener, err = net.Listen("tcp", addr); err != nil {
+			return nil, fmt.Errorf("failed to listen for peers: %w", err)
+		}
+	}
+
+	timeout := config.RequestTimeout
+	if timeout <= 0 {
+		timeout = DefaultRequestTimeout
+	}
+	hotTTL := config.HotCacheTTL
+	if hotTTL <= 0 {
+		hotTTL = DefaultHotCacheTTL
+	}
+	interval := config.DiscoveryInterval
+	if interval <= 0 {
+		interval = DefaultDiscoveryInterval
+	}
+
+	s := &Store{
+		self:              self,
+		local:             config.Local,
+		hot:               config.HotCache,
+		hotTTL:            hotTTL,
+		replicas:          config.Replicas,
+		client:            &http.Client{Timeout: timeout},
+		serveDone:         make(chan struct{}),
+		discovery:         config.Discovery,
+		discoveryInterval: interval,
+		stopDiscovery:     make(chan struct{}),
+	}
+	if err := s.SetPeers(config.Peers...); err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+
+	s.server = &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: timeout,
+	}
+	go func() {
+		defer close(s.serveDone)
+		_ = s.server.Serve(listener) // Returns ErrServerClosed after Close
+	}()
+
+	if s.discovery != nil {
+		s.refreshPeers()
+		s.discoveryWg.Add(1)
+		go s.discoverPeers()
+	}
+	return s, nil
+}
+
+// SetPeers replaces the peer list and rebuilds the hash ring
+// Self is always included
+func (s *Store) SetPeers(peers ...string) error {
+	nodes := make([]string, 0, len(peers)+1)
+	nodes = append(nodes, s.self)
+	for _, peer := range peers {
+		peer, err := normalizePeer(peer)
+		if err != nil {
+			return err
+		}
+		nodes = append(nodes, peer)
+	}
+	s.ring.Store(hashring.New(s.replicas, nodes...))
+	return nil
+}
+
+// Peers returns the peers on the hash ring, including this one
+func (s *Store) Peers() []string {
+	return s.ring.Load().Nodes()
+}
+
+// Owner returns the base URL of the peer that owns key
+func (s *Store) Owner(key string) string {
+	if owner := s.ring.Load().Get(key); owner != "" {
+		return owner
+	}
+	return s.self
+}
+
+// Get retrieves an entry from this peer if it owns key, otherwise from the hot
+// cache or the owner. Concurrent reads of the same remote key share one request
+func (s *Store) Get(key string) (*entry.Entry, bool) {
+	owner := s.Owner(key)
+	if owner == s.self {
+		return s.local.Get(key)
+	}
+	return s.getRemote(owner, key, false)
+}
+
+// Peek retrieves an entry without affecting its eviction position on the owner
+func (s *Store) Peek(key string) (*entry.Entry, bool) {
+	owner := s.Owner(key)
+	if owner == s.self {
+		return s.local.Peek(key)
+	}
+	return s.getRemote(owner, key, true)
+}
+
+// getRemote reads key from the hot cache, or else fetches it from owner
+func (s *Store) getRemote(owner, key string, peek bool) (*entry.Entry, bool) {
+	if s.hot != nil {
+		if e, found := unwrap(s.hot.Get(key)); found {
+			return e, true
+		}
+	}
+
+	flightKey := key
+	if peek {
+		flightKey = "\x00peek:" + key // Kept apart from Gets of the same key
+	}
+	e, err, _ := s.fetches.Do(flightKey, func() (*entry.Entry, error) {
+		return s.fetch(owner, key, peek)
+	})
+	if err != nil || e == nil {
+		return nil, false
+	}
+
+	if s.hot != nil {
+		_ = s.hot.Set(key, s.hotEntry(e)) // The hot cache is best effort
+	}
+	return e, true
+}
+
+// Set stores the entry on the peer that owns key
+// A hot copy is kept when another peer owns it
+func (s *Store) Set(key string, e *entry.Entry) error {
+	owner := s.Owner(key)
+	if owner == s.self {
+		return s.local.Set(key, e)
+	}
+
+	data, err := envelope.Marshal(e, nil)
+	if err != nil {
+		return err
+	}
+	if err := s.send(http.MethodPut, owner, entryURL(owner, key), data); err != nil {
+		s.dropHot(key)
+		return err
+	}
+	if s.hot != nil {
+		_ = s.hot.Set(key, s.hotEntry(e))
+	}
+	return nil
+}
+
+// Delete removes the entry from the peer that owns key and from the hot cache
+func (s *Store) Delete(key string) error {
+	s.dropHot(key)
+
+	owner := s.Owner(key)
+	if owner == s.self {
+		return s.local.Delete(key)
+	}
+	return s.send(http.MethodDelete, owner, entryURL(owner, key), nil)
+}
+
+// Keys returns the keys this peer owns
+func (s *Store) Keys() []string {
+	return s.local.Keys()
+}
+
+// Len returns the number of entries this peer owns
+func (s *Store) Len() int {
+	return s.local.Len()
+}
+
+// Clear removes all entries from every peer and the hot cache
+func (s *Store) Clear() error {
+	var errs []error
+	if s.hot != nil {
+		errs = append(errs, s.hot.Clear())
+	}
+	for _, peer := range s.Peers() {
+		if peer == s.self {
+			errs = append(errs, s.local.Clear())
+			continue
+		}
+		errs = append(errs, s.send(http.MethodDelete, peer, clearURL(peer), nil))
+	}
+	return errors.Join(errs...)
+}
+
+// Ping checks the local store
+func (s *Store) Ping(ctx context.Context) error {
+	if checker, ok := s.local.(store.HealthChecker); ok {
+		return checker.Ping(ctx)
+	}
+	return ctx.Err()
+}
+
+// Close stops peer discovery and the peer server, then closes the local stores
+// Entries are not handed over to other peers
+func (s *Store) Close() error {
+	var errs []error
+	s.closeOnce.Do(func() {
+		close(s.stopDiscovery)
+		s.discoveryWg.Wait()
+
+		ctx, cancel := context.WithTimeout(context.Background(), s.client.Timeout)
+		defer cancel()
+		if err := s.server.Shutdown(ctx); err != nil {
+			errs = append(errs, s.server.Close())
+		}
+		<-s.serveDone
+
+		if s.hot != nil {
+			errs = append(errs, s.hot.Close())
+		}
+		errs = append(errs, s.local.Close())
+	})
+	return errors.Join(errs...)
+}
+
+// SetEvictCallback sets the callback for capacity evictions of owned entries
+func (s *Store) SetEvictCallback(callback store.EvictCallback) {
+	if lruStore, ok := s.local.(store.LRUStore); ok {
+		lruStore.SetEvictCallback(callback)
+	}
+}
+
+// Capacity returns the capacity of the local store, or 0 if it is unbounded
+func (s *Store) Capacity() int {
+	if lruStore, ok := s.local.(store.LRUStore); ok {
+		return lruStore.Capacity()
+	}
+	return 0
+}
+
+// SetCleanupCallback sets the callback for expired owned entries
+func (s *Store) SetCleanupCallback(callback store.EvictCallback) {
+	if ttlStore, ok := s.local.(store.TTLStore); ok {
+		ttlStore.SetCleanupCallback(callback)
+	}
+}
+
+// SetEntryCallbacks sets the callbacks for capacity evictions and expirations of
+// owned entries, passing them whole entries
+func (s *Store) SetEntryCallbacks(evict, cleanup store.EntryCallback) {
+	if entryStore, ok := s.local.(store.EntryCallbackStore); ok {
+		entryStore.SetEntryCallbacks(evict, cleanup)
+	}
+}
+
+// Cleanup removes expired entries from the local store and the hot cache
+// Only owned entries are counted
+func (s *Store) Cleanup() int {
+	if ttlStore, ok := s.hot.(store.TTLStore); ok {
+		ttlStore.Cleanup()
+	}
+	if ttlStore, ok := s.local.(store.TTLStore); ok {
+		return ttlStore.Cleanup()
+	}
+	return 0
+}
+
+// UpdateTTL changes the expiration of an entry
+// For a key another peer owns, the entry is read and written back, so a
+// concurrent write to it may be lost
+func (s *Store) UpdateTTL(key string, ttl time.Duration) bool {
+	owner := s.Owner(key)
+	if owner == s.self {
+		ttlStore, ok := s.local.(store.TTLStore)
+		return ok && ttlStore.UpdateTTL(key, ttl)
+	}
+
+	s.dropHot(key)
+	e, err := s.fetch(owner, key, true)
+	if err != nil || e == nil {
+		return false
+	}
+	e.UpdateExpiry(ttl)
+	data, err := envelope.Marshal(e, nil)
+	if err != nil {
+		return false
+	}
+	return s.send(http.MethodPut, owner, entryURL(owner, key), data) == nil
+}
+
+// Handler returns the HTTP handler serving this peer's entries to other peers
+func (s *Store) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+BasePath+"{key...}", s.handleGet)
+	mux.HandleFunc("PUT "+BasePath+"{key...}", s.handleSet)
+	mux.HandleFunc("DELETE "+BasePath+"{key...}", s.handleDelete)
+	mux.HandleFunc("DELETE "+strings.TrimSuffix(BasePath, "/"), s.handleClear)
+	return mux
+}
+
+// handleGet serves an owned entry, or 404 if there is none
+func (s *Store) handleGet(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	lookup := s.local.Get
+	if r.URL.Query().Has("peek") {
+		lookup = s.local.Peek
+	}
+
+	e, found := lookup(key)
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+	data, err := envelope.Marshal(e, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+// handleSet stores an entry sent by another peer
+// It is stored locally even if this peer's ring disagrees about the owner, so
+// peers with different views of the ring never forward requests in a loop
+func (s *Store) handleSet(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxEntrySize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	e, err := envelope.Unmarshal(data, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.local.Set(r.PathValue("key"), e); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleDelete removes an owned entry
+func (s *Store) handleDelete(w http.ResponseWriter, r *http.Request) {
+	if err := s.local.Delete(r.PathValue("key")); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleClear removes all owned entries
+func (s *Store) handleClear(w http.ResponseWriter, _ *http.Request) {
+	if err := s.local.Clear(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// fetch reads key from peer, returning nil if the peer does not have it
+func (s *Store) fetch(peer, key string, peek bool) (*entry.Entry, error) {
+	target := entryURL(peer, key)
+	if peek {
+		target += "?peek"
+	}
+	resp, err := s.client.Get(target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch from peer %s: %w", peer, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("peer %s answered %s", peer, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxEntrySize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from peer %s: %w", peer, err)
+	}
+	e, err := envelope.Unmarshal(data, nil)
+	if err != nil || e.IsExpired() {
+		return nil, err
+	}
+	return e, nil
+}
+
+// send makes a write request to peer
+func (s *Store) send(method, peer, target string, body []byte) error {
+	req, err := http.NewRequest(method, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach peer %s: %w", peer, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("peer %s answered %s: %s", peer, resp.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// dropHot removes key from the hot cache, if any
+func (s *Store) dropHot(key string) {
+	if s.hot != nil {
+		_ = s.hot.Delete(key)
+	}
+}
+
+// discoverPeers polls Discovery until Close
+func (s *Store) discoverPeers() {
+	defer s.discoveryWg.Done()
+
+	ticker := time.NewTicker(s.discoveryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.refreshPeers()
+		case <-s.stopDiscovery:
+			return
+		}
+	}
+}
+
+// refreshPeers rebuilds the ring from Discovery, keeping the current peers if
+// discovery fails
+func (s *Store) refreshPeers() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.client.Timeout)
+	defer cancel()
+
+	peers, err := s.discovery.Peers(ctx)
+	if err != nil || len(peers) == 0 {
+		return
+	}
+	_ = s.SetPeers(peers...)
+}
+
+// hotEntry wraps e for the hot cache in an entry that expires no later than
+// hotTTL from now. Callers get e back with its own expiration from unwrap
+func (s *Store) hotEntry(e *entry.Entry) *entry.Entry {
+	expiry := time.Now().Add(s.hotTTL)
+	if expiresAt := e.Expiry(); expiresAt != nil && expiresAt.Before(expiry) {
+		expiry = *expiresAt
+	}
+
+	return &entry.Entry{
+		Value:     e,
+		ExpiresAt: &expiry,
+		CreatedAt: time.Now(),
+		ValueSize: e.ValueSize,
+	}
+}
+
+// unwrap returns the entry held by a hot cache wrapper entry
+func unwrap(wrapper *entry.Entry, found bool) (*entry.Entry, bool) {
+	if !found {
+		return nil, false
+	}
+	e, ok := wrapper.Value.(*entry.Entry)
+	if !ok || e.IsExpired() {
+		return nil, false
+	}
+	return e, true
+}
+
+// normalizePeer validates a peer base URL and strips any trailing slash
+func normalizePeer(peer string) (string, error) {
+	u, err := url.Parse(peer)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid peer address %q: expected a base URL such as http://host:port", peer)
+	}
+	return strings.TrimSuffix(peer, "/"), nil
+}
+
+// entryURL returns the URL of key on peer
+func entryURL(peer, key string) string {
+	return peer + BasePath + url.PathEscape(key)
+}
+
+// clearURL returns the URL that clears all of peer's entries
+func clearURL(peer string) string {
+	return peer + strings.TrimSuffix(BasePath, "/")
+}
+
+// Ensure Store implements the required interfaces
+var (
+	_ store.Store              = (*Store)(nil)
+	_ store.LRUStore           = (*Store)(nil)
+	_ store.TTLStore           = (*Store)(nil)
+	_ store.HealthChecker      = (*Store)(nil)
+	_ store.EntryCallbackStore = (*Store)(nil)
+)
@@ -0,0 +1,237 @@
+package distributed
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/internal/eviction"
+	"github.com/1mb-dev/obcache-go/v2/internal/store/memory"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+)
+
+// countingStore counts reads served to peers and can slow them down
+type countingStore struct {
+	*memory.StrategyStore
+	gets  atomic.Int32
+	delay time.Duration
+}
+
+func (s *countingStore) Get(key string) (*entry.Entry, bool) {
+	s.gets.Add(1)
+	time.Sleep(s.delay)
+	return s.StrategyStore.Get(key)
+}
+
+type testNode struct {
+	store *Store
+	local *countingStore
+}
+
+// newCluster starts n peers in process, each with a hot cache when hot is set
+func newCluster(t *testing.T, n int, hot bool) []testNode {
+	t.Helper()
+
+	listeners := make([]net.Listener, n)
+	peers := make([]string, n)
+	for i := range listeners {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Failed to listen: %v", err)
+		}
+		listeners[i] = listener
+		peers[i] = "http://" + listener.Addr().String()
+	}
+
+	nodes := make([]testNode, n)
+	for i := range nodes {
+		backing, _ := memory.NewWithStrategy(eviction.Config{Type: eviction.LRU, Capacity: 100})
+		local := &countingStore{StrategyStore: backing}
+		config := &Config{
+			Self:     peers[i],
+			Peers:    peers,
+			Local:    local,
+			Listener: listeners[i],
+		}
+		if hot {
+			config.HotCache, _ = memory.NewWithStrategy(eviction.Config{Type: eviction.LRU, Capacity: 100})
+		}
+
+		s, err := New(config)
+		if err != nil {
+			t.Fatalf("Failed to create peer %d: %v", i, err)
+		}
+		t.Cleanup(func() { _ = s.Close() })
+		nodes[i] = testNode{store: s, local: local}
+	}
+	return nodes
+}
+
+// keyOwnedBy finds a key that owner holds
+func keyOwnedBy(t *testing.T, s *Store, owner string) string {
+	t.Helper()
+	for i := range 1000 {
+		if key := fmt.Sprintf("key-%d", i); s.Owner(key) == owner {
+			return key
+		}
+	}
+	t.Fatalf("No key owned by %s", owner)
+	return ""
+}
+
+func TestDistributedStoreRoutesToOwner(t *testing.T) {
+	nodes := newCluster(t, 3, false)
+
+	for i := range 30 {
+		key := fmt.Sprintf("key-%d", i)
+		writer := nodes[i%3].store
+		if err := writer.Set(key, entry.New(fmt.Sprintf("value-%d", i), time.Hour)); err != nil {
+			t.Fatalf("Set %s failed: %v", key, err)
+		}
+	}
+
+	total := 0
+	for _, node := range nodes {
+		for _, key := range node.store.Keys() {
+			if owner := node.store.Owner(key); owner != node.store.self {
+				t.Errorf("Key %s is stored on %s but owned by %s", key, node.store.self, owner)
+			}
+		}
+		if node.store.Len() == 0 {
+			t.Errorf("Expected %s to own some keys", node.store.self)
+		}
+		total += node.store.Len()
+	}
+	if total != 30 {
+		t.Errorf("Expected each key stored exactly once, got %d entries", total)
+	}
+
+	// Every peer agrees on the owner and reads the same value
+	for i := range 30 {
+		key := fmt.Sprintf("key-%d", i)
+		for _, node := range nodes {
+			if e, found := node.store.Get(key); !found || e.Value != fmt.Sprintf("value-%d", i) {
+				t.Errorf("Expected %s from %s, got %v (found=%v)", key, node.store.self, e, found)
+			}
+		}
+	}
+
+	// Deletes through any peer reach the owner
+	if err := nodes[0].store.Delete("key-1"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	for _, node := range nodes {
+		if _, found := node.store.Get("key-1"); found {
+			t.Errorf("Expected key-1 to be deleted on %s", node.store.self)
+		}
+	}
+
+	if err := nodes[2].store.Clear(); err != nil {
+		t.Fatalf("Clear failed: %v", err)
+	}
+	for _, node := range nodes {
+		if node.store.Len() != 0 {
+			t.Errorf("Expected Clear to empty %s, got %d entries", node.store.self, node.store.Len())
+		}
+	}
+}
+
+func TestDistributedStoreHotCache(t *testing.T) {
+	nodes := newCluster(t, 3, true)
+	owner, reader := nodes[0], nodes[1]
+	key := keyOwnedBy(t, reader.store, owner.store.self)
+
+	_ = owner.store.Set(key, entry.New("value", time.Hour))
+	if e, found := reader.store.Get(key); !found || e.Value != "value" {
+		t.Fatalf("Expected remote read, got %v (found=%v)", e, found)
+	}
+	if e, found := reader.store.Get(key); !found || e.Value != "value" {
+		t.Fatalf("Expected hot read, got %v (found=%v)", e, found)
+	}
+	if gets := owner.local.gets.Load(); gets != 1 {
+		t.Errorf("Expected the second read to be served from the hot cache, owner saw %d reads", gets)
+	}
+
+	// The hot copy is served until its TTL even if the owner changes the entry
+	_ = owner.local.StrategyStore.Set(key, entry.New("changed", time.Hour))
+	if e, _ := reader.store.Get(key); e.Value != "value" {
+		t.Errorf("Expected the hot copy, got %v", e.Value)
+	}
+
+	// Writes and deletes through the reader keep its hot copy current
+	_ = reader.store.Set(key, entry.New("rewritten", time.Hour))
+	if e, _ := reader.store.Get(key); e.Value != "rewritten" {
+		t.Errorf("Expected the reader's own write, got %v", e.Value)
+	}
+	_ = reader.store.Delete(key)
+	if _, found := reader.store.Get(key); found {
+		t.Error("Expected delete to drop the hot copy")
+	}
+}
+
+func TestDistributedStoreCollapsesConcurrentFetches(t *testing.T) {
+	nodes := newCluster(t, 3, false)
+	owner, reader := nodes[0], nodes[1]
+	key := keyOwnedBy(t, reader.store, owner.store.self)
+	_ = owner.store.Set(key, entry.New("value", time.Hour))
+	owner.local.delay = 50 * time.Millisecond
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Go(func() {
+			if e, found := reader.store.Get(key); !found || e.Value != "value" {
+				t.Errorf("Expected value, got %v (found=%v)", e, found)
+			}
+		})
+	}
+	wg.Wait()
+
+	if gets := owner.local.gets.Load(); gets != 1 {
+		t.Errorf("Expected concurrent reads to share one fetch, owner saw %d reads", gets)
+	}
+}
+
+func TestDistributedStoreUnreachablePeer(t *testing.T) {
+	nodes := newCluster(t, 2, false)
+	owner, reader := nodes[0], nodes[1]
+	key := keyOwnedBy(t, reader.store, owner.store.self)
+
+	_ = owner.store.Close()
+	if _, found := reader.store.Get(key); found {
+		t.Error("Expected a miss while the owner is down")
+	}
+	if err := reader.store.Set(key, entry.NewWithoutTTL("value")); err == nil {
+		t.Error("Expected Set to fail while the owner is down")
+	}
+}
+
+type staticDiscovery []string
+
+func (d staticDiscovery) Peers(context.Context) ([]string, error) {
+	return d, nil
+}
+
+func TestDistributedStoreDiscovery(t *testing.T) {
+	backing, _ := memory.NewWithStrategy(eviction.Config{Type: eviction.LRU, Capacity: 10})
+	s, err := New(&Config{
+		Self:       "http://127.0.0.1:1",
+		ListenAddr: "127.0.0.1:0",
+		Local:      backing,
+		Discovery:  staticDiscovery{"http://10.0.0.2:7946", "http://10.0.0.3:7946/"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	if peers := s.Peers(); len(peers) != 3 {
+		t.Errorf("Expected self and two discovered peers, got %v", peers)
+	}
+	if err := s.SetPeers("not a url"); err == nil {
+		t.Error("Expected an invalid peer address to be rejected")
+	}
+}
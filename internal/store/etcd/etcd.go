@@ -0,0 +1,470 @@
+package etcd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/1mb-dev/obcache-go/v2/internal/envelope"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+	"github.com/1mb-dev/obcache-go/v2/pkg/store"
+)
+
+const (
+	// DefaultKeyPrefix is the etcd key prefix used when none is configured
+	DefaultKeyPrefix = "obcache/"
+
+	// DefaultMaxValueSize bounds the serialized size of a single entry.
+	// etcd rejects requests over 1.5 MiB by default and performs best with
+	// values of a few kilobytes
+	DefaultMaxValueSize = 128 * 1024
+
+	// DefaultRequestTimeout bounds each etcd request
+	DefaultRequestTimeout = 5 * time.Second
+
+	// watchRetryInterval is the pause before a failed watch is re-established
+	watchRetryInterval = time.Second
+)
+
+// ErrValueTooLarge is returned by Set when a serialized entry exceeds MaxValueSize
+var ErrValueTooLarge = errors.New("etcd entry exceeds the maximum value size")
+
+// Store implements a cache store in etcd
+// Entries are kept under a key prefix with one lease per entry that has a TTL,
+// so etcd removes them when they expire. It suits small, strongly consistent
+// data shared across instances; large or write-heavy caches belong in Redis
+type Store struct {
+	client       *clientv3.Client
+	closeClient  bool
+	prefix       string
+	maxValueSize int
+	timeout      time.Duration
+	ctx          context.Context
+	cancel       context.CancelFunc
+
+	mu                 sync.RWMutex
+	cleanupCallback    store.EvictCallback
+	invalidateCallback store.InvalidateCallback
+
+	// ownWrites holds the revision of this store's latest write to each key
+	// until the watch sees it, so only other clients' changes are reported
+	writesMu  sync.Mutex
+	ownWrites map[string]int64
+	watchDone chan struct{}
+}
+
+// Config holds etcd store configuration
+type Config struct {
+	// Client is the etcd client to use
+	Client *clientv3.Client
+
+	// CloseClient makes Close also close Client
+	CloseClient bool
+
+	// KeyPrefix is prepended to every cache key
+	// Default: "obcache/"
+	KeyPrefix string
+
+	// MaxValueSize is the largest serialized entry Set accepts, in bytes
+	// Default: 128 KiB
+	MaxValueSize int
+
+	// RequestTimeout bounds each etcd request
+	// Default: 5 seconds
+	RequestTimeout time.Duration
+
+	// Watch watches the key prefix and reports changes made by other clients
+	// through the invalidate callback
+	Watch bool
+}
+
+// New creates an etcd store
+// With Watch set, the watch starts from the current revision before New returns
+func New(config *Config) (*Store, error) {
+	if config.Client == nil {
+		return nil, fmt.Errorf("etcd client is required")
+	}
+
+	prefix := config.KeyPrefix
+	if prefix == "" {
+		prefix = DefaultKeyPrefix
+	}
+	maxValueSize := config.MaxValueSize
+	if maxValueSize <= 0 {
+		maxValueSize = DefaultMaxValueSize
+	}
+	timeout := config.RequestTimeout
+	if timeout <= 0 {
+		timeout = DefaultRequestTimeout
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Store{
+		client:       config.Client,
+		closeClient:  config.CloseClient,
+		prefix:       prefix,
+		maxValueSize: maxValueSize,
+		timeout:      timeout,
+		ctx:          ctx,
+		cancel:       cancel,
+		ownWrites:    make(map[string]int64),
+	}
+
+	if config.Watch {
+		revision, err := s.revision()
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to start etcd watch: %w", err)
+		}
+		s.watchDone = make(chan struct{})
+		go s.watch(revision)
+	}
+
+	return s, nil
+}
+
+// Get retrieves an entry by key
+func (s *Store) Get(key string) (*entry.Entry, bool) {
+	e, found := s.Peek(key)
+	if found {
+		e.Touch()
+	}
+	return e, found
+}
+
+// Peek retrieves an entry by key
+// Entries past their expiration are reported as not found even if their lease
+// has not been revoked yet
+func (s *Store) Peek(key string) (*entry.Entry, bool) {
+	ctx, cancel := s.requestContext()
+	defer cancel()
+
+	resp, err := s.client.Get(ctx, s.buildKey(key))
+	if err != nil || len(resp.Kvs) == 0 {
+		return nil, false
+	}
+
+	e, err := envelope.Unmarshal(resp.Kvs[0].Value, nil)
+	if err != nil || e.IsExpired() {
+		return nil, false
+	}
+	return e, true
+}
+
+// Set stores an entry with the given key, attached to a new lease if it has a TTL
+// The lease of the entry it replaces is revoked
+func (s *Store) Set(key string, e *entry.Entry) error {
+	data, err := envelope.Marshal(e, nil)
+	if err != nil {
+		return err
+	}
+	if len(data) > s.maxValueSize {
+		return fmt.Errorf("%w: %d bytes for key %q, limit %d", ErrValueTooLarge, len(data), key, s.maxValueSize)
+	}
+
+	ctx, cancel := s.requestContext()
+	defer cancel()
+
+	opts := []clientv3.OpOption{clientv3.WithPrevKV()}
+	if e.ExpiresAt != nil {
+		lease, err := s.grant(ctx, time.Until(*e.ExpiresAt))
+		if err != nil {
+			return err
+		}
+		opts = append(opts, clientv3.WithLease(lease))
+	}
+
+	etcdKey := s.buildKey(key)
+	resp, err := s.client.Put(ctx, etcdKey, string(data), opts...)
+	if err != nil {
+		return fmt.Errorf("failed to write etcd entry: %w", err)
+	}
+	s.recordWrite(etcdKey, resp.Header.Revision)
+
+	if resp.PrevKv != nil {
+		s.revoke(ctx, clientv3.LeaseID(resp.PrevKv.Lease))
+	}
+	return nil
+}
+
+// Delete removes an entry by key and revokes its lease
+func (s *Store) Delete(key string) error {
+	ctx, cancel := s.requestContext()
+	defer cancel()
+
+	etcdKey := s.buildKey(key)
+	resp, err := s.client.Delete(ctx, etcdKey, clientv3.WithPrevKV())
+	if err != nil {
+		return fmt.Errorf("failed to delete etcd entry: %w", err)
+	}
+	if resp.Deleted > 0 {
+		s.recordWrite(etcdKey, resp.Header.Revision)
+	}
+
+	for _, kv := range resp.PrevKvs {
+		s.revoke(ctx, clientv3.LeaseID(kv.Lease))
+	}
+	return nil
+}
+
+// Keys returns all non-expired keys under the prefix
+// Values are read to check expiration, so this is meant for small caches
+func (s *Store) Keys() []string {
+	ctx, cancel := s.requestContext()
+	defer cancel()
+
+	resp, err := s.client.Get(ctx, s.prefix, clientv3.WithPrefix())
+	if err != nil {
+		return []string{}
+	}
+
+	keys := make([]string, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		if e, err := envelope.Unmarshal(kv.Value, nil); err == nil && !e.IsExpired() {
+			keys = append(keys, s.extractKey(string(kv.Key)))
+		}
+	}
+	return keys
+}
+
+// Len returns the number of non-expired entries under the prefix
+func (s *Store) Len() int {
+	return len(s.Keys())
+}
+
+// Clear deletes every key under the prefix in one request
+// Leases are left to expire on their own
+func (s *Store) Clear() error {
+	ctx, cancel := s.requestContext()
+	defer cancel()
+
+	if _, err := s.client.Delete(ctx, s.prefix, clientv3.WithPrefix()); err != nil {
+		return fmt.Errorf("failed to clear etcd entries: %w", err)
+	}
+	return nil
+}
+
+// Ping checks that the etcd cluster answers a read
+func (s *Store) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	_, err := s.client.Get(ctx, s.prefix, clientv3.WithCountOnly())
+	return err
+}
+
+// Close stops the watch and closes the client if the store owns it
+// Entries are kept in etcd since other instances share them
+func (s *Store) Close() error {
+	s.cancel()
+	if s.watchDone != nil {
+		<-s.watchDone
+	}
+	if s.closeClient {
+		return s.client.Close()
+	}
+	return nil
+}
+
+// SetCleanupCallback sets the callback for TTL cleanup
+// etcd removes expired entries through their leases, so it is never called
+func (s *Store) SetCleanupCallback(callback store.EvictCallback) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cleanupCallback = callback
+}
+
+// Cleanup removes expired entries (etcd revokes expired leases automatically)
+func (s *Store) Cleanup() int {
+	return 0
+}
+
+// UpdateTTL rewrites an existing entry with a new expiration and lease
+// The write only applies if the entry has not changed since it was read
+func (s *Store) UpdateTTL(key string, ttl time.Duration) bool {
+	ctx, cancel := s.requestContext()
+	defer cancel()
+
+	etcdKey := s.buildKey(key)
+	resp, err := s.client.Get(ctx, etcdKey)
+	if err != nil || len(resp.Kvs) == 0 {
+		return false
+	}
+	current := resp.Kvs[0]
+	e, err := envelope.Unmarshal(current.Value, nil)
+	if err != nil || e.IsExpired() {
+		return false
+	}
+
+	e.UpdateExpiry(ttl)
+	data, err := envelope.Marshal(e, nil)
+	if err != nil {
+		return false
+	}
+
+	var opts []clientv3.OpOption
+	if ttl > 0 {
+		lease, err := s.grant(ctx, ttl)
+		if err != nil {
+			return false
+		}
+		opts = append(opts, clientv3.WithLease(lease))
+	}
+
+	txn, err := s.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(etcdKey), "=", current.ModRevision)).
+		Then(clientv3.OpPut(etcdKey, string(data), opts...)).
+		Commit()
+	if err != nil || !txn.Succeeded {
+		return false
+	}
+	s.recordWrite(etcdKey, txn.Header.Revision)
+	s.revoke(ctx, clientv3.LeaseID(current.Lease))
+	return true
+}
+
+// SetInvalidateCallback sets the callback for changes made by other clients
+// It is only called when Watch is enabled, from the watch goroutine
+func (s *Store) SetInvalidateCallback(callback store.InvalidateCallback) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.invalidateCallback = callback
+}
+
+// grant creates a lease covering ttl, rounded up to whole seconds
+func (s *Store) grant(ctx context.Context, ttl time.Duration) (clientv3.LeaseID, error) {
+	seconds := int64((ttl + time.Second - 1) / time.Second)
+	lease, err := s.client.Grant(ctx, max(seconds, 1))
+	if err != nil {
+		return clientv3.NoLease, fmt.Errorf("failed to grant etcd lease: %w", err)
+	}
+	return lease.ID, nil
+}
+
+// revoke releases a lease no longer attached to any entry
+// Failures are ignored since the lease expires on its own
+func (s *Store) revoke(ctx context.Context, lease clientv3.LeaseID) {
+	if lease != clientv3.NoLease {
+		_, _ = s.client.Revoke(ctx, lease)
+	}
+}
+
+// revision returns the current revision of the etcd cluster
+func (s *Store) revision() (int64, error) {
+	ctx, cancel := s.requestContext()
+	defer cancel()
+
+	resp, err := s.client.Get(ctx, s.prefix, clientv3.WithCountOnly())
+	if err != nil {
+		return 0, err
+	}
+	return resp.Header.Revision, nil
+}
+
+// watch reports changes under the prefix after revision until Close
+// When the watch fails, e.g. because the revision was compacted, it restarts
+// from the current revision and reports that any key may have changed
+func (s *Store) watch(revision int64) {
+	defer close(s.watchDone)
+
+	for {
+		watchCtx := clientv3.WithRequireLeader(s.ctx)
+		for resp := range s.client.Watch(watchCtx, s.prefix, clientv3.WithPrefix(), clientv3.WithRev(revision+1)) {
+			if resp.Err() != nil {
+				break
+			}
+			s.handleEvents(resp.Events)
+		}
+
+		// The watch ended, so changes may have been missed until it resumes
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-time.After(watchRetryInterval):
+			}
+			current, err := s.revision()
+			if err == nil {
+				revision = current
+				break
+			}
+		}
+
+		s.writesMu.Lock()
+		clear(s.ownWrites)
+		s.writesMu.Unlock()
+		s.invalidate(nil)
+	}
+}
+
+// handleEvents reports the keys changed by other clients
+func (s *Store) handleEvents(events []*clientv3.Event) {
+	var keys []string
+	s.writesMu.Lock()
+	for _, event := range events {
+		etcdKey := string(event.Kv.Key)
+		if revision, ok := s.ownWrites[etcdKey]; ok && revision >= event.Kv.ModRevision {
+			if revision == event.Kv.ModRevision {
+				delete(s.ownWrites, etcdKey)
+			}
+			continue
+		}
+		if event.Type == mvccpb.PUT || event.Type == mvccpb.DELETE {
+			keys = append(keys, s.extractKey(etcdKey))
+		}
+	}
+	s.writesMu.Unlock()
+
+	if len(keys) > 0 {
+		s.invalidate(keys)
+	}
+}
+
+// invalidate calls the invalidate callback, if any
+func (s *Store) invalidate(keys []string) {
+	s.mu.RLock()
+	callback := s.invalidateCallback
+	s.mu.RUnlock()
+
+	if callback != nil {
+		callback(keys)
+	}
+}
+
+// recordWrite notes a write by this store so the watch does not report it
+func (s *Store) recordWrite(etcdKey string, revision int64) {
+	if s.watchDone == nil {
+		return
+	}
+	s.writesMu.Lock()
+	defer s.writesMu.Unlock()
+	s.ownWrites[etcdKey] = revision
+}
+
+// requestContext returns a context bounded by the request timeout
+func (s *Store) requestContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(s.ctx, s.timeout)
+}
+
+// buildKey creates the etcd key with prefix
+func (s *Store) buildKey(key string) string {
+	return s.prefix + key
+}
+
+// extractKey removes the prefix from an etcd key
+func (s *Store) extractKey(etcdKey string) string {
+	return strings.TrimPrefix(etcdKey, s.prefix)
+}
+
+// Ensure Store implements the required interfaces
+var (
+	_ store.Store             = (*Store)(nil)
+	_ store.TTLStore          = (*Store)(nil)
+	_ store.HealthChecker     = (*Store)(nil)
+	_ store.InvalidationStore = (*Store)(nil)
+)
@@ -0,0 +1,174 @@
+//go:build integration
+
+package etcd
+
+import (
+	"context"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+)
+
+// Run with: go test -tags integration ./internal/store/etcd/
+// OBCACHE_ETCD_ENDPOINTS overrides the default endpoint, localhost:2379
+
+func newIntegrationStore(t *testing.T, prefix string, watch bool) *Store {
+	t.Helper()
+
+	endpoints := []string{"localhost:2379"}
+	if env := os.Getenv("OBCACHE_ETCD_ENDPOINTS"); env != "" {
+		endpoints = strings.Split(env, ",")
+	}
+	client, err := clientv3.New(clientv3.Config{Endpoints: endpoints, DialTimeout: 2 * time.Second})
+	if err != nil {
+		t.Skipf("etcd not available: %v", err)
+	}
+
+	s, err := New(&Config{Client: client, CloseClient: true, KeyPrefix: prefix, Watch: watch})
+	if err != nil {
+		_ = client.Close()
+		t.Skipf("etcd not available: %v", err)
+	}
+	if err := s.Ping(context.Background()); err != nil {
+		_ = s.Close()
+		t.Skipf("etcd not available: %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = s.Clear()
+		_ = s.Close()
+	})
+	return s
+}
+
+func TestEtcdStoreBasicOperations(t *testing.T) {
+	s := newIntegrationStore(t, "obcache-test-basic/", false)
+
+	if err := s.Set("key1", entry.NewWithoutTTL("value1")); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if e, found := s.Get("key1"); !found || e.Value != "value1" {
+		t.Errorf("Expected value1, got %v (found=%v)", e, found)
+	}
+
+	_ = s.Set("key2", entry.NewWithoutTTL("value2"))
+	keys := s.Keys()
+	slices.Sort(keys)
+	if !slices.Equal(keys, []string{"key1", "key2"}) {
+		t.Errorf("Expected [key1 key2], got %v", keys)
+	}
+
+	if err := s.Delete("key1"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, found := s.Get("key1"); found {
+		t.Error("Expected key1 to be deleted")
+	}
+	if s.Len() != 1 {
+		t.Errorf("Expected 1 entry, got %d", s.Len())
+	}
+}
+
+func TestEtcdStoreLeaseExpiry(t *testing.T) {
+	s := newIntegrationStore(t, "obcache-test-ttl/", false)
+
+	if err := s.Set("short", entry.New("value", 500*time.Millisecond)); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if _, found := s.Get("short"); !found {
+		t.Fatal("Expected entry before it expires")
+	}
+
+	time.Sleep(600 * time.Millisecond)
+	if _, found := s.Get("short"); found {
+		t.Error("Expected entry past its expiration to be a miss before the lease ends")
+	}
+
+	// The lease is rounded up to a second, and etcd may extend short leases
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		ctx, cancel := s.requestContext()
+		resp, err := s.client.Get(ctx, s.buildKey("short"), clientv3.WithCountOnly())
+		cancel()
+		if err == nil && resp.Count == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected etcd to delete the entry when its lease expired")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func TestEtcdStoreUpdateTTL(t *testing.T) {
+	s := newIntegrationStore(t, "obcache-test-update-ttl/", false)
+
+	_ = s.Set("key", entry.New("value", time.Hour))
+	if !s.UpdateTTL("key", 2*time.Hour) {
+		t.Fatal("Expected UpdateTTL to succeed")
+	}
+	if e, _ := s.Get("key"); e.TTL() < 119*time.Minute {
+		t.Errorf("Expected the new TTL, got %v", e.TTL())
+	}
+	if s.UpdateTTL("missing", time.Hour) {
+		t.Error("Expected UpdateTTL on a missing key to fail")
+	}
+}
+
+func TestEtcdStoreClearIsPrefixScoped(t *testing.T) {
+	a := newIntegrationStore(t, "obcache-test-clear-a/", false)
+	b := newIntegrationStore(t, "obcache-test-clear-b/", false)
+
+	_ = a.Set("key", entry.NewWithoutTTL("a"))
+	_ = b.Set("key", entry.NewWithoutTTL("b"))
+
+	if err := a.Clear(); err != nil {
+		t.Fatalf("Clear failed: %v", err)
+	}
+	if a.Len() != 0 {
+		t.Errorf("Expected cleared store to be empty, got %d", a.Len())
+	}
+	if e, found := b.Get("key"); !found || e.Value != "b" {
+		t.Errorf("Expected other prefix to be untouched, got %v (found=%v)", e, found)
+	}
+}
+
+func TestEtcdStoreWatchReportsOtherWriters(t *testing.T) {
+	watcher := newIntegrationStore(t, "obcache-test-watch/", true)
+	writer := newIntegrationStore(t, "obcache-test-watch/", false)
+
+	var mu sync.Mutex
+	var invalidated []string
+	watcher.SetInvalidateCallback(func(keys []string) {
+		mu.Lock()
+		defer mu.Unlock()
+		invalidated = append(invalidated, keys...)
+	})
+
+	_ = watcher.Set("own", entry.NewWithoutTTL("mine"))
+	_ = writer.Set("shared", entry.NewWithoutTTL("theirs"))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		got := slices.Clone(invalidated)
+		mu.Unlock()
+		if slices.Contains(got, "shared") {
+			if slices.Contains(got, "own") {
+				t.Errorf("Expected the store's own write not to be reported, got %v", got)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the other writer's change to be reported, got %v", got)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,93 @@
+package etcd
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/1mb-dev/obcache-go/v2/internal/envelope"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+)
+
+// newOfflineStore returns a store whose client never connects, for tests that
+// do not reach etcd
+func newOfflineStore(t *testing.T, config *Config) *Store {
+	t.Helper()
+	config.Client = clientv3.NewCtxClient(context.Background())
+	s, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create etcd store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func TestEtcdStoreRequiresClient(t *testing.T) {
+	if _, err := New(&Config{}); err == nil {
+		t.Error("Expected an error without a client")
+	}
+}
+
+func TestEtcdStoreMaxValueSize(t *testing.T) {
+	s := newOfflineStore(t, &Config{MaxValueSize: 64})
+
+	err := s.Set("big", entry.NewWithoutTTL(strings.Repeat("x", 100)))
+	if !errors.Is(err, ErrValueTooLarge) {
+		t.Errorf("Expected ErrValueTooLarge, got %v", err)
+	}
+}
+
+func TestEtcdStoreSerialization(t *testing.T) {
+	original := entry.New(map[string]any{"name": "test"}, time.Hour)
+	original.Version = 3
+
+	data, err := envelope.Marshal(original, nil)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	e, err := envelope.Unmarshal(data, nil)
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if e.Value.(map[string]any)["name"] != "test" || e.Version != 3 {
+		t.Errorf("Unexpected entry after round trip: %+v", e)
+	}
+	if e.ExpiresAt == nil || !e.ExpiresAt.Equal(*original.ExpiresAt) {
+		t.Errorf("Expected expiration to survive, got %v", e.ExpiresAt)
+	}
+
+	raw, _ := envelope.Marshal(entry.NewWithoutTTL([]byte{1, 2, 3}), nil)
+	if e, err := envelope.Unmarshal(raw, nil); err != nil || !slices.Equal(e.Value.([]byte), []byte{1, 2, 3}) {
+		t.Errorf("Expected byte slices to be restored, got %v (err=%v)", e, err)
+	}
+}
+
+func TestEtcdStoreIgnoresOwnWritesInWatch(t *testing.T) {
+	s := newOfflineStore(t, &Config{KeyPrefix: "test/"})
+	s.watchDone = make(chan struct{}) // Record writes as if watching
+	close(s.watchDone)
+
+	var invalidated [][]string
+	s.SetInvalidateCallback(func(keys []string) { invalidated = append(invalidated, keys) })
+
+	s.recordWrite("test/mine", 10)
+	s.handleEvents([]*clientv3.Event{
+		{Type: mvccpb.PUT, Kv: &mvccpb.KeyValue{Key: []byte("test/mine"), ModRevision: 9}},
+		{Type: mvccpb.PUT, Kv: &mvccpb.KeyValue{Key: []byte("test/mine"), ModRevision: 10}},
+		{Type: mvccpb.PUT, Kv: &mvccpb.KeyValue{Key: []byte("test/theirs"), ModRevision: 11}},
+		{Type: mvccpb.DELETE, Kv: &mvccpb.KeyValue{Key: []byte("test/mine"), ModRevision: 12}},
+	})
+
+	if len(invalidated) != 1 || !slices.Equal(invalidated[0], []string{"theirs", "mine"}) {
+		t.Errorf("Expected only other clients' changes to be reported, got %v", invalidated)
+	}
+	if len(s.ownWrites) != 0 {
+		t.Errorf("Expected own write to be forgotten once seen, got %v", s.ownWrites)
+	}
+}
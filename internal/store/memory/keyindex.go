@@ -0,0 +1,89 @@
+package memory
+
+import "math/rand/v2"
+
+// keyIndexMaxLevel bounds the height of skip list towers; with each level a
+// quarter as likely as the one below, 16 levels suit billions of keys
+const keyIndexMaxLevel = 16
+
+// keyIndex keeps keys in lexical order in a skip list, so a prefix scan finds
+// its first key in O(log n) and then walks only the keys it returns
+type keyIndex struct {
+	head  keyNode
+	level int
+}
+
+// keyNode is an indexed key with its successor on every level it reaches
+type keyNode struct {
+	key  string
+	next []*keyNode
+}
+
+// newKeyIndex creates an empty index
+func newKeyIndex() *keyIndex {
+	return &keyIndex{head: keyNode{next: make([]*keyNode, keyIndexMaxLevel)}, level: 1}
+}
+
+// seek returns the first node whose key is not less than key, or nil
+// If path is not nil it receives the last node before key on every level
+func (x *keyIndex) seek(key string, path *[keyIndexMaxLevel]*keyNode) *keyNode {
+	node := &x.head
+	for i := x.level - 1; i >= 0; i-- {
+		for node.next[i] != nil && node.next[i].key < key {
+			node = node.next[i]
+		}
+		if path != nil {
+			path[i] = node
+		}
+	}
+	return node.next[0]
+}
+
+// insert adds key unless it is already indexed
+func (x *keyIndex) insert(key string) {
+	var path [keyIndexMaxLevel]*keyNode
+	if next := x.seek(key, &path); next != nil && next.key == key {
+		return
+	}
+
+	level := 1
+	for level < keyIndexMaxLevel && rand.IntN(4) == 0 {
+		level++
+	}
+	for i := x.level; i < level; i++ {
+		path[i] = &x.head
+	}
+	x.level = max(x.level, level)
+
+	node := &keyNode{key: key, next: make([]*keyNode, level)}
+	for i := range level {
+		node.next[i] = path[i].next[i]
+		path[i].next[i] = node
+	}
+}
+
+// delete removes key if it is indexed
+func (x *keyIndex) delete(key string) {
+	var path [keyIndexMaxLevel]*keyNode
+	node := x.seek(key, &path)
+	if node == nil || node.key != key {
+		return
+	}
+	for i := range node.next {
+		path[i].next[i] = node.next[i]
+	}
+	for x.level > 1 && x.head.next[x.level-1] == nil {
+		x.level--
+	}
+}
+
+// from returns the node a scan for prefix resumes at after cursor, or nil
+// Callers stop at the first key without the prefix. An empty cursor starts at
+// the first key with the prefix
+func (x *keyIndex) from(prefix, cursor string) *keyNode {
+	node := x.seek(max(prefix, cursor), nil)
+	if cursor != "" && node != nil && node.key == cursor {
+		node = node.next[0]
+	}
+	return node
+}
@@ -1,34 +1,67 @@
 package memory
 
 import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/1mb-dev/obcache-go/v2/internal/entry"
 	"github.com/1mb-dev/obcache-go/v2/internal/eviction"
-	"github.com/1mb-dev/obcache-go/v2/internal/store"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+	"github.com/1mb-dev/obcache-go/v2/pkg/store"
 )
 
+// scanChunkSize is the number of keys inspected per lock acquisition during long scans
+const scanChunkSize = 1024
+
+// EntryOverhead approximates the bytes each entry costs beyond its key and value:
+// the Entry itself and the strategy's map and list bookkeeping
+const EntryOverhead = 128
+
 // StrategyStore implements an in-memory cache with pluggable eviction strategies
 type StrategyStore struct {
 	strategy        eviction.Strategy
 	mutex           sync.RWMutex
-	evictCallback   store.EvictCallback
-	cleanupCallback store.EvictCallback
+	evictCallback   store.EntryCallback
+	evictEntry      func(key string, e *entry.Entry)
+	cleanupCallback store.EntryCallback
 	cleanupTicker   *time.Ticker
 	stopCleanup     chan struct{}
+
+	// memoryBytes is the running total of entrySize over the stored entries
+	memoryBytes atomic.Int64
+
+	// index orders the keys for Scan and CountPrefix; nil until the first of
+	// them, so stores that are never scanned do not pay to maintain it
+	index atomic.Pointer[keyIndex]
+
+	// nextExpiry is a UnixNano no later than the first stored entry expires, or
+	// math.MaxInt64 if none expires, so Len can skip checking entries before it
+	nextExpiry atomic.Int64
 }
 
 // NewWithStrategy creates a new memory store with the specified eviction strategy
 func NewWithStrategy(config eviction.Config) (*StrategyStore, error) {
-	strategy := eviction.NewStrategy(config)
+	return NewFromStrategy(eviction.NewStrategy(config), 0), nil
+}
 
+// NewFromStrategy creates a new memory store around an already constructed strategy
+// A positive cleanupInterval starts automatic TTL cleanup
+func NewFromStrategy(strategy eviction.Strategy, cleanupInterval time.Duration) *StrategyStore {
 	s := &StrategyStore{
 		strategy:    strategy,
 		stopCleanup: make(chan struct{}),
 	}
+	s.nextExpiry.Store(math.MaxInt64)
 
-	return s, nil
+	if cleanupInterval > 0 {
+		s.startCleanup(cleanupInterval)
+	}
+
+	return s
 }
 
 // NewWithStrategyAndCleanup creates a new memory store with eviction strategy and automatic TTL cleanup
@@ -60,11 +93,11 @@ func (s *StrategyStore) Get(key string) (*entry.Entry, bool) {
 		// Remove expired entry (do this in a separate goroutine to avoid deadlock)
 		go func() {
 			s.mutex.Lock()
-			s.strategy.Remove(key)
+			s.remove(key)
 			s.mutex.Unlock()
 
 			if s.cleanupCallback != nil {
-				s.cleanupCallback(key, entry.Value)
+				s.cleanupCallback(key, entry)
 			}
 		}()
 		return nil, false
@@ -75,27 +108,379 @@ func (s *StrategyStore) Get(key string) (*entry.Entry, bool) {
 	return entry, true
 }
 
+// GetBatch retrieves the entries for keys in a single pass under one lock
+// Expired entries are left out and removed in the background, as in Get
+func (s *StrategyStore) GetBatch(keys []string) (map[string]*entry.Entry, error) {
+	found := make(map[string]*entry.Entry, len(keys))
+	var expired map[string]*entry.Entry
+
+	s.mutex.RLock()
+	for _, key := range keys {
+		e, ok := s.strategy.Get(key)
+		if !ok {
+			continue
+		}
+		if e.IsExpired() {
+			if expired == nil {
+				expired = make(map[string]*entry.Entry)
+			}
+			expired[key] = e
+			continue
+		}
+		e.Touch()
+		found[key] = e
+	}
+	s.mutex.RUnlock()
+
+	if len(expired) > 0 {
+		go s.removeExpired(expired)
+	}
+	return found, nil
+}
+
+// removeExpired removes entries found expired during a read, unless they have
+// been replaced in the meantime, and reports them to the cleanup callback
+func (s *StrategyStore) removeExpired(expired map[string]*entry.Entry) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for key, e := range expired {
+		if current, ok := s.strategy.Peek(key); !ok || current != e {
+			continue
+		}
+		s.remove(key)
+		if s.cleanupCallback != nil {
+			s.cleanupCallback(key, e)
+		}
+	}
+}
+
+// Peek retrieves an entry without affecting its eviction position or frequency
+func (s *StrategyStore) Peek(key string) (*entry.Entry, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	entry, found := s.strategy.Peek(key)
+	if !found || entry.IsExpired() {
+		return nil, false
+	}
+	return entry, true
+}
+
 // Set stores an entry with the given key
 func (s *StrategyStore) Set(key string, entry *entry.Entry) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	evictedKey, evictedEntry, wasEvicted := s.strategy.Add(key, entry)
+	return s.add(key, entry)
+}
 
-	// Call eviction callback if an entry was evicted
-	if wasEvicted && s.evictCallback != nil && evictedKey != "" && evictedEntry != nil {
-		s.evictCallback(evictedKey, evictedEntry.Value)
+// add inserts an entry through the strategy and reports evictions (assumes lock is held)
+// Returns eviction.ErrAllPinned if the store is full of pinned entries
+func (s *StrategyStore) add(key string, entry *entry.Entry) error {
+	if pinner := s.pinner(); pinner != nil && !pinner.HasRoom(key) {
+		// Expired pinned entries may still be taking up room
+		if s.cleanup() == 0 || !pinner.HasRoom(key) {
+			return eviction.ErrAllPinned
+		}
 	}
 
+	// The replaced entry is released whether or not the strategy reports it evicted
+	previous, replaced := s.strategy.Peek(key)
+	delta := entrySize(key, entry)
+	if replaced {
+		delta -= entrySize(key, previous)
+	}
+
+	var evicted []eviction.Evicted
+	if multi, ok := s.strategy.(eviction.MultiEvictor); ok {
+		evicted = multi.AddEvicting(key, entry)
+	} else if evictedKey, evictedEntry, wasEvicted := s.strategy.Add(key, entry); wasEvicted {
+		evicted = []eviction.Evicted{{Key: evictedKey, Entry: evictedEntry}}
+	}
+	for _, e := range evicted {
+		if e.Entry != nil && (!replaced || e.Entry != previous) {
+			delta -= entrySize(e.Key, e.Entry)
+		}
+	}
+	s.memoryBytes.Add(delta)
+	s.expiresBy(entry.Expiry())
+
+	if index := s.index.Load(); index != nil {
+		// Strategies may turn the new key away, so index what they kept
+		for _, e := range evicted {
+			if !s.strategy.Contains(e.Key) {
+				index.delete(e.Key)
+			}
+		}
+		if s.strategy.Contains(key) {
+			index.insert(key)
+		}
+	}
+
+	for _, e := range evicted {
+		s.notifyEvict(e.Key, e.Entry)
+	}
 	return nil
 }
 
+// remove removes key from the strategy and releases its bytes (assumes lock is held)
+func (s *StrategyStore) remove(key string) bool {
+	e, found := s.strategy.Peek(key)
+	if !found || !s.strategy.Remove(key) {
+		return false
+	}
+	s.memoryBytes.Add(-entrySize(key, e))
+	if index := s.index.Load(); index != nil {
+		index.delete(key)
+	}
+	return true
+}
+
+// keyIndex returns the index of the stored keys, building it on first use
+func (s *StrategyStore) keyIndex() *keyIndex {
+	if index := s.index.Load(); index != nil {
+		return index
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if index := s.index.Load(); index != nil {
+		return index
+	}
+	index := newKeyIndex()
+	for _, key := range s.strategy.Keys() {
+		index.insert(key)
+	}
+	s.index.Store(index)
+	return index
+}
+
+// expiresBy lowers nextExpiry to expiresAt (assumes lock is held)
+func (s *StrategyStore) expiresBy(expiresAt *time.Time) {
+	if expiresAt != nil {
+		s.nextExpiry.Store(min(s.nextExpiry.Load(), expiresAt.UnixNano()))
+	}
+}
+
+// entrySize estimates the memory held by an entry: its key, its stored value
+// size and EntryOverhead
+func entrySize(key string, e *entry.Entry) int64 {
+	return int64(len(key)+e.Size()) + EntryOverhead
+}
+
+// findStrategy returns the first layer of strategy, following Base() through
+// wrappers, that implements T
+func findStrategy[T any](strategy eviction.Strategy) (T, bool) {
+	for {
+		if found, ok := strategy.(T); ok {
+			return found, true
+		}
+		wrapper, ok := strategy.(interface{ Base() eviction.Strategy })
+		if !ok {
+			var zero T
+			return zero, false
+		}
+		strategy = wrapper.Base()
+	}
+}
+
+// pinner returns the pinning layer of the strategy, or nil if it has none
+func (s *StrategyStore) pinner() eviction.Pinner {
+	pinner, _ := findStrategy[eviction.Pinner](s.strategy)
+	return pinner
+}
+
+// FrequencyReporter returns the strategy's per-key access counts, or nil if it does not count accesses
+func (s *StrategyStore) FrequencyReporter() eviction.FrequencyReporter {
+	reporter, _ := findStrategy[eviction.FrequencyReporter](s.strategy)
+	return reporter
+}
+
+// RecencyReporter returns the strategy's access ordering, or nil if it does not order keys by access
+func (s *StrategyStore) RecencyReporter() eviction.RecencyReporter {
+	reporter, _ := findStrategy[eviction.RecencyReporter](s.strategy)
+	return reporter
+}
+
+// Pin protects key from capacity eviction
+func (s *StrategyStore) Pin(key string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	pinner := s.pinner()
+	if pinner == nil {
+		return false
+	}
+	if entry, found := s.strategy.Peek(key); !found || entry.IsExpired() {
+		return false
+	}
+	return pinner.Pin(key)
+}
+
+// Unpin removes the eviction protection from key
+func (s *StrategyStore) Unpin(key string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	pinner := s.pinner()
+	return pinner != nil && pinner.Unpin(key)
+}
+
+// PinnedCount returns the number of pinned keys
+func (s *StrategyStore) PinnedCount() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if pinner := s.pinner(); pinner != nil {
+		return pinner.PinnedCount()
+	}
+	return 0
+}
+
+// notifyEvict calls the eviction callback for an evicted entry (assumes lock is held)
+// Entries that had already expired when the strategy displaced them are reported
+// through the cleanup callback, since their TTL rather than capacity removed them
+func (s *StrategyStore) notifyEvict(key string, entry *entry.Entry) {
+	if key == "" || entry == nil {
+		return
+	}
+	if entry.IsExpired() {
+		if s.cleanupCallback != nil {
+			s.cleanupCallback(key, entry)
+		}
+		return
+	}
+	if s.evictEntry != nil {
+		s.evictEntry(key, entry)
+		return
+	}
+	if s.evictCallback != nil {
+		s.evictCallback(key, entry)
+	}
+}
+
+// Swap stores an entry and returns the one it replaced under a single lock
+func (s *StrategyStore) Swap(key string, entry *entry.Entry) (*entry.Entry, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	previous, existed := s.strategy.Peek(key)
+	if existed && previous.IsExpired() {
+		previous, existed = nil, false
+	}
+
+	if err := s.add(key, entry); err != nil {
+		return nil, false, err
+	}
+
+	return previous, existed, nil
+}
+
+// SetIfNewer stores the entry unless a live entry with an equal or higher version exists
+func (s *StrategyStore) SetIfNewer(key string, entry *entry.Entry) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if current, found := s.strategy.Peek(key); found && !current.IsExpired() && current.Version >= entry.Version {
+		return false, nil
+	}
+
+	if err := s.add(key, entry); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
 // Delete removes an entry by key
 func (s *StrategyStore) Delete(key string) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	s.strategy.Remove(key)
+	s.remove(key)
+	return nil
+}
+
+// Evict removes up to n entries chosen by the eviction strategy
+func (s *StrategyStore) Evict(n int, fn store.EvictCallback) int {
+	if n <= 0 {
+		return 0
+	}
+
+	type evicted struct {
+		key   string
+		value any
+	}
+
+	s.mutex.Lock()
+	victims := s.strategy.Victims(n)
+	removed := make([]evicted, 0, len(victims))
+	for _, key := range victims {
+		entry, found := s.strategy.Peek(key)
+		if !found {
+			continue
+		}
+		if s.remove(key) {
+			removed = append(removed, evicted{key: key, value: entry.Value})
+		}
+	}
+	s.mutex.Unlock()
+
+	if fn != nil {
+		for _, e := range removed {
+			fn(e.key, e.value)
+		}
+	}
+	return len(removed)
+}
+
+// Victims returns up to n keys in the order the eviction policy would evict them
+func (s *StrategyStore) Victims(n int) []string {
+	if n <= 0 {
+		return []string{}
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.strategy.Victims(n)
+}
+
+// Resize changes the maximum number of entries
+// When shrinking, expired entries are cleaned up first and the remaining
+// overflow is evicted in policy order through the evict callback. Returns
+// eviction.ErrAllPinned without changing anything if pinned entries would
+// have to be evicted
+func (s *StrategyStore) Resize(capacity int) error {
+	if capacity <= 0 {
+		return fmt.Errorf("capacity must be positive, got %d", capacity)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	resizer, ok := findStrategy[eviction.Resizer](s.strategy)
+	if !ok {
+		return fmt.Errorf("eviction strategy does not support resizing")
+	}
+
+	if s.strategy.Len() > capacity {
+		s.cleanup()
+	}
+	if overflow := s.strategy.Len() - capacity; overflow > 0 {
+		victims := s.strategy.Victims(overflow)
+		if len(victims) < overflow {
+			return eviction.ErrAllPinned
+		}
+		for _, key := range victims {
+			if entry, found := s.strategy.Peek(key); found && s.remove(key) {
+				s.notifyEvict(key, entry)
+			}
+		}
+	}
+
+	resizer.Resize(capacity)
 	return nil
 }
 
@@ -117,28 +502,106 @@ func (s *StrategyStore) Keys() []string {
 }
 
 // Len returns the current number of entries in the store
+// Until an entry may have expired the strategy's count is exact; after that,
+// expired entries not yet cleaned up are left out by checking every entry
 func (s *StrategyStore) Len() int {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
+	now := time.Now()
+	if now.UnixNano() < s.nextExpiry.Load() {
+		return s.strategy.Len()
+	}
+
 	// Count only non-expired entries
 	count := 0
-	keys := s.strategy.Keys()
-	for _, key := range keys {
-		if entry, found := s.strategy.Peek(key); found && !entry.IsExpired() {
+	next := int64(math.MaxInt64)
+	for _, key := range s.strategy.Keys() {
+		entry, found := s.strategy.Peek(key)
+		if !found {
+			continue
+		}
+		expiresAt := entry.Expiry()
+		if expiresAt != nil {
+			next = min(next, expiresAt.UnixNano())
+		}
+		if expiresAt == nil || now.Before(*expiresAt) {
 			count++
 		}
 	}
+	// Writers hold the lock exclusively, so no entry was added during the walk
+	s.nextExpiry.Store(next)
 
 	return count
 }
 
+// Scan returns up to limit non-expired keys with the given prefix in lexical order
+// The cursor is the last key of the previous page, so iteration stays stable
+// while entries are added or removed between calls. Keys are walked in order
+// from the cursor, so a page costs O(log n + limit) plus any expired keys skipped
+func (s *StrategyStore) Scan(prefix string, cursor string, limit int) ([]string, string, error) {
+	if limit <= 0 {
+		return nil, "", fmt.Errorf("scan limit must be positive, got %d", limit)
+	}
+
+	s.keyIndex()
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	keys := make([]string, 0, min(limit, scanChunkSize))
+	for node := s.index.Load().from(prefix, cursor); node != nil && strings.HasPrefix(node.key, prefix); node = node.next[0] {
+		if entry, found := s.strategy.Peek(node.key); !found || entry.IsExpired() {
+			continue
+		}
+		if len(keys) == limit {
+			// Another matching key follows, so there is a next page
+			return keys, keys[limit-1], nil
+		}
+		keys = append(keys, node.key)
+	}
+	return keys, "", nil
+}
+
+// CountPrefix counts non-expired entries with the given prefix and sums their sizes
+// Only keys with the prefix are visited, in order and in chunks, so the store
+// lock is not held for the whole count
+func (s *StrategyStore) CountPrefix(prefix string) (int, int64) {
+	s.keyIndex()
+
+	var count int
+	var size int64
+	cursor := ""
+	for {
+		s.mutex.RLock()
+		node := s.index.Load().from(prefix, cursor)
+		for visited := 0; visited < scanChunkSize && node != nil && strings.HasPrefix(node.key, prefix); visited++ {
+			if entry, found := s.strategy.Peek(node.key); found && !entry.IsExpired() {
+				count++
+				size += int64(entry.Size())
+			}
+			cursor = node.key
+			node = node.next[0]
+		}
+		done := node == nil || !strings.HasPrefix(node.key, prefix)
+		s.mutex.RUnlock()
+
+		if done {
+			return count, size
+		}
+	}
+}
+
 // Clear removes all entries from the store
 func (s *StrategyStore) Clear() error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
 	s.strategy.Clear()
+	s.memoryBytes.Store(0)
+	s.nextExpiry.Store(math.MaxInt64)
+	if s.index.Load() != nil {
+		s.index.Store(newKeyIndex())
+	}
 	return nil
 }
 
@@ -152,18 +615,39 @@ func (s *StrategyStore) Close() error {
 	return s.Clear()
 }
 
+// Ping always succeeds; an in-process store has no backend to lose
+func (s *StrategyStore) Ping(ctx context.Context) error {
+	return nil
+}
+
 // SetEvictCallback sets the callback for evictions
 func (s *StrategyStore) SetEvictCallback(callback store.EvictCallback) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
-	s.evictCallback = callback
+	s.evictCallback = store.ValueCallback(callback)
+}
+
+// SetEvictEntryCallback sets a callback that receives whole entries displaced by
+// capacity instead of the evict callback, e.g. to move them to another tier
+func (s *StrategyStore) SetEvictEntryCallback(callback func(key string, e *entry.Entry)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.evictEntry = callback
 }
 
 // SetCleanupCallback sets the callback for TTL cleanup
 func (s *StrategyStore) SetCleanupCallback(callback store.EvictCallback) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
-	s.cleanupCallback = callback
+	s.cleanupCallback = store.ValueCallback(callback)
+}
+
+// SetEntryCallbacks sets the callbacks for evictions and TTL cleanup, passing them whole entries
+func (s *StrategyStore) SetEntryCallbacks(evict, cleanup store.EntryCallback) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.evictCallback = evict
+	s.cleanupCallback = cleanup
 }
 
 // Capacity returns the maximum number of entries the store can hold
@@ -171,21 +655,40 @@ func (s *StrategyStore) Capacity() int {
 	return s.strategy.Capacity()
 }
 
+// Weight returns the total stored size of the entries when MaxWeight is set, otherwise 0
+func (s *StrategyStore) Weight() int64 {
+	if weighted, ok := s.strategy.(eviction.WeightedStrategy); ok {
+		return weighted.Weight()
+	}
+	return 0
+}
+
+// MemoryBytes returns the approximate bytes held by the entries, including
+// expired ones not yet cleaned up
+func (s *StrategyStore) MemoryBytes() int64 {
+	return s.memoryBytes.Load()
+}
+
 // Cleanup removes expired entries and returns the number of entries removed
 func (s *StrategyStore) Cleanup() int {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
+	return s.cleanup()
+}
+
+// cleanup removes expired entries (internal method, assumes lock is held)
+func (s *StrategyStore) cleanup() int {
 	keys := s.strategy.Keys()
 	removed := 0
 
 	for _, key := range keys {
 		if entry, found := s.strategy.Peek(key); found && entry.IsExpired() {
-			s.strategy.Remove(key)
+			s.remove(key)
 			removed++
 
 			if s.cleanupCallback != nil {
-				s.cleanupCallback(key, entry.Value)
+				s.cleanupCallback(key, entry)
 			}
 		}
 	}
@@ -193,6 +696,25 @@ func (s *StrategyStore) Cleanup() int {
 	return removed
 }
 
+// UpdateTTL changes the expiration of an existing entry without touching its value
+// Uses Peek so the entry's eviction position and frequency are left unchanged
+func (s *StrategyStore) UpdateTTL(key string, ttl time.Duration) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry, found := s.strategy.Peek(key)
+	if !found || entry.IsExpired() {
+		return false
+	}
+
+	entry.UpdateExpiry(ttl)
+	s.expiresBy(entry.Expiry())
+	if tracker, ok := s.strategy.(eviction.ExpiryTracker); ok {
+		tracker.Reindex(key)
+	}
+	return true
+}
+
 // startCleanup starts the automatic cleanup goroutine
 func (s *StrategyStore) startCleanup(interval time.Duration) {
 	s.cleanupTicker = time.NewTicker(interval)
@@ -211,13 +733,32 @@ func (s *StrategyStore) startCleanup(interval time.Duration) {
 
 // GetEvictionType returns the eviction strategy type (convenience method for debugging)
 func (s *StrategyStore) GetEvictionType() string {
-	switch s.strategy.(type) {
+	strategy := s.strategy
+	for {
+		wrapper, ok := strategy.(interface{ Base() eviction.Strategy })
+		if !ok {
+			break
+		}
+		strategy = wrapper.Base()
+	}
+
+	switch strategy.(type) {
 	case *eviction.LRUStrategy:
 		return string(eviction.LRU)
 	case *eviction.LFUStrategy:
 		return string(eviction.LFU)
 	case *eviction.FIFOStrategy:
 		return string(eviction.FIFO)
+	case *eviction.TinyLFUStrategy:
+		return string(eviction.TinyLFU)
+	case *eviction.RandomStrategy:
+		return string(eviction.Random)
+	case *eviction.TTLFirstStrategy:
+		return string(eviction.TTLFirst)
+	case *eviction.ClockStrategy:
+		return string(eviction.Clock)
+	case *eviction.GDSFStrategy:
+		return string(eviction.GDSF)
 	default:
 		return "unknown"
 	}
@@ -225,7 +766,20 @@ func (s *StrategyStore) GetEvictionType() string {
 
 // Ensure StrategyStore implements the required interfaces
 var (
-	_ store.Store    = (*StrategyStore)(nil)
-	_ store.LRUStore = (*StrategyStore)(nil)
-	_ store.TTLStore = (*StrategyStore)(nil)
+	_ store.Store              = (*StrategyStore)(nil)
+	_ store.LRUStore           = (*StrategyStore)(nil)
+	_ store.TTLStore           = (*StrategyStore)(nil)
+	_ store.EvictStore         = (*StrategyStore)(nil)
+	_ store.PinStore           = (*StrategyStore)(nil)
+	_ store.ResizeStore        = (*StrategyStore)(nil)
+	_ store.AccessStore        = (*StrategyStore)(nil)
+	_ store.ScanStore          = (*StrategyStore)(nil)
+	_ store.CountStore         = (*StrategyStore)(nil)
+	_ store.WeightStore        = (*StrategyStore)(nil)
+	_ store.MemoryStore        = (*StrategyStore)(nil)
+	_ store.SwapStore          = (*StrategyStore)(nil)
+	_ store.VersionedStore     = (*StrategyStore)(nil)
+	_ store.BatchGetStore      = (*StrategyStore)(nil)
+	_ store.HealthChecker      = (*StrategyStore)(nil)
+	_ store.EntryCallbackStore = (*StrategyStore)(nil)
 )
@@ -0,0 +1,366 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"runtime"
+	"slices"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/internal/eviction"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+	"github.com/1mb-dev/obcache-go/v2/pkg/store"
+)
+
+// ShardedStore spreads keys across independent StrategyStores so concurrent
+// operations on different keys do not contend on a single lock
+// Each shard evicts on its own, so eviction order is only kept per shard
+type ShardedStore struct {
+	shards        []*StrategyStore
+	mask          uint64
+	cleanupTicker *time.Ticker
+	stopCleanup   chan struct{}
+}
+
+// ShardCount normalizes a requested shard count to a power of two
+// n <= 0 selects four shards per GOMAXPROCS
+func ShardCount(n int) int {
+	if n <= 0 {
+		n = runtime.GOMAXPROCS(0) * 4
+	}
+	count := 1
+	for count < n {
+		count <<= 1
+	}
+	return count
+}
+
+// ShardCountFor normalizes a requested shard count like ShardCount, then halves
+// it until every shard holds at least one of capacity entries, so small caches
+// are not rounded up to one entry per shard. A non-positive capacity is unbounded
+func ShardCountFor(n, capacity int) int {
+	count := ShardCount(n)
+	for capacity > 0 && count > capacity {
+		count >>= 1
+	}
+	return count
+}
+
+// ShareOf returns shard i's share of total split across shards, giving the
+// remainder to the first shards so the shares add up to total
+func ShareOf[T ~int | ~int64](total T, shards, i int) T {
+	share := total / T(shards)
+	if T(i) < total%T(shards) {
+		share++
+	}
+	return share
+}
+
+// NewSharded creates a sharded store with one shard per strategy
+// The number of strategies must be a power of two. A positive cleanupInterval
+// starts a single goroutine that cleans up every shard
+func NewSharded(strategies []eviction.Strategy, cleanupInterval time.Duration) (*ShardedStore, error) {
+	n := len(strategies)
+	if n == 0 || n&(n-1) != 0 {
+		return nil, fmt.Errorf("shard count must be a power of two, got %d", n)
+	}
+
+	s := &ShardedStore{
+		shards:      make([]*StrategyStore, n),
+		mask:        uint64(n - 1),
+		stopCleanup: make(chan struct{}),
+	}
+	for i, strategy := range strategies {
+		s.shards[i] = NewFromStrategy(strategy, 0)
+	}
+
+	if cleanupInterval > 0 {
+		s.startCleanup(cleanupInterval)
+	}
+
+	return s, nil
+}
+
+// NewShardedWithStrategy creates a sharded store using the built-in strategy described by config
+// The shard count is capped by ShardCountFor, and Capacity and MaxWeight are
+// split across shards with ShareOf, so the shards add up to the configured limits
+func NewShardedWithStrategy(config eviction.Config, shards int, cleanupInterval time.Duration) (*ShardedStore, error) {
+	shards = ShardCountFor(shards, config.Capacity)
+
+	strategies := make([]eviction.Strategy, shards)
+	for i := range strategies {
+		shardConfig := config
+		shardConfig.Capacity = max(ShareOf(config.Capacity, shards, i), 1)
+		if config.MaxWeight > 0 {
+			shardConfig.MaxWeight = max(ShareOf(config.MaxWeight, shards, i), 1)
+		}
+		strategies[i] = eviction.NewStrategy(shardConfig)
+	}
+	return NewSharded(strategies, cleanupInterval)
+}
+
+// shard returns the shard that owns key
+func (s *ShardedStore) shard(key string) *StrategyStore {
+	// FNV-1a, inlined to avoid allocating a hash.Hash per call
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(key); i++ {
+		h ^= uint64(key[i])
+		h *= 1099511628211
+	}
+	return s.shards[h&s.mask]
+}
+
+// ShardCount returns the number of shards
+func (s *ShardedStore) ShardCount() int {
+	return len(s.shards)
+}
+
+// Get retrieves an entry by key
+func (s *ShardedStore) Get(key string) (*entry.Entry, bool) {
+	return s.shard(key).Get(key)
+}
+
+// GetBatch retrieves the entries for keys with one pass per shard
+func (s *ShardedStore) GetBatch(keys []string) (map[string]*entry.Entry, error) {
+	byShard := make(map[*StrategyStore][]string)
+	for _, key := range keys {
+		shard := s.shard(key)
+		byShard[shard] = append(byShard[shard], key)
+	}
+
+	found := make(map[string]*entry.Entry, len(keys))
+	for shard, shardKeys := range byShard {
+		entries, _ := shard.GetBatch(shardKeys) // Memory shards do not fail
+		maps.Copy(found, entries)
+	}
+	return found, nil
+}
+
+// Peek retrieves an entry without affecting its eviction position or frequency
+func (s *ShardedStore) Peek(key string) (*entry.Entry, bool) {
+	return s.shard(key).Peek(key)
+}
+
+// Set stores an entry with the given key
+func (s *ShardedStore) Set(key string, entry *entry.Entry) error {
+	return s.shard(key).Set(key, entry)
+}
+
+// Swap stores an entry and returns the one it replaced
+func (s *ShardedStore) Swap(key string, entry *entry.Entry) (*entry.Entry, bool, error) {
+	return s.shard(key).Swap(key, entry)
+}
+
+// SetIfNewer stores the entry unless a live entry has an equal or higher version
+func (s *ShardedStore) SetIfNewer(key string, entry *entry.Entry) (bool, error) {
+	return s.shard(key).SetIfNewer(key, entry)
+}
+
+// Delete removes an entry by key
+func (s *ShardedStore) Delete(key string) error {
+	return s.shard(key).Delete(key)
+}
+
+// Pin protects key from capacity eviction
+func (s *ShardedStore) Pin(key string) bool {
+	return s.shard(key).Pin(key)
+}
+
+// Unpin removes the eviction protection from key
+func (s *ShardedStore) Unpin(key string) bool {
+	return s.shard(key).Unpin(key)
+}
+
+// PinnedCount returns the number of pinned keys across all shards
+func (s *ShardedStore) PinnedCount() int {
+	count := 0
+	for _, shard := range s.shards {
+		count += shard.PinnedCount()
+	}
+	return count
+}
+
+// UpdateTTL changes the expiration of an existing entry
+func (s *ShardedStore) UpdateTTL(key string, ttl time.Duration) bool {
+	return s.shard(key).UpdateTTL(key, ttl)
+}
+
+// Keys returns all non-expired keys across all shards
+func (s *ShardedStore) Keys() []string {
+	var keys []string
+	for _, shard := range s.shards {
+		keys = append(keys, shard.Keys()...)
+	}
+	return keys
+}
+
+// Len returns the number of non-expired entries across all shards
+func (s *ShardedStore) Len() int {
+	count := 0
+	for _, shard := range s.shards {
+		count += shard.Len()
+	}
+	return count
+}
+
+// Scan returns up to limit non-expired keys with the given prefix in lexical order
+// Every shard is scanned from the same cursor and the pages are merged
+func (s *ShardedStore) Scan(prefix string, cursor string, limit int) ([]string, string, error) {
+	if limit <= 0 {
+		return nil, "", fmt.Errorf("scan limit must be positive, got %d", limit)
+	}
+
+	var keys []string
+	hasMore := false
+	for _, shard := range s.shards {
+		page, next, err := shard.Scan(prefix, cursor, limit)
+		if err != nil {
+			return nil, "", err
+		}
+		keys = append(keys, page...)
+		hasMore = hasMore || next != ""
+	}
+
+	slices.Sort(keys)
+	if len(keys) > limit {
+		keys = keys[:limit]
+		hasMore = true
+	}
+	if hasMore {
+		return keys, keys[len(keys)-1], nil
+	}
+	return keys, "", nil
+}
+
+// CountPrefix counts non-expired entries with the given prefix across all shards
+func (s *ShardedStore) CountPrefix(prefix string) (int, int64) {
+	var count int
+	var size int64
+	for _, shard := range s.shards {
+		c, sz := shard.CountPrefix(prefix)
+		count += c
+		size += sz
+	}
+	return count, size
+}
+
+// Clear removes all entries from every shard
+func (s *ShardedStore) Clear() error {
+	for _, shard := range s.shards {
+		if err := shard.Clear(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close stops automatic cleanup and closes every shard
+func (s *ShardedStore) Close() error {
+	if s.cleanupTicker != nil {
+		s.cleanupTicker.Stop()
+	}
+	close(s.stopCleanup)
+
+	var firstErr error
+	for _, shard := range s.shards {
+		if err := shard.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Ping always succeeds; an in-process store has no backend to lose
+func (s *ShardedStore) Ping(ctx context.Context) error {
+	return nil
+}
+
+// SetEvictCallback sets the eviction callback on every shard
+func (s *ShardedStore) SetEvictCallback(callback store.EvictCallback) {
+	for _, shard := range s.shards {
+		shard.SetEvictCallback(callback)
+	}
+}
+
+// SetEvictEntryCallback sets the evicted entry callback on every shard
+func (s *ShardedStore) SetEvictEntryCallback(callback func(key string, e *entry.Entry)) {
+	for _, shard := range s.shards {
+		shard.SetEvictEntryCallback(callback)
+	}
+}
+
+// SetCleanupCallback sets the TTL cleanup callback on every shard
+func (s *ShardedStore) SetCleanupCallback(callback store.EvictCallback) {
+	for _, shard := range s.shards {
+		shard.SetCleanupCallback(callback)
+	}
+}
+
+// Capacity returns the total capacity of all shards
+func (s *ShardedStore) Capacity() int {
+	capacity := 0
+	for _, shard := range s.shards {
+		capacity += shard.Capacity()
+	}
+	return capacity
+}
+
+// Weight returns the total stored size of the entries in all shards
+func (s *ShardedStore) Weight() int64 {
+	var weight int64
+	for _, shard := range s.shards {
+		weight += shard.Weight()
+	}
+	return weight
+}
+
+// MemoryBytes returns the approximate bytes held by the entries in all shards
+func (s *ShardedStore) MemoryBytes() int64 {
+	var total int64
+	for _, shard := range s.shards {
+		total += shard.MemoryBytes()
+	}
+	return total
+}
+
+// Cleanup removes expired entries from every shard and returns the number removed
+func (s *ShardedStore) Cleanup() int {
+	removed := 0
+	for _, shard := range s.shards {
+		removed += shard.Cleanup()
+	}
+	return removed
+}
+
+// startCleanup starts the automatic cleanup goroutine
+func (s *ShardedStore) startCleanup(interval time.Duration) {
+	s.cleanupTicker = time.NewTicker(interval)
+
+	go func() {
+		for {
+			select {
+			case <-s.cleanupTicker.C:
+				s.Cleanup()
+			case <-s.stopCleanup:
+				return
+			}
+		}
+	}()
+}
+
+// Ensure ShardedStore implements the required interfaces
+var (
+	_ store.Store          = (*ShardedStore)(nil)
+	_ store.LRUStore       = (*ShardedStore)(nil)
+	_ store.TTLStore       = (*ShardedStore)(nil)
+	_ store.PinStore       = (*ShardedStore)(nil)
+	_ store.ScanStore      = (*ShardedStore)(nil)
+	_ store.CountStore     = (*ShardedStore)(nil)
+	_ store.WeightStore    = (*ShardedStore)(nil)
+	_ store.MemoryStore    = (*ShardedStore)(nil)
+	_ store.SwapStore      = (*ShardedStore)(nil)
+	_ store.VersionedStore = (*ShardedStore)(nil)
+	_ store.BatchGetStore  = (*ShardedStore)(nil)
+	_ store.HealthChecker  = (*ShardedStore)(nil)
+)
@@ -0,0 +1,299 @@
+package redis
+
+import (
+	"errors"
+	"maps"
+	"runtime"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/1mb-dev/obcache-go/v2/internal/envelope"
+	"github.com/1mb-dev/obcache-go/v2/pkg/codec"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+	"github.com/1mb-dev/obcache-go/v2/pkg/store"
+)
+
+// DefaultMaxBatchSize is the maximum number of commands SetBatch and DeleteBatch
+// send in one pipeline, and of keys GetBatch reads with one MGET
+const DefaultMaxBatchSize = 1000
+
+// GetBatch retrieves the entries for keys with MGET, one round trip per MaxBatchSize keys
+// Missing, expired and undecodable keys are left out of the result. Entries are
+// deserialized concurrently. On Redis Cluster, keys read together must hash to
+// the same slot
+func (s *Store) GetBatch(keys []string) (map[string]*entry.Entry, error) {
+	if s.degraded() {
+		return store.GetMany(s.breaker.fallback, keys), nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	found := make(map[string]*entry.Entry, len(keys))
+	for start := 0; start < len(keys); start += s.maxBatchSize {
+		chunk := keys[start:min(start+s.maxBatchSize, len(keys))]
+		values, ttls, err := s.mget(chunk)
+		if s.observe(err) {
+			maps.Copy(found, store.GetMany(s.breaker.fallback, keys[start:]))
+			return found, nil
+		}
+		if err != nil {
+			return found, err
+		}
+
+		var stale []string
+		entries, errs := s.decodeBatch(values)
+		for i, e := range entries {
+			key := chunk[i]
+			if e == nil {
+				if errs[i] != nil && !errors.Is(errs[i], codec.ErrUnknownCodec) {
+					stale = append(stale, s.buildKey(key)) // Remove corrupted values, as Get does
+				}
+				continue
+			}
+			if remaining, err := ttls[i].Result(); err == nil {
+				applyRedisTTL(e, remaining)
+			}
+			if e.IsExpired() {
+				stale = append(stale, s.buildKey(key))
+				if s.cleanupCallback != nil {
+					go s.cleanupCallback(key, e)
+				}
+				continue
+			}
+			e.Touch()
+			found[key] = e
+		}
+		if len(stale) > 0 {
+			s.client.Del(s.ctx, stale...)
+		}
+	}
+	return found, nil
+}
+
+// mget reads keys with one MGET, pipelined with a PTTL per key since Redis
+// owns the expiration. Transient failover errors are retried
+func (s *Store) mget(keys []string) ([]any, []*redis.DurationCmd, error) {
+	redisKeys := make([]string, len(keys))
+	for i, key := range keys {
+		redisKeys[i] = s.buildKey(key)
+	}
+
+	var mgetCmd *redis.SliceCmd
+	ttlCmds := make([]*redis.DurationCmd, len(keys))
+	err := s.retry(func() error {
+		pipe := s.client.Pipeline()
+		mgetCmd = pipe.MGet(s.ctx, redisKeys...)
+		for i, redisKey := range redisKeys {
+			ttlCmds[i] = pipe.PTTL(s.ctx, redisKey)
+		}
+		_, _ = pipe.Exec(s.ctx) // Errors are inspected per command below
+		return mgetCmd.Err()
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return mgetCmd.Val(), ttlCmds, nil
+}
+
+// decodeBatchWorkers caps the goroutines used to deserialize one MGET reply
+var decodeBatchWorkers = runtime.GOMAXPROCS(0)
+
+// decodeBatchMinSize is the reply size below which entries are deserialized serially
+const decodeBatchMinSize = 64
+
+// decodeBatch deserializes MGET values in parallel; misses and values that
+// fail to deserialize are nil in the result, the latter with their error
+func (s *Store) decodeBatch(values []any) ([]*entry.Entry, []error) {
+	entries := make([]*entry.Entry, len(values))
+	errs := make([]error, len(values))
+	decode := func(i int) {
+		if data, ok := values[i].(string); ok {
+			entries[i], errs[i] = envelope.Unmarshal([]byte(data), s.codec)
+		}
+	}
+
+	workers := min(decodeBatchWorkers, len(values)/decodeBatchMinSize)
+	if workers <= 1 {
+		for i := range values {
+			decode(i)
+		}
+		return entries, errs
+	}
+
+	var wg sync.WaitGroup
+	size := (len(values) + workers - 1) / workers
+	for start := 0; start < len(values); start += size {
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				decode(i)
+			}
+		}(start, min(start+size, len(values)))
+	}
+	wg.Wait()
+	return entries, errs
+}
+
+// SetBatch stores all entries with pipelined SET commands, one round trip per MaxBatchSize keys
+// Entries that fail to serialize or whose command fails are reported in a *store.BatchError
+func (s *Store) SetBatch(entries map[string]*entry.Entry) error {
+	if s.degraded() {
+		failed := make(map[string]error)
+		s.fallbackBatch(slices.Collect(maps.Keys(entries)), failed, func(key string) error {
+			return s.setFallback(key, entries[key])
+		})
+		return store.JoinBatchErrors(failed)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	failed := make(map[string]error)
+	data := make(map[string]string, len(entries))
+	for key, e := range entries {
+		serialized, err := envelope.Marshal(e, s.codec)
+		if err != nil {
+			failed[key] = err
+			continue
+		}
+		data[key] = string(serialized)
+	}
+
+	// Expiry is decided once so that each SET is expected once, however often
+	// it is resent, and forgotten unless it reached Redis
+	keys := slices.Sorted(maps.Keys(data))
+	ttls := make(map[string]time.Duration, len(keys))
+	for _, key := range keys {
+		if redisTTL, expired := s.redisTTL(entries[key]); !expired {
+			ttls[key] = redisTTL
+			s.ownWrites.expect(s.buildKey(key))
+		}
+	}
+	forget := func(key string) {
+		if _, ok := ttls[key]; ok {
+			s.ownWrites.forget(s.buildKey(key))
+			delete(ttls, key) // Keys are not resent once they went to the fallback
+		}
+	}
+	s.execBatch(keys, failed, func(pipe redis.Pipeliner, key string) redis.Cmder {
+		redisKey := s.buildKey(key)
+		redisTTL, ok := ttls[key]
+		if !ok {
+			return pipe.Del(s.ctx, redisKey)
+		}
+		return pipe.Set(s.ctx, redisKey, data[key], redisTTL)
+	}, func(key string) error {
+		forget(key)
+		return s.setFallback(key, entries[key])
+	})
+	for key := range failed {
+		forget(key)
+	}
+
+	return store.JoinBatchErrors(failed)
+}
+
+// DeleteBatch removes all keys with pipelined DEL commands, one round trip per MaxBatchSize keys
+// A DEL per key keeps failures attributable to single keys and works across cluster slots
+func (s *Store) DeleteBatch(keys []string) error {
+	if s.degraded() {
+		failed := make(map[string]error)
+		s.fallbackBatch(keys, failed, s.deleteFallback)
+		return store.JoinBatchErrors(failed)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	failed := make(map[string]error)
+	s.execBatch(keys, failed, func(pipe redis.Pipeliner, key string) redis.Cmder {
+		return pipe.Del(s.ctx, s.buildKey(key))
+	}, s.deleteFallback)
+
+	return store.JoinBatchErrors(failed)
+}
+
+// fallbackBatch applies write to the fallback store for every key while the
+// circuit is open, recording the keys it fails for in failed
+func (s *Store) fallbackBatch(keys []string, failed map[string]error, write func(key string) error) {
+	for _, key := range keys {
+		if err := write(key); err != nil {
+			failed[key] = err
+		}
+	}
+}
+
+// execBatch queues one command per key in pipelines of at most maxBatchSize commands
+// Keys whose command fails with a retryable error are resent like single-key writes;
+// keys that still fail are recorded in failed. If the failures open the circuit,
+// the failed keys and those not yet sent are passed to fallback instead
+func (s *Store) execBatch(keys []string, failed map[string]error, queue func(pipe redis.Pipeliner, key string) redis.Cmder, fallback func(key string) error) {
+	for start := 0; start < len(keys); start += s.maxBatchSize {
+		chunk := keys[start:min(start+s.maxBatchSize, len(keys))]
+		pending := chunk
+		errs := make(map[string]error)
+
+		_ = s.retry(func() error {
+			pipe := s.client.Pipeline()
+			cmds := make([]redis.Cmder, len(pending))
+			for i, key := range pending {
+				cmds[i] = queue(pipe, key)
+			}
+			// Errors are inspected per command below, but a dropped connection
+			// is only reported by Exec and applies to every unanswered command
+			_, execErr := pipe.Exec(s.ctx)
+			var replyErr redis.Error
+			if errors.As(execErr, &replyErr) {
+				execErr = nil
+			}
+
+			var retryErr error
+			var retryable []string
+			for i, cmd := range cmds {
+				key := pending[i]
+				err := cmd.Err()
+				if err == nil {
+					err = execErr
+				}
+				if err != nil {
+					errs[key] = err
+					if isRetryable(err) {
+						retryable = append(retryable, key)
+						retryErr = err
+					}
+					continue
+				}
+				delete(errs, key)
+			}
+			pending = retryable
+			return retryErr
+		})
+
+		if s.observe(backendFailure(errs)) {
+			// Keys Redis accepted stay there; the rest go to the fallback
+			rest := slices.DeleteFunc(slices.Clone(chunk), func(key string) bool {
+				_, ok := errs[key]
+				return !ok
+			})
+			s.fallbackBatch(slices.Concat(rest, keys[start+len(chunk):]), failed, fallback)
+			return
+		}
+		maps.Copy(failed, errs)
+	}
+}
+
+// backendFailure returns one of errs that means Redis is unavailable, or nil
+// if there is none, so a pipeline counts once towards opening the circuit
+func backendFailure(errs map[string]error) error {
+	for _, err := range errs {
+		if isBackendFailure(err) {
+			return err
+		}
+	}
+	return nil
+}
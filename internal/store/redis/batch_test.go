@@ -0,0 +1,207 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/1mb-dev/obcache-go/v2/internal/envelope"
+	"github.com/1mb-dev/obcache-go/v2/pkg/codec"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+	"github.com/1mb-dev/obcache-go/v2/pkg/store"
+)
+
+func newBatchTestStore(tb testing.TB, maxBatchSize int) *Store {
+	tb.Helper()
+	client := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		tb.Skipf("Redis not available, skipping test: %v", err)
+	}
+
+	s, err := New(&Config{Client: client, KeyPrefix: "batch-test:", MaxBatchSize: maxBatchSize})
+	if err != nil {
+		tb.Fatalf("Failed to create Redis store: %v", err)
+	}
+	_ = s.Clear()
+	tb.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func TestRedisStoreBatch(t *testing.T) {
+	s := newBatchTestStore(t, 3) // Smaller than the batch so it is sent in chunks
+
+	entries := make(map[string]*entry.Entry)
+	keys := make([]string, 0, 10)
+	for i := range 10 {
+		key := fmt.Sprintf("key%d", i)
+		entries[key] = entry.New(i, time.Hour)
+		keys = append(keys, key)
+	}
+	expired := entry.New("gone", time.Hour)
+	past := time.Now().Add(-time.Second)
+	expired.ExpiresAt = &past
+	entries["expired"] = expired
+
+	if err := s.SetBatch(entries); err != nil {
+		t.Fatalf("SetBatch failed: %v", err)
+	}
+	if s.Len() != 10 {
+		t.Fatalf("Expected 10 entries, got %d", s.Len())
+	}
+	if e, found := s.Get("key7"); !found || e.Value != float64(7) || e.TTL() <= 0 {
+		t.Fatalf("Expected key7 with a TTL, got %+v (found=%v)", e, found)
+	}
+
+	if err := s.DeleteBatch(keys[:8]); err != nil {
+		t.Fatalf("DeleteBatch failed: %v", err)
+	}
+	if s.Len() != 2 {
+		t.Fatalf("Expected 2 entries after DeleteBatch, got %d", s.Len())
+	}
+}
+
+func TestRedisStoreBatchReportsFailedKeys(t *testing.T) {
+	server := newFailoverServer(t, 1)
+	s := newFailoverStore(t, server, 0)
+	s.maxBatchSize = 2
+
+	err := s.SetBatch(map[string]*entry.Entry{
+		"a": entry.NewWithoutTTL("1"),
+		"b": entry.NewWithoutTTL("2"),
+		"c": entry.NewWithoutTTL("3"),
+	})
+
+	var batchErr *store.BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("Expected a BatchError, got %v", err)
+	}
+	if keys := batchErr.Keys(); len(keys) != 1 || keys[0] != "a" {
+		t.Fatalf("Expected only the first key to fail, got %v", keys)
+	}
+	if !redis.IsReadOnlyError(batchErr.Errors["a"]) {
+		t.Errorf("Expected the READONLY reply for a, got %v", batchErr.Errors["a"])
+	}
+	if _, found := s.Get("b"); !found {
+		t.Error("Expected b to be written despite a failing")
+	}
+}
+
+func TestRedisStoreBatchRetriesFailedKeys(t *testing.T) {
+	server := newFailoverServer(t, 1)
+	s := newFailoverStore(t, server, 1)
+
+	err := s.SetBatch(map[string]*entry.Entry{
+		"a": entry.NewWithoutTTL("1"),
+		"b": entry.NewWithoutTTL("2"),
+	})
+	if err != nil {
+		t.Fatalf("Expected SetBatch to succeed after a retry, got %v", err)
+	}
+	if server.sets != 3 {
+		t.Errorf("Expected only the failed key to be resent, got %d SETs", server.sets)
+	}
+}
+
+func TestRedisStoreGetBatch(t *testing.T) {
+	server := newFailoverServer(t, 0)
+	s := newFailoverStore(t, server, 0)
+	s.maxBatchSize = 2
+
+	for i := range 5 {
+		if err := s.Set(fmt.Sprintf("key%d", i), entry.NewWithoutTTL(fmt.Sprintf("value%d", i))); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+	server.mu.Lock()
+	server.values[s.buildKey("corrupt")] = "not json"
+	server.mu.Unlock()
+
+	found, err := s.GetBatch([]string{"key0", "key1", "missing", "key3", "corrupt", "key4"})
+	if err != nil {
+		t.Fatalf("GetBatch failed: %v", err)
+	}
+	if len(found) != 4 {
+		t.Fatalf("Expected 4 entries, got %d: %v", len(found), found)
+	}
+	for _, i := range []int{0, 1, 3, 4} {
+		key := fmt.Sprintf("key%d", i)
+		if e, ok := found[key]; !ok || e.Value != fmt.Sprintf("value%d", i) {
+			t.Errorf("Expected %s to be found, got %v (found=%v)", key, e, ok)
+		}
+	}
+	if _, ok := server.value(s.buildKey("corrupt")); ok {
+		t.Error("Expected corrupted entry to be deleted")
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.mgets != 3 {
+		t.Errorf("Expected 6 keys to be read with 3 MGETs, got %d", server.mgets)
+	}
+}
+
+func TestRedisStoreDecodeBatchConcurrently(t *testing.T) {
+	s := &Store{codec: codec.JSON{}}
+	values := make([]any, 500)
+	for i := range values {
+		if i%7 == 0 {
+			continue // Miss
+		}
+		data, err := envelope.Marshal(entry.NewWithoutTTL(i), s.codec)
+		if err != nil {
+			t.Fatalf("Failed to serialize entry: %v", err)
+		}
+		values[i] = string(data)
+	}
+
+	entries, _ := s.decodeBatch(values)
+	for i, e := range entries {
+		switch {
+		case i%7 == 0 && e != nil:
+			t.Fatalf("Expected a miss at %d, got %v", i, e)
+		case i%7 != 0 && (e == nil || e.Value != float64(i)):
+			t.Fatalf("Expected %d at %d, got %v", i, i, e)
+		}
+	}
+}
+
+const benchmarkBatchEntries = 10000
+
+func benchmarkEntries() map[string]*entry.Entry {
+	entries := make(map[string]*entry.Entry, benchmarkBatchEntries)
+	for i := range benchmarkBatchEntries {
+		entries[fmt.Sprintf("key%d", i)] = entry.New(i, time.Hour)
+	}
+	return entries
+}
+
+// BenchmarkRedisSetPerKey stores 10k entries with one round trip each
+func BenchmarkRedisSetPerKey(b *testing.B) {
+	s := newBatchTestStore(b, 0)
+	entries := benchmarkEntries()
+
+	b.ResetTimer()
+	for b.Loop() {
+		for key, e := range entries {
+			if err := s.Set(key, e); err != nil {
+				b.Fatalf("Set failed: %v", err)
+			}
+		}
+	}
+}
+
+// BenchmarkRedisSetBatch stores the same 10k entries with pipelined SetBatch
+func BenchmarkRedisSetBatch(b *testing.B) {
+	s := newBatchTestStore(b, 0)
+	entries := benchmarkEntries()
+
+	b.ResetTimer()
+	for b.Loop() {
+		if err := s.SetBatch(entries); err != nil {
+			b.Fatalf("SetBatch failed: %v", err)
+		}
+	}
+}
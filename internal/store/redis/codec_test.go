@@ -0,0 +1,138 @@
+package redis
+
+import (
+	"bytes"
+	"encoding/gob"
+	"strings"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/codec"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+)
+
+type codecPoint struct {
+	X, Y int
+}
+
+func init() {
+	gob.Register(codecPoint{})
+}
+
+func newCodecStore(t *testing.T, server *failoverServer, c codec.Codec) *Store {
+	t.Helper()
+	client := redis.NewClient(&redis.Options{
+		Addr:            server.listener.Addr().String(),
+		Protocol:        2,
+		DisableIdentity: true,
+	})
+	t.Cleanup(func() { _ = client.Close() })
+
+	s, err := New(&Config{Client: client, Codec: c})
+	if err != nil {
+		t.Fatalf("Failed to create Redis store: %v", err)
+	}
+	return s
+}
+
+func TestRedisStoreGobCodecKeepsConcreteTypes(t *testing.T) {
+	server := newFailoverServer(t, 0)
+	s := newCodecStore(t, server, codec.Gob{})
+
+	if err := s.Set("point", entry.NewWithoutTTL(codecPoint{X: 1, Y: 2})); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	e, found := s.Get("point")
+	if !found {
+		t.Fatal("Expected gob entry to be found")
+	}
+	if p, ok := e.Value.(codecPoint); !ok || p != (codecPoint{X: 1, Y: 2}) {
+		t.Errorf("Expected codecPoint{1 2}, got %T %v", e.Value, e.Value)
+	}
+
+	// A store configured with the default codec decodes it through the registry
+	reader := newCodecStore(t, server, nil)
+	if e, found := reader.Get("point"); !found || e.Value != (codecPoint{X: 1, Y: 2}) {
+		t.Errorf("Expected JSON store to read gob entry, got %v (found=%v)", e, found)
+	}
+}
+
+func TestRedisStoreJSONEntriesStayReadable(t *testing.T) {
+	server := newFailoverServer(t, 0)
+	s := newCodecStore(t, server, codec.Gob{})
+	server.mu.Lock()
+	server.values[s.buildKey("legacy")] = `{"value":"hello","created_at":"2024-01-01T00:00:00Z","last_access":"2024-01-01T00:00:00Z"}`
+	server.mu.Unlock()
+
+	if e, found := s.Get("legacy"); !found || e.Value != "hello" {
+		t.Errorf("Expected entry written without a codec to decode as JSON, got %v (found=%v)", e, found)
+	}
+}
+
+func TestRedisStoreUnknownCodecIsMissButKept(t *testing.T) {
+	server := newFailoverServer(t, 0)
+	const stored = `{"data":"AQID","codec":"protobuf","created_at":"2024-01-01T00:00:00Z","last_access":"2024-01-01T00:00:00Z"}`
+	s := newCodecStore(t, server, nil)
+	server.mu.Lock()
+	server.values[s.buildKey("other")] = stored
+	server.mu.Unlock()
+
+	if _, found := s.Get("other"); found {
+		t.Error("Expected entry with an unknown codec to be a miss")
+	}
+	if found, err := s.GetBatch([]string{"other"}); err != nil || len(found) != 0 {
+		t.Errorf("Expected GetBatch miss, got %v (err=%v)", found, err)
+	}
+	if value, ok := server.value(s.buildKey("other")); !ok || value != stored {
+		t.Error("Expected entry with an unknown codec to be left for instances that can read it")
+	}
+}
+
+func TestRedisStoreRawBytesSkipCodec(t *testing.T) {
+	server := newFailoverServer(t, 0)
+	s := newCodecStore(t, server, nil)
+
+	original := []byte{0x00, 0xff, '{', '"'}
+	if err := s.Set("bytes", entry.NewWithoutTTL(original)); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	e, found := s.Get("bytes")
+	if !found {
+		t.Fatal("Expected the entry to be found")
+	}
+	if b, ok := e.Value.([]byte); !ok || !bytes.Equal(b, original) {
+		t.Errorf("Expected the bytes back unchanged, got %T %v", e.Value, e.Value)
+	}
+
+	stored, _ := server.value(s.buildKey("bytes"))
+	if !strings.Contains(stored, `"raw":true`) || strings.Contains(stored, `"value"`) {
+		t.Errorf("Expected the bytes stored raw instead of JSON-encoded, got %s", stored)
+	}
+
+	_ = s.Set("empty", entry.NewWithoutTTL([]byte{}))
+	if e, _ := s.Get("empty"); e == nil || e.Value == nil {
+		t.Errorf("Expected an empty slice back, got %v", e)
+	}
+}
+
+func TestRedisStoreKeepsCompressionMetadata(t *testing.T) {
+	server := newFailoverServer(t, 0)
+	s := newCodecStore(t, server, nil)
+
+	e := entry.NewWithoutTTL([]byte("compressed"))
+	e.CodecName = "json"
+	e.SetCompressionInfo("zstd", 100, 10)
+	if err := s.Set("compressed", e); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	got, found := s.Get("compressed")
+	if !found {
+		t.Fatal("Expected the entry to be found")
+	}
+	if !got.IsCompressed || got.CompressorName != "zstd" || got.CodecName != "json" || got.OriginalSize != 100 || got.CompressedSize != 10 {
+		t.Errorf("Expected the compression metadata to survive, got %+v", got)
+	}
+}
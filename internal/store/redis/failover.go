@@ -0,0 +1,351 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"net"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/1mb-dev/obcache-go/v2/internal/envelope"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+	"github.com/1mb-dev/obcache-go/v2/pkg/store"
+)
+
+const (
+	// DefaultFailureThreshold is the number of consecutive backend errors that open the circuit
+	DefaultFailureThreshold = 5
+
+	// DefaultProbeInterval is how often Redis is pinged while the circuit is open
+	DefaultProbeInterval = time.Second
+)
+
+// FailoverConfig enables a circuit breaker that serves Get, Peek, Set, Swap,
+// SetIfNewer, Delete and UpdateTTL from a local store while Redis is unreachable.
+// Swap and SetIfNewer only see entries written to the fallback during the
+// outage, so they report keys held only by Redis as absent
+type FailoverConfig struct {
+	// Fallback is the store used while the circuit is open, typically a
+	// size-bounded memory store. It is closed with the Redis store
+	Fallback store.Store
+
+	// FailureThreshold is the number of consecutive backend errors that open the circuit
+	// Default: 5
+	FailureThreshold int
+
+	// ProbeInterval is how often Redis is pinged while the circuit is open; the
+	// first successful PING closes it. Each ping times out after one interval
+	// Default: 1 second
+	ProbeInterval time.Duration
+
+	// Replay copies entries written to the fallback during the outage back to
+	// Redis when the circuit closes, overwriting what Redis held for those keys,
+	// and deletes from Redis the keys deleted during the outage. Without Replay
+	// every key written or deleted during the outage is deleted from Redis, so
+	// readers miss instead of seeing the value from before the outage
+	Replay bool
+
+	// OnStateChange is called with true when the circuit opens and false when it closes
+	OnStateChange func(open bool)
+}
+
+// breaker tracks consecutive backend failures and the fallback store they route to
+type breaker struct {
+	fallback      store.Store
+	threshold     int64
+	probeInterval time.Duration
+	replay        bool
+	onStateChange func(open bool)
+
+	open     atomic.Bool
+	failures atomic.Int64
+
+	mu        sync.Mutex
+	stop      chan struct{}
+	probeDone chan struct{} // Closed when the current probe goroutine exits
+
+	// Keys written or deleted in the fallback that Redis has not caught up with
+	dirtyMu sync.Mutex
+	dirty   map[string]struct{}
+}
+
+// newBreaker creates a closed circuit breaker from config
+func newBreaker(config *FailoverConfig) *breaker {
+	threshold := config.FailureThreshold
+	if threshold <= 0 {
+		threshold = DefaultFailureThreshold
+	}
+	probeInterval := config.ProbeInterval
+	if probeInterval <= 0 {
+		probeInterval = DefaultProbeInterval
+	}
+
+	return &breaker{
+		fallback:      config.Fallback,
+		threshold:     int64(threshold),
+		probeInterval: probeInterval,
+		replay:        config.Replay,
+		onStateChange: config.OnStateChange,
+		stop:          make(chan struct{}),
+		dirty:         make(map[string]struct{}),
+	}
+}
+
+// markDirty records that key changed in the fallback while the circuit was open
+func (b *breaker) markDirty(key string) {
+	b.dirtyMu.Lock()
+	defer b.dirtyMu.Unlock()
+	b.dirty[key] = struct{}{}
+}
+
+// dirtyKeys returns the keys Redis has not caught up with
+func (b *breaker) dirtyKeys() []string {
+	b.dirtyMu.Lock()
+	defer b.dirtyMu.Unlock()
+	return slices.Collect(maps.Keys(b.dirty))
+}
+
+// clean records that Redis has caught up with keys
+func (b *breaker) clean(keys []string) {
+	b.dirtyMu.Lock()
+	defer b.dirtyMu.Unlock()
+	for _, key := range keys {
+		delete(b.dirty, key)
+	}
+}
+
+// isBackendFailure reports whether err means Redis is unavailable, as opposed to
+// a miss or an error in the command itself
+func isBackendFailure(err error) bool {
+	if isRetryable(err) {
+		return true
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, redis.ErrPoolTimeout) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// degraded reports whether the circuit is open and requests go to the fallback
+func (s *Store) degraded() bool {
+	return s.breaker != nil && s.breaker.open.Load()
+}
+
+// IsDegraded reports whether Redis is considered unavailable and requests are
+// served from the fallback store
+func (s *Store) IsDegraded() bool {
+	return s.degraded()
+}
+
+// observe records the outcome of a Redis call and reports whether the circuit
+// is now open, in which case the caller should fall back
+func (s *Store) observe(err error) bool {
+	b := s.breaker
+	if b == nil {
+		return false
+	}
+	if !isBackendFailure(err) {
+		b.failures.Store(0)
+		return false
+	}
+	if b.failures.Add(1) >= b.threshold {
+		s.openCircuit()
+	}
+	return b.open.Load()
+}
+
+// openCircuit routes requests to the fallback and starts probing Redis
+func (s *Store) openCircuit() {
+	b := s.breaker
+	b.mu.Lock()
+	select {
+	case <-b.stop:
+		b.mu.Unlock()
+		return // Closing
+	default:
+	}
+	if !b.open.CompareAndSwap(false, true) {
+		b.mu.Unlock()
+		return
+	}
+	done := make(chan struct{})
+	b.probeDone = done
+	b.mu.Unlock()
+
+	go s.probe(done)
+	if b.onStateChange != nil {
+		b.onStateChange(true)
+	}
+}
+
+// probe pings Redis every probe interval until it answers, then closes the circuit
+func (s *Store) probe(done chan struct{}) {
+	defer close(done)
+	b := s.breaker
+
+	ticker := time.NewTicker(b.probeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(s.ctx, b.probeInterval)
+			err := s.client.Ping(ctx).Err()
+			cancel()
+			if err == nil && s.closeCircuit() {
+				return
+			}
+		}
+	}
+}
+
+// closeCircuit brings Redis up to date with the keys changed during the outage
+// and sends requests back to it, emptying the fallback. It reports false, leaving
+// the circuit open, if Redis fails again first
+func (s *Store) closeCircuit() bool {
+	b := s.breaker
+
+	// Requests keep going to the fallback until Redis has caught up, so every
+	// round picks up the keys changed during the previous one
+	for keys := b.dirtyKeys(); len(keys) > 0; keys = b.dirtyKeys() {
+		if !s.reconcile(keys) {
+			return false
+		}
+	}
+
+	b.failures.Store(0)
+	b.open.Store(false)
+	_ = b.fallback.Clear()
+
+	if b.onStateChange != nil {
+		b.onStateChange(false)
+	}
+	return true
+}
+
+// reconcile brings Redis up to date with keys changed in the fallback: with
+// Replay their live entries overwrite Redis and the rest are deleted, otherwise
+// all of them are deleted, so no pre-outage value survives. It reports false if
+// Redis fails before every batch is applied
+func (s *Store) reconcile(keys []string) bool {
+	fallback := s.breaker.fallback
+	for start := 0; start < len(keys); start += s.maxBatchSize {
+		batch := keys[start:min(start+s.maxBatchSize, len(keys))]
+		pipe := s.client.Pipeline()
+		for _, key := range batch {
+			if e, found := fallback.Peek(key); found && s.breaker.replay && s.queueReplay(pipe, key, e) {
+				continue
+			}
+			pipe.Unlink(s.ctx, s.buildKey(key))
+		}
+		if _, err := pipe.Exec(s.ctx); isBackendFailure(err) {
+			return false
+		}
+		s.breaker.clean(batch)
+	}
+	return true
+}
+
+// queueReplay adds a SET of e to pipe and reports whether it did, which it
+// does not for entries that expired or cannot be serialized
+func (s *Store) queueReplay(pipe redis.Pipeliner, key string, e *entry.Entry) bool {
+	data, err := envelope.Marshal(e, s.codec)
+	if err != nil {
+		return false
+	}
+	redisTTL, expired := s.redisTTL(e)
+	if expired {
+		return false
+	}
+	pipe.Set(s.ctx, s.buildKey(key), string(data), redisTTL)
+	return true
+}
+
+// updateFallbackTTL changes the expiration of key in the fallback store
+func (s *Store) updateFallbackTTL(key string, ttl time.Duration) bool {
+	ttlStore, ok := s.breaker.fallback.(store.TTLStore)
+	if !ok || !ttlStore.UpdateTTL(key, ttl) {
+		return false
+	}
+	s.breaker.markDirty(key)
+	return true
+}
+
+// setFallback stores an entry in the fallback store while the circuit is open
+func (s *Store) setFallback(key string, e *entry.Entry) error {
+	s.breaker.markDirty(key)
+	return s.breaker.fallback.Set(key, e)
+}
+
+// swapFallback swaps an entry in the fallback store while the circuit is open
+// Fallbacks without Swap are read and written in two steps
+func (s *Store) swapFallback(key string, e *entry.Entry) (*entry.Entry, bool, error) {
+	s.breaker.markDirty(key)
+	fallback := s.breaker.fallback
+	if swapStore, ok := fallback.(store.SwapStore); ok {
+		return swapStore.Swap(key, e)
+	}
+	previous, existed := fallback.Peek(key)
+	if err := fallback.Set(key, e); err != nil {
+		return nil, false, err
+	}
+	return previous, existed, nil
+}
+
+// setIfNewerFallback stores a versioned entry in the fallback store while the
+// circuit is open. Fallbacks without SetIfNewer are read and written in two steps
+func (s *Store) setIfNewerFallback(key string, e *entry.Entry) (bool, error) {
+	fallback := s.breaker.fallback
+	if versionedStore, ok := fallback.(store.VersionedStore); ok {
+		written, err := versionedStore.SetIfNewer(key, e)
+		if written {
+			s.breaker.markDirty(key)
+		}
+		return written, err
+	}
+	if current, found := fallback.Peek(key); found && current.Version >= e.Version {
+		return false, nil
+	}
+	if err := s.setFallback(key, e); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// deleteFallback removes an entry from the fallback store while the circuit is open
+func (s *Store) deleteFallback(key string) error {
+	s.breaker.markDirty(key)
+	return s.breaker.fallback.Delete(key)
+}
+
+// stopBreaker stops the probe goroutine and closes the fallback store
+func (s *Store) stopBreaker() error {
+	b := s.breaker
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	select {
+	case <-b.stop:
+		b.mu.Unlock()
+		return nil
+	default:
+	}
+	close(b.stop)
+	done := b.probeDone
+	b.mu.Unlock()
+
+	if done != nil {
+		<-done
+	}
+	return b.fallback.Close()
+}
@@ -0,0 +1,308 @@
+package redis
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/1mb-dev/obcache-go/v2/internal/eviction"
+	"github.com/1mb-dev/obcache-go/v2/internal/store/memory"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+)
+
+// stateRecorder collects circuit state changes
+type stateRecorder struct {
+	mu     sync.Mutex
+	states []bool
+}
+
+func (r *stateRecorder) record(open bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states = append(r.states, open)
+}
+
+func (r *stateRecorder) get() []bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]bool(nil), r.states...)
+}
+
+func newBreakerStore(t *testing.T, server *failoverServer, replay bool) (*Store, *memory.StrategyStore, *stateRecorder) {
+	t.Helper()
+	client := redis.NewClient(&redis.Options{
+		Addr:            server.listener.Addr().String(),
+		Protocol:        2,
+		DisableIdentity: true,
+		MaxRetries:      -1,
+	})
+	t.Cleanup(func() { _ = client.Close() })
+
+	fallback, err := memory.NewWithStrategy(eviction.Config{Type: eviction.LRU, Capacity: 10})
+	if err != nil {
+		t.Fatalf("Failed to create fallback store: %v", err)
+	}
+	recorder := &stateRecorder{}
+	s, err := New(&Config{Client: client, Failover: &FailoverConfig{
+		Fallback:         fallback,
+		FailureThreshold: 3,
+		ProbeInterval:    10 * time.Millisecond,
+		Replay:           replay,
+		OnStateChange:    recorder.record,
+	}})
+	if err != nil {
+		t.Fatalf("Failed to create Redis store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.stopBreaker() })
+	return s, fallback, recorder
+}
+
+func waitForCircuit(t *testing.T, s *Store, open bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for s.IsDegraded() != open && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if s.IsDegraded() != open {
+		t.Fatalf("Timed out waiting for the circuit to become open=%v", open)
+	}
+}
+
+func TestFailoverOpensAfterConsecutiveFailures(t *testing.T) {
+	server := newFailoverServer(t, 0)
+	s, fallback, recorder := newBreakerStore(t, server, false)
+	server.setDown(true)
+
+	for i := range 2 {
+		if err := s.Set("key", entry.NewWithoutTTL(i)); err == nil {
+			t.Fatalf("Expected Set %d to fail while below the threshold", i)
+		}
+	}
+	if s.IsDegraded() {
+		t.Fatal("Expected the circuit to stay closed below the threshold")
+	}
+
+	// The third failure opens the circuit and the write lands in the fallback
+	if err := s.Set("key", entry.NewWithoutTTL("local")); err != nil {
+		t.Fatalf("Expected the tripping Set to fall back, got %v", err)
+	}
+	if !s.IsDegraded() {
+		t.Fatal("Expected the circuit to open after 3 failures")
+	}
+	if e, found := s.Get("key"); !found || e.Value != "local" {
+		t.Errorf("Expected Get to be served by the fallback, got %v (found=%v)", e, found)
+	}
+	if _, found := fallback.Peek("key"); !found {
+		t.Error("Expected the write to be stored in the fallback")
+	}
+	if states := recorder.get(); len(states) != 1 || !states[0] {
+		t.Errorf("Expected one transition to open, got %v", states)
+	}
+}
+
+func TestFailoverServesSwapAndSetIfNewer(t *testing.T) {
+	server := newFailoverServer(t, 0)
+	s, fallback, _ := newBreakerStore(t, server, false)
+	server.setDown(true)
+
+	for i := range 2 {
+		if _, _, err := s.Swap("key", entry.NewWithoutTTL(i)); err == nil {
+			t.Fatalf("Expected Swap %d to fail while below the threshold", i)
+		}
+	}
+
+	// The third failure opens the circuit and the swap lands in the fallback
+	if _, existed, err := s.Swap("key", entry.NewWithoutTTL("v1")); err != nil || existed {
+		t.Fatalf("Expected the tripping Swap to fall back with no previous entry, got existed=%v, err=%v", existed, err)
+	}
+	if !s.IsDegraded() {
+		t.Fatal("Expected the circuit to open after 3 failed Swaps")
+	}
+	if previous, existed, err := s.Swap("key", entry.NewWithoutTTL("v2")); err != nil || !existed || previous.Value != "v1" {
+		t.Fatalf("Expected Swap to return v1 from the fallback, got %v (existed=%v, err=%v)", previous, existed, err)
+	}
+
+	newer := entry.NewWithoutTTL("v3")
+	newer.Version = 3
+	if written, err := s.SetIfNewer("versioned", newer); err != nil || !written {
+		t.Fatalf("Expected SetIfNewer to write to the fallback, got written=%v, err=%v", written, err)
+	}
+	older := entry.NewWithoutTTL("v2")
+	older.Version = 2
+	if written, err := s.SetIfNewer("versioned", older); err != nil || written {
+		t.Fatalf("Expected an older version to be skipped, got written=%v, err=%v", written, err)
+	}
+	if e, found := fallback.Peek("versioned"); !found || e.Value != "v3" {
+		t.Errorf("Expected the fallback to hold v3, got %v (found=%v)", e, found)
+	}
+	if dirty := s.breaker.dirtyKeys(); len(dirty) != 2 {
+		t.Errorf("Expected both keys to be reconciled on recovery, got %v", dirty)
+	}
+}
+
+func TestFailoverServesBatches(t *testing.T) {
+	server := newFailoverServer(t, 0)
+	s, fallback, _ := newBreakerStore(t, server, false)
+	if err := s.SetBatch(map[string]*entry.Entry{"stale": entry.NewWithoutTTL("redis")}); err != nil {
+		t.Fatalf("SetBatch failed: %v", err)
+	}
+	server.setDown(true)
+
+	batch := map[string]*entry.Entry{"a": entry.NewWithoutTTL("a"), "b": entry.NewWithoutTTL("b")}
+	for i := range 2 {
+		if err := s.SetBatch(batch); err == nil {
+			t.Fatalf("Expected SetBatch %d to fail while below the threshold", i)
+		}
+	}
+
+	// The third failed pipeline opens the circuit and the batch lands in the fallback
+	if err := s.SetBatch(batch); err != nil {
+		t.Fatalf("Expected the tripping SetBatch to fall back, got %v", err)
+	}
+	if !s.IsDegraded() {
+		t.Fatal("Expected the circuit to open after 3 failed batches")
+	}
+	if fallback.Len() != 2 {
+		t.Errorf("Expected both entries in the fallback, got %d", fallback.Len())
+	}
+
+	if err := s.DeleteBatch([]string{"a", "stale"}); err != nil {
+		t.Fatalf("Expected DeleteBatch to be served by the fallback, got %v", err)
+	}
+	found, err := s.GetBatch([]string{"a", "b", "stale"})
+	if err != nil || len(found) != 1 || found["b"] == nil {
+		t.Errorf("Expected only b after the batch delete, got %v (err=%v)", found, err)
+	}
+
+	// Every key changed during the outage is reconciled, including the deleted ones
+	if dirty := s.breaker.dirtyKeys(); len(dirty) != 3 {
+		t.Errorf("Expected a, b and stale to be reconciled on recovery, got %v", dirty)
+	}
+	server.setDown(false)
+	waitForCircuit(t, s, false)
+	if _, ok := server.value("obcache:stale"); ok {
+		t.Error("Expected the key deleted during the outage to be removed from Redis")
+	}
+}
+
+func TestFailoverSuccessResetsFailureCount(t *testing.T) {
+	server := newFailoverServer(t, 0)
+	s, _, _ := newBreakerStore(t, server, false)
+
+	for range 3 {
+		server.setDown(true)
+		_ = s.Set("key", entry.NewWithoutTTL("v"))
+		_ = s.Set("key", entry.NewWithoutTTL("v"))
+		server.setDown(false)
+		if err := s.Set("key", entry.NewWithoutTTL("v")); err != nil {
+			t.Fatalf("Expected Set to succeed once Redis is back, got %v", err)
+		}
+	}
+	if s.IsDegraded() {
+		t.Error("Expected non-consecutive failures to leave the circuit closed")
+	}
+}
+
+func TestFailoverClosesWhenRedisRecovers(t *testing.T) {
+	server := newFailoverServer(t, 0)
+	s, fallback, recorder := newBreakerStore(t, server, false)
+
+	server.setDown(true)
+	for range 3 {
+		_ = s.Set("local", entry.NewWithoutTTL("v"))
+	}
+	waitForCircuit(t, s, true)
+
+	server.setDown(false)
+	waitForCircuit(t, s, false)
+
+	if fallback.Len() != 0 {
+		t.Errorf("Expected the fallback to be emptied, got %d entries", fallback.Len())
+	}
+	if _, ok := server.value("obcache:local"); ok {
+		t.Error("Expected fallback entries not to be replayed by default")
+	}
+	if err := s.Set("key", entry.NewWithoutTTL("redis")); err != nil {
+		t.Fatalf("Expected Set to reach Redis, got %v", err)
+	}
+	if _, ok := server.value("obcache:key"); !ok {
+		t.Error("Expected the write to reach Redis after recovery")
+	}
+	if states := recorder.get(); len(states) != 2 || !states[0] || states[1] {
+		t.Errorf("Expected open then closed, got %v", states)
+	}
+}
+
+func TestFailoverReplay(t *testing.T) {
+	server := newFailoverServer(t, 0)
+	s, _, _ := newBreakerStore(t, server, true)
+
+	if err := s.Set("existing", entry.NewWithoutTTL("v1")); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	_ = s.Set("deleted", entry.NewWithoutTTL("v1"))
+
+	server.setDown(true)
+	for range 3 {
+		_ = s.Set("local", entry.New("from fallback", time.Hour))
+	}
+	waitForCircuit(t, s, true)
+	_ = s.Set("existing", entry.NewWithoutTTL("v2"))
+	_ = s.Delete("deleted")
+
+	server.setDown(false)
+	waitForCircuit(t, s, false)
+
+	if e, found := s.Get("local"); !found || e.Value != "from fallback" {
+		t.Errorf("Expected the fallback entry to be replayed, got %v (found=%v)", e, found)
+	}
+	if e, found := s.Get("existing"); !found || e.Value != "v2" {
+		t.Errorf("Expected the outage write to replace the pre-outage value, got %v (found=%v)", e, found)
+	}
+	if _, found := s.Get("deleted"); found {
+		t.Error("Expected the outage delete to be replayed")
+	}
+}
+
+func TestFailoverDropsStaleValues(t *testing.T) {
+	server := newFailoverServer(t, 0)
+	s, _, _ := newBreakerStore(t, server, false)
+
+	if err := s.Set("key", entry.NewWithoutTTL("v1")); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := s.Set("untouched", entry.NewWithoutTTL("v1")); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	server.setDown(true)
+	for range 3 {
+		_ = s.Set("key", entry.NewWithoutTTL("v2"))
+	}
+	waitForCircuit(t, s, true)
+
+	server.setDown(false)
+	waitForCircuit(t, s, false)
+
+	if e, found := s.Get("key"); found && e.Value != "v2" {
+		t.Errorf("Expected v2 or a miss after recovery, got the pre-outage value %v", e.Value)
+	}
+	if e, found := s.Get("untouched"); !found || e.Value != "v1" {
+		t.Errorf("Expected keys not written during the outage to be kept, got %v (found=%v)", e, found)
+	}
+}
+
+func TestIsBackendFailure(t *testing.T) {
+	if isBackendFailure(nil) || isBackendFailure(redis.Nil) {
+		t.Error("Expected success and misses not to count as failures")
+	}
+	if isBackendFailure(errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")) {
+		t.Error("Expected command errors not to count as failures")
+	}
+	if !isBackendFailure(redis.ErrPoolTimeout) {
+		t.Error("Expected pool timeouts to count as failures")
+	}
+}
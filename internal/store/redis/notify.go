@@ -0,0 +1,101 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// subscriber is implemented by clients that can open Pub/Sub subscriptions
+type subscriber interface {
+	Subscribe(ctx context.Context, channels ...string) *redis.PubSub
+}
+
+// expiredChannel returns the keyevent channel Redis publishes expirations of db to
+func expiredChannel(db int) string {
+	return fmt.Sprintf("__keyevent@%d__:expired", db)
+}
+
+// clientDB returns the database a client is connected to, or 0 if it cannot tell
+func clientDB(client redis.Cmdable) int {
+	if c, ok := client.(interface{ Options() *redis.Options }); ok {
+		return c.Options().DB
+	}
+	return 0
+}
+
+// startExpiryNotifications subscribes to the expired keyevent channel of db and
+// reports expirations of keys under the store's prefix to the cleanup callback.
+// It returns once the subscription is confirmed. The subscription reconnects and
+// resubscribes on its own after connection errors, but events published while it
+// is disconnected are lost
+func (s *Store) startExpiryNotifications(db int) error {
+	sub, ok := s.client.(subscriber)
+	if !ok {
+		return fmt.Errorf("expiry notifications require a client that supports SUBSCRIBE")
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	pubsub := sub.Subscribe(ctx, expiredChannel(db))
+	if _, err := pubsub.Receive(ctx); err != nil {
+		cancel()
+		_ = pubsub.Close()
+		return fmt.Errorf("failed to subscribe to expiry notifications: %w", err)
+	}
+
+	done := make(chan struct{})
+	s.pubsub = pubsub
+	s.stopNotifications = cancel
+	s.notificationsDone = done
+
+	messages := pubsub.Channel()
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				s.notifyExpired(msg.Payload)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// stopExpiryNotifications closes the subscription and waits for its goroutine to exit
+func (s *Store) stopExpiryNotifications() {
+	s.mu.Lock()
+	pubsub, cancel, done := s.pubsub, s.stopNotifications, s.notificationsDone
+	s.pubsub, s.stopNotifications, s.notificationsDone = nil, nil, nil
+	s.mu.Unlock()
+
+	if pubsub == nil {
+		return
+	}
+	cancel()
+	_ = pubsub.Close()
+	<-done
+}
+
+// notifyExpired reports an expired Redis key to the cleanup callback if it belongs to the store
+// The entry is gone by the time Redis publishes the event, so the callback receives nil
+func (s *Store) notifyExpired(redisKey string) {
+	key := s.extractKey(redisKey)
+	if key == "" {
+		return
+	}
+
+	s.mu.RLock()
+	callback := s.cleanupCallback
+	s.mu.RUnlock()
+
+	if callback != nil {
+		callback(key, nil)
+	}
+}
@@ -0,0 +1,92 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+)
+
+func newNotifyTestStore(t *testing.T, client *redis.Client) (*Store, <-chan string) {
+	t.Helper()
+	s, err := New(&Config{Client: client, KeyPrefix: "notify-test:", ExpiryNotifications: true})
+	if err != nil {
+		t.Fatalf("Failed to create Redis store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+
+	expired := make(chan string, 10)
+	s.SetCleanupCallback(func(key string, value any) {
+		if value != nil {
+			t.Errorf("Expected a nil value for expired key %q, got %v", key, value)
+		}
+		expired <- key
+	})
+	return s, expired
+}
+
+func TestRedisStoreExpiryNotifications(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "localhost:6379", DB: 2})
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Redis not available, skipping test: %v", err)
+	}
+	s, expired := newNotifyTestStore(t, client)
+
+	// Publish the events Redis would send, so the test does not depend on server config
+	channel := expiredChannel(2)
+	client.Publish(ctx, "__keyevent@0__:expired", "notify-test:other-db")
+	client.Publish(ctx, channel, "other-prefix:key")
+	client.Publish(ctx, channel, "notify-test:key1")
+
+	select {
+	case key := <-expired:
+		if key != "key1" {
+			t.Fatalf("Expected key1 with the prefix stripped, got %q", key)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected an expiry notification for key1")
+	}
+
+	s.stopExpiryNotifications()
+	client.Publish(ctx, channel, "notify-test:key2")
+	select {
+	case key := <-expired:
+		t.Fatalf("Expected no notifications after Close, got %q", key)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestRedisStoreExpiryNotificationsFromServer(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Redis not available, skipping test: %v", err)
+	}
+	if err := client.ConfigSet(ctx, "notify-keyspace-events", "Ex").Err(); err != nil {
+		t.Skipf("Cannot enable keyspace notifications, skipping test: %v", err)
+	}
+	s, expired := newNotifyTestStore(t, client)
+
+	if err := s.Set("short", entry.New("value", 50*time.Millisecond)); err != nil {
+		t.Fatalf("Failed to set entry: %v", err)
+	}
+
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case key := <-expired:
+			if key == "short" {
+				return
+			}
+		case <-time.After(100 * time.Millisecond):
+			// Redis expires idle keys lazily or by sampling; a read forces it
+			client.Exists(ctx, "notify-test:short")
+		case <-deadline:
+			t.Fatal("Expected Redis to report the expiration of short")
+		}
+	}
+}
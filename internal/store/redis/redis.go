@@ -2,27 +2,55 @@ package redis
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 
-	"github.com/1mb-dev/obcache-go/v2/internal/entry"
-	"github.com/1mb-dev/obcache-go/v2/internal/store"
+	"github.com/1mb-dev/obcache-go/v2/internal/envelope"
+	"github.com/1mb-dev/obcache-go/v2/pkg/codec"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+	"github.com/1mb-dev/obcache-go/v2/pkg/store"
 )
 
+// scanBatchSize is the COUNT hint used for SCAN iterations that walk the whole keyspace
+const scanBatchSize = 1000
+
 // Store implements a Redis-backed cache store
 type Store struct {
 	client          redis.Cmdable
 	keyPrefix       string
 	defaultTTL      time.Duration
 	evictCallback   store.EvictCallback
-	cleanupCallback store.EvictCallback
-	mu              sync.RWMutex
-	ctx             context.Context
+	cleanupCallback store.EntryCallback
+	maxRetries      int
+	retryBackoff    time.Duration
+	maxBatchSize    int
+	scanCount       int64
+	codec           codec.Codec
+
+	// Expiry notification subscription, nil unless ExpiryNotifications is enabled
+	pubsub            *redis.PubSub
+	stopNotifications context.CancelFunc
+	notificationsDone chan struct{}
+
+	// Client tracking connection, nil unless ClientTracking is enabled
+	tracker            *redis.Client
+	stopTrackingLoop   context.CancelFunc
+	trackingDone       chan struct{}
+	invalidateCallback store.InvalidateCallback
+	ownWrites          *ownWrites
+
+	// Circuit breaker routing requests to a fallback store, nil unless Failover is set
+	breaker *breaker
+
+	mu  sync.RWMutex
+	ctx context.Context
 }
 
 // Config holds Redis store configuration
@@ -38,16 +66,69 @@ type Config struct {
 
 	// Context for Redis operations
 	Context context.Context
-}
 
-// SerializedEntry represents an entry as stored in Redis
-type SerializedEntry struct {
-	Value      json.RawMessage `json:"value"`
-	CreatedAt  time.Time       `json:"created_at"`
-	ExpiresAt  *time.Time      `json:"expires_at,omitempty"`
-	LastAccess time.Time       `json:"last_access"`
+	// MaxRetries is the number of times Get and Set are retried after a transient
+	// failover error such as READONLY or a dropped connection
+	// Default: 0 (no retries)
+	MaxRetries int
+
+	// RetryBackoff is the delay before the first retry; retry n waits n times as long
+	// Default: 50ms
+	RetryBackoff time.Duration
+
+	// MaxBatchSize bounds the number of commands SetBatch and DeleteBatch send in
+	// one pipeline, and the number of keys GetBatch reads with one MGET
+	// Default: 1000
+	MaxBatchSize int
+
+	// ExpiryNotifications subscribes to Redis keyspace notifications so that
+	// server-side expirations reach the cleanup callback, with a nil value.
+	// The server must have notify-keyspace-events including "Ex", and the client
+	// must support SUBSCRIBE. Delivery is best-effort: Pub/Sub does not buffer, so
+	// expirations during a disconnect are missed, and Redis may publish an
+	// expiration some time after the TTL passed. Not supported with Redis Cluster,
+	// where notifications are local to each node
+	ExpiryNotifications bool
+
+	// ClientTracking enables Redis server-assisted client-side caching: a dedicated
+	// RESP3 connection turns on CLIENT TRACKING in broadcast mode for KeyPrefix and
+	// changed keys are reported to the invalidate callback. Requires Redis 6 or
+	// later and a *redis.Client. Keys this store sets are not reported, but its
+	// deletes and TTL changes are
+	ClientTracking bool
+
+	// TrackingInterval is how often the tracking connection is polled for invalidations
+	// Default: 100ms
+	TrackingInterval time.Duration
+
+	// Failover serves requests from a local fallback store while Redis is unreachable
+	// If nil, Redis errors are returned to callers (or reported as misses by Get)
+	Failover *FailoverConfig
+
+	// Codec encodes entry values. Entries written with another registered codec
+	// are still readable; those with an unknown codec are misses and left in place
+	// Default: codec.JSON
+	Codec codec.Codec
 }
 
+// setIfNewerScript writes ARGV[1] unless the stored entry carries a version >= ARGV[2]
+// ARGV[3] is the expiration in milliseconds (0 for none)
+var setIfNewerScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if current then
+	local ok, decoded = pcall(cjson.decode, current)
+	if ok and type(decoded) == 'table' and tonumber(decoded['version'] or 0) >= tonumber(ARGV[2]) then
+		return 0
+	end
+end
+if tonumber(ARGV[3]) > 0 then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[3])
+else
+	redis.call('SET', KEYS[1], ARGV[1])
+end
+return 1
+`)
+
 // New creates a new Redis store with the given configuration
 func New(config *Config) (*Store, error) {
 	if config.Client == nil {
@@ -64,11 +145,50 @@ func New(config *Config) (*Store, error) {
 		keyPrefix = "obcache:"
 	}
 
+	retryBackoff := config.RetryBackoff
+	if retryBackoff <= 0 {
+		retryBackoff = DefaultRetryBackoff
+	}
+
+	maxBatchSize := config.MaxBatchSize
+	if maxBatchSize <= 0 {
+		maxBatchSize = DefaultMaxBatchSize
+	}
+
 	s := &Store{
-		client:     config.Client,
-		keyPrefix:  keyPrefix,
-		defaultTTL: config.DefaultTTL,
-		ctx:        ctx,
+		client:       config.Client,
+		keyPrefix:    keyPrefix,
+		defaultTTL:   config.DefaultTTL,
+		maxRetries:   max(config.MaxRetries, 0),
+		retryBackoff: retryBackoff,
+		maxBatchSize: maxBatchSize,
+		scanCount:    scanBatchSize,
+		codec:        codec.OrDefault(config.Codec),
+		ctx:          ctx,
+	}
+
+	if config.Failover != nil {
+		if config.Failover.Fallback == nil {
+			return nil, fmt.Errorf("redis failover requires a fallback store")
+		}
+		s.breaker = newBreaker(config.Failover)
+	}
+
+	if config.ExpiryNotifications {
+		if err := s.startExpiryNotifications(clientDB(config.Client)); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.ClientTracking {
+		interval := config.TrackingInterval
+		if interval <= 0 {
+			interval = DefaultTrackingInterval
+		}
+		if err := s.startTracking(interval); err != nil {
+			s.stopExpiryNotifications()
+			return nil, err
+		}
 	}
 
 	return s, nil
@@ -76,28 +196,19 @@ func New(config *Config) (*Store, error) {
 
 // Get retrieves an entry by key
 func (s *Store) Get(key string) (*entry.Entry, bool) {
+	if s.degraded() {
+		return s.breaker.fallback.Get(key)
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	redisKey := s.buildKey(key)
-	result := s.client.Get(s.ctx, redisKey)
-	if result.Err() != nil {
-		if result.Err() == redis.Nil {
-			return nil, false // Key not found
-		}
-		return nil, false // Other Redis errors treated as miss
+	entry, found, err := s.load(redisKey)
+	if s.observe(err) {
+		return s.breaker.fallback.Get(key)
 	}
-
-	data, err := result.Result()
-	if err != nil {
-		return nil, false
-	}
-
-	// Deserialize the entry
-	entry, err := s.deserializeEntry([]byte(data))
-	if err != nil {
-		// If deserialization fails, remove the corrupted key
-		s.client.Del(s.ctx, redisKey)
+	if !found {
 		return nil, false
 	}
 
@@ -108,102 +219,427 @@ func (s *Store) Get(key string) (*entry.Entry, bool) {
 
 		// Call cleanup callback if set
 		if s.cleanupCallback != nil {
-			go s.cleanupCallback(key, entry.Value)
+			go s.cleanupCallback(key, entry)
 		}
 		return nil, false
 	}
 
-	// Update last access time and save back to Redis
+	// The access time is not written back: a read must never replace a newer
+	// write, such as one from SetIfNewer, that landed since the load
 	entry.Touch()
-	_ = s.saveEntryToRedis(redisKey, entry)
+	return entry, true
+}
+
+// Peek retrieves an entry without updating its access time
+func (s *Store) Peek(key string) (*entry.Entry, bool) {
+	if s.degraded() {
+		return s.breaker.fallback.Peek(key)
+	}
 
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, found, err := s.load(s.buildKey(key))
+	if s.observe(err) {
+		return s.breaker.fallback.Peek(key)
+	}
+	if !found || entry.IsExpired() {
+		return nil, false
+	}
 	return entry, true
 }
 
+// load reads and deserializes an entry, taking its expiration from Redis
+// Transient failover errors are retried before the read is treated as a miss.
+// err is the Redis error for reads that failed other than with a miss
+func (s *Store) load(redisKey string) (*entry.Entry, bool, error) {
+	var getCmd *redis.StringCmd
+	var ttlCmd *redis.DurationCmd
+	_ = s.retry(func() error {
+		pipe := s.client.Pipeline()
+		getCmd = pipe.Get(s.ctx, redisKey)
+		ttlCmd = pipe.PTTL(s.ctx, redisKey)
+		_, _ = pipe.Exec(s.ctx) // Errors are inspected per command below
+		return getCmd.Err()
+	})
+
+	data, err := getCmd.Result()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		// Other Redis errors are treated as a miss
+		return nil, false, err
+	}
+
+	// Deserialize the entry
+	entry, err := envelope.Unmarshal([]byte(data), s.codec)
+	if errors.Is(err, codec.ErrUnknownCodec) {
+		// Written by an instance with a codec this one lacks; leave it for that instance
+		return nil, false, err
+	}
+	if err != nil {
+		// If deserialization fails, remove the corrupted key
+		s.client.Del(s.ctx, redisKey)
+		return nil, false, nil
+	}
+
+	// Redis owns the expiration, which UpdateTTL may have changed since the entry was written
+	if remaining, err := ttlCmd.Result(); err == nil {
+		applyRedisTTL(entry, remaining)
+	}
+
+	return entry, true, nil
+}
+
 // Set stores an entry with the given key
 func (s *Store) Set(key string, entry *entry.Entry) error {
+	if s.degraded() {
+		return s.setFallback(key, entry)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	redisKey := s.buildKey(key)
+	err := s.retry(func() error {
+		return s.saveEntryToRedis(redisKey, entry)
+	})
+	if s.observe(err) {
+		return s.setFallback(key, entry)
+	}
+	return err
+}
+
+// Swap stores an entry and returns the previous one using a single SET ... GET command
+func (s *Store) Swap(key string, e *entry.Entry) (*entry.Entry, bool, error) {
+	if s.degraded() {
+		return s.swapFallback(key, e)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := envelope.Marshal(e, s.codec)
+	if err != nil {
+		return nil, false, err
+	}
+
+	redisKey := s.buildKey(key)
+	redisTTL, expired := s.redisTTL(e)
+	var old string
+	err = s.retry(func() error {
+		if expired {
+			old, err = s.client.GetDel(s.ctx, redisKey).Result()
+		} else {
+			s.ownWrites.expect(redisKey)
+			old, err = s.client.SetArgs(s.ctx, redisKey, string(data), redis.SetArgs{TTL: redisTTL, Get: true}).Result()
+			if err != nil && err != redis.Nil {
+				s.ownWrites.forget(redisKey)
+			}
+		}
+		return err
+	})
+	if s.observe(err) {
+		return s.swapFallback(key, e)
+	}
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Redis only returns keys that have not expired, so the previous entry is live
+	previous, err := envelope.Unmarshal([]byte(old), s.codec)
+	if err != nil {
+		return nil, false, nil
+	}
+	return previous, true, nil
+}
+
+// SetIfNewer atomically stores the entry unless Redis holds one with an equal or higher version
+func (s *Store) SetIfNewer(key string, e *entry.Entry) (bool, error) {
+	if s.degraded() {
+		return s.setIfNewerFallback(key, e)
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	data, err := envelope.Marshal(e, s.codec)
+	if err != nil {
+		return false, err
+	}
+
+	redisTTL, expired := s.redisTTL(e)
+	if expired {
+		return false, nil
+	}
+
+	ttlMillis := redisTTL.Milliseconds()
+	if redisTTL > 0 && ttlMillis == 0 {
+		ttlMillis = 1 // Round sub-millisecond TTLs up rather than dropping the expiration
+	}
+
 	redisKey := s.buildKey(key)
-	return s.saveEntryToRedis(redisKey, entry)
+	var written int
+	err = s.retry(func() error {
+		s.ownWrites.expect(redisKey)
+		written, err = setIfNewerScript.Run(s.ctx, s.client, []string{redisKey},
+			string(data), e.Version, ttlMillis).Int()
+		if err != nil || written != 1 {
+			s.ownWrites.forget(redisKey)
+		}
+		return err
+	})
+	if s.observe(err) {
+		return s.setIfNewerFallback(key, e)
+	}
+	if err != nil {
+		return false, err
+	}
+	return written == 1, nil
 }
 
 // Delete removes an entry by key
 func (s *Store) Delete(key string) error {
+	if s.degraded() {
+		return s.deleteFallback(key)
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	redisKey := s.buildKey(key)
-	return s.client.Del(s.ctx, redisKey).Err()
+	err := s.client.Del(s.ctx, redisKey).Err()
+	if s.observe(err) {
+		return s.deleteFallback(key)
+	}
+	return err
 }
 
 // Keys returns all keys currently in the store
+// Returns an empty slice if the keyspace cannot be read
 func (s *Store) Keys() []string {
+	keys, err := s.KeysContext(s.ctx)
+	if err != nil {
+		return []string{}
+	}
+	return keys
+}
+
+// KeysContext returns all keys using incremental SCAN with the key prefix as MATCH
+// pattern, so no single command walks the whole keyspace. Redis skips expired keys.
+// Keys modified during the iteration may be missed or, when stored again, repeated
+// by SCAN; repeats are removed
+func (s *Store) KeysContext(ctx context.Context) ([]string, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	pattern := s.buildKey("*")
-	result := s.client.Keys(s.ctx, pattern)
-	if result.Err() != nil {
-		return []string{}
+	seen := make(map[string]struct{})
+	keys := []string{}
+	err := s.scanKeys(ctx, func(redisKeys []string) error {
+		for _, redisKey := range redisKeys {
+			key := s.extractKey(redisKey)
+			if _, dup := seen[key]; !dup {
+				seen[key] = struct{}{}
+				keys = append(keys, key)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return keys, nil
+}
 
-	redisKeys, err := result.Result()
-	if err != nil {
-		return []string{}
+// scanKeys walks all keys with the store's prefix, calling fn with each SCAN page
+// It stops with ctx.Err() when ctx is done between pages
+func (s *Store) scanKeys(ctx context.Context, fn func(redisKeys []string) error) error {
+	return s.scanPattern(ctx, s.matchPattern(""), fn)
+}
+
+// scanPattern is scanKeys for the keys matching a SCAN MATCH pattern
+func (s *Store) scanPattern(ctx context.Context, pattern string, fn func(redisKeys []string) error) error {
+	var cursor uint64
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		redisKeys, next, err := s.client.Scan(ctx, cursor, pattern, s.scanCount).Result()
+		if err != nil {
+			return err
+		}
+		if len(redisKeys) > 0 {
+			if err := fn(redisKeys); err != nil {
+				return err
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// Scan returns keys with the given prefix using SCAN with MATCH and COUNT
+// The cursor is Redis' own SCAN cursor, so pages may contain slightly more than
+// limit keys and keys modified during iteration may be returned more than once
+func (s *Store) Scan(prefix string, cursor string, limit int) ([]string, string, error) {
+	if limit <= 0 {
+		return nil, "", fmt.Errorf("scan limit must be positive, got %d", limit)
+	}
+
+	var redisCursor uint64
+	if cursor != "" {
+		parsed, err := strconv.ParseUint(cursor, 10, 64)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid scan cursor %q: %w", cursor, err)
+		}
+		redisCursor = parsed
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	pattern := s.matchPattern(prefix)
+	keys := make([]string, 0, limit)
+	for {
+		redisKeys, next, err := s.client.Scan(s.ctx, redisCursor, pattern, int64(limit)).Result()
+		if err != nil {
+			return nil, "", err
+		}
+		for _, redisKey := range redisKeys {
+			keys = append(keys, s.extractKey(redisKey)) // MATCH guarantees the prefix
+		}
+
+		redisCursor = next
+		if redisCursor == 0 {
+			return keys, "", nil
+		}
+		if len(keys) >= limit {
+			return keys, strconv.FormatUint(redisCursor, 10), nil
+		}
 	}
+}
 
-	// Convert Redis keys back to cache keys and filter expired entries
-	cacheKeys := make([]string, 0, len(redisKeys))
-	for _, redisKey := range redisKeys {
-		cacheKey := s.extractKey(redisKey)
-		if cacheKey == "" {
-			continue
+// CountPrefix counts keys with the given prefix using SCAN and sums their
+// stored lengths with STRLEN, one pipelined batch per SCAN page. The size is
+// that of the stored envelope: the serialized entry with its metadata, after
+// compression, rather than of the value alone
+func (s *Store) CountPrefix(prefix string) (int, int64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	pattern := s.matchPattern(prefix)
+	var count int
+	var size int64
+	var cursor uint64
+	for {
+		redisKeys, next, err := s.client.Scan(s.ctx, cursor, pattern, s.scanCount).Result()
+		if err != nil {
+			return count, size
+		}
+
+		if len(redisKeys) > 0 {
+			pipe := s.client.Pipeline()
+			lengths := make([]*redis.IntCmd, len(redisKeys))
+			for i, redisKey := range redisKeys {
+				lengths[i] = pipe.StrLen(s.ctx, redisKey)
+			}
+			_, _ = pipe.Exec(s.ctx) // Errors are inspected per command below
+
+			for _, length := range lengths {
+				// Keys that expired between SCAN and STRLEN report a length of 0
+				if n, err := length.Result(); err == nil && n > 0 {
+					count++
+					size += n
+				}
+			}
 		}
 
-		// Check if the entry is valid (not expired)
-		if _, found := s.Get(cacheKey); found {
-			cacheKeys = append(cacheKeys, cacheKey)
+		cursor = next
+		if cursor == 0 {
+			return count, size
 		}
 	}
+}
+
+// CountPrefixKeys counts keys with the given prefix using SCAN alone, skipping
+// the STRLEN round trip per page that CountPrefix needs for sizes
+func (s *Store) CountPrefixKeys(prefix string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	return cacheKeys
+	count := 0
+	_ = s.scanPattern(s.ctx, s.matchPattern(prefix), func(redisKeys []string) error {
+		count += len(redisKeys)
+		return nil
+	})
+	return count
 }
 
-// Len returns the current number of entries in the store
+// Len returns the number of keys under the store's prefix
 func (s *Store) Len() int {
 	return len(s.Keys())
 }
 
-// Clear removes all entries from the store
+// Clear removes the keys under the store's prefix, leaving the rest of the
+// database to the other caches and applications that share it
 func (s *Store) Clear() error {
+	return s.ClearContext(s.ctx, nil)
+}
+
+// ClearContext removes all entries by unlinking each SCAN page, in batches of at
+// most the SCAN COUNT hint, and calls fn (if not nil) with the keys of each batch.
+// UNLINK frees values in the background so large entries do not block Redis.
+// When ctx is done it stops with ctx.Err(), leaving keys not yet scanned in place
+func (s *Store) ClearContext(ctx context.Context, fn func(keys []string)) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	pattern := s.buildKey("*")
-	result := s.client.Keys(s.ctx, pattern)
-	if result.Err() != nil {
-		return result.Err()
-	}
-
-	keys, err := result.Result()
-	if err != nil {
-		return err
-	}
-
-	if len(keys) > 0 {
-		return s.client.Del(s.ctx, keys...).Err()
-	}
-
-	return nil
+	return s.scanKeys(ctx, func(redisKeys []string) error {
+		// Servers may return more than COUNT keys per page
+		for batch := range slices.Chunk(redisKeys, int(s.scanCount)) {
+			if err := s.client.Unlink(ctx, batch...).Err(); err != nil {
+				return err
+			}
+			if fn != nil {
+				keys := make([]string, len(batch))
+				for i, redisKey := range batch {
+					keys[i] = s.extractKey(redisKey)
+				}
+				fn(keys)
+			}
+		}
+		return nil
+	})
 }
 
 // Close closes the store and cleans up resources
 func (s *Store) Close() error {
+	s.stopExpiryNotifications()
+	s.stopTracking()
+	fallbackErr := s.stopBreaker()
+	if s.degraded() {
+		return fallbackErr // Redis is unreachable, so there is nothing to clear
+	}
+
 	// Redis client cleanup is handled externally
 	// We just clear our data
-	return s.Clear()
+	if err := s.Clear(); err != nil {
+		return err
+	}
+	return fallbackErr
+}
+
+// Ping checks that Redis answers PING
+func (s *Store) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
 }
 
 // SetEvictCallback sets the callback for evictions (not applicable for Redis)
@@ -214,10 +650,19 @@ func (s *Store) SetEvictCallback(callback store.EvictCallback) {
 }
 
 // SetCleanupCallback sets the callback for TTL cleanup
+// It is only called when ExpiryNotifications is enabled, from the subscriber goroutine
 func (s *Store) SetCleanupCallback(callback store.EvictCallback) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.cleanupCallback = callback
+	s.cleanupCallback = store.ValueCallback(callback)
+}
+
+// SetEntryCallbacks sets the callback for TTL cleanup, passing it the expired entry
+// when the store read it and nil for expiry notifications. evict is not applicable
+func (s *Store) SetEntryCallbacks(_, cleanup store.EntryCallback) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cleanupCallback = cleanup
 }
 
 // Cleanup removes expired entries (Redis handles TTL automatically)
@@ -228,100 +673,125 @@ func (s *Store) Cleanup() int {
 	return 0
 }
 
-// buildKey creates a Redis key with the configured prefix
-func (s *Store) buildKey(key string) string {
-	return s.keyPrefix + key
-}
-
-// extractKey extracts the cache key from a Redis key
-func (s *Store) extractKey(redisKey string) string {
-	if !strings.HasPrefix(redisKey, s.keyPrefix) {
-		return ""
+// UpdateTTL changes the expiration of an existing entry using PEXPIRE, or PERSIST for ttl <= 0
+func (s *Store) UpdateTTL(key string, ttl time.Duration) bool {
+	if s.degraded() {
+		return s.updateFallbackTTL(key, ttl)
 	}
-	return strings.TrimPrefix(redisKey, s.keyPrefix)
-}
 
-// serializeEntry converts an entry to JSON for Redis storage
-func (s *Store) serializeEntry(e *entry.Entry) ([]byte, error) {
-	valueBytes, err := json.Marshal(e.Value)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal entry value: %w", err)
-	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	serialized := SerializedEntry{
-		Value:      valueBytes,
-		CreatedAt:  e.CreatedAt,
-		LastAccess: e.AccessedAt,
+	redisKey := s.buildKey(key)
+	if ttl > 0 {
+		updated, err := s.client.PExpire(s.ctx, redisKey, ttl).Result()
+		if s.observe(err) {
+			return s.updateFallbackTTL(key, ttl)
+		}
+		return err == nil && updated
 	}
 
-	if e.HasExpiry() {
-		serialized.ExpiresAt = e.ExpiresAt
+	// PERSIST also reports false for keys without a TTL, so check existence separately
+	exists, err := s.client.Exists(s.ctx, redisKey).Result()
+	if s.observe(err) {
+		return s.updateFallbackTTL(key, ttl)
 	}
+	if err != nil || exists == 0 {
+		return false
+	}
+	return s.client.Persist(s.ctx, redisKey).Err() == nil
+}
 
-	return json.Marshal(serialized)
+// buildKey creates a Redis key with the configured prefix
+func (s *Store) buildKey(key string) string {
+	return s.keyPrefix + key
 }
 
-// deserializeEntry converts JSON data back to an entry
-func (s *Store) deserializeEntry(data []byte) (*entry.Entry, error) {
-	var serialized SerializedEntry
-	if err := json.Unmarshal(data, &serialized); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal serialized entry: %w", err)
-	}
+// matchPattern returns the SCAN MATCH pattern for keys starting with prefix
+// The store's own prefix is escaped too, so a prefix such as "cache[1]:" matches
+// only itself and never another application's keys
+func (s *Store) matchPattern(prefix string) string {
+	return escapePattern(s.buildKey(prefix)) + "*"
+}
 
-	var value any
-	if err := json.Unmarshal(serialized.Value, &value); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal entry value: %w", err)
+// escapePattern escapes glob metacharacters so a literal string can be used in a MATCH pattern
+func escapePattern(literal string) string {
+	var b strings.Builder
+	for _, r := range literal {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
 	}
+	return b.String()
+}
 
-	// Create a new entry with current time, then manually set the fields
-	var e *entry.Entry
-	if serialized.ExpiresAt != nil {
-		ttl := serialized.ExpiresAt.Sub(serialized.CreatedAt)
-		e = entry.New(value, ttl)
-	} else {
-		e = entry.NewWithoutTTL(value)
+// extractKey extracts the cache key from a Redis key
+func (s *Store) extractKey(redisKey string) string {
+	if !strings.HasPrefix(redisKey, s.keyPrefix) {
+		return ""
 	}
+	return strings.TrimPrefix(redisKey, s.keyPrefix)
+}
 
-	// Manually restore the timestamps by direct field access
-	// Note: This requires the Entry fields to be exported
-	e.CreatedAt = serialized.CreatedAt
-	e.AccessedAt = serialized.LastAccess
-	if serialized.ExpiresAt != nil {
-		e.ExpiresAt = serialized.ExpiresAt
+// applyRedisTTL sets the entry expiration from the remaining TTL reported by PTTL
+// A negative duration means the key has no expiration in Redis
+func applyRedisTTL(e *entry.Entry, remaining time.Duration) {
+	if remaining < 0 {
+		e.ExpiresAt = nil
+		return
 	}
-
-	return e, nil
+	expiry := time.Now().Add(remaining)
+	e.ExpiresAt = &expiry
 }
 
 // saveEntryToRedis saves an entry to Redis with appropriate TTL
 func (s *Store) saveEntryToRedis(redisKey string, e *entry.Entry) error {
-	data, err := s.serializeEntry(e)
+	data, err := envelope.Marshal(e, s.codec)
 	if err != nil {
 		return err
 	}
 
-	// Calculate TTL for Redis
-	var redisTTL time.Duration
+	redisTTL, expired := s.redisTTL(e)
+	if expired {
+		return s.client.Del(s.ctx, redisKey).Err()
+	}
+
+	// Set uses PX for sub-second precision, unlike SETEX which truncates to seconds
+	s.ownWrites.expect(redisKey)
+	err = s.client.Set(s.ctx, redisKey, string(data), redisTTL).Err()
+	if err != nil {
+		s.ownWrites.forget(redisKey)
+	}
+	return err
+}
+
+// redisTTL calculates the Redis expiration for an entry, reporting whether it has already expired
+func (s *Store) redisTTL(e *entry.Entry) (time.Duration, bool) {
 	if e.HasExpiry() {
 		remaining := e.TTL()
 		if remaining <= 0 {
-			// Entry has already expired
-			return s.client.Del(s.ctx, redisKey).Err()
+			return 0, true
 		}
-		redisTTL = remaining
-	} else if s.defaultTTL > 0 {
-		// Use default TTL if no expiry set
-		redisTTL = s.defaultTTL
-	}
-
-	if redisTTL > 0 {
-		return s.client.SetEx(s.ctx, redisKey, string(data), redisTTL).Err()
+		return remaining, false
 	}
-	return s.client.Set(s.ctx, redisKey, string(data), 0).Err()
+	// Use default TTL if no expiry set
+	return s.defaultTTL, false
 }
 
 // Ensure Store implements the required interfaces
 var (
-	_ store.Store    = (*Store)(nil)
-	_ store.TTLStore = (*Store)(nil)
+	_ store.Store              = (*Store)(nil)
+	_ store.TTLStore           = (*Store)(nil)
+	_ store.ScanStore          = (*Store)(nil)
+	_ store.KeyCountStore      = (*Store)(nil)
+	_ store.SwapStore          = (*Store)(nil)
+	_ store.VersionedStore     = (*Store)(nil)
+	_ store.BatchStore         = (*Store)(nil)
+	_ store.BatchGetStore      = (*Store)(nil)
+	_ store.ContextStore       = (*Store)(nil)
+	_ store.HealthChecker      = (*Store)(nil)
+	_ store.InvalidationStore  = (*Store)(nil)
+	_ store.EntryCallbackStore = (*Store)(nil)
 )
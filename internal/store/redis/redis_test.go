@@ -2,13 +2,17 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"slices"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 
-	"github.com/1mb-dev/obcache-go/v2/internal/entry"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
 )
 
 // TestRedisStoreBasicOperations tests basic Redis store operations using a mock
@@ -184,3 +188,443 @@ func TestRedisStoreClear(t *testing.T) {
 		t.Fatal("Expected no entries after clear")
 	}
 }
+
+// commandRecorder is a go-redis hook that records the name and argument count of every command
+type commandRecorder struct {
+	mu       sync.Mutex
+	commands []recordedCommand
+}
+
+type recordedCommand struct {
+	name string
+	args int
+}
+
+func (r *commandRecorder) record(cmds ...redis.Cmder) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, cmd := range cmds {
+		r.commands = append(r.commands, recordedCommand{name: cmd.Name(), args: len(cmd.Args())})
+	}
+}
+
+func (r *commandRecorder) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (r *commandRecorder) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		r.record(cmd)
+		return next(ctx, cmd)
+	}
+}
+
+func (r *commandRecorder) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		r.record(cmds...)
+		return next(ctx, cmds)
+	}
+}
+
+func TestRedisStoreKeysAndClearUseScan(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Redis not available, skipping test: %v", err)
+	}
+
+	s, err := New(&Config{Client: client, KeyPrefix: "scan-clear-test:", Context: ctx})
+	if err != nil {
+		t.Fatalf("Failed to create Redis store: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+	s.scanCount = 100
+
+	const total = 3000
+	entries := make(map[string]*entry.Entry, total)
+	for i := range total {
+		entries[fmt.Sprintf("key-%d", i)] = entry.New(i, time.Hour)
+	}
+	if err := s.SetBatch(entries); err != nil {
+		t.Fatalf("Failed to set entries: %v", err)
+	}
+	_ = client.Set(ctx, "other:key", "kept", time.Hour) // Outside the prefix
+
+	recorder := &commandRecorder{}
+	client.AddHook(recorder)
+
+	keys, err := s.KeysContext(ctx)
+	if err != nil {
+		t.Fatalf("KeysContext failed: %v", err)
+	}
+	if len(keys) != total {
+		t.Fatalf("Expected %d keys, got %d", total, len(keys))
+	}
+	for _, key := range keys {
+		if _, ok := entries[key]; !ok {
+			t.Fatalf("Unexpected key %q", key)
+		}
+	}
+
+	var cleared int
+	if err := s.ClearContext(ctx, func(batch []string) { cleared += len(batch) }); err != nil {
+		t.Fatalf("ClearContext failed: %v", err)
+	}
+	if cleared != total || s.Len() != 0 {
+		t.Fatalf("Expected %d keys reported and none left, got %d and %d", total, cleared, s.Len())
+	}
+	if exists, _ := client.Exists(ctx, "other:key").Result(); exists != 1 {
+		t.Fatal("Expected keys outside the prefix to survive Clear")
+	}
+	_ = client.Del(ctx, "other:key")
+
+	var unlinks int
+	for _, cmd := range recorder.commands {
+		switch cmd.name {
+		case "keys":
+			t.Fatal("Expected no KEYS command")
+		case "unlink":
+			unlinks++
+			if keyArgs := cmd.args - 1; keyArgs > 100 {
+				t.Fatalf("Expected UNLINK batches of at most 100 keys, got %d", keyArgs)
+			}
+		}
+	}
+	if unlinks < total/100 {
+		t.Fatalf("Expected at least %d UNLINK batches, got %d", total/100, unlinks)
+	}
+}
+
+func TestRedisStoreClearContextCancelled(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Redis not available, skipping test: %v", err)
+	}
+
+	s, err := New(&Config{Client: client, KeyPrefix: "scan-cancel-test:", Context: ctx})
+	if err != nil {
+		t.Fatalf("Failed to create Redis store: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	_ = s.Set("key", entry.New("value", time.Hour))
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := s.KeysContext(cancelled); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected KeysContext to stop with context.Canceled, got %v", err)
+	}
+	if err := s.ClearContext(cancelled, nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected ClearContext to stop with context.Canceled, got %v", err)
+	}
+	if s.Len() != 1 {
+		t.Fatal("Expected a cancelled Clear to leave entries in place")
+	}
+}
+
+func TestRedisStoreUpdateTTL(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+	})
+
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Redis not available, skipping test: %v", err)
+	}
+
+	store, err := New(&Config{
+		Client:    client,
+		KeyPrefix: "update-ttl-test:",
+		Context:   ctx,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create Redis store: %v", err)
+	}
+	defer func() {
+		_ = store.Close() // Test cleanup - ignore error
+	}()
+
+	if store.UpdateTTL("missing", time.Hour) {
+		t.Fatal("Expected UpdateTTL to fail for missing key")
+	}
+
+	_ = store.Set("key", entry.New("value", 100*time.Millisecond))
+	if !store.UpdateTTL("key", time.Hour) {
+		t.Fatal("Expected UpdateTTL to succeed for existing key")
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	e, found := store.Get("key")
+	if !found {
+		t.Fatal("Expected entry to outlive its original TTL")
+	}
+	if ttl := e.TTL(); ttl <= 30*time.Minute {
+		t.Fatalf("Expected extended TTL, got %v", ttl)
+	}
+
+	if !store.UpdateTTL("key", 0) {
+		t.Fatal("Expected UpdateTTL to remove expiration")
+	}
+	if e, found = store.Get("key"); !found || e.HasExpiry() {
+		t.Fatal("Expected entry without expiration")
+	}
+}
+
+func TestRedisStoreScan(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+	})
+
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Redis not available, skipping test: %v", err)
+	}
+
+	store, err := New(&Config{
+		Client:    client,
+		KeyPrefix: "scan-test:",
+		Context:   ctx,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create Redis store: %v", err)
+	}
+	defer func() {
+		_ = store.Close() // Test cleanup - ignore error
+	}()
+
+	for i := 0; i < 20; i++ {
+		_ = store.Set(fmt.Sprintf("user:%d", i), entry.New(i, time.Hour))
+		_ = store.Set(fmt.Sprintf("order:%d", i), entry.New(i, time.Hour))
+	}
+	_ = store.Set("user*literal", entry.New("x", time.Hour))
+
+	seen := make(map[string]bool)
+	cursor := ""
+	for {
+		keys, next, err := store.Scan("user:", cursor, 5)
+		if err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		for _, key := range keys {
+			seen[key] = true
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	if len(seen) != 20 {
+		t.Fatalf("Expected 20 user keys, got %d", len(seen))
+	}
+
+	keys, _, err := store.Scan("user*", "", 100)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if len(keys) != 1 || keys[0] != "user*literal" {
+		t.Fatalf("Expected glob characters in prefix to match literally, got %v", keys)
+	}
+}
+
+func TestRedisStoreSwap(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+	})
+
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Redis not available, skipping test: %v", err)
+	}
+
+	store, err := New(&Config{
+		Client:    client,
+		KeyPrefix: "swap-test:",
+		Context:   ctx,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create Redis store: %v", err)
+	}
+	defer func() {
+		_ = store.Close() // Test cleanup - ignore error
+	}()
+
+	_, existed, err := store.Swap("key", entry.New("v1", time.Hour))
+	if err != nil || existed {
+		t.Fatalf("Expected no previous entry, got existed=%v err=%v", existed, err)
+	}
+
+	previous, existed, err := store.Swap("key", entry.New("v2", time.Hour))
+	if err != nil || !existed || previous.Value != "v1" {
+		t.Fatalf("Expected previous value v1, got %v (existed=%v, err=%v)", previous, existed, err)
+	}
+
+	current, found := store.Get("key")
+	if !found || current.Value != "v2" {
+		t.Fatal("Expected swapped value to be stored")
+	}
+	if ttl := current.TTL(); ttl <= 0 || ttl > time.Hour {
+		t.Fatalf("Expected swapped entry to keep its TTL, got %v", ttl)
+	}
+}
+
+func TestRedisStoreSetIfNewer(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+	})
+
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Redis not available, skipping test: %v", err)
+	}
+
+	store, err := New(&Config{
+		Client:    client,
+		KeyPrefix: "versioned-test:",
+		Context:   ctx,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create Redis store: %v", err)
+	}
+	defer func() {
+		_ = store.Close() // Test cleanup - ignore error
+	}()
+
+	newer := entry.New("v2", time.Hour)
+	newer.Version = 2
+	if written, err := store.SetIfNewer("key", newer); err != nil || !written {
+		t.Fatalf("Expected first versioned write to succeed, got %v (err=%v)", written, err)
+	}
+
+	stale := entry.New("v1", time.Hour)
+	stale.Version = 1
+	if written, err := store.SetIfNewer("key", stale); err != nil || written {
+		t.Fatalf("Expected stale write to be rejected, got %v (err=%v)", written, err)
+	}
+
+	current, found := store.Get("key")
+	if !found || current.Value != "v2" || current.Version != 2 {
+		t.Fatalf("Expected v2 at version 2, got %v", current)
+	}
+	if ttl := current.TTL(); ttl <= 0 {
+		t.Fatalf("Expected versioned entry to keep its TTL, got %v", ttl)
+	}
+}
+
+func TestRedisStoreGetDoesNotWrite(t *testing.T) {
+	server := newFailoverServer(t, 0)
+	s := newFailoverStore(t, server, 0)
+
+	if err := s.Set("key", entry.New("v1", time.Hour)); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	server.mu.Lock()
+	sets := server.sets
+	server.mu.Unlock()
+
+	// A write-back could replace a newer versioned write that landed after the load
+	if e, found := s.Get("key"); !found || e.Value != "v1" {
+		t.Fatalf("Expected v1, got %v (found=%v)", e, found)
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.sets != sets {
+		t.Errorf("Expected Get not to write to Redis, got %d SETs", server.sets-sets)
+	}
+}
+
+func TestRedisStoreCountPrefixKeys(t *testing.T) {
+	server := newFailoverServer(t, 0)
+	s := newFailoverStore(t, server, 0)
+
+	for _, key := range []string{"user:1", "user:2", "order:1"} {
+		if err := s.Set(key, entry.NewWithoutTTL("v")); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+
+	// The test server has no STRLEN, so only a SCAN-only count can succeed
+	if n := s.CountPrefixKeys("user:"); n != 2 {
+		t.Errorf("Expected 2 user keys, got %d", n)
+	}
+}
+
+func TestRedisStorePing(t *testing.T) {
+	s := newFailoverStore(t, newFailoverServer(t, 0), 0)
+	if err := s.Ping(context.Background()); err != nil {
+		t.Errorf("Expected Ping to succeed, got %v", err)
+	}
+
+	// Nothing listens on a closed listener's address
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	_ = listener.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: addr, MaxRetries: -1})
+	defer func() { _ = client.Close() }()
+	down, err := New(&Config{Client: client})
+	if err != nil {
+		t.Fatalf("Failed to create Redis store: %v", err)
+	}
+	if err := down.Ping(context.Background()); err == nil {
+		t.Error("Expected Ping to fail when Redis is unreachable")
+	}
+}
+
+func TestRedisStoreClearIsPrefixScoped(t *testing.T) {
+	server := newFailoverServer(t, 0)
+	client := redis.NewClient(&redis.Options{
+		Addr:            server.listener.Addr().String(),
+		Protocol:        2,
+		DisableIdentity: true,
+	})
+	t.Cleanup(func() { _ = client.Close() })
+
+	// An unescaped "cache[1]:*" pattern would also match the other cache's keys
+	newStore := func(prefix string) *Store {
+		s, err := New(&Config{Client: client, KeyPrefix: prefix})
+		if err != nil {
+			t.Fatalf("Failed to create Redis store: %v", err)
+		}
+		return s
+	}
+	first := newStore("cache[1]:")
+	second := newStore("cache1:")
+
+	for _, key := range []string{"a", "b"} {
+		_ = first.Set(key, entry.New("first", time.Hour))
+	}
+	for _, key := range []string{"a", "c"} {
+		_ = second.Set(key, entry.New("second", time.Hour))
+	}
+	_ = client.Set(context.Background(), "session:42", "other application", 0).Err()
+
+	if n := first.Len(); n != 2 {
+		t.Errorf("Expected the first cache to count 2 keys, got %d", n)
+	}
+	keys := second.Keys()
+	slices.Sort(keys)
+	if !slices.Equal(keys, []string{"a", "c"}) {
+		t.Errorf("Expected the second cache's keys to be [a c], got %v", keys)
+	}
+
+	if err := first.Clear(); err != nil {
+		t.Fatalf("Clear failed: %v", err)
+	}
+	if n := first.Len(); n != 0 {
+		t.Errorf("Expected the first cache to be empty, got %d keys", n)
+	}
+	if n := second.Len(); n != 2 {
+		t.Errorf("Expected the second cache to keep its 2 keys, got %d", n)
+	}
+	if e, found := second.Get("a"); !found || e.Value != "second" {
+		t.Errorf("Expected the second cache's a to survive, got %v (found=%v)", e, found)
+	}
+	if _, ok := server.value("session:42"); !ok {
+		t.Error("Expected keys outside both prefixes to survive")
+	}
+}
@@ -0,0 +1,50 @@
+package redis
+
+import (
+	"errors"
+	"io"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultRetryBackoff is the delay before the first retry; later retries wait proportionally longer
+const DefaultRetryBackoff = 50 * time.Millisecond
+
+// isRetryable reports whether err is a transient failure of the kind seen while
+// Sentinel promotes a new master: writes hitting a demoted replica, a master that
+// is still loading or unreachable, and connections dropped by the switch-over
+func isRetryable(err error) bool {
+	if err == nil || errors.Is(err, redis.Nil) {
+		return false
+	}
+
+	switch {
+	case redis.IsReadOnlyError(err), redis.IsLoadingError(err),
+		redis.IsMasterDownError(err), redis.IsTryAgainError(err):
+		return true
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.EPIPE), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return true
+	}
+
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && !opErr.Timeout()
+}
+
+// retry runs op, retrying up to maxRetries times while it fails with a retryable error
+// It stops early when the store's context is done and returns the last error
+func (s *Store) retry(op func() error) error {
+	err := op()
+	for attempt := 1; attempt <= s.maxRetries && isRetryable(err); attempt++ {
+		select {
+		case <-time.After(s.retryBackoff * time.Duration(attempt)):
+		case <-s.ctx.Done():
+			return err
+		}
+		err = op()
+	}
+	return err
+}
@@ -0,0 +1,271 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+)
+
+// failoverServer is a minimal RESP2 server that answers SET with READONLY until
+// readOnlyWrites reaches zero, as a demoted master does during a failover
+type failoverServer struct {
+	listener       net.Listener
+	mu             sync.Mutex
+	readOnlyWrites int
+	sets           int
+	mgets          int
+	values         map[string]string
+	down           bool // Drops connections instead of replying, like an unreachable server
+}
+
+func newFailoverServer(t *testing.T, readOnlyWrites int) *failoverServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	s := &failoverServer{listener: listener, readOnlyWrites: readOnlyWrites, values: make(map[string]string)}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *failoverServer) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil || s.isDown() {
+			return
+		}
+		if _, err := io.WriteString(conn, s.reply(args)); err != nil {
+			return
+		}
+	}
+}
+
+func (s *failoverServer) isDown() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.down
+}
+
+func (s *failoverServer) setDown(down bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.down = down
+}
+
+func (s *failoverServer) value(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.values[key]
+	return value, ok
+}
+
+func (s *failoverServer) reply(args []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch strings.ToUpper(args[0]) {
+	case "SET":
+		s.sets++
+		if s.readOnlyWrites > 0 {
+			s.readOnlyWrites--
+			return "-READONLY You can't write against a read only replica.\r\n"
+		}
+		if _, exists := s.values[args[1]]; exists && slices.ContainsFunc(args[3:], isNX) {
+			return "$-1\r\n"
+		}
+		s.values[args[1]] = args[2]
+		return "+OK\r\n"
+	case "GET":
+		value, ok := s.values[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+	case "MGET":
+		s.mgets++
+		var reply strings.Builder
+		fmt.Fprintf(&reply, "*%d\r\n", len(args)-1)
+		for _, key := range args[1:] {
+			if value, ok := s.values[key]; ok {
+				fmt.Fprintf(&reply, "$%d\r\n%s\r\n", len(value), value)
+			} else {
+				reply.WriteString("$-1\r\n")
+			}
+		}
+		return reply.String()
+	case "DEL", "UNLINK":
+		deleted := 0
+		for _, key := range args[1:] {
+			if _, ok := s.values[key]; ok {
+				delete(s.values, key)
+				deleted++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", deleted)
+	case "PTTL":
+		return ":-1\r\n"
+	case "SCAN":
+		// Every matching key is returned in one page
+		pattern := "*"
+		if i := slices.IndexFunc(args, isMatch); i > 0 {
+			pattern = args[i+1]
+		}
+		var keys []string
+		for key := range s.values {
+			if matched, _ := path.Match(pattern, key); matched {
+				keys = append(keys, key)
+			}
+		}
+		var reply strings.Builder
+		fmt.Fprintf(&reply, "*2\r\n$1\r\n0\r\n*%d\r\n", len(keys))
+		for _, key := range keys {
+			fmt.Fprintf(&reply, "$%d\r\n%s\r\n", len(key), key)
+		}
+		return reply.String()
+	case "PING":
+		return "+PONG\r\n"
+	default:
+		return "-ERR unknown command\r\n"
+	}
+}
+
+func isMatch(arg string) bool {
+	return strings.EqualFold(arg, "MATCH")
+}
+
+func isNX(arg string) bool {
+	return strings.EqualFold(arg, "NX")
+}
+
+// readCommand reads one RESP array of bulk strings
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		if _, err := r.ReadString('\n'); err != nil { // $<len>
+			return nil, err
+		}
+		arg, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+	return args, nil
+}
+
+func newFailoverStore(t *testing.T, server *failoverServer, maxRetries int) *Store {
+	t.Helper()
+	client := redis.NewClient(&redis.Options{
+		Addr:            server.listener.Addr().String(),
+		Protocol:        2,
+		DisableIdentity: true,
+		MaxRetries:      -1, // Leave retries to the store
+	})
+	t.Cleanup(func() { _ = client.Close() })
+
+	s, err := New(&Config{Client: client, MaxRetries: maxRetries, RetryBackoff: time.Millisecond})
+	if err != nil {
+		t.Fatalf("Failed to create Redis store: %v", err)
+	}
+	return s
+}
+
+func TestRetryOnReadOnly(t *testing.T) {
+	server := newFailoverServer(t, 2)
+	s := newFailoverStore(t, server, 3)
+
+	if err := s.Set("key", entry.NewWithoutTTL("value")); err != nil {
+		t.Fatalf("Expected Set to succeed after retries, got %v", err)
+	}
+	if server.sets != 3 {
+		t.Errorf("Expected 3 SET attempts, got %d", server.sets)
+	}
+	if e, found := s.Get("key"); !found || e.Value != "value" {
+		t.Errorf("Expected value after failover, got %v (found=%v)", e, found)
+	}
+}
+
+func TestRetryIsBounded(t *testing.T) {
+	server := newFailoverServer(t, 10)
+	s := newFailoverStore(t, server, 2)
+
+	err := s.Set("key", entry.NewWithoutTTL("value"))
+	if !redis.IsReadOnlyError(err) {
+		t.Fatalf("Expected READONLY error once retries are exhausted, got %v", err)
+	}
+	if server.sets != 3 {
+		t.Errorf("Expected 1 attempt plus 2 retries, got %d", server.sets)
+	}
+	if !isRetryable(err) {
+		t.Error("Expected a READONLY reply to be retryable")
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"miss", redis.Nil, false},
+		{"connection reset", fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+		{"connection refused", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, true},
+		{"closed connection", io.EOF, true},
+		{"timeout", &net.OpError{Op: "read", Net: "tcp", Err: timeoutError{}}, false},
+		{"canceled", context.Canceled, false},
+		{"application error", errors.New("ERR wrong number of arguments"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryable(tt.err); got != tt.want {
+				t.Errorf("isRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// timeoutError is a net.Error that reports a timeout
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
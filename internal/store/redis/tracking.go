@@ -0,0 +1,225 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/redis/go-redis/v9/push"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/store"
+)
+
+// DefaultTrackingInterval is how often the tracking connection is polled for invalidations
+const DefaultTrackingInterval = 100 * time.Millisecond
+
+// invalidatePush is the RESP3 push Redis sends when a tracked key changes
+const invalidatePush = "invalidate"
+
+// startTracking opens a dedicated RESP3 connection with broadcast client tracking
+// for the store's prefix and polls it so invalidation pushes are read even while
+// reads are served from a local tier. Every (re)connect after the first reports a
+// nil key set, since pushes sent while the connection was down are lost.
+// Pushes for the store's own SETs are skipped, see ownWrites.
+// It returns once tracking is enabled
+func (s *Store) startTracking(interval time.Duration) error {
+	client, ok := s.client.(*redis.Client)
+	if !ok {
+		return fmt.Errorf("client tracking requires a *redis.Client")
+	}
+
+	s.ownWrites = &ownWrites{counts: make(map[string]int)}
+
+	options := *client.Options()
+	options.Protocol = 3
+	options.PoolSize = 1
+	options.MinIdleConns = 0
+	options.MaxIdleConns = 0
+	options.MaxRetries = -1 // Surface dropped connections instead of retrying on a new one
+	options.PushNotificationProcessor = nil
+
+	var connected atomic.Bool
+	onConnect := options.OnConnect
+	options.OnConnect = func(ctx context.Context, cn *redis.Conn) error {
+		if onConnect != nil {
+			if err := onConnect(ctx, cn); err != nil {
+				return err
+			}
+		}
+		if err := cn.Do(ctx, "CLIENT", "TRACKING", "ON", "BCAST", "PREFIX", s.keyPrefix).Err(); err != nil {
+			return fmt.Errorf("failed to enable client tracking: %w", err)
+		}
+		if connected.Swap(true) {
+			s.ownWrites.reset() // Their pushes may have been lost with the connection
+			s.notifyInvalidated(nil)
+		}
+		return nil
+	}
+
+	tracker := redis.NewClient(&options)
+	if err := tracker.RegisterPushNotificationHandler(invalidatePush, invalidationHandler{s}, true); err != nil {
+		_ = tracker.Close()
+		return fmt.Errorf("failed to enable client tracking: %w", err)
+	}
+	if err := tracker.Ping(s.ctx).Err(); err != nil {
+		_ = tracker.Close()
+		return fmt.Errorf("failed to enable client tracking: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	done := make(chan struct{})
+	s.tracker = tracker
+	s.stopTrackingLoop = cancel
+	s.trackingDone = done
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				// Pending pushes are handled before the reply is read; errors drop
+				// the connection and the next poll reconnects
+				_ = tracker.Ping(ctx).Err()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// stopTracking stops polling and closes the tracking connection
+func (s *Store) stopTracking() {
+	s.mu.Lock()
+	tracker, cancel, done := s.tracker, s.stopTrackingLoop, s.trackingDone
+	s.tracker, s.stopTrackingLoop, s.trackingDone = nil, nil, nil
+	s.mu.Unlock()
+
+	if tracker == nil {
+		return
+	}
+	cancel()
+	<-done
+	_ = tracker.Close()
+}
+
+// SetInvalidateCallback sets the callback for keys reported changed by client tracking
+func (s *Store) SetInvalidateCallback(callback store.InvalidateCallback) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.invalidateCallback = callback
+}
+
+// notifyInvalidated reports changed keys under the store's prefix to the
+// invalidate callback; nil means any key may have changed
+func (s *Store) notifyInvalidated(keys []string) {
+	s.mu.RLock()
+	callback := s.invalidateCallback
+	s.mu.RUnlock()
+
+	if callback != nil {
+		callback(keys)
+	}
+}
+
+// invalidationHandler turns invalidate pushes into invalidate callbacks
+type invalidationHandler struct {
+	s *Store
+}
+
+// HandlePushNotification handles ["invalidate", [key, ...]], where a nil key
+// list means the database was flushed
+func (h invalidationHandler) HandlePushNotification(_ context.Context, _ push.NotificationHandlerContext, notification []any) error {
+	if len(notification) < 2 || notification[1] == nil {
+		h.s.notifyInvalidated(nil)
+		return nil
+	}
+
+	redisKeys, ok := notification[1].([]any)
+	if !ok {
+		return fmt.Errorf("unexpected invalidate payload %T", notification[1])
+	}
+	keys := make([]string, 0, len(redisKeys))
+	for _, redisKey := range redisKeys {
+		if name, ok := redisKey.(string); ok && !h.s.ownWrites.take(name) {
+			if key := h.s.extractKey(name); key != "" {
+				keys = append(keys, key)
+			}
+		}
+	}
+	if len(keys) > 0 {
+		h.s.notifyInvalidated(keys)
+	}
+	return nil
+}
+
+// ownWrites counts the SETs this store sent per Redis key whose invalidate push
+// has not been read yet, so local copies are not dropped for the store's own
+// writes. NOLOOP cannot do this: it only skips writes sent on the tracking
+// connection, and the store writes through its client's pool. Writes are
+// recorded before they are sent and forgotten when they fail, so a count never
+// outlives its push and another client's write is always reported
+type ownWrites struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// expect records one upcoming SET of each key; it does nothing on a nil receiver
+func (w *ownWrites) expect(redisKeys ...string) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, redisKey := range redisKeys {
+		w.counts[redisKey]++
+	}
+}
+
+// forget removes a SET recorded by expect that failed or did not write
+func (w *ownWrites) forget(redisKeys ...string) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, redisKey := range redisKeys {
+		if w.counts[redisKey] <= 1 {
+			delete(w.counts, redisKey)
+		} else {
+			w.counts[redisKey]--
+		}
+	}
+}
+
+// take reports whether a push for redisKey is for a recorded SET, consuming it
+func (w *ownWrites) take(redisKey string) bool {
+	if w == nil {
+		return false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.counts[redisKey]; !ok {
+		return false
+	}
+	if w.counts[redisKey]--; w.counts[redisKey] == 0 {
+		delete(w.counts, redisKey)
+	}
+	return true
+}
+
+// reset forgets every recorded SET
+func (w *ownWrites) reset() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	clear(w.counts)
+}
@@ -0,0 +1,244 @@
+package redis
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+)
+
+// trackingServer is a minimal RESP3 server that supports broadcast client tracking
+type trackingServer struct {
+	listener net.Listener
+
+	mu       sync.Mutex
+	values   map[string]string
+	sets     int
+	tracking map[*trackingConn]string // Tracking connections and their prefixes
+}
+
+// trackingConn serializes replies and pushes written to one connection
+type trackingConn struct {
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+func (c *trackingConn) write(s string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := io.WriteString(c.conn, s)
+	return err
+}
+
+func newTrackingServer(t *testing.T) *trackingServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	s := &trackingServer{
+		listener: listener,
+		values:   make(map[string]string),
+		tracking: make(map[*trackingConn]string),
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(&trackingConn{conn: conn})
+		}
+	}()
+	return s
+}
+
+func (s *trackingServer) serve(c *trackingConn) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.tracking, c)
+		s.mu.Unlock()
+		_ = c.conn.Close()
+	}()
+	r := bufio.NewReader(c.conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		if err := c.write(s.reply(c, args)); err != nil {
+			return
+		}
+	}
+}
+
+func (s *trackingServer) reply(c *trackingConn, args []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch strings.ToUpper(args[0]) {
+	case "HELLO":
+		return "%3\r\n+server\r\n+redis\r\n+version\r\n+7.2.0\r\n+proto\r\n:3\r\n"
+	case "CLIENT":
+		if strings.EqualFold(args[1], "TRACKING") {
+			s.tracking[c] = args[slices.IndexFunc(args, isPrefix)+1]
+		}
+		return "+OK\r\n"
+	case "SET":
+		s.sets++
+		s.values[args[1]] = args[2]
+		s.invalidate(args[1])
+		return "+OK\r\n"
+	case "DEL":
+		delete(s.values, args[1])
+		s.invalidate(args[1])
+		return ":1\r\n"
+	case "GET":
+		value, ok := s.values[args[1]]
+		if !ok {
+			return "_\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+	case "PTTL":
+		return ":-1\r\n"
+	case "PING":
+		return "+PONG\r\n"
+	default:
+		return "-ERR unknown command\r\n"
+	}
+}
+
+func isPrefix(arg string) bool {
+	return strings.EqualFold(arg, "PREFIX")
+}
+
+// invalidate pushes key to every connection tracking a prefix of it, in the
+// order of the writes as Redis does (assumes s.mu is held)
+func (s *trackingServer) invalidate(key string) {
+	push := fmt.Sprintf(">2\r\n$10\r\ninvalidate\r\n*1\r\n$%d\r\n%s\r\n", len(key), key)
+	for c, prefix := range s.tracking {
+		if strings.HasPrefix(key, prefix) {
+			_ = c.write(push)
+		}
+	}
+}
+
+// dropTracking closes every tracking connection
+func (s *trackingServer) dropTracking() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.tracking {
+		_ = c.conn.Close()
+	}
+}
+
+func (s *trackingServer) setCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sets
+}
+
+func newTrackingStore(t *testing.T, server *trackingServer) (*Store, <-chan []string) {
+	t.Helper()
+	client := redis.NewClient(&redis.Options{
+		Addr:            server.listener.Addr().String(),
+		DisableIdentity: true,
+	})
+	t.Cleanup(func() { _ = client.Close() })
+
+	s, err := New(&Config{Client: client, ClientTracking: true, TrackingInterval: 5 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Failed to create Redis store: %v", err)
+	}
+	t.Cleanup(func() { s.stopTracking() })
+
+	invalidated := make(chan []string, 10)
+	s.SetInvalidateCallback(func(keys []string) { invalidated <- keys })
+	return s, invalidated
+}
+
+func awaitInvalidation(t *testing.T, invalidated <-chan []string) []string {
+	t.Helper()
+	select {
+	case keys := <-invalidated:
+		return keys
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected an invalidation")
+		return nil
+	}
+}
+
+func TestClientTrackingReportsChangedKeys(t *testing.T) {
+	server := newTrackingServer(t)
+	s, invalidated := newTrackingStore(t, server)
+
+	other := redis.NewClient(&redis.Options{Addr: server.listener.Addr().String(), DisableIdentity: true})
+	t.Cleanup(func() { _ = other.Close() })
+
+	_ = other.Set(t.Context(), "unrelated", "value", 0).Err()
+	_ = other.Set(t.Context(), s.buildKey("user:1"), "value", 0).Err()
+	if keys := awaitInvalidation(t, invalidated); !slices.Equal(keys, []string{"user:1"}) {
+		t.Errorf("Expected user:1 to be reported without its prefix, got %v", keys)
+	}
+
+	// Only writes from other clients are reported; the push for the store's
+	// own write would arrive before the one for user:2
+	if err := s.Set("config", entry.New("v1", time.Hour)); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := s.SetBatch(map[string]*entry.Entry{"batch": entry.New("v1", time.Hour)}); err != nil {
+		t.Fatalf("SetBatch failed: %v", err)
+	}
+	_ = other.Set(t.Context(), s.buildKey("user:2"), "value", 0).Err()
+	if keys := awaitInvalidation(t, invalidated); !slices.Equal(keys, []string{"user:2"}) {
+		t.Errorf("Expected only user:2 from the other client, got %v", keys)
+	}
+
+	// Reads do not write back access times, which would invalidate other
+	// instances' copies of every key they read
+	sets := server.setCount()
+	if _, found := s.Get("config"); !found {
+		t.Fatal("Expected config to be found")
+	}
+	if server.setCount() != sets {
+		t.Error("Expected Get not to write to Redis while tracking")
+	}
+
+	_ = other.Set(t.Context(), s.buildKey("config"), "value", 0).Err()
+	if keys := awaitInvalidation(t, invalidated); !slices.Equal(keys, []string{"config"}) {
+		t.Errorf("Expected config written by the other client, got %v", keys)
+	}
+}
+
+func TestClientTrackingFlushesAfterReconnect(t *testing.T) {
+	server := newTrackingServer(t)
+	_, invalidated := newTrackingStore(t, server)
+
+	// Pushes sent while the tracking connection is down are lost, so the
+	// reconnect reports that any key may have changed
+	server.dropTracking()
+	if keys := awaitInvalidation(t, invalidated); keys != nil {
+		t.Errorf("Expected a nil key set after reconnecting, got %v", keys)
+	}
+}
+
+func TestClientTrackingRequiresClient(t *testing.T) {
+	server := newTrackingServer(t)
+	ring := redis.NewRing(&redis.RingOptions{Addrs: map[string]string{"a": server.listener.Addr().String()}})
+	t.Cleanup(func() { _ = ring.Close() })
+
+	if _, err := New(&Config{Client: ring, ClientTracking: true}); err == nil {
+		t.Error("Expected client tracking to require a *redis.Client")
+	}
+}
@@ -0,0 +1,299 @@
+package ristretto
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dgraph-io/ristretto/v2"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+	"github.com/1mb-dev/obcache-go/v2/pkg/store"
+)
+
+// DefaultBufferItems is the number of keys per Get buffer, as recommended by Ristretto
+const DefaultBufferItems = 64
+
+// Store is an in-memory store backed by a Ristretto cache, which admits and
+// evicts entries by estimated access frequency (TinyLFU) with low contention.
+//
+// It differs from the memory store in three ways:
+//   - Writes are applied asynchronously. A Get right after a Set of a new key may
+//     miss until Ristretto has processed the write, unless SyncWrites is set
+//   - Admission may reject a Set, or Ristretto may drop it under contention; the
+//     entry is then simply not cached and Set still returns nil
+//   - Keys, Len and Peek read a key index kept alongside the cache, which may
+//     briefly list keys whose writes are still pending or were rejected
+type Store struct {
+	cache       *ristretto.Cache[string, *item]
+	maxEntries  int
+	syncWrites  bool
+	keys        sync.Map // key -> *item, the index behind Keys, Len and Peek
+	count       atomic.Int64
+	quiet       atomic.Bool // Set while clearing or closing, when removals are not evictions
+	mu          sync.RWMutex
+	evictFunc   store.EvictCallback
+	cleanupFunc store.EvictCallback
+}
+
+// item is the value stored in Ristretto, which only keeps hashes of its keys
+type item struct {
+	key   string
+	entry *entry.Entry
+}
+
+// Config holds Ristretto store configuration
+type Config struct {
+	// MaxEntries bounds the number of entries when MaxWeight is zero
+	MaxEntries int
+
+	// MaxWeight bounds the total entry size in bytes; each entry costs its Size
+	// When set, MaxEntries is only used to size the frequency counters
+	MaxWeight int64
+
+	// NumCounters is the number of access counters; Ristretto recommends ten
+	// times the expected number of entries
+	// Default: 10 * MaxEntries, or MaxWeight / 100 when only MaxWeight is set
+	NumCounters int64
+
+	// SyncWrites makes Set wait until Ristretto has applied the write, trading
+	// write throughput for read-your-writes behaviour
+	SyncWrites bool
+}
+
+// New creates a Ristretto-backed store
+func New(config *Config) (*Store, error) {
+	if config.MaxEntries <= 0 && config.MaxWeight <= 0 {
+		return nil, fmt.Errorf("ristretto store requires a positive MaxEntries or MaxWeight")
+	}
+
+	numCounters := config.NumCounters
+	if numCounters <= 0 {
+		if config.MaxEntries > 0 {
+			numCounters = 10 * int64(config.MaxEntries)
+		} else {
+			numCounters = max(config.MaxWeight/100, 1000)
+		}
+	}
+
+	s := &Store{
+		maxEntries: config.MaxEntries,
+		syncWrites: config.SyncWrites,
+	}
+
+	maxCost := int64(config.MaxEntries)
+	cost := func(*item) int64 { return 1 }
+	if config.MaxWeight > 0 {
+		maxCost = config.MaxWeight
+		cost = func(it *item) int64 { return int64(max(it.entry.Size(), 1)) }
+	}
+
+	cache, err := ristretto.NewCache(&ristretto.Config[string, *item]{
+		NumCounters:        numCounters,
+		MaxCost:            maxCost,
+		BufferItems:        DefaultBufferItems,
+		Cost:               cost,
+		IgnoreInternalCost: true,
+		OnEvict:            s.onEvict,
+		OnExit:             s.onExit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ristretto cache: %w", err)
+	}
+	s.cache = cache
+
+	return s, nil
+}
+
+// Get retrieves an entry, counting the access towards the key's admission frequency
+func (s *Store) Get(key string) (*entry.Entry, bool) {
+	it, found := s.cache.Get(key)
+	if !found || it == nil || it.entry.IsExpired() {
+		return nil, false
+	}
+	return it.entry, true
+}
+
+// Peek retrieves an entry from the key index without recording an access
+func (s *Store) Peek(key string) (*entry.Entry, bool) {
+	value, found := s.keys.Load(key)
+	if !found {
+		return nil, false
+	}
+	e := value.(*item).entry
+	if e.IsExpired() {
+		return nil, false
+	}
+	return e, true
+}
+
+// Set offers the entry to Ristretto. A rejected or dropped write is not an error
+func (s *Store) Set(key string, e *entry.Entry) error {
+	var ttl time.Duration
+	if e.ExpiresAt != nil {
+		if ttl = time.Until(*e.ExpiresAt); ttl <= 0 {
+			return s.Delete(key)
+		}
+	}
+
+	it := &item{key: key, entry: e}
+	if _, replaced := s.keys.Swap(key, it); !replaced {
+		s.count.Add(1)
+	}
+	if !s.cache.SetWithTTL(key, it, 0, ttl) {
+		s.forget(it)
+		return nil
+	}
+	if s.syncWrites {
+		s.cache.Wait()
+	}
+	return nil
+}
+
+// Delete removes an entry
+func (s *Store) Delete(key string) error {
+	if _, found := s.keys.LoadAndDelete(key); found {
+		s.count.Add(-1)
+	}
+	s.cache.Del(key)
+	return nil
+}
+
+// Keys returns the keys in the index that have not expired
+func (s *Store) Keys() []string {
+	keys := make([]string, 0, s.count.Load())
+	s.keys.Range(func(key, value any) bool {
+		if !value.(*item).entry.IsExpired() {
+			keys = append(keys, key.(string))
+		}
+		return true
+	})
+	return keys
+}
+
+// Len returns the number of keys in the index, including expired entries not yet cleaned up
+func (s *Store) Len() int {
+	return int(s.count.Load())
+}
+
+// Clear removes all entries without reporting them as evicted
+func (s *Store) Clear() error {
+	s.quiet.Store(true)
+	defer s.quiet.Store(false)
+
+	s.cache.Clear()
+	s.keys.Clear()
+	s.count.Store(0)
+	return nil
+}
+
+// Close stops Ristretto's background goroutines
+func (s *Store) Close() error {
+	s.quiet.Store(true)
+	s.cache.Close()
+	return nil
+}
+
+// Ping always succeeds; the store is in process
+func (s *Store) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// UpdateTTL replaces the entry with a copy that expires after ttl, since
+// Ristretto only changes an expiration on Set. A ttl <= 0 removes the expiration
+func (s *Store) UpdateTTL(key string, ttl time.Duration) bool {
+	e, found := s.Peek(key)
+	if !found {
+		return false
+	}
+	return s.Set(key, withTTL(e, ttl)) == nil
+}
+
+// Cleanup returns 0; Ristretto removes expired entries on its own ticker and
+// reports them through the cleanup callback
+func (s *Store) Cleanup() int {
+	return 0
+}
+
+// Capacity returns the configured maximum number of entries
+func (s *Store) Capacity() int {
+	return s.maxEntries
+}
+
+// SetEvictCallback sets the callback for entries evicted by the admission policy
+func (s *Store) SetEvictCallback(callback store.EvictCallback) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evictFunc = callback
+}
+
+// SetCleanupCallback sets the callback for expired entries removed by Ristretto
+func (s *Store) SetCleanupCallback(callback store.EvictCallback) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cleanupFunc = callback
+}
+
+// onEvict reports an entry Ristretto removed on its own, as expired if it was
+// and as a capacity eviction otherwise
+func (s *Store) onEvict(evicted *ristretto.Item[*item]) {
+	it := evicted.Value
+	if it == nil || s.quiet.Load() {
+		return
+	}
+
+	s.mu.RLock()
+	callback := s.evictFunc
+	if it.entry.IsExpired() {
+		callback = s.cleanupFunc
+	}
+	s.mu.RUnlock()
+
+	if callback != nil {
+		callback(it.key, it.entry.Value)
+	}
+}
+
+// onExit drops a value that left Ristretto, through eviction, rejection,
+// replacement or deletion, from the key index
+func (s *Store) onExit(it *item) {
+	if it != nil {
+		s.forget(it)
+	}
+}
+
+// forget removes it from the key index unless its key now holds a newer item
+func (s *Store) forget(it *item) {
+	if s.keys.CompareAndDelete(it.key, it) {
+		s.count.Add(-1)
+	}
+}
+
+// withTTL returns a copy of e that expires after ttl
+func withTTL(e *entry.Entry, ttl time.Duration) *entry.Entry {
+	updated := &entry.Entry{
+		Value:          e.Value,
+		CreatedAt:      e.CreatedAt,
+		AccessedAt:     e.LastAccess(),
+		ValueSize:      e.ValueSize,
+		Version:        e.Version,
+		IsCompressed:   e.IsCompressed,
+		CompressorName: e.CompressorName,
+		CodecName:      e.CodecName,
+		DictionaryID:   e.DictionaryID,
+		OriginalSize:   e.OriginalSize,
+		CompressedSize: e.CompressedSize,
+	}
+	updated.UpdateExpiry(ttl)
+	return updated
+}
+
+// Ensure Store implements the required interfaces
+var (
+	_ store.Store         = (*Store)(nil)
+	_ store.LRUStore      = (*Store)(nil)
+	_ store.TTLStore      = (*Store)(nil)
+	_ store.HealthChecker = (*Store)(nil)
+)
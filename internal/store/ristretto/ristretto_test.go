@@ -0,0 +1,271 @@
+package ristretto
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/ristretto/v2"
+
+	"github.com/1mb-dev/obcache-go/v2/internal/eviction"
+	"github.com/1mb-dev/obcache-go/v2/internal/store/memory"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+	"github.com/1mb-dev/obcache-go/v2/pkg/store"
+)
+
+func newTestStore(t *testing.T, config *Config) *Store {
+	t.Helper()
+	s, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create ristretto store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+// expiredEntry returns an entry that expired a second ago
+func expiredEntry(value any) *entry.Entry {
+	e := entry.NewWithoutTTL(value)
+	expiresAt := time.Now().Add(-time.Second)
+	e.ExpiresAt = &expiresAt
+	return e
+}
+
+func TestRistrettoStoreBasicOperations(t *testing.T) {
+	s := newTestStore(t, &Config{MaxEntries: 100, SyncWrites: true})
+
+	if err := s.Set("key", entry.New("value", time.Hour)); err != nil {
+		t.Fatalf("Failed to set entry: %v", err)
+	}
+	if e, found := s.Get("key"); !found || e.Value != "value" {
+		t.Fatalf("Expected to get value, got %v (found=%v)", e, found)
+	}
+	if e, found := s.Peek("key"); !found || e.Value != "value" {
+		t.Errorf("Expected to peek value, got %v (found=%v)", e, found)
+	}
+	if keys := s.Keys(); len(keys) != 1 || keys[0] != "key" {
+		t.Errorf("Expected keys [key], got %v", keys)
+	}
+	if s.Len() != 1 {
+		t.Errorf("Expected length 1, got %d", s.Len())
+	}
+
+	if err := s.Set("key", entry.New("updated", time.Hour)); err != nil {
+		t.Fatalf("Failed to update entry: %v", err)
+	}
+	if e, _ := s.Get("key"); e == nil || e.Value != "updated" {
+		t.Errorf("Expected the update to be visible, got %v", e)
+	}
+	if s.Len() != 1 {
+		t.Errorf("Expected an update to keep length 1, got %d", s.Len())
+	}
+
+	if err := s.Delete("key"); err != nil {
+		t.Fatalf("Failed to delete entry: %v", err)
+	}
+	if _, found := s.Get("key"); found {
+		t.Error("Expected key to be deleted")
+	}
+	if s.Len() != 0 {
+		t.Errorf("Expected length 0 after delete, got %d", s.Len())
+	}
+}
+
+func TestRistrettoStoreRequiresCapacity(t *testing.T) {
+	if _, err := New(&Config{}); err == nil {
+		t.Error("Expected an error without MaxEntries or MaxWeight")
+	}
+}
+
+func TestRistrettoStoreExpiration(t *testing.T) {
+	s := newTestStore(t, &Config{MaxEntries: 100, SyncWrites: true})
+
+	_ = s.Set("short", entry.New("value", 20*time.Millisecond))
+	_ = s.Set("expired", expiredEntry("value"))
+	if _, found := s.Peek("expired"); found {
+		t.Error("Expected an already expired entry not to be stored")
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	if _, found := s.Get("short"); found {
+		t.Error("Expected the entry to expire")
+	}
+	if keys := s.Keys(); len(keys) != 0 {
+		t.Errorf("Expected Keys to skip expired entries, got %v", keys)
+	}
+}
+
+func TestRistrettoStoreUpdateTTL(t *testing.T) {
+	s := newTestStore(t, &Config{MaxEntries: 100, SyncWrites: true})
+
+	_ = s.Set("key", entry.New("value", time.Hour))
+	if !s.UpdateTTL("key", time.Minute) {
+		t.Fatal("Expected UpdateTTL to succeed")
+	}
+	if e, _ := s.Get("key"); e == nil || e.TTL() > time.Minute {
+		t.Errorf("Expected the entry to expire within a minute, got %v", e)
+	}
+	if !s.UpdateTTL("key", 0) {
+		t.Fatal("Expected UpdateTTL to remove the expiration")
+	}
+	if e, _ := s.Get("key"); e == nil || e.ExpiresAt != nil {
+		t.Errorf("Expected the entry not to expire, got %v", e)
+	}
+	if s.UpdateTTL("missing", time.Minute) {
+		t.Error("Expected UpdateTTL to fail for a missing key")
+	}
+}
+
+func TestRistrettoStoreEvictCallback(t *testing.T) {
+	s := newTestStore(t, &Config{MaxEntries: 10, SyncWrites: true})
+
+	var mu sync.Mutex
+	evicted := make(map[string]bool)
+	s.SetEvictCallback(func(key string, value any) {
+		mu.Lock()
+		defer mu.Unlock()
+		evicted[key] = true
+	})
+
+	for i := range 100 {
+		_ = s.Set(fmt.Sprintf("key-%d", i), entry.NewWithoutTTL(i))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(evicted) == 0 {
+		t.Error("Expected entries beyond capacity to be evicted")
+	}
+	if s.Len() > 10 {
+		t.Errorf("Expected at most 10 entries, got %d", s.Len())
+	}
+	for key := range evicted {
+		if _, found := s.Peek(key); found {
+			t.Errorf("Expected evicted key %s to leave the index", key)
+		}
+	}
+}
+
+func TestRistrettoStoreWeightedCapacity(t *testing.T) {
+	s := newTestStore(t, &Config{MaxEntries: 100, MaxWeight: 100, SyncWrites: true})
+
+	if s.cache.MaxCost() != 100 {
+		t.Errorf("Expected MaxWeight to set the max cost, got %d", s.cache.MaxCost())
+	}
+	_ = s.Set("small", entry.NewWithoutTTL("abc"))
+	_ = s.Set("large", entry.NewWithoutTTL(string(make([]byte, 200))))
+	if _, found := s.Get("large"); found {
+		t.Error("Expected an entry larger than MaxWeight to be rejected")
+	}
+	if _, found := s.Peek("large"); found {
+		t.Error("Expected a rejected entry to leave the index")
+	}
+	if _, found := s.Get("small"); !found {
+		t.Error("Expected the small entry to be admitted")
+	}
+}
+
+func TestRistrettoStoreCleanupCallback(t *testing.T) {
+	s := newTestStore(t, &Config{MaxEntries: 100})
+
+	var expired, evicted []string
+	s.SetCleanupCallback(func(key string, value any) { expired = append(expired, key) })
+	s.SetEvictCallback(func(key string, value any) { evicted = append(evicted, key) })
+
+	// Ristretto's cleanup runs on a coarse ticker, so hand it removals directly
+	s.onEvict(&ristretto.Item[*item]{Value: &item{key: "old", entry: expiredEntry(1)}})
+	s.onEvict(&ristretto.Item[*item]{Value: &item{key: "cold", entry: entry.NewWithoutTTL(2)}})
+
+	if len(expired) != 1 || expired[0] != "old" {
+		t.Errorf("Expected the expired entry to be reported as a cleanup, got %v", expired)
+	}
+	if len(evicted) != 1 || evicted[0] != "cold" {
+		t.Errorf("Expected the live entry to be reported as an eviction, got %v", evicted)
+	}
+}
+
+func TestRistrettoStoreClearDoesNotReportEvictions(t *testing.T) {
+	s := newTestStore(t, &Config{MaxEntries: 100, SyncWrites: true})
+
+	var evictions atomic.Int64
+	s.SetEvictCallback(func(key string, value any) { evictions.Add(1) })
+	for i := range 10 {
+		_ = s.Set(fmt.Sprintf("key-%d", i), entry.NewWithoutTTL(i))
+	}
+
+	if err := s.Clear(); err != nil {
+		t.Fatalf("Failed to clear store: %v", err)
+	}
+	if s.Len() != 0 || len(s.Keys()) != 0 {
+		t.Errorf("Expected an empty store after Clear, got %d entries", s.Len())
+	}
+	if evictions.Load() != 0 {
+		t.Errorf("Expected Clear not to report evictions, got %d", evictions.Load())
+	}
+}
+
+// BenchmarkReadThrough compares the Ristretto store with the sharded memory store
+// on a read-through workload: each miss is followed by a Set, and the key space
+// is sized so a perfect cache would hit at the target rate
+func BenchmarkReadThrough(b *testing.B) {
+	const capacity = 10000
+
+	stores := []struct {
+		name string
+		new  func() (store.Store, error)
+	}{
+		{"sharded", func() (store.Store, error) {
+			return memory.NewShardedWithStrategy(eviction.Config{Type: eviction.LRU, Capacity: capacity}, 0, 0)
+		}},
+		{"ristretto", func() (store.Store, error) {
+			return New(&Config{MaxEntries: capacity})
+		}},
+	}
+
+	for _, hitRate := range []int{50, 90, 99} {
+		keys := make([]string, capacity*100/hitRate)
+		for i := range keys {
+			keys[i] = fmt.Sprintf("key-%d", i)
+		}
+
+		for _, sc := range stores {
+			b.Run(fmt.Sprintf("%s/hit%d", sc.name, hitRate), func(b *testing.B) {
+				s, err := sc.new()
+				if err != nil {
+					b.Fatal(err)
+				}
+				defer func() { _ = s.Close() }()
+				for _, key := range keys[:capacity] {
+					_ = s.Set(key, entry.NewWithoutTTL(key)) // Benchmark setup
+				}
+				if r, ok := s.(*Store); ok {
+					r.cache.Wait()
+				}
+
+				b.ResetTimer()
+				b.ReportAllocs()
+
+				var hits, gets atomic.Int64
+				b.RunParallel(func(pb *testing.PB) {
+					rng := rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+					var localHits, localGets int64
+					for pb.Next() {
+						key := keys[rng.IntN(len(keys))]
+						localGets++
+						if _, found := s.Get(key); found {
+							localHits++
+							continue
+						}
+						_ = s.Set(key, entry.NewWithoutTTL(key))
+					}
+					hits.Add(localHits)
+					gets.Add(localGets)
+				})
+				b.ReportMetric(100*float64(hits.Load())/float64(max(gets.Load(), 1)), "hit%")
+			})
+		}
+	}
+}
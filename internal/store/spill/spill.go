@@ -0,0 +1,722 @@
+// Package spill keeps entries evicted from a memory store in files on local
+// disk, so a full cache degrades to disk reads instead of backend misses.
+package spill
+
+import (
+	"bytes"
+	"container/list"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/internal/snapshot"
+	"github.com/1mb-dev/obcache-go/v2/pkg/codec"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+	"github.com/1mb-dev/obcache-go/v2/pkg/store"
+)
+
+// DefaultMaxDiskBytes bounds the total size of the spill files
+const DefaultMaxDiskBytes = 256 << 20
+
+// DefaultQueueSize bounds the number of spilled entries waiting to be written
+const DefaultQueueSize = 1024
+
+// fileExt marks the files owned by the disk tier
+const fileExt = ".obspill"
+
+var (
+	// errTooLarge is returned for entries that cannot fit on disk at all
+	errTooLarge = errors.New("entry exceeds the disk tier size")
+
+	// errNotEncodable is returned for values the codec cannot encode
+	errNotEncodable = errors.New("entry value cannot be encoded")
+
+	// errQueueFull is reported for entries spilled while the write queue is full
+	errQueueFull = errors.New("spill queue is full")
+
+	// errSuperseded is returned for writes whose entry was restored, replaced or
+	// removed while it waited
+	errSuperseded = errors.New("spilled entry was superseded")
+)
+
+// Memory is the memory store the disk tier sits under
+type Memory interface {
+	store.LRUStore
+	store.TTLStore
+
+	// SetEvictEntryCallback receives entries displaced by capacity in place of
+	// the evict callback
+	SetEvictEntryCallback(callback func(key string, e *entry.Entry))
+}
+
+// Config holds configuration for the disk tier
+type Config struct {
+	// Memory is the store entries are spilled from
+	Memory Memory
+
+	// Dir holds the spill files. Leftover spill files in it are removed on startup
+	// Default: a temporary directory removed on Close
+	Dir string
+
+	// MaxDiskBytes bounds the total size of the spill files; the oldest spilled
+	// entries are dropped to make room
+	// Default: 256 MiB
+	MaxDiskBytes int64
+
+	// Codec encodes values on disk. Byte slices and compressed values are
+	// written as they are
+	// Default: codec.JSON
+	Codec codec.Codec
+
+	// QueueSize bounds the number of spilled entries waiting to be written. An
+	// entry spilled while the queue is full is evicted instead
+	// Default: 1024
+	QueueSize int
+
+	// CleanupInterval is how often expired entries are removed from both tiers
+	// Default: 0 (Cleanup runs only when called)
+	CleanupInterval time.Duration
+}
+
+// Store serves entries from a memory store and moves the entries it evicts for
+// capacity to files on disk. Get falls through to disk and moves hits back into
+// memory, which may in turn spill another entry. Files are written by a
+// background goroutine, so eviction never waits on disk; until its file is
+// written a spilled entry is served from the queue. Values on disk are encoded
+// with the configured codec, so with the default JSON codec they come back as
+// JSON types (e.g. numbers as float64). Spilled entries that cannot be read
+// back are dropped as if never spilled, and entries dropped from disk are
+// reported to the callbacks without their value
+type Store struct {
+	memory Memory
+	disk   *diskTier
+
+	// mu serializes writes with moves between the tiers, so a key is never
+	// visible in both or in neither while it moves
+	mu sync.Mutex
+
+	callbackMu      sync.RWMutex
+	evictCallback   store.EvictCallback
+	cleanupCallback store.EvictCallback
+	spillFunc       func()
+	restoreFunc     func()
+
+	stopCleanup chan struct{}
+	cleanupDone chan struct{}
+	writerDone  chan struct{}
+	closeOnce   sync.Once
+}
+
+// New creates a disk tier under config.Memory
+func New(config *Config) (*Store, error) {
+	if config.Memory == nil {
+		return nil, fmt.Errorf("spill store requires a memory store")
+	}
+
+	maxBytes := config.MaxDiskBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxDiskBytes
+	}
+
+	queueSize := config.QueueSize
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+
+	disk, err := openDisk(config.Dir, maxBytes, codec.OrDefault(config.Codec), queueSize)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Store{
+		memory:      config.Memory,
+		disk:        disk,
+		stopCleanup: make(chan struct{}),
+		cleanupDone: make(chan struct{}),
+		writerDone:  make(chan struct{}),
+	}
+	s.memory.SetEvictEntryCallback(s.spill)
+	go s.writeLoop()
+
+	if config.CleanupInterval > 0 {
+		go s.cleanupLoop(config.CleanupInterval)
+	} else {
+		close(s.cleanupDone)
+	}
+
+	return s, nil
+}
+
+// spill queues an entry evicted from memory to be written to disk, reporting
+// it as evicted if the queue is full. It runs under the memory store's lock, so
+// it never touches the disk itself
+func (s *Store) spill(key string, e *entry.Entry) {
+	if !s.disk.enqueue(key, e) {
+		s.reportSpill(key, e, nil, errQueueFull)
+	}
+}
+
+// writeLoop writes queued entries to disk until Close
+func (s *Store) writeLoop() {
+	defer close(s.writerDone)
+
+	for {
+		select {
+		case w := <-s.disk.queue:
+			dropped, err := s.disk.write(w)
+			if !errors.Is(err, errSuperseded) {
+				s.reportSpill(w.key, w.entry, dropped, err)
+			}
+			s.disk.inflight.Done()
+		case <-s.disk.stop:
+			// Close removes the files anyway, so what is left is dropped unwritten
+			for {
+				select {
+				case <-s.disk.queue:
+					s.disk.inflight.Done()
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// Flush waits until the entries spilled so far have been written to disk or
+// dropped
+func (s *Store) Flush() {
+	s.disk.inflight.Wait()
+}
+
+// reportSpill reports the outcome of writing key to disk: an eviction if it
+// could not be written, and the entries dropped to make room if it was
+func (s *Store) reportSpill(key string, e *entry.Entry, dropped []string, err error) {
+	s.callbackMu.RLock()
+	evict, onSpill := s.evictCallback, s.spillFunc
+	s.callbackMu.RUnlock()
+
+	if evict != nil {
+		for _, droppedKey := range dropped {
+			evict(droppedKey, nil)
+		}
+	}
+	if err != nil {
+		if evict != nil {
+			evict(key, e.Value)
+		}
+		return
+	}
+	if onSpill != nil {
+		onSpill()
+	}
+}
+
+// Get retrieves an entry from memory, or from disk and moves it back into memory
+func (s *Store) Get(key string) (*entry.Entry, bool) {
+	if e, ok := s.memory.Get(key); ok {
+		return e, true
+	}
+	return s.restore(key)
+}
+
+// restore moves key from disk back into memory
+func (s *Store) restore(key string) (*entry.Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Another Get may have restored it while this one waited
+	if e, ok := s.memory.Get(key); ok {
+		return e, true
+	}
+
+	e, ok := s.disk.take(key)
+	if !ok {
+		return nil, false
+	}
+	if e.IsExpired() {
+		s.reportExpired(key)
+		return nil, false
+	}
+
+	if err := s.memory.Set(key, e); err != nil {
+		// Put it back rather than lose it, e.g. when memory is full of pinned entries
+		s.spill(key, e)
+		return nil, false
+	}
+
+	s.callbackMu.RLock()
+	onRestore := s.restoreFunc
+	s.callbackMu.RUnlock()
+	if onRestore != nil {
+		onRestore()
+	}
+	return e, true
+}
+
+// Peek retrieves an entry from either tier without moving it
+func (s *Store) Peek(key string) (*entry.Entry, bool) {
+	if e, ok := s.memory.Peek(key); ok {
+		return e, true
+	}
+	e, ok := s.disk.read(key)
+	if !ok || e.IsExpired() {
+		return nil, false
+	}
+	return e, true
+}
+
+// Set stores an entry in memory, replacing any spilled copy
+func (s *Store) Set(key string, e *entry.Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.disk.remove(key)
+	return s.memory.Set(key, e)
+}
+
+// Delete removes an entry from both tiers
+func (s *Store) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.disk.remove(key)
+	return s.memory.Delete(key)
+}
+
+// Keys returns the keys in both tiers
+func (s *Store) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append(s.memory.Keys(), s.disk.keys()...)
+}
+
+// Len returns the number of entries in both tiers
+func (s *Store) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.memory.Len() + s.disk.len()
+}
+
+// Clear removes all entries from both tiers
+func (s *Store) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.disk.clear()
+	return s.memory.Clear()
+}
+
+// Close stops the cleanup goroutine, closes the memory store and removes the
+// spill files
+func (s *Store) Close() error {
+	var errs []error
+	s.closeOnce.Do(func() {
+		close(s.stopCleanup)
+		<-s.cleanupDone
+
+		errs = append(errs, s.memory.Close())
+		close(s.disk.stop)
+		<-s.writerDone
+		errs = append(errs, s.disk.close())
+	})
+	return errors.Join(errs...)
+}
+
+// SetEvictCallback sets the callback for entries evicted from memory that could
+// not be spilled and for spilled entries dropped to make room on disk
+func (s *Store) SetEvictCallback(callback store.EvictCallback) {
+	s.callbackMu.Lock()
+	defer s.callbackMu.Unlock()
+	s.evictCallback = callback
+}
+
+// SetCleanupCallback sets the callback for expired entries removed from either tier
+func (s *Store) SetCleanupCallback(callback store.EvictCallback) {
+	s.callbackMu.Lock()
+	s.cleanupCallback = callback
+	s.callbackMu.Unlock()
+
+	s.memory.SetCleanupCallback(callback)
+}
+
+// SetSpillCallbacks sets the callbacks for entries moved to disk and back
+func (s *Store) SetSpillCallbacks(onSpill, onRestore func()) {
+	s.callbackMu.Lock()
+	defer s.callbackMu.Unlock()
+	s.spillFunc, s.restoreFunc = onSpill, onRestore
+}
+
+// Capacity returns the number of entries the memory tier can hold
+func (s *Store) Capacity() int {
+	return s.memory.Capacity()
+}
+
+// Cleanup removes expired entries from both tiers and returns the number removed
+func (s *Store) Cleanup() int {
+	removed := s.memory.Cleanup()
+	for _, key := range s.disk.removeExpired(time.Now()) {
+		s.reportExpired(key)
+		removed++
+	}
+	return removed
+}
+
+// reportExpired reports an expired entry dropped from disk
+func (s *Store) reportExpired(key string) {
+	s.callbackMu.RLock()
+	callback := s.cleanupCallback
+	s.callbackMu.RUnlock()
+	if callback != nil {
+		callback(key, nil)
+	}
+}
+
+// UpdateTTL changes the expiration of an entry, moving it back into memory
+// first if it was spilled
+func (s *Store) UpdateTTL(key string, ttl time.Duration) bool {
+	if s.memory.UpdateTTL(key, ttl) {
+		return true
+	}
+	if _, ok := s.restore(key); !ok {
+		return false
+	}
+	return s.memory.UpdateTTL(key, ttl)
+}
+
+// cleanupLoop runs Cleanup every interval until Close
+func (s *Store) cleanupLoop(interval time.Duration) {
+	defer close(s.cleanupDone)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.Cleanup()
+		case <-s.stopCleanup:
+			return
+		}
+	}
+}
+
+// diskFile is a spilled entry; files are kept in spill order, so the oldest
+// spill is dropped first. A hit moves the entry back into memory, which makes
+// the order least recently used as well
+type diskFile struct {
+	key       string
+	name      string
+	size      int64
+	expiresAt *time.Time
+}
+
+// pendingWrite is a spilled entry waiting in the queue for its file
+type pendingWrite struct {
+	key   string
+	entry *entry.Entry
+}
+
+// diskTier indexes the spill files in a directory
+type diskTier struct {
+	dir      string
+	ownsDir  bool
+	maxBytes int64
+	codec    codec.Codec
+	seq      atomic.Uint64
+
+	// queue feeds the writer; inflight counts the writes queued or in progress
+	queue    chan *pendingWrite
+	inflight sync.WaitGroup
+	stop     chan struct{}
+
+	mu      sync.Mutex
+	pending map[string]*pendingWrite
+	files   map[string]*list.Element
+	order   *list.List
+	used    int64
+}
+
+// openDisk prepares dir for spill files, creating a temporary directory if dir is empty
+func openDisk(dir string, maxBytes int64, c codec.Codec, queueSize int) (*diskTier, error) {
+	d := &diskTier{
+		dir:      dir,
+		maxBytes: maxBytes,
+		codec:    c,
+		queue:    make(chan *pendingWrite, queueSize),
+		stop:     make(chan struct{}),
+		pending:  make(map[string]*pendingWrite),
+		files:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+
+	if dir == "" {
+		tempDir, err := os.MkdirTemp("", "obcache-spill-")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create spill directory: %w", err)
+		}
+		d.dir, d.ownsDir = tempDir, true
+		return d, nil
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create spill directory: %w", err)
+	}
+	// The index does not survive a restart, so files left by a previous run are orphans
+	if err := d.removeFiles(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// enqueue replaces any spilled copy of key with e and queues e to be written
+// Returns false, dropping e, if the queue is full or the tier is closed
+func (d *diskTier) enqueue(key string, e *entry.Entry) bool {
+	w := &pendingWrite{key: key, entry: e}
+
+	d.mu.Lock()
+	d.removeLocked(key)
+	d.pending[key] = w
+	d.mu.Unlock()
+
+	d.inflight.Add(1)
+	select {
+	case <-d.stop:
+	default:
+		select {
+		case d.queue <- w:
+			return true
+		default:
+		}
+	}
+	d.inflight.Done()
+
+	d.mu.Lock()
+	d.dropPendingLocked(w)
+	d.mu.Unlock()
+	return false
+}
+
+// write encodes a queued entry to a new file and returns the keys dropped to
+// make room. The file is written without holding the lock; if the entry left
+// the queue meanwhile the file is discarded and errSuperseded returned
+func (d *diskTier) write(w *pendingWrite) ([]string, error) {
+	var buf bytes.Buffer
+	n, err := snapshot.WriteCodec(&buf, []snapshot.Record{{Key: w.key, Entry: w.entry}}, d.codec)
+	if err == nil && n == 0 {
+		err = errNotEncodable
+	}
+	size := int64(buf.Len())
+	if err == nil && size > d.maxBytes {
+		err = errTooLarge
+	}
+
+	var name string
+	if err == nil {
+		name = fmt.Sprintf("%016x%s", d.seq.Add(1), fileExt)
+		if writeErr := os.WriteFile(filepath.Join(d.dir, name), buf.Bytes(), 0o600); writeErr != nil {
+			err = fmt.Errorf("failed to write spill file: %w", writeErr)
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.pending[w.key] != w {
+		if err == nil {
+			_ = os.Remove(filepath.Join(d.dir, name))
+		}
+		return nil, errSuperseded
+	}
+	delete(d.pending, w.key)
+	if err != nil {
+		return nil, err
+	}
+
+	d.files[w.key] = d.order.PushBack(&diskFile{key: w.key, name: name, size: size, expiresAt: w.entry.Expiry()})
+	d.used += size
+
+	var dropped []string
+	for d.used > d.maxBytes {
+		oldest := d.order.Front().Value.(*diskFile)
+		d.removeLocked(oldest.key)
+		dropped = append(dropped, oldest.key)
+	}
+	return dropped, nil
+}
+
+// read decodes the spilled entry for key, dropping its file if it is corrupt
+func (d *diskTier) read(key string) (*entry.Entry, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if w, ok := d.pending[key]; ok {
+		return w.entry, true
+	}
+	elem, ok := d.files[key]
+	if !ok {
+		return nil, false
+	}
+	e, err := d.decode(elem.Value.(*diskFile))
+	if err != nil {
+		d.removeLocked(key)
+		return nil, false
+	}
+	return e, true
+}
+
+// take decodes the spilled entry for key and removes its file
+func (d *diskTier) take(key string) (*entry.Entry, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if w, ok := d.pending[key]; ok {
+		delete(d.pending, key)
+		return w.entry, true
+	}
+	elem, ok := d.files[key]
+	if !ok {
+		return nil, false
+	}
+	e, err := d.decode(elem.Value.(*diskFile))
+	d.removeLocked(key)
+	return e, err == nil
+}
+
+// decode reads the entry in file (assumes lock is held)
+func (d *diskTier) decode(file *diskFile) (*entry.Entry, error) {
+	data, err := os.ReadFile(filepath.Join(d.dir, file.name))
+	if err != nil {
+		return nil, err
+	}
+	records, err := snapshot.ReadCodec(bytes.NewReader(data), d.codec)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) != 1 || records[0].Key != file.key {
+		return nil, snapshot.ErrCorrupt
+	}
+	return records[0].Entry, nil
+}
+
+// remove drops the spilled entry for key, if any
+func (d *diskTier) remove(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.removeLocked(key)
+}
+
+// removeLocked drops the spilled entry for key (assumes lock is held)
+func (d *diskTier) removeLocked(key string) {
+	delete(d.pending, key)
+	elem, ok := d.files[key]
+	if !ok {
+		return
+	}
+	file := elem.Value.(*diskFile)
+	d.order.Remove(elem)
+	delete(d.files, key)
+	d.used -= file.size
+	_ = os.Remove(filepath.Join(d.dir, file.name)) // A leftover file is only wasted space
+}
+
+// dropPendingLocked forgets w unless a later spill of its key replaced it
+// (assumes lock is held)
+func (d *diskTier) dropPendingLocked(w *pendingWrite) {
+	if d.pending[w.key] == w {
+		delete(d.pending, w.key)
+	}
+}
+
+// removeExpired drops the entries that expired before now and returns their keys
+func (d *diskTier) removeExpired(now time.Time) []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var expired []string
+	for key, w := range d.pending {
+		if expiresAt := w.entry.Expiry(); expiresAt != nil && now.After(*expiresAt) {
+			expired = append(expired, key)
+		}
+	}
+	for key, elem := range d.files {
+		if expiresAt := elem.Value.(*diskFile).expiresAt; expiresAt != nil && now.After(*expiresAt) {
+			expired = append(expired, key)
+		}
+	}
+	for _, key := range expired {
+		d.removeLocked(key)
+	}
+	return expired
+}
+
+// keys returns the spilled keys
+func (d *diskTier) keys() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	keys := make([]string, 0, len(d.pending)+len(d.files))
+	for key := range d.pending {
+		keys = append(keys, key)
+	}
+	for key := range d.files {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// len returns the number of spilled entries
+func (d *diskTier) len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.pending) + len(d.files)
+}
+
+// clear drops every spilled entry
+func (d *diskTier) clear() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	clear(d.pending)
+	for key := range d.files {
+		d.removeLocked(key)
+	}
+}
+
+// close drops every spilled entry and removes the directory if it was created by openDisk
+func (d *diskTier) close() error {
+	d.clear()
+	if d.ownsDir {
+		return os.RemoveAll(d.dir)
+	}
+	return nil
+}
+
+// removeFiles deletes the spill files in the directory
+func (d *diskTier) removeFiles() error {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read spill directory: %w", err)
+	}
+	for _, dirEntry := range entries {
+		if dirEntry.Type().IsRegular() && strings.HasSuffix(dirEntry.Name(), fileExt) {
+			if err := os.Remove(filepath.Join(d.dir, dirEntry.Name())); err != nil {
+				return fmt.Errorf("failed to remove stale spill file: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// Ensure Store implements the required interfaces
+var (
+	_ store.Store      = (*Store)(nil)
+	_ store.LRUStore   = (*Store)(nil)
+	_ store.TTLStore   = (*Store)(nil)
+	_ store.SpillStore = (*Store)(nil)
+)
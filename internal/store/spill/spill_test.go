@@ -0,0 +1,305 @@
+package spill
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/internal/eviction"
+	"github.com/1mb-dev/obcache-go/v2/internal/store/memory"
+	"github.com/1mb-dev/obcache-go/v2/pkg/codec"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+)
+
+func newTestStore(t *testing.T, capacity int, config Config) *Store {
+	t.Helper()
+	mem, err := memory.NewWithStrategy(eviction.Config{Type: eviction.LRU, Capacity: capacity})
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	config.Memory = mem
+	s, err := New(&config)
+	if err != nil {
+		t.Fatalf("Failed to create spill store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+// spillFiles returns the names of the spill files in dir
+func spillFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(dir, "*"+fileExt))
+	if err != nil {
+		t.Fatalf("Glob failed: %v", err)
+	}
+	return matches
+}
+
+func TestSpillStoreSpillsAndRestores(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestStore(t, 2, Config{Dir: dir})
+
+	var spills, restores int
+	s.SetSpillCallbacks(func() { spills++ }, func() { restores++ })
+	var evicted []string
+	s.SetEvictCallback(func(key string, _ any) { evicted = append(evicted, key) })
+
+	_ = s.Set("a", entry.New("alpha", time.Hour))
+	_ = s.Set("b", entry.New([]byte("beta"), time.Hour))
+	_ = s.Set("c", entry.New("gamma", time.Hour))
+	s.Flush()
+
+	if spills != 1 || len(evicted) != 0 {
+		t.Fatalf("Expected a to spill instead of being evicted, got %d spills and evictions %v", spills, evicted)
+	}
+	if files := spillFiles(t, dir); len(files) != 1 {
+		t.Errorf("Expected one spill file, got %v", files)
+	}
+	if s.Len() != 3 {
+		t.Errorf("Expected 3 entries across both tiers, got %d", s.Len())
+	}
+
+	if e, found := s.Peek("a"); !found || e.Value != "alpha" {
+		t.Errorf("Expected Peek to read the spilled entry, got %v (found=%v)", e, found)
+	}
+	if restores != 0 {
+		t.Error("Expected Peek to leave the entry on disk")
+	}
+
+	// Restoring a pushes the least recently used entry, b, down to disk
+	if e, found := s.Get("a"); !found || e.Value != "alpha" {
+		t.Fatalf("Expected a restored from disk, got %v (found=%v)", e, found)
+	}
+	s.Flush()
+	if restores != 1 || spills != 2 {
+		t.Errorf("Expected 1 restore and 2 spills, got %d and %d", restores, spills)
+	}
+	if e, found := s.Get("b"); !found || !slices.Equal(e.Value.([]byte), []byte("beta")) {
+		t.Errorf("Expected byte values to survive a spill, got %v (found=%v)", e, found)
+	}
+
+	keys := s.Keys()
+	slices.Sort(keys)
+	if !slices.Equal(keys, []string{"a", "b", "c"}) {
+		t.Errorf("Expected keys from both tiers, got %v", keys)
+	}
+}
+
+func TestSpillStoreKeepsTypesWithCodec(t *testing.T) {
+	s := newTestStore(t, 1, Config{Codec: codec.Gob{}})
+
+	_ = s.Set("int", entry.New(42, time.Hour))
+	_ = s.Set("bytes", entry.New([]byte("raw"), time.Hour))
+	_ = s.Set("last", entry.New("x", time.Hour))
+	s.Flush()
+
+	// Both are read back from their files
+	if e, found := s.Get("int"); !found || e.Value != 42 {
+		t.Errorf("Expected the int to survive a spill, got %#v (found=%v)", e, found)
+	}
+	s.Flush()
+	if e, found := s.Get("bytes"); !found || !slices.Equal(e.Value.([]byte), []byte("raw")) {
+		t.Errorf("Expected byte values to stay raw, got %#v (found=%v)", e, found)
+	}
+
+	// JSON, the default, turns numbers into float64
+	j := newTestStore(t, 1, Config{})
+	_ = j.Set("int", entry.New(42, time.Hour))
+	_ = j.Set("last", entry.New("x", time.Hour))
+	j.Flush()
+	if e, found := j.Get("int"); !found || e.Value != float64(42) {
+		t.Errorf("Expected a JSON number, got %#v (found=%v)", e, found)
+	}
+}
+
+func TestDiskTierServesQueuedEntries(t *testing.T) {
+	// A tier without a writer keeps entries queued
+	d, err := openDisk(t.TempDir(), DefaultMaxDiskBytes, codec.JSON{}, 1)
+	if err != nil {
+		t.Fatalf("openDisk failed: %v", err)
+	}
+	t.Cleanup(func() { _ = d.close() })
+
+	if !d.enqueue("a", entry.New("alpha", time.Hour)) {
+		t.Fatal("Expected the entry to be queued")
+	}
+	if d.enqueue("b", entry.New("beta", time.Hour)) {
+		t.Error("Expected a full queue to refuse the entry")
+	}
+	if e, found := d.read("a"); !found || e.Value != "alpha" || d.len() != 1 {
+		t.Errorf("Expected a queued entry to be readable, got %v (found=%v)", e, found)
+	}
+
+	// A write whose entry was removed while queued leaves no file behind
+	d.remove("a")
+	if _, err := d.write(<-d.queue); !errors.Is(err, errSuperseded) {
+		t.Errorf("Expected the write to be superseded, got %v", err)
+	}
+	d.inflight.Done()
+	if files := spillFiles(t, d.dir); len(files) != 0 || d.len() != 0 {
+		t.Errorf("Expected the superseded file to be removed, got %v", files)
+	}
+}
+
+func TestSpillStoreDeleteAndClearPurgeBothTiers(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestStore(t, 1, Config{Dir: dir})
+
+	_ = s.Set("a", entry.New("alpha", time.Hour))
+	_ = s.Set("b", entry.New("beta", time.Hour))
+	s.Flush()
+	if err := s.Delete("a"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, found := s.Get("a"); found {
+		t.Error("Expected a deleted spilled entry to be gone")
+	}
+	if files := spillFiles(t, dir); len(files) != 0 {
+		t.Errorf("Expected Delete to remove the spill file, got %v", files)
+	}
+
+	_ = s.Set("c", entry.New("gamma", time.Hour))
+	if err := s.Clear(); err != nil {
+		t.Fatalf("Clear failed: %v", err)
+	}
+	if s.Len() != 0 || len(spillFiles(t, dir)) != 0 {
+		t.Errorf("Expected Clear to empty both tiers, got %d entries", s.Len())
+	}
+
+	// A Set replaces the spilled copy
+	_ = s.Set("a", entry.New("old", time.Hour))
+	_ = s.Set("b", entry.New("beta", time.Hour))
+	_ = s.Set("a", entry.New("new", time.Hour))
+	if e, found := s.Get("a"); !found || e.Value != "new" {
+		t.Errorf("Expected the latest value, got %v (found=%v)", e, found)
+	}
+}
+
+func TestSpillStoreRotatesOldestWhenDiskIsFull(t *testing.T) {
+	s := newTestStore(t, 1, Config{MaxDiskBytes: 1024})
+
+	var evicted []string
+	s.SetEvictCallback(func(key string, value any) {
+		if value != nil {
+			t.Errorf("Expected no value for an entry dropped from disk, got %v", value)
+		}
+		evicted = append(evicted, key)
+	})
+
+	value := strings.Repeat("x", 300)
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		_ = s.Set(key, entry.New(value, time.Hour))
+	}
+	s.Flush()
+
+	if len(evicted) == 0 || evicted[0] != "a" {
+		t.Fatalf("Expected the oldest spill to be dropped first, got %v", evicted)
+	}
+	if _, found := s.Get("a"); found {
+		t.Error("Expected a dropped entry to be a miss")
+	}
+	if _, found := s.Get("d"); !found {
+		t.Error("Expected the newest spill to be kept")
+	}
+
+	// Entries larger than the whole disk tier are evicted right away
+	s2 := newTestStore(t, 1, Config{MaxDiskBytes: 64})
+	var lost []any
+	s2.SetEvictCallback(func(_ string, value any) { lost = append(lost, value) })
+	_ = s2.Set("big", entry.New(value, time.Hour))
+	_ = s2.Set("next", entry.New("x", time.Hour))
+	s2.Flush()
+	if len(lost) != 1 || lost[0] != value {
+		t.Errorf("Expected the oversized entry evicted with its value, got %d evictions", len(lost))
+	}
+}
+
+func TestSpillStoreSkipsExpiredAndCorruptEntries(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestStore(t, 1, Config{Dir: dir})
+
+	var cleaned []string
+	s.SetCleanupCallback(func(key string, _ any) { cleaned = append(cleaned, key) })
+
+	_ = s.Set("short", entry.New("value", 20*time.Millisecond))
+	_ = s.Set("corrupt", entry.New("value", time.Hour))
+	_ = s.Set("stale", entry.New("value", 20*time.Millisecond))
+	_ = s.Set("live", entry.New("value", time.Hour))
+	s.Flush()
+
+	// Damage the file holding corrupt
+	s.disk.mu.Lock()
+	corruptFile := filepath.Join(dir, s.disk.files["corrupt"].Value.(*diskFile).name)
+	s.disk.mu.Unlock()
+	if err := os.WriteFile(corruptFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatalf("Failed to damage spill file: %v", err)
+	}
+
+	if _, found := s.Get("corrupt"); found {
+		t.Error("Expected a corrupt spill file to be a miss")
+	}
+	if s.Len() != 3 {
+		t.Errorf("Expected the corrupt entry to be dropped, got %d entries", s.Len())
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if _, found := s.Get("short"); found {
+		t.Error("Expected an expired spilled entry to be a miss")
+	}
+	if removed := s.Cleanup(); removed != 1 {
+		t.Errorf("Expected Cleanup to remove stale from disk, got %d", removed)
+	}
+	slices.Sort(cleaned)
+	if !slices.Equal(cleaned, []string{"short", "stale"}) {
+		t.Errorf("Expected cleanup callbacks for short and stale, got %v", cleaned)
+	}
+	if files := spillFiles(t, dir); len(files) != 0 {
+		t.Errorf("Expected no spill files left, got %v", files)
+	}
+}
+
+func TestSpillStoreDirectoryLifecycle(t *testing.T) {
+	dir := t.TempDir()
+	stale := filepath.Join(dir, "0000000000000001"+fileExt)
+	if err := os.WriteFile(stale, []byte("left over"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	other := filepath.Join(dir, "keep.txt")
+	if err := os.WriteFile(other, []byte("unrelated"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestStore(t, 1, Config{Dir: dir})
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Error("Expected spill files from a previous run to be removed")
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Error("Expected unrelated files to be left alone")
+	}
+
+	// A temporary directory is created when none is given and removed on Close
+	temp := newTestStore(t, 1, Config{})
+	tempDir := temp.disk.dir
+	_ = temp.Set("a", entry.New("alpha", time.Hour))
+	_ = temp.Set("b", entry.New("beta", time.Hour))
+	if err := temp.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := os.Stat(tempDir); !os.IsNotExist(err) {
+		t.Error("Expected the temporary spill directory to be removed on Close")
+	}
+
+	_ = s.Set("a", entry.New("alpha", time.Hour))
+	_ = s.Set("b", entry.New("beta", time.Hour))
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if files := spillFiles(t, dir); len(files) != 0 {
+		t.Errorf("Expected Close to remove spill files, got %v", files)
+	}
+}
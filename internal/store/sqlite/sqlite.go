@@ -0,0 +1,600 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/1mb-dev/obcache-go/v2/internal/envelope"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+	"github.com/1mb-dev/obcache-go/v2/pkg/store"
+)
+
+const (
+	// DefaultDriverName is the database/sql driver used when Config.DriverName is empty
+	DefaultDriverName = "sqlite3"
+
+	// DefaultCleanupBatchSize is the number of expired rows deleted per transaction
+	DefaultCleanupBatchSize = 500
+)
+
+// schema creates the entries table; expires_at and created_at are Unix milliseconds
+// and raw marks values stored as bytes rather than JSON. meta holds the entry's
+// envelope without its value, for the codec and compression metadata; rows
+// written before it was added have none
+const schema = `
+CREATE TABLE IF NOT EXISTS obcache_entries (
+	key        TEXT PRIMARY KEY,
+	value      BLOB NOT NULL,
+	expires_at INTEGER,
+	created_at INTEGER NOT NULL,
+	compressed INTEGER NOT NULL DEFAULT 0,
+	raw        INTEGER NOT NULL DEFAULT 0,
+	version    INTEGER NOT NULL DEFAULT 0,
+	meta       TEXT
+);
+CREATE INDEX IF NOT EXISTS obcache_entries_expires_at ON obcache_entries (expires_at);
+`
+
+// liveCondition matches rows that have not expired; its only parameter is the current time
+const liveCondition = `(expires_at IS NULL OR expires_at > ?)`
+
+// Store implements a cache store in a SQLite database through database/sql
+// SQLite allows a single writer at a time, so writes are serialized by the
+// store's lock while reads run concurrently
+type Store struct {
+	db               *sql.DB
+	getStmt          *sql.Stmt
+	setStmt          *sql.Stmt
+	deleteStmt       *sql.Stmt
+	cleanupBatchSize int
+	cleanupCallback  store.EvictCallback
+	mu               sync.RWMutex
+	cleanupTicker    *time.Ticker
+	stopCleanup      chan struct{}
+}
+
+// Config holds SQLite store configuration
+type Config struct {
+	// DriverName is the registered database/sql driver name
+	// The application must import the driver, e.g. github.com/mattn/go-sqlite3
+	// Default: "sqlite3"
+	DriverName string
+
+	// DSN is the data source name passed to sql.Open, usually a file path
+	DSN string
+
+	// CleanupInterval sets how often expired rows are deleted
+	// Default: 0 (no automatic cleanup; expired rows are still removed on read)
+	CleanupInterval time.Duration
+
+	// CleanupBatchSize is the number of expired rows deleted per transaction
+	// Default: 500
+	CleanupBatchSize int
+}
+
+// New opens the database, creates the schema if needed and prepares statements
+func New(config *Config) (*Store, error) {
+	if config.DSN == "" {
+		return nil, fmt.Errorf("sqlite DSN is required")
+	}
+
+	driverName := config.DriverName
+	if driverName == "" {
+		driverName = DefaultDriverName
+	}
+
+	batchSize := config.CleanupBatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultCleanupBatchSize
+	}
+
+	db, err := sql.Open(driverName, config.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+	if _, err := db.Exec(schema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to create sqlite schema: %w", err)
+	}
+	if err := addMetaColumn(db); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to migrate sqlite schema: %w", err)
+	}
+
+	s := &Store{
+		db:               db,
+		cleanupBatchSize: batchSize,
+		stopCleanup:      make(chan struct{}),
+	}
+
+	statements := []struct {
+		stmt  **sql.Stmt
+		query string
+	}{
+		{&s.getStmt, `SELECT value, expires_at, created_at, compressed, raw, version, meta FROM obcache_entries WHERE key = ?`},
+		{&s.setStmt, `INSERT OR REPLACE INTO obcache_entries (key, value, expires_at, created_at, compressed, raw, version, meta) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`},
+		{&s.deleteStmt, `DELETE FROM obcache_entries WHERE key = ?`},
+	}
+	for _, st := range statements {
+		if *st.stmt, err = db.Prepare(st.query); err != nil {
+			_ = s.closeStatements()
+			_ = db.Close()
+			return nil, fmt.Errorf("failed to prepare sqlite statement: %w", err)
+		}
+	}
+
+	if config.CleanupInterval > 0 {
+		s.startCleanup(config.CleanupInterval)
+	}
+
+	return s, nil
+}
+
+// Get retrieves an entry by key
+// Expired rows are deleted on read, so they are never returned even if Cleanup has not run
+func (s *Store) Get(key string) (*entry.Entry, bool) {
+	s.mu.RLock()
+	e, found := s.load(key)
+	s.mu.RUnlock()
+	if !found {
+		return nil, false
+	}
+
+	if e.IsExpired() {
+		s.expire(key, e)
+		return nil, false
+	}
+
+	e.Touch()
+	return e, true
+}
+
+// Peek retrieves an entry without side effects
+func (s *Store) Peek(key string) (*entry.Entry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	e, found := s.load(key)
+	if !found || e.IsExpired() {
+		return nil, false
+	}
+	return e, true
+}
+
+// load reads the row for key and converts it to an entry
+func (s *Store) load(key string) (*entry.Entry, bool) {
+	var (
+		data       []byte
+		expiresAt  sql.NullInt64
+		createdAt  int64
+		compressed bool
+		raw        bool
+		version    int64
+		meta       sql.NullString
+	)
+	err := s.getStmt.QueryRow(key).Scan(&data, &expiresAt, &createdAt, &compressed, &raw, &version, &meta)
+	if err != nil {
+		// sql.ErrNoRows and database errors are treated as a miss
+		return nil, false
+	}
+
+	if meta.Valid {
+		e, err := decodeEnvelope(meta.String, data, raw)
+		if err != nil {
+			return nil, false
+		}
+		// The expires_at column is authoritative, as UpdateTTL only changes it
+		e.ExpiresAt = nil
+		if expiresAt.Valid {
+			expiry := time.UnixMilli(expiresAt.Int64)
+			e.ExpiresAt = &expiry
+		}
+		return e, true
+	}
+
+	value, err := decodeValue(data, raw)
+	if err != nil {
+		return nil, false
+	}
+
+	created := time.UnixMilli(createdAt)
+	e := &entry.Entry{
+		Value:        value,
+		CreatedAt:    created,
+		AccessedAt:   created,
+		Version:      version,
+		IsCompressed: compressed,
+	}
+	if expiresAt.Valid {
+		expiry := time.UnixMilli(expiresAt.Int64)
+		e.ExpiresAt = &expiry
+	}
+	if compressed {
+		e.CompressedSize = len(data)
+	}
+	return e, true
+}
+
+// expire deletes an expired row read by Get and reports it through the cleanup callback
+// The delete is conditional so a concurrent Set of a fresh value is left alone
+func (s *Store) expire(key string, e *entry.Entry) {
+	s.mu.Lock()
+	result, err := s.db.Exec(`DELETE FROM obcache_entries WHERE key = ? AND NOT `+liveCondition, key, nowMillis())
+	callback := s.cleanupCallback
+	s.mu.Unlock()
+
+	if err != nil || callback == nil {
+		return
+	}
+	if n, err := result.RowsAffected(); err == nil && n > 0 {
+		callback(key, e.Value)
+	}
+}
+
+// Set stores an entry with the given key, replacing any existing row
+func (s *Store) Set(key string, e *entry.Entry) error {
+	data, raw, meta, err := encodeEnvelope(e)
+	if err != nil {
+		return err
+	}
+
+	var expiresAt sql.NullInt64
+	if e.ExpiresAt != nil {
+		expiresAt = sql.NullInt64{Int64: e.ExpiresAt.UnixMilli(), Valid: true}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.setStmt.Exec(key, data, expiresAt, e.CreatedAt.UnixMilli(), e.IsCompressed, raw, e.Version, meta)
+	return err
+}
+
+// Delete removes an entry by key
+func (s *Store) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.deleteStmt.Exec(key)
+	return err
+}
+
+// Keys returns all non-expired keys in lexical order
+func (s *Store) Keys() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys, err := s.queryKeys(`SELECT key FROM obcache_entries WHERE `+liveCondition+` ORDER BY key`, nowMillis())
+	if err != nil {
+		return []string{}
+	}
+	return keys
+}
+
+// Scan returns up to limit non-expired keys with the given prefix in lexical order
+// The cursor is the last key of the previous page
+func (s *Store) Scan(prefix string, cursor string, limit int) ([]string, string, error) {
+	if limit <= 0 {
+		return nil, "", fmt.Errorf("scan limit must be positive, got %d", limit)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Fetch one extra key to learn whether another page follows
+	keys, err := s.queryKeys(`SELECT key FROM obcache_entries
+		WHERE substr(key, 1, ?) = ? AND key > ? AND `+liveCondition+`
+		ORDER BY key LIMIT ?`,
+		utf8.RuneCountInString(prefix), prefix, cursor, nowMillis(), limit+1)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(keys) > limit {
+		keys = keys[:limit]
+		return keys, keys[limit-1], nil
+	}
+	return keys, "", nil
+}
+
+// CountPrefix counts non-expired entries with the given prefix and sums their stored sizes
+func (s *Store) CountPrefix(prefix string) (int, int64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var count int
+	var size int64
+	err := s.db.QueryRow(`SELECT COUNT(*), COALESCE(SUM(length(value)), 0) FROM obcache_entries
+		WHERE substr(key, 1, ?) = ? AND `+liveCondition,
+		utf8.RuneCountInString(prefix), prefix, nowMillis()).Scan(&count, &size)
+	if err != nil {
+		return 0, 0
+	}
+	return count, size
+}
+
+// queryKeys runs a query returning a single key column
+func (s *Store) queryKeys(query string, args ...any) ([]string, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// Len returns the number of non-expired entries
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var count int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM obcache_entries WHERE `+liveCondition, nowMillis()).Scan(&count); err != nil {
+		return 0
+	}
+	return count
+}
+
+// Clear removes all entries from the store
+func (s *Store) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`DELETE FROM obcache_entries`)
+	return err
+}
+
+// Ping checks that the database connection is alive
+func (s *Store) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Close stops automatic cleanup and closes the database
+// Entries are kept so they are available the next time the database is opened
+func (s *Store) Close() error {
+	if s.cleanupTicker != nil {
+		s.cleanupTicker.Stop()
+	}
+	close(s.stopCleanup)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stmtErr := s.closeStatements()
+	if err := s.db.Close(); err != nil {
+		return err
+	}
+	return stmtErr
+}
+
+// closeStatements closes the prepared statements that were created
+func (s *Store) closeStatements() error {
+	var firstErr error
+	for _, stmt := range []*sql.Stmt{s.getStmt, s.setStmt, s.deleteStmt} {
+		if stmt == nil {
+			continue
+		}
+		if err := stmt.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// SetCleanupCallback sets the callback for TTL cleanup
+func (s *Store) SetCleanupCallback(callback store.EvictCallback) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cleanupCallback = callback
+}
+
+// Cleanup deletes expired rows in batches and returns the number of rows removed
+// Each batch is its own transaction, so the write lock is released between batches
+func (s *Store) Cleanup() int {
+	removed := 0
+	for {
+		n, err := s.cleanupBatch()
+		removed += n
+		if err != nil || n < s.cleanupBatchSize {
+			return removed
+		}
+	}
+}
+
+// cleanupBatch deletes up to cleanupBatchSize expired rows in one transaction
+func (s *Store) cleanupBatch() (int, error) {
+	type expiredRow struct {
+		key   string
+		value any
+	}
+
+	s.mu.Lock()
+	tx, err := s.db.Begin()
+	if err != nil {
+		s.mu.Unlock()
+		return 0, err
+	}
+
+	now := nowMillis()
+	rows, err := tx.Query(`SELECT key, value, raw FROM obcache_entries
+		WHERE NOT `+liveCondition+` LIMIT ?`, now, s.cleanupBatchSize)
+	if err != nil {
+		_ = tx.Rollback()
+		s.mu.Unlock()
+		return 0, err
+	}
+
+	var expired []expiredRow
+	for rows.Next() {
+		var key string
+		var data []byte
+		var raw bool
+		if err := rows.Scan(&key, &data, &raw); err != nil {
+			continue
+		}
+		value, _ := decodeValue(data, raw) // Undecodable rows are still removed
+		expired = append(expired, expiredRow{key: key, value: value})
+	}
+	_ = rows.Close()
+
+	for _, row := range expired {
+		if _, err := tx.Stmt(s.deleteStmt).Exec(row.key); err != nil {
+			_ = tx.Rollback()
+			s.mu.Unlock()
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		s.mu.Unlock()
+		return 0, err
+	}
+	callback := s.cleanupCallback
+	s.mu.Unlock()
+
+	if callback != nil {
+		for _, row := range expired {
+			callback(row.key, row.value)
+		}
+	}
+	return len(expired), nil
+}
+
+// UpdateTTL changes the expiration of an existing, non-expired row
+func (s *Store) UpdateTTL(key string, ttl time.Duration) bool {
+	var expiresAt sql.NullInt64
+	if ttl > 0 {
+		expiresAt = sql.NullInt64{Int64: time.Now().Add(ttl).UnixMilli(), Valid: true}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec(`UPDATE obcache_entries SET expires_at = ? WHERE key = ? AND `+liveCondition,
+		expiresAt, key, nowMillis())
+	if err != nil {
+		return false
+	}
+	n, err := result.RowsAffected()
+	return err == nil && n == 1
+}
+
+// startCleanup starts the automatic cleanup goroutine
+func (s *Store) startCleanup(interval time.Duration) {
+	s.cleanupTicker = time.NewTicker(interval)
+
+	go func() {
+		for {
+			select {
+			case <-s.cleanupTicker.C:
+				s.Cleanup()
+			case <-s.stopCleanup:
+				return
+			}
+		}
+	}()
+}
+
+// nowMillis returns the current time in the Unix milliseconds used by the schema
+func nowMillis() int64 {
+	return time.Now().UnixMilli()
+}
+
+// addMetaColumn adds the meta column to tables created before it existed
+func addMetaColumn(db *sql.DB) error {
+	rows, err := db.Query(`SELECT name FROM pragma_table_info('obcache_entries')`)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		if name == "meta" {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	_, err = db.Exec(`ALTER TABLE obcache_entries ADD COLUMN meta TEXT`)
+	return err
+}
+
+// encodeEnvelope converts an entry to the value and meta columns: the value's
+// bytes, whether they are raw rather than JSON, and the rest of the entry's
+// envelope. Byte slices, which include compressed and serialized values, are
+// stored as-is; other values are stored as JSON
+func encodeEnvelope(e *entry.Entry) ([]byte, bool, string, error) {
+	serialized, err := envelope.New(e, nil)
+	if err != nil {
+		return nil, false, "", err
+	}
+
+	data, raw := []byte(serialized.Value), serialized.Raw
+	if raw {
+		data = serialized.Data
+	}
+	if data == nil {
+		data = []byte{} // The value column is NOT NULL
+	}
+	serialized.Value, serialized.Data = nil, nil
+
+	meta, err := json.Marshal(serialized)
+	if err != nil {
+		return nil, false, "", fmt.Errorf("failed to marshal entry metadata: %w", err)
+	}
+	return data, raw, string(meta), nil
+}
+
+// decodeEnvelope converts the value and meta columns written by encodeEnvelope
+// back to an entry
+func decodeEnvelope(meta string, data []byte, raw bool) (*entry.Entry, error) {
+	var serialized envelope.Entry
+	if err := json.Unmarshal([]byte(meta), &serialized); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal entry metadata: %w", err)
+	}
+	if raw {
+		serialized.Data = data
+	} else {
+		serialized.Value = data
+	}
+	return serialized.Entry(nil)
+}
+
+// decodeValue converts a value column back to an entry value
+func decodeValue(data []byte, raw bool) (any, error) {
+	if raw {
+		return data, nil
+	}
+
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal entry value: %w", err)
+	}
+	return value, nil
+}
+
+// Ensure Store implements the required interfaces
+var (
+	_ store.Store         = (*Store)(nil)
+	_ store.TTLStore      = (*Store)(nil)
+	_ store.ScanStore     = (*Store)(nil)
+	_ store.CountStore    = (*Store)(nil)
+	_ store.HealthChecker = (*Store)(nil)
+)
@@ -0,0 +1,229 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+)
+
+func newTestStore(t *testing.T, config *Config) *Store {
+	t.Helper()
+	if config.DSN == "" {
+		config.DSN = filepath.Join(t.TempDir(), "cache.db")
+	}
+	s, err := New(config)
+	if err != nil {
+		// The driver needs cgo; without it Open or the schema fails
+		t.Skipf("SQLite not available, skipping test: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func TestSQLiteStoreBasicOperations(t *testing.T) {
+	s := newTestStore(t, &Config{})
+
+	e := entry.New("value1", time.Hour)
+	e.Version = 3
+	if err := s.Set("key1", e); err != nil {
+		t.Fatalf("Failed to set entry: %v", err)
+	}
+
+	got, found := s.Get("key1")
+	if !found || got.Value != "value1" || got.Version != 3 {
+		t.Fatalf("Expected value1 at version 3, got %+v (found=%v)", got, found)
+	}
+	if got.ExpiresAt == nil || got.ExpiresAt.UnixMilli() != e.ExpiresAt.UnixMilli() {
+		t.Errorf("Expected expiration %v, got %v", e.ExpiresAt, got.ExpiresAt)
+	}
+
+	if err := s.Set("raw", entry.NewWithoutTTL([]byte{0, 1, 2})); err != nil {
+		t.Fatalf("Failed to set entry: %v", err)
+	}
+	if got, found := s.Get("raw"); !found || string(got.Value.([]byte)) != "\x00\x01\x02" {
+		t.Errorf("Expected byte value to round-trip, got %#v", got)
+	}
+
+	if err := s.Delete("key1"); err != nil {
+		t.Fatalf("Failed to delete entry: %v", err)
+	}
+	if _, found := s.Get("key1"); found {
+		t.Error("Expected key1 to be deleted")
+	}
+
+	if err := s.Clear(); err != nil {
+		t.Fatalf("Failed to clear store: %v", err)
+	}
+	if s.Len() != 0 {
+		t.Errorf("Expected empty store after Clear, got %d entries", s.Len())
+	}
+}
+
+func TestSQLiteStoreCompressionMetadata(t *testing.T) {
+	s := newTestStore(t, &Config{})
+
+	e := entry.New([]byte{0x28, 0xb5, 0x2f, 0xfd}, time.Hour)
+	e.IsCompressed = true
+	e.CompressorName = "zstd"
+	e.CodecName = "raw"
+	e.DictionaryID = 42
+	e.OriginalSize = 100
+	e.CompressedSize = 4
+	if err := s.Set("key", e); err != nil {
+		t.Fatalf("Failed to set entry: %v", err)
+	}
+
+	got, found := s.Get("key")
+	if !found {
+		t.Fatal("Expected to find the entry")
+	}
+	if got.CompressorName != "zstd" || got.CodecName != "raw" || got.DictionaryID != 42 ||
+		got.OriginalSize != 100 || got.CompressedSize != 4 || !got.IsCompressed {
+		t.Errorf("Expected the compression metadata to round-trip, got %+v", got)
+	}
+}
+
+func TestSQLiteStoreReadsRowsWithoutMetadata(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "cache.db")
+	db, err := sql.Open(DefaultDriverName, dsn)
+	if err != nil {
+		t.Skipf("SQLite not available, skipping test: %v", err)
+	}
+	// The table as created before the meta column was added
+	_, err = db.Exec(`CREATE TABLE obcache_entries (key TEXT PRIMARY KEY, value BLOB NOT NULL,
+		expires_at INTEGER, created_at INTEGER NOT NULL, compressed INTEGER NOT NULL DEFAULT 0,
+		raw INTEGER NOT NULL DEFAULT 0, version INTEGER NOT NULL DEFAULT 0);
+		INSERT INTO obcache_entries (key, value, created_at) VALUES ('old', '"value"', 0)`)
+	_ = db.Close()
+	if err != nil {
+		t.Skipf("SQLite not available, skipping test: %v", err)
+	}
+
+	s := newTestStore(t, &Config{DSN: dsn})
+	if got, found := s.Get("old"); !found || got.Value != "value" {
+		t.Errorf("Expected the row written without metadata to be readable, got %+v (found=%v)", got, found)
+	}
+	if err := s.Set("new", entry.NewWithoutTTL("value")); err != nil {
+		t.Fatalf("Expected writes after adding the meta column to succeed, got %v", err)
+	}
+}
+
+func TestSQLiteStoreExpiryOnRead(t *testing.T) {
+	s := newTestStore(t, &Config{})
+
+	var cleaned []string
+	s.SetCleanupCallback(func(key string, value any) {
+		cleaned = append(cleaned, key)
+	})
+
+	_ = s.Set("short", entry.New("v", 10*time.Millisecond))
+	_ = s.Set("long", entry.New("v", time.Hour))
+	time.Sleep(20 * time.Millisecond)
+
+	// No Cleanup has run, so the expired row is still in the table
+	var rows int
+	_ = s.db.QueryRow(`SELECT COUNT(*) FROM obcache_entries`).Scan(&rows)
+	if rows != 2 {
+		t.Fatalf("Expected 2 rows before reading, got %d", rows)
+	}
+
+	if _, found := s.Peek("short"); found {
+		t.Error("Expected Peek to hide the expired entry")
+	}
+	if s.Len() != 1 || len(s.Keys()) != 1 {
+		t.Errorf("Expected Len and Keys to skip the expired entry, got %d and %v", s.Len(), s.Keys())
+	}
+	if _, found := s.Get("short"); found {
+		t.Error("Expected Get to miss on the expired entry")
+	}
+
+	_ = s.db.QueryRow(`SELECT COUNT(*) FROM obcache_entries`).Scan(&rows)
+	if rows != 1 {
+		t.Errorf("Expected Get to delete the expired row, got %d rows", rows)
+	}
+	if len(cleaned) != 1 || cleaned[0] != "short" {
+		t.Errorf("Expected cleanup callback for short, got %v", cleaned)
+	}
+}
+
+func TestSQLiteStoreCleanupBatches(t *testing.T) {
+	s := newTestStore(t, &Config{CleanupBatchSize: 2})
+
+	var cleaned int
+	s.SetCleanupCallback(func(string, any) { cleaned++ })
+
+	for i := range 5 {
+		_ = s.Set(fmt.Sprintf("expired%d", i), entry.New("v", 10*time.Millisecond))
+	}
+	_ = s.Set("live", entry.NewWithoutTTL("v"))
+	time.Sleep(20 * time.Millisecond)
+
+	if removed := s.Cleanup(); removed != 5 {
+		t.Errorf("Expected 5 expired rows to be removed, got %d", removed)
+	}
+	if cleaned != 5 {
+		t.Errorf("Expected 5 cleanup callbacks, got %d", cleaned)
+	}
+	if s.Len() != 1 {
+		t.Errorf("Expected 1 live entry, got %d", s.Len())
+	}
+}
+
+func TestSQLiteStoreScan(t *testing.T) {
+	s := newTestStore(t, &Config{})
+
+	for _, key := range []string{"user:3", "user:1", "User:2", "user:2", "session:1"} {
+		_ = s.Set(key, entry.NewWithoutTTL(key))
+	}
+	_ = s.Set("user:0", entry.New("expired", time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+
+	keys, cursor, err := s.Scan("user:", "", 2)
+	if err != nil || len(keys) != 2 || keys[0] != "user:1" || keys[1] != "user:2" || cursor == "" {
+		t.Fatalf("Unexpected first page: %v (cursor=%q, err=%v)", keys, cursor, err)
+	}
+	keys, cursor, err = s.Scan("user:", cursor, 2)
+	if err != nil || len(keys) != 1 || keys[0] != "user:3" || cursor != "" {
+		t.Fatalf("Unexpected second page: %v (cursor=%q, err=%v)", keys, cursor, err)
+	}
+
+	if count, size := s.CountPrefix("user:"); count != 3 || size == 0 {
+		t.Errorf("Expected 3 user entries with a size, got %d and %d", count, size)
+	}
+}
+
+func TestSQLiteStoreConcurrentWrites(t *testing.T) {
+	s := newTestStore(t, &Config{})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8*50)
+	for w := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 50 {
+				key := fmt.Sprintf("w%d-%d", w, i)
+				if err := s.Set(key, entry.New(key, time.Hour)); err != nil {
+					errs <- err
+				}
+				s.Get(key)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("Concurrent write failed: %v", err)
+	}
+	if s.Len() != 400 {
+		t.Errorf("Expected 400 entries, got %d", s.Len())
+	}
+}
@@ -0,0 +1,516 @@
+package tiered
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+	"github.com/1mb-dev/obcache-go/v2/pkg/store"
+)
+
+const (
+	// TierLocal identifies hits served by the local (L1) tier
+	TierLocal = 1
+
+	// TierShared identifies hits served by the shared (L2) tier
+	TierShared = 2
+)
+
+// DefaultLocalTTL caps how long an entry is served from the local tier
+const DefaultLocalTTL = time.Minute
+
+// Store composes a small local store (L1) in front of a shared store (L2)
+// Reads try L1 first and fill it from L2 on a hit; writes and deletes go to both.
+// L2 is the source of truth: Keys, Len, Scan and expiration callbacks come from it,
+// and L1 entries expire no later than LocalTTL after they were cached so other
+// instances' writes become visible within that bound. When the shared store
+// reports other clients' changes, the affected keys are dropped from L1 right away
+type Store struct {
+	local       store.Store
+	shared      store.Store
+	localTTL    time.Duration
+	tierHitFunc func(tier int)
+	invalidated store.InvalidateCallback
+	mu          sync.RWMutex
+
+	// generation is bumped on every invalidation so a read from the shared tier
+	// that raced with one does not fill L1 with the value it replaced
+	generation atomic.Uint64
+	fillMu     sync.RWMutex
+}
+
+// Config holds tiered store configuration
+type Config struct {
+	// Local is the L1 store, typically a size-bounded memory store
+	Local store.Store
+
+	// Shared is the L2 store, typically Redis
+	Shared store.Store
+
+	// LocalTTL caps the TTL of entries in the local tier
+	// Default: 1 minute
+	LocalTTL time.Duration
+}
+
+// New creates a tiered store from a local and a shared store
+func New(config *Config) (*Store, error) {
+	if config.Local == nil || config.Shared == nil {
+		return nil, fmt.Errorf("tiered store requires both a local and a shared store")
+	}
+
+	localTTL := config.LocalTTL
+	if localTTL <= 0 {
+		localTTL = DefaultLocalTTL
+	}
+
+	s := &Store{
+		local:    config.Local,
+		shared:   config.Shared,
+		localTTL: localTTL,
+	}
+	if invalidator, ok := config.Shared.(store.InvalidationStore); ok {
+		invalidator.SetInvalidateCallback(s.invalidate)
+	}
+	return s, nil
+}
+
+// Get retrieves an entry from the local tier, falling back to the shared tier
+// Shared hits are copied into the local tier with a capped TTL
+func (s *Store) Get(key string) (*entry.Entry, bool) {
+	return s.GetContext(context.Background(), key)
+}
+
+// GetContext retrieves an entry like Get, bounding the shared tier's read by ctx
+// where the shared store supports it
+func (s *Store) GetContext(ctx context.Context, key string) (*entry.Entry, bool) {
+	if e, found := unwrap(s.local.Get(key)); found {
+		s.reportHit(TierLocal)
+		return e, true
+	}
+
+	generation := s.generation.Load()
+	e, found := store.GetWithContext(ctx, s.shared, key)
+	if !found {
+		return nil, false
+	}
+
+	s.fill(key, e, generation)
+	s.reportHit(TierShared)
+	return e, true
+}
+
+// GetBatch retrieves the entries for keys from the local tier and reads the
+// remaining keys from the shared tier in one batch when it supports batching
+// Shared hits are copied into the local tier as in Get
+func (s *Store) GetBatch(keys []string) (map[string]*entry.Entry, error) {
+	found := make(map[string]*entry.Entry, len(keys))
+	var missing []string
+	for _, key := range keys {
+		if e, ok := unwrap(s.local.Get(key)); ok {
+			s.reportHit(TierLocal)
+			found[key] = e
+		} else {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return found, nil
+	}
+
+	generation := s.generation.Load()
+	for key, e := range store.GetMany(s.shared, missing) {
+		s.fill(key, e, generation)
+		s.reportHit(TierShared)
+		found[key] = e
+	}
+	return found, nil
+}
+
+// Peek retrieves an entry from either tier without filling the local tier
+func (s *Store) Peek(key string) (*entry.Entry, bool) {
+	if e, found := unwrap(s.local.Peek(key)); found {
+		return e, true
+	}
+	return s.shared.Peek(key)
+}
+
+// Set writes the entry to the shared tier and then to the local tier
+// If the shared write fails, the key is dropped from the local tier so it
+// does not serve a value other instances cannot see
+func (s *Store) Set(key string, e *entry.Entry) error {
+	return s.SetContext(context.Background(), key, e)
+}
+
+// SetContext stores an entry like Set, bounding the shared tier's write by ctx
+// where the shared store supports it
+func (s *Store) SetContext(ctx context.Context, key string, e *entry.Entry) error {
+	if err := store.SetWithContext(ctx, s.shared, key, e); err != nil {
+		_ = s.local.Delete(key)
+		return err
+	}
+	return s.local.Set(key, s.localEntry(e))
+}
+
+// Swap replaces the entry in the shared tier and refreshes the local tier
+// Falls back to Peek followed by Set when the shared store cannot swap atomically
+func (s *Store) Swap(key string, e *entry.Entry) (*entry.Entry, bool, error) {
+	swapper, ok := s.shared.(store.SwapStore)
+	if !ok {
+		previous, existed := s.shared.Peek(key)
+		if err := s.Set(key, e); err != nil {
+			return nil, false, err
+		}
+		return previous, existed, nil
+	}
+
+	previous, existed, err := swapper.Swap(key, e)
+	if err != nil {
+		_ = s.local.Delete(key)
+		return nil, false, err
+	}
+	_ = s.local.Set(key, s.localEntry(e))
+	return previous, existed, nil
+}
+
+// SetIfNewer writes to the shared tier when its version check passes and mirrors
+// the result in the local tier
+func (s *Store) SetIfNewer(key string, e *entry.Entry) (bool, error) {
+	versioned, ok := s.shared.(store.VersionedStore)
+	if !ok {
+		if current, found := s.shared.Peek(key); found && current.Version >= e.Version {
+			return false, nil
+		}
+		return true, s.Set(key, e)
+	}
+
+	written, err := versioned.SetIfNewer(key, e)
+	if err != nil || !written {
+		// The local copy may be older than what the shared tier holds
+		_ = s.local.Delete(key)
+		return written, err
+	}
+	_ = s.local.Set(key, s.localEntry(e))
+	return true, nil
+}
+
+// SetBatch writes the entries to the shared tier in one batch when it supports
+// batching, then mirrors the written keys in the local tier
+func (s *Store) SetBatch(entries map[string]*entry.Entry) error {
+	batcher, ok := s.shared.(store.BatchStore)
+	if !ok {
+		failed := make(map[string]error)
+		for key, e := range entries {
+			if err := s.Set(key, e); err != nil {
+				failed[key] = err
+			}
+		}
+		return store.JoinBatchErrors(failed)
+	}
+
+	err := batcher.SetBatch(entries)
+	failed := batchFailures(err)
+	for key, e := range entries {
+		// An error without per-key detail may have left any key unwritten
+		if _, keyFailed := failed[key]; keyFailed || (err != nil && failed == nil) {
+			_ = s.local.Delete(key)
+			continue
+		}
+		_ = s.local.Set(key, s.localEntry(e))
+	}
+	return err
+}
+
+// DeleteBatch removes the keys from both tiers
+func (s *Store) DeleteBatch(keys []string) error {
+	for _, key := range keys {
+		_ = s.local.Delete(key)
+	}
+
+	if batcher, ok := s.shared.(store.BatchStore); ok {
+		return batcher.DeleteBatch(keys)
+	}
+
+	failed := make(map[string]error)
+	for _, key := range keys {
+		if err := s.shared.Delete(key); err != nil {
+			failed[key] = err
+		}
+	}
+	return store.JoinBatchErrors(failed)
+}
+
+// Delete removes the key from both tiers
+func (s *Store) Delete(key string) error {
+	return s.DeleteContext(context.Background(), key)
+}
+
+// DeleteContext removes the key from both tiers, bounding the shared tier's
+// delete by ctx where the shared store supports it
+func (s *Store) DeleteContext(ctx context.Context, key string) error {
+	localErr := s.local.Delete(key)
+	if err := store.DeleteWithContext(ctx, s.shared, key); err != nil {
+		return err
+	}
+	return localErr
+}
+
+// InvalidateLocal drops key from the local tier only, so the next Get reloads it
+// from the shared tier. It is the hook for cross-instance invalidation
+func (s *Store) InvalidateLocal(key string) {
+	_ = s.local.Delete(key)
+}
+
+// invalidate drops keys changed by other clients from the local tier, or the
+// whole local tier when keys is nil, and then passes them on to the invalidate callback
+func (s *Store) invalidate(keys []string) {
+	s.fillMu.Lock()
+	s.generation.Add(1)
+	if keys == nil {
+		_ = s.local.Clear()
+	}
+	for _, key := range keys {
+		_ = s.local.Delete(key)
+	}
+	s.fillMu.Unlock()
+
+	s.mu.RLock()
+	callback := s.invalidated
+	s.mu.RUnlock()
+
+	if callback != nil {
+		callback(keys)
+	}
+}
+
+// SetInvalidateCallback sets a callback invoked with the keys the shared tier
+// reported changed, after they were dropped from the local tier
+func (s *Store) SetInvalidateCallback(callback store.InvalidateCallback) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.invalidated = callback
+}
+
+// fill copies an entry read from the shared tier into the local tier unless an
+// invalidation arrived since generation was read
+func (s *Store) fill(key string, e *entry.Entry, generation uint64) {
+	s.fillMu.RLock()
+	defer s.fillMu.RUnlock()
+
+	if s.generation.Load() == generation {
+		_ = s.local.Set(key, s.localEntry(e)) // The local tier is best effort
+	}
+}
+
+// Keys returns all keys in the shared tier
+func (s *Store) Keys() []string {
+	return s.shared.Keys()
+}
+
+// Len returns the number of entries in the shared tier
+func (s *Store) Len() int {
+	return s.shared.Len()
+}
+
+// Scan pages through keys in the shared tier
+func (s *Store) Scan(prefix string, cursor string, limit int) ([]string, string, error) {
+	scanner, ok := s.shared.(store.ScanStore)
+	if !ok {
+		return nil, "", fmt.Errorf("shared store does not support scanning")
+	}
+	return scanner.Scan(prefix, cursor, limit)
+}
+
+// CountPrefix counts keys with the given prefix in the shared tier
+func (s *Store) CountPrefix(prefix string) (int, int64) {
+	if counter, ok := s.shared.(store.CountStore); ok {
+		return counter.CountPrefix(prefix)
+	}
+
+	count := 0
+	for _, key := range s.shared.Keys() {
+		if strings.HasPrefix(key, prefix) {
+			count++
+		}
+	}
+	return count, 0
+}
+
+// CountPrefixKeys counts keys with the given prefix in the shared tier without their sizes
+func (s *Store) CountPrefixKeys(prefix string) int {
+	if counter, ok := s.shared.(store.CountStore); ok {
+		return store.CountPrefixKeys(counter, prefix)
+	}
+	count, _ := s.CountPrefix(prefix)
+	return count
+}
+
+// Clear removes all entries from both tiers
+func (s *Store) Clear() error {
+	localErr := s.local.Clear()
+	if err := s.shared.Clear(); err != nil {
+		return err
+	}
+	return localErr
+}
+
+// KeysContext returns all keys in the shared tier, bounded by ctx where the shared store supports it
+func (s *Store) KeysContext(ctx context.Context) ([]string, error) {
+	if ctxStore, ok := s.shared.(store.ContextStore); ok {
+		return ctxStore.KeysContext(ctx)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.shared.Keys(), nil
+}
+
+// ClearContext clears the local tier and then clears the shared tier in batches
+// where it supports that, reporting the shared tier's keys to fn
+func (s *Store) ClearContext(ctx context.Context, fn func(keys []string)) error {
+	if err := s.local.Clear(); err != nil {
+		return err
+	}
+
+	if ctxStore, ok := s.shared.(store.ContextStore); ok {
+		return ctxStore.ClearContext(ctx, fn)
+	}
+
+	keys := s.shared.Keys()
+	if err := s.shared.Clear(); err != nil {
+		return err
+	}
+	if fn != nil && len(keys) > 0 {
+		fn(keys)
+	}
+	return nil
+}
+
+// Ping checks the shared tier, which every write depends on, and then the local tier
+func (s *Store) Ping(ctx context.Context) error {
+	for _, tier := range []store.Store{s.shared, s.local} {
+		if checker, ok := tier.(store.HealthChecker); ok {
+			if err := checker.Ping(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Close closes both tiers
+func (s *Store) Close() error {
+	localErr := s.local.Close()
+	if err := s.shared.Close(); err != nil {
+		return err
+	}
+	return localErr
+}
+
+// SetTierHitCallback sets a callback invoked with TierLocal or TierShared for each Get hit
+func (s *Store) SetTierHitCallback(callback func(tier int)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tierHitFunc = callback
+}
+
+// reportHit calls the tier hit callback, if any
+func (s *Store) reportHit(tier int) {
+	s.mu.RLock()
+	callback := s.tierHitFunc
+	s.mu.RUnlock()
+
+	if callback != nil {
+		callback(tier)
+	}
+}
+
+// SetCleanupCallback sets the TTL cleanup callback on the shared tier
+// Local expirations only bound staleness and are not reported
+func (s *Store) SetCleanupCallback(callback store.EvictCallback) {
+	if ttlStore, ok := s.shared.(store.TTLStore); ok {
+		ttlStore.SetCleanupCallback(callback)
+	}
+}
+
+// Cleanup removes expired entries from both tiers
+// Only entries removed from the shared tier are counted
+func (s *Store) Cleanup() int {
+	if ttlStore, ok := s.local.(store.TTLStore); ok {
+		ttlStore.Cleanup()
+	}
+	if ttlStore, ok := s.shared.(store.TTLStore); ok {
+		return ttlStore.Cleanup()
+	}
+	return 0
+}
+
+// UpdateTTL changes the expiration in the shared tier and drops the local copy
+func (s *Store) UpdateTTL(key string, ttl time.Duration) bool {
+	ttlStore, ok := s.shared.(store.TTLStore)
+	if !ok {
+		return false
+	}
+
+	s.InvalidateLocal(key)
+	return ttlStore.UpdateTTL(key, ttl)
+}
+
+// localEntry wraps e for the local tier in an entry that expires no later than
+// localTTL from now. The wrapper only drives local expiration and eviction; callers
+// get e back with its own expiration from unwrap
+func (s *Store) localEntry(e *entry.Entry) *entry.Entry {
+	expiry := time.Now().Add(s.localTTL)
+	if expiresAt := e.Expiry(); expiresAt != nil && expiresAt.Before(expiry) {
+		expiry = *expiresAt
+	}
+
+	return &entry.Entry{
+		Value:     e,
+		ExpiresAt: &expiry,
+		CreatedAt: time.Now(),
+		ValueSize: e.ValueSize,
+	}
+}
+
+// unwrap returns the shared-tier entry held by a local wrapper entry
+func unwrap(wrapper *entry.Entry, found bool) (*entry.Entry, bool) {
+	if !found {
+		return nil, false
+	}
+	e, ok := wrapper.Value.(*entry.Entry)
+	if !ok || e.IsExpired() {
+		return nil, false
+	}
+	return e, true
+}
+
+// batchFailures returns the per-key errors of a *store.BatchError, or nil for any other error
+func batchFailures(err error) map[string]error {
+	var batchErr *store.BatchError
+	if errors.As(err, &batchErr) {
+		return batchErr.Errors
+	}
+	return nil
+}
+
+// Ensure Store implements the required interfaces
+var (
+	_ store.Store               = (*Store)(nil)
+	_ store.TieredStore         = (*Store)(nil)
+	_ store.TTLStore            = (*Store)(nil)
+	_ store.ScanStore           = (*Store)(nil)
+	_ store.KeyCountStore       = (*Store)(nil)
+	_ store.SwapStore           = (*Store)(nil)
+	_ store.VersionedStore      = (*Store)(nil)
+	_ store.BatchStore          = (*Store)(nil)
+	_ store.BatchGetStore       = (*Store)(nil)
+	_ store.ContextStore        = (*Store)(nil)
+	_ store.RequestContextStore = (*Store)(nil)
+	_ store.InvalidationStore   = (*Store)(nil)
+	_ store.HealthChecker       = (*Store)(nil)
+)
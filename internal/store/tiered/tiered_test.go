@@ -0,0 +1,198 @@
+package tiered
+
+import (
+	"testing"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/internal/eviction"
+	"github.com/1mb-dev/obcache-go/v2/internal/store/memory"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+	"github.com/1mb-dev/obcache-go/v2/pkg/store"
+)
+
+func newTestStore(t *testing.T, localTTL time.Duration) (*Store, *memory.StrategyStore, *memory.StrategyStore) {
+	t.Helper()
+	local, _ := memory.NewWithStrategy(eviction.Config{Type: eviction.LRU, Capacity: 2})
+	shared, _ := memory.NewWithStrategy(eviction.Config{Type: eviction.LRU, Capacity: 100})
+	s, err := New(&Config{Local: local, Shared: shared, LocalTTL: localTTL})
+	if err != nil {
+		t.Fatalf("Failed to create tiered store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s, local, shared
+}
+
+func TestTieredStoreReadThrough(t *testing.T) {
+	s, local, shared := newTestStore(t, time.Hour)
+
+	var tiers []int
+	s.SetTierHitCallback(func(tier int) { tiers = append(tiers, tier) })
+
+	_ = shared.Set("key", entry.New("value", time.Hour))
+	if _, found := local.Peek("key"); found {
+		t.Fatal("Expected key to start in the shared tier only")
+	}
+
+	if e, found := s.Get("key"); !found || e.Value != "value" {
+		t.Fatalf("Expected shared hit, got %v (found=%v)", e, found)
+	}
+	if e, found := s.Get("key"); !found || e.Value != "value" {
+		t.Fatalf("Expected local hit, got %v (found=%v)", e, found)
+	}
+	if len(tiers) != 2 || tiers[0] != TierShared || tiers[1] != TierLocal {
+		t.Errorf("Expected a shared hit followed by a local hit, got %v", tiers)
+	}
+
+	// Entries read from the local tier keep their shared expiration
+	e, _ := s.Get("key")
+	if e.TTL() < 59*time.Minute {
+		t.Errorf("Expected the shared TTL to be reported, got %v", e.TTL())
+	}
+}
+
+func TestTieredStoreGetBatch(t *testing.T) {
+	s, local, shared := newTestStore(t, time.Hour)
+
+	var tiers []int
+	s.SetTierHitCallback(func(tier int) { tiers = append(tiers, tier) })
+
+	_ = s.Set("a", entry.New("1", time.Hour))
+	_ = shared.Set("b", entry.New("2", time.Hour))
+
+	found, err := s.GetBatch([]string{"a", "b", "missing"})
+	if err != nil {
+		t.Fatalf("GetBatch failed: %v", err)
+	}
+	if len(found) != 2 || found["a"].Value != "1" || found["b"].Value != "2" {
+		t.Fatalf("Expected a and b, got %v", found)
+	}
+	if len(tiers) != 2 || tiers[0] != TierLocal || tiers[1] != TierShared {
+		t.Errorf("Expected a local hit followed by a shared hit, got %v", tiers)
+	}
+	if _, found := local.Peek("b"); !found {
+		t.Error("Expected the shared hit to fill the local tier")
+	}
+}
+
+func TestTieredStoreWritesAndDeletes(t *testing.T) {
+	s, local, shared := newTestStore(t, time.Hour)
+
+	if err := s.Set("key", entry.New("value", time.Hour)); err != nil {
+		t.Fatalf("Failed to set entry: %v", err)
+	}
+	if _, found := local.Peek("key"); !found {
+		t.Error("Expected Set to write the local tier")
+	}
+	if _, found := shared.Peek("key"); !found {
+		t.Error("Expected Set to write the shared tier")
+	}
+
+	if err := s.Delete("key"); err != nil {
+		t.Fatalf("Failed to delete entry: %v", err)
+	}
+	if _, found := local.Peek("key"); found {
+		t.Error("Expected Delete to remove the local copy")
+	}
+	if _, found := shared.Peek("key"); found {
+		t.Error("Expected Delete to remove the shared entry")
+	}
+
+	// The small local tier evicts without losing entries from the shared tier
+	for _, key := range []string{"a", "b", "c"} {
+		_ = s.Set(key, entry.NewWithoutTTL(key))
+	}
+	if local.Len() != 2 || s.Len() != 3 {
+		t.Errorf("Expected 2 local and 3 shared entries, got %d and %d", local.Len(), s.Len())
+	}
+	if e, found := s.Get("a"); !found || e.Value != "a" {
+		t.Errorf("Expected entry evicted locally to be read from the shared tier, got %v", e)
+	}
+}
+
+func TestTieredStoreLocalTTLBoundsStaleness(t *testing.T) {
+	s, _, shared := newTestStore(t, 10*time.Millisecond)
+
+	_ = s.Set("key", entry.New("old", time.Hour))
+
+	// Another instance writes straight to the shared tier
+	_ = shared.Set("key", entry.New("new", time.Hour))
+	if e, _ := s.Get("key"); e.Value != "old" {
+		t.Fatalf("Expected the local copy to be served before LocalTTL, got %v", e.Value)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if e, _ := s.Get("key"); e.Value != "new" {
+		t.Errorf("Expected the shared value after LocalTTL, got %v", e.Value)
+	}
+
+	_ = shared.Set("key", entry.New("newer", time.Hour))
+	s.InvalidateLocal("key")
+	if e, _ := s.Get("key"); e.Value != "newer" {
+		t.Errorf("Expected InvalidateLocal to force a shared read, got %v", e.Value)
+	}
+}
+
+// notifyingStore is a shared store that reports other clients' changes
+type notifyingStore struct {
+	*memory.StrategyStore
+	callback store.InvalidateCallback
+}
+
+func (s *notifyingStore) SetInvalidateCallback(callback store.InvalidateCallback) {
+	s.callback = callback
+}
+
+func TestTieredStoreSharedInvalidation(t *testing.T) {
+	local, _ := memory.NewWithStrategy(eviction.Config{Type: eviction.LRU, Capacity: 10})
+	backing, _ := memory.NewWithStrategy(eviction.Config{Type: eviction.LRU, Capacity: 100})
+	shared := &notifyingStore{StrategyStore: backing}
+	s, err := New(&Config{Local: local, Shared: shared, LocalTTL: time.Hour})
+	if err != nil {
+		t.Fatalf("Failed to create tiered store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if shared.callback == nil {
+		t.Fatal("Expected the tiered store to register an invalidate callback")
+	}
+
+	_ = s.Set("a", entry.New("old", time.Hour))
+	_ = s.Set("b", entry.New("old", time.Hour))
+
+	// Another client writes a, and the shared store reports it
+	_ = backing.Set("a", entry.New("new", time.Hour))
+	shared.callback([]string{"a"})
+	if e, _ := s.Get("a"); e.Value != "new" {
+		t.Errorf("Expected invalidated key to be re-read, got %v", e.Value)
+	}
+	if _, found := local.Peek("b"); !found {
+		t.Error("Expected other keys to stay in the local tier")
+	}
+
+	// A nil key list flushes the whole local tier
+	shared.callback(nil)
+	if local.Len() != 0 {
+		t.Errorf("Expected local tier to be flushed, got %d entries", local.Len())
+	}
+
+	// A read that raced with an invalidation does not fill the local tier
+	generation := s.generation.Load()
+	shared.callback([]string{"b"})
+	s.fill("b", entry.New("stale", time.Hour), generation)
+	if _, found := local.Peek("b"); found {
+		t.Error("Expected a fill older than the last invalidation to be dropped")
+	}
+
+	// Invalidations are passed on after the local tier dropped the keys
+	var forwarded []string
+	s.SetInvalidateCallback(func(keys []string) {
+		if _, found := local.Peek("a"); found {
+			t.Error("Expected the local copy to be gone before the callback runs")
+		}
+		forwarded = keys
+	})
+	_, _ = s.Get("a")
+	shared.callback([]string{"a"})
+	if len(forwarded) != 1 || forwarded[0] != "a" {
+		t.Errorf("Expected the invalidation to be forwarded, got %v", forwarded)
+	}
+}
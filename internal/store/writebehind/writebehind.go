@@ -0,0 +1,643 @@
+package writebehind
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+	"github.com/1mb-dev/obcache-go/v2/pkg/store"
+)
+
+// OverflowPolicy decides what a write does when its queue is full
+type OverflowPolicy string
+
+const (
+	// OverflowBlock makes the write wait until a worker frees room in the queue
+	OverflowBlock OverflowPolicy = "block"
+
+	// OverflowDropOldest discards the oldest queued Set to make room. The
+	// discarded write never reaches the backing store. Deletes are never
+	// discarded: a Set that replaced a queued Delete turns back into it, and with
+	// only deletes queued the write waits as with OverflowBlock
+	OverflowDropOldest OverflowPolicy = "drop_oldest"
+
+	// OverflowError rejects the write with ErrQueueFull
+	OverflowError OverflowPolicy = "error"
+)
+
+const (
+	// DefaultQueueSize is the number of distinct keys that may wait to be flushed
+	DefaultQueueSize = 10000
+
+	// DefaultWorkers is the number of goroutines flushing to the backing store
+	DefaultWorkers = 4
+
+	// DefaultBatchSize is the maximum number of writes a worker flushes at once
+	DefaultBatchSize = 100
+)
+
+var (
+	// ErrQueueFull is returned by writes under OverflowError, and reported for
+	// writes discarded under OverflowDropOldest
+	ErrQueueFull = errors.New("write-behind queue is full")
+
+	// ErrClosed is returned by writes after Close
+	ErrClosed = errors.New("write-behind store is closed")
+)
+
+// Store acknowledges Set and Delete as soon as they are queued and applies them
+// to a backing store from worker goroutines. Reads see queued writes, so callers
+// read their own writes; other readers of the backing store see them once flushed.
+// Keys are split across workers by hash and each worker applies its writes in
+// order, so a Delete is never overtaken by an earlier Set of the same key.
+// Writes to a key that is still queued replace the queued write in place
+type Store struct {
+	backing   store.Store
+	queues    []*queue
+	batchSize int
+	overflow  OverflowPolicy
+	onError   func(key string, err error)
+
+	depth     atomic.Int64
+	depthFunc func(depth int)
+	mu        sync.RWMutex
+
+	clearMu sync.Mutex
+	closed  atomic.Bool
+	wg      sync.WaitGroup
+}
+
+// queue holds the writes owned by one worker. A nil entry is a queued Delete
+type queue struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	order    []string                // Queued keys, oldest first
+	pending  map[string]*entry.Entry // Queued writes by key
+	flushing map[string]*entry.Entry // Writes the worker is applying
+	deletes  map[string]struct{}     // Pending Sets that replaced a queued Delete
+	capacity int
+	paused   bool // Set by Clear while the backing store is cleared
+	closed   bool
+}
+
+// Config holds write-behind store configuration
+type Config struct {
+	// Backing is the store writes are flushed to
+	Backing store.Store
+
+	// QueueSize bounds the number of distinct keys waiting to be flushed
+	// Default: 10000
+	QueueSize int
+
+	// Workers is the number of goroutines flushing to the backing store
+	// Default: 4
+	Workers int
+
+	// BatchSize is the maximum number of writes a worker flushes at once,
+	// using the backing store's batch operations where it has them
+	// Default: 100
+	BatchSize int
+
+	// Overflow decides what a write does when the queue is full
+	// Default: OverflowBlock
+	Overflow OverflowPolicy
+
+	// OnError is called for writes that fail to flush or are discarded by
+	// OverflowDropOldest. It runs on a worker or writer goroutine
+	OnError func(key string, err error)
+}
+
+// New creates a write-behind store in front of config.Backing and starts its workers
+func New(config *Config) (*Store, error) {
+	if config.Backing == nil {
+		return nil, fmt.Errorf("write-behind store requires a backing store")
+	}
+
+	overflow := config.Overflow
+	switch overflow {
+	case "":
+		overflow = OverflowBlock
+	case OverflowBlock, OverflowDropOldest, OverflowError:
+	default:
+		return nil, fmt.Errorf("unknown write-behind overflow policy: %q", overflow)
+	}
+
+	queueSize := config.QueueSize
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+	workers := config.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	batchSize := config.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	s := &Store{
+		backing:   config.Backing,
+		queues:    make([]*queue, workers),
+		batchSize: batchSize,
+		overflow:  overflow,
+		onError:   config.OnError,
+	}
+	for i := range s.queues {
+		q := &queue{
+			pending:  make(map[string]*entry.Entry),
+			deletes:  make(map[string]struct{}),
+			capacity: max((queueSize+workers-1)/workers, 1),
+		}
+		q.cond = sync.NewCond(&q.mu)
+		s.queues[i] = q
+
+		s.wg.Add(1)
+		go s.run(q)
+	}
+	return s, nil
+}
+
+// queueFor returns the queue that owns key
+func (s *Store) queueFor(key string) *queue {
+	// FNV-1a, inlined to avoid allocating a hash.Hash per call
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(key); i++ {
+		h ^= uint64(key[i])
+		h *= 1099511628211
+	}
+	return s.queues[h%uint64(len(s.queues))]
+}
+
+// Get returns the queued write for key if there is one, and otherwise reads the backing store
+func (s *Store) Get(key string) (*entry.Entry, bool) {
+	if e, found, queued := s.queued(key); queued {
+		return e, found
+	}
+	return s.backing.Get(key)
+}
+
+// Peek returns the queued write for key if there is one, and otherwise peeks the backing store
+func (s *Store) Peek(key string) (*entry.Entry, bool) {
+	if e, found, queued := s.queued(key); queued {
+		return e, found
+	}
+	return s.backing.Peek(key)
+}
+
+// queued looks key up among writes not yet applied to the backing store
+// queued is false when no write for key is pending
+func (s *Store) queued(key string) (e *entry.Entry, found bool, queued bool) {
+	q := s.queueFor(key)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	e, queued = q.lookup(key)
+	if !queued {
+		return nil, false, false
+	}
+	if e == nil || e.IsExpired() {
+		return nil, false, true
+	}
+	return e, true, true
+}
+
+// lookup returns the most recent unapplied write for key
+// Must be called with q.mu held
+func (q *queue) lookup(key string) (*entry.Entry, bool) {
+	if e, ok := q.pending[key]; ok {
+		return e, true
+	}
+	e, ok := q.flushing[key]
+	return e, ok
+}
+
+// Set queues e to be written to the backing store
+func (s *Store) Set(key string, e *entry.Entry) error {
+	return s.enqueue(key, e)
+}
+
+// Delete queues the removal of key from the backing store
+func (s *Store) Delete(key string) error {
+	return s.enqueue(key, nil)
+}
+
+// enqueue queues a write of e, or a delete when e is nil, applying the overflow
+// policy when the queue is full
+func (s *Store) enqueue(key string, e *entry.Entry) error {
+	q := s.queueFor(key)
+	var dropped []string
+	var removed int
+
+	q.mu.Lock()
+	for {
+		if q.closed {
+			q.mu.Unlock()
+			return ErrClosed
+		}
+		if queued, ok := q.pending[key]; ok {
+			// Replaces the queued write and keeps its place. A Set replacing a
+			// Delete remembers it, so dropping the Set restores the Delete
+			if queued == nil && e != nil {
+				q.deletes[key] = struct{}{}
+			} else if e == nil {
+				delete(q.deletes, key)
+			}
+			q.pending[key] = e
+			q.mu.Unlock()
+			s.addDepth(-removed)
+			s.reportDropped(dropped)
+			return nil
+		}
+		if len(q.order) < q.capacity {
+			break
+		}
+
+		switch s.overflow {
+		case OverflowError:
+			q.mu.Unlock()
+			return ErrQueueFull
+		case OverflowDropOldest:
+			discarded, freed := q.dropOldestSet()
+			dropped = append(dropped, discarded...)
+			if freed {
+				removed++
+				continue
+			}
+			q.cond.Wait() // Only deletes are queued, and those are never dropped
+		default:
+			q.cond.Wait()
+		}
+	}
+
+	q.push(key, e)
+	q.mu.Unlock()
+
+	s.addDepth(1 - removed)
+	s.reportDropped(dropped)
+	return nil
+}
+
+// dropOldestSet discards queued Sets, oldest first, until one leaves the queue
+// and returns their keys, or false if none could leave. A Set that replaced a
+// queued Delete turns back into that Delete and keeps its place, so a discarded
+// write never brings back a deleted value. Must be called with q.mu held
+func (q *queue) dropOldestSet() ([]string, bool) {
+	var discarded []string
+	for i, key := range q.order {
+		if q.pending[key] == nil {
+			continue
+		}
+		discarded = append(discarded, key)
+		if _, ok := q.deletes[key]; ok {
+			delete(q.deletes, key)
+			q.pending[key] = nil
+			continue
+		}
+		q.order = slices.Delete(q.order, i, i+1)
+		delete(q.pending, key)
+		return discarded, true
+	}
+	return discarded, false
+}
+
+// push queues a write for a key that has none pending
+// Must be called with q.mu held
+func (q *queue) push(key string, e *entry.Entry) {
+	q.pending[key] = e
+	q.order = append(q.order, key)
+	q.cond.Broadcast()
+}
+
+// run flushes q to the backing store until q is closed and drained
+func (s *Store) run(q *queue) {
+	defer s.wg.Done()
+
+	for {
+		q.mu.Lock()
+		for q.paused || (len(q.order) == 0 && !q.closed) {
+			q.cond.Wait()
+		}
+		if len(q.order) == 0 {
+			q.mu.Unlock()
+			return
+		}
+
+		n := min(len(q.order), s.batchSize)
+		batch := make(map[string]*entry.Entry, n)
+		for _, key := range q.order[:n] {
+			batch[key] = q.pending[key]
+			delete(q.pending, key)
+			delete(q.deletes, key)
+		}
+		q.order = q.order[n:]
+		q.flushing = batch
+		q.cond.Broadcast() // Wake writers waiting for room
+		q.mu.Unlock()
+
+		s.flush(batch)
+
+		q.mu.Lock()
+		q.flushing = nil
+		q.cond.Broadcast() // Wake Flush and Clear
+		q.mu.Unlock()
+		s.addDepth(-n)
+	}
+}
+
+// flush applies a batch of writes to the backing store, reporting failed keys
+// Queued entries that expired before being flushed are deleted instead
+func (s *Store) flush(batch map[string]*entry.Entry) {
+	sets := make(map[string]*entry.Entry, len(batch))
+	var deletes []string
+	for key, e := range batch {
+		if e == nil || e.IsExpired() {
+			deletes = append(deletes, key)
+		} else {
+			sets[key] = e
+		}
+	}
+
+	if batcher, ok := s.backing.(store.BatchStore); ok {
+		if len(sets) > 0 {
+			keys := make([]string, 0, len(sets))
+			for key := range sets {
+				keys = append(keys, key)
+			}
+			s.reportBatch(keys, batcher.SetBatch(sets))
+		}
+		if len(deletes) > 0 {
+			s.reportBatch(deletes, batcher.DeleteBatch(deletes))
+		}
+		return
+	}
+
+	for key, e := range sets {
+		if err := s.backing.Set(key, e); err != nil {
+			s.reportError(key, err)
+		}
+	}
+	for _, key := range deletes {
+		if err := s.backing.Delete(key); err != nil {
+			s.reportError(key, err)
+		}
+	}
+}
+
+// reportBatch reports the keys a batch operation failed to apply
+// Errors without per-key detail are reported for every key in the batch
+func (s *Store) reportBatch(keys []string, err error) {
+	if err == nil {
+		return
+	}
+
+	var batchErr *store.BatchError
+	if errors.As(err, &batchErr) {
+		for key, keyErr := range batchErr.Errors {
+			s.reportError(key, keyErr)
+		}
+		return
+	}
+	for _, key := range keys {
+		s.reportError(key, err)
+	}
+}
+
+// reportDropped reports writes discarded by OverflowDropOldest
+func (s *Store) reportDropped(keys []string) {
+	for _, key := range keys {
+		s.reportError(key, ErrQueueFull)
+	}
+}
+
+// reportError calls the OnError callback, if any
+func (s *Store) reportError(key string, err error) {
+	if s.onError != nil {
+		s.onError(key, err)
+	}
+}
+
+// addDepth adjusts the queue depth and reports it to the depth callback
+func (s *Store) addDepth(delta int) {
+	if delta == 0 {
+		return
+	}
+	depth := int(s.depth.Add(int64(delta)))
+
+	s.mu.RLock()
+	callback := s.depthFunc
+	s.mu.RUnlock()
+
+	if callback != nil {
+		callback(depth)
+	}
+}
+
+// QueueDepth returns the number of writes not yet applied to the backing store
+func (s *Store) QueueDepth() int {
+	return int(s.depth.Load())
+}
+
+// SetQueueDepthCallback sets a callback called with the queue depth whenever it changes
+func (s *Store) SetQueueDepthCallback(callback func(depth int)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.depthFunc = callback
+}
+
+// Flush blocks until the queue is empty and every write has been applied to
+// the backing store, or ctx is done
+func (s *Store) Flush(ctx context.Context) error {
+	stop := context.AfterFunc(ctx, func() {
+		for _, q := range s.queues {
+			q.mu.Lock()
+			q.cond.Broadcast()
+			q.mu.Unlock()
+		}
+	})
+	defer stop()
+
+	for _, q := range s.queues {
+		q.mu.Lock()
+		for (len(q.order) > 0 || q.flushing != nil) && ctx.Err() == nil {
+			q.cond.Wait()
+		}
+		q.mu.Unlock()
+	}
+	return ctx.Err()
+}
+
+// Keys returns the keys in the backing store combined with queued writes
+func (s *Store) Keys() []string {
+	keys := make(map[string]struct{})
+	for _, key := range s.backing.Keys() {
+		keys[key] = struct{}{}
+	}
+
+	apply := func(writes map[string]*entry.Entry) {
+		for key, e := range writes {
+			if e == nil || e.IsExpired() {
+				delete(keys, key)
+			} else {
+				keys[key] = struct{}{}
+			}
+		}
+	}
+	for _, q := range s.queues {
+		q.mu.Lock()
+		apply(q.flushing)
+		apply(q.pending) // Pending writes are newer than the ones being flushed
+		q.mu.Unlock()
+	}
+
+	result := make([]string, 0, len(keys))
+	for key := range keys {
+		result = append(result, key)
+	}
+	return result
+}
+
+// Len returns the number of keys in the backing store combined with queued writes
+func (s *Store) Len() int {
+	return len(s.Keys())
+}
+
+// Clear discards queued writes, waits for writes being flushed and clears the
+// backing store. Workers are paused meanwhile so that writes queued during Clear
+// are applied after it
+func (s *Store) Clear() error {
+	s.clearMu.Lock()
+	defer s.clearMu.Unlock()
+
+	discarded := 0
+	for _, q := range s.queues {
+		q.mu.Lock()
+		q.paused = true
+		discarded += len(q.order)
+		q.order = nil
+		clear(q.pending)
+		clear(q.deletes)
+		for q.flushing != nil {
+			q.cond.Wait()
+		}
+		q.mu.Unlock()
+	}
+	s.addDepth(-discarded)
+
+	err := s.backing.Clear()
+
+	for _, q := range s.queues {
+		q.mu.Lock()
+		q.paused = false
+		q.cond.Broadcast()
+		q.mu.Unlock()
+	}
+	return err
+}
+
+// Close rejects further writes, waits for the queue to drain and closes the backing store
+func (s *Store) Close() error {
+	if !s.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	for _, q := range s.queues {
+		q.mu.Lock()
+		q.closed = true
+		q.cond.Broadcast()
+		q.mu.Unlock()
+	}
+	s.wg.Wait()
+
+	return s.backing.Close()
+}
+
+// Ping checks the backing store, failing once the store is closed
+func (s *Store) Ping(ctx context.Context) error {
+	if s.closed.Load() {
+		return ErrClosed
+	}
+	if checker, ok := s.backing.(store.HealthChecker); ok {
+		return checker.Ping(ctx)
+	}
+	return nil
+}
+
+// SetCleanupCallback sets the TTL cleanup callback on the backing store
+func (s *Store) SetCleanupCallback(callback store.EvictCallback) {
+	if ttlStore, ok := s.backing.(store.TTLStore); ok {
+		ttlStore.SetCleanupCallback(callback)
+	}
+}
+
+// Cleanup removes expired entries from the backing store
+// Expired queued writes are turned into deletes when they are flushed
+func (s *Store) Cleanup() int {
+	if ttlStore, ok := s.backing.(store.TTLStore); ok {
+		return ttlStore.Cleanup()
+	}
+	return 0
+}
+
+// UpdateTTL changes the expiration of key. A queued write is replaced by a copy
+// with the new expiration; otherwise the backing store is updated directly
+func (s *Store) UpdateTTL(key string, ttl time.Duration) bool {
+	q := s.queueFor(key)
+	q.mu.Lock()
+	e, queued := q.lookup(key)
+	if queued {
+		defer q.mu.Unlock()
+		if e == nil || e.IsExpired() {
+			return false
+		}
+
+		updated := withTTL(e, ttl)
+		if _, ok := q.pending[key]; ok {
+			q.pending[key] = updated
+			return true
+		}
+		// The write is being flushed; queue the update behind it. This may
+		// exceed the queue's capacity by one entry
+		q.push(key, updated)
+		s.addDepth(1)
+		return true
+	}
+	q.mu.Unlock()
+
+	if ttlStore, ok := s.backing.(store.TTLStore); ok {
+		return ttlStore.UpdateTTL(key, ttl)
+	}
+	return false
+}
+
+// withTTL returns a copy of e that expires ttl from now
+// Queued entries may be read by a worker, so they are never modified in place
+func withTTL(e *entry.Entry, ttl time.Duration) *entry.Entry {
+	updated := &entry.Entry{
+		Value:          e.Value,
+		CreatedAt:      e.CreatedAt,
+		AccessedAt:     e.LastAccess(),
+		ValueSize:      e.ValueSize,
+		Version:        e.Version,
+		IsCompressed:   e.IsCompressed,
+		CompressorName: e.CompressorName,
+		CodecName:      e.CodecName,
+		DictionaryID:   e.DictionaryID,
+		OriginalSize:   e.OriginalSize,
+		CompressedSize: e.CompressedSize,
+	}
+	updated.UpdateExpiry(ttl)
+	return updated
+}
+
+// Ensure Store implements the required interfaces
+var (
+	_ store.Store            = (*Store)(nil)
+	_ store.TTLStore         = (*Store)(nil)
+	_ store.HealthChecker    = (*Store)(nil)
+	_ store.WriteBehindStore = (*Store)(nil)
+)
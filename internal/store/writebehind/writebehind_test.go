@@ -0,0 +1,432 @@
+package writebehind
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/internal/eviction"
+	"github.com/1mb-dev/obcache-go/v2/internal/store/memory"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+	"github.com/1mb-dev/obcache-go/v2/pkg/store"
+)
+
+// gatedStore is a memory store that logs writes and holds each one until gate is closed
+type gatedStore struct {
+	*memory.StrategyStore
+	gate chan struct{}
+	mu   sync.Mutex
+	ops  []string
+}
+
+func newGatedStore(t *testing.T, gated bool) *gatedStore {
+	t.Helper()
+	memStore, err := memory.NewWithStrategy(eviction.Config{Type: eviction.LRU, Capacity: 1000})
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	s := &gatedStore{StrategyStore: memStore}
+	if gated {
+		s.gate = make(chan struct{})
+	}
+	return s
+}
+
+func (s *gatedStore) Set(key string, e *entry.Entry) error {
+	s.wait()
+	s.log("set " + key)
+	return s.StrategyStore.Set(key, e)
+}
+
+func (s *gatedStore) Delete(key string) error {
+	s.wait()
+	s.log("delete " + key)
+	return s.StrategyStore.Delete(key)
+}
+
+func (s *gatedStore) wait() {
+	if s.gate != nil {
+		<-s.gate
+	}
+}
+
+func (s *gatedStore) log(op string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ops = append(s.ops, op)
+}
+
+func (s *gatedStore) opLog() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.ops...)
+}
+
+func newTestStore(t *testing.T, backing store.Store, config *Config) *Store {
+	t.Helper()
+	config.Backing = backing
+	s, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create write-behind store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+// waitForWorker waits until the single worker has taken every queued write
+func waitForWorker(t *testing.T, s *Store) {
+	t.Helper()
+	q := s.queues[0]
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		q.mu.Lock()
+		idle := len(q.order) == 0 && q.flushing != nil
+		q.mu.Unlock()
+		if idle {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("Timed out waiting for the worker to start flushing")
+}
+
+func flush(t *testing.T, s *Store) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Flush(ctx); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+}
+
+func TestWriteBehindReadsQueuedWrites(t *testing.T) {
+	backing := newGatedStore(t, true)
+	s := newTestStore(t, backing, &Config{Workers: 1})
+
+	if err := s.Set("key", entry.New("value", time.Hour)); err != nil {
+		t.Fatalf("Failed to set entry: %v", err)
+	}
+	if e, found := s.Get("key"); !found || e.Value != "value" {
+		t.Fatalf("Expected to read the queued write, got %v (found=%v)", e, found)
+	}
+	if _, found := backing.Peek("key"); found {
+		t.Fatal("Expected the backing store to be written asynchronously")
+	}
+	if keys := s.Keys(); len(keys) != 1 || keys[0] != "key" {
+		t.Errorf("Expected Keys to include the queued write, got %v", keys)
+	}
+	if s.QueueDepth() != 1 {
+		t.Errorf("Expected queue depth 1, got %d", s.QueueDepth())
+	}
+
+	close(backing.gate)
+	flush(t, s)
+	if e, found := backing.Peek("key"); !found || e.Value != "value" {
+		t.Errorf("Expected the write to reach the backing store, got %v (found=%v)", e, found)
+	}
+	if s.QueueDepth() != 0 {
+		t.Errorf("Expected an empty queue after Flush, got %d", s.QueueDepth())
+	}
+}
+
+func TestWriteBehindDeleteOrdering(t *testing.T) {
+	backing := newGatedStore(t, true)
+	s := newTestStore(t, backing, &Config{Workers: 1, BatchSize: 1})
+
+	_ = s.Set("key", entry.New("value", time.Hour))
+	waitForWorker(t, s)
+
+	// The Set is being flushed; the Delete queued behind it must win
+	_ = s.Delete("key")
+	if _, found := s.Get("key"); found {
+		t.Error("Expected the queued Delete to hide the Set being flushed")
+	}
+
+	close(backing.gate)
+	flush(t, s)
+	if _, found := backing.Peek("key"); found {
+		t.Error("Expected the Delete to be applied after the Set")
+	}
+	if ops := backing.opLog(); len(ops) != 2 || ops[0] != "set key" || ops[1] != "delete key" {
+		t.Errorf("Expected set then delete, got %v", ops)
+	}
+}
+
+func TestWriteBehindCoalescesQueuedWrites(t *testing.T) {
+	backing := newGatedStore(t, true)
+	s := newTestStore(t, backing, &Config{Workers: 1, BatchSize: 1})
+
+	_ = s.Set("first", entry.NewWithoutTTL(0))
+	waitForWorker(t, s)
+	for i := 1; i <= 3; i++ {
+		_ = s.Set("key", entry.NewWithoutTTL(i))
+	}
+	if s.QueueDepth() != 2 {
+		t.Errorf("Expected repeated writes to one key to share a queue slot, got depth %d", s.QueueDepth())
+	}
+
+	close(backing.gate)
+	flush(t, s)
+	if e, _ := backing.Peek("key"); e == nil || e.Value != 3 {
+		t.Errorf("Expected the last write to be flushed, got %v", e)
+	}
+	if ops := backing.opLog(); len(ops) != 2 {
+		t.Errorf("Expected one flush per key, got %v", ops)
+	}
+}
+
+func TestWriteBehindOverflow(t *testing.T) {
+	t.Run("error", func(t *testing.T) {
+		backing := newGatedStore(t, true)
+		s := newTestStore(t, backing, &Config{Workers: 1, QueueSize: 1, BatchSize: 1, Overflow: OverflowError})
+
+		_ = s.Set("a", entry.NewWithoutTTL("a"))
+		waitForWorker(t, s)
+		if err := s.Set("b", entry.NewWithoutTTL("b")); err != nil {
+			t.Fatalf("Expected room for one queued write, got %v", err)
+		}
+		if err := s.Set("c", entry.NewWithoutTTL("c")); !errors.Is(err, ErrQueueFull) {
+			t.Fatalf("Expected ErrQueueFull, got %v", err)
+		}
+		if err := s.Set("b", entry.NewWithoutTTL("b2")); err != nil {
+			t.Errorf("Expected a write to a queued key to fit, got %v", err)
+		}
+		close(backing.gate)
+	})
+
+	t.Run("drop oldest", func(t *testing.T) {
+		backing := newGatedStore(t, true)
+		var mu sync.Mutex
+		var dropped []string
+		s := newTestStore(t, backing, &Config{
+			Workers: 1, QueueSize: 1, BatchSize: 1, Overflow: OverflowDropOldest,
+			OnError: func(key string, err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				if errors.Is(err, ErrQueueFull) {
+					dropped = append(dropped, key)
+				}
+			},
+		})
+
+		_ = s.Set("a", entry.NewWithoutTTL("a"))
+		waitForWorker(t, s)
+		_ = s.Set("b", entry.NewWithoutTTL("b"))
+		if err := s.Set("c", entry.NewWithoutTTL("c")); err != nil {
+			t.Fatalf("Expected the oldest write to make room, got %v", err)
+		}
+
+		close(backing.gate)
+		flush(t, s)
+		mu.Lock()
+		defer mu.Unlock()
+		if len(dropped) != 1 || dropped[0] != "b" {
+			t.Errorf("Expected b to be dropped, got %v", dropped)
+		}
+		if _, found := backing.Peek("b"); found {
+			t.Error("Expected the dropped write not to reach the backing store")
+		}
+		if _, found := backing.Peek("c"); !found {
+			t.Error("Expected c to be flushed")
+		}
+	})
+
+	t.Run("drop oldest keeps deletes", func(t *testing.T) {
+		backing := newGatedStore(t, true)
+		_ = backing.StrategyStore.Set("x", entry.NewWithoutTTL("x"))
+		var mu sync.Mutex
+		var dropped []string
+		s := newTestStore(t, backing, &Config{
+			Workers: 1, QueueSize: 2, BatchSize: 1, Overflow: OverflowDropOldest,
+			OnError: func(key string, _ error) {
+				mu.Lock()
+				defer mu.Unlock()
+				dropped = append(dropped, key)
+			},
+		})
+
+		_ = s.Set("a", entry.NewWithoutTTL("a"))
+		waitForWorker(t, s)
+		_ = s.Delete("x")
+		_ = s.Set("b", entry.NewWithoutTTL("b"))
+		_ = s.Set("c", entry.NewWithoutTTL("c")) // Drops b, not the older delete
+		_ = s.Delete("y")                        // Drops c
+
+		// With only deletes queued the write waits for room
+		done := make(chan error, 1)
+		go func() { done <- s.Set("d", entry.NewWithoutTTL("d")) }()
+		select {
+		case err := <-done:
+			t.Fatalf("Expected Set to wait behind queued deletes, got %v", err)
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		close(backing.gate)
+		if err := <-done; err != nil {
+			t.Fatalf("Expected the waiting Set to succeed, got %v", err)
+		}
+		flush(t, s)
+		mu.Lock()
+		defer mu.Unlock()
+		if !slices.Equal(dropped, []string{"b", "c"}) {
+			t.Errorf("Expected b and c to be dropped, got %v", dropped)
+		}
+		if _, found := backing.Peek("x"); found {
+			t.Error("Expected the queued delete to reach the backing store")
+		}
+		if _, found := backing.Peek("d"); !found {
+			t.Error("Expected d to be flushed")
+		}
+	})
+
+	t.Run("drop oldest restores a replaced delete", func(t *testing.T) {
+		backing := newGatedStore(t, true)
+		_ = backing.StrategyStore.Set("k", entry.NewWithoutTTL("old"))
+		var mu sync.Mutex
+		var dropped []string
+		s := newTestStore(t, backing, &Config{
+			Workers: 1, QueueSize: 2, BatchSize: 1, Overflow: OverflowDropOldest,
+			OnError: func(key string, _ error) {
+				mu.Lock()
+				defer mu.Unlock()
+				dropped = append(dropped, key)
+			},
+		})
+
+		_ = s.Set("a", entry.NewWithoutTTL("a"))
+		waitForWorker(t, s)
+		_ = s.Delete("k")
+		_ = s.Set("k", entry.NewWithoutTTL("new")) // Replaces the queued delete
+		_ = s.Set("b", entry.NewWithoutTTL("b"))
+		_ = s.Set("c", entry.NewWithoutTTL("c")) // Drops the Set of k, then b
+
+		close(backing.gate)
+		flush(t, s)
+		if e, found := s.Get("k"); found {
+			t.Errorf("Expected the restored delete to remove k, got %v", e.Value)
+		}
+		if _, found := backing.Peek("c"); !found {
+			t.Error("Expected c to be flushed")
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if !slices.Equal(dropped, []string{"k", "b"}) {
+			t.Errorf("Expected the Set of k and b to be dropped, got %v", dropped)
+		}
+		if depth := s.depth.Load(); depth != 0 {
+			t.Errorf("Expected an empty queue, got depth %d", depth)
+		}
+	})
+
+	t.Run("block", func(t *testing.T) {
+		backing := newGatedStore(t, true)
+		s := newTestStore(t, backing, &Config{Workers: 1, QueueSize: 1, BatchSize: 1})
+
+		_ = s.Set("a", entry.NewWithoutTTL("a"))
+		waitForWorker(t, s)
+		_ = s.Set("b", entry.NewWithoutTTL("b"))
+
+		done := make(chan error, 1)
+		go func() { done <- s.Set("c", entry.NewWithoutTTL("c")) }()
+		select {
+		case err := <-done:
+			t.Fatalf("Expected Set to block on a full queue, got %v", err)
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		close(backing.gate)
+		if err := <-done; err != nil {
+			t.Fatalf("Expected blocked Set to succeed, got %v", err)
+		}
+		flush(t, s)
+		if backing.Len() != 3 {
+			t.Errorf("Expected all 3 writes to be flushed, got %d", backing.Len())
+		}
+	})
+}
+
+func TestWriteBehindCloseDrainsQueue(t *testing.T) {
+	backing := newGatedStore(t, false)
+	s, err := New(&Config{Backing: backing, Workers: 2, BatchSize: 8})
+	if err != nil {
+		t.Fatalf("Failed to create write-behind store: %v", err)
+	}
+
+	var depths []int
+	var mu sync.Mutex
+	s.SetQueueDepthCallback(func(depth int) {
+		mu.Lock()
+		defer mu.Unlock()
+		depths = append(depths, depth)
+	})
+
+	for i := range 100 {
+		_ = s.Set(string(rune('a'+i%26))+string(rune('0'+i/26)), entry.NewWithoutTTL(i))
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Failed to close store: %v", err)
+	}
+
+	if ops := backing.opLog(); len(ops) != 100 {
+		t.Errorf("Expected Close to flush all 100 writes, got %d", len(ops))
+	}
+	if err := s.Set("late", entry.NewWithoutTTL(1)); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed after Close, got %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(depths) == 0 || depths[len(depths)-1] != 0 {
+		t.Errorf("Expected the depth callback to end at 0, got %v", depths)
+	}
+}
+
+func TestWriteBehindClearDiscardsQueuedWrites(t *testing.T) {
+	backing := newGatedStore(t, true)
+	s := newTestStore(t, backing, &Config{Workers: 1, BatchSize: 1})
+
+	_ = s.Set("a", entry.NewWithoutTTL("a"))
+	waitForWorker(t, s)
+	_ = s.Set("b", entry.NewWithoutTTL("b"))
+
+	cleared := make(chan error, 1)
+	go func() { cleared <- s.Clear() }()
+	time.Sleep(10 * time.Millisecond)
+	close(backing.gate) // Lets the in-flight write finish so Clear can proceed
+
+	if err := <-cleared; err != nil {
+		t.Fatalf("Failed to clear store: %v", err)
+	}
+	flush(t, s)
+	if backing.Len() != 0 || s.Len() != 0 || s.QueueDepth() != 0 {
+		t.Errorf("Expected an empty store after Clear, got %d backing, %d total, depth %d",
+			backing.Len(), s.Len(), s.QueueDepth())
+	}
+	if ops := backing.opLog(); len(ops) != 1 || ops[0] != "set a" {
+		t.Errorf("Expected only the in-flight write to reach the backing store, got %v", ops)
+	}
+}
+
+func TestWriteBehindUpdateTTL(t *testing.T) {
+	backing := newGatedStore(t, true)
+	s := newTestStore(t, backing, &Config{Workers: 1})
+
+	_ = s.Set("key", entry.New("value", time.Hour))
+	if !s.UpdateTTL("key", time.Minute) {
+		t.Fatal("Expected UpdateTTL to update the queued write")
+	}
+	if e, _ := s.Get("key"); e == nil || e.TTL() > time.Minute {
+		t.Errorf("Expected the queued write to expire within a minute, got %v", e)
+	}
+
+	close(backing.gate)
+	flush(t, s)
+	if e, _ := backing.Peek("key"); e == nil || e.TTL() > time.Minute {
+		t.Errorf("Expected the new TTL to be flushed, got %v", e)
+	}
+	if !s.UpdateTTL("key", time.Hour) {
+		t.Error("Expected UpdateTTL to update the backing store once flushed")
+	}
+}
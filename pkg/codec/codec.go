@@ -0,0 +1,196 @@
+// Package codec defines how cached values are encoded to bytes by stores that
+// serialize them, such as Redis, and by the compression step.
+//
+// Each codec has a name that is stored with the encoded value, so an instance
+// can decode entries written with another codec as long as that codec is
+// registered, and reports ErrUnknownCodec otherwise. JSON, Gob and Raw are
+// registered by default.
+package codec
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Codec encodes values to bytes and back
+type Codec interface {
+	// Marshal encodes v
+	Marshal(v any) ([]byte, error)
+
+	// Unmarshal decodes data into the value v points to
+	Unmarshal(data []byte, v any) error
+
+	// Name identifies the codec in stored entries. It must be unique and stable
+	Name() string
+}
+
+// ErrUnknownCodec is returned when an entry was encoded with a codec that is
+// not registered
+var ErrUnknownCodec = errors.New("unknown codec")
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Codec{}
+)
+
+func init() {
+	Register(JSON{})
+	Register(Gob{})
+	Register(Raw{})
+}
+
+// Register makes c available to Lookup, replacing any codec with the same name
+func Register(c Codec) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[c.Name()] = c
+}
+
+// Lookup returns the registered codec with the given name
+// Returns an error wrapping ErrUnknownCodec if there is none
+func Lookup(name string) (Codec, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	c, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownCodec, name)
+	}
+	return c, nil
+}
+
+// OrDefault returns c, or JSON if c is nil
+func OrDefault(c Codec) Codec {
+	if c == nil {
+		return JSON{}
+	}
+	return c
+}
+
+// JSON encodes values with encoding/json. Values decoded into an interface
+// come back as JSON types (e.g. numbers as float64 and structs as map[string]any)
+type JSON struct{}
+
+// Marshal encodes v as JSON
+func (JSON) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal decodes JSON data into v
+func (JSON) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// Name returns "json"
+func (JSON) Name() string {
+	return "json"
+}
+
+// Gob encodes values with encoding/gob, keeping their concrete Go types.
+// Values are sent as interfaces, so types other than the basic ones must be
+// registered with gob.Register by every instance that writes or reads them
+type Gob struct{}
+
+// Marshal encodes v together with its concrete type
+func (Gob) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes data into v, which must point to a variable the decoded
+// value's type is assignable to, such as *any or a pointer to that type
+func (Gob) Unmarshal(data []byte, v any) error {
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Pointer || target.IsNil() {
+		return fmt.Errorf("gob: cannot decode into non-pointer %T", v)
+	}
+
+	var decoded any
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&decoded); err != nil {
+		return err
+	}
+
+	elem := target.Elem()
+	if decoded == nil {
+		elem.SetZero()
+		return nil
+	}
+	value := reflect.ValueOf(decoded)
+	if !value.Type().AssignableTo(elem.Type()) {
+		return fmt.Errorf("gob: cannot decode %s into %s", value.Type(), elem.Type())
+	}
+	elem.Set(value)
+	return nil
+}
+
+// Name returns "gob"
+func (Gob) Name() string {
+	return "gob"
+}
+
+// RawValue is implemented by values that are already serialized, such as
+// encoded protobuf messages. Raw stores the bytes RawBytes returns unchanged
+type RawValue interface {
+	RawBytes() []byte
+}
+
+// RawBytes returns the bytes of a []byte or RawValue value
+func RawBytes(v any) ([]byte, bool) {
+	switch v := v.(type) {
+	case []byte:
+		return v, true
+	case RawValue:
+		return v.RawBytes(), true
+	}
+	return nil, false
+}
+
+// Raw passes already-serialized bytes through unchanged. It is used instead of
+// the configured codec for []byte and RawValue values, which read back as []byte
+type Raw struct{}
+
+// Marshal returns the bytes of v, which must be a []byte or RawValue
+func (Raw) Marshal(v any) ([]byte, error) {
+	data, ok := RawBytes(v)
+	if !ok {
+		return nil, fmt.Errorf("raw: cannot encode %T", v)
+	}
+	return data, nil
+}
+
+// Unmarshal copies data into v, which must be a *[]byte or *any
+func (Raw) Unmarshal(data []byte, v any) error {
+	data = bytes.Clone(data)
+	if data == nil {
+		data = []byte{}
+	}
+	switch target := v.(type) {
+	case *[]byte:
+		*target = data
+	case *any:
+		*target = data
+	default:
+		return fmt.Errorf("raw: cannot decode into %T", v)
+	}
+	return nil
+}
+
+// Name returns "raw"
+func (Raw) Name() string {
+	return "raw"
+}
+
+// Ensure interfaces are implemented
+var (
+	_ Codec = JSON{}
+	_ Codec = Gob{}
+	_ Codec = Raw{}
+)
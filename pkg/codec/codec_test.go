@@ -0,0 +1,115 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"testing"
+)
+
+type point struct {
+	X, Y int
+}
+
+func init() {
+	gob.Register(point{})
+}
+
+func TestJSONCodec(t *testing.T) {
+	data, err := JSON{}.Marshal(map[string]int{"a": 1})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var value any
+	if err := (JSON{}).Unmarshal(data, &value); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if m, ok := value.(map[string]any); !ok || m["a"] != float64(1) {
+		t.Errorf("Expected a JSON map, got %T %v", value, value)
+	}
+}
+
+func TestGobCodecKeepsConcreteTypes(t *testing.T) {
+	data, err := Gob{}.Marshal(point{X: 1, Y: 2})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var value any
+	if err := (Gob{}).Unmarshal(data, &value); err != nil {
+		t.Fatalf("Unmarshal into interface failed: %v", err)
+	}
+	if p, ok := value.(point); !ok || p != (point{X: 1, Y: 2}) {
+		t.Errorf("Expected point{1 2}, got %T %v", value, value)
+	}
+
+	var p point
+	if err := (Gob{}).Unmarshal(data, &p); err != nil || p != (point{X: 1, Y: 2}) {
+		t.Errorf("Expected to decode into the concrete type, got %v (err=%v)", p, err)
+	}
+
+	var s string
+	if err := (Gob{}).Unmarshal(data, &s); err == nil {
+		t.Error("Expected an error decoding a point into a string")
+	}
+}
+
+func TestGobCodecRequiresRegisteredTypes(t *testing.T) {
+	type unregistered struct{ A int }
+	if _, err := (Gob{}).Marshal(unregistered{A: 1}); err == nil {
+		t.Error("Expected an error for an unregistered type")
+	}
+}
+
+type rawMessage struct{ data []byte }
+
+func (m rawMessage) RawBytes() []byte { return m.data }
+
+func TestRawCodec(t *testing.T) {
+	original := []byte{0x00, 0xff, '"', 0x1f}
+	data, err := Raw{}.Marshal(original)
+	if err != nil || !bytes.Equal(data, original) {
+		t.Fatalf("Expected the bytes unchanged, got %v (err=%v)", data, err)
+	}
+
+	var decoded any
+	if err := (Raw{}).Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if b, ok := decoded.([]byte); !ok || !bytes.Equal(b, original) {
+		t.Errorf("Expected []byte back, got %T %v", decoded, decoded)
+	}
+	decoded.([]byte)[0] = 1
+	if data[0] != 0x00 {
+		t.Error("Expected Unmarshal to copy the bytes")
+	}
+
+	if data, err := (Raw{}).Marshal(rawMessage{data: []byte("pre-encoded")}); err != nil || string(data) != "pre-encoded" {
+		t.Errorf("Expected the RawValue bytes, got %q (err=%v)", data, err)
+	}
+	var empty []byte
+	if err := (Raw{}).Unmarshal(nil, &empty); err != nil || empty == nil {
+		t.Errorf("Expected an empty non-nil slice, got %v (err=%v)", empty, err)
+	}
+
+	if _, err := (Raw{}).Marshal("text"); err == nil {
+		t.Error("Expected an error encoding a string")
+	}
+	var s string
+	if err := (Raw{}).Unmarshal(data, &s); err == nil {
+		t.Error("Expected an error decoding into a string")
+	}
+}
+
+func TestLookup(t *testing.T) {
+	for _, name := range []string{"json", "gob", "raw"} {
+		if c, err := Lookup(name); err != nil || c.Name() != name {
+			t.Errorf("Expected built-in codec %q, got %v (err=%v)", name, c, err)
+		}
+	}
+
+	if _, err := Lookup("protobuf"); !errors.Is(err, ErrUnknownCodec) {
+		t.Errorf("Expected ErrUnknownCodec, got %v", err)
+	}
+}
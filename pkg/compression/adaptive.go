@@ -0,0 +1,97 @@
+package compression
+
+import (
+	"strings"
+	"sync"
+)
+
+const (
+	// DefaultAdaptiveMinRatio is the compressed/original size at or below which a
+	// value counts as compressible
+	DefaultAdaptiveMinRatio = 0.9
+
+	// DefaultAdaptiveResampleEvery is how many writes to an incompressible prefix
+	// pass before one is compressed again
+	DefaultAdaptiveResampleEvery = 100
+
+	// maxAdaptivePrefixes bounds the prefixes tracked as incompressible; values
+	// under further prefixes are always compressed
+	maxAdaptivePrefixes = 1024
+)
+
+// Adaptive predicts, per key prefix, whether compressing a value is worth it.
+// A prefix is the key up to its first ':' (the whole key if there is none).
+// Prefixes whose last compressed value did not reach the minimum ratio are
+// skipped, except for one write in every resampleEvery, so they are
+// compressed again once their values start to shrink
+type Adaptive struct {
+	minRatio      float64
+	resampleEvery int
+
+	mu sync.Mutex
+	// skips counts the writes skipped since the last sample, per incompressible prefix
+	skips map[string]int
+}
+
+// NewAdaptive creates an Adaptive predictor; non-positive arguments select
+// DefaultAdaptiveMinRatio and DefaultAdaptiveResampleEvery
+func NewAdaptive(minRatio float64, resampleEvery int) *Adaptive {
+	if minRatio <= 0 {
+		minRatio = DefaultAdaptiveMinRatio
+	}
+	if resampleEvery <= 0 {
+		resampleEvery = DefaultAdaptiveResampleEvery
+	}
+	return &Adaptive{
+		minRatio:      minRatio,
+		resampleEvery: resampleEvery,
+		skips:         make(map[string]int),
+	}
+}
+
+// ShouldCompress reports whether a value stored under key should be compressed
+func (a *Adaptive) ShouldCompress(key string) bool {
+	prefix := keyPrefix(key)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	skips, incompressible := a.skips[prefix]
+	if !incompressible {
+		return true
+	}
+	if skips+1 >= a.resampleEvery {
+		a.skips[prefix] = 0
+		return true
+	}
+	a.skips[prefix] = skips + 1
+	return false
+}
+
+// Record notes the sizes of a value stored under key before and after compression
+// Pass compressedSize equal to originalSize when compression did not shrink it
+func (a *Adaptive) Record(key string, originalSize, compressedSize int) {
+	if originalSize <= 0 {
+		return
+	}
+	prefix := keyPrefix(key)
+	incompressible := float64(compressedSize)/float64(originalSize) > a.minRatio
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !incompressible {
+		delete(a.skips, prefix)
+		return
+	}
+	if _, tracked := a.skips[prefix]; !tracked && len(a.skips) < maxAdaptivePrefixes {
+		a.skips[prefix] = 0
+	}
+}
+
+func keyPrefix(key string) string {
+	if i := strings.IndexByte(key, ':'); i >= 0 {
+		return key[:i]
+	}
+	return key
+}
@@ -0,0 +1,60 @@
+package compression
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestAdaptiveSkipsIncompressiblePrefixes(t *testing.T) {
+	a := NewAdaptive(0.9, 5)
+
+	if !a.ShouldCompress("img:1") {
+		t.Fatal("Expected unknown prefixes to be compressed")
+	}
+	a.Record("img:1", 1000, 990)
+	a.Record("doc:1", 1000, 200)
+
+	if !a.ShouldCompress("doc:2") {
+		t.Error("Expected a compressible prefix to keep being compressed")
+	}
+
+	// Four skips, then the fifth write re-samples
+	for i := range 4 {
+		if a.ShouldCompress(fmt.Sprintf("img:%d", i+2)) {
+			t.Fatalf("Expected write %d to an incompressible prefix to be skipped", i+1)
+		}
+	}
+	if !a.ShouldCompress("img:6") {
+		t.Fatal("Expected the fifth write to be re-sampled")
+	}
+
+	// A sample that compresses well clears the prediction
+	a.Record("img:6", 1000, 500)
+	if !a.ShouldCompress("img:7") {
+		t.Error("Expected the prefix to be compressed again after a good sample")
+	}
+}
+
+func TestAdaptiveDefaultsAndKeyPrefix(t *testing.T) {
+	a := NewAdaptive(0, 0)
+	if a.minRatio != DefaultAdaptiveMinRatio || a.resampleEvery != DefaultAdaptiveResampleEvery {
+		t.Errorf("Expected defaults, got ratio %v and resample every %d", a.minRatio, a.resampleEvery)
+	}
+
+	for key, prefix := range map[string]string{"img:1:thumb": "img", "plain": "plain", ":x": ""} {
+		if got := keyPrefix(key); got != prefix {
+			t.Errorf("Expected prefix %q for %q, got %q", prefix, key, got)
+		}
+	}
+
+	// Tracking is bounded, and untracked prefixes are always compressed
+	for i := range maxAdaptivePrefixes + 10 {
+		a.Record(fmt.Sprintf("p%d:x", i), 100, 100)
+	}
+	if len(a.skips) != maxAdaptivePrefixes {
+		t.Errorf("Expected %d tracked prefixes, got %d", maxAdaptivePrefixes, len(a.skips))
+	}
+	if !a.ShouldCompress(fmt.Sprintf("p%d:x", maxAdaptivePrefixes+5)) {
+		t.Error("Expected an untracked prefix to be compressed")
+	}
+}
@@ -0,0 +1,131 @@
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"testing"
+)
+
+// benchmarkSizes are the payload sizes compared by the compressor benchmarks
+var benchmarkSizes = []struct {
+	name string
+	size int
+}{
+	{"1KB", 1 << 10},
+	{"64KB", 64 << 10},
+	{"1MB", 1 << 20},
+}
+
+// jsonPayload returns about size bytes of JSON records with repeated field
+// names and varied values, like a typical cached API response
+func jsonPayload(size int) []byte {
+	rng := rand.New(rand.NewPCG(42, 42))
+	type record struct {
+		ID     int      `json:"id"`
+		Name   string   `json:"name"`
+		Email  string   `json:"email"`
+		Score  float64  `json:"score"`
+		Active bool     `json:"active"`
+		Tags   []string `json:"tags"`
+	}
+	tags := []string{"admin", "beta", "premium", "trial", "internal"}
+
+	encoded := []byte{'['}
+	for i := 0; len(encoded) < size; i++ {
+		if i > 0 {
+			encoded = append(encoded, ',')
+		}
+		item, _ := json.Marshal(record{
+			ID:     i,
+			Name:   fmt.Sprintf("user-%d", rng.IntN(100000)),
+			Email:  fmt.Sprintf("user%d@example.com", rng.IntN(100000)),
+			Score:  rng.Float64() * 100,
+			Active: rng.IntN(2) == 0,
+			Tags:   tags[:rng.IntN(len(tags))],
+		})
+		encoded = append(encoded, item...)
+	}
+	return encoded[:size]
+}
+
+// benchmarkCompressors are the compressors compared, at their default levels
+func benchmarkCompressors(b *testing.B) []Compressor {
+	zstd, err := NewZstdCompressor(-1)
+	if err != nil {
+		b.Fatalf("Failed to create zstd compressor: %v", err)
+	}
+	return []Compressor{
+		NewGzipCompressor(-1),
+		NewDeflateCompressor(-1),
+		NewLZ4Compressor(-1),
+		zstd,
+		NewBrotliCompressor(-1),
+	}
+}
+
+// BenchmarkCompress reports throughput and compression ratio for each algorithm
+// Run with: go test -bench=Compress -benchmem ./pkg/compression
+func BenchmarkCompress(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		payload := jsonPayload(size.size)
+		for _, compressor := range benchmarkCompressors(b) {
+			b.Run(fmt.Sprintf("%s/%s", compressor.Name(), size.name), func(b *testing.B) {
+				b.SetBytes(int64(len(payload)))
+				var compressed []byte
+				for b.Loop() {
+					compressed, _ = compressor.Compress(payload)
+				}
+				b.ReportMetric(float64(len(payload))/float64(len(compressed)), "ratio")
+			})
+		}
+	}
+}
+
+// BenchmarkDecompress reports decompression throughput for each algorithm
+func BenchmarkDecompress(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		payload := jsonPayload(size.size)
+		for _, compressor := range benchmarkCompressors(b) {
+			compressed, err := compressor.Compress(payload)
+			if err != nil {
+				b.Fatalf("%s compress failed: %v", compressor.Name(), err)
+			}
+			b.Run(fmt.Sprintf("%s/%s", compressor.Name(), size.name), func(b *testing.B) {
+				b.SetBytes(int64(len(payload)))
+				for b.Loop() {
+					if _, err := compressor.Decompress(compressed); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkGzipPooling compares the pooled GzipCompressor with creating a gzip
+// writer per call, as it did before writers were pooled
+// Run with: go test -bench=GzipPooling -benchmem ./pkg/compression
+func BenchmarkGzipPooling(b *testing.B) {
+	payload := jsonPayload(1 << 10)
+
+	b.Run("fresh", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			var buf bytes.Buffer
+			writer, _ := gzip.NewWriterLevel(&buf, gzip.DefaultCompression)
+			_, _ = writer.Write(payload)
+			_ = writer.Close()
+		}
+	})
+
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		compressor := NewGzipCompressor(gzip.DefaultCompression)
+		for b.Loop() {
+			_, _ = compressor.Compress(payload)
+		}
+	})
+}
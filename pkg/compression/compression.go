@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"compress/gzip"
 	"compress/zlib"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"sync"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/codec"
 )
 
 // Compressor defines the interface for cache value compression
@@ -28,8 +31,15 @@ const (
 	CompressorNone    CompressorType = "none"
 	CompressorGzip    CompressorType = "gzip"
 	CompressorDeflate CompressorType = "deflate"
+	CompressorZstd    CompressorType = "zstd"
+	CompressorLZ4     CompressorType = "lz4"
+	CompressorBrotli  CompressorType = "brotli"
 )
 
+// ErrUnknownCompressor is returned when an entry was compressed with an
+// algorithm Lookup does not know
+var ErrUnknownCompressor = errors.New("unknown compressor")
+
 // Config holds compression configuration
 type Config struct {
 	// Enabled determines whether compression is enabled
@@ -42,8 +52,37 @@ type Config struct {
 	// Values smaller than this will not be compressed to avoid overhead
 	MinSize int
 
-	// Level is the compression level (1-9 for gzip/deflate, -1 for default)
+	// Codec serializes values before they are compressed. Its name is stored with
+	// each entry, so entries written with another registered codec stay readable
+	// and entries written with an unregistered one fail with codec.ErrUnknownCodec
+	// Default: codec.JSON
+	Codec codec.Codec
+
+	// Level is the compression level (1-9 for gzip/deflate, 1-22 for zstd, -1 for default)
+	// For lz4, 1-9 select high compression mode and -1 the fast mode; for brotli
+	// it is the quality (0-11, -1 for 6)
 	Level int
+
+	// Adaptive skips compressing values whose key prefix (the text before the first
+	// ':') recently failed to shrink to AdaptiveMinRatio, such as images or encrypted
+	// blobs, and re-samples one write in AdaptiveResampleEvery. See Adaptive
+	Adaptive bool
+
+	// AdaptiveMinRatio is the compressed/original size a value must reach for its
+	// prefix to keep being compressed
+	// Default: 0.9
+	AdaptiveMinRatio float64
+
+	// AdaptiveResampleEvery is how many writes to a skipped prefix pass before one
+	// is compressed again to check whether its values have become compressible
+	// Default: 100
+	AdaptiveResampleEvery int
+
+	// Dictionary is a zstd dictionary used to compress values, which shrinks small
+	// values with a shared structure much further than compressing each alone. Its
+	// ID is stored with each entry; reading entries compressed with another
+	// dictionary fails with ErrDictionaryMismatch. Only supported by zstd
+	Dictionary []byte
 }
 
 // NewDefaultConfig creates a default compression configuration
@@ -53,6 +92,9 @@ func NewDefaultConfig() *Config {
 		Algorithm: CompressorGzip,
 		MinSize:   1024, // 1KB minimum
 		Level:     -1,   // Default level
+
+		AdaptiveMinRatio:      DefaultAdaptiveMinRatio,
+		AdaptiveResampleEvery: DefaultAdaptiveResampleEvery,
 	}
 }
 
@@ -74,6 +116,32 @@ func (c *Config) WithMinSize(minSize int) *Config {
 	return c
 }
 
+// WithCodec sets the codec values are serialized with before compression
+func (c *Config) WithCodec(valueCodec codec.Codec) *Config {
+	c.Codec = valueCodec
+	return c
+}
+
+// WithAdaptive sets whether compression is skipped for key prefixes whose values
+// do not compress
+func (c *Config) WithAdaptive(enabled bool) *Config {
+	c.Adaptive = enabled
+	return c
+}
+
+// WithAdaptiveMinRatio sets the compressed/original size a value must reach to
+// count as compressible in adaptive mode
+func (c *Config) WithAdaptiveMinRatio(ratio float64) *Config {
+	c.AdaptiveMinRatio = ratio
+	return c
+}
+
+// WithDictionary sets the zstd dictionary values are compressed with
+func (c *Config) WithDictionary(dictionary []byte) *Config {
+	c.Dictionary = dictionary
+	return c
+}
+
 // WithLevel sets the compression level
 func (c *Config) WithLevel(level int) *Config {
 	c.Level = level
@@ -104,8 +172,12 @@ func (n *NoOpCompressor) Name() string {
 }
 
 // GzipCompressor implements compression using gzip
+// Writers and readers are pooled, so it is safe for concurrent use without
+// allocating flate's buffers on every call
 type GzipCompressor struct {
-	level int
+	level   int
+	writers sync.Pool
+	readers sync.Pool
 }
 
 // NewGzipCompressor creates a new gzip compressor with the specified level
@@ -117,10 +189,16 @@ func NewGzipCompressor(level int) *GzipCompressor {
 func (g *GzipCompressor) Compress(data []byte) ([]byte, error) {
 	var buf bytes.Buffer
 
-	writer, err := gzip.NewWriterLevel(&buf, g.level)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create gzip writer: %w", err)
+	writer, ok := g.writers.Get().(*gzip.Writer)
+	if ok {
+		writer.Reset(&buf)
+	} else {
+		var err error
+		if writer, err = gzip.NewWriterLevel(&buf, g.level); err != nil {
+			return nil, fmt.Errorf("failed to create gzip writer: %w", err)
+		}
 	}
+	defer g.writers.Put(writer)
 
 	if _, err := writer.Write(data); err != nil {
 		_ = writer.Close() // Ignore error on cleanup path
@@ -136,13 +214,21 @@ func (g *GzipCompressor) Compress(data []byte) ([]byte, error) {
 
 // Decompress decompresses gzip data
 func (g *GzipCompressor) Decompress(compressed []byte) ([]byte, error) {
-	reader, err := gzip.NewReader(bytes.NewReader(compressed))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+	src := bytes.NewReader(compressed)
+
+	reader, ok := g.readers.Get().(*gzip.Reader)
+	if ok {
+		if err := reader.Reset(src); err != nil {
+			g.readers.Put(reader)
+			return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+		}
+	} else {
+		var err error
+		if reader, err = gzip.NewReader(src); err != nil {
+			return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+		}
 	}
-	defer func() {
-		_ = reader.Close() // Ignore error on defer
-	}()
+	defer g.readers.Put(reader)
 
 	data, err := io.ReadAll(reader)
 	if err != nil {
@@ -158,8 +244,11 @@ func (g *GzipCompressor) Name() string {
 }
 
 // DeflateCompressor implements compression using zlib/deflate
+// Writers and readers are pooled like GzipCompressor's
 type DeflateCompressor struct {
-	level int
+	level   int
+	writers sync.Pool
+	readers sync.Pool
 }
 
 // NewDeflateCompressor creates a new deflate compressor with the specified level
@@ -171,10 +260,16 @@ func NewDeflateCompressor(level int) *DeflateCompressor {
 func (d *DeflateCompressor) Compress(data []byte) ([]byte, error) {
 	var buf bytes.Buffer
 
-	writer, err := zlib.NewWriterLevel(&buf, d.level)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create deflate writer: %w", err)
+	writer, ok := d.writers.Get().(*zlib.Writer)
+	if ok {
+		writer.Reset(&buf)
+	} else {
+		var err error
+		if writer, err = zlib.NewWriterLevel(&buf, d.level); err != nil {
+			return nil, fmt.Errorf("failed to create deflate writer: %w", err)
+		}
 	}
+	defer d.writers.Put(writer)
 
 	if _, err := writer.Write(data); err != nil {
 		_ = writer.Close() // Ignore error on cleanup path
@@ -190,13 +285,21 @@ func (d *DeflateCompressor) Compress(data []byte) ([]byte, error) {
 
 // Decompress decompresses deflate data
 func (d *DeflateCompressor) Decompress(compressed []byte) ([]byte, error) {
-	reader, err := zlib.NewReader(bytes.NewReader(compressed))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create deflate reader: %w", err)
+	src := bytes.NewReader(compressed)
+
+	reader, ok := d.readers.Get().(io.ReadCloser)
+	if ok {
+		if err := reader.(zlib.Resetter).Reset(src, nil); err != nil {
+			d.readers.Put(reader)
+			return nil, fmt.Errorf("failed to create deflate reader: %w", err)
+		}
+	} else {
+		var err error
+		if reader, err = zlib.NewReader(src); err != nil {
+			return nil, fmt.Errorf("failed to create deflate reader: %w", err)
+		}
 	}
-	defer func() {
-		_ = reader.Close() // Ignore error on defer
-	}()
+	defer d.readers.Put(reader)
 
 	data, err := io.ReadAll(reader)
 	if err != nil {
@@ -217,6 +320,10 @@ func NewCompressor(config *Config) (Compressor, error) {
 		return NewNoOpCompressor(), nil
 	}
 
+	if config.Dictionary != nil && config.Algorithm != CompressorZstd {
+		return nil, fmt.Errorf("compression dictionaries require zstd, not %s", config.Algorithm)
+	}
+
 	switch config.Algorithm {
 	case CompressorNone:
 		return NewNoOpCompressor(), nil
@@ -224,39 +331,105 @@ func NewCompressor(config *Config) (Compressor, error) {
 		return NewGzipCompressor(config.Level), nil
 	case CompressorDeflate:
 		return NewDeflateCompressor(config.Level), nil
+	case CompressorZstd:
+		return NewZstdDictCompressor(config.Level, config.Dictionary)
+	case CompressorLZ4:
+		return NewLZ4Compressor(config.Level), nil
+	case CompressorBrotli:
+		return NewBrotliCompressor(config.Level), nil
 	default:
 		return nil, fmt.Errorf("unsupported compression algorithm: %s", config.Algorithm)
 	}
 }
 
-// SerializeAndCompress converts a value to bytes and compresses it if it meets size threshold
+var (
+	decompressorsMu sync.Mutex
+	decompressors   = make(map[string]Compressor)
+)
+
+// Lookup returns a compressor that decompresses data written by the compressor
+// with the given name, e.g. for entries written before the configured algorithm
+// changed. Compressors are created once with the default level and shared.
+// Returns an error wrapping ErrUnknownCompressor for other names
+func Lookup(name string) (Compressor, error) {
+	decompressorsMu.Lock()
+	defer decompressorsMu.Unlock()
+
+	if c, ok := decompressors[name]; ok {
+		return c, nil
+	}
+
+	var c Compressor
+	switch CompressorType(name) {
+	case CompressorNone:
+		c = NewNoOpCompressor()
+	case CompressorGzip:
+		c = NewGzipCompressor(-1)
+	case CompressorDeflate:
+		c = NewDeflateCompressor(-1)
+	case CompressorZstd:
+		var err error
+		if c, err = NewZstdCompressor(-1); err != nil {
+			return nil, err
+		}
+	case CompressorLZ4:
+		c = NewLZ4Compressor(-1)
+	case CompressorBrotli:
+		c = NewBrotliCompressor(-1)
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownCompressor, name)
+	}
+
+	decompressors[name] = c
+	return c, nil
+}
+
+// SerializeAndCompress converts a value to JSON and compresses it if it meets size threshold
 func SerializeAndCompress(value any, compressor Compressor, minSize int) ([]byte, bool, error) {
-	// Serialize the value to bytes using JSON encoding (more compatible than gob)
-	serialized, err := json.Marshal(value)
+	return SerializeAndCompressWith(codec.JSON{}, value, compressor, minSize)
+}
+
+// SerializeAndCompressWith converts a value to bytes with c and compresses it if
+// it meets size threshold
+func SerializeAndCompressWith(c codec.Codec, value any, compressor Compressor, minSize int) ([]byte, bool, error) {
+	data, _, compressed, err := SerializeAndCompressSized(c, value, compressor, minSize)
+	return data, compressed, err
+}
+
+// SerializeAndCompressSized is SerializeAndCompressWith that also returns the
+// serialized size before compression, which equals len(data) when the value
+// was not compressed
+func SerializeAndCompressSized(c codec.Codec, value any, compressor Compressor, minSize int) (data []byte, serializedSize int, compressed bool, err error) {
+	serialized, err := c.Marshal(value)
 	if err != nil {
-		return nil, false, fmt.Errorf("failed to serialize value: %w", err)
+		return nil, 0, false, fmt.Errorf("failed to serialize value: %w", err)
 	}
 
 	// Only compress if the serialized data meets the minimum size threshold
 	if len(serialized) < minSize {
-		return serialized, false, nil
+		return serialized, len(serialized), false, nil
 	}
 
-	compressed, err := compressor.Compress(serialized)
+	compressedData, err := compressor.Compress(serialized)
 	if err != nil {
-		return nil, false, fmt.Errorf("failed to compress data: %w", err)
+		return nil, 0, false, fmt.Errorf("failed to compress data: %w", err)
 	}
 
 	// Only use compression if it actually reduces size
-	if len(compressed) >= len(serialized) {
-		return serialized, false, nil
+	if len(compressedData) >= len(serialized) {
+		return serialized, len(serialized), false, nil
 	}
 
-	return compressed, true, nil
+	return compressedData, len(serialized), true, nil
 }
 
-// DecompressAndDeserialize decompresses and deserializes data back to a value
+// DecompressAndDeserialize decompresses and deserializes JSON data back to a value
 func DecompressAndDeserialize(data []byte, isCompressed bool, compressor Compressor, target any) error {
+	return DecompressAndDeserializeWith(codec.JSON{}, data, isCompressed, compressor, target)
+}
+
+// DecompressAndDeserializeWith decompresses data and decodes it with c
+func DecompressAndDeserializeWith(c codec.Codec, data []byte, isCompressed bool, compressor Compressor, target any) error {
 	var serialized []byte
 	var err error
 
@@ -269,8 +442,7 @@ func DecompressAndDeserialize(data []byte, isCompressed bool, compressor Compres
 		serialized = data
 	}
 
-	// Deserialize using JSON
-	if err := json.Unmarshal(serialized, target); err != nil {
+	if err := c.Unmarshal(serialized, target); err != nil {
 		return fmt.Errorf("failed to deserialize value: %w", err)
 	}
 
@@ -2,8 +2,12 @@ package compression
 
 import (
 	"bytes"
+	"fmt"
 	"strings"
+	"sync"
 	"testing"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/codec"
 )
 
 func TestNewDefaultConfig(t *testing.T) {
@@ -28,7 +32,9 @@ func TestConfigBuilder(t *testing.T) {
 		WithEnabled(true).
 		WithAlgorithm(CompressorDeflate).
 		WithMinSize(2048).
-		WithLevel(6)
+		WithLevel(6).
+		WithCodec(codec.Gob{}).
+		WithDictionary([]byte("dict"))
 
 	if !config.Enabled {
 		t.Error("Expected Enabled to be true")
@@ -42,6 +48,12 @@ func TestConfigBuilder(t *testing.T) {
 	if config.Level != 6 {
 		t.Errorf("Expected Level to be 6, got %d", config.Level)
 	}
+	if config.Codec == nil || config.Codec.Name() != "gob" {
+		t.Errorf("Expected the gob codec, got %v", config.Codec)
+	}
+	if string(config.Dictionary) != "dict" {
+		t.Errorf("Expected the dictionary to be set, got %q", config.Dictionary)
+	}
 }
 
 func TestNoOpCompressor(t *testing.T) {
@@ -158,6 +170,38 @@ func TestDeflateCompressor(t *testing.T) {
 	}
 }
 
+func TestPooledCompressorsConcurrent(t *testing.T) {
+	// Run with -race: writers and readers are reused across goroutines
+	for _, compressor := range []Compressor{NewGzipCompressor(6), NewDeflateCompressor(6)} {
+		t.Run(compressor.Name(), func(t *testing.T) {
+			var wg sync.WaitGroup
+			for i := range 16 {
+				wg.Go(func() {
+					original := []byte(strings.Repeat(fmt.Sprintf("goroutine %d payload ", i), 200+i*10))
+					for range 50 {
+						compressed, err := compressor.Compress(original)
+						if err != nil {
+							t.Errorf("Compress failed: %v", err)
+							return
+						}
+						decompressed, err := compressor.Decompress(compressed)
+						if err != nil || !bytes.Equal(decompressed, original) {
+							t.Errorf("Concurrent round trip failed (err=%v)", err)
+							return
+						}
+						// Corrupt input must not leave a broken reader in the pool
+						if _, err := compressor.Decompress(compressed[:len(compressed)/2]); err == nil {
+							t.Error("Expected truncated input to fail")
+							return
+						}
+					}
+				})
+			}
+			wg.Wait()
+		})
+	}
+}
+
 func TestNewCompressor(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -209,6 +253,36 @@ func TestNewCompressor(t *testing.T) {
 			expected: "deflate",
 			wantErr:  false,
 		},
+		{
+			name: "CompressorZstd returns Zstd",
+			config: &Config{
+				Enabled:   true,
+				Algorithm: CompressorZstd,
+				Level:     3,
+			},
+			expected: "zstd",
+			wantErr:  false,
+		},
+		{
+			name: "CompressorLZ4 returns LZ4",
+			config: &Config{
+				Enabled:   true,
+				Algorithm: CompressorLZ4,
+				Level:     -1,
+			},
+			expected: "lz4",
+			wantErr:  false,
+		},
+		{
+			name: "CompressorBrotli returns Brotli",
+			config: &Config{
+				Enabled:   true,
+				Algorithm: CompressorBrotli,
+				Level:     11,
+			},
+			expected: "brotli",
+			wantErr:  false,
+		},
 		{
 			name: "Invalid algorithm returns error",
 			config: &Config{
@@ -309,6 +383,25 @@ func TestSerializeAndCompress(t *testing.T) {
 	})
 }
 
+func TestSerializeAndCompressSized(t *testing.T) {
+	value := map[string]string{"message": strings.Repeat("large data ", 100)}
+	serialized, _ := codec.JSON{}.Marshal(value)
+	compressor := NewGzipCompressor(-1)
+
+	data, size, compressed, err := SerializeAndCompressSized(codec.JSON{}, value, compressor, 100)
+	if err != nil || !compressed {
+		t.Fatalf("Expected the value to be compressed (err=%v)", err)
+	}
+	if size != len(serialized) || len(data) >= size {
+		t.Errorf("Expected serialized size %d above the compressed size, got %d and %d", len(serialized), size, len(data))
+	}
+
+	data, size, compressed, err = SerializeAndCompressSized(codec.JSON{}, value, compressor, len(serialized)+1)
+	if err != nil || compressed || size != len(data) || !bytes.Equal(data, serialized) {
+		t.Errorf("Expected the serialized value below MinSize, got size %d (compressed=%v, err=%v)", size, compressed, err)
+	}
+}
+
 func TestDecompressAndDeserialize(t *testing.T) {
 	type TestData struct {
 		Message string `json:"message"`
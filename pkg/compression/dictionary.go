@@ -0,0 +1,42 @@
+package compression
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/klauspost/compress/dict"
+)
+
+// ErrDictionaryMismatch is returned when an entry was compressed with a
+// dictionary other than the configured one
+var ErrDictionaryMismatch = errors.New("compression dictionary mismatch")
+
+// DictionaryCompressor is implemented by compressors that can use a dictionary
+type DictionaryCompressor interface {
+	Compressor
+
+	// DictionaryID identifies the dictionary in stored entries (0 without one)
+	DictionaryID() uint32
+}
+
+// DictionaryIDOf returns the dictionary ID of c, or 0 if it uses none
+func DictionaryIDOf(c Compressor) uint32 {
+	if dc, ok := c.(DictionaryCompressor); ok {
+		return dc.DictionaryID()
+	}
+	return 0
+}
+
+// TrainZstdDictionary builds a zstd dictionary of at most maxSize bytes from
+// samples of typical serialized values. Dictionaries help most with many small
+// values that share structure, such as JSON documents of a few hundred bytes
+func TrainZstdDictionary(samples [][]byte, maxSize int) ([]byte, error) {
+	if maxSize <= 0 {
+		maxSize = 16 << 10
+	}
+	trained, err := dict.BuildZstdDict(samples, dict.Options{MaxDictSize: maxSize, HashBytes: 6})
+	if err != nil {
+		return nil, fmt.Errorf("failed to train zstd dictionary: %w", err)
+	}
+	return trained, nil
+}
@@ -0,0 +1,98 @@
+package compression
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// userSamples returns small JSON documents sharing the same structure
+func userSamples(n int) [][]byte {
+	samples := make([][]byte, n)
+	for i := range samples {
+		samples[i] = fmt.Appendf(nil,
+			`{"id":%d,"name":"user-%d","email":"user-%d@example.com","role":"member","active":true,"preferences":{"theme":"dark","language":"en-US","notifications":{"email":true,"sms":false}}}`,
+			i, i*7, i*13)
+	}
+	return samples
+}
+
+func TestTrainZstdDictionary(t *testing.T) {
+	samples := userSamples(500)
+	dict, err := TrainZstdDictionary(samples, 4096)
+	if err != nil {
+		t.Fatalf("Failed to train dictionary: %v", err)
+	}
+	if len(dict) == 0 || len(dict) > 4096 {
+		t.Fatalf("Expected a dictionary of at most 4096 bytes, got %d", len(dict))
+	}
+
+	if _, err := TrainZstdDictionary(nil, 4096); err == nil {
+		t.Error("Expected training without samples to fail")
+	}
+}
+
+func TestZstdDictCompressor(t *testing.T) {
+	samples := userSamples(500)
+	dict, err := TrainZstdDictionary(samples, 4096)
+	if err != nil {
+		t.Fatalf("Failed to train dictionary: %v", err)
+	}
+
+	withDict, err := NewZstdDictCompressor(-1, dict)
+	if err != nil {
+		t.Fatalf("Failed to create dictionary compressor: %v", err)
+	}
+	if withDict.DictionaryID() == 0 {
+		t.Error("Expected a non-zero dictionary ID")
+	}
+	plain, _ := NewZstdCompressor(-1)
+	if DictionaryIDOf(plain) != 0 {
+		t.Errorf("Expected no dictionary ID without a dictionary, got %08x", DictionaryIDOf(plain))
+	}
+	if DictionaryIDOf(NewGzipCompressor(-1)) != 0 {
+		t.Error("Expected no dictionary ID for gzip")
+	}
+
+	value := userSamples(1001)[1000]
+	compressed, err := withDict.Compress(value)
+	if err != nil {
+		t.Fatalf("Compress failed: %v", err)
+	}
+	plainCompressed, _ := plain.Compress(value)
+	if len(compressed) >= len(plainCompressed) {
+		t.Errorf("Expected the dictionary to shrink the value further: %d >= %d bytes",
+			len(compressed), len(plainCompressed))
+	}
+
+	decompressed, err := withDict.Decompress(compressed)
+	if err != nil || !bytes.Equal(decompressed, value) {
+		t.Fatalf("Expected the value to round trip, got %q (err=%v)", decompressed, err)
+	}
+	if _, err := plain.Decompress(compressed); err == nil {
+		t.Error("Expected decompressing without the dictionary to fail")
+	}
+
+	if _, err := NewZstdDictCompressor(-1, []byte("not a dictionary")); err == nil {
+		t.Error("Expected an invalid dictionary to be rejected")
+	}
+}
+
+func TestNewCompressorDictionary(t *testing.T) {
+	dict, err := TrainZstdDictionary(userSamples(500), 4096)
+	if err != nil {
+		t.Fatalf("Failed to train dictionary: %v", err)
+	}
+
+	c, err := NewCompressor(NewDefaultConfig().WithEnabled(true).WithAlgorithm(CompressorZstd).WithDictionary(dict))
+	if err != nil {
+		t.Fatalf("Failed to create compressor: %v", err)
+	}
+	if DictionaryIDOf(c) == 0 {
+		t.Error("Expected the compressor to use the dictionary")
+	}
+
+	if _, err := NewCompressor(NewDefaultConfig().WithEnabled(true).WithAlgorithm(CompressorGzip).WithDictionary(dict)); err == nil {
+		t.Error("Expected a dictionary with gzip to be rejected")
+	}
+}
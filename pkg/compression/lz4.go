@@ -0,0 +1,97 @@
+package compression
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/pierrec/lz4/v4"
+)
+
+// lz4MaxRatio bounds the size an LZ4 block can expand to, which keeps a corrupt
+// length header from allocating more than the block could hold
+const lz4MaxRatio = 255
+
+// errCorruptLZ4 is returned for data that was not written by LZ4Compressor
+var errCorruptLZ4 = errors.New("corrupt lz4 data")
+
+// lz4Block is the block compressor shared by the fast and high compression modes
+type lz4Block interface {
+	CompressBlock(src, dst []byte) (int, error)
+}
+
+// LZ4Compressor implements compression using the LZ4 block format, which is
+// much faster than gzip at a lower ratio. The output is the uncompressed length
+// as a uvarint followed by the block, or by the data itself when the block
+// would not be smaller, so incompressible values are never kept compressed
+type LZ4Compressor struct {
+	level int
+	pool  sync.Pool // Block compressors are not safe for concurrent use
+}
+
+// NewLZ4Compressor creates a new LZ4 compressor with the specified level
+// A level <= 0 uses the fast compressor; 1-9 use the high compression
+// compressor with increasing search depth
+func NewLZ4Compressor(level int) *LZ4Compressor {
+	l := &LZ4Compressor{level: min(level, 9)}
+	l.pool.New = func() any {
+		if l.level <= 0 {
+			return &lz4.Compressor{}
+		}
+		return &lz4.CompressorHC{Level: lz4.CompressionLevel(1 << (8 + l.level))}
+	}
+	return l
+}
+
+// Compress compresses data using LZ4
+func (l *LZ4Compressor) Compress(data []byte) ([]byte, error) {
+	out := binary.AppendUvarint(make([]byte, 0, binary.MaxVarintLen64+len(data)), uint64(len(data)))
+	header := len(out)
+
+	// A destination one byte short of the input makes incompressible data fail fast
+	if len(data) > 1 {
+		block := l.pool.Get().(lz4Block)
+		n, err := block.CompressBlock(data, out[header:header+len(data)-1])
+		l.pool.Put(block)
+		if err == nil && n > 0 {
+			return out[:header+n], nil
+		}
+	}
+
+	return append(out, data...), nil
+}
+
+// Decompress decompresses LZ4 data
+func (l *LZ4Compressor) Decompress(compressed []byte) ([]byte, error) {
+	size, header := binary.Uvarint(compressed)
+	if header <= 0 {
+		return nil, fmt.Errorf("%w: invalid length header", errCorruptLZ4)
+	}
+	payload := compressed[header:]
+
+	switch {
+	case size == uint64(len(payload)):
+		return append([]byte(nil), payload...), nil
+	case size < uint64(len(payload)) || size > uint64(len(payload))*lz4MaxRatio:
+		return nil, fmt.Errorf("%w: length %d does not match block of %d bytes", errCorruptLZ4, size, len(payload))
+	}
+
+	data := make([]byte, size)
+	n, err := lz4.UncompressBlock(payload, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode lz4 block: %w", err)
+	}
+	if n != len(data) {
+		return nil, fmt.Errorf("%w: decoded %d of %d bytes", errCorruptLZ4, n, size)
+	}
+	return data, nil
+}
+
+// Name returns the compressor name
+func (l *LZ4Compressor) Name() string {
+	return "lz4"
+}
+
+// Ensure LZ4Compressor implements Compressor
+var _ Compressor = (*LZ4Compressor)(nil)
@@ -0,0 +1,135 @@
+package compression
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// randomBytes returns n bytes that do not compress
+func randomBytes(n int) []byte {
+	rng := rand.New(rand.NewPCG(1, 2))
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(rng.IntN(256))
+	}
+	return data
+}
+
+func TestLZ4Compressor(t *testing.T) {
+	for _, level := range []int{-1, 0, 1, 9, 12} {
+		compressor := NewLZ4Compressor(level)
+		if compressor.Name() != "lz4" {
+			t.Errorf("Expected name 'lz4', got %s", compressor.Name())
+		}
+
+		original := []byte(strings.Repeat(`{"id":1,"name":"test data"},`, 100))
+		compressed, err := compressor.Compress(original)
+		if err != nil {
+			t.Fatalf("LZ4 compress at level %d failed: %v", level, err)
+		}
+		if len(compressed) >= len(original) {
+			t.Errorf("Expected compression at level %d, but compressed size (%d) >= original size (%d)",
+				level, len(compressed), len(original))
+		}
+
+		decompressed, err := compressor.Decompress(compressed)
+		if err != nil {
+			t.Fatalf("LZ4 decompress at level %d failed: %v", level, err)
+		}
+		if !bytes.Equal(decompressed, original) {
+			t.Errorf("Decompressed data at level %d doesn't match original", level)
+		}
+	}
+}
+
+func TestLZ4CompressorIncompressible(t *testing.T) {
+	compressor := NewLZ4Compressor(-1)
+
+	for _, original := range [][]byte{nil, {7}, []byte("ab"), randomBytes(4096)} {
+		compressed, err := compressor.Compress(original)
+		if err != nil {
+			t.Fatalf("LZ4 compress failed: %v", err)
+		}
+		if len(compressed) <= len(original) {
+			t.Errorf("Expected incompressible data of %d bytes to grow by its header, got %d", len(original), len(compressed))
+		}
+		decompressed, err := compressor.Decompress(compressed)
+		if err != nil || !bytes.Equal(decompressed, original) {
+			t.Errorf("Expected %d stored bytes to round trip (err=%v)", len(original), err)
+		}
+	}
+}
+
+func TestLZ4CompressorCorruptInput(t *testing.T) {
+	compressor := NewLZ4Compressor(-1)
+	compressed, _ := compressor.Compress([]byte(strings.Repeat("payload ", 200)))
+
+	huge := append([]byte{0xff, 0xff, 0xff, 0xff, 0x0f}, compressed[2:]...)
+	gzipped, _ := NewGzipCompressor(-1).Compress([]byte(strings.Repeat("gzip data ", 50)))
+
+	for name, data := range map[string][]byte{
+		"empty":     nil,
+		"truncated": compressed[:len(compressed)/2],
+		"length":    huge,
+		"gzip":      gzipped,
+	} {
+		if _, err := compressor.Decompress(data); err == nil {
+			t.Errorf("Expected an error decompressing %s input", name)
+		}
+	}
+}
+
+func TestLZ4CompressorConcurrent(t *testing.T) {
+	compressor := NewLZ4Compressor(3)
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Go(func() {
+			original := []byte(strings.Repeat(string(rune('a'+i))+"bc", 2048))
+			for range 50 {
+				compressed, _ := compressor.Compress(original)
+				decompressed, err := compressor.Decompress(compressed)
+				if err != nil || !bytes.Equal(decompressed, original) {
+					t.Errorf("Concurrent round trip failed (err=%v)", err)
+					return
+				}
+			}
+		})
+	}
+	wg.Wait()
+}
+
+func TestSerializeAndCompressWithLZ4(t *testing.T) {
+	compressor := NewLZ4Compressor(-1)
+
+	// Values below MinSize are left alone
+	data, compressed, err := SerializeAndCompress(strings.Repeat("a", 50), compressor, 100)
+	if err != nil || compressed {
+		t.Errorf("Expected a value below MinSize to stay uncompressed (err=%v)", err)
+	}
+
+	// Compressible values above MinSize are compressed and round trip
+	value := strings.Repeat("compressible ", 100)
+	data, compressed, err = SerializeAndCompress(value, compressor, 100)
+	if err != nil || !compressed {
+		t.Fatalf("Expected a compressible value to be compressed (err=%v)", err)
+	}
+	var result string
+	if err := DecompressAndDeserialize(data, compressed, compressor, &result); err != nil || result != value {
+		t.Errorf("Expected the value to round trip, got %q (err=%v)", result, err)
+	}
+
+	// Data LZ4 cannot shrink is stored uncompressed rather than with a header
+	random := randomBytes(2048)
+	data, compressed, err = SerializeAndCompress(random, compressor, 100)
+	if err != nil || compressed {
+		t.Errorf("Expected incompressible data to be kept uncompressed (err=%v)", err)
+	}
+	var decoded []byte
+	if err := DecompressAndDeserialize(data, compressed, compressor, &decoded); err != nil || !bytes.Equal(decoded, random) {
+		t.Errorf("Expected incompressible data to round trip (err=%v)", err)
+	}
+}
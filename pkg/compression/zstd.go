@@ -0,0 +1,87 @@
+package compression
+
+import (
+	"fmt"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// ZstdCompressor implements compression using Zstandard
+// The encoder and decoder are created once and shared; both are safe for concurrent use
+type ZstdCompressor struct {
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+	dictID  uint32
+}
+
+// NewZstdCompressor creates a new zstd compressor with the specified level
+// Levels follow the zstd command line (1-22) and are mapped onto the encoder's
+// four speeds: below 3 is fastest, 3-5 default, 6-9 better and 10 and up best.
+// A level <= 0 uses the default speed
+func NewZstdCompressor(level int) (*ZstdCompressor, error) {
+	return NewZstdDictCompressor(level, nil)
+}
+
+// NewZstdDictCompressor creates a zstd compressor that compresses with dict, a
+// dictionary in zstd format such as one from TrainZstdDictionary or
+// "zstd --train". A nil dict compresses without a dictionary
+func NewZstdDictCompressor(level int, dict []byte) (*ZstdCompressor, error) {
+	speed := zstd.SpeedDefault
+	if level > 0 {
+		speed = zstd.EncoderLevelFromZstd(level)
+	}
+
+	encoderOptions := []zstd.EOption{zstd.WithEncoderLevel(speed)}
+	var decoderOptions []zstd.DOption
+	var dictID uint32
+	if dict != nil {
+		info, err := zstd.InspectDictionary(dict)
+		if err != nil {
+			return nil, fmt.Errorf("invalid zstd dictionary: %w", err)
+		}
+		if dictID = info.ID(); dictID == 0 {
+			return nil, fmt.Errorf("invalid zstd dictionary: ID must not be 0")
+		}
+		encoderOptions = append(encoderOptions, zstd.WithEncoderDict(dict))
+		decoderOptions = append(decoderOptions, zstd.WithDecoderDicts(dict))
+	}
+
+	encoder, err := zstd.NewWriter(nil, encoderOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create zstd encoder: %w", err)
+	}
+	decoder, err := zstd.NewReader(nil, decoderOptions...)
+	if err != nil {
+		_ = encoder.Close() // Ignore error on cleanup path
+		return nil, fmt.Errorf("failed to create zstd decoder: %w", err)
+	}
+
+	return &ZstdCompressor{encoder: encoder, decoder: decoder, dictID: dictID}, nil
+}
+
+// Compress compresses data using zstd
+func (z *ZstdCompressor) Compress(data []byte) ([]byte, error) {
+	return z.encoder.EncodeAll(data, nil), nil
+}
+
+// Decompress decompresses zstd data
+func (z *ZstdCompressor) Decompress(compressed []byte) ([]byte, error) {
+	data, err := z.decoder.DecodeAll(compressed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode zstd data: %w", err)
+	}
+	return data, nil
+}
+
+// Name returns the compressor name
+func (z *ZstdCompressor) Name() string {
+	return "zstd"
+}
+
+// DictionaryID returns the ID of the compressor's dictionary, or 0 without one
+func (z *ZstdCompressor) DictionaryID() uint32 {
+	return z.dictID
+}
+
+// Ensure ZstdCompressor implements DictionaryCompressor
+var _ DictionaryCompressor = (*ZstdCompressor)(nil)
@@ -0,0 +1,131 @@
+package compression
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestZstdCompressor(t *testing.T) {
+	compressor, err := NewZstdCompressor(-1)
+	if err != nil {
+		t.Fatalf("Failed to create zstd compressor: %v", err)
+	}
+
+	if compressor.Name() != "zstd" {
+		t.Errorf("Expected name 'zstd', got %s", compressor.Name())
+	}
+
+	original := []byte(strings.Repeat(`{"id":1,"name":"test data"},`, 100))
+
+	compressed, err := compressor.Compress(original)
+	if err != nil {
+		t.Fatalf("Zstd compress failed: %v", err)
+	}
+	if len(compressed) >= len(original) {
+		t.Errorf("Expected compression, but compressed size (%d) >= original size (%d)",
+			len(compressed), len(original))
+	}
+
+	decompressed, err := compressor.Decompress(compressed)
+	if err != nil {
+		t.Fatalf("Zstd decompress failed: %v", err)
+	}
+	if !bytes.Equal(decompressed, original) {
+		t.Error("Decompressed data doesn't match original")
+	}
+
+	// Empty input round trips as well
+	empty, _ := compressor.Compress(nil)
+	if decompressed, err := compressor.Decompress(empty); err != nil || len(decompressed) != 0 {
+		t.Errorf("Expected empty data to round trip, got %q (err=%v)", decompressed, err)
+	}
+}
+
+func TestZstdCompressorLevels(t *testing.T) {
+	original := []byte(strings.Repeat("level test data ", 500))
+
+	for _, level := range []int{-1, 1, 3, 7, 19} {
+		compressor, err := NewZstdCompressor(level)
+		if err != nil {
+			t.Fatalf("Failed to create zstd compressor at level %d: %v", level, err)
+		}
+		compressed, err := compressor.Compress(original)
+		if err != nil {
+			t.Fatalf("Compress at level %d failed: %v", level, err)
+		}
+		decompressed, err := compressor.Decompress(compressed)
+		if err != nil || !bytes.Equal(decompressed, original) {
+			t.Errorf("Round trip at level %d failed (err=%v)", level, err)
+		}
+	}
+}
+
+func TestZstdCompressorCorruptInput(t *testing.T) {
+	compressor, err := NewZstdCompressor(-1)
+	if err != nil {
+		t.Fatalf("Failed to create zstd compressor: %v", err)
+	}
+
+	compressed, _ := compressor.Compress([]byte(strings.Repeat("payload ", 200)))
+	truncated := compressed[:len(compressed)/2]
+	flipped := bytes.Clone(compressed)
+	flipped[len(flipped)-1] ^= 0xff
+
+	gzipped, _ := NewGzipCompressor(-1).Compress([]byte("gzip data"))
+
+	for name, data := range map[string][]byte{
+		"garbage":   []byte("not zstd at all"),
+		"truncated": truncated,
+		"checksum":  flipped,
+		"gzip":      gzipped,
+	} {
+		if _, err := compressor.Decompress(data); err == nil {
+			t.Errorf("Expected an error decompressing %s input", name)
+		}
+	}
+}
+
+func TestZstdCompressorConcurrent(t *testing.T) {
+	compressor, err := NewZstdCompressor(3)
+	if err != nil {
+		t.Fatalf("Failed to create zstd compressor: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Go(func() {
+			original := []byte(strings.Repeat(string(rune('a'+i)), 4096))
+			for range 50 {
+				compressed, _ := compressor.Compress(original)
+				decompressed, err := compressor.Decompress(compressed)
+				if err != nil || !bytes.Equal(decompressed, original) {
+					t.Errorf("Concurrent round trip failed (err=%v)", err)
+					return
+				}
+			}
+		})
+	}
+	wg.Wait()
+}
+
+func TestLookup(t *testing.T) {
+	for _, name := range []string{"none", "gzip", "deflate", "zstd", "lz4", "brotli"} {
+		c, err := Lookup(name)
+		if err != nil {
+			t.Fatalf("Lookup(%q) failed: %v", name, err)
+		}
+		if c.Name() != name {
+			t.Errorf("Expected %s compressor, got %s", name, c.Name())
+		}
+		if again, _ := Lookup(name); again != c {
+			t.Errorf("Expected Lookup(%q) to reuse its compressor", name)
+		}
+	}
+
+	if _, err := Lookup("snappy"); !errors.Is(err, ErrUnknownCompressor) {
+		t.Errorf("Expected ErrUnknownCompressor, got %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+// Package encryption provides encryption of cache values at rest
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Encryptor encrypts serialized values before they are stored
+type Encryptor interface {
+	// Encrypt seals plaintext and binds it to associatedData, such as the cache key
+	Encrypt(plaintext, associatedData []byte) ([]byte, error)
+
+	// Decrypt opens a value sealed by Encrypt with the same associatedData
+	// Fails with ErrAuthenticationFailed if the value or associatedData was altered
+	Decrypt(ciphertext, associatedData []byte) ([]byte, error)
+}
+
+var (
+	// ErrAuthenticationFailed is returned when a value was tampered with, truncated,
+	// moved to another key or sealed with a different secret
+	ErrAuthenticationFailed = errors.New("encrypted value failed authentication")
+
+	// ErrUnknownKey is returned when a value was sealed with a key ID that is not
+	// in the keyring, e.g. after the key was retired
+	ErrUnknownKey = errors.New("unknown encryption key")
+)
+
+// keyIDSize is the length of the key ID prefix of each sealed value
+const keyIDSize = 4
+
+// Key is an AES key and the ID stored with each value it encrypts
+type Key struct {
+	// ID identifies the key in sealed values; give every key in a keyring its own
+	ID uint32
+
+	// Secret is the AES key: 16, 24 or 32 bytes for AES-128, AES-192 or AES-256
+	Secret []byte
+}
+
+// AESGCM encrypts values with AES-GCM and a random nonce per value
+// Sealed values are the key ID, the nonce and the ciphertext with its tag
+type AESGCM struct {
+	current uint32
+	aeads   map[uint32]cipher.AEAD
+}
+
+// NewAESGCM creates an encryptor from a keyring. The first key encrypts new
+// values; all of them decrypt, so to rotate keys put the new key first and keep
+// the previous ones until the values they sealed have expired
+func NewAESGCM(keys ...Key) (*AESGCM, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("at least one encryption key is required")
+	}
+
+	e := &AESGCM{current: keys[0].ID, aeads: make(map[uint32]cipher.AEAD, len(keys))}
+	for _, key := range keys {
+		if _, dup := e.aeads[key.ID]; dup {
+			return nil, fmt.Errorf("duplicate encryption key ID %d", key.ID)
+		}
+		block, err := aes.NewCipher(key.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key %d: %w", key.ID, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key %d: %w", key.ID, err)
+		}
+		e.aeads[key.ID] = aead
+	}
+	return e, nil
+}
+
+// Encrypt seals plaintext with the current key
+func (e *AESGCM) Encrypt(plaintext, associatedData []byte) ([]byte, error) {
+	aead := e.aeads[e.current]
+	nonceSize := aead.NonceSize()
+
+	sealed := make([]byte, keyIDSize+nonceSize, keyIDSize+nonceSize+len(plaintext)+aead.Overhead())
+	binary.BigEndian.PutUint32(sealed, e.current)
+	nonce := sealed[keyIDSize:]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(sealed, nonce, plaintext, associatedData), nil
+}
+
+// Decrypt opens a value sealed with any key in the keyring
+func (e *AESGCM) Decrypt(ciphertext, associatedData []byte) ([]byte, error) {
+	if len(ciphertext) < keyIDSize {
+		return nil, fmt.Errorf("%w: value is truncated", ErrAuthenticationFailed)
+	}
+	keyID := binary.BigEndian.Uint32(ciphertext)
+	aead, ok := e.aeads[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownKey, keyID)
+	}
+
+	ciphertext = ciphertext[keyIDSize:]
+	if len(ciphertext) < aead.NonceSize()+aead.Overhead() {
+		return nil, fmt.Errorf("%w: value is truncated", ErrAuthenticationFailed)
+	}
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, associatedData)
+	if err != nil {
+		return nil, ErrAuthenticationFailed
+	}
+	return plaintext, nil
+}
+
+// KeyID returns the ID of the key that encrypts new values
+func (e *AESGCM) KeyID() uint32 {
+	return e.current
+}
+
+// Ensure AESGCM implements Encryptor
+var _ Encryptor = (*AESGCM)(nil)
@@ -0,0 +1,118 @@
+package encryption
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func testKey(id uint32, fill byte) Key {
+	return Key{ID: id, Secret: bytes.Repeat([]byte{fill}, 32)}
+}
+
+func TestAESGCMRoundTrip(t *testing.T) {
+	e, err := NewAESGCM(testKey(1, 'a'))
+	if err != nil {
+		t.Fatalf("Failed to create encryptor: %v", err)
+	}
+
+	plaintext := []byte(`{"email":"ada@example.com"}`)
+	sealed, err := e.Encrypt(plaintext, []byte("user:1"))
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if bytes.Contains(sealed, plaintext) {
+		t.Error("Expected the sealed value not to contain the plaintext")
+	}
+	again, _ := e.Encrypt(plaintext, []byte("user:1"))
+	if bytes.Equal(sealed, again) {
+		t.Error("Expected a fresh nonce for every value")
+	}
+
+	opened, err := e.Decrypt(sealed, []byte("user:1"))
+	if err != nil || !bytes.Equal(opened, plaintext) {
+		t.Fatalf("Expected the value to round trip, got %q (err=%v)", opened, err)
+	}
+
+	empty, _ := e.Encrypt(nil, nil)
+	if opened, err := e.Decrypt(empty, nil); err != nil || len(opened) != 0 {
+		t.Errorf("Expected an empty value to round trip, got %q (err=%v)", opened, err)
+	}
+}
+
+func TestAESGCMTamperDetection(t *testing.T) {
+	e, _ := NewAESGCM(testKey(1, 'a'))
+	sealed, _ := e.Encrypt([]byte("secret"), []byte("user:1"))
+
+	for i := keyIDSize; i < len(sealed); i++ {
+		tampered := bytes.Clone(sealed)
+		tampered[i] ^= 0x01
+		if _, err := e.Decrypt(tampered, []byte("user:1")); !errors.Is(err, ErrAuthenticationFailed) {
+			t.Fatalf("Expected flipping byte %d to fail authentication, got %v", i, err)
+		}
+	}
+
+	if _, err := e.Decrypt(sealed, []byte("user:2")); !errors.Is(err, ErrAuthenticationFailed) {
+		t.Errorf("Expected a value moved to another key to fail authentication, got %v", err)
+	}
+	for _, n := range []int{0, 2, keyIDSize + 5, len(sealed) - 1} {
+		if _, err := e.Decrypt(sealed[:n], []byte("user:1")); !errors.Is(err, ErrAuthenticationFailed) {
+			t.Errorf("Expected a value truncated to %d bytes to fail authentication, got %v", n, err)
+		}
+	}
+
+	other, _ := NewAESGCM(testKey(1, 'b'))
+	if _, err := other.Decrypt(sealed, []byte("user:1")); !errors.Is(err, ErrAuthenticationFailed) {
+		t.Errorf("Expected another secret under the same ID to fail authentication, got %v", err)
+	}
+}
+
+func TestAESGCMKeyRotation(t *testing.T) {
+	old, _ := NewAESGCM(testKey(1, 'a'))
+	sealedOld, _ := old.Encrypt([]byte("before"), nil)
+
+	rotated, err := NewAESGCM(testKey(2, 'b'), testKey(1, 'a'))
+	if err != nil {
+		t.Fatalf("Failed to create rotated encryptor: %v", err)
+	}
+	if rotated.KeyID() != 2 {
+		t.Errorf("Expected the first key to encrypt, got key %d", rotated.KeyID())
+	}
+	if opened, err := rotated.Decrypt(sealedOld, nil); err != nil || string(opened) != "before" {
+		t.Errorf("Expected values sealed with the previous key to stay readable, got %q (err=%v)", opened, err)
+	}
+
+	sealedNew, _ := rotated.Encrypt([]byte("after"), nil)
+	if _, err := old.Decrypt(sealedNew, nil); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Expected the old keyring not to know the new key, got %v", err)
+	}
+
+	retired, _ := NewAESGCM(testKey(2, 'b'))
+	if _, err := retired.Decrypt(sealedOld, nil); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Expected values sealed with a retired key to fail, got %v", err)
+	}
+}
+
+func TestNewAESGCMValidation(t *testing.T) {
+	for _, size := range []int{16, 24, 32} {
+		if _, err := NewAESGCM(Key{ID: 1, Secret: make([]byte, size)}); err != nil {
+			t.Errorf("Expected a %d-byte key to be accepted, got %v", size, err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		keys []Key
+	}{
+		{"no keys", nil},
+		{"short secret", []Key{{ID: 1, Secret: make([]byte, 10)}}},
+		{"duplicate ID", []Key{testKey(1, 'a'), testKey(1, 'b')}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewAESGCM(tt.keys...); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
@@ -1,3 +1,5 @@
+// Package entry defines the cache entry that stores hold for each key, with its
+// value, expiration and bookkeeping data.
 package entry
 
 import (
@@ -11,19 +13,33 @@ type Entry struct {
 	Value any
 
 	// ExpiresAt indicates when this entry expires (nil means no expiration)
+	// Stores may change it with UpdateExpiry while others read the entry, so
+	// read it with Expiry once the entry is stored
 	ExpiresAt *time.Time
 
 	// CreatedAt is when this entry was created
 	CreatedAt time.Time
 
 	// AccessedAt is when this entry was last accessed (for LRU)
-	// Protected by mu for concurrent access
+	// Protected by mu for concurrent access, as is ExpiresAt
 	AccessedAt time.Time
 	mu         sync.RWMutex
 
+	// accesses counts calls to Touch; protected by mu
+	accesses int64
+
+	// ValueSize is the size of the uncompressed value in bytes as estimated by
+	// the cache (0 if unknown)
+	ValueSize int
+
+	// Version is a caller-supplied version used for conditional writes (0 if unversioned)
+	Version int64
+
 	// Compression metadata
 	IsCompressed   bool   // Whether the value is compressed
-	CompressorName string // Name of the compressor used (for debugging/metrics)
+	CompressorName string // Name of the compressor used, so the value can be read after the algorithm changes
+	CodecName      string // Name of the codec that serialized the value ("" if it is not serialized)
+	DictionaryID   uint32 // ID of the compression dictionary (0 if none was used)
 	OriginalSize   int    // Original size before compression (0 if not compressed)
 	CompressedSize int    // Size after compression (0 if not compressed)
 }
@@ -56,22 +72,31 @@ func NewWithoutTTL(value any) *Entry {
 	}
 }
 
+// Expiry returns when the entry expires, or nil if it does not
+func (e *Entry) Expiry() *time.Time {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.ExpiresAt
+}
+
 // IsExpired returns true if the entry has expired
 func (e *Entry) IsExpired() bool {
-	if e.ExpiresAt == nil {
+	expiresAt := e.Expiry()
+	if expiresAt == nil {
 		return false
 	}
-	return time.Now().After(*e.ExpiresAt)
+	return time.Now().After(*expiresAt)
 }
 
 // TTL returns the time remaining until expiration
 // Returns 0 if the entry has no expiration or has already expired
 func (e *Entry) TTL() time.Duration {
-	if e.ExpiresAt == nil {
+	expiresAt := e.Expiry()
+	if expiresAt == nil {
 		return 0 // No expiration
 	}
 
-	remaining := time.Until(*e.ExpiresAt)
+	remaining := time.Until(*expiresAt)
 	if remaining < 0 {
 		return 0 // Already expired
 	}
@@ -92,26 +117,45 @@ func (e *Entry) TimeSinceLastAccess() time.Duration {
 	return time.Since(accessedAt)
 }
 
-// Touch updates the last accessed time to now
+// LastAccess returns when this entry was last accessed
+func (e *Entry) LastAccess() time.Time {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.AccessedAt
+}
+
+// Touch updates the last accessed time to now and counts the access
 func (e *Entry) Touch() {
 	e.mu.Lock()
 	e.AccessedAt = time.Now()
+	e.accesses++
 	e.mu.Unlock()
 }
 
+// AccessCount returns the number of times the entry was touched by reads
+// Stores that rebuild entries on every read, such as Redis, only count the current read
+func (e *Entry) AccessCount() int64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.accesses
+}
+
 // UpdateExpiry updates the expiration time with a new TTL from now
+// It is safe to call while other goroutines read the entry
 func (e *Entry) UpdateExpiry(ttl time.Duration) {
+	var expiresAt *time.Time
 	if ttl > 0 {
 		expiry := time.Now().Add(ttl)
-		e.ExpiresAt = &expiry
-	} else {
-		e.ExpiresAt = nil
+		expiresAt = &expiry
 	}
+	e.mu.Lock()
+	e.ExpiresAt = expiresAt
+	e.mu.Unlock()
 }
 
 // HasExpiry returns true if the entry has an expiration time set
 func (e *Entry) HasExpiry() bool {
-	return e.ExpiresAt != nil
+	return e.Expiry() != nil
 }
 
 // String returns a string representation of the entry (for debugging)
@@ -120,10 +164,10 @@ func (e *Entry) String() string {
 	if e.IsCompressed {
 		status += "compressed, "
 	}
-	if e.ExpiresAt == nil {
+	if expiresAt := e.Expiry(); expiresAt == nil {
 		status += "no-expiry}"
 	} else {
-		status += "expires: " + e.ExpiresAt.Format(time.RFC3339) + "}"
+		status += "expires: " + expiresAt.Format(time.RFC3339) + "}"
 	}
 	return status
 }
@@ -146,6 +190,27 @@ func (e *Entry) SpaceSaved() int {
 	return e.OriginalSize - e.CompressedSize
 }
 
+// Size returns the stored size of the value in bytes when it is known
+// Compressed entries report their compressed size, entries with a ValueSize
+// report it, and []byte or string values report their length. Returns 0 for
+// other values.
+func (e *Entry) Size() int {
+	if e.IsCompressed {
+		return e.CompressedSize
+	}
+	if e.ValueSize > 0 {
+		return e.ValueSize
+	}
+	switch v := e.Value.(type) {
+	case []byte:
+		return len(v)
+	case string:
+		return len(v)
+	default:
+		return 0
+	}
+}
+
 // SetCompressionInfo sets compression metadata for the entry
 func (e *Entry) SetCompressionInfo(compressorName string, originalSize, compressedSize int) {
 	e.IsCompressed = true
@@ -143,6 +143,12 @@ func TestTouch(t *testing.T) {
 	if !entry.AccessedAt.After(initialAccess) {
 		t.Fatal("Touch should update AccessedAt")
 	}
+
+	// Touch should count the access
+	entry.Touch()
+	if n := entry.AccessCount(); n != 2 {
+		t.Fatalf("Expected AccessCount 2 after two touches, got %d", n)
+	}
 }
 
 func TestUpdateExpiry(t *testing.T) {
@@ -201,6 +207,24 @@ func TestString(t *testing.T) {
 	}
 }
 
+func TestSize(t *testing.T) {
+	if size := NewWithoutTTL([]byte("abcd")).Size(); size != 4 {
+		t.Fatalf("Expected size 4 for []byte value, got %d", size)
+	}
+	if size := NewWithoutTTL("abc").Size(); size != 3 {
+		t.Fatalf("Expected size 3 for string value, got %d", size)
+	}
+	if size := NewWithoutTTL(42).Size(); size != 0 {
+		t.Fatalf("Expected size 0 for value of unknown size, got %d", size)
+	}
+
+	compressed := NewWithoutTTL([]byte("zz"))
+	compressed.SetCompressionInfo("gzip", 100, 2)
+	if size := compressed.Size(); size != 2 {
+		t.Fatalf("Expected compressed size 2, got %d", size)
+	}
+}
+
 func TestConcurrentTouch(_ *testing.T) {
 	entry := New("value", time.Hour)
 
@@ -0,0 +1,337 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultAsyncQueueSize is the number of events an AsyncExporter buffers by default
+	DefaultAsyncQueueSize = 1024
+
+	// DefaultAsyncWorkers is the number of goroutines an AsyncExporter exports with by default
+	DefaultAsyncWorkers = 1
+
+	// DefaultAsyncCloseTimeout bounds how long Close waits for the queue to drain by default
+	DefaultAsyncCloseTimeout = 5 * time.Second
+)
+
+// ErrExporterClosed is returned by an AsyncExporter after Close
+var ErrExporterClosed = errors.New("metrics exporter is closed")
+
+// AsyncOptions holds configuration options for NewAsyncExporter
+type AsyncOptions struct {
+	// QueueSize bounds the events waiting to be exported
+	// Default: DefaultAsyncQueueSize
+	QueueSize int
+
+	// Workers is the number of goroutines calling the inner exporter. With more
+	// than one, events may reach it out of order; ExportStats is not affected
+	// Default: DefaultAsyncWorkers
+	Workers int
+
+	// CloseTimeout bounds how long Close waits for queued events to be exported
+	// Default: DefaultAsyncCloseTimeout
+	CloseTimeout time.Duration
+
+	// OnError is called with the errors returned by the inner exporter
+	OnError func(err error)
+}
+
+// AsyncOption is a function that configures AsyncOptions
+type AsyncOption func(*AsyncOptions)
+
+// WithAsyncQueueSize sets the number of events buffered before the oldest are dropped
+func WithAsyncQueueSize(size int) AsyncOption {
+	return func(opts *AsyncOptions) {
+		opts.QueueSize = size
+	}
+}
+
+// WithAsyncWorkers sets the number of goroutines calling the inner exporter
+func WithAsyncWorkers(n int) AsyncOption {
+	return func(opts *AsyncOptions) {
+		opts.Workers = n
+	}
+}
+
+// WithAsyncCloseTimeout sets how long Close waits for queued events to be exported
+func WithAsyncCloseTimeout(timeout time.Duration) AsyncOption {
+	return func(opts *AsyncOptions) {
+		opts.CloseTimeout = timeout
+	}
+}
+
+// WithAsyncErrorHandler sets a function called with the inner exporter's errors
+func WithAsyncErrorHandler(fn func(err error)) AsyncOption {
+	return func(opts *AsyncOptions) {
+		opts.OnError = fn
+	}
+}
+
+// AsyncExporter exports through another exporter on background goroutines, so a
+// slow metrics backend does not add latency to cache operations. Calls are
+// queued and return at once; when the queue is full the oldest event is dropped
+// to make room and counted in Dropped. Errors from the inner exporter go to
+// AsyncOptions.OnError. Labels and stats are read when the event is exported,
+// so callers must not modify them afterwards.
+//
+// Stats are cumulative totals that exporters turn into counter increments, so
+// they are not queued: ExportStats keeps the latest stats per label set, and one
+// goroutine exports them in the order they were passed
+type AsyncExporter struct {
+	inner   Exporter
+	options AsyncOptions
+
+	// mu guards closed against enqueues racing with Close closing the queue
+	mu      sync.RWMutex
+	closed  bool
+	queue   chan func(Exporter) error
+	stop    chan struct{}
+	workers sync.WaitGroup
+	dropped atomic.Int64
+
+	// statsMu guards pendingStats, the stats not yet exported keyed by label set;
+	// statsReady wakes the goroutine that exports them
+	statsMu      sync.Mutex
+	pendingStats map[string]pendingStats
+	statsReady   chan struct{}
+}
+
+// pendingStats is an ExportStats call waiting to be exported
+type pendingStats struct {
+	stats  Stats
+	labels Labels
+}
+
+// NewAsyncExporter creates an AsyncExporter in front of inner and starts its workers
+func NewAsyncExporter(inner Exporter, opts ...AsyncOption) *AsyncExporter {
+	options := AsyncOptions{
+		QueueSize:    DefaultAsyncQueueSize,
+		Workers:      DefaultAsyncWorkers,
+		CloseTimeout: DefaultAsyncCloseTimeout,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.QueueSize <= 0 {
+		options.QueueSize = DefaultAsyncQueueSize
+	}
+	if options.Workers <= 0 {
+		options.Workers = DefaultAsyncWorkers
+	}
+	if options.CloseTimeout <= 0 {
+		options.CloseTimeout = DefaultAsyncCloseTimeout
+	}
+
+	a := &AsyncExporter{
+		inner:        inner,
+		options:      options,
+		queue:        make(chan func(Exporter) error, options.QueueSize),
+		stop:         make(chan struct{}),
+		pendingStats: make(map[string]pendingStats),
+		statsReady:   make(chan struct{}, 1),
+	}
+	a.workers.Add(options.Workers + 1)
+	for range options.Workers {
+		go a.run()
+	}
+	go a.runStats()
+	return a
+}
+
+// run exports queued events until the queue is closed and drained, or Close gives up
+func (a *AsyncExporter) run() {
+	defer a.workers.Done()
+	for {
+		select {
+		case <-a.stop:
+			return
+		default:
+		}
+		select {
+		case <-a.stop:
+			return
+		case event, ok := <-a.queue:
+			if !ok {
+				return
+			}
+			if err := event(a.inner); err != nil && a.options.OnError != nil {
+				a.options.OnError(err)
+			}
+		}
+	}
+}
+
+// runStats exports pending stats whenever ExportStats adds some, until Close
+// has been called and they are exported, or Close gives up
+func (a *AsyncExporter) runStats() {
+	defer a.workers.Done()
+	for {
+		select {
+		case <-a.stop:
+			return
+		case _, ok := <-a.statsReady:
+			for _, pending := range a.takePendingStats() {
+				if err := a.inner.ExportStats(pending.stats, pending.labels); err != nil && a.options.OnError != nil {
+					a.options.OnError(err)
+				}
+			}
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// takePendingStats removes and returns the stats waiting to be exported
+func (a *AsyncExporter) takePendingStats() map[string]pendingStats {
+	a.statsMu.Lock()
+	defer a.statsMu.Unlock()
+	pending := a.pendingStats
+	a.pendingStats = make(map[string]pendingStats)
+	return pending
+}
+
+// enqueue queues event, dropping the oldest queued events while the queue is full
+func (a *AsyncExporter) enqueue(event func(Exporter) error) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return ErrExporterClosed
+	}
+
+	for {
+		select {
+		case a.queue <- event:
+			return nil
+		default:
+		}
+		select {
+		case <-a.queue:
+			a.dropped.Add(1)
+		default:
+		}
+	}
+}
+
+// Dropped returns the number of events discarded because the queue was full or
+// Close timed out
+func (a *AsyncExporter) Dropped() int64 {
+	return a.dropped.Load()
+}
+
+// ExportStats queues an export of stats, replacing stats for the same labels
+// that have not been exported yet
+func (a *AsyncExporter) ExportStats(stats Stats, labels Labels) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return ErrExporterClosed
+	}
+
+	a.statsMu.Lock()
+	a.pendingStats[fmt.Sprint(labels)] = pendingStats{stats: stats, labels: labels} // Maps print sorted by key
+	a.statsMu.Unlock()
+
+	select {
+	case a.statsReady <- struct{}{}:
+	default: // The exporting goroutine is already woken
+	}
+	return nil
+}
+
+// RecordCacheOperation queues a cache operation
+func (a *AsyncExporter) RecordCacheOperation(operation Operation, duration time.Duration, labels Labels) error {
+	return a.enqueue(func(inner Exporter) error {
+		return inner.RecordCacheOperation(operation, duration, labels)
+	})
+}
+
+// RecordStoreOperation queues a store call
+func (a *AsyncExporter) RecordStoreOperation(operation Operation, duration time.Duration, labels Labels) error {
+	return a.enqueue(func(inner Exporter) error {
+		return RecordStoreOperation(inner, operation, duration, labels)
+	})
+}
+
+// IncrementCounter queues a counter increment
+func (a *AsyncExporter) IncrementCounter(name string, labels Labels) error {
+	return a.enqueue(func(inner Exporter) error {
+		return inner.IncrementCounter(name, labels)
+	})
+}
+
+// RecordHistogram queues a histogram observation
+func (a *AsyncExporter) RecordHistogram(name string, value float64, labels Labels) error {
+	return a.enqueue(func(inner Exporter) error {
+		return inner.RecordHistogram(name, value, labels)
+	})
+}
+
+// SetGauge queues a gauge update
+func (a *AsyncExporter) SetGauge(name string, value float64, labels Labels) error {
+	return a.enqueue(func(inner Exporter) error {
+		return inner.SetGauge(name, value, labels)
+	})
+}
+
+// DeleteGauges queues a gauge deletion; it does nothing if the inner exporter
+// cannot delete gauges
+func (a *AsyncExporter) DeleteGauges(name string, labels Labels) error {
+	if _, ok := a.inner.(GaugeDeleter); !ok {
+		return nil
+	}
+	return a.enqueue(func(inner Exporter) error {
+		return inner.(GaugeDeleter).DeleteGauges(name, labels)
+	})
+}
+
+// Close stops accepting events, waits up to CloseTimeout for the queued ones to
+// be exported and closes the inner exporter. Events still queued at the timeout
+// are dropped and reported in the returned error
+func (a *AsyncExporter) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.closed = true
+	close(a.queue)
+	close(a.statsReady)
+	a.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		a.workers.Wait()
+		close(drained)
+	}()
+
+	var drainErr error
+	select {
+	case <-drained:
+	case <-time.After(a.options.CloseTimeout):
+		// Workers finish their current event; whatever is still queued is dropped
+		close(a.stop)
+		n := int64(len(a.takePendingStats()))
+		for range a.queue {
+			n++
+		}
+		if n > 0 {
+			a.dropped.Add(n)
+			drainErr = fmt.Errorf("metrics exporter dropped %d events not exported within %v", n, a.options.CloseTimeout)
+		}
+	}
+
+	return errors.Join(drainErr, a.inner.Close())
+}
+
+// Ensure interfaces are implemented
+var (
+	_ Exporter               = (*AsyncExporter)(nil)
+	_ GaugeDeleter           = (*AsyncExporter)(nil)
+	_ StoreOperationRecorder = (*AsyncExporter)(nil)
+)
@@ -0,0 +1,154 @@
+package metrics
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// slowExporter takes delay to record each operation, like a remote backend
+type slowExporter struct {
+	NoOpExporter
+	delay      time.Duration
+	operations atomic.Int64
+	err        error
+	closed     atomic.Bool
+}
+
+func (s *slowExporter) RecordCacheOperation(Operation, time.Duration, Labels) error {
+	time.Sleep(s.delay)
+	s.operations.Add(1)
+	return s.err
+}
+
+func (s *slowExporter) Close() error {
+	s.closed.Store(true)
+	return nil
+}
+
+func TestAsyncExporterDoesNotBlock(t *testing.T) {
+	inner := &slowExporter{delay: 20 * time.Millisecond}
+	exporter := NewAsyncExporter(inner, WithAsyncQueueSize(4))
+
+	start := time.Now()
+	for range 50 {
+		if err := exporter.RecordCacheOperation(OperationGet, time.Millisecond, nil); err != nil {
+			t.Fatalf("RecordCacheOperation failed: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 20*time.Millisecond {
+		t.Errorf("Expected queued calls to return at once, took %v", elapsed)
+	}
+
+	if err := exporter.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	exported, dropped := inner.operations.Load(), exporter.Dropped()
+	if dropped == 0 || exported+dropped != 50 {
+		t.Errorf("Expected every operation to be exported or dropped, got %d and %d", exported, dropped)
+	}
+	if !inner.closed.Load() {
+		t.Error("Expected Close to close the inner exporter")
+	}
+	if err := exporter.RecordCacheOperation(OperationGet, time.Millisecond, nil); !errors.Is(err, ErrExporterClosed) {
+		t.Errorf("Expected ErrExporterClosed after Close, got %v", err)
+	}
+}
+
+func TestAsyncExporterDrainsOnClose(t *testing.T) {
+	inner := &slowExporter{delay: time.Millisecond}
+	exporter := NewAsyncExporter(inner, WithAsyncWorkers(4))
+	for range 100 {
+		_ = exporter.RecordCacheOperation(OperationSet, time.Millisecond, nil)
+	}
+	if err := exporter.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if n := inner.operations.Load(); n != 100 || exporter.Dropped() != 0 {
+		t.Errorf("Expected all 100 operations to be exported, got %d (%d dropped)", n, exporter.Dropped())
+	}
+}
+
+func TestAsyncExporterCloseTimeout(t *testing.T) {
+	inner := &slowExporter{delay: 50 * time.Millisecond}
+	exporter := NewAsyncExporter(inner, WithAsyncCloseTimeout(10*time.Millisecond))
+	for range 10 {
+		_ = exporter.RecordCacheOperation(OperationGet, time.Millisecond, nil)
+	}
+
+	start := time.Now()
+	err := exporter.Close()
+	if err == nil || !strings.Contains(err.Error(), "dropped") {
+		t.Errorf("Expected Close to report dropped events, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 40*time.Millisecond {
+		t.Errorf("Expected Close to give up after its timeout, took %v", elapsed)
+	}
+	if exporter.Dropped() == 0 {
+		t.Error("Expected events left at the timeout to be counted as dropped")
+	}
+}
+
+func TestAsyncExporterErrorHandler(t *testing.T) {
+	var mu sync.Mutex
+	var reported []error
+	inner := &slowExporter{err: errors.New("backend down")}
+	exporter := NewAsyncExporter(inner, WithAsyncErrorHandler(func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		reported = append(reported, err)
+	}))
+
+	_ = exporter.RecordCacheOperation(OperationGet, time.Millisecond, nil)
+	_ = exporter.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reported) != 1 || reported[0] != inner.err {
+		t.Errorf("Expected the inner error to be reported once, got %v", reported)
+	}
+}
+
+// unevenExporter takes a different time to export each stats, so workers
+// racing each other would finish out of order
+type unevenExporter struct {
+	*PrometheusExporter
+}
+
+func (u unevenExporter) ExportStats(stats Stats, labels Labels) error {
+	time.Sleep(time.Duration(stats.Hits()%4) * 100 * time.Microsecond)
+	return u.PrometheusExporter.ExportStats(stats, labels)
+}
+
+func TestAsyncExporterExportsStatsInOrder(t *testing.T) {
+	inner, err := NewPrometheusExporter(nil, &PrometheusConfig{Registry: prometheus.NewRegistry()})
+	if err != nil {
+		t.Fatalf("Failed to create Prometheus exporter: %v", err)
+	}
+	exporter := NewAsyncExporter(unevenExporter{inner}, WithAsyncWorkers(8))
+
+	// Counters are advanced by the difference to the previous export, so an
+	// older total exported after a newer one would be counted again
+	labels := Labels{"cache_name": "test"}
+	for i := range int64(1000) {
+		if err := exporter.ExportStats(&mockStats{hits: i + 1, misses: 2 * (i + 1)}, labels); err != nil {
+			t.Fatalf("ExportStats failed: %v", err)
+		}
+	}
+	if err := exporter.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if hits := testutil.ToFloat64(inner.hitsTotal.With(prometheus.Labels{"cache_name": "test"})); hits != 1000 {
+		t.Errorf("Expected 1000 hits, got %v", hits)
+	}
+	if misses := testutil.ToFloat64(inner.missesTotal.With(prometheus.Labels{"cache_name": "test"})); misses != 2000 {
+		t.Errorf("Expected 2000 misses, got %v", misses)
+	}
+}
@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// StatsProvider supplies the statistics a CacheCollector reads at scrape time
+// obcache.Cache implements it with a snapshot of its statistics
+type StatsProvider interface {
+	MetricsStats() Stats
+}
+
+// StatsProviderFunc adapts a function to a StatsProvider
+type StatsProviderFunc func() Stats
+
+// MetricsStats calls f
+func (f StatsProviderFunc) MetricsStats() Stats {
+	return f()
+}
+
+// CacheCollector is a prometheus.Collector that reads a cache's statistics when
+// it is scraped, so values are never older than the scrape and counters are
+// reported as counters. Each cache gets its own collector, told apart by its
+// constant labels such as cache_name.
+//
+// It uses the DefaultMetricNames, so register it instead of a PrometheusExporter
+// on a registry, not alongside one with the same names
+type CacheCollector struct {
+	provider StatsProvider
+
+	hits           *prometheus.Desc
+	misses         *prometheus.Desc
+	evictions      *prometheus.Desc
+	invalidations  *prometheus.Desc
+	errors         *prometheus.Desc
+	sets           *prometheus.Desc
+	deletes        *prometheus.Desc
+	clears         *prometheus.Desc
+	cleanupRemoved *prometheus.Desc
+	keys           *prometheus.Desc
+	inFlight       *prometheus.Desc
+	hitRate        *prometheus.Desc
+	recentHitRate  *prometheus.Desc
+	memoryBytes    *prometheus.Desc
+}
+
+// NewCacheCollector creates a collector for the statistics of provider, adding
+// labels as constant labels to every series
+func NewCacheCollector(provider StatsProvider, labels Labels) *CacheCollector {
+	names := DefaultMetricNames()
+	constLabels := prometheus.Labels(labels)
+	desc := func(name, help string, variableLabels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(name, help, variableLabels, constLabels)
+	}
+
+	return &CacheCollector{
+		provider:       provider,
+		hits:           desc(names.CacheHitsTotal, "Total number of cache hits"),
+		misses:         desc(names.CacheMissesTotal, "Total number of cache misses"),
+		evictions:      desc(names.CacheEvictionsTotal, "Total number of cache evictions", "reason"),
+		invalidations:  desc(names.CacheInvalidationsTotal, "Total number of cache invalidations"),
+		errors:         desc(names.CacheErrorsTotal, "Total number of cache errors", LabelErrorType),
+		sets:           desc(names.CacheSetsTotal, "Total number of entries written"),
+		deletes:        desc(names.CacheDeletesTotal, "Total number of entries deleted"),
+		clears:         desc(names.CacheClearsTotal, "Total number of cache clears"),
+		cleanupRemoved: desc(names.CacheCleanupRemovedTotal, "Total number of expired entries removed by cleanup"),
+		keys:           desc(names.CacheKeysCount, "Current number of keys in cache"),
+		inFlight:       desc(names.CacheInFlightRequests, "Current number of in-flight requests"),
+		hitRate:        desc(names.CacheHitRate, "Cache hit rate as a percentage"),
+		recentHitRate:  desc(names.CacheRecentHitRate, "Cache hit rate over the recent window as a percentage"),
+		memoryBytes:    desc(names.CacheMemoryBytes, "Approximate bytes held by cache entries"),
+	}
+}
+
+// Describe sends the descriptors of every metric the collector can emit
+func (c *CacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		c.hits, c.misses, c.evictions, c.invalidations, c.errors,
+		c.sets, c.deletes, c.clears, c.cleanupRemoved,
+		c.keys, c.inFlight, c.hitRate, c.recentHitRate, c.memoryBytes,
+	} {
+		ch <- desc
+	}
+}
+
+// Collect reads the statistics and sends them as metrics
+// Metrics backed by optional Stats interfaces are sent only when stats implement them
+func (c *CacheCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.provider.MetricsStats()
+
+	counter := func(desc *prometheus.Desc, value int64, labelValues ...string) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, float64(value), labelValues...)
+	}
+	gauge := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value)
+	}
+
+	counter(c.hits, stats.Hits())
+	counter(c.misses, stats.Misses())
+	counter(c.invalidations, stats.Invalidations())
+
+	evictionsByReason := map[string]int64{"capacity": stats.Evictions()} // Default when stats carry no breakdown
+	if reasonStats, ok := stats.(EvictionStats); ok {
+		evictionsByReason = reasonStats.EvictionsByReason()
+	}
+	for reason, count := range evictionsByReason {
+		counter(c.evictions, count, reason)
+	}
+
+	if errorStats, ok := stats.(ErrorStats); ok {
+		for errorType, count := range errorStats.ErrorsByType() {
+			counter(c.errors, count, errorType)
+		}
+	}
+	if operationStats, ok := stats.(OperationStats); ok {
+		counter(c.sets, operationStats.Sets())
+		counter(c.deletes, operationStats.Deletes())
+		counter(c.clears, operationStats.Clears())
+		counter(c.cleanupRemoved, operationStats.CleanupRemoved())
+	}
+
+	gauge(c.keys, float64(stats.KeyCount()))
+	gauge(c.inFlight, float64(stats.InFlight()))
+	gauge(c.hitRate, stats.HitRate())
+	if recentStats, ok := stats.(RecentStats); ok {
+		gauge(c.recentHitRate, recentStats.RecentHitRate(0))
+	}
+	if memoryStats, ok := stats.(MemoryStats); ok {
+		gauge(c.memoryBytes, float64(memoryStats.MemoryBytes()))
+	}
+}
+
+// Ensure interfaces are implemented
+var _ prometheus.Collector = (*CacheCollector)(nil)
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCacheCollector(t *testing.T) {
+	collector := NewCacheCollector(StatsProviderFunc(func() Stats { return fixedStats{} }), Labels{"cache_name": "users"})
+
+	expected := `
+# HELP obcache_evictions_total Total number of cache evictions
+# TYPE obcache_evictions_total counter
+obcache_evictions_total{cache_name="users",reason="capacity"} 2
+# HELP obcache_errors_total Total number of cache errors
+# TYPE obcache_errors_total counter
+obcache_errors_total{cache_name="users",type="set_failed"} 1
+# HELP obcache_hit_rate Cache hit rate as a percentage
+# TYPE obcache_hit_rate gauge
+obcache_hit_rate{cache_name="users"} 75
+# HELP obcache_hits_total Total number of cache hits
+# TYPE obcache_hits_total counter
+obcache_hits_total{cache_name="users"} 3
+# HELP obcache_memory_bytes Approximate bytes held by cache entries
+# TYPE obcache_memory_bytes gauge
+obcache_memory_bytes{cache_name="users"} 4096
+# HELP obcache_recent_hit_rate Cache hit rate over the recent window as a percentage
+# TYPE obcache_recent_hit_rate gauge
+obcache_recent_hit_rate{cache_name="users"} 40
+# HELP obcache_sets_total Total number of entries written
+# TYPE obcache_sets_total counter
+obcache_sets_total{cache_name="users"} 6
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"obcache_evictions_total", "obcache_errors_total", "obcache_hit_rate", "obcache_hits_total",
+		"obcache_memory_bytes", "obcache_recent_hit_rate", "obcache_sets_total")
+	if err != nil {
+		t.Errorf("Unexpected metrics: %v", err)
+	}
+	if n := testutil.CollectAndCount(collector); n != 14 {
+		t.Errorf("Expected 14 series, got %d", n)
+	}
+}
+
+func TestCacheCollectorBasicStats(t *testing.T) {
+	// Stats without the optional interfaces leave out the metrics they back
+	stats := &mockStats{hits: 2, evictions: 1}
+	collector := NewCacheCollector(StatsProviderFunc(func() Stats { return stats }), nil)
+
+	if n := testutil.CollectAndCount(collector); n != 7 {
+		t.Errorf("Expected 7 series, got %d", n)
+	}
+	if n := testutil.CollectAndCount(collector, "obcache_sets_total", "obcache_memory_bytes"); n != 0 {
+		t.Errorf("Expected no series for missing stats, got %d", n)
+	}
+
+	// Values are read at scrape time
+	stats.hits = 5
+	expected := `
+# HELP obcache_hits_total Total number of cache hits
+# TYPE obcache_hits_total counter
+obcache_hits_total 5
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "obcache_hits_total"); err != nil {
+		t.Errorf("Unexpected metrics: %v", err)
+	}
+}
+
+func TestCacheCollectorMultipleCaches(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	for _, name := range []string{"users", "sessions"} {
+		collector := NewCacheCollector(StatsProviderFunc(func() Stats { return fixedStats{} }), Labels{"cache_name": name})
+		if err := registry.Register(collector); err != nil {
+			t.Fatalf("Failed to register collector for %s: %v", name, err)
+		}
+	}
+
+	if n, err := testutil.GatherAndCount(registry, "obcache_hits_total"); err != nil || n != 2 {
+		t.Errorf("Expected one hits series per cache, got %d (%v)", n, err)
+	}
+
+	duplicate := NewCacheCollector(StatsProviderFunc(func() Stats { return fixedStats{} }), Labels{"cache_name": "users"})
+	if err := registry.Register(duplicate); err == nil {
+		t.Error("Expected registering a second collector for the same cache to fail")
+	}
+}
@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"expvar"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// expvarMu serializes lookups and creation of expvar variables, which expvar
+// itself does not make atomic
+var expvarMu sync.Mutex
+
+// ExpvarExporter publishes cache metrics as expvar variables, which the standard
+// /debug/vars endpoint serves without further dependencies. All metrics live in
+// one expvar.Map named after the prefix; labels are ignored, so give each cache
+// its own prefix
+type ExpvarExporter struct {
+	vars *expvar.Map
+}
+
+// NewExpvarExporter creates an exporter that publishes an expvar.Map named prefix
+// Exporters created with the same prefix share their variables. Fails if prefix
+// is already published as something other than a map
+func NewExpvarExporter(prefix string) (*ExpvarExporter, error) {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+
+	if existing := expvar.Get(prefix); existing != nil {
+		vars, ok := existing.(*expvar.Map)
+		if !ok {
+			return nil, fmt.Errorf("expvar %q is already published as %T", prefix, existing)
+		}
+		return &ExpvarExporter{vars: vars}, nil
+	}
+	return &ExpvarExporter{vars: expvar.NewMap(prefix)}, nil
+}
+
+// ExportStats publishes the current cache statistics
+func (e *ExpvarExporter) ExportStats(stats Stats, _ Labels) error {
+	expvarInt(e.vars, "hits").Set(stats.Hits())
+	expvarInt(e.vars, "misses").Set(stats.Misses())
+	expvarInt(e.vars, "evictions").Set(stats.Evictions())
+	expvarInt(e.vars, "invalidations").Set(stats.Invalidations())
+	expvarInt(e.vars, "keys").Set(stats.KeyCount())
+	expvarInt(e.vars, "in_flight").Set(stats.InFlight())
+	expvarFloat(e.vars, "hit_rate").Set(stats.HitRate())
+	if operationStats, ok := stats.(OperationStats); ok {
+		expvarInt(e.vars, "sets").Set(operationStats.Sets())
+		expvarInt(e.vars, "deletes").Set(operationStats.Deletes())
+		expvarInt(e.vars, "clears").Set(operationStats.Clears())
+		expvarInt(e.vars, "cleanup_removed").Set(operationStats.CleanupRemoved())
+	}
+	if recentStats, ok := stats.(RecentStats); ok {
+		expvarFloat(e.vars, "recent_hit_rate").Set(recentStats.RecentHitRate(0))
+	}
+	if memoryStats, ok := stats.(MemoryStats); ok {
+		expvarInt(e.vars, "memory_bytes").Set(memoryStats.MemoryBytes())
+	}
+
+	if reasonStats, ok := stats.(EvictionStats); ok {
+		byReason := expvarMap(e.vars, "evictions_by_reason")
+		for reason, count := range reasonStats.EvictionsByReason() {
+			expvarInt(byReason, reason).Set(count)
+		}
+	}
+	if errorStats, ok := stats.(ErrorStats); ok {
+		byType := expvarMap(e.vars, "errors_by_type")
+		for errorType, count := range errorStats.ErrorsByType() {
+			expvarInt(byType, errorType).Set(count)
+		}
+	}
+	return nil
+}
+
+// RecordCacheOperation adds to the count and total latency of the operation
+func (e *ExpvarExporter) RecordCacheOperation(operation Operation, duration time.Duration, _ Labels) error {
+	observe(expvarMap(expvarMap(e.vars, "operations"), string(operation)), "total_seconds", duration.Seconds())
+	return nil
+}
+
+// RecordStoreOperation adds to the count and total latency of the store call
+func (e *ExpvarExporter) RecordStoreOperation(operation Operation, duration time.Duration, _ Labels) error {
+	observe(expvarMap(expvarMap(e.vars, "store_operations"), string(operation)), "total_seconds", duration.Seconds())
+	return nil
+}
+
+// IncrementCounter increments the named counter
+func (e *ExpvarExporter) IncrementCounter(name string, _ Labels) error {
+	expvarInt(e.vars, name).Add(1)
+	return nil
+}
+
+// RecordHistogram adds value to the count and sum of the named summary
+func (e *ExpvarExporter) RecordHistogram(name string, value float64, _ Labels) error {
+	observe(expvarMap(e.vars, name), "sum", value)
+	return nil
+}
+
+// SetGauge sets the named gauge
+func (e *ExpvarExporter) SetGauge(name string, value float64, _ Labels) error {
+	expvarFloat(e.vars, name).Set(value)
+	return nil
+}
+
+// Close does nothing; expvar variables cannot be unpublished
+func (e *ExpvarExporter) Close() error {
+	return nil
+}
+
+// observe adds one observation of value to the count and sum in summary
+func observe(summary *expvar.Map, sumName string, value float64) {
+	expvarInt(summary, "count").Add(1)
+	expvarFloat(summary, sumName).Add(value)
+}
+
+// expvarInt returns the expvar.Int name in m, creating it if needed
+func expvarInt(m *expvar.Map, name string) *expvar.Int {
+	return getOrAdd(m, name, func() *expvar.Int { return new(expvar.Int) })
+}
+
+// expvarFloat returns the expvar.Float name in m, creating it if needed
+func expvarFloat(m *expvar.Map, name string) *expvar.Float {
+	return getOrAdd(m, name, func() *expvar.Float { return new(expvar.Float) })
+}
+
+// expvarMap returns the expvar.Map name in m, creating it if needed
+func expvarMap(m *expvar.Map, name string) *expvar.Map {
+	return getOrAdd(m, name, func() *expvar.Map { return new(expvar.Map).Init() })
+}
+
+// getOrAdd returns the variable name in m if it has type V, otherwise replaces it
+// with a new one
+func getOrAdd[V expvar.Var](m *expvar.Map, name string, create func() V) V {
+	if v, ok := m.Get(name).(V); ok {
+		return v
+	}
+
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+	if v, ok := m.Get(name).(V); ok {
+		return v
+	}
+	v := create()
+	m.Set(name, v)
+	return v
+}
+
+// Ensure interfaces are implemented
+var (
+	_ Exporter               = (*ExpvarExporter)(nil)
+	_ StoreOperationRecorder = (*ExpvarExporter)(nil)
+)
@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"expvar"
+	"testing"
+	"time"
+)
+
+type fixedStats struct{}
+
+func (fixedStats) Hits() int64           { return 3 }
+func (fixedStats) Misses() int64         { return 1 }
+func (fixedStats) Evictions() int64      { return 2 }
+func (fixedStats) Invalidations() int64  { return 0 }
+func (fixedStats) KeyCount() int64       { return 5 }
+func (fixedStats) InFlight() int64       { return 0 }
+func (fixedStats) HitRate() float64      { return 75 }
+func (fixedStats) MemoryBytes() int64    { return 4096 }
+func (fixedStats) Sets() int64           { return 6 }
+func (fixedStats) Deletes() int64        { return 1 }
+func (fixedStats) Clears() int64         { return 0 }
+func (fixedStats) CleanupRemoved() int64 { return 2 }
+
+func (fixedStats) RecentHitRate(time.Duration) float64 { return 40 }
+func (fixedStats) EvictionsByReason() map[string]int64 {
+	return map[string]int64{"capacity": 2}
+}
+func (fixedStats) ErrorsByType() map[string]int64 {
+	return map[string]int64{"set_failed": 1}
+}
+
+func TestExpvarExporter(t *testing.T) {
+	exporter, err := NewExpvarExporter("obcache_expvar_test")
+	if err != nil {
+		t.Fatalf("Failed to create exporter: %v", err)
+	}
+
+	_ = exporter.ExportStats(fixedStats{}, nil)
+	_ = exporter.RecordCacheOperation(OperationGet, 2*time.Millisecond, nil)
+	_ = exporter.RecordCacheOperation(OperationGet, 3*time.Millisecond, nil)
+	_ = exporter.IncrementCounter("custom_total", nil)
+	_ = exporter.RecordHistogram("custom_seconds", 0.5, nil)
+	_ = exporter.SetGauge("custom_ratio", 0.25, nil)
+
+	vars := expvar.Get("obcache_expvar_test").(*expvar.Map)
+	if hits := vars.Get("hits").(*expvar.Int).Value(); hits != 3 {
+		t.Errorf("Expected 3 hits, got %d", hits)
+	}
+	if sets, deletes := vars.Get("sets").(*expvar.Int).Value(), vars.Get("deletes").(*expvar.Int).Value(); sets != 6 || deletes != 1 {
+		t.Errorf("Expected 6 sets and 1 delete, got %d and %d", sets, deletes)
+	}
+	if rate := vars.Get("hit_rate").(*expvar.Float).Value(); rate != 75 {
+		t.Errorf("Expected a hit rate of 75, got %v", rate)
+	}
+	if rate := vars.Get("recent_hit_rate").(*expvar.Float).Value(); rate != 40 {
+		t.Errorf("Expected a recent hit rate of 40, got %v", rate)
+	}
+	if n := vars.Get("memory_bytes").(*expvar.Int).Value(); n != 4096 {
+		t.Errorf("Expected 4096 memory bytes, got %d", n)
+	}
+	if n := vars.Get("evictions_by_reason").(*expvar.Map).Get("capacity").(*expvar.Int).Value(); n != 2 {
+		t.Errorf("Expected 2 capacity evictions, got %d", n)
+	}
+	if n := vars.Get("errors_by_type").(*expvar.Map).Get("set_failed").(*expvar.Int).Value(); n != 1 {
+		t.Errorf("Expected 1 failed set, got %d", n)
+	}
+
+	get := vars.Get("operations").(*expvar.Map).Get("get").(*expvar.Map)
+	if count := get.Get("count").(*expvar.Int).Value(); count != 2 {
+		t.Errorf("Expected 2 get operations, got %d", count)
+	}
+	if total := get.Get("total_seconds").(*expvar.Float).Value(); total < 0.0049 || total > 0.0051 {
+		t.Errorf("Expected 5ms of get latency, got %v", total)
+	}
+
+	if n := vars.Get("custom_total").(*expvar.Int).Value(); n != 1 {
+		t.Errorf("Expected the counter to be 1, got %d", n)
+	}
+	if sum := vars.Get("custom_seconds").(*expvar.Map).Get("sum").(*expvar.Float).Value(); sum != 0.5 {
+		t.Errorf("Expected the summary sum to be 0.5, got %v", sum)
+	}
+	if gauge := vars.Get("custom_ratio").(*expvar.Float).Value(); gauge != 0.25 {
+		t.Errorf("Expected the gauge to be 0.25, got %v", gauge)
+	}
+}
+
+func TestExpvarExporterSamePrefix(t *testing.T) {
+	first, err := NewExpvarExporter("obcache_expvar_shared")
+	if err != nil {
+		t.Fatalf("Failed to create exporter: %v", err)
+	}
+	second, err := NewExpvarExporter("obcache_expvar_shared")
+	if err != nil {
+		t.Fatalf("Expected a second exporter with the same prefix, got %v", err)
+	}
+
+	_ = first.IncrementCounter("shared_total", nil)
+	_ = second.IncrementCounter("shared_total", nil)
+	vars := expvar.Get("obcache_expvar_shared").(*expvar.Map)
+	if n := vars.Get("shared_total").(*expvar.Int).Value(); n != 2 {
+		t.Errorf("Expected exporters with the same prefix to share variables, got %d", n)
+	}
+
+	expvar.NewInt("obcache_expvar_taken")
+	if _, err := NewExpvarExporter("obcache_expvar_taken"); err == nil {
+		t.Error("Expected a prefix published as another type to be rejected")
+	}
+}
@@ -11,6 +11,8 @@ type Exporter interface {
 	ExportStats(stats Stats, labels Labels) error
 
 	// RecordCacheOperation records individual cache operations with timing
+	// Labels may carry LabelResult to count the outcome and LabelFunction to
+	// attribute the operation to a wrapped function
 	RecordCacheOperation(operation Operation, duration time.Duration, labels Labels) error
 
 	// IncrementCounter increments a named counter with labels
@@ -41,6 +43,70 @@ type Stats interface {
 	HitRate() float64
 }
 
+// EvictionStats is optionally implemented by Stats that break evictions down by
+// reason. Exporters use it to label eviction metrics; keys are reason names such as
+// "ttl" or "capacity"
+type EvictionStats interface {
+	EvictionsByReason() map[string]int64
+}
+
+// OperationStats is optionally implemented by Stats that count writes, deletes,
+// clears and expired entries removed by cleanup; exporters publish them as counters
+type OperationStats interface {
+	Sets() int64
+	Deletes() int64
+	Clears() int64
+	CleanupRemoved() int64
+}
+
+// ErrorStats is optionally implemented by Stats that count failed operations by
+// type. Exporters label the errors total with LabelErrorType; keys are type names
+// such as "set_failed" or "decode_failed"
+type ErrorStats interface {
+	ErrorsByType() map[string]int64
+}
+
+// StoreOperationRecorder is optionally implemented by exporters that record the
+// latency of backend store calls apart from the whole cache operation, which also
+// covers encoding, compression and hooks. Use RecordStoreOperation to fall back to
+// a StoreOperationDuration histogram on other exporters
+type StoreOperationRecorder interface {
+	RecordStoreOperation(operation Operation, duration time.Duration, labels Labels) error
+}
+
+// RecordStoreOperation records the latency of a store call on exporter, as a
+// StoreOperationDuration histogram with an operation label when exporter does not
+// implement StoreOperationRecorder
+func RecordStoreOperation(exporter Exporter, operation Operation, duration time.Duration, labels Labels) error {
+	if recorder, ok := exporter.(StoreOperationRecorder); ok {
+		return recorder.RecordStoreOperation(operation, duration, labels)
+	}
+	histogramLabels := Labels{"operation": string(operation)}
+	for k, v := range labels {
+		histogramLabels[k] = v
+	}
+	return exporter.RecordHistogram(DefaultMetricNames().StoreOperationDuration, duration.Seconds(), histogramLabels)
+}
+
+// GaugeDeleter is optionally implemented by exporters that can remove gauge
+// series, so a changing set of labelled gauges does not accumulate stale ones
+type GaugeDeleter interface {
+	// DeleteGauges removes the series of the named gauge whose labels include labels
+	DeleteGauges(name string, labels Labels) error
+}
+
+// RecentStats is optionally implemented by Stats that keep a sliding-window hit
+// rate; exporters publish it over the whole window, i.e. RecentHitRate(0)
+type RecentStats interface {
+	RecentHitRate(window time.Duration) float64
+}
+
+// MemoryStats is optionally implemented by Stats that estimate the bytes held by
+// the cache; exporters publish it as a gauge
+type MemoryStats interface {
+	MemoryBytes() int64
+}
+
 // Operation represents different cache operations for metrics
 type Operation string
 
@@ -64,44 +130,116 @@ const (
 	ResultHit   Result = "hit"
 	ResultMiss  Result = "miss"
 	ResultError Result = "error"
+
+	// ResultSuccess marks a wrapped function call that returned without error
+	ResultSuccess Result = "success"
+)
+
+// Label names with a meaning to exporters
+const (
+	// LabelFunction names the wrapped function an operation belongs to
+	LabelFunction = "function"
+
+	// LabelResult holds the Result of an operation
+	LabelResult = "result"
+
+	// LabelErrorType holds the type of a counted error
+	LabelErrorType = "type"
+
+	// LabelHook holds the kind of hook a duration was recorded for, e.g. "OnHit"
+	LabelHook = "hook"
 )
 
 // MetricNames defines standard metric names used across exporters
 type MetricNames struct {
 	// Counters
-	CacheHitsTotal          string
-	CacheMissesTotal        string
-	CacheEvictionsTotal     string
-	CacheInvalidationsTotal string
-	CacheOperationsTotal    string
-	CacheErrorsTotal        string
+	CacheHitsTotal           string
+	CacheMissesTotal         string
+	CacheEvictionsTotal      string
+	CacheInvalidationsTotal  string
+	CacheOperationsTotal     string
+	CacheErrorsTotal         string
+	DecodeErrorsTotal        string
+	CacheSetsTotal           string
+	CacheDeletesTotal        string
+	CacheClearsTotal         string
+	CacheCleanupRemovedTotal string
+
+	// Eviction strategy internals, recorded when metrics are enabled
+	StrategyAdmissionsTotal      string
+	StrategyPromotionsTotal      string
+	StrategyDemotionsTotal       string
+	StrategyAgeResetsTotal       string
+	StrategyVictimSelectDuration string
+
+	// Compression effectiveness, recorded when compression is enabled
+	CompressedEntriesTotal     string
+	CompressionDuration        string
+	DecompressionDuration      string
+	CompressionOriginalBytes   string
+	CompressionCompressedBytes string
+	CompressionRatio           string
 
 	// Histograms
 	CacheOperationDuration string
+	StoreOperationDuration string
 	CacheKeySize           string
 	CacheValueSize         string
+	HookDuration           string
 
 	// Gauges
 	CacheKeysCount        string
+	CacheMemoryBytes      string
 	CacheInFlightRequests string
 	CacheHitRate          string
+	CacheRecentHitRate    string
+	CacheBackendHealthy   string
+	CacheWriteQueueDepth  string
+	CacheBackendDegraded  string
+	HotKeyReads           string
 }
 
 // DefaultMetricNames returns the default metric names with proper namespacing
 func DefaultMetricNames() MetricNames {
 	return MetricNames{
-		CacheHitsTotal:          "obcache_hits_total",
-		CacheMissesTotal:        "obcache_misses_total",
-		CacheEvictionsTotal:     "obcache_evictions_total",
-		CacheInvalidationsTotal: "obcache_invalidations_total",
-		CacheOperationsTotal:    "obcache_operations_total",
-		CacheErrorsTotal:        "obcache_errors_total",
-		CacheOperationDuration:  "obcache_operation_duration_seconds",
-		CacheKeySize:            "obcache_key_size_bytes",
-		CacheValueSize:          "obcache_value_size_bytes",
-		CacheKeysCount:          "obcache_keys_count",
-		CacheInFlightRequests:   "obcache_inflight_requests",
-		CacheHitRate:            "obcache_hit_rate",
+		CacheHitsTotal:           "obcache_hits_total",
+		CacheMissesTotal:         "obcache_misses_total",
+		CacheEvictionsTotal:      "obcache_evictions_total",
+		CacheInvalidationsTotal:  "obcache_invalidations_total",
+		CacheOperationsTotal:     "obcache_operations_total",
+		CacheErrorsTotal:         "obcache_errors_total",
+		DecodeErrorsTotal:        "obcache_decode_errors_total",
+		CacheSetsTotal:           "obcache_sets_total",
+		CacheDeletesTotal:        "obcache_deletes_total",
+		CacheClearsTotal:         "obcache_clears_total",
+		CacheCleanupRemovedTotal: "obcache_cleanup_removed_total",
+		CacheOperationDuration:   "obcache_operation_duration_seconds",
+		StoreOperationDuration:   "obcache_store_operation_duration_seconds",
+		CacheKeySize:             "obcache_key_size_bytes",
+		CacheValueSize:           "obcache_value_size_bytes",
+		HookDuration:             "obcache_hook_duration_seconds",
+		CacheKeysCount:           "obcache_keys_count",
+		CacheMemoryBytes:         "obcache_memory_bytes",
+		CacheInFlightRequests:    "obcache_inflight_requests",
+		CacheHitRate:             "obcache_hit_rate",
+		CacheRecentHitRate:       "obcache_recent_hit_rate",
+		CacheBackendHealthy:      "obcache_backend_healthy",
+		CacheWriteQueueDepth:     "obcache_write_queue_depth",
+		CacheBackendDegraded:     "obcache_backend_degraded",
+		HotKeyReads:              "obcache_hot_key_reads",
+
+		StrategyAdmissionsTotal:      "obcache_strategy_admissions_total",
+		StrategyPromotionsTotal:      "obcache_strategy_promotions_total",
+		StrategyDemotionsTotal:       "obcache_strategy_demotions_total",
+		StrategyAgeResetsTotal:       "obcache_strategy_age_resets_total",
+		StrategyVictimSelectDuration: "obcache_strategy_victim_select_duration_seconds",
+
+		CompressedEntriesTotal:     "obcache_compressed_entries_total",
+		CompressionDuration:        "obcache_compression_duration_seconds",
+		DecompressionDuration:      "obcache_decompression_duration_seconds",
+		CompressionOriginalBytes:   "obcache_compression_original_bytes",
+		CompressionCompressedBytes: "obcache_compression_compressed_bytes",
+		CompressionRatio:           "obcache_compression_ratio",
 	}
 }
 
@@ -236,6 +374,28 @@ func (m *MultiExporter) SetGauge(name string, value float64, labels Labels) erro
 	return nil
 }
 
+// RecordStoreOperation records a store call to all configured exporters
+func (m *MultiExporter) RecordStoreOperation(operation Operation, duration time.Duration, labels Labels) error {
+	for _, exporter := range m.exporters {
+		if err := RecordStoreOperation(exporter, operation, duration, labels); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteGauges deletes from all configured exporters that support it
+func (m *MultiExporter) DeleteGauges(name string, labels Labels) error {
+	for _, exporter := range m.exporters {
+		if deleter, ok := exporter.(GaugeDeleter); ok {
+			if err := deleter.DeleteGauges(name, labels); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // Close closes all configured exporters
 func (m *MultiExporter) Close() error {
 	for _, exporter := range m.exporters {
@@ -274,6 +434,8 @@ func (n *NoOpExporter) Close() error { return nil }
 
 // Ensure interfaces are implemented
 var (
-	_ Exporter = (*MultiExporter)(nil)
-	_ Exporter = (*NoOpExporter)(nil)
+	_ Exporter               = (*MultiExporter)(nil)
+	_ GaugeDeleter           = (*MultiExporter)(nil)
+	_ StoreOperationRecorder = (*MultiExporter)(nil)
+	_ Exporter               = (*NoOpExporter)(nil)
 )
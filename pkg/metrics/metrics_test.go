@@ -171,6 +171,13 @@ func TestDefaultMetricNames(t *testing.T) {
 		{"CacheKeysCount", names.CacheKeysCount, "obcache_keys_count"},
 		{"CacheInFlightRequests", names.CacheInFlightRequests, "obcache_inflight_requests"},
 		{"CacheHitRate", names.CacheHitRate, "obcache_hit_rate"},
+		{"DecodeErrorsTotal", names.DecodeErrorsTotal, "obcache_decode_errors_total"},
+		{"CacheSetsTotal", names.CacheSetsTotal, "obcache_sets_total"},
+		{"CacheDeletesTotal", names.CacheDeletesTotal, "obcache_deletes_total"},
+		{"CacheClearsTotal", names.CacheClearsTotal, "obcache_clears_total"},
+		{"CacheCleanupRemovedTotal", names.CacheCleanupRemovedTotal, "obcache_cleanup_removed_total"},
+		{"CompressedEntriesTotal", names.CompressedEntriesTotal, "obcache_compressed_entries_total"},
+		{"CompressionRatio", names.CompressionRatio, "obcache_compression_ratio"},
 	}
 
 	for _, tt := range tests {
@@ -18,24 +18,36 @@ type PrometheusExporter struct {
 	missesTotal        *prometheus.CounterVec
 	evictionsTotal     *prometheus.CounterVec
 	invalidationsTotal *prometheus.CounterVec
+	setsTotal          *prometheus.CounterVec
+	deletesTotal       *prometheus.CounterVec
+	clearsTotal        *prometheus.CounterVec
+	cleanupRemoved     *prometheus.CounterVec
 	operationsTotal    *prometheus.CounterVec
 	errorsTotal        *prometheus.CounterVec
 
-	// Histograms
-	operationDuration *prometheus.HistogramVec
+	// Histograms (operationDuration is a summary when objectives are configured)
+	operationDuration prometheus.ObserverVec
+	storeDuration     prometheus.ObserverVec
 	keySize           *prometheus.HistogramVec
 	valueSize         *prometheus.HistogramVec
 
 	// Gauges
 	keysCount        *prometheus.GaugeVec
+	memoryBytes      *prometheus.GaugeVec
 	inFlightRequests *prometheus.GaugeVec
 	hitRate          *prometheus.GaugeVec
+	recentHitRate    *prometheus.GaugeVec
 
 	// Custom metrics (for IncrementCounter, etc.)
 	customCounters   map[string]*prometheus.CounterVec
 	customHistograms map[string]*prometheus.HistogramVec
 	customGauges     map[string]*prometheus.GaugeVec
 	mu               sync.RWMutex
+
+	// exported holds the cumulative stats last exported per counter and label
+	// set, so ExportStats adds only what changed since
+	exported   map[string]float64
+	exportedMu sync.Mutex
 }
 
 // PrometheusConfig holds Prometheus-specific configuration
@@ -46,9 +58,65 @@ type PrometheusConfig struct {
 	// DefaultLabels are applied to all metrics
 	DefaultLabels prometheus.Labels
 
-	// Buckets for histogram metrics
+	// Buckets for histogram metrics, in seconds and bytes. They must be non-empty
+	// and strictly increasing; nil selects the defaults
 	DurationBuckets []float64
 	SizeBuckets     []float64
+
+	// DurationObjectives makes operation durations a summary with these quantiles
+	// and their allowed errors, e.g. {0.5: 0.05, 0.99: 0.001}, instead of a
+	// histogram. Cannot be combined with DurationBuckets
+	DurationObjectives map[float64]float64
+}
+
+// Default histogram buckets used when PrometheusConfig leaves them nil
+var (
+	defaultDurationBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	defaultSizeBuckets     = []float64{64, 256, 1024, 4096, 16384, 65536, 262144, 1048576}
+)
+
+// validate checks the bucket and objective settings
+func (c *PrometheusConfig) validate() error {
+	if err := validateBuckets("DurationBuckets", c.DurationBuckets); err != nil {
+		return err
+	}
+	if err := validateBuckets("SizeBuckets", c.SizeBuckets); err != nil {
+		return err
+	}
+	if c.DurationObjectives == nil {
+		return nil
+	}
+	if c.DurationBuckets != nil {
+		return fmt.Errorf("DurationBuckets and DurationObjectives cannot both be set")
+	}
+	if len(c.DurationObjectives) == 0 {
+		return fmt.Errorf("DurationObjectives must not be empty")
+	}
+	for quantile, allowedErr := range c.DurationObjectives {
+		if quantile <= 0 || quantile >= 1 {
+			return fmt.Errorf("DurationObjectives: quantile %v must be between 0 and 1", quantile)
+		}
+		if allowedErr < 0 || allowedErr >= 1 {
+			return fmt.Errorf("DurationObjectives: error %v for quantile %v must be between 0 and 1", allowedErr, quantile)
+		}
+	}
+	return nil
+}
+
+// validateBuckets checks that buckets, if set, are non-empty and strictly increasing
+func validateBuckets(field string, buckets []float64) error {
+	if buckets == nil {
+		return nil
+	}
+	if len(buckets) == 0 {
+		return fmt.Errorf("%s must not be empty", field)
+	}
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] <= buckets[i-1] {
+			return fmt.Errorf("%s must be strictly increasing, got %v after %v", field, buckets[i], buckets[i-1])
+		}
+	}
+	return nil
 }
 
 // NewPrometheusExporter creates a new Prometheus metrics exporter
@@ -60,6 +128,9 @@ func NewPrometheusExporter(config *Config, promConfig *PrometheusConfig) (*Prome
 	if promConfig == nil {
 		promConfig = &PrometheusConfig{}
 	}
+	if err := promConfig.validate(); err != nil {
+		return nil, fmt.Errorf("invalid Prometheus config: %w", err)
+	}
 
 	registry := promConfig.Registry
 	if registry == nil {
@@ -69,12 +140,12 @@ func NewPrometheusExporter(config *Config, promConfig *PrometheusConfig) (*Prome
 	// Default histogram buckets
 	durationBuckets := promConfig.DurationBuckets
 	if durationBuckets == nil {
-		durationBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+		durationBuckets = defaultDurationBuckets
 	}
 
 	sizeBuckets := promConfig.SizeBuckets
 	if sizeBuckets == nil {
-		sizeBuckets = []float64{64, 256, 1024, 4096, 16384, 65536, 262144, 1048576}
+		sizeBuckets = defaultSizeBuckets
 	}
 
 	// Convert config labels to prometheus labels
@@ -96,10 +167,11 @@ func NewPrometheusExporter(config *Config, promConfig *PrometheusConfig) (*Prome
 		customCounters:   make(map[string]*prometheus.CounterVec),
 		customHistograms: make(map[string]*prometheus.HistogramVec),
 		customGauges:     make(map[string]*prometheus.GaugeVec),
+		exported:         make(map[string]float64),
 	}
 
 	// Create standard metrics
-	if err := exporter.createStandardMetrics(defaultLabels, durationBuckets, sizeBuckets); err != nil {
+	if err := exporter.createStandardMetrics(defaultLabels, durationBuckets, promConfig.DurationObjectives, sizeBuckets); err != nil {
 		return nil, fmt.Errorf("failed to create standard metrics: %w", err)
 	}
 
@@ -107,7 +179,7 @@ func NewPrometheusExporter(config *Config, promConfig *PrometheusConfig) (*Prome
 }
 
 // createStandardMetrics creates all the standard cache metrics
-func (p *PrometheusExporter) createStandardMetrics(defaultLabels prometheus.Labels, durationBuckets, sizeBuckets []float64) error {
+func (p *PrometheusExporter) createStandardMetrics(defaultLabels prometheus.Labels, durationBuckets []float64, durationObjectives map[float64]float64, sizeBuckets []float64) error {
 	var err error
 
 	// Use a consistent set of base labels for all metrics
@@ -134,19 +206,54 @@ func (p *PrometheusExporter) createStandardMetrics(defaultLabels prometheus.Labe
 		return err
 	}
 
-	p.operationsTotal, err = p.createCounterVec(p.config.MetricNames.CacheOperationsTotal, "Total number of cache operations", append(baseLabels, "operation", "result"), defaultLabels)
+	p.setsTotal, err = p.createCounterVec(p.config.MetricNames.CacheSetsTotal, "Total number of entries written", baseLabels, defaultLabels)
 	if err != nil {
 		return err
 	}
 
-	p.errorsTotal, err = p.createCounterVec(p.config.MetricNames.CacheErrorsTotal, "Total number of cache errors", append(baseLabels, "operation"), defaultLabels)
+	p.deletesTotal, err = p.createCounterVec(p.config.MetricNames.CacheDeletesTotal, "Total number of entries deleted", baseLabels, defaultLabels)
+	if err != nil {
+		return err
+	}
+
+	p.clearsTotal, err = p.createCounterVec(p.config.MetricNames.CacheClearsTotal, "Total number of cache clears", baseLabels, defaultLabels)
+	if err != nil {
+		return err
+	}
+
+	p.cleanupRemoved, err = p.createCounterVec(p.config.MetricNames.CacheCleanupRemovedTotal, "Total number of expired entries removed by cleanup", baseLabels, defaultLabels)
+	if err != nil {
+		return err
+	}
+
+	p.operationsTotal, err = p.createCounterVec(p.config.MetricNames.CacheOperationsTotal, "Total number of cache operations", append(baseLabels, LabelFunction, "operation", LabelResult), defaultLabels)
+	if err != nil {
+		return err
+	}
+
+	p.errorsTotal, err = p.createCounterVec(p.config.MetricNames.CacheErrorsTotal, "Total number of cache errors", append(baseLabels, LabelFunction, "operation", LabelErrorType), defaultLabels)
 	if err != nil {
 		return err
 	}
 
 	// Histograms
 	if p.config.IncludeDetailedTimings {
-		p.operationDuration, err = p.createHistogramVec(p.config.MetricNames.CacheOperationDuration, "Cache operation duration in seconds", append(baseLabels, "operation"), defaultLabels, durationBuckets)
+		labelNames := append(baseLabels, LabelFunction, "operation")
+		if durationObjectives != nil {
+			p.operationDuration, err = p.createSummaryVec(p.config.MetricNames.CacheOperationDuration, "Cache operation duration in seconds", labelNames, defaultLabels, durationObjectives)
+		} else {
+			p.operationDuration, err = p.createHistogramVec(p.config.MetricNames.CacheOperationDuration, "Cache operation duration in seconds", labelNames, defaultLabels, durationBuckets)
+		}
+		if err != nil {
+			return err
+		}
+
+		storeLabelNames := append(baseLabels, "operation")
+		if durationObjectives != nil {
+			p.storeDuration, err = p.createSummaryVec(p.config.MetricNames.StoreOperationDuration, "Backend store call duration in seconds", storeLabelNames, defaultLabels, durationObjectives)
+		} else {
+			p.storeDuration, err = p.createHistogramVec(p.config.MetricNames.StoreOperationDuration, "Backend store call duration in seconds", storeLabelNames, defaultLabels, durationBuckets)
+		}
 		if err != nil {
 			return err
 		}
@@ -170,6 +277,11 @@ func (p *PrometheusExporter) createStandardMetrics(defaultLabels prometheus.Labe
 		return err
 	}
 
+	p.memoryBytes, err = p.createGaugeVec(p.config.MetricNames.CacheMemoryBytes, "Approximate bytes held by cache entries", baseLabels, defaultLabels)
+	if err != nil {
+		return err
+	}
+
 	p.inFlightRequests, err = p.createGaugeVec(p.config.MetricNames.CacheInFlightRequests, "Current number of in-flight requests", baseLabels, defaultLabels)
 	if err != nil {
 		return err
@@ -180,6 +292,11 @@ func (p *PrometheusExporter) createStandardMetrics(defaultLabels prometheus.Labe
 		return err
 	}
 
+	p.recentHitRate, err = p.createGaugeVec(p.config.MetricNames.CacheRecentHitRate, "Cache hit rate over the recent window as a percentage", baseLabels, defaultLabels)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -192,46 +309,122 @@ func (p *PrometheusExporter) ExportStats(stats Stats, labels Labels) error {
 	}
 
 	// Update counters that only need cache_name
-	p.hitsTotal.With(baseLabels).Add(float64(stats.Hits()))
-	p.missesTotal.With(baseLabels).Add(float64(stats.Misses()))
-	p.invalidationsTotal.With(baseLabels).Add(float64(stats.Invalidations()))
+	p.addSinceLastExport(p.hitsTotal, baseLabels, stats.Hits())
+	p.addSinceLastExport(p.missesTotal, baseLabels, stats.Misses())
+	p.addSinceLastExport(p.invalidationsTotal, baseLabels, stats.Invalidations())
+	if operationStats, ok := stats.(OperationStats); ok {
+		p.addSinceLastExport(p.setsTotal, baseLabels, operationStats.Sets())
+		p.addSinceLastExport(p.deletesTotal, baseLabels, operationStats.Deletes())
+		p.addSinceLastExport(p.clearsTotal, baseLabels, operationStats.Clears())
+		p.addSinceLastExport(p.cleanupRemoved, baseLabels, operationStats.CleanupRemoved())
+	}
 
 	// For evictions, we need to add the reason label
-	evictionLabels := make(prometheus.Labels)
-	for k, v := range baseLabels {
-		evictionLabels[k] = v
+	evictionsByReason := map[string]int64{"capacity": stats.Evictions()} // Default when stats carry no breakdown
+	if reasonStats, ok := stats.(EvictionStats); ok {
+		evictionsByReason = reasonStats.EvictionsByReason()
+	}
+	for reason, count := range evictionsByReason {
+		evictionLabels := make(prometheus.Labels)
+		for k, v := range baseLabels {
+			evictionLabels[k] = v
+		}
+		evictionLabels["reason"] = reason
+		p.addSinceLastExport(p.evictionsTotal, evictionLabels, count)
+	}
+
+	if errorStats, ok := stats.(ErrorStats); ok {
+		for errorType, count := range errorStats.ErrorsByType() {
+			errorLabels := prometheus.Labels{LabelFunction: "", "operation": "", LabelErrorType: errorType}
+			for k, v := range baseLabels {
+				errorLabels[k] = v
+			}
+			p.addSinceLastExport(p.errorsTotal, errorLabels, count)
+		}
 	}
-	evictionLabels["reason"] = "capacity" // Default reason for stats export
-	p.evictionsTotal.With(evictionLabels).Add(float64(stats.Evictions()))
 
 	// Update gauges
 	p.keysCount.With(baseLabels).Set(float64(stats.KeyCount()))
 	p.inFlightRequests.With(baseLabels).Set(float64(stats.InFlight()))
 	p.hitRate.With(baseLabels).Set(stats.HitRate())
+	if recentStats, ok := stats.(RecentStats); ok {
+		p.recentHitRate.With(baseLabels).Set(recentStats.RecentHitRate(0))
+	}
+	if memoryStats, ok := stats.(MemoryStats); ok {
+		p.memoryBytes.With(baseLabels).Set(float64(memoryStats.MemoryBytes()))
+	}
 
 	return nil
 }
 
+// addSinceLastExport advances counter to the cumulative total from Stats
+// A total below the last one means the stats were reset, so it is added in full
+func (p *PrometheusExporter) addSinceLastExport(counter *prometheus.CounterVec, labels prometheus.Labels, total int64) {
+	c := counter.With(labels)
+	key := fmt.Sprintf("%p%v", counter, labels)
+
+	p.exportedMu.Lock()
+	delta := float64(total) - p.exported[key]
+	if delta < 0 {
+		delta = float64(total)
+	}
+	p.exported[key] = float64(total)
+	p.exportedMu.Unlock()
+
+	if delta > 0 {
+		c.Add(delta)
+	}
+}
+
 // RecordCacheOperation records a cache operation with timing
+// Operations labelled with a result are also counted, and errors counted again
+// in the errors total
 func (p *PrometheusExporter) RecordCacheOperation(operation Operation, duration time.Duration, labels Labels) error {
-	// Extract only cache_name for basic operations
-	baseLabels := prometheus.Labels{}
-	if cacheName, exists := labels["cache_name"]; exists {
-		baseLabels["cache_name"] = cacheName
+	// Extract only cache_name and the wrapped function for operations
+	opLabels := prometheus.Labels{
+		LabelFunction: labels[LabelFunction],
+		"operation":   string(operation),
 	}
-
-	// Add operation to labels
-	opLabels := prometheus.Labels{}
-	for k, v := range baseLabels {
-		opLabels[k] = v
+	if cacheName, exists := labels["cache_name"]; exists {
+		opLabels["cache_name"] = cacheName
 	}
-	opLabels["operation"] = string(operation)
 
 	// Record operation timing if enabled
 	if p.operationDuration != nil {
 		p.operationDuration.With(opLabels).Observe(duration.Seconds())
 	}
 
+	result, counted := labels[LabelResult]
+	if !counted {
+		return nil
+	}
+	if result == string(ResultError) {
+		errorLabels := prometheus.Labels{LabelErrorType: labels[LabelErrorType]}
+		for k, v := range opLabels {
+			errorLabels[k] = v
+		}
+		p.errorsTotal.With(errorLabels).Inc()
+	}
+
+	resultLabels := prometheus.Labels{LabelResult: result}
+	for k, v := range opLabels {
+		resultLabels[k] = v
+	}
+	p.operationsTotal.With(resultLabels).Inc()
+	return nil
+}
+
+// RecordStoreOperation records the duration of a backend store call when
+// detailed timings are enabled
+func (p *PrometheusExporter) RecordStoreOperation(operation Operation, duration time.Duration, labels Labels) error {
+	if p.storeDuration == nil {
+		return nil
+	}
+	storeLabels := prometheus.Labels{"operation": string(operation)}
+	if cacheName, exists := labels["cache_name"]; exists {
+		storeLabels["cache_name"] = cacheName
+	}
+	p.storeDuration.With(storeLabels).Observe(duration.Seconds())
 	return nil
 }
 
@@ -305,6 +498,18 @@ func (p *PrometheusExporter) SetGauge(name string, value float64, labels Labels)
 	return nil
 }
 
+// DeleteGauges removes the series of a custom gauge whose labels include labels
+func (p *PrometheusExporter) DeleteGauges(name string, labels Labels) error {
+	p.mu.RLock()
+	gauge, exists := p.customGauges[name]
+	p.mu.RUnlock()
+
+	if exists {
+		gauge.DeletePartialMatch(p.convertLabels(labels))
+	}
+	return nil
+}
+
 // Close shuts down the exporter
 func (p *PrometheusExporter) Close() error {
 	// Prometheus metrics don't need explicit cleanup
@@ -348,6 +553,24 @@ func (p *PrometheusExporter) createHistogramVec(name, help string, labelNames []
 	return histogram, nil
 }
 
+func (p *PrometheusExporter) createSummaryVec(name, help string, labelNames []string, defaultLabels prometheus.Labels, objectives map[float64]float64) (*prometheus.SummaryVec, error) {
+	summary := prometheus.NewSummaryVec(
+		prometheus.SummaryOpts{
+			Name:        name,
+			Help:        help,
+			ConstLabels: defaultLabels,
+			Objectives:  objectives,
+		},
+		labelNames,
+	)
+
+	if err := p.registry.Register(summary); err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
 func (p *PrometheusExporter) createGaugeVec(name, help string, labelNames []string, defaultLabels prometheus.Labels) (*prometheus.GaugeVec, error) {
 	gauge := prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -398,4 +621,8 @@ func (p *PrometheusExporter) getLabelNames(labels Labels) []string {
 }
 
 // Ensure interface is implemented
-var _ Exporter = (*PrometheusExporter)(nil)
+var (
+	_ Exporter               = (*PrometheusExporter)(nil)
+	_ GaugeDeleter           = (*PrometheusExporter)(nil)
+	_ StoreOperationRecorder = (*PrometheusExporter)(nil)
+)
@@ -0,0 +1,266 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// mockEvictionStats adds a per-reason eviction breakdown to mockStats
+type mockEvictionStats struct {
+	mockStats
+	byReason map[string]int64
+}
+
+func (m *mockEvictionStats) EvictionsByReason() map[string]int64 { return m.byReason }
+
+func TestPrometheusExporterEvictionsByReason(t *testing.T) {
+	exporter, err := NewPrometheusExporter(nil, &PrometheusConfig{Registry: prometheus.NewRegistry()})
+	if err != nil {
+		t.Fatalf("Failed to create Prometheus exporter: %v", err)
+	}
+	labels := Labels{"cache_name": "test"}
+	evictions := func(reason string) float64 {
+		return testutil.ToFloat64(exporter.evictionsTotal.With(prometheus.Labels{"cache_name": "test", "reason": reason}))
+	}
+
+	stats := &mockEvictionStats{
+		mockStats: mockStats{hits: 4, evictions: 3},
+		byReason:  map[string]int64{"ttl": 2, "capacity": 1},
+	}
+	if err := exporter.ExportStats(stats, labels); err != nil {
+		t.Fatalf("ExportStats failed: %v", err)
+	}
+	if evictions("ttl") != 2 || evictions("capacity") != 1 {
+		t.Errorf("Expected 2 ttl and 1 capacity evictions, got %v and %v", evictions("ttl"), evictions("capacity"))
+	}
+
+	// Exporting the same cumulative totals again must not count them twice
+	stats.hits = 5
+	stats.byReason = map[string]int64{"ttl": 2, "capacity": 4}
+	if err := exporter.ExportStats(stats, labels); err != nil {
+		t.Fatalf("ExportStats failed: %v", err)
+	}
+	if evictions("ttl") != 2 || evictions("capacity") != 4 {
+		t.Errorf("Expected 2 ttl and 4 capacity evictions, got %v and %v", evictions("ttl"), evictions("capacity"))
+	}
+	if hits := testutil.ToFloat64(exporter.hitsTotal.With(prometheus.Labels{"cache_name": "test"})); hits != 5 {
+		t.Errorf("Expected 5 hits, got %v", hits)
+	}
+
+	// After a stats reset the new totals are added on top
+	stats.byReason = map[string]int64{"ttl": 1}
+	if err := exporter.ExportStats(stats, labels); err != nil {
+		t.Fatalf("ExportStats failed: %v", err)
+	}
+	if evictions("ttl") != 3 || evictions("capacity") != 4 {
+		t.Errorf("Expected 3 ttl and 4 capacity evictions, got %v and %v", evictions("ttl"), evictions("capacity"))
+	}
+}
+
+// mockErrorStats adds a per-type error breakdown to mockStats
+type mockErrorStats struct {
+	mockStats
+	byType map[string]int64
+}
+
+func (m *mockErrorStats) ErrorsByType() map[string]int64 { return m.byType }
+
+func TestPrometheusExporterErrorsByType(t *testing.T) {
+	exporter, err := NewPrometheusExporter(nil, &PrometheusConfig{Registry: prometheus.NewRegistry()})
+	if err != nil {
+		t.Fatalf("Failed to create Prometheus exporter: %v", err)
+	}
+	labels := Labels{"cache_name": "test"}
+	errorsOf := func(errorType string) float64 {
+		return testutil.ToFloat64(exporter.errorsTotal.With(prometheus.Labels{
+			"cache_name": "test", LabelFunction: "", "operation": "", LabelErrorType: errorType,
+		}))
+	}
+
+	stats := &mockErrorStats{byType: map[string]int64{"set_failed": 3, "decode_failed": 1}}
+	_ = exporter.ExportStats(stats, labels)
+	stats.byType = map[string]int64{"set_failed": 5, "decode_failed": 1}
+	_ = exporter.ExportStats(stats, labels)
+	if errorsOf("set_failed") != 5 || errorsOf("decode_failed") != 1 {
+		t.Errorf("Expected 5 set and 1 decode failures, got %v and %v", errorsOf("set_failed"), errorsOf("decode_failed"))
+	}
+
+	// Failed operations are counted without a type
+	_ = exporter.RecordCacheOperation(OperationFunctionCall, time.Millisecond, Labels{
+		"cache_name": "test", LabelFunction: "load", LabelResult: string(ResultError),
+	})
+	failed := testutil.ToFloat64(exporter.errorsTotal.With(prometheus.Labels{
+		"cache_name": "test", LabelFunction: "load", "operation": string(OperationFunctionCall), LabelErrorType: "",
+	}))
+	if failed != 1 {
+		t.Errorf("Expected 1 failed call, got %v", failed)
+	}
+}
+
+func TestPrometheusExporterGauges(t *testing.T) {
+	exporter, err := NewPrometheusExporter(nil, &PrometheusConfig{Registry: prometheus.NewRegistry()})
+	if err != nil {
+		t.Fatalf("Failed to create Prometheus exporter: %v", err)
+	}
+	if err := exporter.ExportStats(fixedStats{}, Labels{"cache_name": "test"}); err != nil {
+		t.Fatalf("ExportStats failed: %v", err)
+	}
+	if got := testutil.ToFloat64(exporter.memoryBytes.With(prometheus.Labels{"cache_name": "test"})); got != 4096 {
+		t.Errorf("Expected obcache_memory_bytes to be 4096, got %v", got)
+	}
+	if got := testutil.ToFloat64(exporter.recentHitRate.With(prometheus.Labels{"cache_name": "test"})); got != 40 {
+		t.Errorf("Expected obcache_recent_hit_rate to be 40, got %v", got)
+	}
+}
+
+func TestPrometheusExporterDurationBuckets(t *testing.T) {
+	buckets := []float64{1e-6, 5e-6, 10e-6, 50e-6, 100e-6}
+	registry := prometheus.NewRegistry()
+	exporter, err := NewPrometheusExporter(NewDefaultConfig().WithDetailedTimings(true), &PrometheusConfig{
+		Registry:        registry,
+		DurationBuckets: buckets,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create Prometheus exporter: %v", err)
+	}
+	_ = exporter.RecordCacheOperation(OperationGet, 3*time.Microsecond, Labels{"cache_name": "test"})
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	var found bool
+	for _, family := range families {
+		if family.GetName() != "obcache_operation_duration_seconds" {
+			continue
+		}
+		found = true
+		histogram := family.GetMetric()[0].GetHistogram()
+		if len(histogram.GetBucket()) != len(buckets) {
+			t.Fatalf("Expected %d buckets, got %d", len(buckets), len(histogram.GetBucket()))
+		}
+		for i, bucket := range histogram.GetBucket() {
+			if bucket.GetUpperBound() != buckets[i] {
+				t.Errorf("Expected bucket %d to end at %v, got %v", i, buckets[i], bucket.GetUpperBound())
+			}
+			want := uint64(1)
+			if i == 0 {
+				want = 0
+			}
+			if bucket.GetCumulativeCount() != want {
+				t.Errorf("Expected bucket %v to count %d, got %d", buckets[i], want, bucket.GetCumulativeCount())
+			}
+		}
+	}
+	if !found {
+		t.Fatal("Expected the operation duration histogram to be registered")
+	}
+}
+
+func TestPrometheusExporterDefaultDurationBuckets(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	exporter, err := NewPrometheusExporter(NewDefaultConfig().WithDetailedTimings(true), &PrometheusConfig{Registry: registry})
+	if err != nil {
+		t.Fatalf("Failed to create Prometheus exporter: %v", err)
+	}
+	_ = exporter.RecordCacheOperation(OperationGet, time.Millisecond, Labels{"cache_name": "test"})
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() == "obcache_operation_duration_seconds" {
+			if n := len(family.GetMetric()[0].GetHistogram().GetBucket()); n != len(defaultDurationBuckets) {
+				t.Errorf("Expected the %d default buckets, got %d", len(defaultDurationBuckets), n)
+			}
+			return
+		}
+	}
+	t.Fatal("Expected the operation duration histogram to be registered")
+}
+
+func TestPrometheusExporterDurationObjectives(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	exporter, err := NewPrometheusExporter(NewDefaultConfig().WithDetailedTimings(true), &PrometheusConfig{
+		Registry:           registry,
+		DurationObjectives: map[float64]float64{0.5: 0.05, 0.99: 0.001},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create Prometheus exporter: %v", err)
+	}
+	for i := range 100 {
+		_ = exporter.RecordCacheOperation(OperationGet, time.Duration(i+1)*time.Microsecond, Labels{"cache_name": "test"})
+	}
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != "obcache_operation_duration_seconds" {
+			continue
+		}
+		summary := family.GetMetric()[0].GetSummary()
+		if summary == nil || len(summary.GetQuantile()) != 2 {
+			t.Fatalf("Expected a summary with 2 quantiles, got %v", family)
+		}
+		if summary.GetSampleCount() != 100 {
+			t.Errorf("Expected 100 observations, got %d", summary.GetSampleCount())
+		}
+		return
+	}
+	t.Fatal("Expected the operation duration summary to be registered")
+}
+
+func TestPrometheusExporterStoreOperationDuration(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	exporter, err := NewPrometheusExporter(NewDefaultConfig().WithDetailedTimings(true), &PrometheusConfig{Registry: registry})
+	if err != nil {
+		t.Fatalf("Failed to create Prometheus exporter: %v", err)
+	}
+	labels := Labels{"cache_name": "test"}
+	_ = RecordStoreOperation(exporter, OperationGet, time.Millisecond, labels)
+	_ = RecordStoreOperation(exporter, OperationGet, 2*time.Millisecond, labels)
+	_ = exporter.RecordCacheOperation(OperationGet, 5*time.Millisecond, labels)
+
+	counts := make(map[string]uint64)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			counts[family.GetName()] += metric.GetHistogram().GetSampleCount()
+		}
+	}
+	if counts["obcache_store_operation_duration_seconds"] != 2 || counts["obcache_operation_duration_seconds"] != 1 {
+		t.Errorf("Expected 2 store and 1 operation timings, got %v", counts)
+	}
+}
+
+func TestPrometheusConfigValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		config PrometheusConfig
+	}{
+		{"empty duration buckets", PrometheusConfig{DurationBuckets: []float64{}}},
+		{"unsorted duration buckets", PrometheusConfig{DurationBuckets: []float64{0.1, 0.01}}},
+		{"duplicate size buckets", PrometheusConfig{SizeBuckets: []float64{64, 64}}},
+		{"buckets and objectives", PrometheusConfig{DurationBuckets: []float64{0.1}, DurationObjectives: map[float64]float64{0.5: 0.05}}},
+		{"empty objectives", PrometheusConfig{DurationObjectives: map[float64]float64{}}},
+		{"quantile out of range", PrometheusConfig{DurationObjectives: map[float64]float64{1.5: 0.05}}},
+		{"error out of range", PrometheusConfig{DurationObjectives: map[float64]float64{0.5: -1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Registry = prometheus.NewRegistry()
+			if _, err := NewPrometheusExporter(nil, &tt.config); err == nil {
+				t.Error("Expected a construction error")
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package obcache
+
+import (
+	"hash/maphash"
+	"math"
+	"sync"
+)
+
+// AdmissionPolicy decides whether a key that is not yet cached may be stored
+// Rejected writes still succeed for the caller; the value is simply not retained
+type AdmissionPolicy interface {
+	// Admit records an attempt to store key and reports whether to store it
+	// It is called concurrently by writes to different keys
+	Admit(key string) bool
+}
+
+// doorkeeperHashes is the number of bit positions set per key
+const doorkeeperHashes = 4
+
+// Doorkeeper is an AdmissionPolicy that admits a key on its second appearance
+// Seen keys are remembered in a bloom filter sized for window keys. After
+// window distinct keys have been recorded the filter is cleared, so keys must
+// reappear within roughly window writes to be admitted and the filter never
+// saturates. False positives admit a small fraction of one-hit keys early.
+type Doorkeeper struct {
+	bits    []uint64
+	mask    uint64
+	seeds   [2]maphash.Seed
+	window  int
+	entries int
+	mutex   sync.Mutex
+}
+
+// NewDoorkeeper creates a doorkeeper that remembers about window keys
+func NewDoorkeeper(window int) *Doorkeeper {
+	window = max(window, 1)
+
+	// ~10 bits per key keeps the false positive rate around 1-2% with 4 hashes
+	size := uint64(1) << uint(math.Ceil(math.Log2(float64(window*10))))
+	size = max(size, 64)
+
+	return &Doorkeeper{
+		bits:   make([]uint64, size/64),
+		mask:   size - 1,
+		seeds:  [2]maphash.Seed{maphash.MakeSeed(), maphash.MakeSeed()},
+		window: window,
+	}
+}
+
+// Admit returns true if key was seen since the last reset, and remembers it otherwise
+func (d *Doorkeeper) Admit(key string) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	h1 := maphash.String(d.seeds[0], key)
+	h2 := maphash.String(d.seeds[1], key) | 1
+
+	seen := true
+	for i := uint64(0); i < doorkeeperHashes; i++ {
+		pos := (h1 + i*h2) & d.mask
+		word, bit := pos/64, uint64(1)<<(pos%64)
+		if d.bits[word]&bit == 0 {
+			seen = false
+			d.bits[word] |= bit
+		}
+	}
+	if seen {
+		return true
+	}
+
+	d.entries++
+	if d.entries >= d.window {
+		d.reset()
+	}
+	return false
+}
+
+// Reset forgets every key seen so far
+// Happens automatically once the window is full; callers may also reset on a timer
+func (d *Doorkeeper) Reset() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.reset()
+}
+
+// reset clears the filter (internal method, assumes lock is held)
+func (d *Doorkeeper) reset() {
+	clear(d.bits)
+	d.entries = 0
+}
@@ -0,0 +1,81 @@
+package obcache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDoorkeeper(t *testing.T) {
+	d := NewDoorkeeper(100)
+
+	if d.Admit("key") {
+		t.Fatal("Expected first appearance to be rejected")
+	}
+	if !d.Admit("key") {
+		t.Fatal("Expected second appearance to be admitted")
+	}
+
+	d.Reset()
+	if d.Admit("key") {
+		t.Fatal("Expected key to be forgotten after Reset")
+	}
+}
+
+func TestDoorkeeperWindowReset(t *testing.T) {
+	d := NewDoorkeeper(10)
+	d.Admit("old")
+
+	// Filling the window clears the filter
+	for i := 0; i < 10; i++ {
+		d.Admit(fmt.Sprintf("key%d", i))
+	}
+	if d.Admit("old") {
+		t.Error("Expected key from a previous window to be rejected")
+	}
+}
+
+func TestDoorkeeperFalsePositives(t *testing.T) {
+	d := NewDoorkeeper(10000)
+	admitted := 0
+	for i := 0; i < 5000; i++ {
+		if d.Admit(fmt.Sprintf("key%d", i)) {
+			admitted++
+		}
+	}
+	if admitted > 250 {
+		t.Errorf("Expected few false positives for distinct keys, got %d of 5000", admitted)
+	}
+}
+
+func TestAdmissionPolicy(t *testing.T) {
+	cache, err := New(NewDefaultConfig().WithAdmissionPolicy(NewDoorkeeper(100)))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	if err := cache.Set("key", "value1", time.Hour); err != nil {
+		t.Fatalf("Expected rejected Set to succeed, got %v", err)
+	}
+	if cache.Has("key") {
+		t.Fatal("Expected first Set to be rejected by the doorkeeper")
+	}
+	if cache.Stats().AdmissionRejections() != 1 {
+		t.Errorf("Expected 1 admission rejection, got %d", cache.Stats().AdmissionRejections())
+	}
+
+	_ = cache.Set("key", "value2", time.Hour)
+	if value, found := cache.Get("key"); !found || value != "value2" {
+		t.Fatalf("Expected second Set to be admitted, got %v (found=%v)", value, found)
+	}
+
+	// Updates to cached keys bypass the policy
+	_ = cache.Set("key", "value3", time.Hour)
+	if value, _ := cache.Get("key"); value != "value3" {
+		t.Errorf("Expected update of cached key, got %v", value)
+	}
+	if cache.Stats().AdmissionRejections() != 1 {
+		t.Errorf("Expected rejections to stay at 1, got %d", cache.Stats().AdmissionRejections())
+	}
+}
@@ -3,9 +3,12 @@ package obcache
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/compression"
 )
 
 // Helper functions for benchmarking
@@ -68,6 +71,30 @@ func BenchmarkCacheSet(b *testing.B) {
 	}
 }
 
+// largeRecord is a struct that serializes to about 100 KB of JSON
+type largeRecord struct {
+	ID    int      `json:"id"`
+	Items []string `json:"items"`
+}
+
+func BenchmarkCacheSetCompressed(b *testing.B) {
+	cache, err := New(NewDefaultConfig().WithCompression(compression.NewDefaultConfig().WithEnabled(true)))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	record := largeRecord{ID: 1, Items: make([]string, 4000)}
+	for i := range record.Items {
+		record.Items[i] = fmt.Sprintf("item-%06d-payload", i)
+	}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		_ = cache.Set("record", record, TestTTL)
+	}
+}
+
 func BenchmarkCacheGet(b *testing.B) {
 	cache, err := New(NewDefaultConfig())
 	if err != nil {
@@ -104,6 +131,62 @@ func BenchmarkCacheGetMiss(b *testing.B) {
 	}
 }
 
+// BenchmarkCacheGetHooks measures a hit with no hooks and with 10 OnHit hooks of
+// different priorities; the hooks are ordered at registration, so they add no allocations
+func BenchmarkCacheGetHooks(b *testing.B) {
+	for _, n := range []int{0, 10} {
+		b.Run(fmt.Sprintf("hooks=%d", n), func(b *testing.B) {
+			hooks := NewHooks()
+			for i := range n {
+				hooks.AddOnHit(func(context.Context, string, any) {}, WithPriority(i%3))
+			}
+			cache, err := New(NewDefaultConfig().WithHooks(hooks))
+			if err != nil {
+				b.Fatal(err)
+			}
+			_ = cache.Set("key", "value", TestTTL)
+
+			b.ResetTimer()
+			b.ReportAllocs()
+			for b.Loop() {
+				_, _ = cache.Get("key")
+			}
+		})
+	}
+}
+
+// BenchmarkCacheGetScopedHooks measures a Get on a key outside 300 hooks scoped
+// to other prefixes, indexed with WithKeyPrefix or filtered with WithCondition
+func BenchmarkCacheGetScopedHooks(b *testing.B) {
+	scopes := map[string]func(prefix string) HookOption{
+		"KeyPrefix": WithKeyPrefix,
+		"Condition": func(prefix string) HookOption {
+			return WithCondition(func(_ context.Context, key string) bool {
+				return strings.HasPrefix(key, prefix)
+			})
+		},
+	}
+	for name, scope := range scopes {
+		b.Run(name, func(b *testing.B) {
+			hooks := NewHooks()
+			for i := range 300 {
+				hooks.AddOnHit(func(context.Context, string, any) {}, scope(fmt.Sprintf("tenant%d:", i)))
+			}
+			cache, err := New(NewDefaultConfig().WithHooks(hooks))
+			if err != nil {
+				b.Fatal(err)
+			}
+			_ = cache.Set("other:key", "value", TestTTL)
+
+			b.ResetTimer()
+			b.ReportAllocs()
+			for b.Loop() {
+				_, _ = cache.Get("other:key")
+			}
+		})
+	}
+}
+
 // Benchmark: Function Wrapping vs Direct Calls
 
 func BenchmarkDirectFunction(b *testing.B) {
@@ -318,6 +401,96 @@ func BenchmarkConcurrentCacheSet(b *testing.B) {
 	})
 }
 
+// BenchmarkConcurrentCacheGetSharded compares concurrent reads on a single memory
+// store with a sharded one; every LRU hit updates the recency list under a lock,
+// which sharding splits across shards
+func BenchmarkConcurrentCacheGetSharded(b *testing.B) {
+	keys := make([]string, 4096)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%d", i)
+	}
+
+	for _, bc := range []struct {
+		name   string
+		shards int
+	}{
+		{"single", 0},
+		{"sharded", AutoShardCount},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			cache, err := New(NewDefaultConfig().WithMaxEntries(len(keys)).WithShardCount(bc.shards))
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer func() { _ = cache.Close() }()
+			for i, key := range keys {
+				_ = cache.Set(key, i, time.Hour) // Benchmark setup
+			}
+
+			b.ResetTimer()
+			b.ReportAllocs()
+
+			var seed atomic.Int64
+			b.RunParallel(func(pb *testing.PB) {
+				i := int(seed.Add(7919)) // Start goroutines at different keys
+				for pb.Next() {
+					_, _ = cache.Get(keys[i%len(keys)])
+					i++
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkConcurrentCacheSetSharded compares concurrent writes, alone and mixed
+// with reads, on a single memory store with a sharded one. Writes only share the
+// cache lock, so with sharding they contend on the shard locks alone
+func BenchmarkConcurrentCacheSetSharded(b *testing.B) {
+	keys := make([]string, 4096)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%d", i)
+	}
+
+	for _, bc := range []struct {
+		name   string
+		shards int
+		reads  int // Reads per write
+	}{
+		{"single/set", 0, 0},
+		{"sharded/set", AutoShardCount, 0},
+		{"single/mixed", 0, 3},
+		{"sharded/mixed", AutoShardCount, 3},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			cache, err := New(NewDefaultConfig().WithMaxEntries(len(keys)).WithShardCount(bc.shards))
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer func() { _ = cache.Close() }()
+			for i, key := range keys {
+				_ = cache.Set(key, i, time.Hour) // Benchmark setup
+			}
+
+			b.ResetTimer()
+			b.ReportAllocs()
+
+			var seed atomic.Int64
+			b.RunParallel(func(pb *testing.PB) {
+				i := int(seed.Add(7919)) // Start goroutines at different keys
+				for pb.Next() {
+					key := keys[i%len(keys)]
+					if i%(bc.reads+1) == 0 {
+						_ = cache.Set(key, i, time.Hour)
+					} else {
+						_, _ = cache.Get(key)
+					}
+					i++
+				}
+			})
+		})
+	}
+}
+
 func BenchmarkConcurrentWrappedFunction(b *testing.B) {
 	cache, err := New(NewDefaultConfig())
 	if err != nil {
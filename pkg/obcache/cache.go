@@ -2,35 +2,238 @@ package obcache
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"math"
 	"reflect"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	clientv3 "go.etcd.io/etcd/client/v3"
 
-	"github.com/1mb-dev/obcache-go/v2/internal/entry"
 	"github.com/1mb-dev/obcache-go/v2/internal/eviction"
+	"github.com/1mb-dev/obcache-go/v2/internal/hotkeys"
 	"github.com/1mb-dev/obcache-go/v2/internal/singleflight"
-	"github.com/1mb-dev/obcache-go/v2/internal/store"
+	blobstore "github.com/1mb-dev/obcache-go/v2/internal/store/blob"
+	boltstore "github.com/1mb-dev/obcache-go/v2/internal/store/bolt"
+	distributedstore "github.com/1mb-dev/obcache-go/v2/internal/store/distributed"
+	etcdstore "github.com/1mb-dev/obcache-go/v2/internal/store/etcd"
 	"github.com/1mb-dev/obcache-go/v2/internal/store/memory"
 	redisstore "github.com/1mb-dev/obcache-go/v2/internal/store/redis"
+	ristrettostore "github.com/1mb-dev/obcache-go/v2/internal/store/ristretto"
+	"github.com/1mb-dev/obcache-go/v2/internal/store/spill"
+	sqlitestore "github.com/1mb-dev/obcache-go/v2/internal/store/sqlite"
+	tieredstore "github.com/1mb-dev/obcache-go/v2/internal/store/tiered"
+	"github.com/1mb-dev/obcache-go/v2/internal/store/writebehind"
+	"github.com/1mb-dev/obcache-go/v2/pkg/codec"
 	"github.com/1mb-dev/obcache-go/v2/pkg/compression"
+	"github.com/1mb-dev/obcache-go/v2/pkg/encryption"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
 	"github.com/1mb-dev/obcache-go/v2/pkg/metrics"
+	"github.com/1mb-dev/obcache-go/v2/pkg/store"
 )
 
+// NoTTL can be passed as the ttl to Set and SetContext to store an entry that
+// never expires, regardless of the configured DefaultTTL. Any negative ttl is
+// treated as NoTTL, while a ttl of 0 still means "use DefaultTTL".
+const NoTTL time.Duration = -1
+
 func (c *Cache) hit(ctx context.Context, key string, value any) {
 	c.stats.incHits()
+	if c.hotKeys != nil {
+		c.hotKeys.Record(key)
+	}
 	if c.hooks != nil {
-		c.hooks.invokeOnHitWithCtx(ctx, key, value, nil)
+		c.hookRan("OnHit", c.hooks.invokeOnHitWithCtx(ctx, key, value, nil))
 	}
 }
 
 func (c *Cache) miss(ctx context.Context, key string) {
 	c.stats.incMisses()
+	if c.hotKeys != nil {
+		c.hotKeys.Record(key)
+	}
+	if c.hooks != nil {
+		c.hookRan("OnMiss", c.hooks.invokeOnMissWithCtx(ctx, key, nil))
+	}
+}
+
+// beforeSet passes a write through BeforeSet hooks, returning the value and
+// resolved TTL to store, or proceed=false if a hook vetoed the write
+func (c *Cache) beforeSet(ctx context.Context, key string, value any, ttl time.Duration) (any, time.Duration, bool, error) {
+	if c.hooks == nil {
+		return value, ttl, true, nil
+	}
+	value, ttl, proceed, elapsed, err := c.hooks.invokeBeforeSetWithCtx(ctx, key, value, ttl)
+	c.hookRan("BeforeSet", elapsed)
+	return value, c.resolveTTL(ttl), proceed, err
+}
+
+// evicted counts an entry the store removed and passes it to OnEvict hooks
+// Stores may call it with their own locks held
+func (c *Cache) evicted(key string, value any, info EntryInfo, reason EvictReason) {
+	c.stats.incEvictions(reason)
+	c.refreshMemoryBytes()
+	if reason == EvictReasonCapacity && c.capacity != nil {
+		c.capacity.evicted.Store(true) // Reported after the write that caused it
+	}
+	if c.hooks == nil {
+		return
+	}
+
+	c.evictMu.Lock()
+	if c.deferEvicts {
+		c.pendingEvicts = append(c.pendingEvicts, pendingEvict{key: key, value: value, info: info, reason: reason})
+		c.evictMu.Unlock()
+		return
+	}
+	c.evictMu.Unlock()
+	c.fireEvicted(key, value, info, reason)
+}
+
+// fireEvicted passes an evicted entry to OnEvict hooks
+func (c *Cache) fireEvicted(key string, value any, info EntryInfo, reason EvictReason) {
+	evict, evictInfo := c.hooks.invokeOnEvict(key, value, reason, info)
+	c.hookRan("OnEvict", evict)
+	c.hookRan("OnEvictInfo", evictInfo)
+}
+
+// pendingEvict is an eviction whose OnEvict hooks wait for c.mu to be released
+type pendingEvict struct {
+	key    string
+	value  any
+	info   EntryInfo
+	reason EvictReason
+}
+
+// deferEvictions queues OnEvict hooks until takeEvictions, so the store can
+// evict while c.mu is held exclusively without hooks running under it
+// (assumes c.mu is held exclusively)
+func (c *Cache) deferEvictions() {
+	c.evictMu.Lock()
+	c.deferEvicts = true
+	c.evictMu.Unlock()
+}
+
+// takeEvictions stops queueing OnEvict hooks and returns the queued evictions
+// for firePendingEvictions (assumes c.mu is held exclusively)
+func (c *Cache) takeEvictions() []pendingEvict {
+	c.evictMu.Lock()
+	pending := c.pendingEvicts
+	c.deferEvicts, c.pendingEvicts = false, nil
+	c.evictMu.Unlock()
+	return pending
+}
+
+// firePendingEvictions runs the OnEvict hooks of evictions returned by takeEvictions
+// The caller must not hold c.mu
+func (c *Cache) firePendingEvictions(pending []pendingEvict) {
+	for _, e := range pending {
+		c.fireEvicted(e.key, e.value, e.info, e.reason)
+	}
+}
+
+// evictedEntry is evicted for stores that report the whole entry, which is nil
+// when the store no longer has it
+func (c *Cache) evictedEntry(key string, e *entry.Entry, reason EvictReason) {
+	if e == nil {
+		c.evicted(key, nil, EntryInfo{}, reason)
+		return
+	}
+	c.evicted(key, e.Value, newEntryInfo(e), reason)
+}
+
+// stored fires OnSet hooks for a write the store accepted
+// The caller must not hold c.mu
+func (c *Cache) stored(ctx context.Context, key string, value any, ttl time.Duration) {
+	if c.hooks != nil {
+		c.hookRan("OnSet", c.hooks.invokeOnSetWithCtx(ctx, key, value, ttl))
+		c.checkCapacity(ctx)
+	}
+}
+
+// checkCapacity fires OnCapacity hooks if the cache became full enough to report
+// The caller must not hold c.mu
+func (c *Cache) checkCapacity(ctx context.Context) {
+	if c.capacity == nil {
+		return
+	}
+	if set := c.hooks.onCapacity.Load(); set == nil || len(set.all) == 0 {
+		return
+	}
+	length, capacity := int(c.stats.KeyCount()), int(c.stats.Capacity())
+	if c.capacity.check(length, capacity) {
+		c.hookRan("OnCapacity", c.hooks.invokeOnCapacityWithCtx(ctx, length, capacity))
+	}
+}
+
+// undecodable handles a stored entry whose value could not be decoded, e.g.
+// because it is corrupted or was written with an unknown codec: the error is
+// counted and passed to OnError hooks, and the entry is deleted so later reads
+// do not fail on it again. The caller must not hold c.mu
+func (c *Cache) undecodable(ctx context.Context, key string, e *entry.Entry, err error) {
+	c.stats.incErrors(ErrorTypeDecodeFailed)
+	if c.metricsExporter != nil {
+		names := metrics.DefaultMetricNames()
+		c.exportFailed(c.metricsExporter.IncrementCounter(names.DecodeErrorsTotal, c.metricsLabels))
+	}
+	if c.hooks != nil {
+		c.hookRan("OnError", c.hooks.invokeOnErrorWithCtx(ctx, ErrorOpGet, key, err))
+	}
+
+	// Leave the key alone if it was rewritten since it was read
+	c.mu.Lock()
+	if current, found := c.store.Peek(key); found && current.CreatedAt.Equal(e.CreatedAt) {
+		if store.DeleteWithContext(ctx, c.store, key) == nil {
+			c.updateKeyCount()
+		}
+	}
+	c.mu.Unlock()
+}
+
+// setFailed counts a write that could not be encoded or stored and passes err to
+// OnError hooks. The caller must not hold c.mu
+func (c *Cache) setFailed(ctx context.Context, key string, err error) {
+	c.stats.incErrors(ErrorTypeSetFailed)
 	if c.hooks != nil {
-		c.hooks.invokeOnMissWithCtx(ctx, key, nil)
+		c.hookRan("OnError", c.hooks.invokeOnErrorWithCtx(ctx, ErrorOpSet, key, err))
+	}
+}
+
+// hookRan exports the time the hooks of event took when hook durations are exported
+// d is 0 when no hook ran or timing is disabled
+func (c *Cache) hookRan(event string, d time.Duration) {
+	if d == 0 || c.hookLabels == nil {
+		return
+	}
+	names := metrics.DefaultMetricNames()
+	c.exportFailed(c.metricsExporter.RecordHistogram(names.HookDuration, d.Seconds(), c.hookLabels[event]))
+}
+
+// exportErrorInterval is the minimum time between metrics export failures
+// passed to OnError hooks, so a broken exporter does not flood them
+const exportErrorInterval = time.Second
+
+// exportFailed passes a metrics export error, if any, to OnError hooks unless
+// one was reported within exportErrorInterval
+func (c *Cache) exportFailed(err error) {
+	if err == nil || c.hooks == nil {
+		return
+	}
+	now := time.Now().UnixNano()
+	last := c.lastExportError.Load()
+	if last != 0 && now-last < int64(exportErrorInterval) {
+		return
 	}
+	if !c.lastExportError.CompareAndSwap(last, now) {
+		return // Another goroutine is reporting
+	}
+	c.hooks.invokeOnErrorWithCtx(context.Background(), ErrorOpExport, "", fmt.Errorf("failed to export metrics: %w", err))
 }
 
 // Cache is the main cache implementation with LRU and TTL support
@@ -40,16 +243,57 @@ type Cache struct {
 	stats  *Stats
 	hooks  *Hooks
 	sf     *singleflight.Group[string, any]
-	mu     sync.RWMutex
+
+	// mu is held shared by operations that make a single store call, which the
+	// store synchronizes itself, and exclusively by those that must not
+	// interleave with other writes, such as Clear, Resize and read-then-write
+	// fallbacks for stores without an atomic operation
+	mu sync.RWMutex
+
+	// Key count updates run concurrently; countSeq numbers them as they start and
+	// countMu guards countDone, the number of the update last published, so an
+	// update that counted before a later one is never published after it
+	countSeq  atomic.Uint64
+	countMu   sync.Mutex
+	countDone uint64
+
+	// Evictions made while c.mu is held exclusively are queued in pendingEvicts,
+	// guarded by evictMu, and their hooks fired after unlock, so hooks that call
+	// back into the cache do not deadlock
+	evictMu       sync.Mutex
+	deferEvicts   bool
+	pendingEvicts []pendingEvict
+
+	// startedAt is when the cache was created, for uptime reporting
+	startedAt time.Time
 
 	// Compression
 	compressor compression.Compressor
+	codec      codec.Codec
+	adaptive   *compression.Adaptive // nil unless adaptive compression is enabled
+	encryptor  encryption.Encryptor  // nil unless encryption is configured
+
+	// hotKeys counts reads per key; nil unless Config.HotKeys is set
+	hotKeys *hotkeys.Tracker
+
+	// capacity decides when OnCapacity hooks fire; nil without hooks
+	capacity *capacityAlerts
 
 	// Metrics
 	metricsExporter metrics.Exporter
 	metricsLabels   metrics.Labels
+	timeStore       bool                      // store calls are timed only when metrics are enabled
+	hookLabels      map[string]metrics.Labels // per hook kind; nil unless hook durations are exported
+	lastExportError atomic.Int64              // UnixNano of the last export error passed to hooks
 	metricsStop     chan struct{}
 	metricsWg       sync.WaitGroup
+
+	// Backend health
+	health healthState
+
+	// Snapshot persistence
+	persistStop chan struct{}
+	persistWg   sync.WaitGroup
 }
 
 // New creates a new Cache instance with the given configuration
@@ -58,15 +302,53 @@ func New(config *Config) (*Cache, error) {
 		config = NewDefaultConfig()
 	}
 
+	if err := validatePersistence(config); err != nil {
+		return nil, err
+	}
+	if config.Hooks != nil {
+		if err := config.Hooks.Err(); err != nil {
+			return nil, fmt.Errorf("invalid hooks: %w", err)
+		}
+	}
+	if config.DiskOverflow != nil && config.StoreType != StoreTypeMemory {
+		return nil, fmt.Errorf("disk overflow is only supported for the memory store")
+	}
+
+	// Stats exist before the store so store callbacks can count errors
+	stats := &Stats{recent: newHitWindow(config.HitRateWindow, config.HitRateResolution)}
+
 	// Create the appropriate store based on configuration
 	var cacheStore store.Store
 	var err error
 
 	switch config.StoreType {
 	case StoreTypeMemory:
-		cacheStore, err = createMemoryStore(config)
+		if config.DiskOverflow != nil {
+			cacheStore, err = createSpillStore(config)
+		} else {
+			cacheStore, err = createMemoryStore(config)
+		}
 	case StoreTypeRedis:
-		cacheStore, err = createRedisStore(config)
+		cacheStore, err = createRedisStore(config, stats)
+	case StoreTypeBolt:
+		cacheStore, err = createBoltStore(config)
+	case StoreTypeSQLite:
+		cacheStore, err = createSQLiteStore(config)
+	case StoreTypeTiered:
+		cacheStore, err = createTieredStore(config, stats)
+	case StoreTypeRistretto:
+		cacheStore, err = createRistrettoStore(config)
+	case StoreTypeEtcd:
+		cacheStore, err = createEtcdStore(config)
+	case StoreTypeDistributed:
+		cacheStore, err = createDistributedStore(config)
+	case StoreTypeBlob:
+		cacheStore, err = createBlobStore(config)
+	case StoreTypeCustom:
+		if config.CustomStore == nil {
+			return nil, fmt.Errorf("custom store is required when using StoreTypeCustom")
+		}
+		cacheStore = config.CustomStore
 	default:
 		return nil, fmt.Errorf("unsupported store type: %v", config.StoreType)
 	}
@@ -75,12 +357,25 @@ func New(config *Config) (*Cache, error) {
 		return nil, err
 	}
 
+	if config.WriteBehind != nil {
+		if cacheStore, err = createWriteBehindStore(config, cacheStore, stats); err != nil {
+			return nil, err
+		}
+	}
+
 	cache := &Cache{
-		config: config,
-		store:  cacheStore,
-		stats:  &Stats{},
-		hooks:  config.Hooks,
-		sf:     &singleflight.Group[string, any]{},
+		config:    config,
+		store:     cacheStore,
+		stats:     stats,
+		hooks:     config.Hooks,
+		sf:        &singleflight.Group[string, any]{},
+		startedAt: time.Now(),
+	}
+	if config.HotKeys > 0 {
+		cache.hotKeys = hotkeys.New(config.HotKeys)
+	}
+	if config.Hooks != nil {
+		cache.capacity = newCapacityAlerts(config.CapacityWatermarks, config.CapacityAlertInterval)
 	}
 
 	// Initialize compression if configured
@@ -93,26 +388,53 @@ func New(config *Config) (*Cache, error) {
 		return nil, fmt.Errorf("failed to initialize metrics: %w", err)
 	}
 
+	// Restore the snapshot before callbacks are set so it does not count as evictions
+	cache.initializePersistence()
+
 	// Set up store callbacks for statistics and hooks
 	if lruStore, ok := cacheStore.(store.LRUStore); ok {
+		cache.stats.setCapacity(int64(lruStore.Capacity()))
+		// Displaced entries that had already expired arrive via the cleanup callback
 		lruStore.SetEvictCallback(func(key string, value any) {
-			cache.stats.incEvictions()
-			if cache.hooks != nil {
-				// All memory stores now use StrategyStore which evicts based on capacity
-				cache.hooks.invokeOnEvict(key, value, EvictReasonCapacity)
-			}
+			cache.evicted(key, value, EntryInfo{}, EvictReasonCapacity)
 		})
 	}
 
+	if tieredStore, ok := cacheStore.(store.TieredStore); ok {
+		tieredStore.SetTierHitCallback(cache.stats.incTierHits)
+	}
+
+	if invalidationStore, ok := cacheStore.(store.InvalidationStore); ok {
+		invalidationStore.SetInvalidateCallback(cache.remoteInvalidated)
+	}
+
+	if spillStore, ok := cacheStore.(store.SpillStore); ok {
+		spillStore.SetSpillCallbacks(cache.stats.incSpills, cache.stats.incRestores)
+	}
+
+	if writeBehindStore, ok := cacheStore.(store.WriteBehindStore); ok {
+		writeBehindStore.SetQueueDepthCallback(cache.stats.setWriteQueueDepth)
+	}
+
 	if ttlStore, ok := cacheStore.(store.TTLStore); ok {
 		ttlStore.SetCleanupCallback(func(key string, value any) {
-			cache.stats.incEvictions()
-			if cache.hooks != nil {
-				cache.hooks.invokeOnEvict(key, value, EvictReasonTTL)
-			}
+			cache.evicted(key, value, EntryInfo{}, EvictReasonTTL)
 		})
 	}
 
+	// Stores that can report whole entries replace the callbacks above, so
+	// OnEvictInfo hooks receive the entry's metadata
+	if entryStore, ok := cacheStore.(store.EntryCallbackStore); ok {
+		entryStore.SetEntryCallbacks(
+			func(key string, e *entry.Entry) { cache.evictedEntry(key, e, EvictReasonCapacity) },
+			func(key string, e *entry.Entry) { cache.evictedEntry(key, e, EvictReasonTTL) },
+		)
+	}
+
+	if config.Hooks != nil {
+		config.Hooks.attachStats(stats)
+	}
+
 	return cache, nil
 }
 
@@ -124,6 +446,33 @@ func NewSimple(maxEntries int, defaultTTL time.Duration) (*Cache, error) {
 
 // createMemoryStore creates a memory-based store
 func createMemoryStore(config *Config) (store.Store, error) {
+	if config.EvictionStrategyFactory != nil {
+		if config.ShardCount != 0 {
+			// Split the limits across shards like the built-in strategies
+			shards := memory.ShardCountFor(config.ShardCount, config.MaxEntries)
+			strategies := make([]eviction.Strategy, shards)
+			for i := range strategies {
+				capacity := max(memory.ShareOf(config.MaxEntries, shards, i), 1)
+				maxWeight := config.MaxWeight
+				if maxWeight > 0 {
+					maxWeight = max(memory.ShareOf(maxWeight, shards, i), 1)
+				}
+				strategy, err := newFactoryStrategy(config, capacity, maxWeight)
+				if err != nil {
+					return nil, err
+				}
+				strategies[i] = strategy
+			}
+			return memory.NewSharded(strategies, config.CleanupInterval)
+		}
+
+		strategy, err := newFactoryStrategy(config, config.MaxEntries, config.MaxWeight)
+		if err != nil {
+			return nil, err
+		}
+		return memory.NewFromStrategy(strategy, config.CleanupInterval), nil
+	}
+
 	// Determine eviction type (default to LRU if not specified)
 	evictionType := config.EvictionType
 	if evictionType == "" {
@@ -131,8 +480,16 @@ func createMemoryStore(config *Config) (store.Store, error) {
 	}
 
 	evictionConfig := eviction.Config{
-		Type:     evictionType,
-		Capacity: config.MaxEntries,
+		Type:              evictionType,
+		Capacity:          config.MaxEntries,
+		MaxWeight:         config.MaxWeight,
+		LFUAgingInterval:  config.LFUAgingInterval,
+		EvictionBatchSize: config.EvictionBatchSize,
+		Instrumentation:   newStrategyInstrumentation(config, evictionType),
+	}
+
+	if config.ShardCount != 0 {
+		return memory.NewShardedWithStrategy(evictionConfig, config.ShardCount, config.CleanupInterval)
 	}
 
 	// Create store with or without cleanup interval
@@ -142,28 +499,108 @@ func createMemoryStore(config *Config) (store.Store, error) {
 	return memory.NewWithStrategy(evictionConfig)
 }
 
+// createSpillStore creates a memory store that spills evicted entries to disk
+// The spill store runs TTL cleanup for both tiers, so the memory store gets none
+func createSpillStore(config *Config) (store.Store, error) {
+	memoryConfig := *config
+	memoryConfig.CleanupInterval = 0
+	memoryStore, err := createMemoryStore(&memoryConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	diskCodec := config.DiskOverflow.Codec
+	if diskCodec == nil {
+		diskCodec = valueCodec(config)
+	}
+
+	s, err := spill.New(&spill.Config{
+		Memory:          memoryStore.(spill.Memory),
+		Dir:             config.DiskOverflow.Dir,
+		MaxDiskBytes:    config.DiskOverflow.MaxDiskBytes,
+		Codec:           diskCodec,
+		CleanupInterval: config.CleanupInterval,
+	})
+	if err != nil {
+		_ = memoryStore.Close()
+		return nil, fmt.Errorf("failed to create disk overflow: %w", err)
+	}
+	return s, nil
+}
+
+// newFactoryStrategy builds the custom eviction policy from config.EvictionStrategyFactory
+// wrapped with the configured weight and batch limits
+func newFactoryStrategy(config *Config, capacity int, maxWeight int64) (eviction.Strategy, error) {
+	policy := config.EvictionStrategyFactory(capacity)
+	if policy == nil {
+		return nil, fmt.Errorf("eviction strategy factory returned nil")
+	}
+	return eviction.WithLimits(newCustomStrategy(policy, capacity), eviction.Config{
+		MaxWeight:         maxWeight,
+		EvictionBatchSize: config.EvictionBatchSize,
+	}), nil
+}
+
+// createRistrettoStore creates a memory store backed by Ristretto
+func createRistrettoStore(config *Config) (store.Store, error) {
+	storeConfig := &ristrettostore.Config{
+		MaxEntries: config.MaxEntries,
+		MaxWeight:  config.MaxWeight,
+	}
+	if config.Ristretto != nil {
+		storeConfig.NumCounters = config.Ristretto.NumCounters
+		storeConfig.SyncWrites = config.Ristretto.SyncWrites
+	}
+	return ristrettostore.New(storeConfig)
+}
+
 // createRedisStore creates a Redis-based store
-func createRedisStore(config *Config) (store.Store, error) {
+func createRedisStore(config *Config, stats *Stats) (store.Store, error) {
 	if config.Redis == nil {
 		return nil, fmt.Errorf("redis configuration is required when using StoreTypeRedis")
 	}
+	if config.Redis.Options != nil {
+		switch {
+		case config.Redis.Client != nil:
+			return nil, fmt.Errorf("redis Options cannot be combined with a Client")
+		case config.Redis.MasterName != "":
+			return nil, fmt.Errorf("redis Options cannot be combined with MasterName")
+		}
+	}
 
+	// DefaultTTL is resolved by the cache before entries reach the store, so the
+	// store must not apply its own default to entries stored with NoTTL
 	redisConfig := &redisstore.Config{
-		DefaultTTL: config.DefaultTTL,
-		KeyPrefix:  config.Redis.KeyPrefix,
-		Context:    context.Background(),
+		KeyPrefix:           config.Redis.KeyPrefix,
+		Context:             context.Background(),
+		MaxBatchSize:        config.Redis.MaxBatchSize,
+		ExpiryNotifications: config.Redis.ExpiryNotifications,
+		Codec:               config.Redis.Codec,
+	}
+	if config.StoreType == StoreTypeTiered && config.Tiered != nil {
+		redisConfig.ClientTracking = config.Tiered.ClientTracking
+		redisConfig.TrackingInterval = config.Tiered.TrackingInterval
+	}
+
+	if config.Redis.Failover != nil {
+		failover, err := newRedisFailover(config, stats)
+		if err != nil {
+			return nil, err
+		}
+		redisConfig.Failover = failover
 	}
 
+	retries := config.Redis.MaxRetries
+	if retries == 0 && config.Redis.MasterName != "" {
+		retries = DefaultSentinelRetries
+	}
+	redisConfig.MaxRetries = max(retries, 0)
+
 	// Use provided client or create a new one
 	if config.Redis.Client != nil {
 		redisConfig.Client = config.Redis.Client
 	} else {
-		// Create Redis client from connection parameters
-		client := redis.NewClient(&redis.Options{
-			Addr:     config.Redis.Addr,
-			Password: config.Redis.Password,
-			DB:       config.Redis.DB,
-		})
+		client := newRedisClient(config.Redis)
 
 		// Test the connection
 		ctx := context.Background()
@@ -177,6 +614,324 @@ func createRedisStore(config *Config) (store.Store, error) {
 	return redisstore.New(redisConfig)
 }
 
+// newRedisFailover creates the local fallback store and state callback for a
+// Redis circuit breaker. Failovers count as ErrorTypeBackendUnavailable, and state
+// changes are exported as a gauge when metrics are enabled
+func newRedisFailover(config *Config, stats *Stats) (*redisstore.FailoverConfig, error) {
+	failover := config.Redis.Failover
+
+	capacity := failover.LocalMaxEntries
+	if capacity <= 0 {
+		capacity = 1000
+	}
+	fallback, err := memory.NewWithStrategy(eviction.Config{Type: eviction.LRU, Capacity: capacity})
+	if err != nil {
+		return nil, err
+	}
+
+	userCallback := failover.OnStateChange
+	exportDegraded := func(bool) {}
+	if metricsEnabled(config) {
+		exporter := config.Metrics.Exporter
+		name := metrics.DefaultMetricNames().CacheBackendDegraded
+		labels := metricsLabels(config)
+		exportDegraded = func(degraded bool) {
+			value := 0.0
+			if degraded {
+				value = 1
+			}
+			_ = exporter.SetGauge(name, value, labels) //nolint:errcheck // Error handling done at higher level
+		}
+	}
+	onStateChange := func(degraded bool) {
+		if degraded {
+			stats.incErrors(ErrorTypeBackendUnavailable)
+		}
+		exportDegraded(degraded)
+		if userCallback != nil {
+			userCallback(degraded)
+		}
+	}
+
+	return &redisstore.FailoverConfig{
+		Fallback:         fallback,
+		FailureThreshold: failover.FailureThreshold,
+		ProbeInterval:    failover.ProbeInterval,
+		Replay:           failover.Replay,
+		OnStateChange:    onStateChange,
+	}, nil
+}
+
+// createBoltStore creates a store persisted in an embedded Bolt database
+func createBoltStore(config *Config) (store.Store, error) {
+	if config.Bolt == nil {
+		return nil, fmt.Errorf("bolt configuration is required when using StoreTypeBolt")
+	}
+
+	return boltstore.New(&boltstore.Config{
+		Path:            config.Bolt.Path,
+		Capacity:        config.MaxEntries,
+		EvictionType:    config.EvictionType,
+		NoSync:          config.Bolt.NoSync,
+		Timeout:         config.Bolt.Timeout,
+		CleanupInterval: config.CleanupInterval,
+	})
+}
+
+// createSQLiteStore creates a store backed by a SQLite database
+func createSQLiteStore(config *Config) (store.Store, error) {
+	if config.SQLite == nil {
+		return nil, fmt.Errorf("sqlite configuration is required when using StoreTypeSQLite")
+	}
+
+	return sqlitestore.New(&sqlitestore.Config{
+		DriverName:       config.SQLite.DriverName,
+		DSN:              config.SQLite.DSN,
+		CleanupInterval:  config.CleanupInterval,
+		CleanupBatchSize: config.SQLite.CleanupBatchSize,
+	})
+}
+
+// newRedisClient creates a Redis client from connection parameters
+// Options are used as given; otherwise a MasterName selects a Sentinel failover
+// client that follows master promotions
+func newRedisClient(config *RedisConfig) *redis.Client {
+	if config.Options != nil {
+		options := *config.Options // NewClient fills in defaults; leave the caller's copy as is
+		return redis.NewClient(&options)
+	}
+	if config.MasterName != "" {
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       config.MasterName,
+			SentinelAddrs:    config.SentinelAddrs,
+			SentinelPassword: config.SentinelPassword,
+			Password:         config.Password,
+			DB:               config.DB,
+		})
+	}
+
+	return redis.NewClient(&redis.Options{
+		Addr:     config.Addr,
+		Password: config.Password,
+		DB:       config.DB,
+	})
+}
+
+// createTieredStore creates a memory store in front of a Redis store
+func createTieredStore(config *Config, stats *Stats) (store.Store, error) {
+	if config.Tiered == nil {
+		return nil, fmt.Errorf("tiered configuration is required when using StoreTypeTiered")
+	}
+
+	shared, err := createRedisStore(config, stats)
+	if err != nil {
+		return nil, err
+	}
+
+	capacity := config.Tiered.L1MaxEntries
+	if capacity <= 0 {
+		capacity = 1000
+	}
+	evictionType := config.EvictionType
+	if evictionType == "" {
+		evictionType = eviction.LRU
+	}
+	local, err := memory.NewWithStrategyAndCleanup(eviction.Config{
+		Type:     evictionType,
+		Capacity: capacity,
+	}, config.CleanupInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	return tieredstore.New(&tieredstore.Config{
+		Local:    local,
+		Shared:   shared,
+		LocalTTL: config.Tiered.L1TTL,
+	})
+}
+
+// createEtcdStore creates an etcd store, behind a local memory tier kept
+// consistent by a watch when L1MaxEntries is set
+func createEtcdStore(config *Config) (store.Store, error) {
+	if config.Etcd == nil {
+		return nil, fmt.Errorf("etcd configuration is required when using StoreTypeEtcd")
+	}
+
+	etcdConfig := &etcdstore.Config{
+		Client:         config.Etcd.Client,
+		KeyPrefix:      config.Etcd.KeyPrefix,
+		MaxValueSize:   config.Etcd.MaxValueSize,
+		RequestTimeout: config.Etcd.RequestTimeout,
+		Watch:          config.Etcd.L1MaxEntries > 0,
+	}
+	if etcdConfig.Client == nil {
+		dialTimeout := config.Etcd.DialTimeout
+		if dialTimeout <= 0 {
+			dialTimeout = 5 * time.Second
+		}
+		client, err := clientv3.New(clientv3.Config{
+			Endpoints:   config.Etcd.Endpoints,
+			Username:    config.Etcd.Username,
+			Password:    config.Etcd.Password,
+			DialTimeout: dialTimeout,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to etcd: %w", err)
+		}
+		etcdConfig.Client = client
+		etcdConfig.CloseClient = true
+	}
+
+	shared, err := etcdstore.New(etcdConfig)
+	if err != nil {
+		if etcdConfig.CloseClient {
+			_ = etcdConfig.Client.Close()
+		}
+		return nil, err
+	}
+
+	// Test the connection; the client connects lazily
+	if err := shared.Ping(context.Background()); err != nil {
+		_ = shared.Close()
+		return nil, fmt.Errorf("failed to connect to etcd: %w", err)
+	}
+	if config.Etcd.L1MaxEntries <= 0 {
+		return shared, nil
+	}
+	return withLocalTier(config, shared, config.Etcd.L1MaxEntries, config.Etcd.L1TTL)
+}
+
+// createBlobStore creates a store over an S3-compatible bucket, behind a local
+// memory tier when L1MaxEntries is set
+func createBlobStore(config *Config) (store.Store, error) {
+	if config.Blob == nil {
+		return nil, fmt.Errorf("blob configuration is required when using StoreTypeBlob")
+	}
+
+	shared, err := blobstore.New(&blobstore.Config{
+		Client:              config.Blob.Client,
+		KeyPrefix:           config.Blob.KeyPrefix,
+		RequestTimeout:      config.Blob.RequestTimeout,
+		LifecycleExpiration: config.Blob.LifecycleExpiration,
+		CleanupInterval:     config.CleanupInterval,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if config.Blob.L1MaxEntries <= 0 {
+		return shared, nil
+	}
+	return withLocalTier(config, shared, config.Blob.L1MaxEntries, config.Blob.L1TTL)
+}
+
+// withLocalTier puts a memory store of capacity entries in front of shared,
+// serving each entry locally for at most localTTL. shared is closed if the
+// local tier cannot be created
+func withLocalTier(config *Config, shared store.Store, capacity int, localTTL time.Duration) (store.Store, error) {
+	evictionType := config.EvictionType
+	if evictionType == "" {
+		evictionType = eviction.LRU
+	}
+	local, err := memory.NewWithStrategyAndCleanup(eviction.Config{
+		Type:     evictionType,
+		Capacity: capacity,
+	}, time.Minute)
+	if err != nil {
+		_ = shared.Close()
+		return nil, err
+	}
+
+	return tieredstore.New(&tieredstore.Config{
+		Local:    local,
+		Shared:   shared,
+		LocalTTL: localTTL,
+	})
+}
+
+// createDistributedStore creates a memory store for the keys this peer owns and
+// serves it to the other peers
+func createDistributedStore(config *Config) (store.Store, error) {
+	if config.Distributed == nil {
+		return nil, fmt.Errorf("distributed configuration is required when using StoreTypeDistributed")
+	}
+
+	local, err := createMemoryStore(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var hot store.Store
+	if config.Distributed.HotCacheEntries > 0 {
+		hot, err = memory.NewWithStrategyAndCleanup(eviction.Config{
+			Type:     eviction.LRU,
+			Capacity: config.Distributed.HotCacheEntries,
+		}, time.Minute)
+		if err != nil {
+			_ = local.Close()
+			return nil, err
+		}
+	}
+
+	s, err := distributedstore.New(&distributedstore.Config{
+		Self:              config.Distributed.Self,
+		Peers:             config.Distributed.Peers,
+		Discovery:         config.Distributed.Discovery,
+		DiscoveryInterval: config.Distributed.DiscoveryInterval,
+		Local:             local,
+		HotCache:          hot,
+		HotCacheTTL:       config.Distributed.HotCacheTTL,
+		ListenAddr:        config.Distributed.ListenAddr,
+		RequestTimeout:    config.Distributed.RequestTimeout,
+	})
+	if err != nil {
+		_ = local.Close()
+		if hot != nil {
+			_ = hot.Close()
+		}
+		return nil, err
+	}
+	return s, nil
+}
+
+// createWriteBehindStore wraps backing so writes are flushed to it asynchronously
+// Failed and discarded writes count as ErrorTypeSetFailed
+// backing is closed if the wrapper cannot be created
+func createWriteBehindStore(config *Config, backing store.Store, stats *Stats) (store.Store, error) {
+	switch config.StoreType {
+	case StoreTypeMemory, StoreTypeRistretto, StoreTypeDistributed:
+		_ = backing.Close()
+		return nil, fmt.Errorf("write-behind is not supported for the memory store, whose writes are already in-process")
+	case StoreTypeTiered:
+		_ = backing.Close()
+		return nil, fmt.Errorf("write-behind is not supported for the tiered store")
+	}
+
+	onError := config.WriteBehind.OnError
+	hooks := config.Hooks
+	s, err := writebehind.New(&writebehind.Config{
+		Backing:   backing,
+		QueueSize: config.WriteBehind.QueueSize,
+		Workers:   config.WriteBehind.Workers,
+		BatchSize: config.WriteBehind.BatchSize,
+		Overflow:  config.WriteBehind.Overflow,
+		OnError: func(key string, err error) {
+			stats.incErrors(ErrorTypeSetFailed)
+			if hooks != nil {
+				hooks.invokeOnErrorWithCtx(context.Background(), ErrorOpSet, key, fmt.Errorf("failed to write behind: %w", err))
+			}
+			if onError != nil {
+				onError(key, err)
+			}
+		},
+	})
+	if err != nil {
+		_ = backing.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
 // Get retrieves a value from the cache by key
 // For context-aware operations, use GetContext instead
 func (c *Cache) Get(key string) (any, bool) {
@@ -195,16 +950,20 @@ func (c *Cache) GetContext(ctx context.Context, key string) (any, bool) {
 	var found bool
 
 	c.mu.RLock()
-	entry, ok := c.store.Get(key)
+	storeStart := c.storeTimer()
+	entry, ok := store.GetWithContext(ctx, c.store, key)
+	storeTime := c.storeElapsed(storeStart)
+	defer c.recordStoreOperation(metrics.OperationGet, storeTime)
 	if !ok {
 		c.mu.RUnlock()
 		c.miss(ctx, key)
 		return result, found
 	}
 
-	value, err := c.decompressValue(entry)
+	value, err := c.decompressValue(key, entry)
 	if err != nil {
 		c.mu.RUnlock()
+		c.undecodable(ctx, key, entry, err)
 		c.miss(ctx, key)
 		return result, found
 	}
@@ -214,92 +973,922 @@ func (c *Cache) GetContext(ctx context.Context, key string) (any, bool) {
 	found = true
 	c.mu.RUnlock()
 
-	return result, found
+	return result, found
+}
+
+// GetMany returns the cached values for keys, leaving misses out of the result
+// Stores that support batch reads, such as Redis, fetch all keys in a few round
+// trips instead of one per key. Each key counts as a Get for statistics and hooks
+func (c *Cache) GetMany(keys []string) map[string]any {
+	return c.getMany(context.Background(), keys)
+}
+
+// getMany implements GetMany, passing ctx to hooks
+func (c *Cache) getMany(ctx context.Context, keys []string) map[string]any {
+	start := time.Now()
+	defer func() {
+		c.recordCacheOperation(metrics.OperationGet, time.Since(start))
+	}()
+
+	seen := make(map[string]struct{}, len(keys))
+	unique := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if _, dup := seen[key]; !dup {
+			seen[key] = struct{}{}
+			unique = append(unique, key)
+		}
+	}
+
+	c.mu.RLock()
+	storeStart := c.storeTimer()
+	entries := store.GetMany(c.store, unique)
+	storeTime := c.storeElapsed(storeStart)
+	defer c.recordStoreOperation(metrics.OperationGet, storeTime)
+	c.mu.RUnlock()
+
+	results := make(map[string]any, len(entries))
+	for _, key := range unique {
+		e, ok := entries[key]
+		if !ok {
+			c.miss(ctx, key)
+			continue
+		}
+		value, err := c.decompressValue(key, e)
+		if err != nil {
+			c.undecodable(ctx, key, e, err)
+			c.miss(ctx, key)
+			continue
+		}
+		c.hit(ctx, key, value)
+		results[key] = value
+	}
+	return results
+}
+
+// GetEntry retrieves a value together with its entry metadata, including its version
+// It counts as a regular Get for statistics and hooks
+func (c *Cache) GetEntry(key string) (any, EntryInfo, bool) {
+	ctx := context.Background()
+	start := time.Now()
+	defer func() {
+		c.recordCacheOperation(metrics.OperationGet, time.Since(start))
+	}()
+
+	c.mu.RLock()
+	storeStart := c.storeTimer()
+	cacheEntry, ok := c.store.Get(key)
+	storeTime := c.storeElapsed(storeStart)
+	defer c.recordStoreOperation(metrics.OperationGet, storeTime)
+	c.mu.RUnlock()
+	if !ok {
+		c.miss(ctx, key)
+		return nil, EntryInfo{}, false
+	}
+
+	value, err := c.decompressValue(key, cacheEntry)
+	if err != nil {
+		c.undecodable(ctx, key, cacheEntry, err)
+		c.miss(ctx, key)
+		return nil, EntryInfo{}, false
+	}
+
+	c.hit(ctx, key, value)
+	return value, newEntryInfo(cacheEntry), true
+}
+
+// Set stores a value in the cache with the specified key and TTL
+// For context-aware operations, use SetContext instead
+func (c *Cache) Set(key string, value any, ttl time.Duration, options ...SetOption) error {
+	return c.SetContext(context.Background(), key, value, ttl, options...)
+}
+
+// SetContext stores a value in the cache with context support
+// The context can be used for cancellation, timeouts, and trace propagation
+func (c *Cache) SetContext(ctx context.Context, key string, value any, ttl time.Duration, options ...SetOption) error {
+	start := time.Now()
+	defer func() {
+		c.recordCacheOperation(metrics.OperationSet, time.Since(start))
+	}()
+
+	value, ttl, proceed, err := c.beforeSet(ctx, key, value, c.resolveTTL(ttl))
+	if !proceed {
+		return err
+	}
+
+	entry, err := c.createCompressedEntry(key, value, ttl, newSetOptions(options))
+	if err != nil {
+		err = fmt.Errorf("failed to create entry: %w", err)
+		c.setFailed(ctx, key, err)
+		return err
+	}
+
+	c.mu.RLock()
+	if !c.admit(key) {
+		c.mu.RUnlock()
+		c.stats.incAdmissionRejections()
+		return nil
+	}
+	storeStart := c.storeTimer()
+	setErr := store.SetWithContext(ctx, c.store, key, entry)
+	storeTime := c.storeElapsed(storeStart)
+	defer c.recordStoreOperation(metrics.OperationSet, storeTime)
+	if setErr == nil {
+		c.stats.addSets(1)
+		c.updateKeyCount()
+	}
+	c.mu.RUnlock()
+
+	if setErr != nil {
+		c.setFailed(ctx, key, fmt.Errorf("failed to store entry: %w", setErr))
+		return setErr
+	}
+
+	c.stored(ctx, key, value, ttl)
+	return nil
+}
+
+// admit reports whether key may be stored under the admission policy
+// Keys that are already cached are always admitted (assumes c.mu is held, shared or not)
+func (c *Cache) admit(key string) bool {
+	if c.config.AdmissionPolicy == nil || c.config.StoreType != StoreTypeMemory {
+		return true
+	}
+	if _, found := c.store.Peek(key); found {
+		return true
+	}
+	return c.config.AdmissionPolicy.Admit(key)
+}
+
+// lockWrite locks c.mu for a write to one key and returns the matching unlock
+// A single store call only needs it shared; exclusive is for a read followed by
+// a write that no other write may come between
+func (c *Cache) lockWrite(exclusive bool) func() {
+	if exclusive {
+		c.mu.Lock()
+		return c.mu.Unlock
+	}
+	c.mu.RLock()
+	return c.mu.RUnlock
+}
+
+// SetVersioned stores a value only if the key is absent or holds a lower version
+// Returns false without writing when the stored entry's version is equal or higher.
+// Entries written with Set have version 0. Use GetEntry to read an entry's version.
+func (c *Cache) SetVersioned(key string, value any, version int64, ttl time.Duration) (bool, error) {
+	start := time.Now()
+	defer func() {
+		c.recordCacheOperation(metrics.OperationSet, time.Since(start))
+	}()
+
+	value, ttl, proceed, err := c.beforeSet(context.Background(), key, value, c.resolveTTL(ttl))
+	if !proceed {
+		return false, err
+	}
+
+	newEntry, err := c.createCompressedEntry(key, value, ttl, SetOptions{})
+	if err != nil {
+		err = fmt.Errorf("failed to create entry: %w", err)
+		c.setFailed(context.Background(), key, err)
+		return false, err
+	}
+	newEntry.Version = version
+
+	var written bool
+	versionedStore, versioned := c.store.(store.VersionedStore)
+	unlock := c.lockWrite(!versioned)
+	storeStart := c.storeTimer()
+	if versioned {
+		written, err = versionedStore.SetIfNewer(key, newEntry)
+	} else if current, found := c.store.Peek(key); !found || current.Version < version {
+		err = c.store.Set(key, newEntry)
+		written = err == nil
+	}
+	storeTime := c.storeElapsed(storeStart)
+	defer c.recordStoreOperation(metrics.OperationSet, storeTime)
+	if written {
+		c.stats.addSets(1)
+		c.updateKeyCount()
+	}
+	unlock()
+
+	if err != nil {
+		c.setFailed(context.Background(), key, fmt.Errorf("failed to store entry: %w", err))
+	}
+	if written {
+		c.stored(context.Background(), key, value, ttl)
+	}
+
+	return written, err
+}
+
+// Swap stores a value and returns the value it replaced in a single store operation
+// existed is false when there was no live entry for key. The write is reported as a
+// regular set, with no invalidation of the previous value. A write skipped by a
+// BeforeSet hook leaves the entry alone and returns no previous value.
+func (c *Cache) Swap(key string, value any, ttl time.Duration) (old any, existed bool, err error) {
+	start := time.Now()
+	defer func() {
+		c.recordCacheOperation(metrics.OperationSet, time.Since(start))
+	}()
+
+	value, ttl, proceed, err := c.beforeSet(context.Background(), key, value, c.resolveTTL(ttl))
+	if !proceed {
+		return nil, false, err
+	}
+
+	newEntry, err := c.createCompressedEntry(key, value, ttl, SetOptions{})
+	if err != nil {
+		err = fmt.Errorf("failed to create entry: %w", err)
+		c.setFailed(context.Background(), key, err)
+		return nil, false, err
+	}
+
+	var previous *entry.Entry
+	swapStore, swaps := c.store.(store.SwapStore)
+	unlock := c.lockWrite(!swaps)
+	storeStart := c.storeTimer()
+	if swaps {
+		previous, existed, err = swapStore.Swap(key, newEntry)
+	} else {
+		previous, existed = c.store.Peek(key)
+		err = c.store.Set(key, newEntry)
+	}
+	storeTime := c.storeElapsed(storeStart)
+	defer c.recordStoreOperation(metrics.OperationSet, storeTime)
+	if err == nil {
+		c.stats.addSets(1)
+		c.updateKeyCount()
+	}
+	unlock()
+
+	if err != nil {
+		c.setFailed(context.Background(), key, fmt.Errorf("failed to store entry: %w", err))
+		return nil, false, err
+	}
+	c.stored(context.Background(), key, value, ttl)
+	if !existed {
+		return nil, false, nil
+	}
+
+	old, err = c.decompressValue(key, previous)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to decode previous value: %w", err)
+	}
+	return old, true, nil
+}
+
+// SetForever stores a value that never expires, ignoring DefaultTTL
+func (c *Cache) SetForever(key string, value any) error {
+	return c.SetContext(context.Background(), key, value, NoTTL)
+}
+
+// Do returns the cached value for key, or computes it with fn on a miss
+// For context-aware operations, use DoContext instead
+func (c *Cache) Do(key string, fn func(ctx context.Context) (any, error), ttl time.Duration) (any, error) {
+	return c.DoContext(context.Background(), key, fn, ttl)
+}
+
+// DoContext returns the cached value for key, or computes it with fn on a miss
+// Concurrent callers for the same key share a single invocation of fn, and the
+// computed value is stored with the given ttl before being returned to all of them.
+// Cancelling ctx only abandons the wait for this caller: fn runs with a context
+// detached from cancellation so that other waiters still receive the result.
+func (c *Cache) DoContext(ctx context.Context, key string, fn func(ctx context.Context) (any, error), ttl time.Duration) (any, error) {
+	if value, found := c.GetContext(ctx, key); found {
+		return value, nil
+	}
+	return c.compute(ctx, key, fn, ttl)
+}
+
+// Refresh reloads key with fn and stores the result with ttl, whether or not the
+// key is cached, for refresh-ahead and other background reloads. If fn or the
+// write fails the cached value, if any, is left in place and the error returned.
+// Every call is counted in Stats().Refreshes() and passed to OnRefresh hooks with
+// its error and duration. Unlike DoContext, concurrent calls are not coalesced
+func (c *Cache) Refresh(ctx context.Context, key string, fn func(ctx context.Context) (any, error), ttl time.Duration) error {
+	start := time.Now()
+	value, err := fn(ctx)
+	if err == nil {
+		err = c.SetContext(ctx, key, value, ttl)
+	}
+	duration := time.Since(start)
+
+	c.stats.incRefreshes(err != nil)
+	if c.hooks != nil {
+		c.hookRan("OnRefresh", c.hooks.invokeOnRefreshWithCtx(ctx, key, err, duration))
+	}
+	return err
+}
+
+// compute runs fn under the cache's singleflight group and stores its result
+func (c *Cache) compute(ctx context.Context, key string, fn func(ctx context.Context) (any, error), ttl time.Duration) (any, error) {
+	computeCtx := context.WithoutCancel(ctx)
+	value, err, _ := c.sf.DoContext(ctx, key, func() (any, error) {
+		c.stats.incInFlight()
+		defer c.stats.decInFlight()
+
+		value, err := fn(computeCtx)
+		if err != nil {
+			return nil, err
+		}
+		_ = c.SetContext(computeCtx, key, value, ttl) // Computed value is still returned if caching fails
+		return value, nil
+	})
+	return value, err
+}
+
+// ErrNotFound is returned when an operation requires a key that is not cached
+var ErrNotFound = errors.New("key not found")
+
+// ErrAllPinned is returned by Set when the cache is full and every entry is pinned
+var ErrAllPinned = eviction.ErrAllPinned
+
+// BatchError is returned by SetMany and DeleteMany when some keys failed
+// Errors maps each failed key to its error; all other keys were applied
+type BatchError = store.BatchError
+
+// errNotLoaded marks keys that a LoadMany batch loader did not return
+var errNotLoaded = errors.New("key not returned by loader")
+
+// loadBatch carries the result of a single LoadMany loader call to the
+// singleflight executions waiting on it
+type loadBatch struct {
+	done   chan struct{}
+	values map[string]any
+	err    error
+}
+
+// LoadMany returns the values for keys, calling loader once for all keys that miss
+// Loaded values are stored with the given ttl and merged into the result. Keys the
+// loader does not return are treated as misses and left out of the result.
+// Concurrent overlapping calls coalesce per key, so a key that is already being
+// loaded by another call is awaited rather than passed to loader again.
+// Cancelling ctx only abandons the wait for this caller: loader runs with a
+// context detached from cancellation so that other waiters still receive the result.
+func (c *Cache) LoadMany(ctx context.Context, keys []string, loader func(ctx context.Context, missing []string) (map[string]any, error), ttl time.Duration) (map[string]any, error) {
+	results := c.getMany(ctx, keys)
+	var missing []string
+	for _, key := range keys {
+		if _, found := results[key]; !found {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return results, nil
+	}
+
+	batch := &loadBatch{done: make(chan struct{})}
+	waiting := make(map[string]<-chan singleflight.Result[any], len(missing))
+	var owned []string
+	for _, key := range missing {
+		if _, ok := waiting[key]; ok {
+			continue // Duplicate key in the request
+		}
+		ch, joined := c.sf.DoChanJoined(key, func() (any, error) {
+			<-batch.done
+			if batch.err != nil {
+				return nil, batch.err
+			}
+			value, ok := batch.values[key]
+			if !ok {
+				return nil, errNotLoaded
+			}
+			return value, nil
+		})
+		waiting[key] = ch
+		if !joined {
+			owned = append(owned, key)
+		}
+	}
+
+	if len(owned) > 0 {
+		go c.runBatchLoader(context.WithoutCancel(ctx), batch, owned, loader, ttl)
+	}
+
+	for key, ch := range waiting {
+		select {
+		case result := <-ch:
+			if errors.Is(result.Err, errNotLoaded) {
+				continue
+			}
+			if result.Err != nil {
+				return results, result.Err
+			}
+			results[key] = result.Val
+		case <-ctx.Done():
+			return results, ctx.Err()
+		}
+	}
+
+	return results, nil
+}
+
+// runBatchLoader calls loader for the keys owned by batch, stores the loaded
+// values, and releases every singleflight execution waiting on the batch
+func (c *Cache) runBatchLoader(ctx context.Context, batch *loadBatch, owned []string, loader func(ctx context.Context, missing []string) (map[string]any, error), ttl time.Duration) {
+	defer close(batch.done)
+
+	c.stats.incInFlight()
+	defer c.stats.decInFlight()
+
+	values, err := loader(ctx, owned)
+	if err != nil {
+		batch.err = err
+		return
+	}
+
+	batch.values = make(map[string]any, len(owned))
+	for _, key := range owned {
+		if value, ok := values[key]; ok {
+			batch.values[key] = value
+		}
+	}
+	_ = c.SetMany(batch.values, ttl) // Loaded values are still returned if caching fails
+}
+
+// SetMany stores several values with the same TTL
+// Stores that support batching, such as Redis, write them in a few round trips
+// instead of one per key. Keys that could not be stored, or that a BeforeSet hook
+// rejected with an error, are reported in a *BatchError; all other keys were
+// stored or silently skipped by a BeforeSet hook.
+func (c *Cache) SetMany(values map[string]any, ttl time.Duration) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	defer func() {
+		c.recordCacheOperation(metrics.OperationSet, time.Since(start))
+	}()
+
+	ttl = c.resolveTTL(ttl)
+
+	// write is a value and TTL as approved by BeforeSet hooks
+	type write struct {
+		value any
+		ttl   time.Duration
+	}
+
+	ctx := context.Background()
+	failed := make(map[string]error)
+	vetoed := make(map[string]error)
+	writes := make(map[string]write, len(values))
+	entries := make(map[string]*entry.Entry, len(values))
+	for key, value := range values {
+		value, keyTTL, proceed, err := c.beforeSet(ctx, key, value, ttl)
+		if !proceed {
+			if err != nil {
+				vetoed[key] = err
+			}
+			continue
+		}
+		e, err := c.createCompressedEntry(key, value, keyTTL, SetOptions{})
+		if err != nil {
+			failed[key] = fmt.Errorf("failed to create entry: %w", err)
+			continue
+		}
+		entries[key] = e
+		writes[key] = write{value: value, ttl: keyTTL}
+	}
+
+	c.mu.RLock()
+	for key := range entries {
+		if !c.admit(key) {
+			delete(entries, key)
+			c.stats.incAdmissionRejections()
+		}
+	}
+	storeStart := c.storeTimer()
+	if batchStore, ok := c.store.(store.BatchStore); ok {
+		mergeBatchError(failed, slices.Collect(maps.Keys(entries)), batchStore.SetBatch(entries))
+	} else {
+		for key, e := range entries {
+			if err := c.store.Set(key, e); err != nil {
+				failed[key] = err
+			}
+		}
+	}
+	storeTime := c.storeElapsed(storeStart)
+	defer c.recordStoreOperation(metrics.OperationSet, storeTime)
+	stored := 0
+	for key := range entries {
+		if _, ok := failed[key]; !ok {
+			stored++
+		}
+	}
+	c.stats.addSets(stored)
+	c.updateKeyCount()
+	c.mu.RUnlock()
+
+	for key, err := range failed {
+		c.setFailed(ctx, key, err)
+	}
+	for key := range entries {
+		if _, ok := failed[key]; !ok {
+			c.stored(ctx, key, writes[key].value, writes[key].ttl)
+		}
+	}
+	maps.Copy(failed, vetoed)
+	return store.JoinBatchErrors(failed)
+}
+
+// DeleteMany removes several keys, firing OnInvalidate hooks for each removed key
+// Stores that support batching delete them in a few round trips instead of one
+// per key. Keys that could not be removed are reported in a *BatchError.
+func (c *Cache) DeleteMany(keys []string) error {
+	failed := make(map[string]error)
+
+	c.mu.RLock()
+	storeStart := c.storeTimer()
+	c.deleteKeys(keys, failed)
+	storeTime := c.storeElapsed(storeStart)
+	defer c.recordStoreOperation(metrics.OperationDelete, storeTime)
+	c.updateKeyCount()
+	c.mu.RUnlock()
+
+	ctx := context.Background()
+	for _, key := range keys {
+		if _, ok := failed[key]; ok {
+			continue
+		}
+		c.stats.addDeletes(1)
+		c.stats.incInvalidations()
+		if c.hooks != nil {
+			c.hookRan("OnInvalidate", c.hooks.invokeOnInvalidateWithCtx(ctx, key, nil))
+		}
+	}
+
+	return store.JoinBatchErrors(failed)
+}
+
+// remoteInvalidated fires OnInvalidate hooks for keys the store reported changed
+// by other clients. A nil keys slice names no keys, so it fires none
+func (c *Cache) remoteInvalidated(keys []string) {
+	if c.hooks == nil {
+		return
+	}
+	ctx := context.WithValue(context.Background(), remoteInvalidationKey{}, true)
+	for _, key := range keys {
+		c.hookRan("OnInvalidate", c.hooks.invokeOnInvalidateWithCtx(ctx, key, nil))
+	}
+}
+
+// deleteKeys removes keys from the store in one batch where supported and
+// records the keys that failed (assumes c.mu is held, shared or not)
+func (c *Cache) deleteKeys(keys []string, failed map[string]error) {
+	if batchStore, ok := c.store.(store.BatchStore); ok {
+		mergeBatchError(failed, keys, batchStore.DeleteBatch(keys))
+		return
+	}
+	for _, key := range keys {
+		if err := c.store.Delete(key); err != nil {
+			failed[key] = err
+		}
+	}
+}
+
+// mergeBatchError records the per-key errors of a batch operation over keys in failed
+// An error that is not a *BatchError is recorded for every key
+func mergeBatchError(failed map[string]error, keys []string, err error) {
+	if err == nil {
+		return
+	}
+	var batchErr *BatchError
+	if errors.As(err, &batchErr) {
+		maps.Copy(failed, batchErr.Errors)
+		return
+	}
+	for _, key := range keys {
+		failed[key] = err
+	}
+}
+
+// Put stores a value using the default TTL
+func (c *Cache) Put(key string, value any) error {
+	return c.Set(key, value, c.config.DefaultTTL)
+}
+
+// Delete removes a key from the cache
+func (c *Cache) Delete(key string) error {
+	return c.DeleteContext(context.Background(), key)
+}
+
+// DeleteContext removes a key from the cache, passing ctx to the store where it
+// supports request contexts and to OnInvalidate hooks
+func (c *Cache) DeleteContext(ctx context.Context, key string) error {
+	c.mu.RLock()
+	storeStart := c.storeTimer()
+	err := store.DeleteWithContext(ctx, c.store, key)
+	storeTime := c.storeElapsed(storeStart)
+	defer c.recordStoreOperation(metrics.OperationDelete, storeTime)
+	if err == nil {
+		c.updateKeyCount()
+	}
+	c.mu.RUnlock()
+
+	if err != nil {
+		return err
+	}
+	c.stats.addDeletes(1)
+	c.stats.incInvalidations()
+	if c.hooks != nil {
+		c.hookRan("OnInvalidate", c.hooks.invokeOnInvalidateWithCtx(ctx, key, nil))
+	}
+	return nil
+}
+
+// Clear removes all entries from the cache
+func (c *Cache) Clear() error {
+	return c.ClearContext(context.Background())
+}
+
+// ClearContext removes all entries from the cache, passing ctx to OnInvalidate and
+// OnClear hooks. Stores that walk a large keyspace, such as Redis, delete in batches
+// without listing every key first and stop when ctx is done, returning ctx.Err()
+// with the remaining entries still cached. Hooks run after the cache lock is
+// released, so the removed keys are held until then unless invalidations are skipped
+func (c *Cache) ClearContext(ctx context.Context) error {
+	invalidate := c.hooks != nil && !c.config.SkipClearInvalidations
+	removed := 0
+	var keys []string
+
+	if ctxStore, ok := c.store.(store.ContextStore); ok {
+		c.mu.Lock()
+		err := ctxStore.ClearContext(ctx, func(batch []string) {
+			removed += len(batch)
+			if invalidate {
+				keys = append(keys, batch...)
+			}
+		})
+		c.updateKeyCount()
+		c.mu.Unlock()
+
+		c.stats.addInvalidations(removed)
+		if err == nil {
+			c.stats.incClears()
+		}
+		c.invalidated(ctx, keys)
+		c.cleared(ctx, removed)
+		return err
+	}
+
+	c.mu.Lock()
+	if invalidate {
+		keys = c.store.Keys()
+		removed = len(keys)
+	} else {
+		removed = c.store.Len()
+	}
+	err := c.store.Clear()
+	if err == nil {
+		c.updateKeyCount()
+	}
+	c.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	c.stats.addInvalidations(removed)
+	c.stats.incClears()
+	c.invalidated(ctx, keys)
+	c.cleared(ctx, removed)
+	return nil
+}
+
+// invalidated fires OnInvalidate hooks for keys removed from the cache
+// The caller must not hold c.mu
+func (c *Cache) invalidated(ctx context.Context, keys []string) {
+	if c.hooks == nil {
+		return
+	}
+	for _, key := range keys {
+		c.hookRan("OnInvalidate", c.hooks.invokeOnInvalidateWithCtx(ctx, key, nil))
+	}
 }
 
-// Set stores a value in the cache with the specified key and TTL
-// For context-aware operations, use SetContext instead
-func (c *Cache) Set(key string, value any, ttl time.Duration) error {
-	return c.SetContext(context.Background(), key, value, ttl)
+// cleared fires OnClear hooks for a clear that removed n entries
+// The caller must not hold c.mu
+func (c *Cache) cleared(ctx context.Context, n int) {
+	if c.hooks != nil {
+		c.hookRan("OnClear", c.hooks.invokeOnClearWithCtx(ctx, n))
+	}
 }
 
-// SetContext stores a value in the cache with context support
-// The context can be used for cancellation, timeouts, and trace propagation
-func (c *Cache) SetContext(ctx context.Context, key string, value any, ttl time.Duration) error {
-	start := time.Now()
-	defer func() {
-		c.recordCacheOperation(metrics.OperationSet, time.Since(start))
-	}()
+// ClearExpired synchronously removes all expired entries and returns the count removed
+// Removed entries are reported through OnEvict hooks with EvictReasonTTL, exactly as
+// if the background cleanup had found them, and then fire OnClear hooks once with
+// the count. Stores that expire entries natively, such as Redis, have nothing to
+// remove and return 0.
+func (c *Cache) ClearExpired() int {
+	removed := c.Cleanup()
+	if removed > 0 {
+		c.cleared(context.WithValue(context.Background(), partialClearKey{}, true), removed)
+	}
+	return removed
+}
 
-	if ttl <= 0 {
-		ttl = c.config.DefaultTTL
+// Evict removes up to n entries chosen by the configured eviction strategy and
+// returns the count removed. Victims are picked in the same order a capacity
+// eviction would pick them. Removed entries count as evictions and fire OnEvict
+// hooks with EvictReasonManual. Stores without an eviction policy return 0.
+func (c *Cache) Evict(n int) int {
+	evictStore, ok := c.store.(store.EvictStore)
+	if !ok || n <= 0 {
+		return 0
 	}
 
-	entry, err := c.createCompressedEntry(value, ttl)
-	if err != nil {
-		return fmt.Errorf("failed to create entry: %w", err)
+	type evicted struct {
+		key   string
+		value any
+		info  EntryInfo
 	}
+	var removed []evicted
 
 	c.mu.Lock()
-	setErr := c.store.Set(key, entry)
-	if setErr == nil {
-		c.updateKeyCount()
+	// Victims are the entries Evict removes next, so their metadata can be captured first
+	infos := make(map[string]EntryInfo)
+	for _, key := range evictStore.Victims(n) {
+		if e, found := c.store.Peek(key); found {
+			infos[key] = newEntryInfo(e)
+		}
 	}
+	evictStore.Evict(n, func(key string, value any) {
+		removed = append(removed, evicted{key: key, value: value, info: infos[key]})
+	})
+	c.updateKeyCount()
 	c.mu.Unlock()
 
-	return setErr
+	for _, e := range removed {
+		c.evicted(e.key, e.value, e.info, EvictReasonManual)
+	}
+
+	return len(removed)
 }
 
-// Put stores a value using the default TTL
-func (c *Cache) Put(key string, value any) error {
-	return c.Set(key, value, c.config.DefaultTTL)
+// EvictionCandidates returns up to n keys that the eviction strategy would
+// evict next, in order, without removing them or affecting their position.
+// Pinned keys are never candidates. Stores without an eviction policy return nil.
+func (c *Cache) EvictionCandidates(n int) []string {
+	evictStore, ok := c.store.(store.EvictStore)
+	if !ok {
+		return nil
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return evictStore.Victims(n)
 }
 
-// Delete removes a key from the cache
-func (c *Cache) Delete(key string) error {
-	ctx := context.Background()
+// Resize changes the maximum number of entries without recreating the cache
+// Growing takes effect immediately. Shrinking evicts the overflow in eviction
+// policy order, counting evictions exactly like capacity evictions on Set.
+// OnEvict hooks run once the cache lock is released, so they may call back
+// into the cache. Returns ErrAllPinned, leaving the capacity
+// unchanged, if pinned entries would have to be evicted.
+func (c *Cache) Resize(maxEntries int) error {
+	resizeStore, ok := c.store.(store.ResizeStore)
+	if !ok {
+		return fmt.Errorf("store does not support resizing")
+	}
 
 	c.mu.Lock()
-	err := c.store.Delete(key)
+	c.deferEvictions()
+	err := resizeStore.Resize(maxEntries)
 	if err == nil {
-		c.stats.incInvalidations()
+		c.stats.setCapacity(int64(maxEntries))
 		c.updateKeyCount()
-		if c.hooks != nil {
-			c.hooks.invokeOnInvalidateWithCtx(ctx, key, nil)
-		}
 	}
+	pending := c.takeEvictions()
 	c.mu.Unlock()
 
-	return err
+	c.firePendingEvictions(pending)
+	if err != nil {
+		return fmt.Errorf("resize to %d entries: %w", maxEntries, err)
+	}
+	return nil
 }
 
-// Clear removes all entries from the cache
-func (c *Cache) Clear() error {
+// Pin protects an entry from capacity eviction until Unpin is called
+// Pinned entries still expire according to their TTL, and a pin is dropped when
+// its entry is deleted. If the cache fills up with pinned entries, Set returns
+// ErrAllPinned. Returns ErrNotFound if the key is not cached.
+func (c *Cache) Pin(key string) error {
+	pinStore, ok := c.store.(store.PinStore)
+	if !ok {
+		return fmt.Errorf("store does not support pinning")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !pinStore.Pin(key) {
+		return fmt.Errorf("pin %q: %w", key, ErrNotFound)
+	}
+	c.updateKeyCount()
+	return nil
+}
+
+// Unpin makes a pinned entry eligible for eviction again
+// Unpinning a key that is not pinned is a no-op
+func (c *Cache) Unpin(key string) error {
+	pinStore, ok := c.store.(store.PinStore)
+	if !ok {
+		return fmt.Errorf("store does not support pinning")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	pinStore.Unpin(key)
+	c.updateKeyCount()
+	return nil
+}
+
+// ClearWhere removes every entry for which fn returns true and returns the count removed
+// fn runs without holding the cache lock. An entry that is overwritten between being
+// matched and being removed is left in place. Removed keys count as deletes and
+// invalidations and fire OnInvalidate hooks, followed by one OnClear hook call with the count.
+func (c *Cache) ClearWhere(fn func(key string, info EntryInfo) bool) int {
 	ctx := context.Background()
 
+	type match struct {
+		key       string
+		createdAt time.Time
+	}
+	var matches []match
+	for _, key := range c.Keys() {
+		cacheEntry, found := c.store.Peek(key)
+		if !found {
+			continue
+		}
+		if fn(key, newEntryInfo(cacheEntry)) {
+			matches = append(matches, match{key: key, createdAt: cacheEntry.CreatedAt})
+		}
+	}
+	if len(matches) == 0 {
+		return 0
+	}
+
+	candidates := make([]string, 0, len(matches))
+	failed := make(map[string]error)
 	c.mu.Lock()
-	keys := c.store.Keys()
-	err := c.store.Clear()
-	if err == nil {
-		for _, key := range keys {
-			c.stats.incInvalidations()
-			if c.hooks != nil {
-				c.hooks.invokeOnInvalidateWithCtx(ctx, key, nil)
-			}
+	for _, m := range matches {
+		// Skip entries that were replaced or removed after fn inspected them
+		current, found := c.store.Peek(m.key)
+		if found && current.CreatedAt.Equal(m.createdAt) {
+			candidates = append(candidates, m.key)
 		}
-		c.updateKeyCount()
 	}
+	c.deleteKeys(candidates, failed)
+	c.updateKeyCount()
 	c.mu.Unlock()
 
-	return err
+	removed := slices.DeleteFunc(candidates, func(key string) bool {
+		_, ok := failed[key]
+		return ok
+	})
+
+	c.stats.addDeletes(len(removed))
+	for _, key := range removed {
+		c.stats.incInvalidations()
+		if c.hooks != nil {
+			c.hookRan("OnInvalidate", c.hooks.invokeOnInvalidateWithCtx(ctx, key, nil))
+		}
+	}
+	if len(removed) > 0 {
+		c.cleared(context.WithValue(ctx, partialClearKey{}, true), len(removed))
+	}
+
+	return len(removed)
 }
 
-// Stats returns the current cache statistics
+// Stats returns the cache's live statistics, which keep changing as the cache is
+// used. Use Stats().Snapshot() to read several values consistently
 func (c *Cache) Stats() *Stats {
 	c.updateKeyCount()
 	return c.stats
 }
 
+// HookStats returns the execution statistics of each kind of hook, keyed by event
+// name, e.g. "OnHit", or nil if Config.Hooks is nil. Hooks shared with other
+// caches report their combined runs
+func (c *Cache) HookStats() map[string]HookStats {
+	if c.hooks == nil {
+		return nil
+	}
+	return c.hooks.Stats()
+}
+
+// ResetStats zeroes the counters, e.g. after a deployment or between tests
+// The key count, pinned count and byte totals describe the current contents, so
+// they are recomputed from the store rather than zeroed. In-flight requests and
+// the write queue depth are kept
+func (c *Cache) ResetStats() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stats.resetCounters()
+	c.updateKeyCount()
+}
+
 // Keys returns all current cache keys
 func (c *Cache) Keys() []string {
 	c.mu.RLock()
@@ -308,6 +1897,98 @@ func (c *Cache) Keys() []string {
 	return keys
 }
 
+// DefaultScanLimit is the page size used by KeysWithPrefix when limit is not positive
+const DefaultScanLimit = 100
+
+// KeysWithPrefix returns a page of keys that start with prefix
+// Pass an empty cursor to start and the returned nextCursor to continue;
+// an empty nextCursor means there are no more keys. Cursors are opaque and
+// only valid for the store that produced them.
+func (c *Cache) KeysWithPrefix(prefix string, cursor string, limit int) ([]string, string, error) {
+	scanStore, ok := c.store.(store.ScanStore)
+	if !ok {
+		return nil, "", fmt.Errorf("store does not support key scanning")
+	}
+	if limit <= 0 {
+		limit = DefaultScanLimit
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return scanStore.Scan(prefix, cursor, limit)
+}
+
+// CountByPrefix returns the number of non-expired entries whose key starts with prefix
+func (c *Cache) CountByPrefix(prefix string) int {
+	if countStore, ok := c.store.(store.CountStore); ok {
+		return store.CountPrefixKeys(countStore, prefix)
+	}
+	count, _ := c.CountByPrefixWithSize(prefix)
+	return count
+}
+
+// CountByPrefixWithSize returns the number of non-expired entries whose key starts
+// with prefix, along with their total stored size in bytes where it is known
+// (compressed or serialized values; Redis reports the size of the stored entry
+// envelope). The store scans in chunks, so the count is approximate while the
+// cache is being modified concurrently.
+func (c *Cache) CountByPrefixWithSize(prefix string) (int, int64) {
+	// The store locks each chunk itself; holding c.mu here would block writers for the whole scan
+	if countStore, ok := c.store.(store.CountStore); ok {
+		return countStore.CountPrefix(prefix)
+	}
+
+	count := 0
+	for _, key := range c.Keys() {
+		if strings.HasPrefix(key, prefix) {
+			count++
+		}
+	}
+	return count, 0
+}
+
+// TrainCompressionDictionary trains a zstd dictionary of at most maxSize bytes
+// from the serialized form of up to maxSamples current values. Pass the result
+// to compression.Config.WithDictionary when creating the cache next time
+func (c *Cache) TrainCompressionDictionary(maxSamples, maxSize int) ([]byte, error) {
+	var samples [][]byte
+	for _, key := range c.Keys() {
+		if maxSamples > 0 && len(samples) >= maxSamples {
+			break
+		}
+		if sample, ok := c.serializedValue(key); ok {
+			samples = append(samples, sample)
+		}
+	}
+	return compression.TrainZstdDictionary(samples, maxSize)
+}
+
+// serializedValue returns the stored value of key as encoded by its codec,
+// before compression
+func (c *Cache) serializedValue(key string) ([]byte, bool) {
+	c.mu.RLock()
+	cacheEntry, found := c.store.Peek(key)
+	c.mu.RUnlock()
+	if !found || cacheEntry.IsExpired() {
+		return nil, false
+	}
+
+	if !c.serializes() {
+		data, err := c.codec.Marshal(cacheEntry.Value)
+		return data, err == nil
+	}
+	data, err := c.storedBytes(key, cacheEntry)
+	if err != nil || !cacheEntry.IsCompressed {
+		return data, err == nil
+	}
+	_, compressor, err := c.decodersFor(cacheEntry)
+	if err != nil {
+		return nil, false
+	}
+	data, err = compressor.Decompress(data)
+	return data, err == nil
+}
+
 // Len returns the current number of entries in the cache
 func (c *Cache) Len() int {
 	c.mu.RLock()
@@ -317,28 +1998,53 @@ func (c *Cache) Len() int {
 }
 
 // Has checks if a key exists in the cache
+// It does not count as an access, so eviction order and frequencies are unchanged
 func (c *Cache) Has(key string) bool {
 	c.mu.RLock()
-	entry, found := c.store.Get(key)
+	entry, found := c.store.Peek(key)
 	exists := found && !entry.IsExpired()
 	c.mu.RUnlock()
 	return exists
 }
 
 // TTL returns the remaining TTL for a key
+// Entries stored without expiration report NoTTL and true
 func (c *Cache) TTL(key string) (time.Duration, bool) {
 	c.mu.RLock()
-	entry, ok := c.store.Get(key)
+	entry, ok := c.store.Peek(key)
 	c.mu.RUnlock()
 
-	if ok && !entry.IsExpired() {
-		return entry.TTL(), true
+	if !ok || entry.IsExpired() {
+		return 0, false
+	}
+	if !entry.HasExpiry() {
+		return NoTTL, true
+	}
+	return entry.TTL(), true
+}
+
+// SetTTL changes the expiration of an existing entry without rewriting its value
+// A ttl of 0 applies DefaultTTL and NoTTL removes the expiration. Returns false if
+// the key is missing or expired. Hit/miss statistics and eviction order are unaffected.
+func (c *Cache) SetTTL(key string, ttl time.Duration) bool {
+	ttlStore, ok := c.store.(store.TTLStore)
+	if !ok {
+		return false
 	}
-	return 0, false
+
+	c.mu.RLock()
+	updated := ttlStore.UpdateTTL(key, c.resolveTTL(ttl))
+	c.mu.RUnlock()
+	return updated
 }
 
 // Close closes the cache and cleans up resources
 func (c *Cache) Close() error {
+	persistErr := c.stopPersistence()
+	if c.hooks != nil {
+		c.hooks.detachStats(c.stats)
+	}
+
 	c.mu.Lock()
 	if c.metricsStop != nil {
 		close(c.metricsStop)
@@ -349,25 +2055,75 @@ func (c *Cache) Close() error {
 	}
 	err := c.store.Close()
 	c.mu.Unlock()
-	return err
+	return errors.Join(persistErr, err)
 }
 
 // Cleanup removes expired entries and returns count removed
+// OnEvict hooks for the removed entries run after the cache lock is released
 func (c *Cache) Cleanup() int {
-	c.mu.Lock()
-	var removed int
-	if store, ok := c.store.(store.TTLStore); ok {
-		removed = store.Cleanup()
-		c.updateKeyCount()
+	ttlStore, ok := c.store.(store.TTLStore)
+	if !ok {
+		return 0
 	}
+
+	c.mu.Lock()
+	c.deferEvictions()
+	removed := ttlStore.Cleanup()
+	c.updateKeyCount()
+	pending := c.takeEvictions()
 	c.mu.Unlock()
+
+	c.firePendingEvictions(pending)
 	return removed
 }
 
 // updateKeyCount updates the key count statistic
+// Writers holding c.mu shared call it concurrently, so only the most recently
+// started update is published: it counted after every write that came before it
 func (c *Cache) updateKeyCount() {
+	seq := c.countSeq.Add(1)
 	count := int64(c.store.Len())
-	c.stats.setKeyCount(count)
+	pinned, weight := int64(-1), int64(-1)
+	if pinStore, ok := c.store.(store.PinStore); ok {
+		pinned = int64(pinStore.PinnedCount())
+	}
+	if weightStore, ok := c.store.(store.WeightStore); ok {
+		weight = weightStore.Weight()
+	}
+
+	c.countMu.Lock()
+	if seq > c.countDone {
+		c.countDone = seq
+		c.stats.setKeyCount(count)
+		if pinned >= 0 {
+			c.stats.setPinnedCount(pinned)
+		}
+		if weight >= 0 {
+			c.stats.setStoredBytes(weight)
+		}
+	}
+	c.countMu.Unlock()
+	c.refreshMemoryBytes()
+}
+
+// refreshMemoryBytes copies the store's memory estimate into the stats
+// It reads a single counter, so store callbacks may call it under the store lock
+func (c *Cache) refreshMemoryBytes() {
+	if memoryStore, ok := c.store.(store.MemoryStore); ok {
+		c.stats.setMemoryBytes(memoryStore.MemoryBytes())
+	}
+}
+
+// resolveTTL maps a caller-supplied ttl to the effective entry ttl:
+// 0 selects DefaultTTL and negative values mean no expiration
+func (c *Cache) resolveTTL(ttl time.Duration) time.Duration {
+	if ttl < 0 {
+		return NoTTL
+	}
+	if ttl == 0 {
+		return c.config.DefaultTTL
+	}
+	return ttl
 }
 
 // getKeyGenFunc returns the key generation function to use
@@ -378,8 +2134,8 @@ func (c *Cache) getKeyGenFunc() KeyGenFunc {
 	return DefaultKeyFunc
 }
 
-// createCompressedEntry creates a cache entry with compression if applicable
-func (c *Cache) createCompressedEntry(value any, ttl time.Duration) (*entry.Entry, error) {
+// createCompressedEntry creates a cache entry for key with compression if applicable
+func (c *Cache) createCompressedEntry(key string, value any, ttl time.Duration, opts SetOptions) (*entry.Entry, error) {
 	var cacheEntry *entry.Entry
 	if ttl > 0 {
 		cacheEntry = entry.New(nil, ttl) // We'll set the value after compression
@@ -387,55 +2143,187 @@ func (c *Cache) createCompressedEntry(value any, ttl time.Duration) (*entry.Entr
 		cacheEntry = entry.NewWithoutTTL(nil)
 	}
 
-	// Only try compression if it's enabled
-	if c.config.Compression != nil && c.config.Compression.Enabled {
-		// Serialize and compress the value
-		compressed, isCompressed, err := compression.SerializeAndCompress(
+	if c.serializes() {
+		// Serialize and compress the value, only serializing it when compression
+		// is off, the caller opts out or adaptive compression predicts it will not shrink
+		compress := c.config.Compression.Enabled
+		minSize := c.config.Compression.MinSize
+		skip := opts.SkipCompression
+		switch {
+		case !compress:
+			skip = true
+			minSize = math.MaxInt
+		case opts.ForceCompression:
+			minSize = 0
+		case skip:
+			minSize = math.MaxInt
+		case c.adaptive != nil && !c.adaptive.ShouldCompress(key):
+			skip = true
+			minSize = math.MaxInt
+			c.stats.incCompressionSkips()
+		}
+		// Bytes that are already serialized are stored as they are
+		valueCodec := c.codec
+		if _, raw := codec.RawBytes(value); raw {
+			valueCodec = codec.Raw{}
+		}
+
+		start := time.Now()
+		compressed, originalSize, isCompressed, err := compression.SerializeAndCompressSized(
+			valueCodec,
 			value,
 			c.compressor,
-			c.config.Compression.MinSize,
+			minSize,
 		)
 		if err != nil {
 			return nil, err
 		}
+		encodeTime := time.Since(start)
+		cacheEntry.CodecName = valueCodec.Name()
 
 		if isCompressed {
 			// Store compressed data and metadata
 			cacheEntry.Value = compressed
-
-			// Calculate original size by serializing without compression
-			serialized, _, serErr := compression.SerializeAndCompress(value, compression.NewNoOpCompressor(), 0)
-			originalSize := len(serialized)
-			if serErr != nil {
-				// Fallback to approximate size if serialization fails
-				originalSize = c.approximateSize(value)
-			}
-
 			cacheEntry.SetCompressionInfo(c.compressor.Name(), originalSize, len(compressed))
+			cacheEntry.DictionaryID = compression.DictionaryIDOf(c.compressor)
+			if c.adaptive != nil {
+				c.adaptive.Record(key, originalSize, len(compressed))
+			}
 		} else {
 			// Store uncompressed data
 			cacheEntry.Value = compressed // This is actually the uncompressed serialized data
+			if c.adaptive != nil && !skip && len(compressed) >= minSize {
+				c.adaptive.Record(key, len(compressed), len(compressed)) // Compression did not shrink it
+			}
+		}
+		if compress {
+			c.recordCompression(encodeTime, cacheEntry)
+		}
+
+		if c.encryptor != nil {
+			sealed, err := c.encryptor.Encrypt(compressed, []byte(key))
+			if err != nil {
+				return nil, fmt.Errorf("failed to encrypt value: %w", err)
+			}
+			cacheEntry.Value = sealed
 		}
 	} else {
 		// No compression, store value directly
 		cacheEntry.Value = value
+		cacheEntry.ValueSize = c.valueSize(value)
 	}
 
 	return cacheEntry, nil
 }
 
+// decodersFor returns the codec and compressor that wrote e, which are not the
+// configured ones for entries written before the configuration changed
+func (c *Cache) decodersFor(e *entry.Entry) (codec.Codec, compression.Compressor, error) {
+	valueCodec := c.codec
+	if e.CodecName != "" && e.CodecName != valueCodec.Name() {
+		var err error
+		if valueCodec, err = codec.Lookup(e.CodecName); err != nil {
+			return nil, nil, fmt.Errorf("failed to deserialize value: %w", err)
+		}
+	}
+
+	compressor := c.compressor
+	if !e.IsCompressed {
+		return valueCodec, compressor, nil
+	}
+	if e.CompressorName != "" && e.CompressorName != compressor.Name() {
+		var err error
+		if compressor, err = compression.Lookup(e.CompressorName); err != nil {
+			return nil, nil, fmt.Errorf("failed to decompress value: %w", err)
+		}
+	}
+
+	// Values compressed with a dictionary can only be read with that dictionary
+	dictID := compression.DictionaryIDOf(compressor)
+	switch {
+	case e.DictionaryID == dictID:
+	case e.DictionaryID == 0:
+		var err error
+		if compressor, err = compression.Lookup(compressor.Name()); err != nil {
+			return nil, nil, fmt.Errorf("failed to decompress value: %w", err)
+		}
+	default:
+		return nil, nil, fmt.Errorf("failed to decompress value: %w: entry uses %08x, configured %08x",
+			compression.ErrDictionaryMismatch, e.DictionaryID, dictID)
+	}
+	return valueCodec, compressor, nil
+}
+
+// recordCompression adds the encoding time and, if e was stored compressed, its
+// sizes to the compression statistics
+func (c *Cache) recordCompression(d time.Duration, e *entry.Entry) {
+	compressedSize := 0
+	if e.IsCompressed {
+		compressedSize = e.CompressedSize
+	}
+	c.stats.addCompression(d, e.OriginalSize, compressedSize)
+
+	if c.metricsExporter != nil {
+		names := metrics.DefaultMetricNames()
+		c.exportFailed(c.metricsExporter.RecordHistogram(names.CompressionDuration, d.Seconds(), c.metricsLabels))
+		if e.IsCompressed {
+			c.exportFailed(c.metricsExporter.IncrementCounter(names.CompressedEntriesTotal, c.metricsLabels))
+		}
+	}
+}
+
+// recordDecompression adds the decoding time of a value to the compression statistics
+func (c *Cache) recordDecompression(d time.Duration) {
+	if !c.config.Compression.Enabled {
+		return // Values are only serialized for encryption
+	}
+	c.stats.addDecompression(d)
+	if c.metricsExporter != nil {
+		c.exportFailed(c.metricsExporter.RecordHistogram(metrics.DefaultMetricNames().DecompressionDuration, d.Seconds(), c.metricsLabels))
+	}
+}
+
+// serializes reports whether values are stored serialized, which compression
+// and encryption both require
+func (c *Cache) serializes() bool {
+	return (c.config.Compression != nil && c.config.Compression.Enabled) || c.encryptor != nil
+}
+
+// storedBytes returns the serialized, possibly compressed, bytes of the value
+// stored under key in e, decrypting them if encryption is configured
+func (c *Cache) storedBytes(key string, e *entry.Entry) ([]byte, error) {
+	data, ok := e.Value.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("serialized value is not []byte")
+	}
+	if c.encryptor == nil {
+		return data, nil
+	}
+	data, err := c.encryptor.Decrypt(data, []byte(key))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt value: %w", err)
+	}
+	return data, nil
+}
+
 // decompressValue decompresses a cached value if needed
-func (c *Cache) decompressValue(entry *entry.Entry) (any, error) {
-	// Check if compression was used during storage
-	if c.config.Compression != nil && c.config.Compression.Enabled {
-		// Value was stored with compression logic (might be compressed or serialized)
-		data, ok := entry.Value.([]byte)
-		if !ok {
-			return nil, fmt.Errorf("serialized value is not []byte")
+func (c *Cache) decompressValue(key string, entry *entry.Entry) (any, error) {
+	// Check if the value was serialized for compression or encryption
+	if c.serializes() {
+		data, err := c.storedBytes(key, entry)
+		if err != nil {
+			return nil, err
+		}
+
+		valueCodec, compressor, err := c.decodersFor(entry)
+		if err != nil {
+			return nil, err
 		}
 
 		var result any
-		err := compression.DecompressAndDeserialize(data, entry.IsCompressed, c.compressor, &result)
+		start := time.Now()
+		err = compression.DecompressAndDeserializeWith(valueCodec, data, entry.IsCompressed, compressor, &result)
+		c.recordDecompression(time.Since(start))
 		if err != nil {
 			return nil, fmt.Errorf("failed to deserialize value: %w", err)
 		}
@@ -446,6 +2334,19 @@ func (c *Cache) decompressValue(entry *entry.Entry) (any, error) {
 	return entry.Value, nil
 }
 
+// valueSize estimates the size of an uncompressed value for weight-based and
+// size-aware eviction. Returns 0 when neither a weight limit, GDSF eviction
+// nor a Sizer is configured, to keep Set cheap
+func (c *Cache) valueSize(value any) int {
+	if c.config.Sizer != nil {
+		return c.config.Sizer(value)
+	}
+	if c.config.MaxWeight > 0 || c.config.EvictionType == eviction.GDSF {
+		return c.approximateSize(value)
+	}
+	return 0
+}
+
 // approximateSize estimates the memory size of a value
 func (c *Cache) approximateSize(value any) int {
 	if value == nil {
@@ -483,41 +2384,57 @@ func (c *Cache) approximateSize(value any) int {
 	}
 }
 
+// valueCodec returns the codec values are serialized with: the compression
+// codec, else the Redis codec, else JSON
+func valueCodec(config *Config) codec.Codec {
+	switch {
+	case config.Compression != nil && config.Compression.Codec != nil:
+		return config.Compression.Codec
+	case config.Redis != nil && config.Redis.Codec != nil:
+		return config.Redis.Codec
+	}
+	return codec.JSON{}
+}
+
 // initializeCompression sets up compression if enabled
+// Compressed values are serialized with the Redis codec when one is configured
 func (c *Cache) initializeCompression() error {
 	if c.config.Compression == nil {
 		c.config.Compression = compression.NewDefaultConfig()
 	}
 
+	c.codec = valueCodec(c.config)
+
 	compressor, err := compression.NewCompressor(c.config.Compression)
 	if err != nil {
 		return fmt.Errorf("failed to create compressor: %w", err)
 	}
 
 	c.compressor = compressor
+	c.encryptor = c.config.Encryption
+	if c.config.Compression.Enabled && c.config.Compression.Adaptive {
+		c.adaptive = compression.NewAdaptive(c.config.Compression.AdaptiveMinRatio, c.config.Compression.AdaptiveResampleEvery)
+	}
 	return nil
 }
 
 // initializeMetrics sets up metrics collection if enabled
 func (c *Cache) initializeMetrics() error {
-	if c.config.Metrics == nil || !c.config.Metrics.Enabled || c.config.Metrics.Exporter == nil {
+	if !metricsEnabled(c.config) {
 		c.metricsExporter = metrics.NewNoOpExporter()
 		return nil
 	}
 
 	c.metricsExporter = c.config.Metrics.Exporter
-
-	// Prepare metrics labels with cache name
-	c.metricsLabels = make(metrics.Labels)
-	if c.config.Metrics.CacheName != "" {
-		c.metricsLabels["cache_name"] = c.config.Metrics.CacheName
-	} else {
-		c.metricsLabels["cache_name"] = "default"
-	}
-
-	// Add any additional labels from config
-	for k, v := range c.config.Metrics.Labels {
-		c.metricsLabels[k] = v
+	c.metricsLabels = metricsLabels(c.config)
+	c.timeStore = true
+	if c.config.Metrics.ExportHookDurations && c.hooks != nil {
+		c.hookLabels = make(map[string]metrics.Labels)
+		for event := range c.hooks.Stats() {
+			labels := maps.Clone(c.metricsLabels)
+			labels[metrics.LabelHook] = event
+			c.hookLabels[event] = labels
+		}
 	}
 
 	// Start automatic stats reporting if interval is configured
@@ -530,6 +2447,54 @@ func (c *Cache) initializeMetrics() error {
 	return nil
 }
 
+// metricsEnabled reports whether config exports metrics
+func metricsEnabled(config *Config) bool {
+	return config.Metrics != nil && config.Metrics.Enabled && config.Metrics.Exporter != nil
+}
+
+// metricsLabels returns the labels applied to all of a cache's metrics
+func metricsLabels(config *Config) metrics.Labels {
+	labels := make(metrics.Labels)
+	if config.Metrics.CacheName != "" {
+		labels["cache_name"] = config.Metrics.CacheName
+	} else {
+		labels["cache_name"] = "default"
+	}
+
+	// Add any additional labels from config
+	for k, v := range config.Metrics.Labels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// newStrategyInstrumentation forwards eviction strategy events to the metrics
+// exporter, labelled with the strategy name. Returns nil unless metrics and
+// ExportStrategyEvents are enabled so strategies run uninstrumented
+func newStrategyInstrumentation(config *Config, evictionType eviction.EvictionType) *eviction.Instrumentation {
+	if !metricsEnabled(config) || !config.Metrics.ExportStrategyEvents {
+		return nil
+	}
+
+	exporter := config.Metrics.Exporter
+	names := metrics.DefaultMetricNames()
+	labels := metricsLabels(config)
+	labels["strategy"] = string(evictionType)
+
+	count := func(name string) {
+		_ = exporter.IncrementCounter(name, labels) //nolint:errcheck // Error handling done at higher level
+	}
+	return &eviction.Instrumentation{
+		OnAdmit:    func(string) { count(names.StrategyAdmissionsTotal) },
+		OnPromote:  func(string) { count(names.StrategyPromotionsTotal) },
+		OnDemote:   func(string) { count(names.StrategyDemotionsTotal) },
+		OnAgeReset: func() { count(names.StrategyAgeResetsTotal) },
+		OnEvictSelect: func(d time.Duration) {
+			_ = exporter.RecordHistogram(names.StrategyVictimSelectDuration, d.Seconds(), labels) //nolint:errcheck // Error handling done at higher level
+		},
+	}
+}
+
 // metricsReporter periodically exports cache statistics
 func (c *Cache) metricsReporter() {
 	defer c.metricsWg.Done()
@@ -541,6 +2506,7 @@ func (c *Cache) metricsReporter() {
 		select {
 		case <-ticker.C:
 			c.exportCurrentStats()
+			c.Healthy() // Re-probes once the cached result is stale, refreshing the health gauge
 		case <-c.metricsStop:
 			// Final stats export before shutting down
 			c.exportCurrentStats()
@@ -552,13 +2518,52 @@ func (c *Cache) metricsReporter() {
 // exportCurrentStats exports the current statistics to metrics
 func (c *Cache) exportCurrentStats() {
 	if c.metricsExporter != nil {
-		_ = c.metricsExporter.ExportStats(c.stats, c.metricsLabels) //nolint:errcheck // Error handling done at higher level
+		snapshot := c.stats.Snapshot()
+		c.exportFailed(c.metricsExporter.ExportStats(snapshot.exported(), c.metricsLabels))
+		if _, ok := c.store.(store.WriteBehindStore); ok {
+			c.exportFailed(c.metricsExporter.SetGauge(metrics.DefaultMetricNames().CacheWriteQueueDepth, float64(snapshot.WriteQueueDepth), c.metricsLabels))
+		}
+		if c.config.Compression != nil && c.config.Compression.Enabled {
+			names := metrics.DefaultMetricNames()
+			c.exportFailed(c.metricsExporter.SetGauge(names.CompressionOriginalBytes, float64(snapshot.CompressionOriginalBytes), c.metricsLabels))
+			c.exportFailed(c.metricsExporter.SetGauge(names.CompressionCompressedBytes, float64(snapshot.CompressionCompressedBytes), c.metricsLabels))
+			c.exportFailed(c.metricsExporter.SetGauge(names.CompressionRatio, snapshot.CompressionRatio, c.metricsLabels))
+		}
+		if c.hotKeys != nil && c.config.Metrics.ExportHotKeys {
+			c.exportHotKeys()
+		}
 	}
 }
 
 // recordCacheOperation records a cache operation with timing for metrics
 func (c *Cache) recordCacheOperation(operation metrics.Operation, duration time.Duration) {
 	if c.metricsExporter != nil {
-		_ = c.metricsExporter.RecordCacheOperation(operation, duration, c.metricsLabels) //nolint:errcheck // Error handling done at higher level
+		c.exportFailed(c.metricsExporter.RecordCacheOperation(operation, duration, c.metricsLabels))
+	}
+}
+
+// storeTimer returns the start of a store call, or the zero time when metrics
+// are disabled so that store calls cost no clock reads
+func (c *Cache) storeTimer() time.Time {
+	if !c.timeStore {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// storeElapsed returns the time since a store call started at start
+func (c *Cache) storeElapsed(start time.Time) time.Duration {
+	if !c.timeStore {
+		return 0
+	}
+	return time.Since(start)
+}
+
+// recordStoreOperation records the duration of a store call; callers record it
+// after releasing c.mu, since exporters may be slow
+func (c *Cache) recordStoreOperation(operation metrics.Operation, duration time.Duration) {
+	if !c.timeStore {
+		return
 	}
+	c.exportFailed(metrics.RecordStoreOperation(c.metricsExporter, operation, duration, c.metricsLabels))
 }
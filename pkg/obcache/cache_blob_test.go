@@ -0,0 +1,147 @@
+package obcache
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryBucket is a BlobClient keeping objects in a map
+type memoryBucket struct {
+	mu       sync.Mutex
+	objects  map[string][]byte
+	metadata map[string]map[string]string
+	gets     int
+}
+
+func newMemoryBucket() *memoryBucket {
+	return &memoryBucket{objects: make(map[string][]byte), metadata: make(map[string]map[string]string)}
+}
+
+func (b *memoryBucket) PutObject(ctx context.Context, key string, data []byte, metadata map[string]string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.objects[key], b.metadata[key] = data, metadata
+	return nil
+}
+
+func (b *memoryBucket) GetObject(ctx context.Context, key string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.gets++
+	data, ok := b.objects[key]
+	if !ok {
+		return nil, ErrBlobNotFound
+	}
+	return data, nil
+}
+
+func (b *memoryBucket) StatObject(ctx context.Context, key string) (map[string]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.objects[key]; !ok {
+		return nil, ErrBlobNotFound
+	}
+	return b.metadata[key], nil
+}
+
+func (b *memoryBucket) DeleteObjects(ctx context.Context, keys []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, key := range keys {
+		delete(b.objects, key)
+		delete(b.metadata, key)
+	}
+	return nil
+}
+
+func (b *memoryBucket) ListObjects(ctx context.Context, prefix string, fn func(keys []string) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	var keys []string
+	for key := range b.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	b.mu.Unlock()
+	return fn(keys)
+}
+
+func (b *memoryBucket) getCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.gets
+}
+
+func TestCacheWithBlobStore(t *testing.T) {
+	bucket := newMemoryBucket()
+	config := NewBlobConfig(bucket)
+	config.Blob.L1MaxEntries = 10
+	config.CleanupInterval = 0
+
+	var expired []string
+	hooks := NewHooks()
+	hooks.AddOnEvict(func(_ context.Context, key string, _ any, reason EvictReason) {
+		if reason == EvictReasonTTL {
+			expired = append(expired, key)
+		}
+	})
+	config.Hooks = hooks
+
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	t.Cleanup(func() { _ = cache.Close() })
+
+	_ = cache.Set("export", "rendered", time.Hour)
+	if _, ok := bucket.objects["obcache/export"]; !ok {
+		t.Fatal("Expected the entry to be uploaded to the bucket")
+	}
+	if value, found := cache.Get("export"); !found || value != "rendered" {
+		t.Errorf("Expected rendered, got %v (found=%v)", value, found)
+	}
+	if bucket.getCount() != 0 {
+		t.Error("Expected the read to be served by the local tier")
+	}
+
+	// Requests to the bucket are bounded by the caller's context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := cache.DeleteContext(ctx, "export"); err == nil {
+		t.Error("Expected DeleteContext with a cancelled context to fail")
+	}
+	if _, found := cache.GetContext(ctx, "export"); found {
+		t.Error("Expected a read past the local tier with a cancelled context to miss")
+	}
+	if value, found := cache.Get("export"); !found || value != "rendered" {
+		t.Errorf("Expected the failed delete to leave the object, got %v (found=%v)", value, found)
+	}
+
+	_ = cache.Set("other", "value", 50*time.Millisecond)
+
+	time.Sleep(60 * time.Millisecond)
+	if removed := cache.Cleanup(); removed != 1 {
+		t.Errorf("Expected one expired object removed, got %d", removed)
+	}
+	if len(expired) != 1 || expired[0] != "other" {
+		t.Errorf("Expected an OnEvict hook for other, got %v", expired)
+	}
+}
@@ -0,0 +1,378 @@
+package obcache
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/codec"
+	"github.com/1mb-dev/obcache-go/v2/pkg/compression"
+	"github.com/1mb-dev/obcache-go/v2/pkg/encryption"
+)
+
+func TestCacheWithBoltStoreSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+
+	cache, err := New(NewBoltConfig(path).WithMaxEntries(100))
+	if err != nil {
+		t.Fatalf("Failed to create Bolt cache: %v", err)
+	}
+	if err := cache.Set("user:1", "alice", time.Hour); err != nil {
+		t.Fatalf("Failed to set cache entry: %v", err)
+	}
+	if err := cache.Set("session", "token", 20*time.Millisecond); err != nil {
+		t.Fatalf("Failed to set cache entry: %v", err)
+	}
+	if err := cache.Close(); err != nil {
+		t.Fatalf("Failed to close cache: %v", err)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+
+	cache, err = New(NewBoltConfig(path).WithMaxEntries(100))
+	if err != nil {
+		t.Fatalf("Failed to reopen Bolt cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	if value, found := cache.Get("user:1"); !found || value != "alice" {
+		t.Errorf("Expected user:1 to survive restart, got %v (found=%v)", value, found)
+	}
+	if ttl, found := cache.TTL("user:1"); !found || ttl <= 50*time.Minute {
+		t.Errorf("Expected user:1 to keep its TTL, got %v (found=%v)", ttl, found)
+	}
+	if cache.Has("session") {
+		t.Error("Expected session to have expired while the cache was closed")
+	}
+}
+
+func TestCacheWithBoltStoreRequiresConfig(t *testing.T) {
+	config := NewDefaultConfig()
+	config.StoreType = StoreTypeBolt
+	if _, err := New(config); err == nil {
+		t.Error("Expected an error without Bolt configuration")
+	}
+}
+
+func TestCacheWithBoltStoreCompression(t *testing.T) {
+	config := NewBoltConfig(filepath.Join(t.TempDir(), "cache.db")).
+		WithCompression(compression.NewDefaultConfig().WithEnabled(true).WithMinSize(100))
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create Bolt cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	large := strings.Repeat("compressible ", 100)
+	_ = cache.Set("small", "tiny", time.Hour)
+	_ = cache.Set("large", large, time.Hour)
+
+	if value, found := cache.Get("small"); !found || value != "tiny" {
+		t.Errorf("Expected small value below MinSize to round-trip, got %v (found=%v)", value, found)
+	}
+	if value, found := cache.Get("large"); !found || value != large {
+		t.Errorf("Expected compressed value to round-trip, got %v (found=%v)", value, found)
+	}
+}
+
+func TestCacheWithBoltStoreChangedCompression(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+	large := strings.Repeat("compressible ", 100)
+
+	gzipCache, err := New(NewBoltConfig(path).
+		WithCompression(compression.NewDefaultConfig().WithEnabled(true).WithMinSize(100)))
+	if err != nil {
+		t.Fatalf("Failed to create Bolt cache: %v", err)
+	}
+	_ = gzipCache.Set("old", large, time.Hour)
+	_ = gzipCache.Close()
+
+	// Entries record the algorithm that compressed them, so switching to zstd
+	// keeps the gzip entries readable
+	zstdCache, err := New(NewBoltConfig(path).
+		WithCompression(compression.NewDefaultConfig().WithEnabled(true).WithMinSize(100).WithAlgorithm(compression.CompressorZstd)))
+	if err != nil {
+		t.Fatalf("Failed to reopen Bolt cache: %v", err)
+	}
+	defer func() { _ = zstdCache.Close() }()
+
+	if value, found := zstdCache.Get("old"); !found || value != large {
+		t.Errorf("Expected the gzip entry to be readable, got %v (found=%v)", value, found)
+	}
+	if value, found := NewTyped[string](zstdCache).Get("old"); !found || value != large {
+		t.Errorf("Expected the gzip entry to be readable through Typed, got %v (found=%v)", value, found)
+	}
+
+	_ = zstdCache.Set("new", large, time.Hour)
+	e, _ := zstdCache.store.Peek("new")
+	if !e.IsCompressed || e.CompressorName != "zstd" {
+		t.Errorf("Expected new entries compressed with zstd, got %q (compressed=%v)", e.CompressorName, e.IsCompressed)
+	}
+	if value, found := zstdCache.Get("new"); !found || value != large {
+		t.Errorf("Expected the zstd entry to round-trip, got %v (found=%v)", value, found)
+	}
+}
+
+func TestCacheWithBoltStoreReadsBrotliEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+	large := strings.Repeat("<tr><td>cold document</td></tr>", 100)
+
+	brotliCache, err := New(NewBoltConfig(path).
+		WithCompression(compression.NewDefaultConfig().WithEnabled(true).WithMinSize(100).WithAlgorithm(compression.CompressorBrotli).WithLevel(11)))
+	if err != nil {
+		t.Fatalf("Failed to create Bolt cache: %v", err)
+	}
+	_ = brotliCache.Set("page", large, time.Hour)
+	if e, _ := brotliCache.store.Peek("page"); e == nil || e.CompressorName != "brotli" {
+		t.Fatalf("Expected the entry to record brotli, got %+v", e)
+	}
+	_ = brotliCache.Close()
+
+	gzipCache, err := New(NewBoltConfig(path).
+		WithCompression(compression.NewDefaultConfig().WithEnabled(true).WithMinSize(100)))
+	if err != nil {
+		t.Fatalf("Failed to reopen Bolt cache: %v", err)
+	}
+	defer func() { _ = gzipCache.Close() }()
+
+	if value, found := gzipCache.Get("page"); !found || value != large {
+		t.Errorf("Expected the brotli entry to be readable by a gzip cache, got %v (found=%v)", value, found)
+	}
+}
+
+// boltEvent is registered with gob so it can be decoded into an interface
+type boltEvent struct {
+	Name string
+	At   time.Time
+}
+
+func init() {
+	gob.Register(boltEvent{})
+}
+
+// unregisteredCodec is a codec missing from the codec registry
+type unregisteredCodec struct{ codec.JSON }
+
+func (unregisteredCodec) Name() string { return "unregistered" }
+
+func TestCacheWithBoltStoreChangedCodec(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+	when := boltEvent{Name: "deploy", At: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+
+	gobCache, err := New(NewBoltConfig(path).
+		WithCompression(compression.NewDefaultConfig().WithEnabled(true).WithCodec(codec.Gob{})))
+	if err != nil {
+		t.Fatalf("Failed to create Bolt cache: %v", err)
+	}
+	_ = gobCache.Set("when", when, time.Hour)
+	if _, info, _ := gobCache.GetEntry("when"); info.Codec != "gob" {
+		t.Errorf("Expected the entry to record the gob codec, got %q", info.Codec)
+	}
+	_ = gobCache.Close()
+
+	// Entries record the codec that serialized them, so a JSON cache still reads
+	// the gob entries with their Go types
+	jsonCache, err := New(NewBoltConfig(path).
+		WithCompression(compression.NewDefaultConfig().WithEnabled(true)))
+	if err != nil {
+		t.Fatalf("Failed to reopen Bolt cache: %v", err)
+	}
+	defer func() { _ = jsonCache.Close() }()
+
+	if value, found := jsonCache.Get("when"); !found || value != when {
+		t.Errorf("Expected the gob entry decoded as a boltEvent, got %T %v (found=%v)", value, value, found)
+	}
+	if value, found := NewTyped[boltEvent](jsonCache).Get("when"); !found || value != when {
+		t.Errorf("Expected the gob entry to be readable through Typed, got %v (found=%v)", value, found)
+	}
+
+	_ = jsonCache.Set("new", "value", time.Hour)
+	if _, info, _ := jsonCache.GetEntry("new"); info.Codec != "json" {
+		t.Errorf("Expected new entries to record the json codec, got %q", info.Codec)
+	}
+}
+
+func TestCacheWithBoltStoreUnknownCodec(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+
+	writer, err := New(NewBoltConfig(path).
+		WithCompression(compression.NewDefaultConfig().WithEnabled(true).WithCodec(unregisteredCodec{})))
+	if err != nil {
+		t.Fatalf("Failed to create Bolt cache: %v", err)
+	}
+	_ = writer.Set("a", "alpha", time.Hour)
+	_ = writer.Set("b", "beta", time.Hour)
+	_ = writer.Set("c", "gamma", time.Hour)
+	_ = writer.Close()
+
+	reader, err := New(NewBoltConfig(path).
+		WithCompression(compression.NewDefaultConfig().WithEnabled(true)))
+	if err != nil {
+		t.Fatalf("Failed to reopen Bolt cache: %v", err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	if _, found := reader.Get("c"); found {
+		t.Error("Expected an entry written with an unregistered codec to be a miss")
+	}
+	if reader.Has("c") || reader.Stats().DecodeErrors() != 1 {
+		t.Errorf("Expected the unreadable entry to be deleted and counted, got %d errors", reader.Stats().DecodeErrors())
+	}
+	if _, _, err := reader.Swap("a", "new", time.Hour); !errors.Is(err, codec.ErrUnknownCodec) {
+		t.Errorf("Expected Swap to report the unknown codec, got %v", err)
+	}
+
+	// The codec name is readable without decoding, so stale entries can be purged
+	removed := reader.ClearWhere(func(_ string, info EntryInfo) bool { return info.Codec == "unregistered" })
+	if removed != 1 {
+		t.Errorf("Expected the remaining unregistered entry to be purged, got %d", removed)
+	}
+	if value, found := reader.Get("a"); !found || value != "new" {
+		t.Errorf("Expected the swapped value, got %v (found=%v)", value, found)
+	}
+}
+
+func TestCacheWithBoltStoreDictionaryMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+	newDict := func(role string) []byte {
+		samples := make([][]byte, 300)
+		for i := range samples {
+			samples[i] = fmt.Appendf(nil, `{"id":%d,"role":%q,"team":"platform-%d","active":true}`, i, role, i%9)
+		}
+		dict, err := compression.TrainZstdDictionary(samples, 2048)
+		if err != nil {
+			t.Fatalf("Failed to train dictionary: %v", err)
+		}
+		return dict
+	}
+	dictConfig := func(dict []byte) *compression.Config {
+		return compression.NewDefaultConfig().WithEnabled(true).WithAlgorithm(compression.CompressorZstd).
+			WithMinSize(0).WithDictionary(dict)
+	}
+
+	writer, err := New(NewBoltConfig(path).WithCompression(dictConfig(newDict("member"))))
+	if err != nil {
+		t.Fatalf("Failed to create Bolt cache: %v", err)
+	}
+	for i, key := range []string{"a", "b", "c"} {
+		_ = writer.Set(key, map[string]any{"id": i, "role": "member", "team": "platform-1", "active": true}, time.Hour)
+	}
+	_ = writer.Close()
+
+	reader, err := New(NewBoltConfig(path).WithCompression(dictConfig(newDict("admin"))))
+	if err != nil {
+		t.Fatalf("Failed to reopen Bolt cache: %v", err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	if _, found := reader.Get("c"); found || reader.Has("c") {
+		t.Error("Expected an entry compressed with another dictionary to be a miss and deleted")
+	}
+	if _, _, err := reader.Swap("a", "new", time.Hour); !errors.Is(err, compression.ErrDictionaryMismatch) {
+		t.Errorf("Expected Swap to report the dictionary mismatch, got %v", err)
+	}
+
+	// Entries written with the old dictionary can be found and purged
+	current, _ := reader.compressor.(compression.DictionaryCompressor)
+	removed := reader.ClearWhere(func(_ string, info EntryInfo) bool {
+		return info.DictionaryID != 0 && info.DictionaryID != current.DictionaryID()
+	})
+	if removed != 1 {
+		t.Errorf("Expected the remaining stale entry to be purged, got %d", removed)
+	}
+	if value, found := reader.Get("a"); !found || value != "new" {
+		t.Errorf("Expected the swapped value, got %v (found=%v)", value, found)
+	}
+}
+
+func TestCacheWithBoltStoreTrainedDictionary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+	zstdConfig := func() *compression.Config {
+		return compression.NewDefaultConfig().WithEnabled(true).WithAlgorithm(compression.CompressorZstd).WithMinSize(0)
+	}
+	profile := func(i int) map[string]any {
+		return map[string]any{"id": i, "name": fmt.Sprintf("user-%d", i), "role": "member", "team": "platform", "active": true}
+	}
+
+	writer, err := New(NewBoltConfig(path).WithCompression(zstdConfig()))
+	if err != nil {
+		t.Fatalf("Failed to create Bolt cache: %v", err)
+	}
+	for i := range 300 {
+		_ = writer.Set(fmt.Sprintf("user:%d", i), profile(i), time.Hour)
+	}
+	dict, err := writer.TrainCompressionDictionary(200, 2048)
+	if err != nil {
+		t.Fatalf("Failed to train dictionary: %v", err)
+	}
+	plainCompressed := writer.Stats().CompressedEntries()
+	_ = writer.Close()
+
+	reader, err := New(NewBoltConfig(path).WithCompression(zstdConfig().WithDictionary(dict)))
+	if err != nil {
+		t.Fatalf("Failed to reopen Bolt cache: %v", err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	// Entries compressed before the dictionary was configured remain readable
+	value, found := reader.Get("user:7")
+	if !found {
+		t.Fatal("Expected an entry compressed without a dictionary to be readable")
+	}
+	if name := value.(map[string]any)["name"]; name != "user-7" {
+		t.Errorf("Expected user-7, got %v", name)
+	}
+
+	for i := 300; i < 600; i++ {
+		_ = reader.Set(fmt.Sprintf("user:%d", i), profile(i), time.Hour)
+	}
+	// Values this small barely shrink, if at all, without a dictionary
+	if compressed := reader.Stats().CompressedEntries(); compressed <= plainCompressed {
+		t.Errorf("Expected the dictionary to compress more values: %d <= %d", compressed, plainCompressed)
+	}
+	value, found = reader.Get("user:400")
+	if !found || value.(map[string]any)["name"] != "user-400" {
+		t.Errorf("Expected user-400, got %v (found=%v)", value, found)
+	}
+}
+
+func TestCacheWithBoltStoreEncryptionKeyRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+	oldKey := encryption.Key{ID: 1, Secret: []byte(strings.Repeat("o", 32))}
+	newKey := encryption.Key{ID: 2, Secret: []byte(strings.Repeat("n", 32))}
+	open := func(keys ...encryption.Key) *Cache {
+		encryptor, err := encryption.NewAESGCM(keys...)
+		if err != nil {
+			t.Fatalf("Failed to create encryptor: %v", err)
+		}
+		cache, err := New(NewBoltConfig(path).WithEncryption(encryptor))
+		if err != nil {
+			t.Fatalf("Failed to open Bolt cache: %v", err)
+		}
+		return cache
+	}
+
+	writer := open(oldKey)
+	_ = writer.Set("before", "sealed with the old key", time.Hour)
+	_ = writer.Close()
+
+	rotated := open(newKey, oldKey)
+	if value, found := rotated.Get("before"); !found || value != "sealed with the old key" {
+		t.Errorf("Expected entries sealed with the previous key to stay readable, got %v (found=%v)", value, found)
+	}
+	_ = rotated.Set("after", "sealed with the new key", time.Hour)
+	_ = rotated.Close()
+
+	retired := open(newKey)
+	defer func() { _ = retired.Close() }()
+	if value, found := retired.Get("after"); !found || value != "sealed with the new key" {
+		t.Errorf("Expected the new key to read its entries, got %v (found=%v)", value, found)
+	}
+	if _, _, err := retired.Swap("before", "replaced", time.Hour); !errors.Is(err, encryption.ErrUnknownKey) {
+		t.Errorf("Expected entries sealed with a retired key to report it, got %v", err)
+	}
+}
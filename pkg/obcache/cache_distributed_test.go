@@ -0,0 +1,49 @@
+package obcache
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+// freeAddr returns a local address that was free a moment ago
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find a free port: %v", err)
+	}
+	defer func() { _ = listener.Close() }()
+	return listener.Addr().String()
+}
+
+func TestCacheWithDistributedStore(t *testing.T) {
+	peers := []string{"http://" + freeAddr(t), "http://" + freeAddr(t)}
+
+	caches := make([]*Cache, len(peers))
+	for i, self := range peers {
+		config := NewDistributedConfig(self, peers...).WithMaxEntries(100)
+		config.Distributed.HotCacheEntries = 10
+		cache, err := New(config)
+		if err != nil {
+			t.Fatalf("Failed to create peer %s: %v", self, err)
+		}
+		defer func() { _ = cache.Close() }()
+		caches[i] = cache
+	}
+
+	for i := range 20 {
+		_ = caches[i%2].Set(fmt.Sprintf("key-%d", i), i, time.Hour)
+	}
+	for i := range 20 {
+		for _, cache := range caches {
+			if value, found := cache.Get(fmt.Sprintf("key-%d", i)); !found || value != float64(i) && value != i {
+				t.Errorf("Expected key-%d on every peer, got %v (found=%v)", i, value, found)
+			}
+		}
+	}
+	if owned := caches[0].Len() + caches[1].Len(); owned != 20 {
+		t.Errorf("Expected the peers to own 20 keys between them, got %d", owned)
+	}
+}
@@ -0,0 +1,47 @@
+//go:build integration
+
+package obcache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCacheWithEtcdL1Invalidation(t *testing.T) {
+	newEtcdCache := func() *Cache {
+		config := NewEtcdConfig("localhost:2379")
+		config.Etcd.KeyPrefix = "obcache-test-l1/"
+		config.Etcd.L1MaxEntries = 10
+		config.Etcd.L1TTL = time.Hour
+		config.Etcd.DialTimeout = 2 * time.Second
+		cache, err := New(config)
+		if err != nil {
+			t.Skipf("etcd not available, skipping etcd integration test: %v", err)
+		}
+		t.Cleanup(func() { _ = cache.Close() })
+		return cache
+	}
+
+	cache := newEtcdCache()
+	other := newEtcdCache()
+	t.Cleanup(func() { _ = cache.Clear() })
+
+	_ = cache.Set("config", "v1", time.Hour)
+	if value, found := other.Get("config"); !found || value != "v1" {
+		t.Fatalf("Expected v1 from etcd, got %v (found=%v)", value, found)
+	}
+
+	// The second instance now serves config from its local tier until the
+	// watch reports the first instance's write
+	_ = cache.Set("config", "v2", time.Hour)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if value, _ := other.Get("config"); value == "v2" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the watch to invalidate the stale local copy")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
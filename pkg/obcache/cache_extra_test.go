@@ -1,9 +1,23 @@
 package obcache
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/codec"
+	"github.com/1mb-dev/obcache-go/v2/pkg/compression"
+	"github.com/1mb-dev/obcache-go/v2/pkg/encryption"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+	"github.com/1mb-dev/obcache-go/v2/pkg/metrics"
 )
 
 const testValue1 = "value1"
@@ -267,3 +281,978 @@ func TestCacheClearWithHooks(t *testing.T) {
 		t.Fatalf("Expected 2 invalidate hook calls, got %d", invalidateCount)
 	}
 }
+
+func TestCacheNoTTL(t *testing.T) {
+	cache, err := New(NewDefaultConfig().WithDefaultTTL(TestShortTTL))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	_ = cache.Set("default", "value", 0)
+	_ = cache.Set("forever", "value", NoTTL)
+	_ = cache.SetForever("forever2", "value")
+
+	ttl, found := cache.TTL("forever")
+	if !found {
+		t.Fatal("Should find TTL for non-expiring key")
+	}
+	if ttl != NoTTL {
+		t.Fatalf("Expected NoTTL for non-expiring key, got %v", ttl)
+	}
+
+	ttl, found = cache.TTL("default")
+	if !found || ttl <= 0 || ttl > TestShortTTL {
+		t.Fatalf("Expected zero ttl to use DefaultTTL, got %v (found=%v)", ttl, found)
+	}
+
+	time.Sleep(2 * TestShortTTL)
+	cache.Cleanup()
+
+	if cache.Has("default") {
+		t.Fatal("Entry stored with DefaultTTL should have expired")
+	}
+	if !cache.Has("forever") || !cache.Has("forever2") {
+		t.Fatal("Entries stored with NoTTL should survive expiration and cleanup")
+	}
+}
+
+func TestCacheDo(t *testing.T) {
+	cache, err := New(NewDefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	var calls int32
+	release := make(chan struct{})
+	fn := func(ctx context.Context) (any, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "computed", nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := cache.Do("key", fn, time.Hour)
+			if err != nil || value != "computed" {
+				t.Errorf("Expected computed value, got %v (err=%v)", value, err)
+			}
+		}()
+	}
+
+	// Wait until the computation is running, then check the in-flight counter
+	deadline := time.Now().Add(time.Second)
+	for cache.Stats().InFlight() != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if inFlight := cache.Stats().InFlight(); inFlight != 1 {
+		t.Fatalf("Expected 1 in-flight computation, got %d", inFlight)
+	}
+
+	close(release)
+	wg.Wait()
+
+	if atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("Expected fn to be called once, got %d", calls)
+	}
+	if value, found := cache.Get("key"); !found || value != "computed" {
+		t.Fatalf("Expected computed value to be cached, got %v", value)
+	}
+	if inFlight := cache.Stats().InFlight(); inFlight != 0 {
+		t.Fatalf("Expected 0 in-flight computations, got %d", inFlight)
+	}
+}
+
+func TestCacheDoContextCancellation(t *testing.T) {
+	cache, err := New(NewDefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	release := make(chan struct{})
+	fn := func(ctx context.Context) (any, error) {
+		<-release
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return "computed", nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error, 1)
+	go func() {
+		_, err := cache.DoContext(ctx, "key", fn, time.Hour)
+		cancelled <- err
+	}()
+
+	result := make(chan any, 1)
+	go func() {
+		time.Sleep(TestShortTTL) // Join the in-flight computation
+		value, _ := cache.DoContext(context.Background(), "key", fn, time.Hour)
+		result <- value
+	}()
+
+	time.Sleep(2 * TestShortTTL)
+	cancel()
+	if err := <-cancelled; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected cancelled waiter to get context.Canceled, got %v", err)
+	}
+
+	close(release)
+	if value := <-result; value != "computed" {
+		t.Fatalf("Expected other waiter to receive computed value, got %v", value)
+	}
+}
+
+func TestCacheLoadMany(t *testing.T) {
+	cache, err := New(NewDefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	_ = cache.Set("a", "cached-a", time.Hour)
+
+	var requested []string
+	loader := func(ctx context.Context, missing []string) (map[string]any, error) {
+		requested = append(requested, missing...)
+		return map[string]any{"b": "loaded-b"}, nil // "c" is absent upstream
+	}
+
+	results, err := cache.LoadMany(context.Background(), []string{"a", "b", "c"}, loader, time.Hour)
+	if err != nil {
+		t.Fatalf("LoadMany failed: %v", err)
+	}
+
+	if len(requested) != 2 {
+		t.Fatalf("Expected loader to receive 2 missing keys, got %v", requested)
+	}
+	if results["a"] != "cached-a" || results["b"] != "loaded-b" {
+		t.Fatalf("Unexpected results: %v", results)
+	}
+	if _, ok := results["c"]; ok {
+		t.Fatal("Keys absent from loader response should be misses")
+	}
+	if value, found := cache.Get("b"); !found || value != "loaded-b" {
+		t.Fatal("Expected loaded value to be stored in cache")
+	}
+
+	loadErr := errors.New("backend down")
+	_, err = cache.LoadMany(context.Background(), []string{"d"}, func(context.Context, []string) (map[string]any, error) {
+		return nil, loadErr
+	}, time.Hour)
+	if !errors.Is(err, loadErr) {
+		t.Fatalf("Expected loader error, got %v", err)
+	}
+}
+
+func TestCacheLoadManyCoalescesOverlappingCalls(t *testing.T) {
+	cache, err := New(NewDefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	var mu sync.Mutex
+	loads := make(map[string]int)
+	release := make(chan struct{})
+	loader := func(ctx context.Context, missing []string) (map[string]any, error) {
+		<-release
+		mu.Lock()
+		defer mu.Unlock()
+		values := make(map[string]any, len(missing))
+		for _, key := range missing {
+			loads[key]++
+			values[key] = "value-" + key
+		}
+		return values, nil
+	}
+
+	var wg sync.WaitGroup
+	for _, keys := range [][]string{{"x", "y"}, {"y", "z"}} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results, err := cache.LoadMany(context.Background(), keys, loader, time.Hour)
+			if err != nil {
+				t.Errorf("LoadMany failed: %v", err)
+			}
+			for _, key := range keys {
+				if results[key] != "value-"+key {
+					t.Errorf("Expected value-%s, got %v", key, results[key])
+				}
+			}
+		}()
+		time.Sleep(TestShortTTL) // Let the first call claim its keys
+	}
+	close(release)
+	wg.Wait()
+
+	for key, count := range loads {
+		if count != 1 {
+			t.Fatalf("Expected key %s to be loaded once, got %d", key, count)
+		}
+	}
+}
+
+func TestCacheLoadManyOwnerCancelled(t *testing.T) {
+	cache, err := New(NewDefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	loaderErr := make(chan error, 1)
+	loader := func(ctx context.Context, missing []string) (map[string]any, error) {
+		close(started)
+		<-release
+		loaderErr <- ctx.Err()
+		return map[string]any{"a": 1}, nil
+	}
+
+	// The first caller owns the load and gives up waiting for it
+	ctx, cancel := context.WithCancel(context.Background())
+	ownerDone := make(chan error, 1)
+	go func() {
+		_, err := cache.LoadMany(ctx, []string{"a"}, loader, time.Hour)
+		ownerDone <- err
+	}()
+	<-started
+
+	joined := make(chan map[string]any, 1)
+	go func() {
+		results, err := cache.LoadMany(context.Background(), []string{"a"}, loader, time.Hour)
+		if err != nil {
+			t.Errorf("LoadMany failed: %v", err)
+		}
+		joined <- results
+	}()
+	time.Sleep(TestShortTTL) // Let the second call join the load
+
+	cancel()
+	select {
+	case err := <-ownerDone:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected the owner to stop with its context error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the owner to stop waiting once its context was cancelled")
+	}
+
+	close(release)
+	if err := <-loaderErr; err != nil {
+		t.Errorf("Expected the loader context not to be cancelled, got %v", err)
+	}
+	if results := <-joined; results["a"] != 1 {
+		t.Errorf("Expected the joined call to receive a=1, got %v", results)
+	}
+}
+
+func TestCacheSetManyDeleteMany(t *testing.T) {
+	hooks := NewHooks()
+	var invalidated []string
+	hooks.AddOnInvalidate(func(ctx context.Context, key string) {
+		invalidated = append(invalidated, key)
+	})
+
+	cache, err := New(NewDefaultConfig().WithHooks(hooks))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	err = cache.SetMany(map[string]any{"a": 1, "b": 2, "c": 3}, time.Hour)
+	if err != nil {
+		t.Fatalf("SetMany failed: %v", err)
+	}
+	for key, want := range map[string]int{"a": 1, "b": 2, "c": 3} {
+		if value, found := cache.Get(key); !found || value != want {
+			t.Fatalf("Expected %s=%d, got %v (found=%v)", key, want, value, found)
+		}
+	}
+	if ttl, ok := cache.TTL("a"); !ok || ttl <= 0 || ttl > time.Hour {
+		t.Fatalf("Expected a TTL of up to an hour, got %v", ttl)
+	}
+
+	if err := cache.DeleteMany([]string{"a", "b"}); err != nil {
+		t.Fatalf("DeleteMany failed: %v", err)
+	}
+	if cache.Has("a") || cache.Has("b") || !cache.Has("c") {
+		t.Fatal("Expected only a and b to be deleted")
+	}
+	if len(invalidated) != 2 {
+		t.Fatalf("Expected 2 OnInvalidate calls, got %v", invalidated)
+	}
+	if stats := cache.Stats(); stats.Invalidations() != 2 || stats.KeyCount() != 1 {
+		t.Fatalf("Expected 2 invalidations and 1 key, got %d and %d", stats.Invalidations(), stats.KeyCount())
+	}
+	if deletes := cache.Stats().Deletes(); deletes != 2 {
+		t.Fatalf("Expected 2 deletes, got %d", deletes)
+	}
+}
+
+func TestCacheGetMany(t *testing.T) {
+	cache, err := New(NewDefaultConfig().WithShardCount(4))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	_ = cache.SetMany(map[string]any{"a": 1, "b": 2, "c": 3}, time.Hour)
+	_ = cache.Set("expired", "gone", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	results := cache.GetMany([]string{"a", "c", "missing", "expired", "a"})
+	if len(results) != 2 || results["a"] != 1 || results["c"] != 3 {
+		t.Fatalf("Unexpected results: %v", results)
+	}
+
+	stats := cache.Stats()
+	if stats.Hits() != 2 || stats.Misses() != 2 {
+		t.Errorf("Expected 2 hits and 2 misses for distinct keys, got %d and %d", stats.Hits(), stats.Misses())
+	}
+}
+
+func TestCacheSetTTL(t *testing.T) {
+	cache, err := New(NewDefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	if cache.SetTTL("missing", time.Hour) {
+		t.Fatal("SetTTL should return false for a missing key")
+	}
+
+	_ = cache.Set("key", testValue1, TestShortTTL)
+	if !cache.SetTTL("key", time.Hour) {
+		t.Fatal("SetTTL should return true for an existing key")
+	}
+
+	time.Sleep(2 * TestShortTTL)
+	value, found := cache.Get("key")
+	if !found || value != testValue1 {
+		t.Fatal("Entry should outlive its original TTL after SetTTL")
+	}
+
+	if !cache.SetTTL("key", NoTTL) {
+		t.Fatal("SetTTL should accept NoTTL")
+	}
+	if ttl, _ := cache.TTL("key"); ttl != NoTTL {
+		t.Fatalf("Expected NoTTL after removing expiration, got %v", ttl)
+	}
+
+	_ = cache.Set("short", testValue1, TestShortTTL)
+	time.Sleep(2 * TestShortTTL)
+	if cache.SetTTL("short", time.Hour) {
+		t.Fatal("SetTTL should return false for an expired key")
+	}
+
+	stats := cache.Stats()
+	if stats.Hits() != 1 || stats.Misses() != 0 {
+		t.Fatalf("SetTTL should not affect stats, got %d hits and %d misses", stats.Hits(), stats.Misses())
+	}
+}
+
+func TestCacheSetTTLConcurrentReads(t *testing.T) {
+	cache, err := New(NewDefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	_ = cache.Set("key", testValue1, time.Hour)
+
+	// Run with -race: SetTTL must not write the expiry that readers see unguarded
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			ttl := time.Hour
+			if i%2 == 0 {
+				ttl = NoTTL
+			}
+			cache.SetTTL("key", ttl)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			_, _ = cache.TTL("key")
+			_ = cache.Has("key")
+			if _, found := cache.Get("key"); !found {
+				t.Error("Entry should stay cached while its TTL changes")
+				return
+			}
+		}
+	}()
+	wg.Wait()
+}
+
+func TestCacheKeysWithPrefix(t *testing.T) {
+	cache, err := New(NewDefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	for i := 0; i < 25; i++ {
+		_ = cache.Set(fmt.Sprintf("user:%02d", i), i, time.Hour)
+		_ = cache.Set(fmt.Sprintf("order:%02d", i), i, time.Hour)
+	}
+
+	var all []string
+	cursor := ""
+	pages := 0
+	for {
+		keys, next, err := cache.KeysWithPrefix("user:", cursor, 10)
+		if err != nil {
+			t.Fatalf("KeysWithPrefix failed: %v", err)
+		}
+		if len(keys) > 10 {
+			t.Fatalf("Expected at most 10 keys per page, got %d", len(keys))
+		}
+		all = append(all, keys...)
+		pages++
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	if pages != 3 {
+		t.Fatalf("Expected 3 pages, got %d", pages)
+	}
+	if len(all) != 25 {
+		t.Fatalf("Expected 25 user keys, got %d", len(all))
+	}
+	for i, key := range all {
+		if key != fmt.Sprintf("user:%02d", i) {
+			t.Fatalf("Expected keys in lexical order, got %s at %d", key, i)
+		}
+	}
+}
+
+func TestCacheKeysWithPrefixTracksWrites(t *testing.T) {
+	cache, err := New(NewDefaultConfig().WithMaxEntries(20))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	allPages := func(prefix string) []string {
+		var all []string
+		cursor := ""
+		for {
+			keys, next, err := cache.KeysWithPrefix(prefix, cursor, 3)
+			if err != nil {
+				t.Fatalf("KeysWithPrefix failed: %v", err)
+			}
+			all = append(all, keys...)
+			if next == "" {
+				return all
+			}
+			cursor = next
+		}
+	}
+	expected := func(prefix string) []string {
+		keys := slices.DeleteFunc(cache.Keys(), func(key string) bool {
+			return !strings.HasPrefix(key, prefix)
+		})
+		slices.Sort(keys)
+		return keys
+	}
+
+	for i := 0; i < 10; i++ {
+		_ = cache.Set(fmt.Sprintf("user:%02d", i), i, time.Hour)
+	}
+	_ = allPages("user:") // The first scan indexes the keys
+
+	// Later writes, deletes, evictions and expirations are reflected in the next scans
+	for i := 10; i < 30; i++ {
+		_ = cache.Set(fmt.Sprintf("user:%02d", i), i, time.Hour)
+	}
+	_ = cache.Delete("user:25")
+	_ = cache.Set("user:99", 99, TestShortTTL)
+	time.Sleep(2 * TestShortTTL)
+	if got, want := allPages("user:"), expected("user:"); !slices.Equal(got, want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+
+	_ = cache.Clear()
+	_ = cache.Set("user:a", 1, time.Hour)
+	if got := allPages("user:"); !slices.Equal(got, []string{"user:a"}) {
+		t.Fatalf("Expected only user:a after Clear, got %v", got)
+	}
+}
+
+func TestCacheCountByPrefix(t *testing.T) {
+	cache, err := New(NewDefaultConfig().WithMaxEntries(5000))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	for i := 0; i < 3000; i++ {
+		_ = cache.Set(fmt.Sprintf("tenant:%d:item:%d", i%3, i), "abcd", time.Hour)
+	}
+	_ = cache.Set("tenant:0:expired", "abcd", TestShortTTL)
+	time.Sleep(2 * TestShortTTL)
+
+	if count := cache.CountByPrefix("tenant:0:"); count != 1000 {
+		t.Fatalf("Expected 1000 entries for tenant 0, got %d", count)
+	}
+	if count := cache.CountByPrefix("missing:"); count != 0 {
+		t.Fatalf("Expected 0 entries for unknown prefix, got %d", count)
+	}
+
+	count, size := cache.CountByPrefixWithSize("tenant:1:")
+	if count != 1000 || size != 4000 {
+		t.Fatalf("Expected 1000 entries totalling 4000 bytes, got %d entries and %d bytes", count, size)
+	}
+}
+
+func TestCacheClearWhere(t *testing.T) {
+	hooks := NewHooks()
+	var invalidated []string
+	var mu sync.Mutex
+	hooks.AddOnInvalidate(func(ctx context.Context, key string) {
+		mu.Lock()
+		invalidated = append(invalidated, key)
+		mu.Unlock()
+	})
+
+	cache, err := New(NewDefaultConfig().WithHooks(hooks))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	_ = cache.Set("old1", "value", time.Hour)
+	_ = cache.Set("old2", "value", time.Hour)
+	time.Sleep(time.Millisecond)
+	cutoff := time.Now()
+	time.Sleep(time.Millisecond)
+	_ = cache.Set("new1", "value", time.Hour)
+
+	removed := cache.ClearWhere(func(key string, info EntryInfo) bool {
+		return info.CreatedAt.Before(cutoff)
+	})
+	if removed != 2 {
+		t.Fatalf("Expected 2 entries removed, got %d", removed)
+	}
+	if cache.Has("old1") || cache.Has("old2") || !cache.Has("new1") {
+		t.Fatal("Expected only entries created before the cutoff to be removed")
+	}
+	if len(invalidated) != 2 {
+		t.Fatalf("Expected 2 OnInvalidate calls, got %d", len(invalidated))
+	}
+	if stats := cache.Stats(); stats.Invalidations() != 2 || stats.KeyCount() != 1 {
+		t.Fatalf("Expected 2 invalidations and 1 key, got %d and %d", stats.Invalidations(), stats.KeyCount())
+	}
+	if deletes := cache.Stats().Deletes(); deletes != 2 {
+		t.Fatalf("Expected 2 deletes, got %d", deletes)
+	}
+}
+
+func TestCacheClearExpired(t *testing.T) {
+	hooks := NewHooks()
+	var expired int32
+	hooks.AddOnEvict(func(ctx context.Context, key string, value any, reason EvictReason) {
+		if reason == EvictReasonTTL {
+			atomic.AddInt32(&expired, 1)
+		}
+	})
+
+	// No background cleanup so only ClearExpired can remove the entries
+	cache, err := New(NewDefaultConfig().WithCleanupInterval(0).WithHooks(hooks))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	_ = cache.Set("short1", "value", TestShortTTL)
+	_ = cache.Set("short2", "value", TestShortTTL)
+	_ = cache.Set("long", "value", time.Hour)
+	time.Sleep(2 * TestShortTTL)
+
+	if removed := cache.ClearExpired(); removed != 2 {
+		t.Fatalf("Expected 2 expired entries removed, got %d", removed)
+	}
+	if atomic.LoadInt32(&expired) != 2 {
+		t.Fatalf("Expected 2 TTL evictions reported, got %d", expired)
+	}
+	if cache.Stats().Evictions() != 2 {
+		t.Fatalf("Expected 2 evictions, got %d", cache.Stats().Evictions())
+	}
+}
+
+func TestCacheClearExpiredReentrantHook(t *testing.T) {
+	hooks := NewHooks()
+	cache, err := New(NewDefaultConfig().WithCleanupInterval(0).WithHooks(hooks))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	// The hook calls back into the cache, which deadlocks if hooks run while
+	// the cleanup holds the cache lock
+	var lengths []int
+	hooks.AddOnEvict(func(_ context.Context, key string, _ any, _ EvictReason) {
+		_, _ = cache.Get(key)
+		lengths = append(lengths, cache.Len())
+	})
+
+	_ = cache.Set("short1", "value", TestShortTTL)
+	_ = cache.Set("short2", "value", TestShortTTL)
+	_ = cache.Set("long", "value", time.Hour)
+	time.Sleep(2 * TestShortTTL)
+
+	done := make(chan int)
+	go func() { done <- cache.ClearExpired() }()
+
+	select {
+	case removed := <-done:
+		if removed != 2 {
+			t.Fatalf("Expected 2 expired entries removed, got %d", removed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ClearExpired deadlocked with a hook calling back into the cache")
+	}
+
+	if !slices.Equal(lengths, []int{1, 1}) {
+		t.Errorf("Expected 2 hook calls each seeing 1 entry, got %v", lengths)
+	}
+}
+
+func TestCacheSwap(t *testing.T) {
+	cache, err := New(NewDefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	old, existed, err := cache.Swap("key", "v1", time.Hour)
+	if err != nil || existed || old != nil {
+		t.Fatalf("Expected no previous value, got %v (existed=%v, err=%v)", old, existed, err)
+	}
+
+	old, existed, err = cache.Swap("key", "v2", time.Hour)
+	if err != nil || !existed || old != "v1" {
+		t.Fatalf("Expected previous value v1, got %v (existed=%v, err=%v)", old, existed, err)
+	}
+
+	if value, _ := cache.Get("key"); value != "v2" {
+		t.Fatalf("Expected v2 after swap, got %v", value)
+	}
+	if cache.Stats().Invalidations() != 0 {
+		t.Fatal("Swap should not count as an invalidation")
+	}
+
+	_ = cache.Set("expiring", "stale", TestShortTTL)
+	time.Sleep(2 * TestShortTTL)
+	if _, existed, _ := cache.Swap("expiring", "fresh", time.Hour); existed {
+		t.Fatal("Expired entries should not be reported as existing")
+	}
+}
+
+func TestCacheSwapWithCompression(t *testing.T) {
+	config := NewDefaultConfig().WithCompression(compression.NewDefaultConfig().WithEnabled(true).WithMinSize(0))
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	_ = cache.Set("key", strings.Repeat("a", 100), time.Hour)
+	old, existed, err := cache.Swap("key", "short", time.Hour)
+	if err != nil || !existed || old != strings.Repeat("a", 100) {
+		t.Fatalf("Expected decompressed previous value, got %v (existed=%v, err=%v)", old, existed, err)
+	}
+}
+
+func TestCacheSetVersioned(t *testing.T) {
+	config := NewDefaultConfig().WithCompression(compression.NewDefaultConfig().WithEnabled(true).WithMinSize(0))
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	written, err := cache.SetVersioned("key", strings.Repeat("v2", 50), 2, time.Hour)
+	if err != nil || !written {
+		t.Fatalf("Expected write to absent key to succeed, got %v (err=%v)", written, err)
+	}
+
+	written, _ = cache.SetVersioned("key", "stale", 1, time.Hour)
+	if written {
+		t.Fatal("Expected write with lower version to be rejected")
+	}
+	written, _ = cache.SetVersioned("key", "same", 2, time.Hour)
+	if written {
+		t.Fatal("Expected write with equal version to be rejected")
+	}
+
+	value, info, found := cache.GetEntry("key")
+	if !found || value != strings.Repeat("v2", 50) {
+		t.Fatalf("Expected stored value to be unchanged, got %v", value)
+	}
+	if info.Version != 2 || !info.Compressed {
+		t.Fatalf("Expected version 2 on a compressed entry, got %+v", info)
+	}
+
+	written, _ = cache.SetVersioned("key", "v3", 3, time.Hour)
+	if !written {
+		t.Fatal("Expected write with higher version to succeed")
+	}
+	if _, info, _ := cache.GetEntry("key"); info.Version != 3 {
+		t.Fatalf("Expected version 3, got %d", info.Version)
+	}
+}
+
+func TestCacheAdaptiveCompression(t *testing.T) {
+	config := NewDefaultConfig().WithCompression(compression.NewDefaultConfig().WithEnabled(true).WithMinSize(100).WithAdaptive(true))
+	config.Compression.AdaptiveResampleEvery = 10
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	// Random bytes do not compress
+	blob := make([]byte, 512)
+	_, _ = rand.Read(blob)
+
+	for i := range 10 {
+		_ = cache.Set(fmt.Sprintf("blob:%d", i), blob, time.Hour)
+		_ = cache.Set(fmt.Sprintf("text:%d", i), strings.Repeat("compressible ", 50), time.Hour)
+	}
+
+	stats := cache.Stats()
+	if stats.CompressionSkips() != 9 {
+		t.Errorf("Expected the blob writes after the first to be skipped, got %d", stats.CompressionSkips())
+	}
+	if stats.CompressedEntries() != 10 {
+		t.Errorf("Expected every text write to be compressed, got %d", stats.CompressedEntries())
+	}
+
+	// Skipped entries are stored serialized but uncompressed and read back as usual
+	value, info, found := cache.GetEntry("blob:5")
+	if !found || info.Compressed {
+		t.Fatalf("Expected an uncompressed entry, got %+v (found=%v)", info, found)
+	}
+	if decoded, ok := value.([]byte); !ok || !bytes.Equal(decoded, blob) {
+		t.Errorf("Expected the blob to read back, got %T", value)
+	}
+
+	// The tenth write since the prefix was marked is compressed to re-sample it
+	_ = cache.Set("blob:10", blob, time.Hour)
+	if stats.CompressionSkips() != 9 {
+		t.Errorf("Expected the re-sample not to be skipped, got %d skips", stats.CompressionSkips())
+	}
+}
+
+// countingCodec is a JSON codec counting how often values are encoded
+type countingCodec struct {
+	codec.JSON
+	marshals *atomic.Int32
+}
+
+func (c countingCodec) Marshal(v any) ([]byte, error) {
+	c.marshals.Add(1)
+	return c.JSON.Marshal(v)
+}
+
+type preEncoded []byte
+
+func (p preEncoded) RawBytes() []byte { return p }
+
+func TestCacheRawBytesSkipSerialization(t *testing.T) {
+	var marshals atomic.Int32
+	config := NewDefaultConfig().WithCompression(compression.NewDefaultConfig().
+		WithEnabled(true).WithMinSize(100).WithCodec(countingCodec{marshals: &marshals}))
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	small := []byte{0x00, 0xff, '"', '{'}
+	large := []byte(strings.Repeat(`{"id":1,"name":"widget"}`, 50))
+	_ = cache.Set("small", small, time.Hour)
+	_ = cache.Set("large", large, time.Hour)
+	_ = cache.Set("message", preEncoded("pre-encoded"), time.Hour)
+
+	if n := marshals.Load(); n != 0 {
+		t.Errorf("Expected byte values to skip the codec, got %d encodings", n)
+	}
+
+	for key, want := range map[string][]byte{"small": small, "large": large, "message": []byte("pre-encoded")} {
+		value, info, found := cache.GetEntry(key)
+		if !found {
+			t.Fatalf("Expected %s to be cached", key)
+		}
+		if b, ok := value.([]byte); !ok || !bytes.Equal(b, want) {
+			t.Errorf("Expected %s to read back as identical bytes, got %T", key, value)
+		}
+		if info.Codec != "raw" {
+			t.Errorf("Expected %s to record the raw codec, got %q", key, info.Codec)
+		}
+	}
+	if e, _ := cache.store.Peek("small"); !bytes.Equal(e.Value.([]byte), small) {
+		t.Errorf("Expected small bytes stored as they are, got %v", e.Value)
+	}
+	if _, info, _ := cache.GetEntry("large"); !info.Compressed {
+		t.Error("Expected large bytes to still be compressed")
+	}
+	if value, found := NewTyped[[]byte](cache).Get("large"); !found || !bytes.Equal(value, large) {
+		t.Errorf("Expected a typed read to return the bytes, got %d bytes (found=%v)", len(value), found)
+	}
+
+	// Other values still go through the codec
+	_ = cache.Set("text", "value", time.Hour)
+	if n := marshals.Load(); n == 0 {
+		t.Error("Expected a string to be encoded by the configured codec")
+	}
+}
+
+func TestCacheCompressionRecordsSerializedSize(t *testing.T) {
+	config := NewDefaultConfig().WithCompression(compression.NewDefaultConfig().WithEnabled(true).WithMinSize(100))
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	value := map[string]any{"items": strings.Repeat("entry ", 200)}
+	serialized, _ := codec.JSON{}.Marshal(value)
+	_ = cache.Set("key", value, time.Hour)
+
+	e, _ := cache.store.Peek("key")
+	if !e.IsCompressed || e.OriginalSize != len(serialized) || e.CompressedSize != len(e.Value.([]byte)) {
+		t.Errorf("Expected sizes %d -> %d, got %d -> %d", len(serialized), len(e.Value.([]byte)), e.OriginalSize, e.CompressedSize)
+	}
+}
+
+func TestCacheCorruptEntryIsReportedAndDeleted(t *testing.T) {
+	mockExporter := NewMockExporter()
+	var hookKeys []string
+	var hookErr error
+	hooks := NewHooks()
+	hooks.AddOnError(func(_ context.Context, _ string, key string, err error) {
+		hookKeys = append(hookKeys, key)
+		hookErr = err
+	})
+	misses := 0
+	hooks.AddOnMiss(func(context.Context, string) { misses++ })
+
+	cache, err := New(NewDefaultConfig().
+		WithCompression(compression.NewDefaultConfig().WithEnabled(true)).
+		WithHooks(hooks).
+		WithMetrics(&MetricsConfig{Exporter: mockExporter, Enabled: true, CacheName: "corrupt"}))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	// Plant a payload that claims to be gzip but is not
+	poisoned := entry.NewWithoutTTL([]byte("not gzip at all"))
+	poisoned.CodecName = codec.JSON{}.Name()
+	poisoned.SetCompressionInfo("gzip", 100, len("not gzip at all"))
+	if err := cache.store.Set("poisoned", poisoned); err != nil {
+		t.Fatalf("Failed to plant entry: %v", err)
+	}
+	_ = cache.Set("healthy", "fine", time.Hour)
+
+	if _, found := cache.Get("poisoned"); found {
+		t.Fatal("Expected the corrupt entry to be a miss")
+	}
+	if got := cache.Stats().DecodeErrors(); got != 1 {
+		t.Errorf("Expected 1 decode error, got %d", got)
+	}
+	if misses != 1 {
+		t.Errorf("Expected the read to still count as a miss, got %d", misses)
+	}
+	if len(hookKeys) != 1 || hookKeys[0] != "poisoned" || hookErr == nil {
+		t.Errorf("Expected OnError for the corrupt key with its error, got %v (err=%v)", hookKeys, hookErr)
+	}
+	if cache.Has("poisoned") {
+		t.Error("Expected the corrupt entry to be deleted")
+	}
+	if value, found := cache.Get("healthy"); !found || value != "fine" {
+		t.Errorf("Expected other entries to be unaffected, got %v (found=%v)", value, found)
+	}
+
+	// A second read is an ordinary miss, not another error
+	cache.Get("poisoned")
+	if got := cache.Stats().DecodeErrors(); got != 1 {
+		t.Errorf("Expected the deleted entry not to fail again, got %d decode errors", got)
+	}
+
+	names := metrics.DefaultMetricNames()
+	labels := mockExporter.labelsKey(metrics.Labels{"cache_name": "corrupt"})
+	mockExporter.mu.RLock()
+	defer mockExporter.mu.RUnlock()
+	if n := mockExporter.counters[names.DecodeErrorsTotal+labels]; n != 1 {
+		t.Errorf("Expected 1 decode error exported, got %d", n)
+	}
+}
+
+func TestCacheEncryption(t *testing.T) {
+	encryptor, err := encryption.NewAESGCM(encryption.Key{ID: 1, Secret: bytes.Repeat([]byte{'k'}, 32)})
+	if err != nil {
+		t.Fatalf("Failed to create encryptor: %v", err)
+	}
+	profile := map[string]any{"email": "ada@example.com", "bio": strings.Repeat("mathematician ", 200)}
+
+	for _, compressed := range []bool{false, true} {
+		t.Run(fmt.Sprintf("compressed=%v", compressed), func(t *testing.T) {
+			var hookErr error
+			hooks := NewHooks()
+			hooks.AddOnError(func(_ context.Context, _, _ string, err error) { hookErr = err })
+
+			cache, err := New(NewDefaultConfig().
+				WithCompression(compression.NewDefaultConfig().WithEnabled(compressed)).
+				WithEncryption(encryptor).
+				WithHooks(hooks))
+			if err != nil {
+				t.Fatalf("Failed to create cache: %v", err)
+			}
+			defer func() { _ = cache.Close() }()
+
+			_ = cache.Set("user:1", profile, time.Hour)
+			stored, _ := cache.store.Peek("user:1")
+			sealed, ok := stored.Value.([]byte)
+			if !ok || bytes.Contains(sealed, []byte("ada@example.com")) {
+				t.Fatalf("Expected the stored value to be ciphertext, got %T", stored.Value)
+			}
+			if stored.IsCompressed != compressed {
+				t.Errorf("Expected IsCompressed=%v, got %v", compressed, stored.IsCompressed)
+			}
+			if compressed && len(sealed) >= len(profile["bio"].(string)) {
+				t.Errorf("Expected values to be compressed before encryption, got %d bytes", len(sealed))
+			}
+
+			value, found := cache.Get("user:1")
+			if !found || value.(map[string]any)["email"] != "ada@example.com" {
+				t.Fatalf("Expected the value to round trip, got %v (found=%v)", value, found)
+			}
+			if typed, found := NewTyped[map[string]string](cache).Get("user:1"); !found || typed["email"] != "ada@example.com" {
+				t.Errorf("Expected typed reads to decrypt, got %v (found=%v)", typed, found)
+			}
+
+			// A tampered value is reported, not silently missed
+			tampered := entry.NewWithoutTTL(bytes.Clone(sealed))
+			tampered.CodecName = stored.CodecName
+			tampered.SetCompressionInfo(stored.CompressorName, stored.OriginalSize, stored.CompressedSize)
+			tampered.IsCompressed = stored.IsCompressed
+			tampered.Value.([]byte)[len(sealed)-1] ^= 0x01
+			_ = cache.store.Set("user:1", tampered)
+			if _, _, err := cache.Swap("user:1", profile, time.Hour); !errors.Is(err, encryption.ErrAuthenticationFailed) {
+				t.Errorf("Expected Swap to report the authentication failure, got %v", err)
+			}
+			_ = cache.store.Set("user:1", tampered)
+			if _, found := cache.Get("user:1"); found {
+				t.Error("Expected a tampered value to be a miss")
+			}
+			if !errors.Is(hookErr, encryption.ErrAuthenticationFailed) || cache.Stats().DecodeErrors() != 1 {
+				t.Errorf("Expected the authentication failure to be reported, got %v (%d errors)", hookErr, cache.Stats().DecodeErrors())
+			}
+
+			// Values are bound to their key
+			_ = cache.store.Set("user:2", stored)
+			if _, found := cache.Get("user:2"); found {
+				t.Error("Expected a value copied to another key to fail authentication")
+			}
+		})
+	}
+}
@@ -1,11 +1,19 @@
 package obcache
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/compression"
+	"github.com/1mb-dev/obcache-go/v2/pkg/metrics"
 )
 
 const testKeyConst = "test-key"
@@ -150,6 +158,183 @@ func TestCacheWithRedisStoreAndHooks(t *testing.T) {
 	}
 }
 
+func TestCacheClearContextWithRedisStore(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+		DB:   15,
+	})
+
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Redis not available, skipping test: %v", err)
+	}
+	client.FlushDB(ctx)
+
+	var invalidated []string
+	hooks := NewHooks()
+	hooks.AddOnInvalidate(func(ctx context.Context, key string) {
+		invalidated = append(invalidated, key)
+	})
+
+	cache, err := New(NewDefaultConfig().
+		WithHooks(hooks).
+		WithRedis(&RedisConfig{Client: client, KeyPrefix: "clear-ctx:"}))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	_ = cache.SetMany(map[string]any{"a": 1, "b": 2, "c": 3}, time.Hour)
+
+	if err := cache.ClearContext(ctx); err != nil {
+		t.Fatalf("ClearContext failed: %v", err)
+	}
+	if cache.Len() != 0 {
+		t.Fatalf("Expected an empty cache, got %d keys", cache.Len())
+	}
+	if len(invalidated) != 3 || cache.Stats().Invalidations() != 3 {
+		t.Fatalf("Expected 3 invalidations, got hooks %v and stats %d", invalidated, cache.Stats().Invalidations())
+	}
+}
+
+func TestCacheRedisExpiryNotifications(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+		DB:   15,
+	})
+
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Redis not available, skipping test: %v", err)
+	}
+
+	evicted := make(chan string, 1)
+	hooks := NewHooks()
+	hooks.AddOnEvict(func(ctx context.Context, key string, value any, reason EvictReason) {
+		if reason == EvictReasonTTL {
+			evicted <- key
+		}
+	})
+
+	cache, err := New(NewDefaultConfig().
+		WithHooks(hooks).
+		WithRedis(&RedisConfig{Client: client, KeyPrefix: "expiry:", ExpiryNotifications: true}))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	// Publish the event Redis sends when expiry:session expires
+	client.Publish(ctx, "__keyevent@15__:expired", "expiry:session")
+
+	select {
+	case key := <-evicted:
+		if key != "session" {
+			t.Fatalf("Expected OnEvict for session, got %q", key)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected an OnEvict hook with EvictReasonTTL")
+	}
+	if cache.Stats().Evictions() != 1 {
+		t.Fatalf("Expected 1 eviction, got %d", cache.Stats().Evictions())
+	}
+}
+
+func TestCacheRedisWriteBehind(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "localhost:6379", DB: 15})
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Redis not available, skipping Redis integration test: %v", err)
+	}
+	client.FlushDB(ctx)
+
+	config := NewRedisConfigWithClient(client).WithWriteBehind(&WriteBehindConfig{BatchSize: 16})
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create Redis cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	keys := make([]string, 50)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("wb:%d", i)
+		if err := cache.Set(keys[i], i, time.Hour); err != nil {
+			t.Fatalf("Failed to set %s: %v", keys[i], err)
+		}
+	}
+	_ = cache.Delete(keys[0])
+	if _, found := cache.Get(keys[0]); found {
+		t.Error("Expected the queued Delete to be visible immediately")
+	}
+
+	redisKeys := make([]string, len(keys))
+	for i, key := range keys {
+		redisKeys[i] = "obcache:" + key
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for client.Exists(ctx, redisKeys[1:]...).Val() != int64(len(keys)-1) && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := client.Exists(ctx, redisKeys...).Val(); n != int64(len(keys)-1) {
+		t.Errorf("Expected %d keys flushed to Redis, got %d", len(keys)-1, n)
+	}
+}
+
+func TestCacheRedisFailover(t *testing.T) {
+	// Nothing listens on a closed listener's address, so every command fails fast
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	_ = listener.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: addr, MaxRetries: -1})
+	defer func() { _ = client.Close() }()
+
+	var transitions []bool
+	mockExporter := NewMockExporter()
+	config := NewRedisConfigWithClient(client).WithMetrics(&MetricsConfig{
+		Exporter:  mockExporter,
+		Enabled:   true,
+		CacheName: "failover",
+	})
+	config.Redis.Failover = &RedisFailoverConfig{
+		FailureThreshold: 2,
+		ProbeInterval:    time.Hour,
+		OnStateChange:    func(degraded bool) { transitions = append(transitions, degraded) },
+	}
+
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create Redis cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	if err := cache.Set("key", "value", time.Hour); err == nil {
+		t.Fatal("Expected the first Set to fail while Redis is down")
+	}
+	if err := cache.Set("key", "value", time.Hour); err != nil {
+		t.Fatalf("Expected the Set that opens the circuit to fall back, got %v", err)
+	}
+	if value, found := cache.Get("key"); !found || value != "value" {
+		t.Errorf("Expected Get to be served locally, got %v (found=%v)", value, found)
+	}
+
+	if len(transitions) != 1 || !transitions[0] {
+		t.Errorf("Expected one transition to degraded, got %v", transitions)
+	}
+	if counts := cache.Stats().ErrorsByType(); counts["set_failed"] != 1 || counts["backend_unavailable"] != 1 {
+		t.Errorf("Expected the failed Set and the failover to be counted, got %v", counts)
+	}
+	gauge := metrics.DefaultMetricNames().CacheBackendDegraded + mockExporter.labelsKey(metrics.Labels{"cache_name": "failover"})
+	mockExporter.mu.RLock()
+	defer mockExporter.mu.RUnlock()
+	if mockExporter.gauges[gauge] != 1 {
+		t.Errorf("Expected degraded gauge 1, got %v", mockExporter.gauges[gauge])
+	}
+}
+
 func TestWrappedFunctionWithRedisStore(t *testing.T) {
 	client := redis.NewClient(&redis.Options{
 		Addr: "localhost:6379",
@@ -251,3 +436,165 @@ func TestRedisConfigBuilders(t *testing.T) {
 		t.Fatalf("Expected Redis key prefix 'myapp:', got '%s'", config2.Redis.KeyPrefix)
 	}
 }
+
+func TestRedisSentinelConfig(t *testing.T) {
+	config := NewRedisSentinelConfig("mymaster", "sentinel-1:26379", "sentinel-2:26379")
+	if config.StoreType != StoreTypeRedis {
+		t.Fatal("Expected StoreType to be Redis")
+	}
+	if config.Redis.MasterName != "mymaster" || len(config.Redis.SentinelAddrs) != 2 {
+		t.Fatalf("Expected master and two sentinels, got %q and %v", config.Redis.MasterName, config.Redis.SentinelAddrs)
+	}
+
+	// A master name selects the failover client, which resolves the master through Sentinel
+	client := newRedisClient(config.Redis)
+	defer func() { _ = client.Close() }()
+	if addr := client.Options().Addr; addr != "FailoverClient" {
+		t.Errorf("Expected a failover client, got a client for %q", addr)
+	}
+
+	// Without a master name the client connects to Addr directly
+	direct := newRedisClient(&RedisConfig{Addr: "redis:6379", DB: 2})
+	defer func() { _ = direct.Close() }()
+	if direct.Options().Addr != "redis:6379" || direct.Options().DB != 2 {
+		t.Errorf("Expected a direct client for redis:6379 DB 2, got %q DB %d", direct.Options().Addr, direct.Options().DB)
+	}
+}
+
+func TestRedisClientOptions(t *testing.T) {
+	options := &redis.Options{
+		Addr:         "redis.internal:6380",
+		Username:     "cache",
+		Password:     "secret",
+		DB:           3,
+		TLSConfig:    &tls.Config{ServerName: "redis.internal", MinVersion: tls.VersionTLS12},
+		PoolSize:     42,
+		MinIdleConns: 5,
+		DialTimeout:  2 * time.Second,
+		ReadTimeout:  time.Second,
+		WriteTimeout: 1500 * time.Millisecond,
+	}
+
+	client := newRedisClient(&RedisConfig{Options: options, Addr: "ignored:6379"})
+	defer func() { _ = client.Close() }()
+
+	got := client.Options()
+	if got.Addr != "redis.internal:6380" || got.Username != "cache" || got.Password != "secret" || got.DB != 3 {
+		t.Errorf("Expected connection settings from Options, got %s %s %s %d", got.Addr, got.Username, got.Password, got.DB)
+	}
+	if got.TLSConfig == nil || got.TLSConfig.ServerName != "redis.internal" {
+		t.Errorf("Expected the TLS config to be passed through, got %+v", got.TLSConfig)
+	}
+	if got.PoolSize != 42 || got.MinIdleConns != 5 {
+		t.Errorf("Expected pool size 42 and 5 idle connections, got %d and %d", got.PoolSize, got.MinIdleConns)
+	}
+	if got.DialTimeout != 2*time.Second || got.ReadTimeout != time.Second || got.WriteTimeout != 1500*time.Millisecond {
+		t.Errorf("Expected timeouts to be passed through, got %v %v %v", got.DialTimeout, got.ReadTimeout, got.WriteTimeout)
+	}
+
+	for name, redisConfig := range map[string]*RedisConfig{
+		"client":      {Options: options, Client: redis.NewClient(&redis.Options{})},
+		"master name": {Options: options, MasterName: "mymaster"},
+	} {
+		if _, err := New(NewDefaultConfig().WithRedis(redisConfig)); err == nil {
+			t.Errorf("Expected Options combined with a %s to be rejected", name)
+		}
+	}
+}
+
+func TestCacheWithTieredStore(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+		DB:   15,
+	})
+
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Redis not available, skipping tiered store test: %v", err)
+	}
+	client.FlushDB(ctx)
+
+	config := NewDefaultConfig().WithTiered(
+		&TieredConfig{L1MaxEntries: 10, L1TTL: time.Minute},
+		&RedisConfig{Client: client, KeyPrefix: "test:tiered:"},
+	)
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create tiered cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	// A second instance shares Redis but has its own local tier
+	other, err := New(NewDefaultConfig().WithTiered(
+		&TieredConfig{L1MaxEntries: 10, L1TTL: time.Minute},
+		&RedisConfig{Client: client, KeyPrefix: "test:tiered:"},
+	))
+	if err != nil {
+		t.Fatalf("Failed to create second tiered cache: %v", err)
+	}
+	defer func() { _ = other.Close() }()
+
+	_ = cache.Set(testKeyConst, "value", time.Hour)
+	if value, found := cache.Get(testKeyConst); !found || value != "value" {
+		t.Fatalf("Expected value from local tier, got %v (found=%v)", value, found)
+	}
+	if value, found := other.Get(testKeyConst); !found || value != "value" {
+		t.Fatalf("Expected value from Redis in second instance, got %v (found=%v)", value, found)
+	}
+	if value, found := other.Get(testKeyConst); !found || value != "value" {
+		t.Fatalf("Expected value from local tier in second instance, got %v (found=%v)", value, found)
+	}
+
+	if stats := cache.Stats(); stats.L1Hits() != 1 || stats.L2Hits() != 0 {
+		t.Errorf("Expected 1 L1 hit in first instance, got L1=%d L2=%d", stats.L1Hits(), stats.L2Hits())
+	}
+	if stats := other.Stats(); stats.L1Hits() != 1 || stats.L2Hits() != 1 {
+		t.Errorf("Expected 1 L1 and 1 L2 hit in second instance, got L1=%d L2=%d", stats.L1Hits(), stats.L2Hits())
+	}
+
+	if err := cache.Delete(testKeyConst); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	if cache.Has(testKeyConst) {
+		t.Error("Expected key to be deleted from both tiers")
+	}
+	if n, _ := client.Exists(ctx, "test:tiered:"+testKeyConst).Result(); n != 0 {
+		t.Error("Expected key to be deleted from Redis")
+	}
+}
+
+func TestCacheWithRedisStoreRawBytes(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+		DB:   15, // Use DB 15 for testing to avoid conflicts
+	})
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Redis not available, skipping Redis integration test: %v", err)
+	}
+	client.FlushDB(ctx)
+
+	payload := []byte(strings.Repeat("\x00\xff{\"raw\":true}", 100))
+	for name, compressionConfig := range map[string]*compression.Config{
+		"plain":      nil,
+		"compressed": compression.NewDefaultConfig().WithEnabled(true).WithMinSize(100),
+	} {
+		t.Run(name, func(t *testing.T) {
+			config := NewDefaultConfig().WithRedis(&RedisConfig{Client: client, KeyPrefix: "test:raw:" + name + ":"})
+			if compressionConfig != nil {
+				config = config.WithCompression(compressionConfig)
+			}
+			cache, err := New(config)
+			if err != nil {
+				t.Fatalf("Failed to create Redis cache: %v", err)
+			}
+			defer func() { _ = cache.Close() }()
+
+			_ = cache.Set("payload", payload, time.Hour)
+			value, found := cache.Get("payload")
+			if b, ok := value.([]byte); !found || !ok || !bytes.Equal(b, payload) {
+				t.Errorf("Expected identical bytes back, got %T (found=%v)", value, found)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package obcache
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCacheWithRistrettoStore(t *testing.T) {
+	var evicted atomic.Int64
+	hooks := &Hooks{}
+	hooks.AddOnEvict(func(ctx context.Context, key string, value any, reason EvictReason) {
+		if reason == EvictReasonCapacity {
+			evicted.Add(1)
+		}
+	})
+
+	config := NewDefaultConfig().
+		WithMaxEntries(10).
+		WithHooks(hooks).
+		WithRistretto(&RistrettoConfig{SyncWrites: true})
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create Ristretto cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	if err := cache.Set("user:1", "alice", time.Hour); err != nil {
+		t.Fatalf("Failed to set cache entry: %v", err)
+	}
+	if value, found := cache.Get("user:1"); !found || value != "alice" {
+		t.Fatalf("Expected alice, got %v (found=%v)", value, found)
+	}
+
+	for i := range 100 {
+		_ = cache.Set(fmt.Sprintf("key-%d", i), i, time.Hour)
+	}
+	if evicted.Load() == 0 {
+		t.Error("Expected evictions to reach the OnEvict hook")
+	}
+	if cache.Stats().Evictions() != evicted.Load() {
+		t.Errorf("Expected %d evictions in stats, got %d", evicted.Load(), cache.Stats().Evictions())
+	}
+	if cache.Len() > 10 {
+		t.Errorf("Expected at most 10 entries, got %d", cache.Len())
+	}
+}
+
+func TestCacheWithRistrettoStoreDefaults(t *testing.T) {
+	config := NewDefaultConfig()
+	config.StoreType = StoreTypeRistretto
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create Ristretto cache without RistrettoConfig: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	if cache.Stats().Capacity() != int64(config.MaxEntries) {
+		t.Errorf("Expected capacity %d, got %d", config.MaxEntries, cache.Stats().Capacity())
+	}
+}
+
+func TestCacheWithRistrettoStoreRejectsWriteBehind(t *testing.T) {
+	config := NewDefaultConfig().WithRistretto(nil).WithWriteBehind(&WriteBehindConfig{})
+	if _, err := New(config); err == nil {
+		t.Error("Expected write-behind to be rejected for the Ristretto store")
+	}
+}
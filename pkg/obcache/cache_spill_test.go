@@ -0,0 +1,61 @@
+package obcache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/internal/store/spill"
+)
+
+func TestCacheWithDiskOverflow(t *testing.T) {
+	var evicted []string
+	hooks := NewHooks()
+	hooks.AddOnEvict(func(_ context.Context, key string, _ any, _ EvictReason) {
+		evicted = append(evicted, key)
+	})
+
+	config := NewDefaultConfig().WithMaxEntries(2).WithDiskOverflow(t.TempDir(), 0).WithHooks(hooks)
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	t.Cleanup(func() { _ = cache.Close() })
+
+	_ = cache.Set("a", "alpha", time.Hour)
+	_ = cache.Set("b", "beta", time.Hour)
+	_ = cache.Set("c", "gamma", time.Hour)
+	cache.store.(*spill.Store).Flush()
+
+	stats := cache.Stats()
+	if stats.Spills() != 1 || stats.Evictions() != 0 || len(evicted) != 0 {
+		t.Errorf("Expected a spill instead of an eviction, got %d spills, %d evictions", stats.Spills(), stats.Evictions())
+	}
+	if stats.KeyCount() != 3 {
+		t.Errorf("Expected spilled entries to count as keys, got %d", stats.KeyCount())
+	}
+
+	if value, found := cache.Get("a"); !found || value != "alpha" {
+		t.Errorf("Expected alpha from disk, got %v (found=%v)", value, found)
+	}
+	if stats.Restores() != 1 || stats.Hits() != 1 {
+		t.Errorf("Expected the disk read to count as a restore and a hit, got %d restores, %d hits", stats.Restores(), stats.Hits())
+	}
+
+	// b was spilled to make room for a
+	if err := cache.Delete("b"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, found := cache.Get("b"); found {
+		t.Error("Expected a deleted spilled entry to be gone")
+	}
+
+	stats.Reset()
+	if stats.Spills() != 0 || stats.Restores() != 0 {
+		t.Error("Expected Reset to clear the spill counters")
+	}
+
+	if _, err := New(NewBlobConfig(newMemoryBucket()).WithDiskOverflow("", 0)); err == nil {
+		t.Error("Expected disk overflow to be rejected for other stores")
+	}
+}
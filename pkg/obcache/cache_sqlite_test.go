@@ -0,0 +1,128 @@
+package obcache
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/compression"
+)
+
+func TestCacheWithSQLiteStore(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "cache.db")
+	config := NewSQLiteConfig(dsn).
+		WithCompression(compression.NewDefaultConfig().WithEnabled(true).WithMinSize(100))
+
+	cache, err := New(config)
+	if err != nil {
+		t.Skipf("SQLite not available, skipping SQLite integration test: %v", err)
+	}
+
+	large := strings.Repeat("compressible ", 100)
+	_ = cache.Set("user:1", "alice", time.Hour)
+	_ = cache.Set("user:2", large, time.Hour)
+	_ = cache.Set("session", "token", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	if value, found := cache.Get("user:1"); !found || value != "alice" {
+		t.Errorf("Expected alice, got %v (found=%v)", value, found)
+	}
+	if value, found := cache.Get("user:2"); !found || value != large {
+		t.Errorf("Expected compressed value to round-trip, got %v (found=%v)", value, found)
+	}
+	if _, found := cache.Get("session"); found {
+		t.Error("Expected expired session to miss before cleanup runs")
+	}
+	if keys, _, err := cache.KeysWithPrefix("user:", "", 10); err != nil || len(keys) != 2 {
+		t.Errorf("Expected 2 user keys, got %v (err=%v)", keys, err)
+	}
+	if err := cache.Close(); err != nil {
+		t.Fatalf("Failed to close cache: %v", err)
+	}
+
+	cache, err = New(NewSQLiteConfig(dsn).
+		WithCompression(compression.NewDefaultConfig().WithEnabled(true).WithMinSize(100)))
+	if err != nil {
+		t.Fatalf("Failed to reopen SQLite cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+	if value, found := cache.Get("user:1"); !found || value != "alice" {
+		t.Errorf("Expected user:1 to survive reopen, got %v (found=%v)", value, found)
+	}
+}
+
+func TestCacheWithSQLiteStoreDictionary(t *testing.T) {
+	samples := make([][]byte, 300)
+	for i := range samples {
+		samples[i] = fmt.Appendf(nil, `{"id":%d,"role":"member","team":"platform-%d","active":true}`, i, i%9)
+	}
+	dict, err := compression.TrainZstdDictionary(samples, 2048)
+	if err != nil {
+		t.Fatalf("Failed to train dictionary: %v", err)
+	}
+
+	cache, err := New(NewSQLiteConfig(filepath.Join(t.TempDir(), "cache.db")).
+		WithCompression(compression.NewDefaultConfig().WithEnabled(true).
+			WithAlgorithm(compression.CompressorZstd).WithMinSize(0).WithDictionary(dict)))
+	if err != nil {
+		t.Skipf("SQLite not available, skipping SQLite integration test: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	profile := map[string]any{"id": 7.0, "role": "member", "team": "platform-7", "active": true}
+	_ = cache.Set("user:7", profile, time.Hour)
+	value, info, found := cache.GetEntry("user:7")
+	if !found || !reflect.DeepEqual(value, profile) {
+		t.Fatalf("Expected the dictionary-compressed value to round-trip, got %v (found=%v)", value, found)
+	}
+	if info.DictionaryID == 0 {
+		t.Error("Expected the entry to record its dictionary")
+	}
+	if n := cache.Stats().DecodeErrors(); n != 0 {
+		t.Errorf("Expected no decode errors, got %d", n)
+	}
+}
+
+func TestCacheWithSQLiteStoreRawBytes(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "cache.db")
+	gzipConfig := compression.NewDefaultConfig().WithEnabled(true).WithMinSize(100)
+	cache, err := New(NewSQLiteConfig(dsn).WithCompression(gzipConfig))
+	if err != nil {
+		t.Skipf("SQLite not available, skipping SQLite integration test: %v", err)
+	}
+
+	payload := []byte(strings.Repeat("\x00\xff{\"raw\":true}", 100))
+	_ = cache.Set("payload", payload, time.Hour)
+	value, info, found := cache.GetEntry("payload")
+	if b, ok := value.([]byte); !found || !ok || !bytes.Equal(b, payload) {
+		t.Fatalf("Expected identical bytes back, got %T (found=%v)", value, found)
+	}
+	if !info.Compressed || info.Codec != "raw" {
+		t.Errorf("Expected compressed bytes recorded with the raw codec, got %+v", info)
+	}
+	_ = cache.Set("text", strings.Repeat("compressible ", 100), time.Hour)
+	_ = cache.Close()
+
+	// Entries keep the compressor that wrote them after the algorithm changes
+	cache, err = New(NewSQLiteConfig(dsn).WithCompression(compression.NewDefaultConfig().
+		WithEnabled(true).WithMinSize(100).WithAlgorithm(compression.CompressorZstd)))
+	if err != nil {
+		t.Fatalf("Failed to reopen SQLite cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+	if value, found := cache.Get("payload"); !found || !bytes.Equal(value.([]byte), payload) {
+		t.Errorf("Expected the gzip-compressed bytes to stay readable, got %T (found=%v)", value, found)
+	}
+	if value, found := cache.Get("text"); !found || value != strings.Repeat("compressible ", 100) {
+		t.Errorf("Expected the gzip-compressed string to stay readable, got %v (found=%v)", value, found)
+	}
+	if n := cache.Stats().DecodeErrors(); n != 0 {
+		t.Errorf("Expected no decode errors, got %d", n)
+	}
+}
@@ -0,0 +1,79 @@
+package obcache
+
+import (
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultCapacityAlertInterval is the minimum time between OnCapacity hook calls
+// for the same cause by default
+const DefaultCapacityAlertInterval = time.Minute
+
+// capacityAlerts decides when OnCapacity hooks fire: after a write that caused a
+// capacity eviction, and after one that took the entry count past a watermark.
+// Each cause fires at most once per interval, so a full cache does not fire on
+// every write
+type capacityAlerts struct {
+	watermarks []float64 // ascending fractions of capacity
+	interval   time.Duration
+
+	// evicted is set by capacity evictions until the next check reports them
+	evicted atomic.Bool
+
+	// level is the number of watermarks the entry count had reached at the last check
+	level atomic.Int32
+
+	// fired holds the UnixNano of the last call per watermark, then for evictions
+	fired []atomic.Int64
+}
+
+// newCapacityAlerts creates alerts for the positive watermarks
+// A non-positive interval selects DefaultCapacityAlertInterval
+func newCapacityAlerts(watermarks []float64, interval time.Duration) *capacityAlerts {
+	if interval <= 0 {
+		interval = DefaultCapacityAlertInterval
+	}
+	sorted := slices.Sorted(slices.Values(slices.DeleteFunc(slices.Clone(watermarks), func(w float64) bool {
+		return w <= 0
+	})))
+	return &capacityAlerts{
+		watermarks: sorted,
+		interval:   interval,
+		fired:      make([]atomic.Int64, len(sorted)+1),
+	}
+}
+
+// check reports whether OnCapacity hooks should fire for length entries out of
+// capacity, recording the call against every cause it reports
+func (a *capacityAlerts) check(length, capacity int) bool {
+	fire := a.evicted.Swap(false) && a.due(len(a.watermarks))
+	if capacity <= 0 || len(a.watermarks) == 0 {
+		return fire
+	}
+
+	var level int32
+	for _, watermark := range a.watermarks {
+		if float64(length) >= watermark*float64(capacity) {
+			level++
+		}
+	}
+	// Only rising past a watermark counts; falling below re-arms it
+	for i := a.level.Swap(level); i < level; i++ {
+		if a.due(int(i)) {
+			fire = true
+		}
+	}
+	return fire
+}
+
+// due reports whether the interval has passed since the last call for slot and,
+// if so, records a call now
+func (a *capacityAlerts) due(slot int) bool {
+	now := time.Now().UnixNano()
+	last := a.fired[slot].Load()
+	if last != 0 && now-last < int64(a.interval) {
+		return false
+	}
+	return a.fired[slot].CompareAndSwap(last, now)
+}
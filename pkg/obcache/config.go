@@ -4,10 +4,17 @@ import (
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	clientv3 "go.etcd.io/etcd/client/v3"
 
 	"github.com/1mb-dev/obcache-go/v2/internal/eviction"
+	"github.com/1mb-dev/obcache-go/v2/internal/store/blob"
+	"github.com/1mb-dev/obcache-go/v2/internal/store/distributed"
+	"github.com/1mb-dev/obcache-go/v2/internal/store/writebehind"
+	"github.com/1mb-dev/obcache-go/v2/pkg/codec"
 	"github.com/1mb-dev/obcache-go/v2/pkg/compression"
+	"github.com/1mb-dev/obcache-go/v2/pkg/encryption"
 	"github.com/1mb-dev/obcache-go/v2/pkg/metrics"
+	"github.com/1mb-dev/obcache-go/v2/pkg/store"
 )
 
 // StoreType defines the type of backend store to use
@@ -18,14 +25,39 @@ const (
 	StoreTypeMemory StoreType = iota
 	// StoreTypeRedis uses Redis as backend storage
 	StoreTypeRedis
+	// StoreTypeBolt uses an embedded Bolt database that persists across restarts
+	StoreTypeBolt
+	// StoreTypeSQLite uses a SQLite database through database/sql
+	StoreTypeSQLite
+	// StoreTypeTiered uses a local memory store in front of Redis
+	StoreTypeTiered
+	// StoreTypeCustom uses the user-provided Config.CustomStore
+	StoreTypeCustom
+	// StoreTypeRistretto uses an in-memory Ristretto cache with frequency-based
+	// admission. Writes are applied asynchronously and may be rejected
+	StoreTypeRistretto
+	// StoreTypeEtcd uses etcd, for small caches shared across instances with
+	// strong consistency
+	StoreTypeEtcd
+	// StoreTypeDistributed shards a memory store across peer instances with
+	// consistent hashing
+	StoreTypeDistributed
+	// StoreTypeBlob stores each entry as an object in an S3-compatible bucket,
+	// for values too large for Redis or memory
+	StoreTypeBlob
 )
 
 // RedisConfig holds Redis-specific configuration
 type RedisConfig struct {
 	// Client is a pre-configured Redis client
-	// If nil, a new client will be created using Addr, Password, DB
+	// If nil, a new client will be created from Options, or else using Addr, Password, DB
 	Client redis.Cmdable
 
+	// Options are used verbatim to create the client, for settings such as TLS,
+	// pool sizes and timeouts. Addr, Password and DB are ignored when it is set.
+	// Cannot be combined with Client or MasterName
+	Options *redis.Options
+
 	// Addr is the Redis server address (host:port)
 	// Only used if Client is nil
 	Addr string
@@ -38,9 +70,376 @@ type RedisConfig struct {
 	// Only used if Client is nil
 	DB int
 
-	// KeyPrefix is prepended to all cache keys
+	// KeyPrefix is prepended to all cache keys; Clear, Keys and Len only see keys under it
 	// Default: "obcache:"
 	KeyPrefix string
+
+	// Codec encodes values stored in Redis, and values serialized for compression
+	// unless compression.Config.Codec is set. Its name is stored with each entry,
+	// so instances using another registered codec can still read it; see package codec
+	// Default: codec.JSON
+	Codec codec.Codec
+
+	// MasterName is the Sentinel master set name. When set, a failover client
+	// is created from SentinelAddrs instead of connecting to Addr
+	// Only used if Client is nil
+	MasterName string
+
+	// SentinelAddrs are the Sentinel addresses (host:port)
+	// Only used if Client is nil and MasterName is set
+	SentinelAddrs []string
+
+	// SentinelPassword authenticates to the Sentinels, which may differ from Password
+	// Only used if Client is nil and MasterName is set
+	SentinelPassword string
+
+	// MaxRetries is the number of times Get and Set are retried after a transient
+	// failover error (READONLY, LOADING, a connection reset during promotion)
+	// Default: 0, or DefaultSentinelRetries when MasterName is set. Use -1 to disable
+	MaxRetries int
+
+	// MaxBatchSize bounds the number of commands SetMany and DeleteMany send to
+	// Redis in one pipelined round trip
+	// Default: 1000
+	MaxBatchSize int
+
+	// ExpiryNotifications makes server-side expirations fire OnEvict hooks with
+	// EvictReasonTTL, using Redis keyspace notifications. The value passed to the
+	// hooks is nil because Redis has already dropped it. The server must have
+	// notify-keyspace-events set to include "Ex" (e.g. CONFIG SET notify-keyspace-events Ex).
+	// Delivery is best-effort: expirations that happen while the subscription is
+	// reconnecting are not reported, and every cache sharing the KeyPrefix is
+	// notified of every expiration
+	ExpiryNotifications bool
+
+	// Failover serves Get, Set and Delete from a bounded local memory store while
+	// Redis is unreachable, instead of returning errors and waiting on timeouts
+	// If nil, Redis errors reach callers
+	Failover *RedisFailoverConfig
+}
+
+// RedisFailoverConfig configures the circuit breaker that switches a Redis cache
+// to local memory during an outage. After FailureThreshold consecutive connection
+// errors or timeouts the circuit opens; Redis is then pinged every ProbeInterval
+// and the first successful ping closes it again. The initial connection made by
+// New must still succeed
+type RedisFailoverConfig struct {
+	// FailureThreshold is the number of consecutive backend errors that open the circuit
+	// Default: 5
+	FailureThreshold int
+
+	// ProbeInterval is how often Redis is pinged while the circuit is open
+	// Default: 1 second
+	ProbeInterval time.Duration
+
+	// LocalMaxEntries bounds the local store used while the circuit is open
+	// Default: 1000
+	LocalMaxEntries int
+
+	// Replay writes entries stored locally during the outage back to Redis when
+	// it recovers, overwriting those keys, and replays deletes. By default local
+	// entries are dropped and the keys written or deleted during the outage are
+	// deleted from Redis, so they miss rather than return pre-outage values
+	Replay bool
+
+	// OnStateChange is called with true when the cache switches to local memory
+	// and false when it switches back to Redis
+	OnStateChange func(degraded bool)
+}
+
+// DefaultSentinelRetries is the number of failover retries used with Sentinel when
+// RedisConfig.MaxRetries is 0
+const DefaultSentinelRetries = 3
+
+// BoltConfig holds configuration for the embedded Bolt store
+// Values are stored as JSON, so like with Redis they come back as JSON types
+// (e.g. numbers as float64 and structs as map[string]any)
+type BoltConfig struct {
+	// Path is the database file, created if it does not exist
+	Path string
+
+	// NoSync skips fsync after each write for faster writes, at the risk of
+	// losing the most recent ones on a crash. Close still flushes to disk
+	NoSync bool
+
+	// Timeout bounds how long New waits for another process to release the database
+	// Default: 0 (wait indefinitely)
+	Timeout time.Duration
+}
+
+// SQLiteConfig holds configuration for the SQLite store
+// Entries live in the obcache_entries table, which can be queried directly.
+// The application must register a database/sql driver, e.g. with a blank import
+// of github.com/mattn/go-sqlite3
+type SQLiteConfig struct {
+	// DSN is the data source name passed to sql.Open, usually a file path
+	DSN string
+
+	// DriverName is the registered driver to use
+	// Default: "sqlite3"
+	DriverName string
+
+	// CleanupBatchSize is the number of expired rows deleted per transaction
+	// Default: 500
+	CleanupBatchSize int
+}
+
+// TieredConfig holds configuration for the local tier of a tiered store
+// The shared tier is configured through RedisConfig
+type TieredConfig struct {
+	// L1MaxEntries is the maximum number of entries kept in local memory
+	// Default: 1000
+	L1MaxEntries int
+
+	// L1TTL caps how long an entry is served from local memory before it is
+	// re-read from Redis, bounding staleness across instances
+	// Default: 1 minute
+	L1TTL time.Duration
+
+	// ClientTracking uses Redis server-assisted client-side caching (Redis 6+) to
+	// drop local entries as soon as any client writes them, firing OnInvalidate
+	// hooks whose context satisfies IsRemoteInvalidation. It opens one extra
+	// connection and cannot be used with a Redis Client supplied in RedisConfig
+	// that is not a *redis.Client. The whole local tier is dropped after the
+	// tracking connection reconnects; L1TTL still bounds staleness
+	ClientTracking bool
+
+	// TrackingInterval is how often invalidations are read from Redis when
+	// ClientTracking is enabled
+	// Default: 100ms
+	TrackingInterval time.Duration
+}
+
+// RistrettoConfig holds configuration for the Ristretto-backed memory store
+// Capacity comes from Config.MaxEntries, or Config.MaxWeight when it is set
+type RistrettoConfig struct {
+	// NumCounters is the number of access frequency counters; about ten times
+	// the expected number of entries
+	// Default: 10 * MaxEntries
+	NumCounters int64
+
+	// SyncWrites makes Set wait until the write is visible to Get, at the cost
+	// of write throughput
+	SyncWrites bool
+}
+
+// EtcdConfig holds configuration for the etcd store
+// Values are stored as JSON, so like with Redis they come back as JSON types.
+// Entries with a TTL get their own lease, rounded up to whole seconds
+type EtcdConfig struct {
+	// Client is a pre-configured etcd client, which the cache does not close
+	// If nil, a new client is created from Endpoints, Username and Password
+	Client *clientv3.Client
+
+	// Endpoints are the etcd cluster addresses
+	// Only used if Client is nil
+	Endpoints []string
+
+	// Username and Password authenticate to etcd
+	// Only used if Client is nil
+	Username string
+	Password string
+
+	// DialTimeout bounds connecting to the cluster
+	// Only used if Client is nil
+	// Default: 5 seconds
+	DialTimeout time.Duration
+
+	// KeyPrefix is prepended to all cache keys
+	// Default: "obcache/"
+	KeyPrefix string
+
+	// MaxValueSize is the largest serialized entry accepted, in bytes; larger
+	// ones fail to Set. etcd is not a blob store and rejects requests over its
+	// --max-request-bytes (1.5 MiB by default)
+	// Default: 128 KiB
+	MaxValueSize int
+
+	// RequestTimeout bounds each etcd request
+	// Default: 5 seconds
+	RequestTimeout time.Duration
+
+	// L1MaxEntries enables a local memory tier of this size in front of etcd.
+	// A watch on KeyPrefix drops entries from it when another instance changes them
+	// Default: 0 (no local tier)
+	L1MaxEntries int
+
+	// L1TTL caps how long an entry is served from the local tier
+	// Default: 1 minute
+	L1TTL time.Duration
+}
+
+// DistributedConfig holds configuration for sharding the memory store across peers
+// Each key is owned by one peer, found by consistent hashing over the peer list.
+// The owner keeps it in its memory store, configured as usual through MaxEntries,
+// EvictionType and CleanupInterval; other peers read and write it over HTTP.
+// Keys, Len and eviction hooks cover the entries this peer owns
+type DistributedConfig struct {
+	// Self is this peer's base URL as other peers reach it, e.g. "http://10.0.0.1:7946"
+	Self string
+
+	// Peers are the base URLs of all peers, including or excluding Self
+	Peers []string
+
+	// Discovery finds peers instead of, or in addition to, Peers
+	Discovery PeerDiscovery
+
+	// DiscoveryInterval sets how often Discovery is polled
+	// Default: 10 seconds
+	DiscoveryInterval time.Duration
+
+	// ListenAddr is the address serving peer requests
+	// Default: the host and port of Self
+	ListenAddr string
+
+	// RequestTimeout bounds each request to a peer; a peer that does not answer
+	// in time is treated as a miss on reads and an error on writes
+	// Default: 1 second
+	RequestTimeout time.Duration
+
+	// HotCacheEntries enables a local copy of up to this many entries read from
+	// other peers, for keys too hot to fetch every time
+	// Default: 0 (no hot cache)
+	HotCacheEntries int
+
+	// HotCacheTTL caps how long a hot copy is served, bounding how long writes
+	// through other peers can go unseen
+	// Default: 1 minute
+	HotCacheTTL time.Duration
+}
+
+// PeerDiscovery finds the peers of a distributed cache
+type PeerDiscovery = distributed.Discovery
+
+// BlobConfig holds configuration for the S3-compatible blob store
+// Each entry is one object under KeyPrefix. Requests are slow, so pair the
+// store with a local tier through L1MaxEntries, and pass contexts with
+// GetContext, SetContext and DeleteContext to bound them
+type BlobConfig struct {
+	// Client adapts an S3-compatible SDK such as minio-go or the AWS SDK
+	Client BlobClient
+
+	// KeyPrefix is prepended to all cache keys to form object keys
+	// Default: "obcache/"
+	KeyPrefix string
+
+	// RequestTimeout bounds each object request
+	// Default: 30 seconds
+	RequestTimeout time.Duration
+
+	// LifecycleExpiration leaves removing expired objects to bucket lifecycle
+	// rules for KeyPrefix instead of listing them in Cleanup. Expired entries
+	// are misses either way
+	LifecycleExpiration bool
+
+	// L1MaxEntries enables a local memory tier of this size in front of the bucket
+	// Default: 0 (no local tier)
+	L1MaxEntries int
+
+	// L1TTL caps how long an entry is served from the local tier
+	// Default: 1 minute
+	L1TTL time.Duration
+}
+
+// BlobClient is the subset of an S3-compatible API the blob store uses
+// Methods must return an error matching ErrBlobNotFound for missing objects
+type BlobClient = blob.Client
+
+// ErrBlobNotFound is returned, possibly wrapped, by BlobClient methods for
+// objects that do not exist
+var ErrBlobNotFound = blob.ErrNotFound
+
+// BlobExpiresMetadataKey is the object metadata key holding an entry's
+// expiration in Unix milliseconds
+const BlobExpiresMetadataKey = blob.ExpiresMetadataKey
+
+// WriteBehindConfig holds configuration for asynchronous writes to the backend
+// Set and Delete return once the write is queued; reads on this cache see it
+// immediately, while other instances see it once a worker has flushed it
+type WriteBehindConfig struct {
+	// QueueSize bounds the number of distinct keys waiting to be flushed
+	// Default: 10000
+	QueueSize int
+
+	// Workers is the number of goroutines flushing to the backend
+	// Default: 4
+	Workers int
+
+	// BatchSize is the maximum number of writes a worker flushes at once
+	// Default: 100
+	BatchSize int
+
+	// Overflow decides what Set and Delete do when the queue is full
+	// Default: OverflowBlock
+	Overflow OverflowPolicy
+
+	// OnError is called for writes that fail to flush or are discarded by
+	// OverflowDropOldest. Set has already returned for these writes
+	OnError func(key string, err error)
+}
+
+// OverflowPolicy decides what a write does when the write-behind queue is full
+type OverflowPolicy = writebehind.OverflowPolicy
+
+const (
+	// OverflowBlock makes writes wait for room in the queue
+	OverflowBlock = writebehind.OverflowBlock
+
+	// OverflowDropOldest discards the oldest queued Set to make room; queued
+	// deletes are never discarded, so with only deletes queued writes wait
+	OverflowDropOldest = writebehind.OverflowDropOldest
+
+	// OverflowError rejects writes with ErrWriteQueueFull
+	OverflowError = writebehind.OverflowError
+)
+
+// ErrWriteQueueFull is returned by writes under OverflowError, and passed to
+// WriteBehindConfig.OnError for writes discarded under OverflowDropOldest
+var ErrWriteQueueFull = writebehind.ErrQueueFull
+
+// PersistenceConfig holds configuration for periodic snapshots of the memory store
+// Snapshots are written to a temporary file and renamed over Path, so a crash
+// mid-write leaves the previous snapshot in place. Values are stored as JSON, so
+// like with Bolt they come back as JSON types (e.g. numbers as float64)
+type PersistenceConfig struct {
+	// Path is the snapshot file
+	Path string
+
+	// Interval is how often a snapshot is written in the background
+	// Default: 0 (no periodic snapshots)
+	Interval time.Duration
+
+	// SkipLoad starts the cache empty instead of loading the snapshot at Path
+	SkipLoad bool
+
+	// SnapshotOnClose writes a final snapshot when the cache is closed
+	SnapshotOnClose bool
+
+	// OnError is called when a snapshot cannot be written, or when the snapshot
+	// at Path is corrupt and is ignored on startup
+	OnError func(err error)
+}
+
+// DiskOverflowConfig holds configuration for spilling entries evicted from the
+// memory store to local disk. Get falls through to disk and moves hits back into
+// memory. Files are written in the background, so eviction does not wait on the
+// disk. Spilled entries that are corrupt or expired are dropped as misses
+type DiskOverflowConfig struct {
+	// Dir holds the spill files; spill files left in it by a previous run are
+	// removed on startup
+	// Default: a temporary directory removed on Close
+	Dir string
+
+	// MaxDiskBytes bounds the total size of the spill files. The oldest spilled
+	// entries are dropped, and reported as capacity evictions, to make room
+	// Default: 256 MiB
+	MaxDiskBytes int64
+
+	// Codec encodes spilled values. With JSON they come back as JSON types (e.g.
+	// numbers as float64); codec.Gob keeps their types. Byte slices and
+	// compressed values are written as they are
+	// Default: compression.Config.Codec if set, else codec.JSON
+	Codec codec.Codec
 }
 
 // MetricsConfig holds metrics exporter configuration
@@ -60,6 +459,20 @@ type MetricsConfig struct {
 
 	// Labels are additional labels applied to all metrics
 	Labels metrics.Labels
+
+	// ExportHotKeys exports the keys tracked by Config.HotKeys as the
+	// obcache_hot_key_reads gauge labelled with the key, at most HotKeys series
+	ExportHotKeys bool
+
+	// ExportHookDurations records how long the hooks of each cache event take in
+	// the obcache_hook_duration_seconds histogram, labelled with the hook kind
+	// Requires hook timing, see Hooks.WithTiming
+	ExportHookDurations bool
+
+	// ExportStrategyEvents counts eviction strategy admissions, promotions,
+	// demotions and age resets, and records victim selection time, labelled with
+	// the strategy. Each event calls the exporter on the read or write path
+	ExportStrategyEvents bool
 }
 
 // Config defines the configuration options for a Cache instance
@@ -69,24 +482,62 @@ type Config struct {
 	StoreType StoreType
 
 	// MaxEntries sets the maximum number of entries in the cache (LRU)
-	// Only applies to memory store
+	// Only applies to memory and Bolt stores
 	// Default: 1000
 	MaxEntries int
 
 	// DefaultTTL sets the default time-to-live for cache entries
+	// Applied when Set is called with a ttl of 0; use NoTTL to bypass it
 	// Default: 5 minutes
 	DefaultTTL time.Duration
 
 	// CleanupInterval sets how often expired entries are cleaned up
-	// Only applies to memory store (Redis handles TTL automatically)
+	// Applies to memory, Bolt and SQLite stores (Redis handles TTL automatically)
 	// Default: 1 minute
 	CleanupInterval time.Duration
 
 	// EvictionType sets the eviction strategy for memory store
-	// Only applies to memory store
+	// Only applies to memory and Bolt stores
 	// Default: LRU
 	EvictionType eviction.EvictionType
 
+	// MaxWeight bounds the total size in bytes of stored values when positive
+	// Entries are evicted in eviction-strategy order until a new entry fits.
+	// With compression enabled, values are charged their stored length:
+	// compressed if they were compressed, serialized otherwise.
+	// Only applies to memory store
+	MaxWeight int64
+
+	// LFUAgingInterval halves LFU access counts once per interval so formerly
+	// popular entries eventually become evictable. Only applies to LFU eviction
+	// Default: 0 (no aging)
+	LFUAgingInterval time.Duration
+
+	// EvictionBatchSize is the number of entries evicted at once when the memory
+	// store is full, amortizing eviction cost under sustained writes
+	// Default: 0 (evict one entry per Set)
+	EvictionBatchSize int
+
+	// Sizer estimates the size in bytes of values stored as-is for MaxWeight
+	// If nil, a rough estimate based on the value's type is used
+	Sizer Sizer
+
+	// AdmissionPolicy filters which new keys are stored, e.g. a Doorkeeper
+	// that keeps one-hit keys out. Only applies to memory store
+	AdmissionPolicy AdmissionPolicy
+
+	// ShardCount splits the memory store into independent shards, each with its
+	// own lock and a 1/ShardCount share of MaxEntries and MaxWeight, to reduce lock
+	// contention on many cores. Counts are rounded up to a power of two, then
+	// halved while there would be more shards than MaxEntries. AutoShardCount
+	// picks one based on GOMAXPROCS. Eviction order is kept per shard
+	// Default: 0 (a single store)
+	ShardCount int
+
+	// EvictionStrategyFactory builds a custom eviction policy for the memory store
+	// When set it takes precedence over EvictionType. Only applies to memory store
+	EvictionStrategyFactory EvictionStrategyFactory
+
 	// KeyGenFunc defines a custom key generation function
 	// If nil, DefaultKeyFunc will be used
 	KeyGenFunc KeyGenFunc
@@ -94,10 +545,89 @@ type Config struct {
 	// Hooks defines event callbacks for cache operations
 	Hooks *Hooks
 
+	// SkipClearInvalidations stops Clear from firing an OnInvalidate hook for every
+	// removed key; OnClear hooks still fire once per Clear
+	// Default: false (OnInvalidate fires for every key)
+	SkipClearInvalidations bool
+
 	// Redis holds Redis-specific configuration
 	// Only used when StoreType is StoreTypeRedis
 	Redis *RedisConfig
 
+	// Bolt holds embedded Bolt store configuration
+	// Only used when StoreType is StoreTypeBolt
+	Bolt *BoltConfig
+
+	// SQLite holds SQLite store configuration
+	// Only used when StoreType is StoreTypeSQLite
+	SQLite *SQLiteConfig
+
+	// Tiered holds the local tier configuration; the shared tier uses Redis
+	// Only used when StoreType is StoreTypeTiered
+	Tiered *TieredConfig
+
+	// Ristretto holds Ristretto store configuration; nil uses the defaults
+	// Only used when StoreType is StoreTypeRistretto
+	Ristretto *RistrettoConfig
+
+	// Etcd holds etcd store configuration
+	// Only used when StoreType is StoreTypeEtcd
+	Etcd *EtcdConfig
+
+	// Distributed holds peer configuration for the distributed memory store
+	// Only used when StoreType is StoreTypeDistributed
+	Distributed *DistributedConfig
+
+	// Blob holds S3-compatible blob store configuration
+	// Only used when StoreType is StoreTypeBlob
+	Blob *BlobConfig
+
+	// CustomStore is a user-provided backend. The cache takes ownership and
+	// closes it in Close. See package store for the contract it must satisfy
+	// Only used when StoreType is StoreTypeCustom
+	CustomStore store.Store
+
+	// WriteBehind makes writes to the backend asynchronous when set
+	// Applies to Redis, Bolt, SQLite and custom stores
+	WriteBehind *WriteBehindConfig
+
+	// Persistence writes the memory store to a snapshot file periodically and
+	// restores it on startup when set. Only applies to memory store
+	Persistence *PersistenceConfig
+
+	// DiskOverflow spills entries evicted from the memory store to disk instead
+	// of dropping them when set. Only applies to memory store
+	DiskOverflow *DiskOverflowConfig
+
+	// HealthCheckInterval sets how long Healthy reuses the last backend probe result
+	// Default: 5 seconds
+	HealthCheckInterval time.Duration
+
+	// HitRateWindow is how far back Stats.RecentHitRate can look
+	// Default: 1 minute
+	HitRateWindow time.Duration
+
+	// HitRateResolution is the width of the intervals RecentHitRate counts in;
+	// the window keeps HitRateWindow/HitRateResolution of them
+	// Default: 1 second
+	HitRateResolution time.Duration
+
+	// CapacityWatermarks are fractions of MaxEntries, e.g. 0.9 and 1.0, at which
+	// OnCapacity hooks fire as the entry count rises past them. Capacity
+	// evictions fire OnCapacity hooks without any watermarks
+	CapacityWatermarks []float64
+
+	// CapacityAlertInterval is the minimum time between OnCapacity hook calls for
+	// the same watermark, or for capacity evictions
+	// Default: 1 minute
+	CapacityAlertInterval time.Duration
+
+	// HotKeys tracks the most frequently read keys for TopKeys when positive,
+	// reporting up to HotKeys keys. Reads are counted in a fixed-size sketch, so
+	// memory does not grow with the number of keys
+	// Default: 0 (disabled)
+	HotKeys int
+
 	// Metrics holds metrics exporter configuration
 	// If nil, no metrics will be exported
 	Metrics *MetricsConfig
@@ -105,8 +635,19 @@ type Config struct {
 	// Compression holds compression configuration
 	// If nil, compression will be disabled
 	Compression *compression.Config
+
+	// Encryption encrypts values after serialization and compression, e.g. with
+	// encryption.NewAESGCM, so they are stored as ciphertext bound to their key
+	// If nil, values are stored unencrypted
+	Encryption encryption.Encryptor
 }
 
+// AutoShardCount can be passed to WithShardCount to size shards from GOMAXPROCS
+const AutoShardCount = -1
+
+// Sizer returns the approximate size in bytes of a cached value
+type Sizer func(value any) int
+
 // KeyGenFunc defines a function that generates cache keys from function arguments
 type KeyGenFunc func(args []any) string
 
@@ -165,6 +706,85 @@ func NewRedisConfigWithClient(client redis.Cmdable) *Config {
 	return config
 }
 
+// NewBoltConfig returns a Config that persists entries in a Bolt database at path
+func NewBoltConfig(path string) *Config {
+	config := NewDefaultConfig()
+	config.StoreType = StoreTypeBolt
+	config.Bolt = &BoltConfig{
+		Path: path,
+	}
+	return config
+}
+
+// NewSQLiteConfig returns a Config that stores entries in the SQLite database at dsn
+func NewSQLiteConfig(dsn string) *Config {
+	config := NewDefaultConfig()
+	config.StoreType = StoreTypeSQLite
+	config.MaxEntries = 0 // Not applicable for SQLite
+	config.SQLite = &SQLiteConfig{
+		DSN: dsn,
+	}
+	return config
+}
+
+// NewTieredConfig returns a Config with a local memory tier in front of Redis at addr
+func NewTieredConfig(addr string) *Config {
+	config := NewRedisConfig(addr)
+	config.StoreType = StoreTypeTiered
+	config.CleanupInterval = time.Minute // Local tier cleanup
+	config.Tiered = &TieredConfig{
+		L1MaxEntries: 1000,
+		L1TTL:        time.Minute,
+	}
+	return config
+}
+
+// NewEtcdConfig returns a Config that stores entries in the etcd cluster at endpoints
+func NewEtcdConfig(endpoints ...string) *Config {
+	config := NewDefaultConfig()
+	config.StoreType = StoreTypeEtcd
+	config.MaxEntries = 0      // Not applicable for etcd
+	config.CleanupInterval = 0 // etcd expires entries through leases
+	config.Etcd = &EtcdConfig{
+		Endpoints: endpoints,
+	}
+	return config
+}
+
+// NewDistributedConfig returns a Config that shards entries across peers, with
+// self as this instance's base URL
+func NewDistributedConfig(self string, peers ...string) *Config {
+	config := NewDefaultConfig()
+	config.StoreType = StoreTypeDistributed
+	config.Distributed = &DistributedConfig{
+		Self:  self,
+		Peers: peers,
+	}
+	return config
+}
+
+// NewBlobConfig returns a Config that stores entries as objects through client
+// Expired objects are listed and removed every 10 minutes
+func NewBlobConfig(client BlobClient) *Config {
+	config := NewDefaultConfig()
+	config.StoreType = StoreTypeBlob
+	config.MaxEntries = 0 // Not applicable for the blob store
+	config.CleanupInterval = 10 * time.Minute
+	config.Blob = &BlobConfig{
+		Client: client,
+	}
+	return config
+}
+
+// NewRedisSentinelConfig returns a Config for Redis behind Sentinel, following
+// failovers of the named master
+func NewRedisSentinelConfig(masterName string, sentinelAddrs ...string) *Config {
+	config := NewRedisConfig("")
+	config.Redis.MasterName = masterName
+	config.Redis.SentinelAddrs = sentinelAddrs
+	return config
+}
+
 // WithMaxEntries sets the maximum number of cache entries
 func (c *Config) WithMaxEntries(maxEntries int) *Config {
 	c.MaxEntries = maxEntries
@@ -195,6 +815,12 @@ func (c *Config) WithHooks(hooks *Hooks) *Config {
 	return c
 }
 
+// WithClearInvalidations sets whether Clear fires an OnInvalidate hook for every removed key
+func (c *Config) WithClearInvalidations(enabled bool) *Config {
+	c.SkipClearInvalidations = !enabled
+	return c
+}
+
 // WithRedis configures the cache to use Redis storage
 func (c *Config) WithRedis(redisConfig *RedisConfig) *Config {
 	c.StoreType = StoreTypeRedis
@@ -204,20 +830,188 @@ func (c *Config) WithRedis(redisConfig *RedisConfig) *Config {
 	return c
 }
 
+// WithBolt configures the cache to persist entries in an embedded Bolt database
+func (c *Config) WithBolt(boltConfig *BoltConfig) *Config {
+	c.StoreType = StoreTypeBolt
+	c.Bolt = boltConfig
+	return c
+}
+
+// WithSQLite configures the cache to store entries in a SQLite database
+func (c *Config) WithSQLite(sqliteConfig *SQLiteConfig) *Config {
+	c.StoreType = StoreTypeSQLite
+	c.SQLite = sqliteConfig
+	c.MaxEntries = 0
+	return c
+}
+
+// WithTiered configures the cache to keep a local memory tier in front of Redis
+func (c *Config) WithTiered(tieredConfig *TieredConfig, redisConfig *RedisConfig) *Config {
+	c.StoreType = StoreTypeTiered
+	c.Tiered = tieredConfig
+	c.Redis = redisConfig
+	c.MaxEntries = 0
+	return c
+}
+
+// WithRistretto configures the cache to use the Ristretto-backed memory store
+func (c *Config) WithRistretto(ristrettoConfig *RistrettoConfig) *Config {
+	c.StoreType = StoreTypeRistretto
+	c.Ristretto = ristrettoConfig
+	return c
+}
+
+// WithEtcd configures the cache to store entries in etcd
+func (c *Config) WithEtcd(etcdConfig *EtcdConfig) *Config {
+	c.StoreType = StoreTypeEtcd
+	c.Etcd = etcdConfig
+	c.MaxEntries = 0
+	c.CleanupInterval = 0
+	return c
+}
+
+// WithBlob configures the cache to store entries in an S3-compatible bucket
+func (c *Config) WithBlob(blobConfig *BlobConfig) *Config {
+	c.StoreType = StoreTypeBlob
+	c.Blob = blobConfig
+	c.MaxEntries = 0
+	return c
+}
+
+// WithDistributed configures the cache to shard entries across peers
+func (c *Config) WithDistributed(distributedConfig *DistributedConfig) *Config {
+	c.StoreType = StoreTypeDistributed
+	c.Distributed = distributedConfig
+	return c
+}
+
+// WithCustomStore configures the cache to use a user-provided store
+// Capacity, eviction and cleanup are up to the store, so MaxEntries and
+// CleanupInterval are cleared
+func (c *Config) WithCustomStore(customStore store.Store) *Config {
+	c.StoreType = StoreTypeCustom
+	c.CustomStore = customStore
+	c.MaxEntries = 0
+	c.CleanupInterval = 0
+	return c
+}
+
 // WithMetrics configures cache metrics export
 func (c *Config) WithMetrics(metricsConfig *MetricsConfig) *Config {
 	c.Metrics = metricsConfig
 	return c
 }
 
+// WithWriteBehind makes writes to the backend asynchronous
+func (c *Config) WithWriteBehind(writeBehindConfig *WriteBehindConfig) *Config {
+	c.WriteBehind = writeBehindConfig
+	return c
+}
+
+// WithDiskOverflow spills entries evicted from the memory store to files in dir,
+// up to maxDiskBytes; an empty dir uses a temporary directory
+func (c *Config) WithDiskOverflow(dir string, maxDiskBytes int64) *Config {
+	c.DiskOverflow = &DiskOverflowConfig{
+		Dir:          dir,
+		MaxDiskBytes: maxDiskBytes,
+	}
+	return c
+}
+
+// WithPersistence snapshots the memory store to path every interval and loads
+// the snapshot on startup
+func (c *Config) WithPersistence(path string, interval time.Duration) *Config {
+	c.Persistence = &PersistenceConfig{
+		Path:     path,
+		Interval: interval,
+	}
+	return c
+}
+
+// WithHealthCheckInterval sets how long Healthy reuses the last backend probe result
+func (c *Config) WithHealthCheckInterval(interval time.Duration) *Config {
+	c.HealthCheckInterval = interval
+	return c
+}
+
 // WithCompression configures cache compression
 func (c *Config) WithCompression(compressionConfig *compression.Config) *Config {
 	c.Compression = compressionConfig
 	return c
 }
 
+// WithEncryption encrypts stored values with encryptor
+func (c *Config) WithEncryption(encryptor encryption.Encryptor) *Config {
+	c.Encryption = encryptor
+	return c
+}
+
 // WithEvictionType sets the eviction strategy for memory store
 func (c *Config) WithEvictionType(evictionType eviction.EvictionType) *Config {
 	c.EvictionType = evictionType
 	return c
 }
+
+// WithMaxWeight sets the maximum total size in bytes of values in memory store
+func (c *Config) WithMaxWeight(maxWeight int64) *Config {
+	c.MaxWeight = maxWeight
+	return c
+}
+
+// WithLFUAging sets how often LFU access counts are halved
+func (c *Config) WithLFUAging(interval time.Duration) *Config {
+	c.LFUAgingInterval = interval
+	return c
+}
+
+// WithEvictionBatchSize sets how many entries are evicted at once when the memory store is full
+func (c *Config) WithEvictionBatchSize(n int) *Config {
+	c.EvictionBatchSize = n
+	return c
+}
+
+// WithSizer sets the function used to estimate the size of uncompressed values
+func (c *Config) WithSizer(sizer Sizer) *Config {
+	c.Sizer = sizer
+	return c
+}
+
+// WithAdmissionPolicy sets the policy that decides whether new keys are stored
+func (c *Config) WithAdmissionPolicy(policy AdmissionPolicy) *Config {
+	c.AdmissionPolicy = policy
+	return c
+}
+
+// WithShardCount splits the memory store into n shards to reduce lock contention
+// Use AutoShardCount to choose n from GOMAXPROCS
+func (c *Config) WithShardCount(n int) *Config {
+	c.ShardCount = n
+	return c
+}
+
+// WithHitRateWindow sets how far back and at what resolution Stats.RecentHitRate counts
+func (c *Config) WithHitRateWindow(window, resolution time.Duration) *Config {
+	c.HitRateWindow = window
+	c.HitRateResolution = resolution
+	return c
+}
+
+// WithCapacityWatermarks sets the fractions of MaxEntries at which OnCapacity hooks
+// fire, and the minimum time between calls for each of them
+func (c *Config) WithCapacityWatermarks(interval time.Duration, fractions ...float64) *Config {
+	c.CapacityAlertInterval = interval
+	c.CapacityWatermarks = fractions
+	return c
+}
+
+// WithHotKeys enables tracking of the n most frequently read keys for TopKeys
+func (c *Config) WithHotKeys(n int) *Config {
+	c.HotKeys = n
+	return c
+}
+
+// WithEvictionStrategyFactory sets a custom eviction policy for memory store
+func (c *Config) WithEvictionStrategyFactory(factory EvictionStrategyFactory) *Config {
+	c.EvictionStrategyFactory = factory
+	return c
+}
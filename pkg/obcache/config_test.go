@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"testing"
 	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/internal/eviction"
+	"github.com/1mb-dev/obcache-go/v2/internal/store/memory"
 )
 
 func TestConfigDefaults(t *testing.T) {
@@ -53,6 +56,72 @@ func TestWithCleanupInterval(t *testing.T) {
 	}
 }
 
+func TestWithCustomStore(t *testing.T) {
+	customStore, err := memory.NewWithStrategy(eviction.Config{Type: eviction.LRU, Capacity: 10})
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	config := NewDefaultConfig().WithCustomStore(customStore)
+
+	if config.StoreType != StoreTypeCustom || config.CustomStore != customStore {
+		t.Fatal("Expected WithCustomStore to select the custom store")
+	}
+
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	_ = cache.Set("key", "value", time.Hour)
+	if _, found := customStore.Get("key"); !found {
+		t.Fatal("Expected the cache to write to the custom store")
+	}
+
+	if _, err := New(NewDefaultConfig().WithCustomStore(nil)); err == nil {
+		t.Fatal("Expected an error for a nil custom store")
+	}
+}
+
+func TestWithWriteBehind(t *testing.T) {
+	if _, err := New(NewDefaultConfig().WithWriteBehind(&WriteBehindConfig{})); err == nil {
+		t.Fatal("Expected an error for write-behind on the memory store")
+	}
+
+	backing, err := memory.NewWithStrategy(eviction.Config{Type: eviction.LRU, Capacity: 10})
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	var flushErrs []error
+	config := NewDefaultConfig().WithCustomStore(backing).WithWriteBehind(&WriteBehindConfig{
+		Overflow: OverflowError,
+		OnError:  func(key string, err error) { flushErrs = append(flushErrs, err) },
+	})
+
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	if err := cache.Set("key", "value", time.Hour); err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+	if value, found := cache.Get("key"); !found || value != "value" {
+		t.Fatalf("Expected to read the write back, got %v (found=%v)", value, found)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for cache.Stats().WriteQueueDepth() > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if _, found := backing.Peek("key"); !found {
+		t.Fatal("Expected the write to be flushed to the backing store")
+	}
+	if cache.Stats().WriteQueueDepth() != 0 || len(flushErrs) != 0 {
+		t.Errorf("Expected an empty queue and no flush errors, got depth %d and %v",
+			cache.Stats().WriteQueueDepth(), flushErrs)
+	}
+}
+
 func TestWithKeyGenFunc(t *testing.T) {
 	customKeyFunc := func(_ []any) string {
 		const customKey = "custom-key"
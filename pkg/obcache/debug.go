@@ -89,7 +89,7 @@ func (c *Cache) DebugHandler() http.Handler {
 					debugKey := DebugKey{
 						Key:       key,
 						Value:     entry.Value,
-						ExpiresAt: entry.ExpiresAt,
+						ExpiresAt: entry.Expiry(),
 						CreatedAt: entry.CreatedAt,
 						Age:       formatDuration(entry.Age()),
 					}
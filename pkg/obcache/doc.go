@@ -1,5 +1,5 @@
 // Package obcache provides a high-performance, thread-safe, in-memory cache with TTL support,
-// multiple eviction strategies (LRU/LFU/FIFO), function memoization, and hooks for observability.
+// multiple eviction strategies (LRU/LFU/FIFO/TinyLFU), function memoization, and hooks for observability.
 //
 // # Overview
 //
@@ -11,7 +11,7 @@
 //
 //   - Thread-safe concurrent access with minimal lock contention
 //   - Time-to-live (TTL) expiration with automatic cleanup
-//   - Multiple eviction strategies: LRU, LFU, and FIFO
+//   - Multiple eviction strategies: LRU, LFU, FIFO, and TinyLFU
 //   - Function memoization with customizable key generation
 //   - Context-aware hooks for monitoring cache operations
 //   - Built-in statistics and performance monitoring
@@ -94,6 +94,26 @@
 //	// Evicts oldest items regardless of access patterns
 //	config := obcache.NewDefaultConfig().WithEvictionType(eviction.FIFO)
 //
+//	// TinyLFU (W-TinyLFU)
+//	// Admits new items only if they are accessed more often than the items they displace
+//	config := obcache.NewDefaultConfig().WithEvictionType(eviction.TinyLFU)
+//
+//	// Random
+//	// Evicts a uniformly random item; cheapest bookkeeping, good for uniform access
+//	config := obcache.NewDefaultConfig().WithEvictionType(eviction.Random)
+//
+//	// TTLFirst
+//	// Evicts items closest to expiration first, then LRU among items without TTL
+//	config := obcache.NewDefaultConfig().WithEvictionType(eviction.TTLFirst)
+//
+//	// Clock
+//	// Approximates LRU with a reference bit per item, so reads never take a write lock
+//	config := obcache.NewDefaultConfig().WithEvictionType(eviction.Clock)
+//
+//	// GDSF (Greedy-Dual-Size-Frequency)
+//	// Weighs frequency against value size so large, rarely used items go first
+//	config := obcache.NewDefaultConfig().WithEvictionType(eviction.GDSF)
+//
 // # Context-Aware Hooks
 //
 // Monitor cache operations with context-aware hooks:
@@ -112,6 +132,11 @@
 //	    metrics.IncrementCounter("cache.misses")
 //	})
 //
+//	// Hook on successful writes, with the value as passed to Set
+//	hooks.AddOnSet(func(ctx context.Context, key string, value any, ttl time.Duration) {
+//	    auditLog.Record(key, value, ttl)
+//	})
+//
 //	// Hook on evictions
 //	hooks.AddOnEvict(func(ctx context.Context, key string, value any, reason obcache.EvictReason) {
 //	    log.Printf("Evicted: %s (reason: %s)", key, reason)
@@ -122,6 +147,11 @@
 //	    log.Printf("Invalidated: %s", key)
 //	})
 //
+//	// Hook on failed writes, unreadable entries and metrics export errors
+//	hooks.AddOnError(func(ctx context.Context, op string, key string, err error) {
+//	    log.Printf("Cache %s %s failed: %v", op, key, err)
+//	})
+//
 //	cache, _ := obcache.New(obcache.NewDefaultConfig().WithHooks(hooks))
 //
 // # Context Propagation
@@ -205,6 +235,11 @@
 //   - LRU: Good for temporal locality (recently used data)
 //   - LFU: Good for frequency patterns (popular items)
 //   - FIFO: Simple, predictable, good for time-series data
+//   - TinyLFU: Best hit rates for read-heavy workloads with a long tail of rare keys
+//   - Random: Lowest overhead at high write rates when access is roughly uniform
+//   - TTLFirst: Mixed TTLs, where entries about to expire are the cheapest to lose
+//   - Clock: Near-LRU hit rates for read-heavy workloads with many concurrent readers
+//   - GDSF: Mixed value sizes, especially together with WithMaxWeight
 //
 // # Thread Safety
 //
@@ -0,0 +1,81 @@
+package obcache
+
+import (
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+)
+
+// EntryInfo describes the metadata of a cache entry without exposing its value
+type EntryInfo struct {
+	// CreatedAt is when the entry was stored
+	CreatedAt time.Time
+
+	// AccessedAt is when the entry was last read
+	AccessedAt time.Time
+
+	// ExpiresAt is when the entry expires (nil means no expiration)
+	ExpiresAt *time.Time
+
+	// Size is the stored size in bytes when known (compressed or serialized values), otherwise 0
+	Size int
+
+	// OriginalSize is the size in bytes before compression when known, otherwise 0
+	// It equals Size for uncompressed entries
+	OriginalSize int
+
+	// AccessCount is the number of reads that found the entry, for stores that
+	// keep entries between reads such as the memory store; otherwise 0
+	AccessCount int64
+
+	// Compressed reports whether the stored value is compressed
+	Compressed bool
+
+	// Codec names the codec that serialized the value (empty for values stored as-is),
+	// e.g. to find entries written before the configured codec changed
+	Codec string
+
+	// DictionaryID identifies the dictionary the value was compressed with (0 if none),
+	// e.g. to find entries written before the configured dictionary changed
+	DictionaryID uint32
+
+	// Version is the version supplied to SetVersioned (0 for unversioned entries)
+	Version int64
+}
+
+// Age returns how long ago the entry was created
+func (i EntryInfo) Age() time.Duration {
+	return time.Since(i.CreatedAt)
+}
+
+// TTL returns the remaining time until expiration, or NoTTL if the entry never expires
+func (i EntryInfo) TTL() time.Duration {
+	if i.ExpiresAt == nil {
+		return NoTTL
+	}
+	return max(time.Until(*i.ExpiresAt), 0)
+}
+
+// newEntryInfo captures the metadata of a stored entry
+func newEntryInfo(e *entry.Entry) EntryInfo {
+	return EntryInfo{
+		CreatedAt:    e.CreatedAt,
+		AccessedAt:   e.LastAccess(),
+		ExpiresAt:    e.Expiry(),
+		Size:         e.Size(),
+		OriginalSize: originalSize(e),
+		AccessCount:  e.AccessCount(),
+		Compressed:   e.IsCompressed,
+		Codec:        e.CodecName,
+		DictionaryID: e.DictionaryID,
+		Version:      e.Version,
+	}
+}
+
+// originalSize returns the size of e's value before compression when known
+func originalSize(e *entry.Entry) int {
+	if e.IsCompressed {
+		return e.OriginalSize
+	}
+	return e.Size()
+}
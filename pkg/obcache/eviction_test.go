@@ -2,11 +2,17 @@ package obcache
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/1mb-dev/obcache-go/v2/internal/eviction"
+	"github.com/1mb-dev/obcache-go/v2/pkg/compression"
 )
 
 func TestEvictionStrategies(t *testing.T) {
@@ -268,6 +274,54 @@ func TestEvictionCallbacks(t *testing.T) {
 	}
 }
 
+func TestEvictionReasons(t *testing.T) {
+	reasons := make(map[string]EvictReason)
+
+	hooks := NewHooks()
+	hooks.AddOnEvict(func(_ context.Context, key string, _ any, reason EvictReason) {
+		reasons[key] = reason
+	})
+
+	config := NewDefaultConfig().
+		WithMaxEntries(2).
+		WithEvictionType(eviction.FIFO).
+		WithHooks(hooks)
+
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	// key1 expires before it is displaced, key2 is displaced while still live
+	_ = cache.Set("key1", "value1", TestShortTTL)
+	_ = cache.Set("key2", "value2", time.Hour)
+	time.Sleep(2 * TestShortTTL)
+
+	_ = cache.Set("key3", "value3", time.Hour)
+	_ = cache.Set("key4", "value4", time.Hour)
+
+	if reason, ok := reasons["key1"]; !ok || reason != EvictReasonTTL {
+		t.Errorf("Expected expired key1 to be evicted with EvictReasonTTL, got %v (found=%v)", reason, ok)
+	}
+	if reason, ok := reasons["key2"]; !ok || reason != EvictReasonCapacity {
+		t.Errorf("Expected key2 to be evicted with EvictReasonCapacity, got %v (found=%v)", reason, ok)
+	}
+
+	byReason := cache.Stats().EvictionsByReason()
+	if byReason["ttl"] != 1 || byReason["capacity"] != 1 {
+		t.Errorf("Expected one ttl and one capacity eviction, got %v", byReason)
+	}
+	if cache.Stats().Evictions() != 2 {
+		t.Errorf("Expected 2 evictions, got %d", cache.Stats().Evictions())
+	}
+
+	cache.Stats().Reset()
+	if byReason := cache.Stats().EvictionsByReason(); len(byReason) != 0 {
+		t.Errorf("Expected no evictions after reset, got %v", byReason)
+	}
+}
+
 func TestEvictionStrategiesWithCleanup(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -276,6 +330,9 @@ func TestEvictionStrategiesWithCleanup(t *testing.T) {
 		{"LRU", eviction.LRU},
 		{"LFU", eviction.LFU},
 		{"FIFO", eviction.FIFO},
+		{"TinyLFU", eviction.TinyLFU},
+		{"Clock", eviction.Clock},
+		{"GDSF", eviction.GDSF},
 	}
 
 	for _, tc := range testCases {
@@ -345,3 +402,615 @@ func TestConfigurationMethods(t *testing.T) {
 		t.Errorf("Expected eviction type to be FIFO, got %s", config.EvictionType)
 	}
 }
+
+func TestHasDoesNotAffectEviction(t *testing.T) {
+	testCases := []struct {
+		name         string
+		evictionType eviction.EvictionType
+	}{
+		{"LRU", eviction.LRU},
+		{"LFU", eviction.LFU},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cache, err := New(NewDefaultConfig().WithMaxEntries(3).WithEvictionType(tc.evictionType))
+			if err != nil {
+				t.Fatalf("Failed to create cache: %v", err)
+			}
+			defer func() { _ = cache.Close() }()
+
+			_ = cache.Set("oldest", "value", time.Hour)
+			_ = cache.Set("key2", "value", time.Hour)
+			_ = cache.Set("key3", "value", time.Hour)
+
+			// Make the other entries more recent/frequent than "oldest"
+			cache.Get("key2")
+			cache.Get("key3")
+
+			// Probing the oldest key must not protect it from eviction
+			for i := 0; i < 5; i++ {
+				if !cache.Has("oldest") {
+					t.Fatal("Expected oldest key to exist before eviction")
+				}
+				if _, found := cache.TTL("oldest"); !found {
+					t.Fatal("Expected TTL for oldest key before eviction")
+				}
+			}
+
+			_ = cache.Set("key4", "value", time.Hour)
+
+			if cache.Has("oldest") {
+				t.Fatal("Expected probed key to be evicted")
+			}
+		})
+	}
+}
+
+func TestManualEvict(t *testing.T) {
+	var evicted []string
+	hooks := NewHooks()
+	hooks.AddOnEvict(func(_ context.Context, key string, _ any, reason EvictReason) {
+		if reason != EvictReasonManual {
+			t.Errorf("Expected EvictReasonManual, got %v", reason)
+		}
+		evicted = append(evicted, key)
+	})
+
+	cache, err := New(NewDefaultConfig().WithMaxEntries(10).WithEvictionType(eviction.LRU).WithHooks(hooks))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	for _, key := range []string{"key1", "key2", "key3", "key4"} {
+		_ = cache.Set(key, "value", time.Hour)
+	}
+	cache.Get("key1") // key1 becomes most recently used
+
+	if n := cache.Evict(2); n != 2 {
+		t.Fatalf("Expected 2 entries evicted, got %d", n)
+	}
+	if len(evicted) != 2 || evicted[0] != "key2" || evicted[1] != "key3" {
+		t.Fatalf("Expected key2 and key3 evicted in LRU order, got %v", evicted)
+	}
+	if cache.Has("key2") || cache.Has("key3") || !cache.Has("key1") || !cache.Has("key4") {
+		t.Fatal("Unexpected cache contents after Evict")
+	}
+	if cache.Stats().Evictions() != 2 {
+		t.Errorf("Expected 2 evictions in stats, got %d", cache.Stats().Evictions())
+	}
+
+	if n := cache.Evict(10); n != 2 {
+		t.Errorf("Expected remaining 2 entries evicted, got %d", n)
+	}
+	if n := cache.Evict(1); n != 0 {
+		t.Errorf("Expected nothing to evict from empty cache, got %d", n)
+	}
+}
+
+func TestTTLFirstEvictionWithSetTTL(t *testing.T) {
+	cache, err := New(NewDefaultConfig().WithMaxEntries(2).WithEvictionType(eviction.TTLFirst))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	_ = cache.Set("short", "value", time.Minute)
+	_ = cache.Set("long", "value", time.Hour)
+
+	// Extending the deadline must move "short" behind "long"
+	if !cache.SetTTL("short", 2*time.Hour) {
+		t.Fatal("Expected SetTTL to succeed")
+	}
+	_ = cache.Set("new", "value", time.Hour)
+
+	if cache.Has("long") {
+		t.Error("Expected entry closest to expiration to be evicted")
+	}
+	if !cache.Has("short") || !cache.Has("new") {
+		t.Error("Expected extended and new entries to remain")
+	}
+}
+
+// keyOrderStrategy evicts keys in lexical order
+type keyOrderStrategy struct {
+	keys map[string]bool
+}
+
+func (s *keyOrderStrategy) OnAdd(key string, _ any) { s.keys[key] = true }
+func (s *keyOrderStrategy) OnAccess(string)         {}
+func (s *keyOrderStrategy) OnRemove(key string)     { delete(s.keys, key) }
+
+func (s *keyOrderStrategy) Victims(n int) []string {
+	keys := make([]string, 0, len(s.keys))
+	for key := range s.keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys[:min(n, len(keys))]
+}
+
+func TestCustomEvictionStrategy(t *testing.T) {
+	var capacity int
+	config := NewDefaultConfig().
+		WithMaxEntries(2).
+		WithEvictionStrategyFactory(func(c int) EvictionStrategy {
+			capacity = c
+			return &keyOrderStrategy{keys: make(map[string]bool)}
+		})
+
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	if capacity != 2 {
+		t.Errorf("Expected factory to receive capacity 2, got %d", capacity)
+	}
+
+	_ = cache.Set("b", "value", time.Hour)
+	_ = cache.Set("c", "value", time.Hour)
+	_ = cache.Set("a", "value", time.Hour)
+
+	// "a" was just added, so the policy's first victim among older keys is "b"
+	if cache.Has("b") || !cache.Has("c") || !cache.Has("a") {
+		t.Fatalf("Expected b to be evicted, keys: %v", cache.Keys())
+	}
+	if cache.Stats().Evictions() != 1 {
+		t.Errorf("Expected 1 eviction, got %d", cache.Stats().Evictions())
+	}
+
+	if n := cache.Evict(1); n != 1 || cache.Has("a") {
+		t.Errorf("Expected manual Evict to follow the custom policy, removed %d", n)
+	}
+}
+
+func TestCustomEvictionStrategyNilFactoryResult(t *testing.T) {
+	config := NewDefaultConfig().WithEvictionStrategyFactory(func(int) EvictionStrategy { return nil })
+	if _, err := New(config); err == nil {
+		t.Fatal("Expected error when factory returns nil")
+	}
+}
+
+func TestMaxWeightEviction(t *testing.T) {
+	var evicted []string
+	hooks := NewHooks()
+	hooks.AddOnEvict(func(_ context.Context, key string, _ any, reason EvictReason) {
+		if reason == EvictReasonCapacity {
+			evicted = append(evicted, key)
+		}
+	})
+
+	config := NewDefaultConfig().
+		WithMaxEntries(100).
+		WithMaxWeight(100).
+		WithEvictionType(eviction.FIFO).
+		WithHooks(hooks)
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	for _, key := range []string{"a", "b", "c", "d"} {
+		_ = cache.Set(key, strings.Repeat("x", 25), time.Hour)
+	}
+	_ = cache.Set("big", strings.Repeat("x", 60), time.Hour)
+
+	if len(evicted) != 3 || evicted[0] != "a" || evicted[1] != "b" || evicted[2] != "c" {
+		t.Fatalf("Expected a, b and c evicted for the big entry, got %v", evicted)
+	}
+	if cache.Stats().Evictions() != 3 {
+		t.Errorf("Expected 3 evictions in stats, got %d", cache.Stats().Evictions())
+	}
+	if !cache.Has("d") || !cache.Has("big") {
+		t.Error("Expected d and big to remain")
+	}
+}
+
+func TestMaxWeightWithSizer(t *testing.T) {
+	type blob struct{ size int }
+
+	config := NewDefaultConfig().
+		WithMaxEntries(100).
+		WithMaxWeight(1000).
+		WithSizer(func(value any) int {
+			if b, ok := value.(blob); ok {
+				return b.size
+			}
+			return 0
+		})
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	_ = cache.Set("first", blob{size: 600}, time.Hour)
+	_ = cache.Set("second", blob{size: 600}, time.Hour)
+
+	if cache.Has("first") || !cache.Has("second") {
+		t.Fatal("Expected Sizer-reported weight to evict the first blob")
+	}
+}
+
+func TestMaxWeightCompressed(t *testing.T) {
+	const budget = 20 * 1024
+	fill := func(config *Config) *Cache {
+		cache, err := New(config.WithMaxEntries(1000).WithMaxWeight(budget))
+		if err != nil {
+			t.Fatalf("Failed to create cache: %v", err)
+		}
+		for i := range 100 {
+			_ = cache.Set(fmt.Sprintf("page:%d", i), strings.Repeat(fmt.Sprintf("row %d;", i%10), 300), time.Hour)
+		}
+		return cache
+	}
+
+	plain := fill(NewDefaultConfig())
+	defer func() { _ = plain.Close() }()
+	compressed := fill(NewDefaultConfig().WithCompression(compression.NewDefaultConfig().WithEnabled(true)))
+	defer func() { _ = compressed.Close() }()
+
+	if plain.Len() >= 100 {
+		t.Fatalf("Expected the uncompressed values to exceed the budget, %d entries fit", plain.Len())
+	}
+	if compressed.Len() <= plain.Len() {
+		t.Errorf("Expected more compressed entries to fit: %d <= %d", compressed.Len(), plain.Len())
+	}
+
+	for _, cache := range []*Cache{plain, compressed} {
+		stored := cache.Stats().StoredBytes()
+		if stored <= 0 || stored > budget {
+			t.Errorf("Expected stored bytes within the budget, got %d", stored)
+		}
+	}
+	if stored := compressed.Stats().StoredBytes(); stored != compressed.Stats().CompressionCompressedBytes() {
+		t.Errorf("Expected compressed entries charged their compressed size: %d != %d",
+			stored, compressed.Stats().CompressionCompressedBytes())
+	}
+
+	// Values too small to compress are charged their serialized length
+	small, err := New(NewDefaultConfig().WithMaxWeight(budget).
+		WithCompression(compression.NewDefaultConfig().WithEnabled(true)))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = small.Close() }()
+	_ = small.Set("user", map[string]any{"name": "ada"}, time.Hour)
+	if stored := small.Stats().StoredBytes(); stored != int64(len(`{"name":"ada"}`)) {
+		t.Errorf("Expected the serialized size to be charged, got %d", stored)
+	}
+}
+
+func TestEvictionBatchSize(t *testing.T) {
+	evicted := make(map[string]any)
+	hooks := NewHooks()
+	hooks.AddOnEvict(func(_ context.Context, key string, value any, reason EvictReason) {
+		if reason == EvictReasonCapacity {
+			evicted[key] = value
+		}
+	})
+
+	config := NewDefaultConfig().
+		WithMaxEntries(10).
+		WithEvictionType(eviction.FIFO).
+		WithEvictionBatchSize(5).
+		WithHooks(hooks)
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	for i := 0; i < 11; i++ {
+		_ = cache.Set(fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i), time.Hour)
+	}
+
+	if len(evicted) != 5 {
+		t.Fatalf("Expected 5 entries evicted in one batch, got %d", len(evicted))
+	}
+	for i := 0; i < 5; i++ {
+		key := fmt.Sprintf("key%d", i)
+		if evicted[key] != fmt.Sprintf("value%d", i) {
+			t.Errorf("Expected OnEvict for %s with its value, got %v", key, evicted[key])
+		}
+	}
+	if cache.Stats().Evictions() != 5 || cache.Len() != 6 {
+		t.Errorf("Expected 5 evictions and 6 entries, got %d and %d", cache.Stats().Evictions(), cache.Len())
+	}
+}
+
+func TestPinnedEntries(t *testing.T) {
+	cache, err := New(NewDefaultConfig().WithMaxEntries(3))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	_ = cache.Set("config", "value", NoTTL)
+	_ = cache.Set("key1", "value", time.Hour)
+	_ = cache.Set("key2", "value", time.Hour)
+
+	if err := cache.Pin("config"); err != nil {
+		t.Fatalf("Failed to pin: %v", err)
+	}
+	if err := cache.Pin("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for missing key, got %v", err)
+	}
+	if cache.Stats().PinnedCount() != 1 {
+		t.Errorf("Expected 1 pinned key, got %d", cache.Stats().PinnedCount())
+	}
+
+	// config is the LRU entry but survives capacity pressure
+	for i := 0; i < 10; i++ {
+		_ = cache.Set(fmt.Sprintf("fill%d", i), "value", time.Hour)
+	}
+	if !cache.Has("config") {
+		t.Fatal("Expected pinned entry to survive eviction")
+	}
+
+	// With every entry pinned, new keys are refused
+	for _, key := range cache.Keys() {
+		_ = cache.Pin(key)
+	}
+	if err := cache.Set("overflow", "value", time.Hour); !errors.Is(err, ErrAllPinned) {
+		t.Fatalf("Expected ErrAllPinned, got %v", err)
+	}
+	if err := cache.Set("config", "updated", NoTTL); err != nil {
+		t.Errorf("Expected update of pinned key to succeed, got %v", err)
+	}
+
+	_ = cache.Unpin("config")
+	if err := cache.Set("overflow", "value", time.Hour); err != nil {
+		t.Fatalf("Expected Set to succeed after Unpin, got %v", err)
+	}
+	if cache.Has("config") {
+		t.Error("Expected unpinned entry to be evicted")
+	}
+	if cache.Stats().PinnedCount() != 2 {
+		t.Errorf("Expected 2 pinned keys, got %d", cache.Stats().PinnedCount())
+	}
+}
+
+func TestPinnedEntryStillExpires(t *testing.T) {
+	cache, err := New(NewDefaultConfig().WithMaxEntries(1))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	_ = cache.Set("short", "value", TestShortTTL)
+	_ = cache.Pin("short")
+	time.Sleep(2 * TestShortTTL)
+
+	if cache.Has("short") {
+		t.Fatal("Expected pinned entry to expire")
+	}
+	// The expired pinned entry must not block new writes
+	if err := cache.Set("next", "value", time.Hour); err != nil {
+		t.Fatalf("Expected Set to succeed once the pinned entry expired, got %v", err)
+	}
+}
+
+func TestResize(t *testing.T) {
+	var evictedKeys []string
+	hooks := NewHooks()
+	hooks.AddOnEvict(func(_ context.Context, key string, _ any, reason EvictReason) {
+		if reason == EvictReasonCapacity {
+			evictedKeys = append(evictedKeys, key)
+		}
+	})
+
+	cache, err := New(NewDefaultConfig().
+		WithMaxEntries(5).
+		WithEvictionType(eviction.LRU).
+		WithHooks(hooks))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	for i := 1; i <= 5; i++ {
+		_ = cache.Set(fmt.Sprintf("key%d", i), i, time.Hour)
+	}
+	cache.Get("key1")
+
+	if err := cache.Resize(3); err != nil {
+		t.Fatalf("Resize failed: %v", err)
+	}
+	if !reflect.DeepEqual(evictedKeys, []string{"key2", "key3"}) {
+		t.Errorf("Expected key2 and key3 to be evicted in LRU order, got %v", evictedKeys)
+	}
+	if cache.Len() != 3 || cache.Stats().KeyCount() != 3 {
+		t.Errorf("Expected 3 entries after shrinking, got %d (key count %d)", cache.Len(), cache.Stats().KeyCount())
+	}
+	if cache.Stats().Capacity() != 3 || cache.Stats().Evictions() != 2 {
+		t.Errorf("Expected capacity 3 and 2 evictions, got %d and %d", cache.Stats().Capacity(), cache.Stats().Evictions())
+	}
+
+	if err := cache.Resize(6); err != nil {
+		t.Fatalf("Resize failed: %v", err)
+	}
+	for i := 6; i <= 8; i++ {
+		_ = cache.Set(fmt.Sprintf("key%d", i), i, time.Hour)
+	}
+	if cache.Len() != 6 || len(evictedKeys) != 2 {
+		t.Errorf("Expected growing to keep all 6 entries without evictions, got %d entries and evictions %v", cache.Len(), evictedKeys)
+	}
+
+	if err := cache.Resize(0); err == nil {
+		t.Error("Expected error for non-positive capacity")
+	}
+}
+
+func TestResizeReentrantHook(t *testing.T) {
+	hooks := NewHooks()
+	cache, err := New(NewDefaultConfig().WithMaxEntries(10).WithHooks(hooks))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	// The hook calls back into the cache, which deadlocks if hooks run while
+	// Resize holds the cache lock
+	var lengths []int
+	hooks.AddOnEvict(func(_ context.Context, key string, _ any, _ EvictReason) {
+		_, _ = cache.Get(key)
+		lengths = append(lengths, cache.Len())
+	})
+
+	for i := 0; i < 10; i++ {
+		_ = cache.Set(fmt.Sprintf("key%d", i), i, time.Hour)
+	}
+
+	done := make(chan error)
+	go func() { done <- cache.Resize(5) }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Resize failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Resize deadlocked with a hook calling back into the cache")
+	}
+
+	// Hooks run once the resize is complete
+	if !reflect.DeepEqual(lengths, []int{5, 5, 5, 5, 5}) {
+		t.Errorf("Expected 5 hook calls each seeing 5 entries, got %v", lengths)
+	}
+}
+
+func TestResizeWithPinnedEntries(t *testing.T) {
+	cache, err := New(NewDefaultConfig().WithMaxEntries(3))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	for i := 1; i <= 3; i++ {
+		key := fmt.Sprintf("key%d", i)
+		_ = cache.Set(key, i, time.Hour)
+		_ = cache.Pin(key)
+	}
+	_ = cache.Unpin("key3")
+
+	if err := cache.Resize(1); !errors.Is(err, ErrAllPinned) {
+		t.Fatalf("Expected ErrAllPinned, got %v", err)
+	}
+	if cache.Len() != 3 || cache.Stats().Capacity() != 3 {
+		t.Errorf("Expected failed resize to change nothing, got %d entries and capacity %d", cache.Len(), cache.Stats().Capacity())
+	}
+
+	if err := cache.Resize(2); err != nil {
+		t.Fatalf("Resize failed: %v", err)
+	}
+	if cache.Has("key3") || !cache.Has("key1") || !cache.Has("key2") {
+		t.Error("Expected only the unpinned key3 to be evicted")
+	}
+}
+
+func TestEvictionCandidates(t *testing.T) {
+	testCases := []struct {
+		name     string
+		strategy eviction.EvictionType
+		touch    func(c *Cache)
+		expected []string
+	}{
+		{"LRU", eviction.LRU, func(c *Cache) { c.Get("key1") }, []string{"key2", "key3", "key1"}},
+		{"LFU", eviction.LFU, func(c *Cache) { c.Get("key1"); c.Get("key1"); c.Get("key2") }, []string{"key3", "key2", "key1"}},
+		{"FIFO", eviction.FIFO, func(c *Cache) { c.Get("key1") }, []string{"key1", "key2", "key3"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var evictedKeys []string
+			hooks := NewHooks()
+			hooks.AddOnEvict(func(_ context.Context, key string, _ any, _ EvictReason) {
+				evictedKeys = append(evictedKeys, key)
+			})
+
+			cache, err := New(NewDefaultConfig().
+				WithMaxEntries(3).
+				WithEvictionType(tc.strategy).
+				WithHooks(hooks))
+			if err != nil {
+				t.Fatalf("Failed to create cache: %v", err)
+			}
+			defer func() { _ = cache.Close() }()
+
+			for _, key := range []string{"key1", "key2", "key3"} {
+				_ = cache.Set(key, key, time.Hour)
+			}
+			tc.touch(cache)
+
+			// Previewing must not change the order
+			for range 2 {
+				if candidates := cache.EvictionCandidates(5); !reflect.DeepEqual(candidates, tc.expected) {
+					t.Fatalf("Expected candidates %v, got %v", tc.expected, candidates)
+				}
+			}
+			if candidates := cache.EvictionCandidates(0); len(candidates) != 0 {
+				t.Errorf("Expected no candidates for n=0, got %v", candidates)
+			}
+
+			_ = cache.Set("key4", "key4", time.Hour)
+			if len(evictedKeys) != 1 || evictedKeys[0] != tc.expected[0] {
+				t.Errorf("Expected Set to evict %s, got %v", tc.expected[0], evictedKeys)
+			}
+		})
+	}
+}
+
+func TestConcurrentSetEvictionHooksMatchValues(t *testing.T) {
+	const (
+		workers   = 8
+		perWorker = 1000
+	)
+
+	var mu sync.Mutex
+	var evictions, mismatches int
+	hooks := NewHooks()
+	hooks.AddOnEvict(func(_ context.Context, key string, value any, _ EvictReason) {
+		mu.Lock()
+		defer mu.Unlock()
+		evictions++
+		if value != "value-"+key {
+			mismatches++
+		}
+	})
+
+	cache, err := New(NewDefaultConfig().
+		WithMaxEntries(50).
+		WithEvictionType(eviction.LRU).
+		WithHooks(hooks))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range perWorker {
+				key := fmt.Sprintf("w%d-%d", w, i)
+				_ = cache.Set(key, "value-"+key, time.Hour)
+			}
+		}()
+	}
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if mismatches > 0 {
+		t.Errorf("Expected every OnEvict value to match its key, got %d mismatches", mismatches)
+	}
+	if evictions != workers*perWorker-50 {
+		t.Errorf("Expected %d evictions, got %d", workers*perWorker-50, evictions)
+	}
+}
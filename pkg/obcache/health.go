@@ -0,0 +1,82 @@
+package obcache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/metrics"
+	"github.com/1mb-dev/obcache-go/v2/pkg/store"
+)
+
+const (
+	// DefaultHealthCheckInterval is how long Healthy reuses the last probe result
+	DefaultHealthCheckInterval = 5 * time.Second
+
+	// DefaultHealthCheckTimeout bounds the probe Healthy runs when its cached result is stale
+	DefaultHealthCheckTimeout = 2 * time.Second
+)
+
+// healthState holds the result of the last backend probe
+type healthState struct {
+	mu        sync.Mutex
+	checkedAt time.Time
+	healthy   bool
+}
+
+// Ping probes the backend store and records the result for Healthy
+// Stores that do not implement store.HealthChecker are assumed to be healthy
+func (c *Cache) Ping(ctx context.Context) error {
+	c.health.mu.Lock()
+	defer c.health.mu.Unlock()
+	return c.probe(ctx)
+}
+
+// Healthy reports whether the backend store answered its last probe. The result is
+// reused for Config.HealthCheckInterval; after that the store is probed again
+// with a timeout of DefaultHealthCheckTimeout. Concurrent callers share one probe
+func (c *Cache) Healthy() bool {
+	c.health.mu.Lock()
+	defer c.health.mu.Unlock()
+
+	if !c.health.checkedAt.IsZero() && time.Since(c.health.checkedAt) < c.healthCheckInterval() {
+		return c.health.healthy
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultHealthCheckTimeout)
+	defer cancel()
+	_ = c.probe(ctx) // The result is recorded in c.health
+	return c.health.healthy
+}
+
+// probe pings the store, records the result and exports it as a gauge
+// Must be called with c.health.mu held
+func (c *Cache) probe(ctx context.Context) error {
+	var err error
+	if checker, ok := c.store.(store.HealthChecker); ok {
+		err = checker.Ping(ctx)
+	}
+
+	c.health.checkedAt = time.Now()
+	c.health.healthy = err == nil
+	if err != nil {
+		c.stats.incErrors(ErrorTypeBackendUnavailable)
+	}
+
+	if c.metricsExporter != nil {
+		value := 0.0
+		if c.health.healthy {
+			value = 1
+		}
+		_ = c.metricsExporter.SetGauge(metrics.DefaultMetricNames().CacheBackendHealthy, value, c.metricsLabels) //nolint:errcheck // Error handling done at higher level
+	}
+	return err
+}
+
+// healthCheckInterval returns the configured health check interval or the default
+func (c *Cache) healthCheckInterval() time.Duration {
+	if c.config.HealthCheckInterval > 0 {
+		return c.config.HealthCheckInterval
+	}
+	return DefaultHealthCheckInterval
+}
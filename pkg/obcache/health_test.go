@@ -0,0 +1,138 @@
+package obcache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/internal/eviction"
+	"github.com/1mb-dev/obcache-go/v2/internal/store/memory"
+	"github.com/1mb-dev/obcache-go/v2/pkg/metrics"
+	"github.com/1mb-dev/obcache-go/v2/pkg/store"
+)
+
+// flakyStore is a memory store whose Ping fails while err is set
+type flakyStore struct {
+	store.Store
+	mu    sync.Mutex
+	err   error
+	pings int
+}
+
+func (s *flakyStore) Ping(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pings++
+	return s.err
+}
+
+func (s *flakyStore) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+func newFlakyCache(t *testing.T, config *Config) (*Cache, *flakyStore) {
+	t.Helper()
+	memStore, err := memory.NewWithStrategy(eviction.Config{Type: eviction.LRU, Capacity: 10})
+	if err != nil {
+		t.Fatalf("Failed to create memory store: %v", err)
+	}
+	flaky := &flakyStore{Store: memStore}
+	config.WithCustomStore(flaky)
+
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	t.Cleanup(func() { _ = cache.Close() })
+	return cache, flaky
+}
+
+func TestCachePingMemoryStore(t *testing.T) {
+	cache, err := New(NewDefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	if err := cache.Ping(context.Background()); err != nil {
+		t.Errorf("Expected memory store Ping to succeed, got %v", err)
+	}
+	if !cache.Healthy() {
+		t.Error("Expected memory cache to be healthy")
+	}
+}
+
+func TestCacheHealthyCachesResult(t *testing.T) {
+	cache, flaky := newFlakyCache(t, NewDefaultConfig().WithHealthCheckInterval(time.Hour))
+
+	if !cache.Healthy() || !cache.Healthy() {
+		t.Fatal("Expected cache to be healthy")
+	}
+	if flaky.pings != 1 {
+		t.Errorf("Expected the second call to reuse the first probe, got %d pings", flaky.pings)
+	}
+
+	// The cached result hides the outage until the next probe
+	pingErr := errors.New("connection refused")
+	flaky.setErr(pingErr)
+	if !cache.Healthy() {
+		t.Error("Expected cached healthy result within the interval")
+	}
+
+	if err := cache.Ping(context.Background()); !errors.Is(err, pingErr) {
+		t.Fatalf("Expected Ping to return the store error, got %v", err)
+	}
+	if cache.Healthy() {
+		t.Error("Expected Healthy to report the failed Ping")
+	}
+}
+
+func TestCacheHealthyReprobesAfterInterval(t *testing.T) {
+	cache, flaky := newFlakyCache(t, NewDefaultConfig().WithHealthCheckInterval(10*time.Millisecond))
+
+	flaky.setErr(errors.New("connection refused"))
+	if cache.Healthy() {
+		t.Fatal("Expected cache to be unhealthy")
+	}
+
+	flaky.setErr(nil)
+	time.Sleep(20 * time.Millisecond)
+	if !cache.Healthy() {
+		t.Error("Expected a new probe to report recovery")
+	}
+	if flaky.pings != 2 {
+		t.Errorf("Expected 2 pings, got %d", flaky.pings)
+	}
+}
+
+func TestCacheHealthGauge(t *testing.T) {
+	mockExporter := NewMockExporter()
+	config := NewDefaultConfig().WithMetrics(&MetricsConfig{
+		Exporter:  mockExporter,
+		Enabled:   true,
+		CacheName: "health",
+	})
+	cache, flaky := newFlakyCache(t, config)
+
+	gauge := metrics.DefaultMetricNames().CacheBackendHealthy + mockExporter.labelsKey(metrics.Labels{"cache_name": "health"})
+	gaugeValue := func() float64 {
+		mockExporter.mu.RLock()
+		defer mockExporter.mu.RUnlock()
+		return mockExporter.gauges[gauge]
+	}
+
+	_ = cache.Ping(context.Background())
+	if gaugeValue() != 1 {
+		t.Errorf("Expected health gauge 1, got %v", gaugeValue())
+	}
+
+	flaky.setErr(errors.New("connection refused"))
+	_ = cache.Ping(context.Background())
+	if gaugeValue() != 0 {
+		t.Errorf("Expected health gauge 0, got %v", gaugeValue())
+	}
+}
@@ -0,0 +1,97 @@
+package obcache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultHitRateWindow is how far back RecentHitRate can look by default
+	DefaultHitRateWindow = time.Minute
+
+	// DefaultHitRateResolution is the default width of one hit rate bucket
+	DefaultHitRateResolution = time.Second
+)
+
+// hitWindow counts hits and misses in a ring of fixed-width time buckets
+// Buckets are recycled lazily by the lookups that land in them, so no timer is
+// needed. A lookup racing with the recycling of its bucket may be lost
+type hitWindow struct {
+	resolution int64 // bucket width in nanoseconds
+	buckets    []hitBucket
+	now        func() time.Time
+}
+
+// hitBucket holds the counts of one interval, numbered since the Unix epoch
+type hitBucket struct {
+	interval atomic.Int64
+	hits     atomic.Int64
+	misses   atomic.Int64
+}
+
+// newHitWindow creates a window of window/resolution buckets, rounding up
+// Non-positive arguments select DefaultHitRateWindow and DefaultHitRateResolution
+func newHitWindow(window, resolution time.Duration) *hitWindow {
+	if window <= 0 {
+		window = DefaultHitRateWindow
+	}
+	if resolution <= 0 {
+		resolution = DefaultHitRateResolution
+	}
+	resolution = min(resolution, window)
+
+	return &hitWindow{
+		resolution: int64(resolution),
+		buckets:    make([]hitBucket, (window+resolution-1)/resolution),
+		now:        time.Now,
+	}
+}
+
+// record counts a lookup in the current interval's bucket
+func (w *hitWindow) record(hit bool) {
+	interval := w.now().UnixNano() / w.resolution
+	b := &w.buckets[interval%int64(len(w.buckets))]
+	if last := b.interval.Load(); last != interval && b.interval.CompareAndSwap(last, interval) {
+		b.hits.Store(0)
+		b.misses.Store(0)
+	}
+
+	if hit {
+		b.hits.Add(1)
+	} else {
+		b.misses.Add(1)
+	}
+}
+
+// hitRate returns the percentage of hits among the lookups of the intervals
+// overlapping the last window, including the current partial interval
+func (w *hitWindow) hitRate(window time.Duration) float64 {
+	current := w.now().UnixNano() / w.resolution
+	intervals := int64(len(w.buckets))
+	if window > 0 {
+		intervals = min(intervals, max(1, (int64(window)+w.resolution-1)/w.resolution))
+	}
+
+	var hits, misses int64
+	for i := range w.buckets {
+		b := &w.buckets[i]
+		if interval := b.interval.Load(); interval > current-intervals && interval <= current {
+			hits += b.hits.Load()
+			misses += b.misses.Load()
+		}
+	}
+
+	if hits+misses == 0 {
+		return 0
+	}
+	return float64(hits) / float64(hits+misses) * 100
+}
+
+// reset forgets all counts
+func (w *hitWindow) reset() {
+	for i := range w.buckets {
+		w.buckets[i].interval.Store(0)
+		w.buckets[i].hits.Store(0)
+		w.buckets[i].misses.Store(0)
+	}
+}
@@ -2,13 +2,22 @@ package obcache
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"runtime/debug"
+	"slices"
 	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Hook defines a cache operation hook with optional priority and condition
 type Hook struct {
 	// Priority determines execution order (higher values execute first)
-	// Default: 0 (execution order not guaranteed for hooks with same priority)
+	// Default: 0 (hooks with the same priority execute in registration order)
 	Priority int
 
 	// Condition optionally filters hook execution
@@ -16,27 +25,249 @@ type Hook struct {
 	// If returns false, hook is skipped
 	Condition func(ctx context.Context, key string) bool
 
+	// ValueCondition optionally filters hooks that receive a value (OnHit,
+	// BeforeSet, OnSet, OnEvict and OnEvictInfo) by the value as well
+	// It is checked after Condition, and only if Condition passed
+	ValueCondition func(ctx context.Context, key string, value any) bool
+
+	// KeyPrefix optionally limits the hook to keys starting with it. Unlike a
+	// Condition, hooks are indexed by prefix, so other keys skip them without
+	// checking each one. Hooks without a key, such as OnClear, never match
+	KeyPrefix string
+
+	// Timeout optionally bounds how long the cache operation waits for the hook
+	// If zero, the hook runs on the caller's goroutine until it returns
+	Timeout time.Duration
+
+	// SampleRate optionally runs the hook for only this fraction of the events
+	// that pass its conditions, chosen at random
+	// Default: 0 (every event; so does any rate of 1 or more)
+	SampleRate float64
+
+	// RateLimit optionally runs the hook at most RateLimit times per RateLimitPer,
+	// allowing bursts of up to RateLimit. Both must be positive to take effect
+	RateLimit    int
+	RateLimitPer time.Duration
+
 	// Handler is the actual hook function
-	// Set exactly one of: OnHit, OnMiss, OnEvict, OnInvalidate
+	// Set exactly one of: OnHit, OnMiss, BeforeSet, OnSet, OnEvict, OnEvictInfo,
+	// OnInvalidate, OnClear, OnError, OnRefresh, OnCapacity
 	OnHit        func(ctx context.Context, key string, value any)
 	OnMiss       func(ctx context.Context, key string)
+	BeforeSet    func(ctx context.Context, key string, value any, ttl time.Duration) (newValue any, newTTL time.Duration, proceed bool, err error)
+	OnSet        func(ctx context.Context, key string, value any, ttl time.Duration)
 	OnEvict      func(ctx context.Context, key string, value any, reason EvictReason)
+	OnEvictInfo  func(ctx context.Context, key string, value any, reason EvictReason, info EntryInfo)
 	OnInvalidate func(ctx context.Context, key string)
+	OnClear      func(ctx context.Context, keysRemoved int)
+	OnError      func(ctx context.Context, op string, key string, err error)
+	OnRefresh    func(ctx context.Context, key string, err error, duration time.Duration)
+	OnCapacity   func(ctx context.Context, length, capacity int)
+
+	// id identifies the hook for removal
+	id uint64
+
+	// state holds the sampling and rate limiting state; nil if neither is set
+	state *hookState
+}
+
+// hookState decides whether a sampled or rate limited hook runs and counts the
+// events it skips. It is shared by the copies of a Hook in successive hookSets
+type hookState struct {
+	sampleRate float64
+	limiter    *tokenBucket // nil without a rate limit
+
+	skipped     atomic.Int64
+	listSkipped *atomic.Int64 // skips of every hook on the same list
+}
+
+// newHookState returns the state for hook on list, or nil if hook is neither
+// sampled nor rate limited
+func newHookState(hook Hook, list *hookList) *hookState {
+	sampled := hook.SampleRate > 0 && hook.SampleRate < 1
+	limited := hook.RateLimit > 0 && hook.RateLimitPer > 0
+	if !sampled && !limited {
+		return nil
+	}
+	state := &hookState{listSkipped: &list.skipped}
+	if sampled {
+		state.sampleRate = hook.SampleRate
+	}
+	if limited {
+		state.limiter = newTokenBucket(hook.RateLimit, hook.RateLimitPer)
+	}
+	return state
+}
+
+// admit reports whether the hook runs for this event, counting it if skipped
+func (s *hookState) admit() bool {
+	if (s.sampleRate == 0 || rand.Float64() < s.sampleRate) &&
+		(s.limiter == nil || s.limiter.take()) {
+		return true
+	}
+	s.skipped.Add(1)
+	s.listSkipped.Add(1)
+	return false
+}
+
+// tokenBucket allows up to burst events at once, refilled at rate per second
+type tokenBucket struct {
+	mu     sync.Mutex
+	tokens float64
+	burst  float64
+	rate   float64
+	last   time.Time
+}
+
+// newTokenBucket returns a full bucket allowing n events per interval
+func newTokenBucket(n int, per time.Duration) *tokenBucket {
+	return &tokenBucket{
+		tokens: float64(n),
+		burst:  float64(n),
+		rate:   float64(n) / per.Seconds(),
+		last:   time.Now(),
+	}
+}
+
+// take removes a token if one is available
+func (b *tokenBucket) take() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
 }
 
 // Hooks contains all registered cache event hooks
+// Hooks may be added and removed while caches invoke them
 type Hooks struct {
-	onHit        []Hook
-	onMiss       []Hook
-	onEvict      []Hook
-	onInvalidate []Hook
+	// The hook lists are replaced rather than modified, so invocations load them
+	// without locking; mu serializes the writers
+	mu           sync.Mutex
+	nextID       uint64
+	onHit        hookList
+	onMiss       hookList
+	onBeforeSet  hookList
+	onSet        hookList
+	onEvict      hookList
+	onEvictInfo  hookList
+	onInvalidate hookList
+	onClear      hookList
+	onError      hookList
+	onRefresh    hookList
+	onCapacity   hookList
+
+	// propagatePanics lets hook panics reach the cache's caller
+	propagatePanics bool
+	skipTiming      bool
+	panics          atomic.Int64
+	timeouts        atomic.Int64
+
+	// errorStats are the Stats of the caches using these hooks, which count
+	// recovered panics and timeouts as errors (guarded by mu)
+	errorStats map[*Stats]struct{}
+
+	// errs are the registrations rejected as invalid, reported by Err (guarded by mu)
+	errs []error
 }
 
+// ErrInvalidHook is reported by Hooks.Err, and returned by New, when a hook was
+// registered with an option it does not support
+var ErrInvalidHook = errors.New("invalid hook")
+
 // NewHooks creates a new Hooks instance
 func NewHooks() *Hooks {
 	return &Hooks{}
 }
 
+// lists returns the hook lists in a fixed order, for copying hooks between Hooks
+func (h *Hooks) lists() []*hookList {
+	return []*hookList{
+		&h.onHit, &h.onMiss, &h.onBeforeSet, &h.onSet, &h.onEvict, &h.onEvictInfo,
+		&h.onInvalidate, &h.onClear, &h.onError, &h.onRefresh, &h.onCapacity,
+	}
+}
+
+// Clone returns a copy of the registered hooks and settings, so a shared bundle
+// can be extended without changing it. Statistics and rate limits start afresh,
+// and handles returned by the original do not remove hooks from the copy
+func (h *Hooks) Clone() *Hooks {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	clone := &Hooks{
+		nextID:          h.nextID,
+		propagatePanics: h.propagatePanics,
+		skipTiming:      h.skipTiming,
+		errs:            slices.Clone(h.errs),
+	}
+	cloneLists := clone.lists()
+	for i, list := range h.lists() {
+		if set := list.Load(); set != nil {
+			hooks := slices.Clone(set.all)
+			for j := range hooks {
+				hooks[j].state = newHookState(hooks[j], cloneLists[i])
+			}
+			cloneLists[i].Store(newHookSet(hooks))
+		}
+	}
+	return clone
+}
+
+// CombineHooks returns new Hooks holding the hooks of every non-nil argument.
+// Each kind of hook still runs in priority order, whichever Hooks it came from;
+// hooks with the same priority run in argument order, then registration order.
+// Rate limits start afresh, independent of the arguments' hooks.
+// Settings such as WithPanicPropagation are taken from the first non-nil
+// argument, and invalid registrations reported by Err are kept from all of them.
+// The arguments are not changed
+func CombineHooks(hooks ...*Hooks) *Hooks {
+	combined := NewHooks()
+	combinedLists := combined.lists()
+	merged := make([][]Hook, len(combinedLists))
+	first := true
+	for _, h := range hooks {
+		if h == nil {
+			continue
+		}
+		if first {
+			combined.propagatePanics = h.propagatePanics
+			combined.skipTiming = h.skipTiming
+			first = false
+		}
+		for i, list := range h.lists() {
+			if set := list.Load(); set != nil {
+				merged[i] = append(merged[i], set.all...)
+			}
+		}
+		if err := h.Err(); err != nil {
+			combined.errs = append(combined.errs, err)
+		}
+	}
+
+	for i, list := range merged {
+		if len(list) == 0 {
+			continue
+		}
+		// Ids are only unique within one Hooks, so the combined hooks get new ones
+		for j := range list {
+			combined.nextID++
+			list[j].id = combined.nextID
+			list[j].state = newHookState(list[j], combinedLists[i])
+		}
+		sort.SliceStable(list, func(a, b int) bool {
+			return list[a].Priority > list[b].Priority
+		})
+		combinedLists[i].Store(newHookSet(list))
+	}
+	return combined
+}
+
 // EvictReason indicates why a cache entry was evicted
 type EvictReason int
 
@@ -49,6 +280,12 @@ const (
 
 	// EvictReasonCapacity indicates the entry was evicted due to capacity limits
 	EvictReasonCapacity
+
+	// EvictReasonManual indicates the entry was evicted by an explicit Evict call
+	EvictReasonManual
+
+	// evictReasonCount is the number of defined reasons
+	evictReasonCount
 )
 
 func (r EvictReason) String() string {
@@ -59,45 +296,423 @@ func (r EvictReason) String() string {
 		return "TTL"
 	case EvictReasonCapacity:
 		return "Capacity"
+	case EvictReasonManual:
+		return "Manual"
 	default:
 		return "Unknown"
 	}
 }
 
 // AddOnHit registers a hook that executes on cache hits
-func (h *Hooks) AddOnHit(fn func(ctx context.Context, key string, value any), opts ...HookOption) {
-	hook := Hook{OnHit: fn}
-	for _, opt := range opts {
-		opt(&hook)
-	}
-	h.onHit = append(h.onHit, hook)
+func (h *Hooks) AddOnHit(fn func(ctx context.Context, key string, value any), opts ...HookOption) HookHandle {
+	return h.add(&h.onHit, Hook{OnHit: fn}, opts)
 }
 
 // AddOnMiss registers a hook that executes on cache misses
-func (h *Hooks) AddOnMiss(fn func(ctx context.Context, key string), opts ...HookOption) {
-	hook := Hook{OnMiss: fn}
-	for _, opt := range opts {
-		opt(&hook)
-	}
-	h.onMiss = append(h.onMiss, hook)
+func (h *Hooks) AddOnMiss(fn func(ctx context.Context, key string), opts ...HookOption) HookHandle {
+	return h.add(&h.onMiss, Hook{OnMiss: fn}, opts)
+}
+
+// AddBeforeSet registers a hook that executes before a value is encoded and
+// written, e.g. to enforce caching policies. It returns the value and TTL to
+// store, which later BeforeSet hooks receive in turn. Returning proceed=false
+// skips the write without error; returning an error skips it and fails the
+// write with a *SetVetoedError. A ttl of 0 selects DefaultTTL as for Set.
+// A panicking BeforeSet hook vetoes the write. WithTimeout does not apply to
+// BeforeSet hooks, since the write needs their result
+func (h *Hooks) AddBeforeSet(fn func(ctx context.Context, key string, value any, ttl time.Duration) (newValue any, newTTL time.Duration, proceed bool, err error), opts ...HookOption) HookHandle {
+	return h.add(&h.onBeforeSet, Hook{BeforeSet: fn}, opts)
+}
+
+// SetVetoedError is returned by writes a BeforeSet hook rejected with an error
+type SetVetoedError struct {
+	Key string
+	Err error
+}
+
+func (e *SetVetoedError) Error() string {
+	return fmt.Sprintf("set of %q vetoed by hook: %v", e.Key, e.Err)
+}
+
+func (e *SetVetoedError) Unwrap() error {
+	return e.Err
+}
+
+// AddOnSet registers a hook that executes after the store accepts a write, with
+// the value as passed to Set (before encoding and compression) and the TTL it
+// was stored with. Writes that evict other entries fire their OnEvict hooks first
+func (h *Hooks) AddOnSet(fn func(ctx context.Context, key string, value any, ttl time.Duration), opts ...HookOption) HookHandle {
+	return h.add(&h.onSet, Hook{OnSet: fn}, opts)
 }
 
 // AddOnEvict registers a hook that executes when entries are evicted
-func (h *Hooks) AddOnEvict(fn func(ctx context.Context, key string, value any, reason EvictReason), opts ...HookOption) {
-	hook := Hook{OnEvict: fn}
-	for _, opt := range opts {
-		opt(&hook)
-	}
-	h.onEvict = append(h.onEvict, hook)
+func (h *Hooks) AddOnEvict(fn func(ctx context.Context, key string, value any, reason EvictReason), opts ...HookOption) HookHandle {
+	return h.add(&h.onEvict, Hook{OnEvict: fn}, opts)
+}
+
+// AddOnEvictInfo registers a hook that executes when entries are evicted, like
+// AddOnEvict, and also receives the entry's metadata such as its age, remaining
+// TTL, sizes and access count. Stores that cannot report the evicted entry, e.g.
+// Redis keyspace notifications, pass a zero EntryInfo
+func (h *Hooks) AddOnEvictInfo(fn func(ctx context.Context, key string, value any, reason EvictReason, info EntryInfo), opts ...HookOption) HookHandle {
+	return h.add(&h.onEvictInfo, Hook{OnEvictInfo: fn}, opts)
 }
 
 // AddOnInvalidate registers a hook that executes when entries are invalidated
-func (h *Hooks) AddOnInvalidate(fn func(ctx context.Context, key string), opts ...HookOption) {
-	hook := Hook{OnInvalidate: fn}
+func (h *Hooks) AddOnInvalidate(fn func(ctx context.Context, key string), opts ...HookOption) HookHandle {
+	return h.add(&h.onInvalidate, Hook{OnInvalidate: fn}, opts)
+}
+
+// Operations reported to OnError hooks
+const (
+	// ErrorOpGet marks a stored value that could not be read, e.g. because it is
+	// corrupted or was written with an unknown codec. The entry is deleted and the
+	// read reported as a miss
+	ErrorOpGet = "get"
+
+	// ErrorOpSet marks a value that could not be encoded or written to the store
+	ErrorOpSet = "set"
+
+	// ErrorOpHook marks a hook that panicked, with err a *HookPanicError, or that
+	// did not return within its timeout, with err wrapping context.DeadlineExceeded
+	ErrorOpHook = "hook"
+
+	// ErrorOpExport marks a failed metrics export; the key is empty and at most one
+	// failure is reported per exportErrorInterval
+	ErrorOpExport = "export"
+)
+
+// AddOnClear registers a hook that executes once per Clear, ClearContext,
+// ClearExpired or ClearWhere call with the number of entries removed. Use
+// IsPartialClear to tell the last two apart. Conditions receive an empty key
+func (h *Hooks) AddOnClear(fn func(ctx context.Context, keysRemoved int), opts ...HookOption) HookHandle {
+	return h.add(&h.onClear, Hook{OnClear: fn}, opts)
+}
+
+// AddOnError registers a hook that executes when the cache hits an error it
+// would otherwise swallow or only return to the caller. op is one of the
+// ErrorOp constants and err wraps the underlying error
+func (h *Hooks) AddOnError(fn func(ctx context.Context, op string, key string, err error), opts ...HookOption) HookHandle {
+	return h.add(&h.onError, Hook{OnError: fn}, opts)
+}
+
+// AddOnRefresh registers a hook that executes after Cache.Refresh reloads a key
+// err is nil if the new value was stored; duration covers loading and storing it
+func (h *Hooks) AddOnRefresh(fn func(ctx context.Context, key string, err error, duration time.Duration), opts ...HookOption) HookHandle {
+	return h.add(&h.onRefresh, Hook{OnRefresh: fn}, opts)
+}
+
+// AddOnCapacity registers a hook that executes when the cache fills up: after a
+// write that caused a capacity eviction, and after one that took the entry count
+// past one of Config.CapacityWatermarks. Each cause fires at most once per
+// Config.CapacityAlertInterval. Like OnClear, it has no key, so a KeyPrefix never
+// matches
+func (h *Hooks) AddOnCapacity(fn func(ctx context.Context, length, capacity int), opts ...HookOption) HookHandle {
+	return h.add(&h.onCapacity, Hook{OnCapacity: fn}, opts)
+}
+
+// WithPanicPropagation sets whether a panicking hook panics the cache operation
+// that fired it. By default panics are recovered, counted in Panics and in the
+// hook_panic error count of Stats, and passed to OnError hooks as a
+// *HookPanicError, and the remaining hooks still run. Call it before the hooks
+// are passed to New
+func (h *Hooks) WithPanicPropagation(propagate bool) *Hooks {
+	h.propagatePanics = propagate
+	return h
+}
+
+// WithTiming sets whether hook runs are timed for Stats and the hook duration
+// metric. Disabling it saves two clock reads per run; runs are still counted.
+// Call it before the hooks are passed to New
+// Default: enabled
+func (h *Hooks) WithTiming(enabled bool) *Hooks {
+	h.skipTiming = !enabled
+	return h
+}
+
+// HookStats holds the execution statistics of one kind of hook
+type HookStats struct {
+	// Calls is the number of cache events that ran at least one hook of this kind
+	Calls int64
+
+	// Total is the time spent running them, including Condition checks
+	Total time.Duration
+
+	// Last is the time the most recent run took
+	Last time.Duration
+
+	// Skipped is the number of times hooks of this kind passed their conditions
+	// but were not run because of WithSampleRate or WithRateLimit
+	Skipped int64
+}
+
+// Average returns the mean time a run took
+func (s HookStats) Average() time.Duration {
+	if s.Calls == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Calls)
+}
+
+// Stats returns the execution statistics of each kind of hook, keyed by event
+// name, e.g. "OnHit". Hooks shared by several caches report their combined runs.
+// Durations are zero when timing is disabled with WithTiming
+func (h *Hooks) Stats() map[string]HookStats {
+	return map[string]HookStats{
+		"OnHit":        h.onHit.stats(),
+		"OnMiss":       h.onMiss.stats(),
+		"BeforeSet":    h.onBeforeSet.stats(),
+		"OnSet":        h.onSet.stats(),
+		"OnEvict":      h.onEvict.stats(),
+		"OnEvictInfo":  h.onEvictInfo.stats(),
+		"OnInvalidate": h.onInvalidate.stats(),
+		"OnClear":      h.onClear.stats(),
+		"OnError":      h.onError.stats(),
+		"OnRefresh":    h.onRefresh.stats(),
+		"OnCapacity":   h.onCapacity.stats(),
+	}
+}
+
+// start returns the time a run of hooks began, or the zero time if timing is disabled
+func (h *Hooks) start() time.Time {
+	if h.skipTiming {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// finish counts a run of the hooks on list that began at start and returns how
+// long it took, or 0 if timing is disabled
+func (h *Hooks) finish(list *hookList, start time.Time) time.Duration {
+	list.calls.Add(1)
+	if h.skipTiming {
+		return 0
+	}
+	d := time.Since(start)
+	list.total.Add(int64(d))
+	list.last.Store(int64(d))
+	return d
+}
+
+// Err returns the errors of registrations that were rejected as invalid, each
+// wrapping ErrInvalidHook, or nil if there were none. New returns it too, so
+// invalid hooks registered before the cache is created fail its construction
+func (h *Hooks) Err() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return errors.Join(h.errs...)
+}
+
+// Panics returns the number of hook panics recovered
+func (h *Hooks) Panics() int64 {
+	return h.panics.Load()
+}
+
+// Timeouts returns the number of hooks that did not return within their timeout
+func (h *Hooks) Timeouts() int64 {
+	return h.timeouts.Load()
+}
+
+// attachStats makes s count the errors of these hooks until detachStats
+func (h *Hooks) attachStats(s *Stats) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.errorStats == nil {
+		h.errorStats = make(map[*Stats]struct{})
+	}
+	h.errorStats[s] = struct{}{}
+}
+
+// detachStats stops s counting the errors of these hooks
+func (h *Hooks) detachStats(s *Stats) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.errorStats, s)
+}
+
+// countError counts an error of errorType in the Stats of every attached cache
+func (h *Hooks) countError(errorType ErrorType) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.errorStats {
+		s.incErrors(errorType)
+	}
+}
+
+// HookPanicError reports a panic recovered from a hook
+type HookPanicError struct {
+	// Event is the kind of hook that panicked, e.g. "OnHit"
+	Event string
+
+	// Value is the value the hook panicked with
+	Value any
+
+	// Stack is the stack trace of the panicking goroutine
+	Stack []byte
+}
+
+func (e *HookPanicError) Error() string {
+	return fmt.Sprintf("%s hook panicked: %v", e.Event, e.Value)
+}
+
+// hookList holds the hooks registered for one event and how long they ran
+type hookList struct {
+	set atomic.Pointer[hookSet]
+
+	calls   atomic.Int64
+	total   atomic.Int64 // nanoseconds
+	last    atomic.Int64 // nanoseconds
+	skipped atomic.Int64
+}
+
+// Load returns the current hooks, or nil if none were ever added
+func (l *hookList) Load() *hookSet {
+	return l.set.Load()
+}
+
+// Store replaces the hooks
+func (l *hookList) Store(set *hookSet) {
+	l.set.Store(set)
+}
+
+// stats returns the execution statistics of the list
+func (l *hookList) stats() HookStats {
+	return HookStats{
+		Calls:   l.calls.Load(),
+		Total:   time.Duration(l.total.Load()),
+		Last:    time.Duration(l.last.Load()),
+		Skipped: l.skipped.Load(),
+	}
+}
+
+// hookSet is an immutable index of hooks by key prefix
+type hookSet struct {
+	// all holds every hook, sorted by priority (highest first)
+	all []Hook
+
+	// unscoped holds the hooks without a KeyPrefix, in priority order
+	unscoped []Hook
+
+	// scoped holds, for each registered prefix, the unscoped hooks and the hooks
+	// of that prefix and of the registered prefixes it starts with, in priority order
+	scoped map[string][]Hook
+
+	// lengths holds the distinct prefix lengths, longest first
+	lengths []int
+}
+
+// newHookSet indexes hooks, which must be sorted by priority
+func newHookSet(hooks []Hook) *hookSet {
+	set := &hookSet{all: hooks}
+	prefixes := make(map[string]bool)
+	for _, hook := range hooks {
+		if hook.KeyPrefix == "" {
+			set.unscoped = append(set.unscoped, hook)
+		} else {
+			prefixes[hook.KeyPrefix] = true
+		}
+	}
+	if len(prefixes) == 0 {
+		return set
+	}
+
+	set.scoped = make(map[string][]Hook, len(prefixes))
+	for prefix := range prefixes {
+		var matching []Hook
+		for _, hook := range hooks {
+			if strings.HasPrefix(prefix, hook.KeyPrefix) {
+				matching = append(matching, hook)
+			}
+		}
+		set.scoped[prefix] = matching
+		if !slices.Contains(set.lengths, len(prefix)) {
+			set.lengths = append(set.lengths, len(prefix))
+		}
+	}
+	slices.SortFunc(set.lengths, func(a, b int) int { return b - a })
+	return set
+}
+
+// forKey returns the hooks whose prefix matches key, in priority order
+// Every registered prefix of key is a prefix of the longest one, so the
+// longest match selects them all with one map lookup per prefix length
+func (s *hookSet) forKey(key string) []Hook {
+	for _, n := range s.lengths {
+		if n > len(key) {
+			continue
+		}
+		if hooks, ok := s.scoped[key[:n]]; ok {
+			return hooks
+		}
+	}
+	return s.unscoped
+}
+
+// HookHandle identifies a registered hook so it can be removed
+type HookHandle struct {
+	hooks *Hooks
+	list  *hookList
+	id    uint64
+	state *hookState
+}
+
+// Remove unregisters the hook. Invocations already running may still call it;
+// later ones do not. Removing a hook twice, or through the zero HookHandle, does nothing
+func (hh HookHandle) Remove() {
+	if hh.hooks == nil {
+		return
+	}
+	h := hh.hooks
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	set := hh.list.Load()
+	if set == nil {
+		return
+	}
+	for i, hook := range set.all {
+		if hook.id == hh.id {
+			hh.list.Store(newHookSet(slices.Delete(slices.Clone(set.all), i, i+1)))
+			return
+		}
+	}
+}
+
+// Skipped returns the number of events the hook passed its conditions for but
+// skipped because of WithSampleRate or WithRateLimit
+func (hh HookHandle) Skipped() int64 {
+	if hh.state == nil {
+		return 0
+	}
+	return hh.state.skipped.Load()
+}
+
+// add registers hook on list
+// A hook with a ValueCondition that receives no value is not registered: the
+// error is kept for Err and the zero HookHandle returned
+func (h *Hooks) add(list *hookList, hook Hook, opts []HookOption) HookHandle {
 	for _, opt := range opts {
 		opt(&hook)
 	}
-	h.onInvalidate = append(h.onInvalidate, hook)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if hook.ValueCondition != nil && !hook.receivesValue() {
+		h.errs = append(h.errs, fmt.Errorf("%w: WithValueCondition requires an OnHit, BeforeSet, OnSet, OnEvict or OnEvictInfo hook", ErrInvalidHook))
+		return HookHandle{}
+	}
+	h.nextID++
+	hook.id = h.nextID
+	hook.state = newHookState(hook, list)
+	var all []Hook
+	if current := list.Load(); current != nil {
+		all = current.all
+	}
+	// The list is kept sorted, so the hook goes after those of equal or higher priority
+	i := sort.Search(len(all), func(i int) bool { return all[i].Priority < hook.Priority })
+	hooks := make([]Hook, 0, len(all)+1)
+	hooks = append(append(append(hooks, all[:i]...), hook), all[i:]...)
+	list.Store(newHookSet(hooks))
+	return HookHandle{hooks: h, list: list, id: hook.id, state: hook.state}
 }
 
 // HookOption configures a hook
@@ -110,6 +725,27 @@ func WithPriority(priority int) HookOption {
 	}
 }
 
+// WithTimeout runs the hook on its own goroutine with a context cancelled after d
+// If the hook has not returned by then, the cache operation proceeds without it:
+// the timeout is counted in Timeouts and in the hook_timeout error count of
+// Stats, and passed to OnError hooks as an error wrapping
+// context.DeadlineExceeded. The hook is abandoned, not stopped, so it should
+// return once its context is done. A panic it raises after the timeout is
+// recovered even if panics propagate
+func WithTimeout(d time.Duration) HookOption {
+	return func(h *Hook) {
+		h.Timeout = d
+	}
+}
+
+// WithKeyPrefix limits the hook to keys starting with prefix
+// Scoped hooks are indexed, so keys outside the prefix do not iterate them
+func WithKeyPrefix(prefix string) HookOption {
+	return func(h *Hook) {
+		h.KeyPrefix = prefix
+	}
+}
+
 // WithCondition sets a condition that must be true for the hook to execute
 func WithCondition(condition func(ctx context.Context, key string) bool) HookOption {
 	return func(h *Hook) {
@@ -117,65 +753,334 @@ func WithCondition(condition func(ctx context.Context, key string) bool) HookOpt
 	}
 }
 
-// invokeOnHitWithCtx calls all OnHit hooks with context
-func (h *Hooks) invokeOnHitWithCtx(ctx context.Context, key string, value any, _ []any) {
-	h.invokeHooks(h.onHit, func(hook Hook) {
-		if hook.Condition == nil || hook.Condition(ctx, key) {
+// WithSampleRate runs the hook for only a random fraction of the events that pass
+// its conditions, e.g. 0.01 for one in a hundred. Skipped events are counted in
+// HookStats.Skipped and HookHandle.Skipped. A sampled BeforeSet hook lets the
+// writes it skips through unchanged
+func WithSampleRate(fraction float64) HookOption {
+	return func(h *Hook) {
+		h.SampleRate = fraction
+	}
+}
+
+// WithRateLimit runs the hook at most n times per interval, with bursts of up to
+// n; further events that pass its conditions are skipped and counted as with
+// WithSampleRate. Each hook has its own limit
+func WithRateLimit(n int, per time.Duration) HookOption {
+	return func(h *Hook) {
+		h.RateLimit = n
+		h.RateLimitPer = per
+	}
+}
+
+// WithValueCondition sets a condition on the key and value that must be true for
+// the hook to execute, checked after any WithCondition. Only hooks that receive a
+// value accept it; any other hook registered with it is rejected and reported
+// by Hooks.Err and New as an error wrapping ErrInvalidHook
+func WithValueCondition(condition func(ctx context.Context, key string, value any) bool) HookOption {
+	return func(h *Hook) {
+		h.ValueCondition = condition
+	}
+}
+
+// receivesValue reports whether the hook's handler is passed a value
+func (hook *Hook) receivesValue() bool {
+	return hook.OnHit != nil || hook.BeforeSet != nil || hook.OnSet != nil ||
+		hook.OnEvict != nil || hook.OnEvictInfo != nil
+}
+
+// matches reports whether the hook runs for key and value: its conditions must
+// pass, the key condition first since it is usually cheaper, and then its
+// sampling and rate limit
+func (hook *Hook) matches(ctx context.Context, key string, value any) bool {
+	if hook.Condition != nil && !hook.Condition(ctx, key) {
+		return false
+	}
+	if hook.ValueCondition != nil && !hook.ValueCondition(ctx, key, value) {
+		return false
+	}
+	return hook.state == nil || hook.state.admit()
+}
+
+// matchesKey is matches for hooks that receive no value
+func (hook *Hook) matchesKey(ctx context.Context, key string) bool {
+	if hook.Condition != nil && !hook.Condition(ctx, key) {
+		return false
+	}
+	return hook.state == nil || hook.state.admit()
+}
+
+// invokeOnHitWithCtx calls all OnHit hooks with context and returns how long they took
+func (h *Hooks) invokeOnHitWithCtx(ctx context.Context, key string, value any, _ []any) time.Duration {
+	return h.invokeHooks(ctx, "OnHit", key, &h.onHit, func(ctx context.Context, hook Hook) {
+		if hook.matches(ctx, key, value) {
 			hook.OnHit(ctx, key, value)
 		}
 	})
 }
 
-// invokeOnMissWithCtx calls all OnMiss hooks with context
-func (h *Hooks) invokeOnMissWithCtx(ctx context.Context, key string, _ []any) {
-	h.invokeHooks(h.onMiss, func(hook Hook) {
-		if hook.Condition == nil || hook.Condition(ctx, key) {
+// invokeOnMissWithCtx calls all OnMiss hooks with context and returns how long they took
+func (h *Hooks) invokeOnMissWithCtx(ctx context.Context, key string, _ []any) time.Duration {
+	return h.invokeHooks(ctx, "OnMiss", key, &h.onMiss, func(ctx context.Context, hook Hook) {
+		if hook.matchesKey(ctx, key) {
 			hook.OnMiss(ctx, key)
 		}
 	})
 }
 
-// invokeOnEvict calls all OnEvict hooks
-func (h *Hooks) invokeOnEvict(key string, value any, reason EvictReason) {
-	h.invokeOnEvictWithCtx(context.Background(), key, value, reason, nil)
+// invokeBeforeSetWithCtx passes a write through all BeforeSet hooks, returning
+// the value and TTL to store, or proceed=false if a hook vetoed it, and how long
+// the hooks took
+func (h *Hooks) invokeBeforeSetWithCtx(ctx context.Context, key string, value any, ttl time.Duration) (newValue any, newTTL time.Duration, proceed bool, elapsed time.Duration, err error) {
+	set := h.onBeforeSet.Load()
+	if set == nil {
+		return value, ttl, true, 0, nil
+	}
+	hooks := set.forKey(key)
+	if len(hooks) == 0 {
+		return value, ttl, true, 0, nil
+	}
+
+	start := h.start()
+	defer func() {
+		elapsed = h.finish(&h.onBeforeSet, start)
+	}()
+	for _, hook := range hooks {
+		newValue, newTTL, proceed, err := h.runBeforeSet(ctx, key, value, ttl, hook)
+		if err != nil {
+			return nil, 0, false, 0, &SetVetoedError{Key: key, Err: err}
+		}
+		if !proceed {
+			return nil, 0, false, 0, nil
+		}
+		value, ttl = newValue, newTTL
+	}
+	return value, ttl, true, 0, nil
+}
+
+// runBeforeSet executes a single BeforeSet hook; a recovered panic is returned as
+// a *HookPanicError so the write fails closed
+func (h *Hooks) runBeforeSet(ctx context.Context, key string, value any, ttl time.Duration, hook Hook) (newValue any, newTTL time.Duration, proceed bool, err error) {
+	if !h.propagatePanics {
+		defer func() {
+			if r := recover(); r != nil {
+				stack := debug.Stack()
+				h.recovered(ctx, "BeforeSet", key, r, stack)
+				newValue, newTTL, proceed, err = nil, 0, false, &HookPanicError{Event: "BeforeSet", Value: r, Stack: stack}
+			}
+		}()
+	}
+	if !hook.matches(ctx, key, value) {
+		return value, ttl, true, nil
+	}
+	return hook.BeforeSet(ctx, key, value, ttl)
+}
+
+// invokeOnSetWithCtx calls all OnSet hooks with context and returns how long they took
+func (h *Hooks) invokeOnSetWithCtx(ctx context.Context, key string, value any, ttl time.Duration) time.Duration {
+	return h.invokeHooks(ctx, "OnSet", key, &h.onSet, func(ctx context.Context, hook Hook) {
+		if hook.matches(ctx, key, value) {
+			hook.OnSet(ctx, key, value, ttl)
+		}
+	})
+}
+
+// invokeOnEvict calls all OnEvict and OnEvictInfo hooks
+func (h *Hooks) invokeOnEvict(key string, value any, reason EvictReason, info EntryInfo) (evict, evictInfo time.Duration) {
+	return h.invokeOnEvictWithCtx(context.Background(), key, value, reason, info)
 }
 
-// invokeOnEvictWithCtx calls all OnEvict hooks with context
-func (h *Hooks) invokeOnEvictWithCtx(ctx context.Context, key string, value any, reason EvictReason, _ []any) {
-	h.invokeHooks(h.onEvict, func(hook Hook) {
-		if hook.Condition == nil || hook.Condition(ctx, key) {
+// invokeOnEvictWithCtx calls all OnEvict and OnEvictInfo hooks with context and
+// returns how long each kind took
+func (h *Hooks) invokeOnEvictWithCtx(ctx context.Context, key string, value any, reason EvictReason, info EntryInfo) (evict, evictInfo time.Duration) {
+	evict = h.invokeHooks(ctx, "OnEvict", key, &h.onEvict, func(ctx context.Context, hook Hook) {
+		if hook.matches(ctx, key, value) {
 			hook.OnEvict(ctx, key, value, reason)
 		}
 	})
+	evictInfo = h.invokeHooks(ctx, "OnEvictInfo", key, &h.onEvictInfo, func(ctx context.Context, hook Hook) {
+		if hook.matches(ctx, key, value) {
+			hook.OnEvictInfo(ctx, key, value, reason, info)
+		}
+	})
+	return evict, evictInfo
 }
 
-// invokeOnInvalidateWithCtx calls all OnInvalidate hooks with context
-func (h *Hooks) invokeOnInvalidateWithCtx(ctx context.Context, key string, _ []any) {
-	h.invokeHooks(h.onInvalidate, func(hook Hook) {
-		if hook.Condition == nil || hook.Condition(ctx, key) {
+// invokeOnInvalidateWithCtx calls all OnInvalidate hooks with context and returns how long they took
+func (h *Hooks) invokeOnInvalidateWithCtx(ctx context.Context, key string, _ []any) time.Duration {
+	return h.invokeHooks(ctx, "OnInvalidate", key, &h.onInvalidate, func(ctx context.Context, hook Hook) {
+		if hook.matchesKey(ctx, key) {
 			hook.OnInvalidate(ctx, key)
 		}
 	})
 }
 
-// invokeHooks executes hooks in priority order (highest priority first)
-func (h *Hooks) invokeHooks(hooks []Hook, execute func(Hook)) {
+// invokeOnClearWithCtx calls all OnClear hooks with context and returns how long they took
+func (h *Hooks) invokeOnClearWithCtx(ctx context.Context, keysRemoved int) time.Duration {
+	return h.invokeHooks(ctx, "OnClear", "", &h.onClear, func(ctx context.Context, hook Hook) {
+		if hook.matchesKey(ctx, "") {
+			hook.OnClear(ctx, keysRemoved)
+		}
+	})
+}
+
+// invokeOnErrorWithCtx calls all OnError hooks with context and returns how long they took
+func (h *Hooks) invokeOnErrorWithCtx(ctx context.Context, op string, key string, err error) time.Duration {
+	return h.invokeHooks(ctx, "OnError", key, &h.onError, func(ctx context.Context, hook Hook) {
+		if hook.matchesKey(ctx, key) {
+			hook.OnError(ctx, op, key, err)
+		}
+	})
+}
+
+// invokeOnRefreshWithCtx calls all OnRefresh hooks with context and returns how long they took
+func (h *Hooks) invokeOnRefreshWithCtx(ctx context.Context, key string, err error, duration time.Duration) time.Duration {
+	return h.invokeHooks(ctx, "OnRefresh", key, &h.onRefresh, func(ctx context.Context, hook Hook) {
+		if hook.matchesKey(ctx, key) {
+			hook.OnRefresh(ctx, key, err, duration)
+		}
+	})
+}
+
+// invokeOnCapacityWithCtx calls all OnCapacity hooks with context and returns how long they took
+func (h *Hooks) invokeOnCapacityWithCtx(ctx context.Context, length, capacity int) time.Duration {
+	return h.invokeHooks(ctx, "OnCapacity", "", &h.onCapacity, func(ctx context.Context, hook Hook) {
+		if hook.matchesKey(ctx, "") {
+			hook.OnCapacity(ctx, length, capacity)
+		}
+	})
+}
+
+// remoteInvalidationKey marks the context of OnInvalidate hooks fired for keys
+// the backend reported changed
+type remoteInvalidationKey struct{}
+
+// IsRemoteInvalidation reports whether an OnInvalidate hook was fired because the
+// backend reported the key changed, e.g. by Redis client tracking, rather than by
+// a call on this Cache
+func IsRemoteInvalidation(ctx context.Context) bool {
+	remote, _ := ctx.Value(remoteInvalidationKey{}).(bool)
+	return remote
+}
+
+// partialClearKey marks the context of OnClear hooks fired for ClearExpired and ClearWhere
+type partialClearKey struct{}
+
+// IsPartialClear reports whether an OnClear hook was fired by ClearExpired or
+// ClearWhere, which remove only some entries, rather than by Clear
+func IsPartialClear(ctx context.Context) bool {
+	partial, _ := ctx.Value(partialClearKey{}).(bool)
+	return partial
+}
+
+// invokeHooks executes the hooks on list in priority order (highest priority first)
+// and returns how long they took
+func (h *Hooks) invokeHooks(ctx context.Context, event string, key string, list *hookList, execute func(context.Context, Hook)) time.Duration {
+	set := list.Load()
+	if set == nil {
+		return 0
+	}
+	hooks := set.forKey(key)
 	if len(hooks) == 0 {
+		return 0
+	}
+
+	start := h.start()
+	for _, hook := range hooks {
+		h.runHook(ctx, event, key, hook, execute)
+	}
+	return h.finish(list, start)
+}
+
+// runHook executes a single hook, recovering its panic unless panics propagate
+func (h *Hooks) runHook(ctx context.Context, event string, key string, hook Hook, execute func(context.Context, Hook)) {
+	if hook.Timeout > 0 {
+		h.runHookWithTimeout(ctx, event, key, hook, execute)
 		return
 	}
+	if !h.propagatePanics {
+		defer func() {
+			if r := recover(); r != nil {
+				h.recovered(ctx, event, key, r, debug.Stack())
+			}
+		}()
+	}
+	execute(ctx, hook)
+}
 
-	// Sort by priority (highest first) if needed
-	if len(hooks) > 1 {
-		sorted := make([]Hook, len(hooks))
-		copy(sorted, hooks)
-		sort.Slice(sorted, func(i, j int) bool {
-			return sorted[i].Priority > sorted[j].Priority
-		})
-		hooks = sorted
+// hookPanic carries a panic out of a hook's goroutine
+type hookPanic struct {
+	value any
+	stack []byte
+}
+
+// States of a hook run with a timeout
+const (
+	hookRunning int32 = iota
+	hookReturned
+	hookAbandoned
+)
+
+// runHookWithTimeout executes a hook on its own goroutine and waits for it at
+// most hook.Timeout. Whichever of the hook and the timer settles state first
+// decides who handles a panic
+func (h *Hooks) runHookWithTimeout(ctx context.Context, event string, key string, hook Hook, execute func(context.Context, Hook)) {
+	hookCtx, cancel := context.WithTimeout(ctx, hook.Timeout)
+	var state atomic.Int32
+	done := make(chan *hookPanic, 1)
+
+	go func() {
+		defer cancel()
+		var p *hookPanic
+		defer func() {
+			if state.CompareAndSwap(hookRunning, hookReturned) {
+				done <- p
+			} else if p != nil {
+				h.recovered(ctx, event, key, p.value, p.stack)
+			}
+		}()
+		defer func() {
+			if r := recover(); r != nil {
+				p = &hookPanic{value: r, stack: debug.Stack()}
+			}
+		}()
+		execute(hookCtx, hook)
+	}()
+
+	timer := time.NewTimer(hook.Timeout)
+	defer timer.Stop()
+
+	var p *hookPanic
+	select {
+	case p = <-done:
+	case <-timer.C:
+		if state.CompareAndSwap(hookRunning, hookAbandoned) {
+			h.timeouts.Add(1)
+			h.countError(ErrorTypeHookTimeout)
+			if event != "OnError" {
+				err := fmt.Errorf("%s hook did not return within %v: %w", event, hook.Timeout, context.DeadlineExceeded)
+				h.invokeOnErrorWithCtx(ctx, ErrorOpHook, key, err)
+			}
+			return
+		}
+		p = <-done // The hook returned as the timer fired
 	}
 
-	// Execute hooks
-	for _, hook := range hooks {
-		execute(hook)
+	if p == nil {
+		return
+	}
+	if h.propagatePanics {
+		panic(p.value)
+	}
+	h.recovered(ctx, event, key, p.value, p.stack)
+}
+
+// recovered counts a panic recovered from a hook and passes it to OnError hooks
+// A panicking OnError hook is only counted, so it cannot recurse
+func (h *Hooks) recovered(ctx context.Context, event string, key string, value any, stack []byte) {
+	h.panics.Add(1)
+	h.countError(ErrorTypeHookPanic)
+	if event != "OnError" {
+		h.invokeOnErrorWithCtx(ctx, ErrorOpHook, key, &HookPanicError{Event: event, Value: value, Stack: stack})
 	}
 }
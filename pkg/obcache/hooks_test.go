@@ -2,11 +2,20 @@ package obcache
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/internal/eviction"
+	"github.com/1mb-dev/obcache-go/v2/internal/store/memory"
+	"github.com/1mb-dev/obcache-go/v2/pkg/compression"
+	"github.com/1mb-dev/obcache-go/v2/pkg/metrics"
+	"github.com/1mb-dev/obcache-go/v2/pkg/store"
 )
 
 func TestHookExecution(t *testing.T) {
@@ -260,206 +269,1552 @@ func TestHookIntegrationWithWrap(t *testing.T) {
 	}
 }
 
-func TestNilHooks(t *testing.T) {
-	// Test that nil hooks don't cause panics
-	config := NewDefaultConfig().WithHooks(nil)
+func TestHookOnSet(t *testing.T) {
+	type setCall struct {
+		key   string
+		value any
+		ttl   time.Duration
+	}
+	var mu sync.Mutex
+	var calls []setCall
+
+	hooks := NewHooks()
+	hooks.AddOnSet(func(_ context.Context, key string, value any, ttl time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, setCall{key, value, ttl})
+	})
+
+	compressionConfig := compression.NewDefaultConfig().WithEnabled(true).WithMinSize(100)
+	config := NewDefaultConfig().WithDefaultTTL(time.Minute).WithCompression(compressionConfig).WithHooks(hooks)
 	cache, err := New(config)
 	if err != nil {
 		t.Fatalf("Failed to create cache: %v", err)
 	}
 
-	_ = cache.Set("key1", "value1", time.Hour)
-	cache.Get("key1")
-	cache.Get("nonexistent")
-	_ = cache.Delete("key1")
+	large := strings.Repeat("compressible ", 100)
+	_ = cache.Set("large", large, time.Hour)
+	_ = cache.Set("default", "value", 0)
+	_, _ = cache.SetVersioned("versioned", "v1", 1, NoTTL)
+	_, _ = cache.SetVersioned("versioned", "v0", 0, NoTTL) // Older version, not written
+	_, _, _ = cache.Swap("large", "swapped", time.Hour)
+	_ = cache.SetMany(map[string]any{"many": 42}, time.Second)
 
-	// If we reach here without panic, test passes
+	mu.Lock()
+	defer mu.Unlock()
+	expected := []setCall{
+		{"large", large, time.Hour},
+		{"default", "value", time.Minute},
+		{"versioned", "v1", NoTTL},
+		{"large", "swapped", time.Hour},
+		{"many", 42, time.Second},
+	}
+	if len(calls) != len(expected) {
+		t.Fatalf("Expected %d OnSet calls, got %d: %v", len(expected), len(calls), calls)
+	}
+	for i, want := range expected {
+		if calls[i] != want {
+			t.Errorf("OnSet call %d: expected %v, got %v", i, want, calls[i])
+		}
+	}
 }
 
-func TestEmptyHooks(t *testing.T) {
-	// Test that empty hooks struct doesn't cause issues
-	config := NewDefaultConfig().WithHooks(&Hooks{})
+func TestHookOnSetFailedWrite(t *testing.T) {
+	var setCalls int32
+	hooks := NewHooks()
+	hooks.AddOnSet(func(_ context.Context, _ string, _ any, _ time.Duration) {
+		atomic.AddInt32(&setCalls, 1)
+	})
+
+	config := NewDefaultConfig().WithHooks(hooks)
 	cache, err := New(config)
 	if err != nil {
 		t.Fatalf("Failed to create cache: %v", err)
 	}
+	cache.store = &failingStore{Store: cache.store, err: errors.New("backend down")}
 
-	_ = cache.Set("key1", "value1", time.Hour)
-	cache.Get("key1")
-	cache.Get("nonexistent")
-	_ = cache.Delete("key1")
-
-	// If we reach here without panic, test passes
+	if err := cache.Set("key", "value", time.Hour); err == nil {
+		t.Fatal("Expected Set to fail")
+	}
+	if n := atomic.LoadInt32(&setCalls); n != 0 {
+		t.Errorf("Expected no OnSet calls for a failed write, got %d", n)
+	}
 }
 
-func TestHookErrorHandling(t *testing.T) {
-	// Test that panicking hooks don't break the cache
+func TestHookOnSetAfterEvict(t *testing.T) {
+	var mu sync.Mutex
+	var events []string
+
 	hooks := NewHooks()
-	hooks.AddOnHit(func(_ context.Context, _ string, _ any) {
-		panic("hook panic")
+	hooks.AddOnEvict(func(_ context.Context, key string, _ any, reason EvictReason) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, fmt.Sprintf("evict %s (%s)", key, reason))
+	})
+	hooks.AddOnSet(func(_ context.Context, key string, _ any, _ time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, "set "+key)
 	})
 
-	config := NewDefaultConfig().WithHooks(hooks)
+	config := NewDefaultConfig().WithMaxEntries(1).WithHooks(hooks)
 	cache, err := New(config)
 	if err != nil {
 		t.Fatalf("Failed to create cache: %v", err)
 	}
 
 	_ = cache.Set("key1", "value1", time.Hour)
+	_ = cache.Set("key2", "value2", time.Hour) // Displaces key1
 
-	// This should not panic even though the hook panics
-	// The cache should continue to function normally
-	defer func() {
-		if r := recover(); r != nil {
-			// Hook panics are expected to propagate in this implementation
-			// This is acceptable behavior
-			return
+	mu.Lock()
+	defer mu.Unlock()
+	expected := []string{"set key1", "evict key1 (Capacity)", "set key2"}
+	if fmt.Sprint(events) != fmt.Sprint(expected) {
+		t.Errorf("Expected events %v, got %v", expected, events)
+	}
+}
+
+func TestHookOnSetPriorityAndCondition(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+
+	hooks := NewHooks()
+	record := func(name string) func(context.Context, string, any, time.Duration) {
+		return func(_ context.Context, _ string, _ any, _ time.Duration) {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
 		}
-		// If no panic occurred, that's also fine
-	}()
+	}
+	hooks.AddOnSet(record("low"), WithPriority(1))
+	hooks.AddOnSet(record("high"), WithPriority(10))
+	hooks.AddOnSet(record("users"), WithPriority(5), WithCondition(func(_ context.Context, key string) bool {
+		return strings.HasPrefix(key, "user:")
+	}))
+
+	cache, err := New(NewDefaultConfig().WithHooks(hooks))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	_ = cache.Set("user:1", "alice", time.Hour)
+	_ = cache.Set("order:1", "book", time.Hour)
 
-	_, found := cache.Get("key1")
-	// We may or may not reach this point depending on hook implementation
-	_ = found
+	mu.Lock()
+	defer mu.Unlock()
+	expected := []string{"high", "users", "low", "high", "low"}
+	if fmt.Sprint(order) != fmt.Sprint(expected) {
+		t.Errorf("Expected hooks to run as %v, got %v", expected, order)
+	}
 }
 
-func TestHookPriority(t *testing.T) {
-	var executionOrder []int
+func TestHookOnSetWithWrap(t *testing.T) {
 	var mu sync.Mutex
+	var values []any
 
 	hooks := NewHooks()
-	hooks.AddOnHit(func(_ context.Context, _ string, _ any) {
+	hooks.AddOnSet(func(_ context.Context, _ string, value any, _ time.Duration) {
 		mu.Lock()
-		executionOrder = append(executionOrder, 1)
-		mu.Unlock()
-	}, WithPriority(10))
+		defer mu.Unlock()
+		values = append(values, value)
+	})
 
-	hooks.AddOnHit(func(_ context.Context, _ string, _ any) {
-		mu.Lock()
-		executionOrder = append(executionOrder, 2)
-		mu.Unlock()
-	}, WithPriority(100))
+	cache, err := New(NewDefaultConfig().WithHooks(hooks))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
 
-	hooks.AddOnHit(func(_ context.Context, _ string, _ any) {
+	wrapped := Wrap(cache, func(x int) int { return x * 2 })
+	wrapped(5)
+	wrapped(5) // Served from the cache, no write
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(values) != 1 || values[0] != 10 {
+		t.Errorf("Expected one OnSet call with the function result, got %v", values)
+	}
+}
+
+func TestHookOnError(t *testing.T) {
+	type errorCall struct {
+		op, key string
+		err     error
+	}
+	var mu sync.Mutex
+	var calls []errorCall
+	var priorities []int
+
+	hooks := NewHooks()
+	hooks.AddOnError(func(_ context.Context, op string, key string, err error) {
 		mu.Lock()
-		executionOrder = append(executionOrder, 3)
-		mu.Unlock()
-	}, WithPriority(50))
+		defer mu.Unlock()
+		calls = append(calls, errorCall{op, key, err})
+		priorities = append(priorities, 1)
+	}, WithPriority(1))
+	hooks.AddOnError(func(_ context.Context, _ string, _ string, _ error) {
+		mu.Lock()
+		defer mu.Unlock()
+		priorities = append(priorities, 10)
+	}, WithPriority(10), WithCondition(func(_ context.Context, key string) bool {
+		return key == "key"
+	}))
 
-	config := NewDefaultConfig().WithHooks(hooks)
-	cache, err := New(config)
+	errBackend := errors.New("backend down")
+	compressionConfig := compression.NewDefaultConfig().WithEnabled(true)
+	cache, err := New(NewDefaultConfig().WithCompression(compressionConfig).WithHooks(hooks))
 	if err != nil {
 		t.Fatalf("Failed to create cache: %v", err)
 	}
+	cache.store = &failingStore{Store: cache.store, err: errBackend}
 
-	_ = cache.Set("key1", "value1", time.Hour)
-	cache.Get("key1")
+	if err := cache.Set("key", "value", time.Hour); !errors.Is(err, errBackend) {
+		t.Fatalf("Expected Set to return the store error, got %v", err)
+	}
+	if err := cache.Set("unencodable", make(chan int), time.Hour); err == nil {
+		t.Fatal("Expected Set of an unencodable value to fail")
+	}
 
 	mu.Lock()
 	defer mu.Unlock()
-
-	if len(executionOrder) != 3 {
-		t.Fatalf("Expected 3 hooks to execute, got %d", len(executionOrder))
+	if len(calls) != 2 {
+		t.Fatalf("Expected 2 OnError calls, got %d: %v", len(calls), calls)
 	}
-
-	// Should execute in priority order: 100, 50, 10
-	if executionOrder[0] != 2 {
-		t.Fatalf("Expected first hook to be #2 (priority 100), got #%d", executionOrder[0])
+	if calls[0].op != ErrorOpSet || calls[0].key != "key" || !errors.Is(calls[0].err, errBackend) || calls[0].err == errBackend {
+		t.Errorf("Expected a wrapped store error for set key, got %s %s %v", calls[0].op, calls[0].key, calls[0].err)
 	}
-	if executionOrder[1] != 3 {
-		t.Fatalf("Expected second hook to be #3 (priority 50), got #%d", executionOrder[1])
+	if calls[1].op != ErrorOpSet || calls[1].key != "unencodable" || !strings.Contains(calls[1].err.Error(), "failed to create entry") {
+		t.Errorf("Expected an encoding error for set unencodable, got %s %s %v", calls[1].op, calls[1].key, calls[1].err)
 	}
-	if executionOrder[2] != 1 {
-		t.Fatalf("Expected third hook to be #1 (priority 10), got #%d", executionOrder[2])
+	if fmt.Sprint(priorities) != "[10 1 1]" {
+		t.Errorf("Expected the conditional high priority hook to run first for key only, got %v", priorities)
 	}
 }
 
-func TestHookCondition(t *testing.T) {
-	var calls int32
+// failingExporter fails every export
+type failingExporter struct {
+	metrics.NoOpExporter
+}
+
+func (*failingExporter) RecordCacheOperation(metrics.Operation, time.Duration, metrics.Labels) error {
+	return errors.New("exporter down")
+}
+
+func TestHookOnErrorExportThrottled(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
 
 	hooks := NewHooks()
-	// Only execute for keys starting with "cached:"
+	hooks.AddOnError(func(_ context.Context, op string, key string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, fmt.Sprintf("%s %q: %v", op, key, err))
+	})
+
+	cache, err := New(NewDefaultConfig().
+		WithHooks(hooks).
+		WithMetrics(&MetricsConfig{Exporter: &failingExporter{}, Enabled: true, ReportingInterval: time.Hour}))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	for range 10 {
+		_ = cache.Set("key", "value", time.Hour)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(calls) != 1 || calls[0] != `export "": failed to export metrics: exporter down` {
+		t.Errorf("Expected a single throttled export error, got %v", calls)
+	}
+}
+
+func TestHookRemove(t *testing.T) {
+	var first, second int32
+	hooks := NewHooks()
+	handle := hooks.AddOnHit(func(_ context.Context, _ string, _ any) {
+		atomic.AddInt32(&first, 1)
+	})
 	hooks.AddOnHit(func(_ context.Context, _ string, _ any) {
-		atomic.AddInt32(&calls, 1)
-	}, WithCondition(func(_ context.Context, key string) bool {
-		return len(key) >= 7 && key[:7] == "cached:"
-	}))
+		atomic.AddInt32(&second, 1)
+	})
 
-	config := NewDefaultConfig().WithHooks(hooks)
-	cache, err := New(config)
+	cache, err := New(NewDefaultConfig().WithHooks(hooks))
 	if err != nil {
 		t.Fatalf("Failed to create cache: %v", err)
 	}
+	_ = cache.Set("key1", "value1", time.Hour)
 
-	// This should trigger the hook
-	_ = cache.Set("cached:key1", "value1", time.Hour)
-	cache.Get("cached:key1")
+	cache.Get("key1")
+	handle.Remove()
+	handle.Remove()       // Removing twice does nothing
+	HookHandle{}.Remove() // Nor does the zero handle
+	cache.Get("key1")
 
-	if atomic.LoadInt32(&calls) != 1 {
-		t.Fatalf("Expected 1 hook call, got %d", calls)
+	if n := atomic.LoadInt32(&first); n != 1 {
+		t.Errorf("Expected the removed hook to run once, got %d", n)
 	}
+	if n := atomic.LoadInt32(&second); n != 2 {
+		t.Errorf("Expected the remaining hook to run twice, got %d", n)
+	}
+}
 
-	// This should NOT trigger the hook (doesn't match condition)
-	_ = cache.Set("other:key2", "value2", time.Hour)
-	cache.Get("other:key2")
+func TestHookRemoveConcurrent(t *testing.T) {
+	var calls atomic.Int64
+	hooks := NewHooks()
+	hooks.AddOnHit(func(_ context.Context, _ string, _ any) { calls.Add(1) })
 
-	if atomic.LoadInt32(&calls) != 1 {
-		t.Fatalf("Expected still 1 hook call (condition not met), got %d", calls)
+	cache, err := New(NewDefaultConfig().WithHooks(hooks))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	_ = cache.Set("key1", "value1", time.Hour)
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					cache.Get("key1")
+				}
+			}
+		}()
+	}
+	for i := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 200 {
+				handle := hooks.AddOnHit(func(_ context.Context, _ string, _ any) {}, WithPriority(i))
+				handle.Remove()
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(stop)
+	wg.Wait()
+
+	if calls.Load() == 0 {
+		t.Error("Expected the permanent hook to keep running")
+	}
+	if n := len(hooks.onHit.Load().all); n != 1 {
+		t.Errorf("Expected only the permanent hook to remain, got %d", n)
 	}
 }
 
-func TestHookPriorityAndCondition(t *testing.T) {
-	var executionOrder []int
-	var mu sync.Mutex
+func TestHookBeforeSet(t *testing.T) {
+	type profile struct {
+		Name     string
+		Password string
+	}
+	errTooLarge := errors.New("value too large")
 
 	hooks := NewHooks()
-
-	// High priority hook with condition
-	hooks.AddOnHit(func(_ context.Context, _ string, _ any) {
-		mu.Lock()
-		executionOrder = append(executionOrder, 1)
-		mu.Unlock()
-	}, WithPriority(100), WithCondition(func(_ context.Context, key string) bool {
-		return key == "special"
+	hooks.AddBeforeSet(func(_ context.Context, key string, value any, ttl time.Duration) (any, time.Duration, bool, error) {
+		return value, ttl, !strings.Contains(key, "secret"), nil
+	}, WithPriority(10))
+	hooks.AddBeforeSet(func(_ context.Context, _ string, value any, ttl time.Duration) (any, time.Duration, bool, error) {
+		if p, ok := value.(profile); ok {
+			p.Password = ""
+			return p, time.Minute, true, nil
+		}
+		return value, ttl, true, nil
+	})
+	hooks.AddBeforeSet(func(_ context.Context, _ string, value any, ttl time.Duration) (any, time.Duration, bool, error) {
+		if s, ok := value.(string); ok && len(s) > 10 {
+			return nil, 0, false, errTooLarge
+		}
+		return value, ttl, true, nil
+	}, WithCondition(func(_ context.Context, key string) bool {
+		return strings.HasPrefix(key, "small:")
 	}))
 
-	// Low priority hook without condition
-	hooks.AddOnHit(func(_ context.Context, _ string, _ any) {
-		mu.Lock()
-		executionOrder = append(executionOrder, 2)
-		mu.Unlock()
-	}, WithPriority(10))
+	var setValues []any
+	hooks.AddOnSet(func(_ context.Context, _ string, value any, _ time.Duration) {
+		setValues = append(setValues, value)
+	})
 
-	config := NewDefaultConfig().WithHooks(hooks)
-	cache, err := New(config)
+	cache, err := New(NewDefaultConfig().WithHooks(hooks))
 	if err != nil {
 		t.Fatalf("Failed to create cache: %v", err)
 	}
 
-	// Test with "special" key - both hooks should execute
-	_ = cache.Set("special", "value1", time.Hour)
-	cache.Get("special")
+	// Vetoed silently
+	if err := cache.Set("user:secret", "hunter2", time.Hour); err != nil {
+		t.Errorf("Expected a silent veto, got %v", err)
+	}
+	if _, found := cache.Get("user:secret"); found {
+		t.Error("Expected the vetoed key not to be cached")
+	}
 
-	mu.Lock()
-	if len(executionOrder) != 2 {
-		t.Fatalf("Expected 2 hooks to execute, got %d", len(executionOrder))
+	// Transformed value and TTL
+	_ = cache.Set("user:1", profile{Name: "ada", Password: "pw"}, time.Hour)
+	value, info, found := cache.GetEntry("user:1")
+	if !found || value.(profile).Password != "" {
+		t.Errorf("Expected the password to be stripped, got %v", value)
 	}
-	if executionOrder[0] != 1 || executionOrder[1] != 2 {
-		t.Fatalf("Expected execution order [1, 2], got %v", executionOrder)
+	if info.ExpiresAt == nil || time.Until(*info.ExpiresAt) > time.Minute {
+		t.Errorf("Expected the hook's TTL to apply, expires at %v", info.ExpiresAt)
 	}
-	executionOrder = nil
-	mu.Unlock()
 
-	// Test with regular key - only unconditional hook should execute
-	_ = cache.Set("regular", "value2", time.Hour)
-	cache.Get("regular")
+	// Vetoed with an error
+	err = cache.Set("small:1", "far too long a value", time.Hour)
+	var vetoErr *SetVetoedError
+	if !errors.As(err, &vetoErr) || vetoErr.Key != "small:1" || !errors.Is(err, errTooLarge) {
+		t.Errorf("Expected a SetVetoedError wrapping the hook error, got %v", err)
+	}
 
-	mu.Lock()
-	if len(executionOrder) != 1 {
-		t.Fatalf("Expected 1 hook to execute, got %d", len(executionOrder))
+	// Batch writes pass through the same hooks
+	err = cache.SetMany(map[string]any{"a:secret": 1, "small:2": "far too long a value", "b": 2}, time.Hour)
+	var batchErr *store.BatchError
+	if !errors.As(err, &batchErr) || len(batchErr.Errors) != 1 || !errors.Is(batchErr.Errors["small:2"], errTooLarge) {
+		t.Errorf("Expected only small:2 to fail, got %v", err)
 	}
-	if executionOrder[0] != 2 {
-		t.Fatalf("Expected hook #2 to execute, got #%d", executionOrder[0])
+	if _, found := cache.Get("a:secret"); found {
+		t.Error("Expected the vetoed batch key not to be cached")
 	}
-	mu.Unlock()
+
+	if len(setValues) != 2 || setValues[0] != (profile{Name: "ada"}) || setValues[1] != 2 {
+		t.Errorf("Expected OnSet to see only the transformed writes, got %v", setValues)
+	}
+}
+
+func TestHookBeforeSetWithWrap(t *testing.T) {
+	hooks := NewHooks()
+	hooks.AddBeforeSet(func(_ context.Context, _ string, value any, ttl time.Duration) (any, time.Duration, bool, error) {
+		return value, ttl, value.(int) >= 0, nil // Don't memoize negative results
+	})
+
+	cache, err := New(NewDefaultConfig().WithHooks(hooks))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	var calls int32
+	wrapped := Wrap(cache, func(x int) int {
+		atomic.AddInt32(&calls, 1)
+		return x
+	})
+	wrapped(-1)
+	wrapped(-1)
+	wrapped(1)
+	wrapped(1)
+
+	if n := atomic.LoadInt32(&calls); n != 3 {
+		t.Errorf("Expected vetoed results to be recomputed, got %d calls", n)
+	}
+}
+
+func TestHookBeforeSetPanicVetoes(t *testing.T) {
+	hooks := NewHooks()
+	hooks.AddBeforeSet(func(context.Context, string, any, time.Duration) (any, time.Duration, bool, error) {
+		panic("policy panic")
+	})
+
+	cache, err := New(NewDefaultConfig().WithHooks(hooks))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	var panicErr *HookPanicError
+	if err := cache.Set("key1", "value1", time.Hour); !errors.As(err, &panicErr) {
+		t.Errorf("Expected the write to fail with the hook panic, got %v", err)
+	}
+	if _, found := cache.Get("key1"); found {
+		t.Error("Expected a panicking BeforeSet hook to veto the write")
+	}
+	if n := hooks.Panics(); n != 1 {
+		t.Errorf("Expected 1 recovered panic, got %d", n)
+	}
+}
+
+func TestHookOnClear(t *testing.T) {
+	for _, invalidations := range []bool{true, false} {
+		t.Run(fmt.Sprintf("invalidations=%v", invalidations), func(t *testing.T) {
+			var cleared []int
+			var invalidated int32
+			hooks := NewHooks()
+			hooks.AddOnClear(func(ctx context.Context, keysRemoved int) {
+				if IsPartialClear(ctx) {
+					t.Error("Expected Clear not to be reported as partial")
+				}
+				cleared = append(cleared, keysRemoved)
+			})
+			hooks.AddOnInvalidate(func(_ context.Context, _ string) {
+				atomic.AddInt32(&invalidated, 1)
+			})
+
+			cache, err := New(NewDefaultConfig().WithHooks(hooks).WithClearInvalidations(invalidations))
+			if err != nil {
+				t.Fatalf("Failed to create cache: %v", err)
+			}
+			for i := range 5 {
+				_ = cache.Set(fmt.Sprintf("key%d", i), i, time.Hour)
+			}
+
+			if err := cache.Clear(); err != nil {
+				t.Fatalf("Clear failed: %v", err)
+			}
+			_ = cache.Clear()
+
+			if fmt.Sprint(cleared) != "[5 0]" {
+				t.Errorf("Expected one OnClear call per Clear with the count, got %v", cleared)
+			}
+			expected := int32(0)
+			if invalidations {
+				expected = 5
+			}
+			if n := atomic.LoadInt32(&invalidated); n != expected {
+				t.Errorf("Expected %d OnInvalidate calls, got %d", expected, n)
+			}
+			if n := cache.Stats().Invalidations(); n != 5 {
+				t.Errorf("Expected every cleared key to count as an invalidation, got %d", n)
+			}
+		})
+	}
+}
+
+func TestHookOnClearPartial(t *testing.T) {
+	type clear struct {
+		partial bool
+		n       int
+	}
+	var cleared []clear
+	hooks := NewHooks()
+	hooks.AddOnClear(func(ctx context.Context, keysRemoved int) {
+		cleared = append(cleared, clear{IsPartialClear(ctx), keysRemoved})
+	})
+
+	cache, err := New(NewDefaultConfig().WithHooks(hooks))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	_ = cache.Set("user:1", 1, time.Hour)
+	_ = cache.Set("user:2", 2, time.Hour)
+	_ = cache.Set("order:1", 3, time.Hour)
+	_ = cache.Set("session:1", 4, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	if n := cache.ClearExpired(); n != 1 {
+		t.Fatalf("Expected ClearExpired to remove 1 entry, got %d", n)
+	}
+	if n := cache.ClearWhere(func(key string, _ EntryInfo) bool { return strings.HasPrefix(key, "user:") }); n != 2 {
+		t.Fatalf("Expected ClearWhere to remove 2 entries, got %d", n)
+	}
+	cache.ClearWhere(func(string, EntryInfo) bool { return false }) // Removes nothing, no hook
+
+	expected := []clear{{true, 1}, {true, 2}}
+	if fmt.Sprint(cleared) != fmt.Sprint(expected) {
+		t.Errorf("Expected OnClear calls %v, got %v", expected, cleared)
+	}
+}
+
+func TestHookOnInvalidateReentrant(t *testing.T) {
+	hooks := NewHooks()
+	cache, err := New(NewDefaultConfig().WithHooks(hooks))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	// The hook reads the cache and restores deleted keys under a new name,
+	// which deadlocks if hooks run while Delete or Clear holds the cache lock
+	var restored atomic.Int32
+	hooks.AddOnInvalidate(func(_ context.Context, key string) {
+		_, _ = cache.Get(key)
+		_ = cache.Len()
+		if !strings.HasPrefix(key, "restored:") {
+			_ = cache.Set("restored:"+key, "value", time.Hour)
+			restored.Add(1)
+		}
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = cache.Set("key1", "value", time.Hour)
+		_ = cache.Set("key2", "value", time.Hour)
+		_ = cache.Delete("key1")
+		_ = cache.Clear()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Delete or Clear deadlocked with a hook calling back into the cache")
+	}
+
+	// key1 after Delete and key2 after Clear; restored keys are not restored again
+	if n := restored.Load(); n != 2 {
+		t.Errorf("Expected 2 keys restored, got %d", n)
+	}
+	if !cache.Has("restored:key2") {
+		t.Error("Expected a key set by a Clear hook to survive the Clear")
+	}
+}
+
+func TestHookOnEvictInfo(t *testing.T) {
+	type eviction struct {
+		key    string
+		reason EvictReason
+		info   EntryInfo
+	}
+	var mu sync.Mutex
+	var evictions []eviction
+	var plainCalls int32
+
+	hooks := NewHooks()
+	hooks.AddOnEvictInfo(func(_ context.Context, key string, _ any, reason EvictReason, info EntryInfo) {
+		mu.Lock()
+		defer mu.Unlock()
+		evictions = append(evictions, eviction{key, reason, info})
+	})
+	hooks.AddOnEvict(func(_ context.Context, _ string, _ any, _ EvictReason) {
+		atomic.AddInt32(&plainCalls, 1)
+	})
+
+	cache, err := New(NewDefaultConfig().WithMaxEntries(2).WithHooks(hooks))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	_ = cache.Set("read", "value", time.Hour)
+	_ = cache.Set("unread", "value", time.Hour)
+	cache.Get("read")
+	cache.Get("read")
+	time.Sleep(5 * time.Millisecond)
+
+	cache.Evict(1)                                    // Evicts unread, the least recently used
+	_ = cache.Set("short", "value", time.Millisecond) // Fills the cache again
+	_ = cache.Set("new", "value", time.Hour)          // Displaces read
+	time.Sleep(5 * time.Millisecond)
+	cache.ClearExpired() // Removes short
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(evictions) != 3 || atomic.LoadInt32(&plainCalls) != 3 {
+		t.Fatalf("Expected 3 evictions reported to both hook kinds, got %v and %d", evictions, plainCalls)
+	}
+
+	manual, capacity, expired := evictions[0], evictions[1], evictions[2]
+	if manual.key != "unread" || manual.reason != EvictReasonManual || manual.info.AccessCount != 0 {
+		t.Errorf("Unexpected manual eviction %+v", manual)
+	}
+	if capacity.key != "read" || capacity.reason != EvictReasonCapacity || capacity.info.AccessCount != 2 {
+		t.Errorf("Unexpected capacity eviction %+v", capacity)
+	}
+	if age := capacity.info.Age(); age < 5*time.Millisecond {
+		t.Errorf("Expected the evicted entry's age, got %v", age)
+	}
+	if ttl := capacity.info.TTL(); ttl <= 0 || ttl > time.Hour {
+		t.Errorf("Expected the evicted entry's remaining TTL, got %v", ttl)
+	}
+	if capacity.info.Size != len("value") || capacity.info.OriginalSize != len("value") {
+		t.Errorf("Expected the evicted entry's sizes, got %d and %d", capacity.info.Size, capacity.info.OriginalSize)
+	}
+	if expired.key != "short" || expired.reason != EvictReasonTTL || expired.info.CreatedAt.IsZero() {
+		t.Errorf("Unexpected expiration %+v", expired)
+	}
+}
+
+func TestNilHooks(t *testing.T) {
+	// Test that nil hooks don't cause panics
+	config := NewDefaultConfig().WithHooks(nil)
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	_ = cache.Set("key1", "value1", time.Hour)
+	cache.Get("key1")
+	cache.Get("nonexistent")
+	_ = cache.Delete("key1")
+
+	// If we reach here without panic, test passes
+}
+
+func TestEmptyHooks(t *testing.T) {
+	// Test that empty hooks struct doesn't cause issues
+	config := NewDefaultConfig().WithHooks(&Hooks{})
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	_ = cache.Set("key1", "value1", time.Hour)
+	cache.Get("key1")
+	cache.Get("nonexistent")
+	_ = cache.Delete("key1")
+
+	// If we reach here without panic, test passes
+}
+
+func TestHookErrorHandling(t *testing.T) {
+	// A panicking hook is recovered, so the cache keeps serving
+	var mu sync.Mutex
+	var ran []string
+	var reported []error
+
+	hooks := NewHooks()
+	hooks.AddOnHit(func(_ context.Context, _ string, _ any) {
+		panic("hook panic")
+	}, WithPriority(10))
+	hooks.AddOnHit(func(_ context.Context, _ string, _ any) {
+		mu.Lock()
+		defer mu.Unlock()
+		ran = append(ran, "second")
+	})
+	hooks.AddOnError(func(_ context.Context, op string, _ string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if op == ErrorOpHook {
+			reported = append(reported, err)
+		}
+	})
+
+	config := NewDefaultConfig().WithHooks(hooks)
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	_ = cache.Set("key1", "value1", time.Hour)
+	for range 2 {
+		if value, found := cache.Get("key1"); !found || value != "value1" {
+			t.Fatalf("Expected the cache to keep serving after a hook panic, got %v, %v", value, found)
+		}
+	}
+
+	if n := hooks.Panics(); n != 2 {
+		t.Errorf("Expected 2 recovered panics, got %d", n)
+	}
+	if n := cache.Stats().ErrorsByType()["hook_panic"]; n != 2 {
+		t.Errorf("Expected 2 hook_panic errors in Stats, got %d", n)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(ran) != 2 {
+		t.Errorf("Expected the remaining hook to run after each panic, ran %d times", len(ran))
+	}
+	if len(reported) != 2 {
+		t.Fatalf("Expected 2 panics passed to OnError, got %d", len(reported))
+	}
+	var panicErr *HookPanicError
+	if !errors.As(reported[0], &panicErr) || panicErr.Event != "OnHit" || panicErr.Value != "hook panic" {
+		t.Errorf("Expected a HookPanicError for OnHit, got %v", reported[0])
+	}
+	if !strings.Contains(string(panicErr.Stack), "TestHookErrorHandling") {
+		t.Error("Expected the panic stack to include the hook")
+	}
+}
+
+func TestHookTimeout(t *testing.T) {
+	var mu sync.Mutex
+	var reported []error
+	release := make(chan struct{})
+	hookDone := make(chan error, 1)
+
+	hooks := NewHooks()
+	hooks.AddOnHit(func(ctx context.Context, _ string, _ any) {
+		<-ctx.Done()
+		<-release
+		hookDone <- ctx.Err()
+	}, WithTimeout(20*time.Millisecond))
+	hooks.AddOnError(func(_ context.Context, op string, _ string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if op == ErrorOpHook {
+			reported = append(reported, err)
+		}
+	})
+
+	cache, err := New(NewDefaultConfig().WithHooks(hooks))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	_ = cache.Set("key1", "value1", time.Hour)
+
+	start := time.Now()
+	if _, found := cache.Get("key1"); !found {
+		t.Fatal("Expected hit")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected Get to stop waiting for the hook, took %v", elapsed)
+	}
+	if n := hooks.Timeouts(); n != 1 {
+		t.Errorf("Expected 1 hook timeout, got %d", n)
+	}
+	if n := cache.Stats().ErrorsByType()["hook_timeout"]; n != 1 {
+		t.Errorf("Expected 1 hook_timeout error in Stats, got %d", n)
+	}
+
+	mu.Lock()
+	if len(reported) != 1 || !errors.Is(reported[0], context.DeadlineExceeded) {
+		t.Errorf("Expected a deadline error passed to OnError, got %v", reported)
+	}
+	mu.Unlock()
+
+	// The abandoned hook keeps running with a cancelled context
+	close(release)
+	if err := <-hookDone; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the hook context to be cancelled at the timeout, got %v", err)
+	}
+}
+
+func TestHookTimeoutFastHook(t *testing.T) {
+	var calls int32
+	hooks := NewHooks().WithPanicPropagation(true)
+	hooks.AddOnSet(func(ctx context.Context, _ string, _ any, _ time.Duration) {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("Expected the hook context to carry the timeout")
+		}
+		atomic.AddInt32(&calls, 1)
+	}, WithTimeout(time.Second))
+	hooks.AddOnHit(func(_ context.Context, _ string, _ any) {
+		panic("hook panic")
+	}, WithTimeout(time.Second))
+
+	cache, err := New(NewDefaultConfig().WithHooks(hooks))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	_ = cache.Set("key1", "value1", time.Hour)
+
+	// The hook finished before Set returned
+	if n := atomic.LoadInt32(&calls); n != 1 || hooks.Timeouts() != 0 {
+		t.Errorf("Expected the hook to complete within its timeout, got %d calls and %d timeouts", n, hooks.Timeouts())
+	}
+
+	// A panic inside the timeout still propagates to the caller
+	defer func() {
+		if r := recover(); r != "hook panic" {
+			t.Errorf("Expected the hook panic to propagate, got %v", r)
+		}
+	}()
+	cache.Get("key1")
+	t.Error("Expected Get to panic")
+}
+
+func TestHookPanicPropagation(t *testing.T) {
+	hooks := NewHooks().WithPanicPropagation(true)
+	hooks.AddOnHit(func(_ context.Context, _ string, _ any) {
+		panic("hook panic")
+	})
+
+	cache, err := New(NewDefaultConfig().WithHooks(hooks))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	_ = cache.Set("key1", "value1", time.Hour)
+
+	defer func() {
+		if r := recover(); r != "hook panic" {
+			t.Errorf("Expected the hook panic to propagate, got %v", r)
+		}
+		if n := hooks.Panics(); n != 0 {
+			t.Errorf("Expected no recovered panics, got %d", n)
+		}
+	}()
+	cache.Get("key1")
+	t.Error("Expected Get to panic")
+}
+
+func TestHookPriority(t *testing.T) {
+	var executionOrder []int
+	var mu sync.Mutex
+
+	hooks := NewHooks()
+	hooks.AddOnHit(func(_ context.Context, _ string, _ any) {
+		mu.Lock()
+		executionOrder = append(executionOrder, 1)
+		mu.Unlock()
+	}, WithPriority(10))
+
+	hooks.AddOnHit(func(_ context.Context, _ string, _ any) {
+		mu.Lock()
+		executionOrder = append(executionOrder, 2)
+		mu.Unlock()
+	}, WithPriority(100))
+
+	hooks.AddOnHit(func(_ context.Context, _ string, _ any) {
+		mu.Lock()
+		executionOrder = append(executionOrder, 3)
+		mu.Unlock()
+	}, WithPriority(50))
+
+	config := NewDefaultConfig().WithHooks(hooks)
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	_ = cache.Set("key1", "value1", time.Hour)
+	cache.Get("key1")
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(executionOrder) != 3 {
+		t.Fatalf("Expected 3 hooks to execute, got %d", len(executionOrder))
+	}
+
+	// Should execute in priority order: 100, 50, 10
+	if executionOrder[0] != 2 {
+		t.Fatalf("Expected first hook to be #2 (priority 100), got #%d", executionOrder[0])
+	}
+	if executionOrder[1] != 3 {
+		t.Fatalf("Expected second hook to be #3 (priority 50), got #%d", executionOrder[1])
+	}
+	if executionOrder[2] != 1 {
+		t.Fatalf("Expected third hook to be #1 (priority 10), got #%d", executionOrder[2])
+	}
+}
+
+func TestHookPriorityKeepsRegistrationOrder(t *testing.T) {
+	var order []string
+	record := func(name string) func(context.Context, string, any) {
+		return func(context.Context, string, any) { order = append(order, name) }
+	}
+
+	hooks := NewHooks()
+	hooks.AddOnHit(record("a5"), WithPriority(5))
+	hooks.AddOnHit(record("b1"), WithPriority(1))
+	hooks.AddOnHit(record("c5"), WithPriority(5))
+	hooks.AddOnHit(record("d9"), WithPriority(9))
+	hooks.AddOnHit(record("e1"), WithPriority(1))
+	hooks.AddOnHit(record("f5"), WithPriority(5))
+
+	cache, err := New(NewDefaultConfig().WithHooks(hooks))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	_ = cache.Set("key", "value", time.Hour)
+	cache.Get("key")
+
+	// Equal priorities run in the order they were added
+	if want := []string{"d9", "a5", "c5", "f5", "b1", "e1"}; !slices.Equal(order, want) {
+		t.Errorf("Expected %v, got %v", want, order)
+	}
+}
+
+func TestHookPriorityNoAllocations(t *testing.T) {
+	get := func(hookCount int) float64 {
+		hooks := NewHooks()
+		for i := range hookCount {
+			hooks.AddOnHit(func(context.Context, string, any) {}, WithPriority(i%3))
+		}
+		cache, err := New(NewDefaultConfig().WithHooks(hooks))
+		if err != nil {
+			t.Fatalf("Failed to create cache: %v", err)
+		}
+		_ = cache.Set("key", "value", time.Hour)
+		return testing.AllocsPerRun(100, func() {
+			_, _ = cache.Get("key")
+		})
+	}
+
+	// Hooks are ordered when registered, so running them allocates nothing
+	if without, with := get(0), get(10); with != without {
+		t.Errorf("Expected 10 hooks to add no allocations per Get, got %v vs %v", with, without)
+	}
+}
+
+func TestCombineHooks(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) func(context.Context, string, any) {
+		return func(context.Context, string, any) {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+		}
+	}
+
+	shared := NewHooks().WithPanicPropagation(true)
+	shared.AddOnHit(record("shared-10"), WithPriority(10))
+	shared.AddOnHit(record("shared-0"))
+	service := NewHooks()
+	service.AddOnHit(record("service-20"), WithPriority(20))
+	service.AddOnHit(record("service-0"))
+	service.AddOnHit(record("service-5"), WithPriority(5))
+	service.AddOnMiss(func(context.Context, string) {})
+
+	combined := CombineHooks(shared, nil, service)
+	combined.AddOnHit(record("combined-15"), WithPriority(15))
+	if !combined.propagatePanics {
+		t.Error("Expected settings to be taken from the first Hooks")
+	}
+
+	cache, err := New(NewDefaultConfig().WithHooks(combined))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	_ = cache.Set("key", "value", time.Hour)
+	cache.Get("key")
+
+	// Priority decides, then argument order, then registration order
+	expected := []string{"service-20", "combined-15", "shared-10", "service-5", "shared-0", "service-0"}
+	if !slices.Equal(order, expected) {
+		t.Errorf("Expected order %v, got %v", expected, order)
+	}
+	if n := len(combined.onMiss.Load().all); n != 1 {
+		t.Errorf("Expected the OnMiss hook to be combined, got %d", n)
+	}
+	if n := len(shared.onHit.Load().all); n != 2 {
+		t.Errorf("Expected the shared hooks to be unchanged, got %d OnHit hooks", n)
+	}
+}
+
+func TestHooksClone(t *testing.T) {
+	var calls atomic.Int32
+	shared := NewHooks().WithTiming(false)
+	handle := shared.AddOnHit(func(context.Context, string, any) { calls.Add(1) })
+
+	clone := shared.Clone()
+	clone.AddOnHit(func(context.Context, string, any) { calls.Add(10) })
+	handle.Remove() // Removes the hook from shared only
+
+	cache, err := New(NewDefaultConfig().WithHooks(clone))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	_ = cache.Set("key", "value", time.Hour)
+	cache.Get("key")
+
+	if n := calls.Load(); n != 11 {
+		t.Errorf("Expected both hooks to run on the clone, got %d", n)
+	}
+	if set := shared.onHit.Load(); len(set.all) != 0 {
+		t.Errorf("Expected the clone's hook not to be added to shared, got %d hooks", len(set.all))
+	}
+	if !clone.skipTiming {
+		t.Error("Expected the clone to keep the timing setting")
+	}
+}
+
+func TestHookCondition(t *testing.T) {
+	var calls int32
+
+	hooks := NewHooks()
+	// Only execute for keys starting with "cached:"
+	hooks.AddOnHit(func(_ context.Context, _ string, _ any) {
+		atomic.AddInt32(&calls, 1)
+	}, WithCondition(func(_ context.Context, key string) bool {
+		return len(key) >= 7 && key[:7] == "cached:"
+	}))
+
+	config := NewDefaultConfig().WithHooks(hooks)
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	// This should trigger the hook
+	_ = cache.Set("cached:key1", "value1", time.Hour)
+	cache.Get("cached:key1")
+
+	if atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("Expected 1 hook call, got %d", calls)
+	}
+
+	// This should NOT trigger the hook (doesn't match condition)
+	_ = cache.Set("other:key2", "value2", time.Hour)
+	cache.Get("other:key2")
+
+	if atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("Expected still 1 hook call (condition not met), got %d", calls)
+	}
+}
+
+func TestHookValueCondition(t *testing.T) {
+	var hits, evictions, valueChecks atomic.Int32
+
+	hooks := NewHooks()
+	// Only large values under "blob:"; the value condition must not run for other keys
+	hooks.AddOnHit(func(context.Context, string, any) {
+		hits.Add(1)
+	}, WithCondition(func(_ context.Context, key string) bool {
+		return strings.HasPrefix(key, "blob:")
+	}), WithValueCondition(func(_ context.Context, _ string, value any) bool {
+		valueChecks.Add(1)
+		s, ok := value.(string)
+		return ok && len(s) > 10
+	}))
+	hooks.AddOnEvict(func(context.Context, string, any, EvictReason) {
+		evictions.Add(1)
+	}, WithValueCondition(func(_ context.Context, _ string, value any) bool {
+		_, ok := value.(int)
+		return ok
+	}))
+
+	cache, err := New(NewDefaultConfig().WithMaxEntries(2).WithHooks(hooks))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	_ = cache.Set("blob:small", "tiny", time.Hour)
+	_ = cache.Set("blob:large", strings.Repeat("x", 100), time.Hour)
+	cache.Get("blob:small")
+	cache.Get("blob:large")
+	if n := hits.Load(); n != 1 {
+		t.Errorf("Expected 1 hit on a large value, got %d", n)
+	}
+
+	_ = cache.Set("other", 42, time.Hour) // Evicts blob:small, a string
+	cache.Get("other")
+	if n := valueChecks.Load(); n != 2 {
+		t.Errorf("Expected the value condition to run only after the key condition passed, ran %d times", n)
+	}
+	_ = cache.Set("number", 7, time.Hour) // Evicts blob:large, a string
+	if n := evictions.Load(); n != 0 {
+		t.Errorf("Expected no eviction hooks for string values, got %d", n)
+	}
+	_ = cache.Set("last", "value", time.Hour) // Evicts other, an int
+	if n := evictions.Load(); n != 1 {
+		t.Errorf("Expected 1 eviction hook for an int value, got %d", n)
+	}
+}
+
+func TestHookValueConditionWithoutValue(t *testing.T) {
+	hooks := NewHooks()
+	handle := hooks.AddOnMiss(func(context.Context, string) {}, WithValueCondition(func(context.Context, string, any) bool {
+		return true
+	}))
+	handle.Remove() // The zero handle of a rejected hook is safe to use
+
+	if err := hooks.Err(); !errors.Is(err, ErrInvalidHook) {
+		t.Fatalf("Expected ErrInvalidHook for WithValueCondition on an OnMiss hook, got %v", err)
+	}
+	if set := hooks.onMiss.Load(); set != nil && len(set.all) != 0 {
+		t.Error("Expected the invalid hook not to be registered")
+	}
+	if _, err := New(NewDefaultConfig().WithHooks(hooks)); !errors.Is(err, ErrInvalidHook) {
+		t.Errorf("Expected New to reject the hooks with ErrInvalidHook, got %v", err)
+	}
+	if err := CombineHooks(NewHooks(), hooks).Err(); !errors.Is(err, ErrInvalidHook) {
+		t.Errorf("Expected CombineHooks to keep the error, got %v", err)
+	}
+}
+
+func TestHookPriorityAndCondition(t *testing.T) {
+	var executionOrder []int
+	var mu sync.Mutex
+
+	hooks := NewHooks()
+
+	// High priority hook with condition
+	hooks.AddOnHit(func(_ context.Context, _ string, _ any) {
+		mu.Lock()
+		executionOrder = append(executionOrder, 1)
+		mu.Unlock()
+	}, WithPriority(100), WithCondition(func(_ context.Context, key string) bool {
+		return key == "special"
+	}))
+
+	// Low priority hook without condition
+	hooks.AddOnHit(func(_ context.Context, _ string, _ any) {
+		mu.Lock()
+		executionOrder = append(executionOrder, 2)
+		mu.Unlock()
+	}, WithPriority(10))
+
+	config := NewDefaultConfig().WithHooks(hooks)
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	// Test with "special" key - both hooks should execute
+	_ = cache.Set("special", "value1", time.Hour)
+	cache.Get("special")
+
+	mu.Lock()
+	if len(executionOrder) != 2 {
+		t.Fatalf("Expected 2 hooks to execute, got %d", len(executionOrder))
+	}
+	if executionOrder[0] != 1 || executionOrder[1] != 2 {
+		t.Fatalf("Expected execution order [1, 2], got %v", executionOrder)
+	}
+	executionOrder = nil
+	mu.Unlock()
+
+	// Test with regular key - only unconditional hook should execute
+	_ = cache.Set("regular", "value2", time.Hour)
+	cache.Get("regular")
+
+	mu.Lock()
+	if len(executionOrder) != 1 {
+		t.Fatalf("Expected 1 hook to execute, got %d", len(executionOrder))
+	}
+	if executionOrder[0] != 2 {
+		t.Fatalf("Expected hook #2 to execute, got #%d", executionOrder[0])
+	}
+	mu.Unlock()
+}
+
+func TestHookKeyPrefix(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	record := func(name string) func(context.Context, string, any) {
+		return func(_ context.Context, _ string, _ any) {
+			mu.Lock()
+			calls = append(calls, name)
+			mu.Unlock()
+		}
+	}
+
+	hooks := NewHooks()
+	hooks.AddOnHit(record("all"), WithPriority(5))
+	hooks.AddOnHit(record("user"), WithKeyPrefix("user:"), WithPriority(1))
+	hooks.AddOnHit(record("admin"), WithKeyPrefix("user:admin:"), WithPriority(10))
+	session := hooks.AddOnHit(record("session"), WithKeyPrefix("session:"))
+
+	cache, err := New(NewDefaultConfig().WithHooks(hooks))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	tests := []struct {
+		key      string
+		expected []string
+	}{
+		{"user:admin:1", []string{"admin", "all", "user"}},
+		{"user:42", []string{"all", "user"}},
+		{"session:abc", []string{"all", "session"}},
+		{"order:7", []string{"all"}},
+		{"u", []string{"all"}},
+	}
+	for _, tt := range tests {
+		calls = nil
+		_ = cache.Set(tt.key, "value", time.Hour)
+		cache.Get(tt.key)
+		if !slices.Equal(calls, tt.expected) {
+			t.Errorf("Get(%q): expected hooks %v, got %v", tt.key, tt.expected, calls)
+		}
+	}
+
+	session.Remove()
+	calls = nil
+	cache.Get("session:abc")
+	if !slices.Equal(calls, []string{"all"}) {
+		t.Errorf("Expected removed prefix hook not to run, got %v", calls)
+	}
+}
+
+// invalidatingStore is a memory store that reports changes made by other clients
+type invalidatingStore struct {
+	store.Store
+	callback store.InvalidateCallback
+}
+
+func (s *invalidatingStore) SetInvalidateCallback(callback store.InvalidateCallback) {
+	s.callback = callback
+}
+
+func TestHookRemoteInvalidation(t *testing.T) {
+	backing, _ := memory.NewWithStrategy(eviction.Config{Type: eviction.LRU, Capacity: 10})
+	customStore := &invalidatingStore{Store: backing}
+
+	var remote, local []string
+	hooks := NewHooks()
+	hooks.AddOnInvalidate(func(ctx context.Context, key string) {
+		if IsRemoteInvalidation(ctx) {
+			remote = append(remote, key)
+		} else {
+			local = append(local, key)
+		}
+	})
+
+	cache, err := New(NewDefaultConfig().WithCustomStore(customStore).WithHooks(hooks))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	t.Cleanup(func() { _ = cache.Close() })
+	if customStore.callback == nil {
+		t.Fatal("Expected the cache to register an invalidate callback")
+	}
+
+	_ = cache.Set("mine", "value", time.Hour)
+	_ = cache.Delete("mine")
+	customStore.callback([]string{"theirs"})
+	customStore.callback(nil) // A flush names no keys
+
+	if len(local) != 1 || local[0] != "mine" {
+		t.Errorf("Expected one local invalidation of mine, got %v", local)
+	}
+	if len(remote) != 1 || remote[0] != "theirs" {
+		t.Errorf("Expected one remote invalidation of theirs, got %v", remote)
+	}
+	if cache.Stats().Invalidations() != 1 {
+		t.Errorf("Expected remote invalidations not to be counted, got %d", cache.Stats().Invalidations())
+	}
+}
+
+func TestHookStats(t *testing.T) {
+	hooks := NewHooks()
+	hooks.AddOnHit(func(context.Context, string, any) {
+		time.Sleep(5 * time.Millisecond)
+	})
+	hooks.AddOnHit(func(context.Context, string, any) {})
+	hooks.AddOnMiss(func(context.Context, string) {}, WithKeyPrefix("user:"))
+
+	cache, err := New(NewDefaultConfig().WithHooks(hooks))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	_ = cache.Set("key", "value", time.Hour)
+	cache.Get("key")
+	cache.Get("key")
+	cache.Get("missing") // Outside the OnMiss prefix, so no hook runs
+
+	stats := cache.HookStats()
+	hit := stats["OnHit"]
+	if hit.Calls != 2 {
+		t.Errorf("Expected 2 OnHit runs, got %d", hit.Calls)
+	}
+	if hit.Last < 5*time.Millisecond || hit.Total < 10*time.Millisecond || hit.Average() < 5*time.Millisecond {
+		t.Errorf("Expected OnHit durations to include the slow hook, got %+v", hit)
+	}
+	if miss := stats["OnMiss"]; miss.Calls != 0 {
+		t.Errorf("Expected no OnMiss runs, got %+v", miss)
+	}
+	if set := stats["OnSet"]; set != (HookStats{}) {
+		t.Errorf("Expected no stats for an event without hooks, got %+v", set)
+	}
+}
+
+func TestHookStatsWithoutTiming(t *testing.T) {
+	hooks := NewHooks().WithTiming(false)
+	hooks.AddOnHit(func(context.Context, string, any) {
+		time.Sleep(time.Millisecond)
+	})
+
+	cache, err := New(NewDefaultConfig().WithHooks(hooks))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	_ = cache.Set("key", "value", time.Hour)
+	cache.Get("key")
+
+	if hit := cache.HookStats()["OnHit"]; hit.Calls != 1 || hit.Total != 0 || hit.Last != 0 {
+		t.Errorf("Expected the run to be counted but not timed, got %+v", hit)
+	}
+}
+
+func TestHookOnRefresh(t *testing.T) {
+	type refresh struct {
+		key      string
+		err      error
+		duration time.Duration
+	}
+	var mu sync.Mutex
+	var refreshes []refresh
+
+	hooks := NewHooks()
+	hooks.AddOnRefresh(func(_ context.Context, key string, err error, duration time.Duration) {
+		mu.Lock()
+		refreshes = append(refreshes, refresh{key, err, duration})
+		mu.Unlock()
+	}, WithKeyPrefix("user:"))
+
+	cache, err := New(NewDefaultConfig().WithHooks(hooks))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	ctx := context.Background()
+	_ = cache.Set("user:1", "old", time.Hour)
+
+	slowLoad := func(context.Context) (any, error) {
+		time.Sleep(5 * time.Millisecond)
+		return "new", nil
+	}
+	if err := cache.Refresh(ctx, "user:1", slowLoad, time.Hour); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if value, _ := cache.Get("user:1"); value != "new" {
+		t.Errorf("Expected the refreshed value, got %v", value)
+	}
+
+	loadErr := errors.New("backend down")
+	failingLoad := func(context.Context) (any, error) { return nil, loadErr }
+	if err := cache.Refresh(ctx, "user:1", failingLoad, time.Hour); !errors.Is(err, loadErr) {
+		t.Errorf("Expected the loader error, got %v", err)
+	}
+	if value, _ := cache.Get("user:1"); value != "new" {
+		t.Errorf("Expected a failed refresh to keep the cached value, got %v", value)
+	}
+
+	// Outside the hook's prefix: counted but not passed to the hook
+	_ = cache.Refresh(ctx, "order:1", slowLoad, time.Hour)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(refreshes) != 2 {
+		t.Fatalf("Expected 2 refreshes passed to the hook, got %v", refreshes)
+	}
+	if refreshes[0].key != "user:1" || refreshes[0].err != nil || refreshes[0].duration < 5*time.Millisecond {
+		t.Errorf("Unexpected successful refresh %+v", refreshes[0])
+	}
+	if !errors.Is(refreshes[1].err, loadErr) {
+		t.Errorf("Expected the failed refresh to carry its error, got %+v", refreshes[1])
+	}
+
+	stats := cache.Stats()
+	if stats.Refreshes() != 3 || stats.RefreshFailures() != 1 {
+		t.Errorf("Expected 3 refreshes and 1 failure, got %d and %d", stats.Refreshes(), stats.RefreshFailures())
+	}
+}
+
+func TestHookSampleRate(t *testing.T) {
+	var calls atomic.Int64
+	hooks := NewHooks()
+	handle := hooks.AddOnMiss(func(context.Context, string) {
+		calls.Add(1)
+	}, WithSampleRate(0.1), WithCondition(func(_ context.Context, key string) bool {
+		return key != "ignored"
+	}))
+
+	cache, err := New(NewDefaultConfig().WithHooks(hooks))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	const goroutines, perGoroutine = 8, 2500
+	var wg sync.WaitGroup
+	for range goroutines {
+		wg.Go(func() {
+			for range perGoroutine {
+				cache.Get("missing")
+			}
+		})
+	}
+	wg.Wait()
+	cache.Get("ignored") // Fails the condition, so it is not counted as skipped
+
+	// 10% of 20000 is 2000 with a standard deviation of about 42
+	const total = goroutines * perGoroutine
+	ran := calls.Load()
+	if ran < 1700 || ran > 2300 {
+		t.Errorf("Expected about 2000 of %d events sampled, got %d", total, ran)
+	}
+	if skipped := handle.Skipped(); skipped != total-ran {
+		t.Errorf("Expected %d skipped events, got %d", total-ran, skipped)
+	}
+	if stats := hooks.Stats()["OnMiss"]; stats.Skipped != total-ran {
+		t.Errorf("Expected HookStats to report %d skipped events, got %d", total-ran, stats.Skipped)
+	}
+}
+
+func TestHookRateLimit(t *testing.T) {
+	var limited, unlimited atomic.Int64
+	hooks := NewHooks()
+	handle := hooks.AddOnHit(func(context.Context, string, any) {
+		limited.Add(1)
+	}, WithRateLimit(10, 100*time.Millisecond))
+	hooks.AddOnHit(func(context.Context, string, any) {
+		unlimited.Add(1)
+	})
+
+	cache, err := New(NewDefaultConfig().WithHooks(hooks))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	_ = cache.Set("key", "value", time.Hour)
+
+	burst := func() {
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Go(func() {
+				for range 500 {
+					cache.Get("key")
+				}
+			})
+		}
+		wg.Wait()
+	}
+
+	// The full bucket allows a burst of 10, plus 1 per 10ms the burst takes
+	start := time.Now()
+	burst()
+	allowed := 10 + int64(time.Since(start)/(10*time.Millisecond)) + 1
+	if n := limited.Load(); n < 10 || n > allowed {
+		t.Errorf("Expected between 10 and %d runs in a burst, got %d", allowed, n)
+	}
+
+	// After a full interval the bucket has refilled
+	time.Sleep(100 * time.Millisecond)
+	before := limited.Load()
+	start = time.Now()
+	burst()
+	allowed = 10 + int64(time.Since(start)/(10*time.Millisecond)) + 1
+	if n := limited.Load() - before; n < 10 || n > allowed {
+		t.Errorf("Expected between 10 and %d runs after refilling, got %d", allowed, n)
+	}
+
+	if n := unlimited.Load(); n != 8000 {
+		t.Errorf("Expected the other hook to run for every hit, got %d", n)
+	}
+	if skipped := handle.Skipped(); skipped != 8000-limited.Load() {
+		t.Errorf("Expected %d skipped events, got %d", 8000-limited.Load(), skipped)
+	}
+}
+
+func TestHookOnCapacity(t *testing.T) {
+	type call struct{ length, capacity int }
+	var mu sync.Mutex
+	var calls []call
+
+	hooks := NewHooks()
+	hooks.AddOnCapacity(func(_ context.Context, length, capacity int) {
+		mu.Lock()
+		calls = append(calls, call{length, capacity})
+		mu.Unlock()
+	})
+	config := NewDefaultConfig().WithMaxEntries(10).WithHooks(hooks).
+		WithCapacityWatermarks(200*time.Millisecond, 1.0, 0.5)
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	set := func(from, to int) {
+		for i := from; i < to; i++ {
+			_ = cache.Set(fmt.Sprintf("key%d", i), i, time.Hour)
+		}
+	}
+	expect := func(step string, expected ...call) {
+		t.Helper()
+		mu.Lock()
+		defer mu.Unlock()
+		if !slices.Equal(calls, expected) {
+			t.Errorf("%s: expected calls %v, got %v", step, expected, calls)
+		}
+		calls = nil
+	}
+
+	set(0, 4)
+	expect("under the watermarks")
+	set(4, 5)
+	expect("reaching 50%", call{5, 10})
+	set(5, 10)
+	expect("reaching 100%", call{10, 10})
+	set(10, 20)
+	expect("evicting while full", call{10, 10}) // Once per interval, not per write
+
+	// Falling below 50% re-arms it, but it fired within the interval
+	for i := 10; i < 16; i++ {
+		_ = cache.Delete(fmt.Sprintf("key%d", i))
+	}
+	set(20, 21)
+	expect("re-crossing 50% within the interval")
+
+	time.Sleep(250 * time.Millisecond)
+	set(21, 27)
+	expect("filling up again", call{10, 10}, call{10, 10})
 }
@@ -0,0 +1,54 @@
+package obcache
+
+import (
+	"github.com/1mb-dev/obcache-go/v2/pkg/metrics"
+)
+
+// KeyCount is a frequently read key reported by TopKeys
+type KeyCount struct {
+	// Key is the cache key
+	Key string
+
+	// Reads is the estimated number of recent reads of the key, hits and misses
+	// alike. Counts are halved periodically so past popularity fades
+	Reads int64
+
+	// Cached reports whether the key was in the cache when TopKeys was called;
+	// a hot key that is not cached was evicted or expired
+	Cached bool
+}
+
+// TopKeys returns up to n of the most frequently read keys, most read first
+// Requires Config.HotKeys and returns nil otherwise. n is capped at
+// Config.HotKeys. Checking Cached looks each key up in the store
+func (c *Cache) TopKeys(n int) []KeyCount {
+	if c.hotKeys == nil {
+		return nil
+	}
+
+	top := c.hotKeys.Top(n)
+	keys := make([]KeyCount, len(top))
+	for i, count := range top {
+		_, cached := c.store.Peek(count.Key)
+		keys[i] = KeyCount{Key: count.Key, Reads: count.Count, Cached: cached}
+	}
+	return keys
+}
+
+// exportHotKeys replaces the cache's hot key gauges with the current top keys
+// Exporters that cannot delete series keep gauges of keys that cooled down
+func (c *Cache) exportHotKeys() {
+	name := metrics.DefaultMetricNames().HotKeyReads
+	if deleter, ok := c.metricsExporter.(metrics.GaugeDeleter); ok {
+		c.exportFailed(deleter.DeleteGauges(name, c.metricsLabels))
+	}
+
+	for _, count := range c.hotKeys.Top(c.hotKeys.Size()) {
+		labels := make(metrics.Labels, len(c.metricsLabels)+1)
+		for k, v := range c.metricsLabels {
+			labels[k] = v
+		}
+		labels["key"] = count.Key
+		c.exportFailed(c.metricsExporter.SetGauge(name, float64(count.Count), labels))
+	}
+}
@@ -0,0 +1,140 @@
+package obcache
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// readZipf reads n Zipfian-distributed keys out of keys from cache and returns
+// the keys ordered by how often they were read
+func readZipf(cache *Cache, n, keys int) []string {
+	zipf := rand.NewZipf(rand.New(rand.NewPCG(3, 4)), 1.2, 1, uint64(keys-1))
+	counts := make(map[string]int)
+	for range n {
+		key := fmt.Sprintf("key%d", zipf.Uint64())
+		counts[key]++
+		cache.Get(key)
+	}
+
+	ranked := make([]string, 0, len(counts))
+	for key := range counts {
+		ranked = append(ranked, key)
+	}
+	slices.SortFunc(ranked, func(a, b string) int { return counts[b] - counts[a] })
+	return ranked
+}
+
+func TestTopKeys(t *testing.T) {
+	cache, err := New(NewDefaultConfig().WithMaxEntries(100).WithHotKeys(10))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	for i := range 100 {
+		_ = cache.Set(fmt.Sprintf("key%d", i), i, time.Hour)
+	}
+	_ = cache.Delete("key0")
+
+	ranked := readZipf(cache, 20000, 5000)
+
+	top := cache.TopKeys(10)
+	if len(top) != 10 {
+		t.Fatalf("Expected 10 hot keys, got %d", len(top))
+	}
+	reported := make([]string, len(top))
+	for i, key := range top {
+		reported[i] = key.Key
+	}
+	for _, key := range ranked[:5] {
+		if !slices.Contains(reported, key) {
+			t.Errorf("Expected true top key %s in %v", key, reported)
+		}
+	}
+	if top[0].Key != "key0" || top[0].Cached {
+		t.Errorf("Expected the deleted key0 to be hottest and not cached, got %+v", top[0])
+	}
+	if !top[1].Cached || top[1].Reads <= 0 {
+		t.Errorf("Expected the second hottest key to be cached with reads, got %+v", top[1])
+	}
+}
+
+func TestTopKeysDisabled(t *testing.T) {
+	cache, err := New(NewDefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	cache.Get("key")
+	if top := cache.TopKeys(10); top != nil {
+		t.Errorf("Expected no hot keys without Config.HotKeys, got %v", top)
+	}
+}
+
+func TestTopKeysMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	exporter, err := metrics.NewPrometheusExporter(nil, &metrics.PrometheusConfig{Registry: registry})
+	if err != nil {
+		t.Fatalf("Failed to create Prometheus exporter: %v", err)
+	}
+	cache, err := New(NewDefaultConfig().
+		WithHotKeys(3).
+		WithMetrics(&MetricsConfig{Exporter: exporter, Enabled: true, CacheName: "hot", ExportHotKeys: true}))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	hotSeries := func() map[string]float64 {
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatalf("Failed to gather metrics: %v", err)
+		}
+		series := make(map[string]float64)
+		for _, family := range families {
+			if family.GetName() != "obcache_hot_key_reads" {
+				continue
+			}
+			for _, metric := range family.GetMetric() {
+				for _, label := range metric.GetLabel() {
+					if label.GetName() == "key" {
+						series[label.GetValue()] = metric.GetGauge().GetValue()
+					}
+				}
+			}
+		}
+		return series
+	}
+
+	for i := range 20 {
+		for range 20 - i {
+			cache.Get(fmt.Sprintf("a%d", i))
+		}
+	}
+	cache.exportCurrentStats()
+	if got := hotSeries(); len(got) != 3 || got["a0"] != 20 {
+		t.Errorf("Expected 3 series led by a0 with 20 reads, got %v", got)
+	}
+
+	// Keys that are no longer hot lose their series
+	for i := range 5 {
+		for range 100 {
+			cache.Get(fmt.Sprintf("b%d", i))
+		}
+	}
+	cache.exportCurrentStats()
+	got := hotSeries()
+	if len(got) != 3 {
+		t.Errorf("Expected the series to stay capped at 3, got %v", got)
+	}
+	if _, stale := got["a0"]; stale {
+		t.Errorf("Expected a0 to be dropped, got %v", got)
+	}
+}
@@ -0,0 +1,81 @@
+package obcache
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/store"
+)
+
+// ErrIntrospectionUnsupported is returned by AccessStats and ColdestKeys when the
+// eviction strategy does not track per-key access data
+var ErrIntrospectionUnsupported = errors.New("eviction strategy does not support access introspection")
+
+// AccessStats describes how a cached key has been used, as seen by the eviction strategy
+type AccessStats struct {
+	// Frequency is the key's access count, after LFU aging.
+	// It is -1 when the strategy does not count accesses
+	Frequency int64
+
+	// RecencyRank is the number of keys used less recently than this one, so the
+	// least recently used key has rank 0. It is -1 when the strategy does not
+	// order keys by access
+	RecencyRank int
+}
+
+// AccessStats returns the eviction strategy's usage data for key without
+// counting as an access. Frequency is reported by the LFU strategy and
+// RecencyRank by the LRU strategy. Returns ErrIntrospectionUnsupported for
+// other strategies and ErrNotFound if the key is not cached.
+func (c *Cache) AccessStats(key string) (AccessStats, error) {
+	frequencyReporter, recencyReporter, err := c.accessReporters()
+	if err != nil {
+		return AccessStats{}, err
+	}
+
+	stats := AccessStats{Frequency: -1, RecencyRank: -1}
+	found := false
+	if frequencyReporter != nil {
+		stats.Frequency, found = frequencyReporter.Frequency(key)
+	}
+	if recencyReporter != nil {
+		stats.RecencyRank, found = recencyReporter.Recency(key)
+	}
+	if !found {
+		return AccessStats{}, fmt.Errorf("access stats for %q: %w", key, ErrNotFound)
+	}
+	return stats, nil
+}
+
+// ColdestKeys returns up to n keys starting from the least frequently used (LFU)
+// or least recently used (LRU). Pinned keys are included, so the result can
+// differ from the order Evict would remove entries in.
+// Returns ErrIntrospectionUnsupported for other strategies.
+func (c *Cache) ColdestKeys(n int) ([]string, error) {
+	frequencyReporter, recencyReporter, err := c.accessReporters()
+	if err != nil {
+		return nil, err
+	}
+	if n <= 0 {
+		return []string{}, nil
+	}
+
+	if frequencyReporter != nil {
+		return frequencyReporter.ColdestKeys(n), nil
+	}
+	return recencyReporter.ColdestKeys(n), nil
+}
+
+// accessReporters returns the store's access reporters; at least one is non-nil on success
+func (c *Cache) accessReporters() (store.FrequencyReporter, store.RecencyReporter, error) {
+	accessStore, ok := c.store.(store.AccessStore)
+	if !ok {
+		return nil, nil, ErrIntrospectionUnsupported
+	}
+
+	frequency, recency := accessStore.FrequencyReporter(), accessStore.RecencyReporter()
+	if frequency == nil && recency == nil {
+		return nil, nil, ErrIntrospectionUnsupported
+	}
+	return frequency, recency, nil
+}
@@ -0,0 +1,100 @@
+package obcache
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/internal/eviction"
+)
+
+func TestAccessStatsLFU(t *testing.T) {
+	cache, err := New(NewDefaultConfig().WithEvictionType(eviction.LFU))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	for _, key := range []string{"a", "b", "c"} {
+		_ = cache.Set(key, key, time.Hour)
+	}
+	for range 3 {
+		cache.Get("a")
+	}
+	cache.Get("b")
+
+	stats, err := cache.AccessStats("a")
+	if err != nil {
+		t.Fatalf("AccessStats failed: %v", err)
+	}
+	if stats.Frequency != 4 || stats.RecencyRank != -1 {
+		t.Errorf("Expected frequency 4 and no recency rank, got %+v", stats)
+	}
+
+	// Introspection must not count as an access
+	if stats, _ := cache.AccessStats("a"); stats.Frequency != 4 {
+		t.Errorf("Expected AccessStats not to change frequency, got %d", stats.Frequency)
+	}
+
+	coldest, err := cache.ColdestKeys(2)
+	if err != nil {
+		t.Fatalf("ColdestKeys failed: %v", err)
+	}
+	if !reflect.DeepEqual(coldest, []string{"c", "b"}) {
+		t.Errorf("Expected coldest keys [c b], got %v", coldest)
+	}
+
+	if _, err := cache.AccessStats("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for missing key, got %v", err)
+	}
+}
+
+func TestAccessStatsLRU(t *testing.T) {
+	cache, err := New(NewDefaultConfig().WithEvictionType(eviction.LRU))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	for _, key := range []string{"a", "b", "c"} {
+		_ = cache.Set(key, key, time.Hour)
+	}
+	cache.Get("a")
+
+	stats, err := cache.AccessStats("b")
+	if err != nil {
+		t.Fatalf("AccessStats failed: %v", err)
+	}
+	if stats.RecencyRank != 0 || stats.Frequency != -1 {
+		t.Errorf("Expected b to be least recently used with no frequency, got %+v", stats)
+	}
+	if stats, _ := cache.AccessStats("a"); stats.RecencyRank != 2 {
+		t.Errorf("Expected a to be most recently used, got rank %d", stats.RecencyRank)
+	}
+
+	coldest, err := cache.ColdestKeys(10)
+	if err != nil {
+		t.Fatalf("ColdestKeys failed: %v", err)
+	}
+	if !reflect.DeepEqual(coldest, []string{"b", "c", "a"}) {
+		t.Errorf("Expected coldest keys [b c a], got %v", coldest)
+	}
+}
+
+func TestAccessStatsUnsupported(t *testing.T) {
+	cache, err := New(NewDefaultConfig().WithEvictionType(eviction.FIFO))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	_ = cache.Set("a", 1, time.Hour)
+
+	if _, err := cache.AccessStats("a"); !errors.Is(err, ErrIntrospectionUnsupported) {
+		t.Errorf("Expected ErrIntrospectionUnsupported from AccessStats, got %v", err)
+	}
+	if _, err := cache.ColdestKeys(1); !errors.Is(err, ErrIntrospectionUnsupported) {
+		t.Errorf("Expected ErrIntrospectionUnsupported from ColdestKeys, got %v", err)
+	}
+}
@@ -1,12 +1,19 @@
 package obcache
 
 import (
+	"context"
+	"expvar"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/1mb-dev/obcache-go/v2/internal/eviction"
+	"github.com/1mb-dev/obcache-go/v2/pkg/compression"
 	"github.com/1mb-dev/obcache-go/v2/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // MockExporter for testing metrics integration
@@ -106,9 +113,15 @@ func (m *MockExporter) Close() error {
 }
 
 func (m *MockExporter) labelsKey(labels metrics.Labels) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
 	result := ""
-	for k, v := range labels {
-		result += k + "=" + v + ","
+	for _, k := range keys {
+		result += k + "=" + labels[k] + ","
 	}
 	return result
 }
@@ -566,3 +579,341 @@ func BenchmarkMetricsOverhead(b *testing.B) {
 		}
 	})
 }
+
+func TestMetricsStrategyInstrumentation(t *testing.T) {
+	mockExporter := NewMockExporter()
+
+	config := NewDefaultConfig().
+		WithMaxEntries(2).
+		WithEvictionType(eviction.LFU).
+		WithMetrics(&MetricsConfig{
+			Exporter:             mockExporter,
+			Enabled:              true,
+			CacheName:            "strategy-cache",
+			ExportStrategyEvents: true,
+		})
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache with metrics: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	_ = cache.Set("a", 1, time.Hour)
+	_ = cache.Set("b", 2, time.Hour)
+	cache.Get("a")
+	_ = cache.Set("c", 3, time.Hour) // Evicts b
+
+	names := metrics.DefaultMetricNames()
+	if n := mockExporter.GetCounterValue(names.StrategyAdmissionsTotal); n != 3 {
+		t.Errorf("Expected 3 admissions, got %d", n)
+	}
+	if n := mockExporter.GetCounterValue(names.StrategyPromotionsTotal); n != 1 {
+		t.Errorf("Expected 1 promotion, got %d", n)
+	}
+
+	mockExporter.mu.RLock()
+	defer mockExporter.mu.RUnlock()
+	found := false
+	for key, values := range mockExporter.histograms {
+		if strings.HasPrefix(key, names.StrategyVictimSelectDuration) {
+			found = len(values) == 1 && strings.Contains(key, "strategy=lfu")
+		}
+	}
+	if !found {
+		t.Errorf("Expected one victim selection timing labelled strategy=lfu, got %v", mockExporter.histograms)
+	}
+}
+
+func TestMetricsStrategyInstrumentationOptIn(t *testing.T) {
+	mockExporter := NewMockExporter()
+
+	config := NewDefaultConfig().
+		WithMetrics(&MetricsConfig{
+			Exporter:  mockExporter,
+			Enabled:   true,
+			CacheName: "strategy-cache",
+		})
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache with metrics: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	_ = cache.Set("a", 1, time.Hour)
+	cache.Get("a")
+
+	names := metrics.DefaultMetricNames()
+	if n := mockExporter.GetCounterValue(names.StrategyAdmissionsTotal); n != 0 {
+		t.Errorf("Expected no strategy events without ExportStrategyEvents, got %d admissions", n)
+	}
+}
+
+func TestMetricsCompression(t *testing.T) {
+	mockExporter := NewMockExporter()
+
+	config := NewDefaultConfig().
+		WithCompression(compression.NewDefaultConfig().WithEnabled(true).WithMinSize(100)).
+		WithMetrics(&MetricsConfig{
+			Exporter:  mockExporter,
+			Enabled:   true,
+			CacheName: "compression-cache",
+		})
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache with metrics: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	_ = cache.Set("large", strings.Repeat("compressible ", 100), time.Hour)
+	_ = cache.Set("small", "tiny", time.Hour)
+	cache.Get("large")
+	cache.exportCurrentStats()
+
+	stats := cache.Stats()
+	if stats.CompressedEntries() != 1 {
+		t.Errorf("Expected only the large value to be compressed, got %d", stats.CompressedEntries())
+	}
+	if stats.CompressionOriginalBytes() <= stats.CompressionCompressedBytes() || stats.CompressionCompressedBytes() == 0 {
+		t.Errorf("Expected compression to shrink the value, got %d -> %d bytes",
+			stats.CompressionOriginalBytes(), stats.CompressionCompressedBytes())
+	}
+	if ratio := stats.CompressionRatio(); ratio <= 0 || ratio >= 1 {
+		t.Errorf("Expected a ratio between 0 and 1, got %v", ratio)
+	}
+	if stats.CompressionTime() <= 0 || stats.DecompressionTime() <= 0 {
+		t.Errorf("Expected encoding and decoding time to be tracked, got %v and %v", stats.CompressionTime(), stats.DecompressionTime())
+	}
+
+	names := metrics.DefaultMetricNames()
+	labels := mockExporter.labelsKey(metrics.Labels{"cache_name": "compression-cache"})
+	mockExporter.mu.RLock()
+	defer mockExporter.mu.RUnlock()
+	if n := mockExporter.counters[names.CompressedEntriesTotal+labels]; n != 1 {
+		t.Errorf("Expected 1 compressed entry counted, got %d", n)
+	}
+	if n := len(mockExporter.histograms[names.CompressionDuration+labels]); n != 2 {
+		t.Errorf("Expected a compression timing per write, got %d", n)
+	}
+	if n := len(mockExporter.histograms[names.DecompressionDuration+labels]); n != 1 {
+		t.Errorf("Expected a decompression timing per read, got %d", n)
+	}
+	if ratio := mockExporter.gauges[names.CompressionRatio+labels]; ratio != stats.CompressionRatio() {
+		t.Errorf("Expected the ratio gauge to match the stats, got %v", ratio)
+	}
+	if original := mockExporter.gauges[names.CompressionOriginalBytes+labels]; original != float64(stats.CompressionOriginalBytes()) {
+		t.Errorf("Expected the original bytes gauge to match the stats, got %v", original)
+	}
+}
+
+func TestMetricsHookDurations(t *testing.T) {
+	mockExporter := NewMockExporter()
+	hooks := NewHooks()
+	hooks.AddOnHit(func(context.Context, string, any) {})
+	hooks.AddOnInvalidate(func(context.Context, string) {})
+
+	config := NewDefaultConfig().
+		WithHooks(hooks).
+		WithMetrics(&MetricsConfig{
+			Exporter:            mockExporter,
+			Enabled:             true,
+			CacheName:           "hook-cache",
+			ExportHookDurations: true,
+		})
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache with metrics: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	_ = cache.Set("key", "value", time.Hour)
+	cache.Get("key")
+	cache.Get("key")
+	_ = cache.Delete("key")
+
+	names := metrics.DefaultMetricNames()
+	hookKey := func(hook string) string {
+		return names.HookDuration + mockExporter.labelsKey(metrics.Labels{"cache_name": "hook-cache", metrics.LabelHook: hook})
+	}
+	mockExporter.mu.RLock()
+	defer mockExporter.mu.RUnlock()
+	if n := len(mockExporter.histograms[hookKey("OnHit")]); n != 2 {
+		t.Errorf("Expected an OnHit timing per hit, got %d", n)
+	}
+	if n := len(mockExporter.histograms[hookKey("OnInvalidate")]); n != 1 {
+		t.Errorf("Expected one OnInvalidate timing, got %d", n)
+	}
+	if n := len(mockExporter.histograms[hookKey("OnSet")]); n != 0 {
+		t.Errorf("Expected no timings for events without hooks, got %d", n)
+	}
+}
+
+func TestMetricsExpvarExporter(t *testing.T) {
+	exporter, err := metrics.NewExpvarExporter("obcache_cache_test")
+	if err != nil {
+		t.Fatalf("Failed to create expvar exporter: %v", err)
+	}
+	cache, err := New(NewDefaultConfig().WithMetrics(&MetricsConfig{Exporter: exporter, Enabled: true}))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	for i := range 3 {
+		_ = cache.Set(fmt.Sprintf("key%d", i), i, time.Hour)
+	}
+	cache.Get("key0")
+	cache.Get("key1")
+	cache.Get("missing")
+	cache.exportCurrentStats()
+
+	vars := expvar.Get("obcache_cache_test").(*expvar.Map)
+	expected := map[string]int64{"hits": 2, "misses": 1, "keys": 3, "evictions": 0}
+	for name, want := range expected {
+		if got := vars.Get(name).(*expvar.Int).Value(); got != want {
+			t.Errorf("Expected %s = %d, got %d", name, want, got)
+		}
+	}
+	if rate := vars.Get("hit_rate").(*expvar.Float).Value(); rate != cache.Stats().HitRate() {
+		t.Errorf("Expected the hit rate %v, got %v", cache.Stats().HitRate(), rate)
+	}
+
+	operations := vars.Get("operations").(*expvar.Map)
+	for op, want := range map[string]int64{"get": 3, "set": 3} {
+		summary := operations.Get(op).(*expvar.Map)
+		if count := summary.Get("count").(*expvar.Int).Value(); count != want {
+			t.Errorf("Expected %d %s operations, got %d", want, op, count)
+		}
+		if total := summary.Get("total_seconds").(*expvar.Float).Value(); total <= 0 {
+			t.Errorf("Expected %s latency to be recorded, got %v", op, total)
+		}
+	}
+}
+
+func TestMetricsPrometheusEvictionsByReason(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	exporter, err := metrics.NewPrometheusExporter(nil, &metrics.PrometheusConfig{Registry: registry})
+	if err != nil {
+		t.Fatalf("Failed to create Prometheus exporter: %v", err)
+	}
+	config := NewDefaultConfig().
+		WithMaxEntries(2).
+		WithMetrics(&MetricsConfig{Exporter: exporter, Enabled: true, CacheName: "reasons"})
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	evictions := func() map[string]float64 {
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatalf("Failed to gather metrics: %v", err)
+		}
+		counts := make(map[string]float64)
+		for _, family := range families {
+			if family.GetName() != "obcache_evictions_total" {
+				continue
+			}
+			for _, metric := range family.GetMetric() {
+				for _, label := range metric.GetLabel() {
+					if label.GetName() == "reason" {
+						counts[label.GetValue()] = metric.GetCounter().GetValue()
+					}
+				}
+			}
+		}
+		return counts
+	}
+
+	_ = cache.Set("expiring", "value", TestShortTTL)
+	time.Sleep(2 * TestShortTTL)
+	cache.Cleanup()
+	cache.exportCurrentStats()
+
+	if got := evictions(); got["ttl"] != 1 || got["capacity"] != 0 {
+		t.Errorf("Expected only a ttl eviction after cleanup, got %v", got)
+	}
+
+	for i := range 3 {
+		_ = cache.Set(fmt.Sprintf("key%d", i), i, time.Hour)
+	}
+	cache.exportCurrentStats()
+	cache.exportCurrentStats()
+
+	if got := evictions(); got["ttl"] != 1 || got["capacity"] != 1 {
+		t.Errorf("Expected one ttl and one capacity eviction, got %v", got)
+	}
+}
+
+func TestMetricsCacheCollector(t *testing.T) {
+	cache, err := New(NewDefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(metrics.NewCacheCollector(cache, metrics.Labels{"cache_name": "scraped"})); err != nil {
+		t.Fatalf("Failed to register collector: %v", err)
+	}
+
+	_ = cache.Set("key", "value", time.Hour)
+	cache.Get("key")
+	cache.Get("missing")
+
+	// No reporting interval: the scrape reads the current stats
+	values := make(map[string]float64)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			values[family.GetName()] = metric.GetCounter().GetValue() + metric.GetGauge().GetValue()
+		}
+	}
+	if values["obcache_hits_total"] != 1 || values["obcache_misses_total"] != 1 ||
+		values["obcache_sets_total"] != 1 || values["obcache_keys_count"] != 1 || values["obcache_hit_rate"] != 50 {
+		t.Errorf("Unexpected scraped values: %v", values)
+	}
+}
+
+func TestMetricsStoreOperationDuration(t *testing.T) {
+	mockExporter := NewMockExporter()
+	cache, err := New(NewDefaultConfig().WithMetrics(&MetricsConfig{Exporter: mockExporter, Enabled: true, CacheName: "backend"}))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	_ = cache.Set("key", "value", time.Hour)
+	cache.Get("key")
+	cache.Get("missing")
+	_ = cache.Delete("key")
+
+	// Exporters without RecordStoreOperation receive a histogram per operation
+	name := metrics.DefaultMetricNames().StoreOperationDuration
+	mockExporter.mu.RLock()
+	defer mockExporter.mu.RUnlock()
+	for operation, want := range map[metrics.Operation]int{metrics.OperationGet: 2, metrics.OperationSet: 1, metrics.OperationDelete: 1} {
+		key := name + mockExporter.labelsKey(metrics.Labels{"cache_name": "backend", "operation": string(operation)})
+		if n := len(mockExporter.histograms[key]); n != want {
+			t.Errorf("Expected %d store timings for %s, got %d", want, operation, n)
+		}
+	}
+	if n := len(mockExporter.operationsLogged); n != 3 {
+		t.Errorf("Expected the 3 end-to-end get and set timings to be unchanged, got %d", n)
+	}
+}
+
+func TestMetricsStoreOperationDurationDisabled(t *testing.T) {
+	cache, err := New(NewDefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	if cache.timeStore || !cache.storeTimer().IsZero() {
+		t.Error("Expected store calls not to be timed without metrics")
+	}
+}
@@ -0,0 +1,152 @@
+package obcache
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/internal/snapshot"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+)
+
+// validatePersistence checks that persistence is configured for a memory store
+func validatePersistence(config *Config) error {
+	if config.Persistence == nil {
+		return nil
+	}
+	if config.StoreType != StoreTypeMemory {
+		return fmt.Errorf("persistence is only supported for the memory store")
+	}
+	if config.Persistence.Path == "" {
+		return fmt.Errorf("persistence path is required")
+	}
+	return nil
+}
+
+// initializePersistence restores the snapshot and starts the background writer
+// if persistence is configured
+func (c *Cache) initializePersistence() {
+	persistence := c.config.Persistence
+	if persistence == nil {
+		return
+	}
+
+	if !persistence.SkipLoad {
+		c.loadSnapshot()
+	}
+
+	if persistence.Interval > 0 {
+		c.persistStop = make(chan struct{})
+		c.persistWg.Add(1)
+		go c.snapshotWriter()
+	}
+}
+
+// loadSnapshot stores the unexpired entries of the snapshot file, oldest access
+// first so the eviction order survives the restart. A missing file is ignored
+// and a corrupt one is reported through OnError and skipped
+func (c *Cache) loadSnapshot() {
+	records, err := snapshot.ReadFile(c.config.Persistence.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		c.persistenceError(fmt.Errorf("ignoring snapshot %s: %w", c.config.Persistence.Path, err))
+		return
+	}
+
+	slices.SortStableFunc(records, func(a, b snapshot.Record) int {
+		return a.Entry.AccessedAt.Compare(b.Entry.AccessedAt)
+	})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, record := range records {
+		if record.Entry.IsExpired() {
+			continue
+		}
+		_ = c.store.Set(record.Key, record.Entry) // Memory store writes do not fail
+	}
+	c.updateKeyCount()
+}
+
+// snapshotWriter periodically writes the store to the snapshot file
+func (c *Cache) snapshotWriter() {
+	defer c.persistWg.Done()
+
+	ticker := time.NewTicker(c.config.Persistence.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.writeSnapshot(); err != nil {
+				c.persistenceError(err)
+			}
+		case <-c.persistStop:
+			return
+		}
+	}
+}
+
+// writeSnapshot writes the unexpired entries of the store to the snapshot file
+// Entries are copied under the cache lock and encoded after it is released
+func (c *Cache) writeSnapshot() error {
+	c.mu.RLock()
+	keys := c.store.Keys()
+	records := make([]snapshot.Record, 0, len(keys))
+	for _, key := range keys {
+		if e, ok := c.store.Peek(key); ok {
+			records = append(records, snapshot.Record{Key: key, Entry: copyEntry(e)})
+		}
+	}
+	c.mu.RUnlock()
+
+	if _, err := snapshot.WriteFile(c.config.Persistence.Path, records); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// stopPersistence stops the background writer and writes the final snapshot
+// if configured. Must be called without c.mu held
+func (c *Cache) stopPersistence() error {
+	if c.config.Persistence == nil {
+		return nil
+	}
+	if c.persistStop != nil {
+		close(c.persistStop)
+		c.persistWg.Wait()
+	}
+	if c.config.Persistence.SnapshotOnClose {
+		return c.writeSnapshot()
+	}
+	return nil
+}
+
+// persistenceError reports err to PersistenceConfig.OnError if it is set
+func (c *Cache) persistenceError(err error) {
+	if c.config.Persistence.OnError != nil {
+		c.config.Persistence.OnError(err)
+	}
+}
+
+// copyEntry returns a copy of e that can be read without the store's locks
+func copyEntry(e *entry.Entry) *entry.Entry {
+	return &entry.Entry{
+		Value:          e.Value,
+		ExpiresAt:      e.Expiry(),
+		CreatedAt:      e.CreatedAt,
+		AccessedAt:     e.LastAccess(),
+		ValueSize:      e.ValueSize,
+		Version:        e.Version,
+		IsCompressed:   e.IsCompressed,
+		CompressorName: e.CompressorName,
+		CodecName:      e.CodecName,
+		DictionaryID:   e.DictionaryID,
+		OriginalSize:   e.OriginalSize,
+		CompressedSize: e.CompressedSize,
+	}
+}
@@ -0,0 +1,135 @@
+package obcache
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/internal/snapshot"
+)
+
+func TestCachePersistenceSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snap")
+
+	config := NewDefaultConfig().WithPersistence(path, 0)
+	config.Persistence.SnapshotOnClose = true
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	if err := cache.Set("user:1", "alice", time.Hour); err != nil {
+		t.Fatalf("Failed to set cache entry: %v", err)
+	}
+	if err := cache.Set("session", "token", 20*time.Millisecond); err != nil {
+		t.Fatalf("Failed to set cache entry: %v", err)
+	}
+	if err := cache.Close(); err != nil {
+		t.Fatalf("Failed to close cache: %v", err)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+
+	cache, err = New(NewDefaultConfig().WithPersistence(path, 0))
+	if err != nil {
+		t.Fatalf("Failed to reopen cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	if value, found := cache.Get("user:1"); !found || value != "alice" {
+		t.Errorf("Expected user:1 to survive restart, got %v (found=%v)", value, found)
+	}
+	if ttl, found := cache.TTL("user:1"); !found || ttl <= 50*time.Minute {
+		t.Errorf("Expected user:1 to keep its TTL, got %v (found=%v)", ttl, found)
+	}
+	if cache.Has("session") {
+		t.Error("Expected expired session to be skipped on load")
+	}
+	if keys := cache.Stats().KeyCount(); keys != 1 {
+		t.Errorf("Expected key count 1 after load, got %d", keys)
+	}
+}
+
+func TestCachePersistencePeriodicSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snap")
+
+	cache, err := New(NewDefaultConfig().WithPersistence(path, 10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	if err := cache.Set("key", "value", time.Hour); err != nil {
+		t.Fatalf("Failed to set cache entry: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		records, err := snapshot.ReadFile(path)
+		if err == nil && len(records) == 1 && records[0].Key == "key" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected a snapshot with the key, got %v (err=%v)", records, err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestCachePersistenceNoSnapshotOnCloseByDefault(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snap")
+
+	cache, err := New(NewDefaultConfig().WithPersistence(path, time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	_ = cache.Set("key", "value", time.Hour)
+	if err := cache.Close(); err != nil {
+		t.Fatalf("Failed to close cache: %v", err)
+	}
+
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected no snapshot file, got %v", err)
+	}
+}
+
+func TestCachePersistenceIgnoresCorruptSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snap")
+	if err := os.WriteFile(path, []byte("OBCSNAP\x01 partial write"), 0o600); err != nil {
+		t.Fatalf("Failed to write corrupt snapshot: %v", err)
+	}
+
+	var mu sync.Mutex
+	var reported error
+	config := NewDefaultConfig().WithPersistence(path, 0)
+	config.Persistence.OnError = func(err error) {
+		mu.Lock()
+		reported = err
+		mu.Unlock()
+	}
+
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Expected a corrupt snapshot to be ignored, got %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	if cache.Len() != 0 {
+		t.Errorf("Expected an empty cache, got %d entries", cache.Len())
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !errors.Is(reported, snapshot.ErrCorrupt) {
+		t.Errorf("Expected ErrCorrupt to be reported, got %v", reported)
+	}
+}
+
+func TestCachePersistenceRequiresMemoryStore(t *testing.T) {
+	config := NewBoltConfig(filepath.Join(t.TempDir(), "cache.db")).
+		WithPersistence(filepath.Join(t.TempDir(), "cache.snap"), time.Minute)
+	if _, err := New(config); err == nil {
+		t.Error("Expected an error when persisting a Bolt store")
+	}
+}
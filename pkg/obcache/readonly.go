@@ -0,0 +1,72 @@
+package obcache
+
+import (
+	"context"
+	"time"
+)
+
+// ReadOnlyCache is a view of a Cache that can only read entries
+// It is safe to hand to untrusted code: the interface has no methods that
+// write, delete or clear entries, and the value behind it cannot be
+// type-asserted back to *Cache. Reads still update statistics and fire hooks.
+type ReadOnlyCache interface {
+	// Get retrieves a value from the cache by key
+	Get(key string) (any, bool)
+
+	// GetContext retrieves a value from the cache by key with context support
+	GetContext(ctx context.Context, key string) (any, bool)
+
+	// Has checks if a key exists in the cache without updating its access time
+	Has(key string) bool
+
+	// Keys returns all current cache keys
+	Keys() []string
+
+	// Len returns the current number of entries in the cache
+	Len() int
+
+	// Stats returns a point-in-time copy of the cache statistics, which unlike
+	// Cache.Stats cannot be used to reset them
+	Stats() StatsSnapshot
+
+	// TTL returns the remaining time to live for a key
+	TTL(key string) (time.Duration, bool)
+}
+
+// readOnlyCache wraps a Cache so only the ReadOnlyCache methods are reachable
+type readOnlyCache struct {
+	cache *Cache
+}
+
+// ReadOnly returns a read-only view of the cache
+func (c *Cache) ReadOnly() ReadOnlyCache {
+	return readOnlyCache{cache: c}
+}
+
+func (r readOnlyCache) Get(key string) (any, bool) {
+	return r.cache.Get(key)
+}
+
+func (r readOnlyCache) GetContext(ctx context.Context, key string) (any, bool) {
+	return r.cache.GetContext(ctx, key)
+}
+
+func (r readOnlyCache) Has(key string) bool {
+	return r.cache.Has(key)
+}
+
+func (r readOnlyCache) Keys() []string {
+	return r.cache.Keys()
+}
+
+func (r readOnlyCache) Len() int {
+	return r.cache.Len()
+}
+
+func (r readOnlyCache) Stats() StatsSnapshot {
+	return r.cache.Stats().Snapshot()
+}
+
+func (r readOnlyCache) TTL(key string) (time.Duration, bool) {
+	return r.cache.TTL(key)
+}
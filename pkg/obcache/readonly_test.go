@@ -0,0 +1,90 @@
+package obcache
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestReadOnlyCache(t *testing.T) {
+	var hits, misses int
+	hooks := NewHooks()
+	hooks.AddOnHit(func(_ context.Context, _ string, _ any) { hits++ })
+	hooks.AddOnMiss(func(_ context.Context, _ string) { misses++ })
+
+	cache, err := New(NewDefaultConfig().WithHooks(hooks))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	_ = cache.Set("key1", "value1", time.Hour)
+	ro := cache.ReadOnly()
+
+	if value, found := ro.Get("key1"); !found || value != "value1" {
+		t.Errorf("Expected value1, got %v (found=%v)", value, found)
+	}
+	if _, found := ro.GetContext(context.Background(), "missing"); found {
+		t.Error("Expected missing key not to be found")
+	}
+	if !ro.Has("key1") || ro.Len() != 1 || len(ro.Keys()) != 1 {
+		t.Error("Expected read-only view to reflect cache contents")
+	}
+	if ttl, found := ro.TTL("key1"); !found || ttl <= 0 {
+		t.Errorf("Expected positive TTL, got %v (found=%v)", ttl, found)
+	}
+
+	if stats := ro.Stats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("Expected 1 hit and 1 miss, got %d/%d", stats.Hits, stats.Misses)
+	}
+	if hits != 1 || misses != 1 {
+		t.Errorf("Expected hooks to fire for reads, got hits=%d misses=%d", hits, misses)
+	}
+
+	// Writes through the cache are visible through the view
+	_ = cache.Set("key2", "value2", time.Hour)
+	if !ro.Has("key2") {
+		t.Error("Expected read-only view to see later writes")
+	}
+}
+
+func TestReadOnlyCacheCannotMutate(t *testing.T) {
+	cache, err := New(NewDefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	// Guard against methods being added to the interface without review
+	allowed := []string{"Get", "GetContext", "Has", "Keys", "Len", "Stats", "TTL"}
+	iface := reflect.TypeOf((*ReadOnlyCache)(nil)).Elem()
+	var methods []string
+	for i := 0; i < iface.NumMethod(); i++ {
+		methods = append(methods, iface.Method(i).Name)
+	}
+	sort.Strings(methods)
+	if !reflect.DeepEqual(methods, allowed) {
+		t.Fatalf("ReadOnlyCache methods changed: got %v, want %v", methods, allowed)
+	}
+
+	// The concrete value must not expose more than the interface
+	ro := cache.ReadOnly()
+	if n := reflect.TypeOf(ro).NumMethod(); n != len(allowed) {
+		t.Errorf("Expected read-only implementation to have %d methods, got %d", len(allowed), n)
+	}
+	if _, ok := ro.(interface {
+		Set(string, any, time.Duration) error
+	}); ok {
+		t.Error("Read-only view must not be assertable to a writer")
+	}
+	if _, ok := ro.(interface{ Clear() error }); ok {
+		t.Error("Read-only view must not be assertable to a clearer")
+	}
+
+	// Statistics are handed out as a copy, so they cannot be reset through the view
+	if reflect.TypeOf(ro.Stats()).Kind() != reflect.Struct {
+		t.Error("Read-only view must not expose the live statistics")
+	}
+}
@@ -0,0 +1,42 @@
+package obcache
+
+// SetOptions holds per-call options for Set and SetContext
+type SetOptions struct {
+	// SkipCompression stores the value uncompressed even if it would shrink,
+	// e.g. for bodies that are already gzipped
+	SkipCompression bool
+
+	// ForceCompression compresses the value even below Compression.MinSize or when
+	// adaptive compression would skip it. Values that compression would enlarge are
+	// still stored uncompressed
+	ForceCompression bool
+}
+
+// SetOption is a function that configures SetOptions
+// Options only take effect when compression is enabled
+type SetOption func(*SetOptions)
+
+// WithSkipCompression stores the value without compressing it
+func WithSkipCompression() SetOption {
+	return func(opts *SetOptions) {
+		opts.SkipCompression = true
+		opts.ForceCompression = false
+	}
+}
+
+// WithForceCompression compresses the value regardless of its size
+func WithForceCompression() SetOption {
+	return func(opts *SetOptions) {
+		opts.ForceCompression = true
+		opts.SkipCompression = false
+	}
+}
+
+// newSetOptions applies options to empty SetOptions
+func newSetOptions(options []SetOption) SetOptions {
+	var opts SetOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	return opts
+}
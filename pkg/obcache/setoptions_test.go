@@ -0,0 +1,65 @@
+package obcache
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/compression"
+)
+
+func TestSetCompressionOptions(t *testing.T) {
+	config := NewDefaultConfig().WithCompression(compression.NewDefaultConfig().WithEnabled(true).WithMinSize(1000))
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	large := strings.Repeat("compressible ", 200)
+	small := strings.Repeat("ab", 100)
+
+	_ = cache.Set("default", large, time.Hour)
+	_ = cache.Set("skipped", large, time.Hour, WithSkipCompression())
+	_ = cache.Set("forced", small, time.Hour, WithForceCompression())
+	_ = cache.Set("small", small, time.Hour)
+
+	tests := []struct {
+		key        string
+		value      string
+		compressed bool
+	}{
+		{"default", large, true},
+		{"skipped", large, false},
+		{"forced", small, true},
+		{"small", small, false},
+	}
+	for _, tt := range tests {
+		value, info, found := cache.GetEntry(tt.key)
+		if !found || value != tt.value {
+			t.Errorf("Expected %s to read back its value, got %v (found=%v)", tt.key, value, found)
+		}
+		if info.Compressed != tt.compressed {
+			t.Errorf("Expected %s compressed=%v, got %v", tt.key, tt.compressed, info.Compressed)
+		}
+	}
+
+	// The last option wins
+	_ = cache.Set("last", large, time.Hour, WithSkipCompression(), WithForceCompression())
+	if _, info, _ := cache.GetEntry("last"); !info.Compressed {
+		t.Error("Expected the later WithForceCompression to win")
+	}
+}
+
+func TestSetCompressionOptionsWithoutCompression(t *testing.T) {
+	cache, err := New(NewDefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	_ = cache.Set("key", "value", time.Hour, WithForceCompression())
+	if value, info, found := cache.GetEntry("key"); !found || value != "value" || info.Compressed {
+		t.Errorf("Expected the option to be ignored without compression, got %v %+v", value, info)
+	}
+}
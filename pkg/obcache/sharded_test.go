@@ -0,0 +1,171 @@
+package obcache
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShardedCache(t *testing.T) {
+	var mu sync.Mutex
+	reasons := make(map[EvictReason]int)
+	hooks := NewHooks()
+	hooks.AddOnEvict(func(_ context.Context, _ string, _ any, reason EvictReason) {
+		mu.Lock()
+		reasons[reason]++
+		mu.Unlock()
+	})
+
+	cache, err := New(NewDefaultConfig().
+		WithMaxEntries(100).
+		WithShardCount(3). // Rounded up to 4
+		WithHooks(hooks))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	if capacity := cache.Stats().Capacity(); capacity != 100 {
+		t.Errorf("Expected capacity 100 across shards, got %d", capacity)
+	}
+
+	for i := range 200 {
+		_ = cache.Set(fmt.Sprintf("key%03d", i), i, time.Hour)
+	}
+
+	n := cache.Len()
+	if n > 100 || n < 50 {
+		t.Fatalf("Expected between 50 and 100 entries, got %d", n)
+	}
+	if keys := cache.Keys(); len(keys) != n {
+		t.Errorf("Expected Keys to aggregate %d keys, got %d", n, len(keys))
+	}
+	mu.Lock()
+	if reasons[EvictReasonCapacity] != 200-n {
+		t.Errorf("Expected %d capacity evictions, got %d", 200-n, reasons[EvictReasonCapacity])
+	}
+	mu.Unlock()
+	if evictions := cache.Stats().Evictions(); evictions != int64(200-n) {
+		t.Errorf("Expected %d evictions in stats, got %d", 200-n, evictions)
+	}
+
+	// Paging by prefix merges the shards in lexical order
+	var paged []string
+	cursor := ""
+	for {
+		keys, next, err := cache.KeysWithPrefix("key", cursor, 7)
+		if err != nil {
+			t.Fatalf("KeysWithPrefix failed: %v", err)
+		}
+		paged = append(paged, keys...)
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	if len(paged) != n || !slices.IsSorted(paged) {
+		t.Errorf("Expected %d sorted keys from paging, got %d (sorted=%v)", n, len(paged), slices.IsSorted(paged))
+	}
+
+	if err := cache.Clear(); err != nil {
+		t.Fatalf("Failed to clear cache: %v", err)
+	}
+	if cache.Len() != 0 {
+		t.Errorf("Expected empty cache after Clear, got %d entries", cache.Len())
+	}
+}
+
+func TestShardedCacheCleanup(t *testing.T) {
+	var mu sync.Mutex
+	expired := 0
+	hooks := NewHooks()
+	hooks.AddOnEvict(func(_ context.Context, _ string, _ any, reason EvictReason) {
+		if reason == EvictReasonTTL {
+			mu.Lock()
+			expired++
+			mu.Unlock()
+		}
+	})
+
+	cache, err := New(NewDefaultConfig().
+		WithShardCount(AutoShardCount).
+		WithCleanupInterval(time.Hour).
+		WithHooks(hooks))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	for i := range 20 {
+		_ = cache.Set(fmt.Sprintf("key%d", i), i, TestShortTTL)
+	}
+	_ = cache.Set("live", "value", time.Hour)
+	time.Sleep(2 * TestShortTTL)
+
+	if removed := cache.Cleanup(); removed != 20 {
+		t.Errorf("Expected 20 expired entries to be removed, got %d", removed)
+	}
+	mu.Lock()
+	if expired != 20 {
+		t.Errorf("Expected 20 TTL eviction hooks, got %d", expired)
+	}
+	mu.Unlock()
+	if value, found := cache.Get("live"); !found || value != "value" {
+		t.Errorf("Expected live entry to remain, got %v (found=%v)", value, found)
+	}
+}
+
+func TestShardedCacheShardCountCappedByMaxEntries(t *testing.T) {
+	cache, err := New(NewDefaultConfig().
+		WithMaxEntries(10).
+		WithShardCount(64))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	// 64 shards of at least one entry each would hold 64 entries
+	if capacity := cache.Stats().Capacity(); capacity != 10 {
+		t.Errorf("Expected capacity 10, got %d", capacity)
+	}
+	for i := range 100 {
+		_ = cache.Set(fmt.Sprintf("key%03d", i), i, time.Hour)
+	}
+	if n := cache.Len(); n > 10 {
+		t.Errorf("Expected at most 10 entries, got %d", n)
+	}
+}
+
+func TestShardedCacheConcurrentWrites(t *testing.T) {
+	cache, err := New(NewDefaultConfig().
+		WithMaxEntries(1000).
+		WithShardCount(AutoShardCount))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 100 {
+				key := fmt.Sprintf("key-%d-%d", g, i)
+				_ = cache.Set(key, i, time.Hour)
+				if i%10 == 0 {
+					_ = cache.Delete(key)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Writers update the key count concurrently; the last update must win
+	if n, count := cache.Len(), cache.Stats().KeyCount(); n != 720 || count != int64(n) {
+		t.Errorf("Expected 720 entries counted, got %d entries and a count of %d", n, count)
+	}
+}
@@ -1,9 +1,55 @@
 package obcache
 
 import (
+	"strings"
 	"sync/atomic"
+	"time"
 )
 
+// ErrorType classifies the failures counted in Stats
+type ErrorType int
+
+const (
+	// ErrorTypeSetFailed counts writes that could not be stored: encoding or
+	// compression failures, store errors, and write-behind flushes that failed
+	ErrorTypeSetFailed ErrorType = iota
+
+	// ErrorTypeBackendUnavailable counts failed health probes and Redis failovers
+	// to the local fallback store
+	ErrorTypeBackendUnavailable
+
+	// ErrorTypeDecodeFailed counts reads that found a value that could not be decoded
+	ErrorTypeDecodeFailed
+
+	// ErrorTypeHookPanic counts panics recovered from hooks. Hooks shared by
+	// several caches count their panics in each of them
+	ErrorTypeHookPanic
+
+	// ErrorTypeHookTimeout counts hooks that did not return within the timeout
+	// set with WithTimeout, counted like ErrorTypeHookPanic
+	ErrorTypeHookTimeout
+
+	// errorTypeCount is the number of defined types
+	errorTypeCount
+)
+
+func (t ErrorType) String() string {
+	switch t {
+	case ErrorTypeSetFailed:
+		return "set_failed"
+	case ErrorTypeBackendUnavailable:
+		return "backend_unavailable"
+	case ErrorTypeDecodeFailed:
+		return "decode_failed"
+	case ErrorTypeHookPanic:
+		return "hook_panic"
+	case ErrorTypeHookTimeout:
+		return "hook_timeout"
+	default:
+		return "unknown"
+	}
+}
+
 // Stats holds cache performance statistics
 type Stats struct {
 	// Hits is the number of cache hits
@@ -12,17 +58,78 @@ type Stats struct {
 	// Misses is the number of cache misses
 	misses int64
 
+	// recent counts hits and misses over the last HitRateWindow (nil for Stats
+	// not created by a Cache)
+	recent *hitWindow
+
 	// Evictions is the number of evicted entries
 	evictions int64
 
+	// EvictionsByReason breaks evictions down by EvictReason
+	evictionsByReason [evictReasonCount]int64
+
 	// Invalidations is the number of manually invalidated entries
 	invalidations int64
 
+	// Sets, Deletes and Clears count successful writes, deletes and Clear calls
+	sets    int64
+	deletes int64
+	clears  int64
+
 	// KeyCount is the current number of keys in the cache
 	keyCount int64
 
+	// StoredBytes is the current size of the entries charged against MaxWeight
+	storedBytes int64
+
+	// MemoryBytes is the approximate memory held by the entries
+	memoryBytes int64
+
 	// InFlight is the number of requests currently being processed (singleflight)
 	inFlight int64
+
+	// TypeMismatches is the number of typed lookups that found a value of the wrong type
+	typeMismatches int64
+
+	// ErrorsByType counts failed operations by ErrorType
+	errorsByType [errorTypeCount]int64
+
+	// PinnedCount is the current number of pinned keys
+	pinnedCount int64
+
+	// AdmissionRejections is the number of writes dropped by the admission policy
+	admissionRejections int64
+
+	// Capacity is the current maximum number of entries (0 for stores without a limit)
+	capacity int64
+
+	// L1Hits and L2Hits split hits by the tier that served them (tiered store only)
+	l1Hits int64
+	l2Hits int64
+
+	// WriteQueueDepth is the number of writes not yet flushed (write-behind only)
+	writeQueueDepth int64
+
+	// Spills and Restores count entries moved to disk and back (disk overflow only)
+	spills   int64
+	restores int64
+
+	// Refreshes counts Refresh calls and RefreshFailures those that failed
+	refreshes       int64
+	refreshFailures int64
+
+	// CompressedEntries and the byte totals cover writes that were stored compressed
+	compressedEntries          int64
+	compressionOriginalBytes   int64
+	compressionCompressedBytes int64
+
+	// CompressionSkips counts writes adaptive compression stored uncompressed
+	compressionSkips int64
+
+	// CompressionNanos and DecompressionNanos total the time spent encoding and
+	// decoding values when compression is enabled
+	compressionNanos   int64
+	decompressionNanos int64
 }
 
 // Hits returns the number of cache hits
@@ -40,21 +147,194 @@ func (s *Stats) Evictions() int64 {
 	return atomic.LoadInt64(&s.evictions)
 }
 
+// EvictionsByReason returns the number of evicted entries for each reason that has
+// occurred, keyed by the lower-cased reason name (e.g. "ttl", "capacity")
+func (s *Stats) EvictionsByReason() map[string]int64 {
+	counts := make(map[string]int64)
+	for reason := range s.evictionsByReason {
+		if n := atomic.LoadInt64(&s.evictionsByReason[reason]); n > 0 {
+			counts[strings.ToLower(EvictReason(reason).String())] = n
+		}
+	}
+	return counts
+}
+
 // Invalidations returns the number of manually invalidated entries
 func (s *Stats) Invalidations() int64 {
 	return atomic.LoadInt64(&s.invalidations)
 }
 
+// Sets returns the number of entries written by Set and the other write methods
+// Writes dropped by the admission policy or that failed are not counted
+func (s *Stats) Sets() int64 {
+	return atomic.LoadInt64(&s.sets)
+}
+
+// Deletes returns the number of entries removed by Delete, DeleteMany and ClearWhere
+func (s *Stats) Deletes() int64 {
+	return atomic.LoadInt64(&s.deletes)
+}
+
+// Clears returns the number of successful Clear calls
+func (s *Stats) Clears() int64 {
+	return atomic.LoadInt64(&s.clears)
+}
+
+// CleanupRemoved returns the number of expired entries removed by the background
+// cleanup, Cleanup or a read that found them expired, i.e. the TTL evictions
+func (s *Stats) CleanupRemoved() int64 {
+	return atomic.LoadInt64(&s.evictionsByReason[EvictReasonTTL])
+}
+
 // KeyCount returns the current number of keys in the cache
 func (s *Stats) KeyCount() int64 {
 	return atomic.LoadInt64(&s.keyCount)
 }
 
+// StoredBytes returns the current size in bytes of the entries charged against
+// MaxWeight; compressed values count at their compressed length (memory store only)
+func (s *Stats) StoredBytes() int64 {
+	return atomic.LoadInt64(&s.storedBytes)
+}
+
+// MemoryBytes returns the approximate bytes held by the entries: keys, value sizes
+// from compression metadata or the Sizer, and a fixed per-entry overhead
+// (memory store only)
+func (s *Stats) MemoryBytes() int64 {
+	return atomic.LoadInt64(&s.memoryBytes)
+}
+
 // InFlight returns the number of requests currently in flight
 func (s *Stats) InFlight() int64 {
 	return atomic.LoadInt64(&s.inFlight)
 }
 
+// TypeMismatches returns the number of typed lookups that found a value of an unexpected type
+func (s *Stats) TypeMismatches() int64 {
+	return atomic.LoadInt64(&s.typeMismatches)
+}
+
+// DecodeErrors returns the number of reads that found a stored value that could
+// not be decompressed or deserialized; such entries are deleted
+func (s *Stats) DecodeErrors() int64 {
+	return atomic.LoadInt64(&s.errorsByType[ErrorTypeDecodeFailed])
+}
+
+// Errors returns the total number of failed operations of every ErrorType
+func (s *Stats) Errors() int64 {
+	var total int64
+	for errorType := range s.errorsByType {
+		total += atomic.LoadInt64(&s.errorsByType[errorType])
+	}
+	return total
+}
+
+// ErrorsByType returns the number of failed operations for each type that has
+// occurred, keyed by the type name (e.g. "set_failed")
+func (s *Stats) ErrorsByType() map[string]int64 {
+	counts := make(map[string]int64)
+	for errorType := range s.errorsByType {
+		if n := atomic.LoadInt64(&s.errorsByType[errorType]); n > 0 {
+			counts[ErrorType(errorType).String()] = n
+		}
+	}
+	return counts
+}
+
+// PinnedCount returns the current number of pinned keys
+func (s *Stats) PinnedCount() int64 {
+	return atomic.LoadInt64(&s.pinnedCount)
+}
+
+// AdmissionRejections returns the number of writes dropped by the admission policy
+func (s *Stats) AdmissionRejections() int64 {
+	return atomic.LoadInt64(&s.admissionRejections)
+}
+
+// Capacity returns the current maximum number of entries, reflecting any Resize
+// It is 0 for stores without an entry limit, such as Redis
+func (s *Stats) Capacity() int64 {
+	return atomic.LoadInt64(&s.capacity)
+}
+
+// L1Hits returns the number of hits served by the local tier of a tiered store
+func (s *Stats) L1Hits() int64 {
+	return atomic.LoadInt64(&s.l1Hits)
+}
+
+// L2Hits returns the number of hits served by the shared tier of a tiered store
+func (s *Stats) L2Hits() int64 {
+	return atomic.LoadInt64(&s.l2Hits)
+}
+
+// WriteQueueDepth returns the number of writes queued but not yet flushed to the
+// backend when write-behind is enabled
+func (s *Stats) WriteQueueDepth() int64 {
+	return atomic.LoadInt64(&s.writeQueueDepth)
+}
+
+// Spills returns the number of entries evicted from memory and written to disk
+func (s *Stats) Spills() int64 {
+	return atomic.LoadInt64(&s.spills)
+}
+
+// Restores returns the number of spilled entries moved back into memory on a hit
+func (s *Stats) Restores() int64 {
+	return atomic.LoadInt64(&s.restores)
+}
+
+// Refreshes returns the number of keys reloaded with Refresh, including failures
+func (s *Stats) Refreshes() int64 {
+	return atomic.LoadInt64(&s.refreshes)
+}
+
+// RefreshFailures returns the number of Refresh calls whose loader or write failed
+func (s *Stats) RefreshFailures() int64 {
+	return atomic.LoadInt64(&s.refreshFailures)
+}
+
+// CompressedEntries returns the number of writes stored compressed
+func (s *Stats) CompressedEntries() int64 {
+	return atomic.LoadInt64(&s.compressedEntries)
+}
+
+// CompressionOriginalBytes returns the serialized size of the values stored compressed
+func (s *Stats) CompressionOriginalBytes() int64 {
+	return atomic.LoadInt64(&s.compressionOriginalBytes)
+}
+
+// CompressionCompressedBytes returns the size of the values stored compressed after compression
+func (s *Stats) CompressionCompressedBytes() int64 {
+	return atomic.LoadInt64(&s.compressionCompressedBytes)
+}
+
+// CompressionSkips returns the number of writes stored uncompressed because adaptive
+// compression predicted their values would not shrink
+func (s *Stats) CompressionSkips() int64 {
+	return atomic.LoadInt64(&s.compressionSkips)
+}
+
+// CompressionRatio returns compressed bytes as a fraction of original bytes (e.g. 0.25
+// when compression saves 75%), or 0 if nothing has been compressed
+func (s *Stats) CompressionRatio() float64 {
+	original := s.CompressionOriginalBytes()
+	if original == 0 {
+		return 0
+	}
+	return float64(s.CompressionCompressedBytes()) / float64(original)
+}
+
+// CompressionTime returns the total time spent serializing and compressing values,
+// including values that did not shrink enough to be stored compressed
+func (s *Stats) CompressionTime() time.Duration {
+	return time.Duration(atomic.LoadInt64(&s.compressionNanos))
+}
+
+// DecompressionTime returns the total time spent decompressing and deserializing values
+func (s *Stats) DecompressionTime() time.Duration {
+	return time.Duration(atomic.LoadInt64(&s.decompressionNanos))
+}
+
 // HitRate returns the cache hit rate as a percentage (0-100)
 func (s *Stats) HitRate() float64 {
 	hits := s.Hits()
@@ -68,43 +348,136 @@ func (s *Stats) HitRate() float64 {
 	return float64(hits) / float64(total) * 100
 }
 
+// RecentHitRate returns the hit rate as a percentage over the last window, at the
+// resolution of Config.HitRateResolution. A window that is not positive or exceeds
+// Config.HitRateWindow covers the whole configured window
+func (s *Stats) RecentHitRate(window time.Duration) float64 {
+	if s.recent == nil {
+		return 0
+	}
+	return s.recent.hitRate(window)
+}
+
 // Total returns the total number of cache requests (hits + misses)
 func (s *Stats) Total() int64 {
 	return s.Hits() + s.Misses()
 }
 
 // Reset resets all statistics to zero
+// Capacity is configuration rather than a statistic and is kept, as is the
+// write queue depth, which tracks pending work. Prefer Cache.ResetStats, which
+// recomputes the key count and sizes instead of zeroing them
 func (s *Stats) Reset() {
+	s.resetCounters()
+	atomic.StoreInt64(&s.keyCount, 0)
+	atomic.StoreInt64(&s.storedBytes, 0)
+	atomic.StoreInt64(&s.memoryBytes, 0)
+	atomic.StoreInt64(&s.inFlight, 0)
+	atomic.StoreInt64(&s.pinnedCount, 0)
+}
+
+// resetCounters zeroes the statistics that accumulate over time, leaving those
+// that describe the current contents and pending work
+func (s *Stats) resetCounters() {
 	atomic.StoreInt64(&s.hits, 0)
 	atomic.StoreInt64(&s.misses, 0)
+	if s.recent != nil {
+		s.recent.reset()
+	}
 	atomic.StoreInt64(&s.evictions, 0)
+	for reason := range s.evictionsByReason {
+		atomic.StoreInt64(&s.evictionsByReason[reason], 0)
+	}
 	atomic.StoreInt64(&s.invalidations, 0)
-	atomic.StoreInt64(&s.keyCount, 0)
-	atomic.StoreInt64(&s.inFlight, 0)
+	atomic.StoreInt64(&s.sets, 0)
+	atomic.StoreInt64(&s.deletes, 0)
+	atomic.StoreInt64(&s.clears, 0)
+	atomic.StoreInt64(&s.typeMismatches, 0)
+	for errorType := range s.errorsByType {
+		atomic.StoreInt64(&s.errorsByType[errorType], 0)
+	}
+	atomic.StoreInt64(&s.admissionRejections, 0)
+	atomic.StoreInt64(&s.l1Hits, 0)
+	atomic.StoreInt64(&s.l2Hits, 0)
+	atomic.StoreInt64(&s.spills, 0)
+	atomic.StoreInt64(&s.restores, 0)
+	atomic.StoreInt64(&s.refreshes, 0)
+	atomic.StoreInt64(&s.refreshFailures, 0)
+	atomic.StoreInt64(&s.compressedEntries, 0)
+	atomic.StoreInt64(&s.compressionOriginalBytes, 0)
+	atomic.StoreInt64(&s.compressionCompressedBytes, 0)
+	atomic.StoreInt64(&s.compressionSkips, 0)
+	atomic.StoreInt64(&s.compressionNanos, 0)
+	atomic.StoreInt64(&s.decompressionNanos, 0)
 }
 
 // Internal methods for updating stats (not exported)
 
 func (s *Stats) incHits() {
 	atomic.AddInt64(&s.hits, 1)
+	if s.recent != nil {
+		s.recent.record(true)
+	}
 }
 
 func (s *Stats) incMisses() {
 	atomic.AddInt64(&s.misses, 1)
+	if s.recent != nil {
+		s.recent.record(false)
+	}
 }
 
-func (s *Stats) incEvictions() {
+func (s *Stats) incEvictions(reason EvictReason) {
 	atomic.AddInt64(&s.evictions, 1)
+	if reason >= 0 && reason < evictReasonCount {
+		atomic.AddInt64(&s.evictionsByReason[reason], 1)
+	}
 }
 
 func (s *Stats) incInvalidations() {
 	atomic.AddInt64(&s.invalidations, 1)
 }
 
+func (s *Stats) addInvalidations(n int) {
+	atomic.AddInt64(&s.invalidations, int64(n))
+}
+
+func (s *Stats) addSets(n int) {
+	atomic.AddInt64(&s.sets, int64(n))
+}
+
+func (s *Stats) addDeletes(n int) {
+	atomic.AddInt64(&s.deletes, int64(n))
+}
+
+func (s *Stats) incClears() {
+	atomic.AddInt64(&s.clears, 1)
+}
+
 func (s *Stats) setKeyCount(count int64) {
 	atomic.StoreInt64(&s.keyCount, count)
 }
 
+func (s *Stats) setStoredBytes(size int64) {
+	atomic.StoreInt64(&s.storedBytes, size)
+}
+
+func (s *Stats) setMemoryBytes(size int64) {
+	atomic.StoreInt64(&s.memoryBytes, size)
+}
+
+func (s *Stats) setCapacity(capacity int64) {
+	atomic.StoreInt64(&s.capacity, capacity)
+}
+
+func (s *Stats) setWriteQueueDepth(depth int) {
+	atomic.StoreInt64(&s.writeQueueDepth, int64(depth))
+}
+
+func (s *Stats) setPinnedCount(count int64) {
+	atomic.StoreInt64(&s.pinnedCount, count)
+}
+
 func (s *Stats) incInFlight() {
 	atomic.AddInt64(&s.inFlight, 1)
 }
@@ -112,3 +485,58 @@ func (s *Stats) incInFlight() {
 func (s *Stats) decInFlight() {
 	atomic.AddInt64(&s.inFlight, -1)
 }
+
+func (s *Stats) incTypeMismatches() {
+	atomic.AddInt64(&s.typeMismatches, 1)
+}
+
+func (s *Stats) incErrors(errorType ErrorType) {
+	if errorType >= 0 && errorType < errorTypeCount {
+		atomic.AddInt64(&s.errorsByType[errorType], 1)
+	}
+}
+
+func (s *Stats) incAdmissionRejections() {
+	atomic.AddInt64(&s.admissionRejections, 1)
+}
+
+func (s *Stats) incTierHits(tier int) {
+	switch tier {
+	case 1:
+		atomic.AddInt64(&s.l1Hits, 1)
+	case 2:
+		atomic.AddInt64(&s.l2Hits, 1)
+	}
+}
+
+func (s *Stats) incSpills() {
+	atomic.AddInt64(&s.spills, 1)
+}
+
+func (s *Stats) incRestores() {
+	atomic.AddInt64(&s.restores, 1)
+}
+
+func (s *Stats) incRefreshes(failed bool) {
+	atomic.AddInt64(&s.refreshes, 1)
+	if failed {
+		atomic.AddInt64(&s.refreshFailures, 1)
+	}
+}
+
+func (s *Stats) addCompression(d time.Duration, originalSize, compressedSize int) {
+	atomic.AddInt64(&s.compressionNanos, int64(d))
+	if compressedSize > 0 {
+		atomic.AddInt64(&s.compressedEntries, 1)
+		atomic.AddInt64(&s.compressionOriginalBytes, int64(originalSize))
+		atomic.AddInt64(&s.compressionCompressedBytes, int64(compressedSize))
+	}
+}
+
+func (s *Stats) incCompressionSkips() {
+	atomic.AddInt64(&s.compressionSkips, 1)
+}
+
+func (s *Stats) addDecompression(d time.Duration) {
+	atomic.AddInt64(&s.decompressionNanos, int64(d))
+}
@@ -1,8 +1,20 @@
 package obcache
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
 	"sync"
 	"testing"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/internal/eviction"
+	"github.com/1mb-dev/obcache-go/v2/internal/store/memory"
+	"github.com/1mb-dev/obcache-go/v2/pkg/codec"
+	"github.com/1mb-dev/obcache-go/v2/pkg/compression"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+	"github.com/1mb-dev/obcache-go/v2/pkg/store"
 )
 
 func TestStatsInitialState(t *testing.T) {
@@ -50,7 +62,7 @@ func TestStatsIncrement(t *testing.T) {
 	}
 
 	// Test eviction increment
-	stats.incEvictions()
+	stats.incEvictions(EvictReasonCapacity)
 	if evictions := stats.Evictions(); evictions != 1 {
 		t.Fatalf("Expected 1 eviction after increment, got %d", evictions)
 	}
@@ -61,6 +73,16 @@ func TestStatsIncrement(t *testing.T) {
 		t.Fatalf("Expected 1 invalidation after increment, got %d", invalidations)
 	}
 
+	// Test operation counters
+	stats.addSets(3)
+	stats.addDeletes(2)
+	stats.incClears()
+	stats.incEvictions(EvictReasonTTL)
+	if stats.Sets() != 3 || stats.Deletes() != 2 || stats.Clears() != 1 || stats.CleanupRemoved() != 1 {
+		t.Fatalf("Expected 3 sets, 2 deletes, 1 clear and 1 cleanup removal, got %d, %d, %d and %d",
+			stats.Sets(), stats.Deletes(), stats.Clears(), stats.CleanupRemoved())
+	}
+
 	// Test in-flight increment and decrement
 	stats.incInFlight()
 	if inFlight := stats.InFlight(); inFlight != 1 {
@@ -130,8 +152,11 @@ func TestStatsReset(t *testing.T) {
 	// Add some data
 	stats.incHits()
 	stats.incMisses()
-	stats.incEvictions()
+	stats.incEvictions(EvictReasonCapacity)
 	stats.incInvalidations()
+	stats.addSets(1)
+	stats.addDeletes(1)
+	stats.incClears()
 	stats.incInFlight()
 	stats.setKeyCount(10)
 
@@ -156,6 +181,9 @@ func TestStatsReset(t *testing.T) {
 	if invalidations := stats.Invalidations(); invalidations != 0 {
 		t.Fatalf("Expected 0 invalidations after reset, got %d", invalidations)
 	}
+	if stats.Sets() != 0 || stats.Deletes() != 0 || stats.Clears() != 0 {
+		t.Fatalf("Expected 0 sets, deletes and clears after reset, got %d, %d and %d", stats.Sets(), stats.Deletes(), stats.Clears())
+	}
 	if keyCount := stats.KeyCount(); keyCount != 0 {
 		t.Fatalf("Expected 0 key count after reset, got %d", keyCount)
 	}
@@ -164,6 +192,35 @@ func TestStatsReset(t *testing.T) {
 	}
 }
 
+func TestStatsCompression(t *testing.T) {
+	stats := &Stats{}
+	if ratio := stats.CompressionRatio(); ratio != 0 {
+		t.Errorf("Expected ratio 0 before any compression, got %v", ratio)
+	}
+
+	stats.addCompression(time.Millisecond, 1000, 250)
+	stats.addCompression(time.Millisecond, 0, 0) // Not stored compressed
+	stats.addDecompression(2 * time.Millisecond)
+
+	if n := stats.CompressedEntries(); n != 1 {
+		t.Errorf("Expected 1 compressed entry, got %d", n)
+	}
+	if ratio := stats.CompressionRatio(); ratio != 0.25 {
+		t.Errorf("Expected ratio 0.25, got %v", ratio)
+	}
+	if d := stats.CompressionTime(); d != 2*time.Millisecond {
+		t.Errorf("Expected 2ms spent compressing, got %v", d)
+	}
+	if d := stats.DecompressionTime(); d != 2*time.Millisecond {
+		t.Errorf("Expected 2ms spent decompressing, got %v", d)
+	}
+
+	stats.Reset()
+	if stats.CompressedEntries() != 0 || stats.CompressionOriginalBytes() != 0 || stats.CompressionTime() != 0 || stats.DecompressionTime() != 0 {
+		t.Error("Expected Reset to clear the compression statistics")
+	}
+}
+
 func TestStatsConcurrency(t *testing.T) {
 	stats := &Stats{}
 
@@ -199,7 +256,7 @@ func TestStatsConcurrency(t *testing.T) {
 		go func() {
 			defer wg.Done()
 			for j := 0; j < numOperations; j++ {
-				stats.incEvictions()
+				stats.incEvictions(EvictReasonCapacity)
 			}
 		}()
 	}
@@ -314,4 +371,243 @@ func TestStatsIntegrationWithCache(t *testing.T) {
 	if stats.Evictions() == 0 {
 		t.Fatal("Expected at least 1 eviction")
 	}
+
+	// Sets and deletes, including batches, and clears
+	_ = cache.SetMany(map[string]any{"key4": 4, "key5": 5}, TestTTL)
+	_ = cache.DeleteMany([]string{"key4", "key5"})
+	_ = cache.Set("expiring", "value", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	cache.Cleanup()
+	_ = cache.Clear()
+	if stats.Sets() != 7 || stats.Deletes() != 3 || stats.Clears() != 1 || stats.CleanupRemoved() != 1 {
+		t.Errorf("Expected 7 sets, 3 deletes, 1 clear and 1 cleanup removal, got %d, %d, %d and %d",
+			stats.Sets(), stats.Deletes(), stats.Clears(), stats.CleanupRemoved())
+	}
+}
+
+func TestStatsMemoryBytes(t *testing.T) {
+	for _, shards := range []int{1, 4} {
+		t.Run(fmt.Sprintf("shards=%d", shards), func(t *testing.T) {
+			config := NewDefaultConfig().
+				WithMaxEntries(2).
+				WithShardCount(shards).
+				WithEvictionType(eviction.FIFO).
+				WithSizer(func(value any) int {
+					if _, ok := value.(int); ok {
+						return 100
+					}
+					return len(value.(string))
+				})
+			if shards > 1 {
+				config = config.WithMaxEntries(8)
+			}
+			cache, err := New(config)
+			if err != nil {
+				t.Fatalf("Failed to create cache: %v", err)
+			}
+			defer func() { _ = cache.Close() }()
+
+			size := func(key string, valueSize int) int64 {
+				return int64(len(key)+valueSize) + memory.EntryOverhead
+			}
+			expectBytes := func(want int64) {
+				t.Helper()
+				if got := cache.Stats().MemoryBytes(); got != want {
+					t.Errorf("Expected %d memory bytes, got %d", want, got)
+				}
+			}
+
+			_ = cache.Set("a", "hello", time.Hour)
+			_ = cache.Set("b", 42, time.Hour)
+			expectBytes(size("a", 5) + size("b", 100))
+
+			// Replacing a value releases the old size
+			_ = cache.Set("a", "hi", time.Hour)
+			expectBytes(size("a", 2) + size("b", 100))
+
+			_ = cache.Delete("b")
+			expectBytes(size("a", 2))
+
+			_ = cache.Set("expiring", "xyz", TestShortTTL)
+			time.Sleep(2 * TestShortTTL)
+			cache.Cleanup()
+			expectBytes(size("a", 2))
+
+			if shards == 1 {
+				// Capacity displacement releases the evicted entry
+				_ = cache.Set("c", "cc", time.Hour)
+				_ = cache.Set("d", "dddd", time.Hour)
+				if cache.Stats().EvictionsByReason()["capacity"] != 1 {
+					t.Fatal("Expected a capacity eviction")
+				}
+				expectBytes(size("c", 2) + size("d", 4))
+			}
+
+			_ = cache.Clear()
+			expectBytes(0)
+		})
+	}
+}
+
+func TestStatsRecentHitRate(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	window := newHitWindow(time.Minute, time.Second)
+	window.now = func() time.Time { return now }
+	stats := &Stats{recent: window}
+
+	for i := range 100 {
+		if i < 90 {
+			stats.incHits()
+		} else {
+			stats.incMisses()
+		}
+	}
+
+	// A regression 30 seconds later dominates the recent rate but barely moves the lifetime one
+	now = now.Add(30 * time.Second)
+	for range 10 {
+		stats.incMisses()
+	}
+	if rate := stats.RecentHitRate(10 * time.Second); rate != 0 {
+		t.Errorf("Expected a 0%% hit rate over 10s, got %v", rate)
+	}
+	if rate := stats.RecentHitRate(0); math.Abs(rate-90.0/110*100) > 1e-9 {
+		t.Errorf("Expected the whole window to cover both intervals, got %v", rate)
+	}
+	if rate := stats.HitRate(); math.Abs(rate-90.0/110*100) > 1e-9 {
+		t.Errorf("Expected the lifetime hit rate to be unaffected, got %v", rate)
+	}
+
+	// Buckets that fall out of the window stop counting, and recycled ones start over
+	now = now.Add(45 * time.Second)
+	stats.incHits()
+	if rate := stats.RecentHitRate(time.Minute); math.Abs(rate-100.0/11) > 1e-9 {
+		t.Errorf("Expected only the last minute to count, got %v", rate)
+	}
+	now = now.Add(2 * time.Minute)
+	if rate := stats.RecentHitRate(time.Minute); rate != 0 {
+		t.Errorf("Expected no recent lookups, got %v", rate)
+	}
+
+	stats.incHits()
+	stats.Reset()
+	if rate := stats.RecentHitRate(0); rate != 0 {
+		t.Errorf("Expected no recent lookups after reset, got %v", rate)
+	}
+	if rate := (&Stats{}).RecentHitRate(0); rate != 0 {
+		t.Errorf("Expected 0 without a window, got %v", rate)
+	}
+}
+
+func TestCacheRecentHitRate(t *testing.T) {
+	cache, err := New(NewDefaultConfig().WithHitRateWindow(10*time.Second, 500*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	_ = cache.Set("key", "value", time.Hour)
+	cache.Get("key")
+	cache.Get("key")
+	cache.Get("key")
+	cache.Get("missing")
+
+	if rate := cache.Stats().RecentHitRate(0); rate != 75 {
+		t.Errorf("Expected a 75%% recent hit rate, got %v", rate)
+	}
+	if n := len(cache.stats.recent.buckets); n != 20 {
+		t.Errorf("Expected 20 buckets, got %d", n)
+	}
+}
+
+// failingStore is a memory store whose writes fail with err
+type failingStore struct {
+	store.Store
+	err error
+}
+
+func (s *failingStore) Set(string, *entry.Entry) error { return s.err }
+
+func TestStatsErrors(t *testing.T) {
+	errBackend := errors.New("backend down")
+	newFailingCache := func(t *testing.T, config *Config) *Cache {
+		t.Helper()
+		memStore, err := memory.NewWithStrategy(eviction.Config{Type: eviction.LRU, Capacity: 10})
+		if err != nil {
+			t.Fatalf("Failed to create memory store: %v", err)
+		}
+		cache, err := New(config.WithCustomStore(&failingStore{Store: memStore, err: errBackend}))
+		if err != nil {
+			t.Fatalf("Failed to create cache: %v", err)
+		}
+		t.Cleanup(func() { _ = cache.Close() })
+		return cache
+	}
+
+	t.Run("set_failed", func(t *testing.T) {
+		cache := newFailingCache(t, NewDefaultConfig())
+		if err := cache.Set("a", 1, time.Hour); !errors.Is(err, errBackend) {
+			t.Fatalf("Expected the store error, got %v", err)
+		}
+		_ = cache.SetMany(map[string]any{"b": 2, "c": 3}, time.Hour)
+		if _, _, err := cache.Swap("d", 4, time.Hour); err == nil {
+			t.Fatal("Expected Swap to fail")
+		}
+
+		stats := cache.Stats()
+		if stats.Errors() != 4 || stats.ErrorsByType()["set_failed"] != 4 {
+			t.Errorf("Expected 4 failed sets, got %d (%v)", stats.Errors(), stats.ErrorsByType())
+		}
+	})
+
+	t.Run("write_behind", func(t *testing.T) {
+		var reported []string
+		cache := newFailingCache(t, NewDefaultConfig().WithWriteBehind(&WriteBehindConfig{
+			OnError: func(key string, err error) { reported = append(reported, key) },
+		}))
+		if err := cache.Set("a", 1, time.Hour); err != nil {
+			t.Fatalf("Expected the queued Set to succeed, got %v", err)
+		}
+		_ = cache.Close() // Flushes the queue
+
+		if n := cache.Stats().ErrorsByType()["set_failed"]; n != 1 || len(reported) != 1 {
+			t.Errorf("Expected the failed flush to be counted and reported, got %d and %v", n, reported)
+		}
+	})
+
+	t.Run("backend_unavailable", func(t *testing.T) {
+		cache, flaky := newFlakyCache(t, NewDefaultConfig())
+		flaky.setErr(errBackend)
+		_ = cache.Ping(context.Background())
+		flaky.setErr(nil)
+		_ = cache.Ping(context.Background())
+
+		if n := cache.Stats().ErrorsByType()["backend_unavailable"]; n != 1 {
+			t.Errorf("Expected 1 failed probe, got %d", n)
+		}
+	})
+
+	t.Run("decode_failed", func(t *testing.T) {
+		cache, err := New(NewDefaultConfig().WithCompression(compression.NewDefaultConfig().WithEnabled(true)))
+		if err != nil {
+			t.Fatalf("Failed to create cache: %v", err)
+		}
+		defer func() { _ = cache.Close() }()
+
+		// A payload that claims to be gzip but is not cannot be decoded
+		poisoned := entry.NewWithoutTTL([]byte("not gzip"))
+		poisoned.CodecName = codec.JSON{}.Name()
+		poisoned.SetCompressionInfo("gzip", 100, len("not gzip"))
+		_ = cache.store.Set("poisoned", poisoned)
+		cache.Get("poisoned")
+
+		stats := cache.Stats()
+		if stats.DecodeErrors() != 1 || stats.ErrorsByType()["decode_failed"] != 1 || stats.Errors() != 1 {
+			t.Errorf("Expected 1 decode failure, got %v", stats.ErrorsByType())
+		}
+		cache.ResetStats()
+		if stats.Errors() != 0 {
+			t.Errorf("Expected ResetStats to clear errors, got %d", stats.Errors())
+		}
+	})
 }
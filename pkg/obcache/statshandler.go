@@ -0,0 +1,112 @@
+package obcache
+
+import (
+	"cmp"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+// StatsDocumentVersion is the layout version of the StatsHandler document
+// It changes only when fields are removed or change meaning
+const StatsDocumentVersion = 1
+
+// maxStatsTopKeys bounds the number of keys StatsHandler lists per ranking
+const maxStatsTopKeys = 1000
+
+// StatsDocument is the JSON document served by StatsHandler: the fields of a
+// StatsSnapshot plus the document version, uptime and optional top keys
+type StatsDocument struct {
+	Version int `json:"version"`
+	StatsSnapshot
+	UptimeSeconds float64       `json:"uptime_seconds"`
+	TopKeys       *StatsTopKeys `json:"top_keys,omitempty"`
+}
+
+// StatsTopKeys lists the largest and most frequently used keys
+// A ranking is omitted when the cache does not track the data behind it
+type StatsTopKeys struct {
+	BySize      []StatsKey `json:"by_size,omitempty"`
+	ByFrequency []StatsKey `json:"by_frequency,omitempty"`
+}
+
+// StatsKey is a key in a StatsTopKeys ranking
+type StatsKey struct {
+	Key       string `json:"key"`
+	Size      int    `json:"size,omitempty"`
+	Frequency int64  `json:"frequency,omitempty"`
+}
+
+// StatsHandler returns an HTTP handler that serves the statistics of c as a
+// StatsDocument. Pass ?top=N to add the N largest keys, where entry sizes are
+// known, and the N most frequently used keys, with the LFU strategy. Listing
+// keys scans the whole cache, so keep it to admin endpoints
+func StatsHandler(c *Cache) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		top := 0
+		if param := r.URL.Query().Get("top"); param != "" {
+			n, err := strconv.Atoi(param)
+			if err != nil || n < 0 {
+				http.Error(w, "top must be a non-negative integer", http.StatusBadRequest)
+				return
+			}
+			top = min(n, maxStatsTopKeys)
+		}
+
+		document := c.statsDocument()
+		if top > 0 {
+			document.TopKeys = c.topKeys(top)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(document); err != nil {
+			http.Error(w, "Failed to encode JSON response", http.StatusInternalServerError)
+		}
+	})
+}
+
+// statsDocument captures the current statistics
+func (c *Cache) statsDocument() StatsDocument {
+	snapshot := c.stats.Snapshot()
+	return StatsDocument{
+		Version:       StatsDocumentVersion,
+		StatsSnapshot: snapshot,
+		UptimeSeconds: snapshot.CapturedAt.Sub(c.startedAt).Seconds(),
+	}
+}
+
+// topKeys ranks up to n keys by stored size and by access frequency
+func (c *Cache) topKeys(n int) *StatsTopKeys {
+	frequencyReporter, _, _ := c.accessReporters()
+
+	var bySize, byFrequency []StatsKey
+	for _, key := range c.Keys() {
+		if cacheEntry, found := c.store.Peek(key); found && !cacheEntry.IsExpired() {
+			if size := cacheEntry.Size(); size > 0 {
+				bySize = append(bySize, StatsKey{Key: key, Size: size})
+			}
+		}
+		if frequencyReporter != nil {
+			if frequency, found := frequencyReporter.Frequency(key); found {
+				byFrequency = append(byFrequency, StatsKey{Key: key, Frequency: frequency})
+			}
+		}
+	}
+
+	slices.SortFunc(bySize, func(a, b StatsKey) int {
+		return cmp.Or(cmp.Compare(b.Size, a.Size), cmp.Compare(a.Key, b.Key))
+	})
+	slices.SortFunc(byFrequency, func(a, b StatsKey) int {
+		return cmp.Or(cmp.Compare(b.Frequency, a.Frequency), cmp.Compare(a.Key, b.Key))
+	})
+	return &StatsTopKeys{
+		BySize:      bySize[:min(n, len(bySize))],
+		ByFrequency: byFrequency[:min(n, len(byFrequency))],
+	}
+}
@@ -0,0 +1,117 @@
+package obcache
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/internal/eviction"
+)
+
+func getStatsDocument(t *testing.T, handler http.Handler, target string) (StatsDocument, *httptest.ResponseRecorder) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+
+	var document StatsDocument
+	if recorder.Code == http.StatusOK {
+		if err := json.Unmarshal(recorder.Body.Bytes(), &document); err != nil {
+			t.Fatalf("Failed to decode stats document: %v", err)
+		}
+	}
+	return document, recorder
+}
+
+func TestStatsHandler(t *testing.T) {
+	cache, err := New(NewDefaultConfig().WithMaxEntries(100))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	_ = cache.Set("a", "alpha", time.Hour)
+	_ = cache.Set("b", "beta", time.Hour)
+	cache.Get("a")
+	cache.Get("missing")
+	_ = cache.Delete("b")
+
+	handler := StatsHandler(cache)
+	document, recorder := getStatsDocument(t, handler, "/stats")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", recorder.Code)
+	}
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Expected a JSON content type, got %q", contentType)
+	}
+
+	if document.Version != StatsDocumentVersion || document.Hits != 1 || document.Misses != 1 ||
+		document.Invalidations != 1 || document.KeyCount != 1 || document.Capacity != 100 {
+		t.Errorf("Unexpected stats document: %+v", document)
+	}
+	if document.HitRate != 50 || document.UptimeSeconds <= 0 {
+		t.Errorf("Expected a 50%% hit rate and a positive uptime, got %+v", document)
+	}
+	if document.TopKeys != nil {
+		t.Error("Expected no keys without the top parameter")
+	}
+
+	// Field names are part of the contract with dashboards
+	for _, field := range []string{`"version":1,`, `"hits":`, `"misses":`, `"hit_rate":`, `"evictions":`,
+		`"invalidations":`, `"key_count":`, `"in_flight":`, `"capacity":`, `"uptime_seconds":`} {
+		if !strings.Contains(recorder.Body.String(), field) {
+			t.Errorf("Expected the document to contain %s, got %s", field, recorder.Body.String())
+		}
+	}
+
+	for _, target := range []string{"/stats?top=x", "/stats?top=-1"} {
+		if _, recorder := getStatsDocument(t, handler, target); recorder.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", target, recorder.Code)
+		}
+	}
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/stats", nil))
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for POST, got %d", recorder.Code)
+	}
+}
+
+func TestStatsHandlerTopKeys(t *testing.T) {
+	cache, err := New(NewDefaultConfig().WithMaxEntries(100).WithEvictionType(eviction.LFU))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	_ = cache.Set("small", "x", time.Hour)
+	_ = cache.Set("medium", strings.Repeat("x", 10), time.Hour)
+	_ = cache.Set("large", strings.Repeat("x", 100), time.Hour)
+	for range 5 {
+		cache.Get("small")
+	}
+	cache.Get("medium")
+
+	document, _ := getStatsDocument(t, StatsHandler(cache), "/stats?top=2")
+	if document.TopKeys == nil {
+		t.Fatal("Expected top keys with the top parameter")
+	}
+	bySize := document.TopKeys.BySize
+	if len(bySize) != 2 || bySize[0].Key != "large" || bySize[0].Size != 100 || bySize[1].Key != "medium" {
+		t.Errorf("Expected large and medium by size, got %+v", bySize)
+	}
+	byFrequency := document.TopKeys.ByFrequency
+	if len(byFrequency) != 2 || byFrequency[0].Key != "small" || byFrequency[1].Key != "medium" {
+		t.Errorf("Expected small and medium by frequency, got %+v", byFrequency)
+	}
+
+	// Without frequency data the ranking is left out
+	lru, _ := New(NewDefaultConfig())
+	defer func() { _ = lru.Close() }()
+	_ = lru.Set("k", "value", time.Hour)
+	document, _ = getStatsDocument(t, StatsHandler(lru), "/stats?top=5")
+	if document.TopKeys == nil || len(document.TopKeys.BySize) != 1 || document.TopKeys.ByFrequency != nil {
+		t.Errorf("Expected only the size ranking for LRU, got %+v", document.TopKeys)
+	}
+}
@@ -0,0 +1,181 @@
+package obcache
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/metrics"
+)
+
+// StatsSnapshot is a copy of the cache statistics taken at one moment
+// Each value is read atomically; values are not read under a common lock, so
+// traffic during the capture can make them differ by a few operations, but
+// derived values such as HitRate are computed from the captured counts.
+// The JSON field names are part of the API: fields are only ever added
+type StatsSnapshot struct {
+	// CapturedAt is when the snapshot was taken
+	CapturedAt time.Time `json:"captured_at"`
+
+	Hits              int64            `json:"hits"`
+	Misses            int64            `json:"misses"`
+	Evictions         int64            `json:"evictions"`
+	EvictionsByReason map[string]int64 `json:"evictions_by_reason"`
+	Invalidations     int64            `json:"invalidations"`
+
+	Sets           int64 `json:"sets"`
+	Deletes        int64 `json:"deletes"`
+	Clears         int64 `json:"clears"`
+	CleanupRemoved int64 `json:"cleanup_removed"`
+
+	// HitRate is the lifetime hit rate and RecentHitRate the hit rate over
+	// Config.HitRateWindow, both as percentages
+	HitRate       float64 `json:"hit_rate"`
+	RecentHitRate float64 `json:"recent_hit_rate"`
+
+	KeyCount    int64 `json:"key_count"`
+	StoredBytes int64 `json:"stored_bytes"`
+	MemoryBytes int64 `json:"memory_bytes"`
+	InFlight    int64 `json:"in_flight"`
+	PinnedCount int64 `json:"pinned_count"`
+	Capacity    int64 `json:"capacity"`
+
+	TypeMismatches      int64 `json:"type_mismatches"`
+	DecodeErrors        int64 `json:"decode_errors"`
+	AdmissionRejections int64 `json:"admission_rejections"`
+
+	Errors       int64            `json:"errors"`
+	ErrorsByType map[string]int64 `json:"errors_by_type"`
+
+	L1Hits          int64 `json:"l1_hits"`
+	L2Hits          int64 `json:"l2_hits"`
+	WriteQueueDepth int64 `json:"write_queue_depth"`
+	Spills          int64 `json:"spills"`
+	Restores        int64 `json:"restores"`
+
+	Refreshes       int64 `json:"refreshes"`
+	RefreshFailures int64 `json:"refresh_failures"`
+
+	CompressedEntries          int64         `json:"compressed_entries"`
+	CompressionOriginalBytes   int64         `json:"compression_original_bytes"`
+	CompressionCompressedBytes int64         `json:"compression_compressed_bytes"`
+	CompressionSkips           int64         `json:"compression_skips"`
+	CompressionRatio           float64       `json:"compression_ratio"`
+	CompressionTime            time.Duration `json:"compression_nanos"`
+	DecompressionTime          time.Duration `json:"decompression_nanos"`
+}
+
+// Snapshot returns a copy of the current statistics
+func (s *Stats) Snapshot() StatsSnapshot {
+	snapshot := StatsSnapshot{
+		CapturedAt:                 time.Now(),
+		Hits:                       s.Hits(),
+		Misses:                     s.Misses(),
+		Evictions:                  s.Evictions(),
+		EvictionsByReason:          s.EvictionsByReason(),
+		Invalidations:              s.Invalidations(),
+		Sets:                       s.Sets(),
+		Deletes:                    s.Deletes(),
+		Clears:                     s.Clears(),
+		CleanupRemoved:             s.CleanupRemoved(),
+		RecentHitRate:              s.RecentHitRate(0),
+		KeyCount:                   s.KeyCount(),
+		StoredBytes:                s.StoredBytes(),
+		MemoryBytes:                s.MemoryBytes(),
+		InFlight:                   s.InFlight(),
+		PinnedCount:                s.PinnedCount(),
+		Capacity:                   s.Capacity(),
+		TypeMismatches:             s.TypeMismatches(),
+		DecodeErrors:               s.DecodeErrors(),
+		AdmissionRejections:        s.AdmissionRejections(),
+		ErrorsByType:               s.ErrorsByType(),
+		L1Hits:                     s.L1Hits(),
+		L2Hits:                     s.L2Hits(),
+		WriteQueueDepth:            s.WriteQueueDepth(),
+		Spills:                     s.Spills(),
+		Restores:                   s.Restores(),
+		Refreshes:                  s.Refreshes(),
+		RefreshFailures:            s.RefreshFailures(),
+		CompressedEntries:          s.CompressedEntries(),
+		CompressionOriginalBytes:   s.CompressionOriginalBytes(),
+		CompressionCompressedBytes: s.CompressionCompressedBytes(),
+		CompressionSkips:           s.CompressionSkips(),
+		CompressionTime:            s.CompressionTime(),
+		DecompressionTime:          s.DecompressionTime(),
+	}
+
+	for _, n := range snapshot.ErrorsByType {
+		snapshot.Errors += n
+	}
+	if total := snapshot.Hits + snapshot.Misses; total > 0 {
+		snapshot.HitRate = float64(snapshot.Hits) / float64(total) * 100
+	}
+	if snapshot.CompressionOriginalBytes > 0 {
+		snapshot.CompressionRatio = float64(snapshot.CompressionCompressedBytes) / float64(snapshot.CompressionOriginalBytes)
+	}
+	return snapshot
+}
+
+// Total returns the total number of requests in the snapshot (hits + misses)
+func (s StatsSnapshot) Total() int64 {
+	return s.Hits + s.Misses
+}
+
+// String returns a compact summary such as
+// "hits=1024 misses=80 hit_rate=92.75% keys=512"
+func (s StatsSnapshot) String() string {
+	return fmt.Sprintf("hits=%d misses=%d hit_rate=%.2f%% keys=%d", s.Hits, s.Misses, s.HitRate, s.KeyCount)
+}
+
+// MarshalJSON encodes a snapshot of the statistics
+func (s *Stats) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Snapshot())
+}
+
+// String returns a compact summary of a snapshot of the statistics
+func (s *Stats) String() string {
+	return s.Snapshot().String()
+}
+
+// MetricsStats returns a snapshot of the statistics in the form metrics exporters
+// read, so the cache can be passed to metrics.NewCacheCollector
+func (c *Cache) MetricsStats() metrics.Stats {
+	snapshot := c.stats.Snapshot()
+	return snapshot.exported()
+}
+
+// exported adapts the snapshot to the interfaces metrics exporters read
+func (s *StatsSnapshot) exported() snapshotStats {
+	return snapshotStats{s}
+}
+
+// snapshotStats implements metrics.Stats and its optional extensions over a snapshot
+type snapshotStats struct {
+	s *StatsSnapshot
+}
+
+func (e snapshotStats) Hits() int64                         { return e.s.Hits }
+func (e snapshotStats) Misses() int64                       { return e.s.Misses }
+func (e snapshotStats) Evictions() int64                    { return e.s.Evictions }
+func (e snapshotStats) Invalidations() int64                { return e.s.Invalidations }
+func (e snapshotStats) KeyCount() int64                     { return e.s.KeyCount }
+func (e snapshotStats) InFlight() int64                     { return e.s.InFlight }
+func (e snapshotStats) HitRate() float64                    { return e.s.HitRate }
+func (e snapshotStats) EvictionsByReason() map[string]int64 { return e.s.EvictionsByReason }
+func (e snapshotStats) ErrorsByType() map[string]int64      { return e.s.ErrorsByType }
+func (e snapshotStats) Sets() int64                         { return e.s.Sets }
+func (e snapshotStats) Deletes() int64                      { return e.s.Deletes }
+func (e snapshotStats) Clears() int64                       { return e.s.Clears }
+func (e snapshotStats) CleanupRemoved() int64               { return e.s.CleanupRemoved }
+func (e snapshotStats) MemoryBytes() int64                  { return e.s.MemoryBytes }
+func (e snapshotStats) RecentHitRate(time.Duration) float64 { return e.s.RecentHitRate }
+
+// Ensure snapshots carry everything exporters read from Stats
+var (
+	_ metrics.Stats          = snapshotStats{}
+	_ metrics.EvictionStats  = snapshotStats{}
+	_ metrics.ErrorStats     = snapshotStats{}
+	_ metrics.OperationStats = snapshotStats{}
+	_ metrics.MemoryStats    = snapshotStats{}
+	_ metrics.RecentStats    = snapshotStats{}
+)
@@ -0,0 +1,144 @@
+package obcache
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestStatsSnapshot(t *testing.T) {
+	cache, err := New(NewDefaultConfig().WithMaxEntries(2))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	for i := range 3 {
+		_ = cache.Set(fmt.Sprintf("key%d", i), i, time.Hour)
+	}
+	cache.Get("key2")
+	cache.Get("key2")
+	cache.Get("key2")
+	cache.Get("missing")
+
+	snapshot := cache.Stats().Snapshot()
+	if snapshot.Hits != 3 || snapshot.Misses != 1 || snapshot.Total() != 4 {
+		t.Errorf("Expected 3 hits and 1 miss, got %d and %d", snapshot.Hits, snapshot.Misses)
+	}
+	if snapshot.HitRate != 75 || snapshot.RecentHitRate != 75 {
+		t.Errorf("Expected 75%% hit rates, got %v and %v", snapshot.HitRate, snapshot.RecentHitRate)
+	}
+	if snapshot.KeyCount != 2 || snapshot.Capacity != 2 {
+		t.Errorf("Expected 2 keys with capacity 2, got %d and %d", snapshot.KeyCount, snapshot.Capacity)
+	}
+	if snapshot.EvictionsByReason["capacity"] != 1 || snapshot.Evictions != 1 {
+		t.Errorf("Expected one capacity eviction, got %v", snapshot.EvictionsByReason)
+	}
+	if snapshot.CapturedAt.IsZero() {
+		t.Error("Expected the capture time to be set")
+	}
+
+	// The snapshot is a copy, unaffected by later traffic
+	cache.Get("missing")
+	if snapshot.Misses != 1 || cache.Stats().Misses() != 2 {
+		t.Errorf("Expected the snapshot to keep 1 miss while the live stats move on, got %d and %d",
+			snapshot.Misses, cache.Stats().Misses())
+	}
+}
+
+func TestCacheResetStats(t *testing.T) {
+	mockExporter := NewMockExporter()
+	cache, err := New(NewDefaultConfig().WithMetrics(&MetricsConfig{Exporter: mockExporter, Enabled: true}))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	_ = cache.Set("a", "value", time.Hour)
+	_ = cache.Set("b", "value", time.Hour)
+	cache.Get("a")
+	cache.Get("missing")
+	_ = cache.Delete("b")
+	memoryBytes := cache.Stats().MemoryBytes()
+	cache.stats.incInFlight()
+
+	cache.ResetStats()
+
+	snapshot := cache.Stats().Snapshot()
+	if snapshot.Hits != 0 || snapshot.Misses != 0 || snapshot.Invalidations != 0 || snapshot.RecentHitRate != 0 {
+		t.Errorf("Expected the counters to be zeroed, got %+v", snapshot)
+	}
+	if snapshot.KeyCount != 1 || snapshot.MemoryBytes != memoryBytes {
+		t.Errorf("Expected the key count and memory to be recomputed as 1 and %d, got %d and %d",
+			memoryBytes, snapshot.KeyCount, snapshot.MemoryBytes)
+	}
+	if snapshot.InFlight != 1 {
+		t.Errorf("Expected the in-flight request to be kept, got %d", snapshot.InFlight)
+	}
+	cache.stats.decInFlight()
+
+	// Exporters receive a snapshot rather than the live stats
+	cache.Get("a")
+	cache.exportCurrentStats()
+	exported := mockExporter.GetLastStats()
+	if _, live := exported.(*Stats); live {
+		t.Error("Expected the exporter to receive a snapshot")
+	}
+	cache.Get("a")
+	if exported.Hits() != 1 || exported.HitRate() != 100 {
+		t.Errorf("Expected the exported stats to keep 1 hit, got %d", exported.Hits())
+	}
+}
+
+func TestStatsSnapshotJSONShape(t *testing.T) {
+	data, err := json.Marshal(StatsSnapshot{})
+	if err != nil {
+		t.Fatalf("Failed to marshal snapshot: %v", err)
+	}
+	var document map[string]any
+	if err := json.Unmarshal(data, &document); err != nil {
+		t.Fatalf("Failed to decode snapshot: %v", err)
+	}
+
+	// These names are an API; add to the list, never rename or remove
+	expected := []string{
+		"admission_rejections", "capacity", "captured_at", "cleanup_removed", "clears", "compressed_entries",
+		"compression_compressed_bytes", "compression_nanos", "compression_original_bytes",
+		"compression_ratio", "compression_skips", "decode_errors", "decompression_nanos", "deletes",
+		"errors", "errors_by_type", "evictions", "evictions_by_reason", "hit_rate", "hits", "in_flight",
+		"invalidations", "key_count", "l1_hits", "l2_hits", "memory_bytes", "misses",
+		"pinned_count", "recent_hit_rate", "refresh_failures", "refreshes", "restores", "sets", "spills", "stored_bytes",
+		"type_mismatches", "write_queue_depth",
+	}
+	if fields := slices.Sorted(maps.Keys(document)); !slices.Equal(fields, expected) {
+		t.Errorf("JSON fields changed:\n got %v\nwant %v", fields, expected)
+	}
+}
+
+func TestStatsJSONAndString(t *testing.T) {
+	stats := &Stats{}
+	for range 3 {
+		stats.incHits()
+	}
+	stats.incMisses()
+	stats.setKeyCount(2)
+
+	if summary := stats.String(); summary != "hits=3 misses=1 hit_rate=75.00% keys=2" {
+		t.Errorf("Unexpected summary %q", summary)
+	}
+
+	data, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatalf("Failed to marshal stats: %v", err)
+	}
+	var snapshot StatsSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		t.Fatalf("Failed to decode stats: %v", err)
+	}
+	if snapshot.Hits != 3 || snapshot.Misses != 1 || snapshot.HitRate != 75 || snapshot.KeyCount != 2 {
+		t.Errorf("Unexpected decoded stats: %s", data)
+	}
+}
@@ -0,0 +1,196 @@
+package obcache
+
+import (
+	"sync"
+
+	"github.com/1mb-dev/obcache-go/v2/internal/eviction"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+)
+
+// EvictionStrategy is a custom eviction policy for the memory store
+// Implementations only decide which keys to evict; the cache stores the entries
+// and enforces capacity. Calls are serialized by the cache, so implementations
+// do not need their own locking.
+type EvictionStrategy interface {
+	// OnAdd is called when key is stored, both for new keys and overwrites.
+	// value is the stored value, which may be compressed bytes when compression is enabled
+	OnAdd(key string, value any)
+
+	// OnAccess is called when key is read through Get
+	OnAccess(key string)
+
+	// OnRemove is called when key leaves the cache for any reason
+	OnRemove(key string)
+
+	// Victims returns up to n keys in the order they should be evicted
+	Victims(n int) []string
+}
+
+// EvictionStrategyFactory creates an EvictionStrategy for a cache of the given capacity
+type EvictionStrategyFactory func(capacity int) EvictionStrategy
+
+// customStrategy adapts an EvictionStrategy to the internal eviction.Strategy interface
+type customStrategy struct {
+	policy   EvictionStrategy
+	data     map[string]*entry.Entry
+	capacity int
+	mutex    sync.Mutex
+}
+
+// newCustomStrategy wraps policy so the memory store can use it
+func newCustomStrategy(policy EvictionStrategy, capacity int) *customStrategy {
+	return &customStrategy{
+		policy:   policy,
+		data:     make(map[string]*entry.Entry),
+		capacity: capacity,
+	}
+}
+
+// Add stores an entry, evicting the policy's first victim if capacity is exceeded
+// If the policy offers no usable victim an arbitrary key is evicted so the
+// capacity limit still holds
+func (s *customStrategy) Add(key string, e *entry.Entry) (string, *entry.Entry, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.data[key]; exists {
+		s.data[key] = e
+		s.policy.OnAdd(key, e.Value)
+		return "", nil, false
+	}
+
+	var evictKey string
+	var evictedEntry *entry.Entry
+	if len(s.data) >= s.capacity && s.capacity > 0 {
+		evictKey = s.victim()
+		evictedEntry = s.data[evictKey]
+		delete(s.data, evictKey)
+		s.policy.OnRemove(evictKey)
+	}
+
+	s.data[key] = e
+	s.policy.OnAdd(key, e.Value)
+	return evictKey, evictedEntry, evictedEntry != nil
+}
+
+// victim picks the key to evict (internal method, assumes lock is held)
+func (s *customStrategy) victim() string {
+	for _, key := range s.policy.Victims(1) {
+		if _, exists := s.data[key]; exists {
+			return key
+		}
+	}
+	for key := range s.data {
+		return key
+	}
+	return ""
+}
+
+// Get retrieves an entry and reports the access to the policy
+func (s *customStrategy) Get(key string) (*entry.Entry, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	e, found := s.data[key]
+	if found {
+		s.policy.OnAccess(key)
+	}
+	return e, found
+}
+
+// Remove removes an entry and reports its removal to the policy
+func (s *customStrategy) Remove(key string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.data[key]; !exists {
+		return false
+	}
+	delete(s.data, key)
+	s.policy.OnRemove(key)
+	return true
+}
+
+// Contains checks if a key exists in the tracker
+func (s *customStrategy) Contains(key string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, exists := s.data[key]
+	return exists
+}
+
+// Keys returns all keys currently tracked
+func (s *customStrategy) Keys() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	keys := make([]string, 0, len(s.data))
+	for key := range s.data {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Len returns the number of entries currently tracked
+func (s *customStrategy) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return len(s.data)
+}
+
+// Clear removes all entries, reporting each removal to the policy
+func (s *customStrategy) Clear() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for key := range s.data {
+		s.policy.OnRemove(key)
+	}
+	s.data = make(map[string]*entry.Entry)
+}
+
+// Capacity returns the maximum number of entries this strategy can hold
+func (s *customStrategy) Capacity() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.capacity
+}
+
+// Resize sets the maximum number of entries
+func (s *customStrategy) Resize(capacity int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.capacity = capacity
+}
+
+// Peek retrieves an entry without reporting an access to the policy
+func (s *customStrategy) Peek(key string) (*entry.Entry, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	e, found := s.data[key]
+	return e, found
+}
+
+// Victims returns the policy's victims, skipping keys the cache does not hold
+func (s *customStrategy) Victims(n int) []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	victims := make([]string, 0, max(min(n, len(s.data)), 0))
+	for _, key := range s.policy.Victims(n) {
+		if len(victims) == n {
+			break
+		}
+		if _, exists := s.data[key]; exists {
+			victims = append(victims, key)
+		}
+	}
+	return victims
+}
+
+var _ eviction.Strategy = (*customStrategy)(nil)
@@ -0,0 +1,190 @@
+package obcache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+	"github.com/1mb-dev/obcache-go/v2/pkg/metrics"
+	"github.com/1mb-dev/obcache-go/v2/pkg/store"
+)
+
+// errTypeMismatch marks decode failures caused by a stored value of another type
+var errTypeMismatch = errors.New("type mismatch")
+
+// Typed is a type-safe view over a Cache for values of type V
+// It shares the underlying store, statistics and hooks with the wrapped cache
+type Typed[V any] struct {
+	cache *Cache
+}
+
+// NewTyped creates a typed view over the given cache
+func NewTyped[V any](c *Cache) *Typed[V] {
+	return &Typed[V]{cache: c}
+}
+
+// Cache returns the underlying untyped cache
+func (t *Typed[V]) Cache() *Cache {
+	return t.cache
+}
+
+// Get retrieves a value of type V from the cache
+// Values that a serializing store returns in generic JSON form, such as structs
+// read back from Redis as map[string]any, are converted to V. Other values of
+// another type, and those that cannot be converted, are reported as a miss and counted in
+// Stats.TypeMismatches
+func (t *Typed[V]) Get(key string) (V, bool) {
+	return t.GetContext(context.Background(), key)
+}
+
+// GetContext retrieves a value of type V from the cache with context support
+func (t *Typed[V]) GetContext(ctx context.Context, key string) (V, bool) {
+	c := t.cache
+	start := time.Now()
+	defer func() {
+		c.recordCacheOperation(metrics.OperationGet, time.Since(start))
+	}()
+
+	var zero V
+
+	c.mu.RLock()
+	storeStart := c.storeTimer()
+	cacheEntry, ok := store.GetWithContext(ctx, c.store, key)
+	storeTime := c.storeElapsed(storeStart)
+	c.mu.RUnlock()
+	defer c.recordStoreOperation(metrics.OperationGet, storeTime)
+	if !ok {
+		c.miss(ctx, key)
+		return zero, false
+	}
+
+	value, err := t.decode(key, cacheEntry)
+	if err != nil {
+		if errors.Is(err, errTypeMismatch) {
+			c.stats.incTypeMismatches()
+		} else {
+			c.undecodable(ctx, key, cacheEntry, err)
+		}
+		c.miss(ctx, key)
+		return zero, false
+	}
+
+	c.hit(ctx, key, value)
+	return value, true
+}
+
+// Set stores a value of type V in the cache with the specified TTL
+func (t *Typed[V]) Set(key string, value V, ttl time.Duration) error {
+	return t.cache.SetContext(context.Background(), key, value, ttl)
+}
+
+// SetContext stores a value of type V in the cache with context support
+func (t *Typed[V]) SetContext(ctx context.Context, key string, value V, ttl time.Duration) error {
+	return t.cache.SetContext(ctx, key, value, ttl)
+}
+
+// GetOrSet returns the cached value for key, calling loader and storing its result on a miss
+// Concurrent callers for the same key share a single loader invocation
+func (t *Typed[V]) GetOrSet(key string, loader func() (V, error), ttl time.Duration) (V, error) {
+	ctx := context.Background()
+	if value, found := t.GetContext(ctx, key); found {
+		return value, nil
+	}
+
+	result, err := t.cache.compute(ctx, key, func(context.Context) (any, error) {
+		return loader()
+	}, ttl)
+	var zero V
+	if err != nil {
+		return zero, err
+	}
+	value, ok := result.(V)
+	if !ok {
+		t.cache.stats.incTypeMismatches()
+		return zero, fmt.Errorf("computed value has type %T, expected %T", result, zero)
+	}
+	return value, nil
+}
+
+// Delete removes a key from the cache
+func (t *Typed[V]) Delete(key string) error {
+	return t.cache.Delete(key)
+}
+
+// decode converts a stored entry back into a value of type V
+func (t *Typed[V]) decode(key string, cacheEntry *entry.Entry) (V, error) {
+	var value V
+	c := t.cache
+
+	// Compressed and encrypted caches store serialized bytes, so decode straight
+	// into V instead of asserting on the generic value produced by decompressValue
+	if c.serializes() {
+		data, err := c.storedBytes(key, cacheEntry)
+		if err != nil {
+			return value, err
+		}
+		valueCodec, compressor, err := c.decodersFor(cacheEntry)
+		if err != nil {
+			return value, err
+		}
+		start := time.Now()
+		defer func() { c.recordDecompression(time.Since(start)) }()
+		if cacheEntry.IsCompressed {
+			if data, err = compressor.Decompress(data); err != nil {
+				return value, fmt.Errorf("failed to decompress value: %w", err)
+			}
+		}
+		if err := valueCodec.Unmarshal(data, &value); err != nil {
+			// Only data the codec can read at all was stored with another type
+			var generic any
+			if valueCodec.Unmarshal(data, &generic) != nil {
+				return value, fmt.Errorf("failed to deserialize value: %w", err)
+			}
+			return value, fmt.Errorf("%w: %w", errTypeMismatch, err)
+		}
+		return value, nil
+	}
+
+	value, ok := cacheEntry.Value.(V)
+	if ok {
+		return value, nil
+	}
+
+	// Serializing stores, such as Redis, decode values without knowing V and
+	// return structs as map[string]any, so convert those generic forms back
+	if isGenericJSON(cacheEntry.Value) && c.restoresGenericValues() {
+		if data, err := json.Marshal(cacheEntry.Value); err == nil {
+			var converted V
+			if json.Unmarshal(data, &converted) == nil {
+				return converted, nil
+			}
+		}
+	}
+	return value, fmt.Errorf("%w: cached value has type %T, expected %T", errTypeMismatch, cacheEntry.Value, value)
+}
+
+// isGenericJSON reports whether value has one of the types JSON decodes into
+// when the target type is unknown
+func isGenericJSON(value any) bool {
+	switch value.(type) {
+	case map[string]any, []any, float64, string, bool:
+		return true
+	}
+	return false
+}
+
+// restoresGenericValues reports whether the store may return values decoded from
+// JSON in generic form rather than as stored. In-memory stores keep values as
+// they are, unless entries spill to disk
+func (c *Cache) restoresGenericValues() bool {
+	switch c.config.StoreType {
+	case StoreTypeMemory:
+		return c.config.DiskOverflow != nil
+	case StoreTypeRistretto:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,227 @@
+package obcache
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/codec"
+	"github.com/1mb-dev/obcache-go/v2/pkg/compression"
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+)
+
+type typedUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestTypedGetSet(t *testing.T) {
+	cache, err := New(NewDefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	users := NewTyped[typedUser](cache)
+
+	if err := users.Set("user:1", typedUser{ID: 1, Name: "alice"}, time.Hour); err != nil {
+		t.Fatalf("Failed to set typed value: %v", err)
+	}
+
+	user, found := users.Get("user:1")
+	if !found {
+		t.Fatal("Expected to find typed value")
+	}
+	if user.Name != "alice" {
+		t.Fatalf("Expected alice, got %s", user.Name)
+	}
+
+	// The untyped cache sees the same entry
+	if !cache.Has("user:1") {
+		t.Fatal("Expected untyped cache to share the underlying store")
+	}
+}
+
+func TestTypedTypeMismatch(t *testing.T) {
+	cache, err := New(NewDefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	_ = cache.Set("user:1", "not a user", time.Hour)
+
+	users := NewTyped[typedUser](cache)
+	if _, found := users.Get("user:1"); found {
+		t.Fatal("Expected mismatched type to be reported as a miss")
+	}
+
+	stats := cache.Stats()
+	if stats.TypeMismatches() != 1 {
+		t.Fatalf("Expected 1 type mismatch, got %d", stats.TypeMismatches())
+	}
+	if stats.Misses() != 1 || stats.Hits() != 0 {
+		t.Fatalf("Expected 1 miss and 0 hits, got %d misses and %d hits", stats.Misses(), stats.Hits())
+	}
+}
+
+func TestTypedTypeMismatchNotConverted(t *testing.T) {
+	type revA struct{ Name string }
+	type revB struct{ ID int }
+
+	// The memory store keeps values as they are, so nothing is converted
+	cache, err := New(NewDefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	_ = cache.Set("rev", revA{Name: "x"}, time.Hour)
+	_ = cache.Set("count", 3.0, time.Hour)
+	_ = cache.Set("generic", map[string]any{"ID": 1.0}, time.Hour)
+
+	if value, found := NewTyped[revB](cache).Get("rev"); found {
+		t.Errorf("Expected a struct of another type to be a miss, got %+v", value)
+	}
+	if value, found := NewTyped[int](cache).Get("count"); found {
+		t.Errorf("Expected a float64 read as int to be a miss, got %v", value)
+	}
+	if value, found := NewTyped[revB](cache).Get("generic"); found {
+		t.Errorf("Expected a map in the memory store not to be converted, got %+v", value)
+	}
+	if n := cache.Stats().TypeMismatches(); n != 3 {
+		t.Errorf("Expected 3 type mismatches, got %d", n)
+	}
+}
+
+func TestTypedWithCompression(t *testing.T) {
+	config := NewDefaultConfig().WithCompression(compression.NewDefaultConfig().WithEnabled(true).WithMinSize(0))
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	users := NewTyped[typedUser](cache)
+
+	_ = users.Set("user:1", typedUser{ID: 1, Name: "alice"}, time.Hour)
+	user, found := users.Get("user:1")
+	if !found {
+		t.Fatal("Expected to find compressed typed value")
+	}
+	if user.ID != 1 || user.Name != "alice" {
+		t.Fatalf("Unexpected decoded value: %+v", user)
+	}
+}
+
+func TestTypedWithSerializingStore(t *testing.T) {
+	// Bolt stores values as JSON, so structs come back as map[string]any
+	cache, err := New(NewBoltConfig(filepath.Join(t.TempDir(), "cache.db")))
+	if err != nil {
+		t.Fatalf("Failed to create Bolt cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+	users := NewTyped[typedUser](cache)
+
+	_ = users.Set("user:1", typedUser{ID: 1, Name: "alice"}, time.Hour)
+	user, found := users.Get("user:1")
+	if !found {
+		t.Fatal("Expected to find typed value read back from the store")
+	}
+	if user.ID != 1 || user.Name != "alice" {
+		t.Fatalf("Unexpected decoded value: %+v", user)
+	}
+
+	_ = cache.Set("user:2", "not a user", time.Hour)
+	if _, found := users.Get("user:2"); found {
+		t.Fatal("Expected a value that does not convert to be reported as a miss")
+	}
+	if stats := cache.Stats(); stats.Hits() != 1 || stats.TypeMismatches() != 1 {
+		t.Fatalf("Expected 1 hit and 1 type mismatch, got %d and %d", stats.Hits(), stats.TypeMismatches())
+	}
+}
+
+func TestTypedCorruptEntry(t *testing.T) {
+	var hookErr error
+	hooks := NewHooks()
+	hooks.AddOnError(func(_ context.Context, _ string, _ string, err error) { hookErr = err })
+
+	cache, err := New(NewDefaultConfig().
+		WithCompression(compression.NewDefaultConfig().WithEnabled(true).WithMinSize(0)).
+		WithHooks(hooks))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	users := NewTyped[typedUser](cache)
+
+	// Plant a payload that claims to be gzip but is not
+	poisoned := entry.NewWithoutTTL([]byte("not gzip at all"))
+	poisoned.CodecName = codec.JSON{}.Name()
+	poisoned.SetCompressionInfo("gzip", 100, len("not gzip at all"))
+	if err := cache.store.Set("user:1", poisoned); err != nil {
+		t.Fatalf("Failed to plant entry: %v", err)
+	}
+
+	if _, found := users.Get("user:1"); found {
+		t.Fatal("Expected the corrupt entry to be a miss")
+	}
+	stats := cache.Stats()
+	if stats.DecodeErrors() != 1 || stats.TypeMismatches() != 0 {
+		t.Errorf("Expected 1 decode error and no type mismatch, got %d and %d", stats.DecodeErrors(), stats.TypeMismatches())
+	}
+	if hookErr == nil {
+		t.Error("Expected OnError for the corrupt entry")
+	}
+	if cache.Has("user:1") {
+		t.Error("Expected the corrupt entry to be deleted")
+	}
+
+	// A value of another type is still a type mismatch and is kept
+	_ = cache.Set("user:2", []string{"not", "a", "user"}, time.Hour)
+	if _, found := users.Get("user:2"); found {
+		t.Fatal("Expected mismatched type to be reported as a miss")
+	}
+	if stats.TypeMismatches() != 1 || stats.DecodeErrors() != 1 {
+		t.Errorf("Expected 1 type mismatch and still 1 decode error, got %d and %d", stats.TypeMismatches(), stats.DecodeErrors())
+	}
+	if !cache.Has("user:2") {
+		t.Error("Expected the mismatched entry to be kept")
+	}
+}
+
+func TestTypedGetOrSet(t *testing.T) {
+	cache, err := New(NewDefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	counters := NewTyped[int](cache)
+
+	var calls int32
+	loader := func() (int, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(TestShortTTL)
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := counters.GetOrSet("answer", loader, time.Hour)
+			if err != nil || value != 42 {
+				t.Errorf("Expected 42, got %d (err=%v)", value, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls != 1 {
+		t.Fatalf("Expected loader to be called once, got %d", calls)
+	}
+
+	loadErr := errors.New("load failed")
+	_, err = counters.GetOrSet("broken", func() (int, error) { return 0, loadErr }, time.Hour)
+	if !errors.Is(err, loadErr) {
+		t.Fatalf("Expected loader error, got %v", err)
+	}
+	if cache.Has("broken") {
+		t.Fatal("Loader errors should not be cached")
+	}
+}
@@ -0,0 +1,163 @@
+package obcache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultWarmupConcurrency is the number of keys Warmup loads in parallel by default
+const DefaultWarmupConcurrency = 8
+
+// WarmupOptions holds configuration options for Warmup
+type WarmupOptions struct {
+	// Concurrency limits how many loader calls run at the same time
+	Concurrency int
+
+	// TTL is the TTL applied to loaded values (0 uses the cache's DefaultTTL)
+	TTL time.Duration
+
+	// ContinueOnError keeps loading the remaining keys after a loader fails
+	ContinueOnError bool
+
+	// Overwrite reloads keys that are already present in the cache
+	Overwrite bool
+}
+
+// WarmupOption is a function that configures WarmupOptions
+type WarmupOption func(*WarmupOptions)
+
+// WithWarmupConcurrency sets the maximum number of concurrent loader calls
+func WithWarmupConcurrency(n int) WarmupOption {
+	return func(opts *WarmupOptions) {
+		opts.Concurrency = n
+	}
+}
+
+// WithWarmupTTL sets the TTL for values loaded during warmup
+func WithWarmupTTL(ttl time.Duration) WarmupOption {
+	return func(opts *WarmupOptions) {
+		opts.TTL = ttl
+	}
+}
+
+// WithWarmupContinueOnError keeps warming the remaining keys when a loader fails
+func WithWarmupContinueOnError() WarmupOption {
+	return func(opts *WarmupOptions) {
+		opts.ContinueOnError = true
+	}
+}
+
+// WithWarmupOverwrite reloads keys even if they are already cached
+func WithWarmupOverwrite() WarmupOption {
+	return func(opts *WarmupOptions) {
+		opts.Overwrite = true
+	}
+}
+
+// WarmupReport summarizes the outcome of a Warmup call
+type WarmupReport struct {
+	// Loaded is the number of keys whose values were loaded and stored
+	Loaded int
+
+	// Failed is the number of keys whose loader returned an error
+	Failed int
+
+	// Skipped is the number of keys that were already cached
+	Skipped int
+
+	// Errors holds the loader error for each failed key
+	Errors map[string]error
+}
+
+// Warmup preloads keys into the cache by calling loader for each of them
+// Keys that are already cached are skipped unless WithWarmupOverwrite is given.
+// Loads are coalesced with concurrent Do/GetOrSet calls for the same key, so live
+// traffic and warmup never call a loader twice for one key.
+//
+// By default the first loader error stops warmup and is returned. With
+// WithWarmupContinueOnError all keys are attempted and failures are only reported.
+// Cancelling ctx stops starting new loads and returns ctx.Err(); keys that were
+// never started are not counted in the report.
+func (c *Cache) Warmup(ctx context.Context, keys []string, loader func(ctx context.Context, key string) (any, error), opts ...WarmupOption) (WarmupReport, error) {
+	options := &WarmupOptions{
+		Concurrency: DefaultWarmupConcurrency,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = DefaultWarmupConcurrency
+	}
+
+	report := WarmupReport{Errors: make(map[string]error)}
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+		seen     = make(map[string]struct{}, len(keys))
+		sem      = make(chan struct{}, options.Concurrency)
+	)
+
+	for _, key := range keys {
+		if _, dup := seen[key]; dup {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		select {
+		case sem <- struct{}{}:
+		case <-runCtx.Done():
+		}
+		if runCtx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if !options.Overwrite && c.Has(key) {
+				mu.Lock()
+				report.Skipped++
+				mu.Unlock()
+				return
+			}
+
+			_, err := c.compute(runCtx, key, func(loadCtx context.Context) (any, error) {
+				return loader(loadCtx, key)
+			}, options.TTL)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				report.Loaded++
+			case runCtx.Err() != nil:
+				// Abandoned because warmup was cancelled; not a loader failure
+			default:
+				report.Failed++
+				report.Errors[key] = err
+				if !options.ContinueOnError && firstErr == nil {
+					firstErr = fmt.Errorf("warmup failed for key %q: %w", key, err)
+					cancel()
+				}
+			}
+		}(key)
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return report, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return report, err
+	}
+	return report, nil
+}
@@ -0,0 +1,164 @@
+package obcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWarmup(t *testing.T) {
+	cache, err := New(NewDefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	_ = cache.Set("key0", "existing", time.Hour)
+
+	var active, peak int32
+	loader := func(_ context.Context, key string) (any, error) {
+		n := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return "loaded-" + key, nil
+	}
+
+	keys := make([]string, 10)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key%d", i)
+	}
+
+	report, err := cache.Warmup(context.Background(), keys, loader, WithWarmupConcurrency(3))
+	if err != nil {
+		t.Fatalf("Warmup failed: %v", err)
+	}
+	if report.Loaded != 9 || report.Skipped != 1 || report.Failed != 0 {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+	if p := atomic.LoadInt32(&peak); p > 3 {
+		t.Errorf("Expected at most 3 concurrent loads, got %d", p)
+	}
+
+	if value, _ := cache.Get("key0"); value != "existing" {
+		t.Errorf("Expected existing key to be skipped, got %v", value)
+	}
+	if value, _ := cache.Get("key5"); value != "loaded-key5" {
+		t.Errorf("Expected key5 to be warmed, got %v", value)
+	}
+
+	report, err = cache.Warmup(context.Background(), []string{"key0"}, loader, WithWarmupOverwrite())
+	if err != nil || report.Loaded != 1 {
+		t.Fatalf("Expected overwrite to reload key0, got %+v (err=%v)", report, err)
+	}
+	if value, _ := cache.Get("key0"); value != "loaded-key0" {
+		t.Errorf("Expected key0 to be reloaded, got %v", value)
+	}
+}
+
+func TestWarmupErrors(t *testing.T) {
+	errLoad := errors.New("load failed")
+	loader := func(_ context.Context, key string) (any, error) {
+		if key == "bad" {
+			return nil, errLoad
+		}
+		return key, nil
+	}
+
+	cache, err := New(NewDefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	_, err = cache.Warmup(context.Background(), []string{"bad"}, loader)
+	if !errors.Is(err, errLoad) {
+		t.Fatalf("Expected loader error, got %v", err)
+	}
+
+	report, err := cache.Warmup(context.Background(), []string{"a", "bad", "b"}, loader, WithWarmupContinueOnError())
+	if err != nil {
+		t.Fatalf("Expected no error with continue-on-error, got %v", err)
+	}
+	if report.Loaded != 2 || report.Failed != 1 || !errors.Is(report.Errors["bad"], errLoad) {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+	if cache.Has("bad") {
+		t.Error("Expected failed key not to be cached")
+	}
+}
+
+func TestWarmupCancellation(t *testing.T) {
+	cache, err := New(NewDefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls int32
+	loader := func(_ context.Context, key string) (any, error) {
+		if atomic.AddInt32(&calls, 1) == 2 {
+			cancel()
+		}
+		return key, nil
+	}
+
+	keys := make([]string, 100)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key%d", i)
+	}
+
+	_, err = cache.Warmup(ctx, keys, loader, WithWarmupConcurrency(1))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if n := atomic.LoadInt32(&calls); n >= int32(len(keys)) {
+		t.Errorf("Expected warmup to stop early, loader called %d times", n)
+	}
+}
+
+func TestWarmupCoalescesWithLiveTraffic(t *testing.T) {
+	cache, err := New(NewDefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	var calls int32
+	release := make(chan struct{})
+	loader := func(_ context.Context, _ string) (any, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "value", nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = cache.Do("shared", func(ctx context.Context) (any, error) { return loader(ctx, "shared") }, time.Hour)
+	}()
+	time.Sleep(10 * time.Millisecond) // Let Do start its load
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+	}()
+	report, err := cache.Warmup(context.Background(), []string{"shared"}, loader)
+	<-done
+
+	if err != nil || report.Loaded != 1 {
+		t.Fatalf("Unexpected warmup result: %+v (err=%v)", report, err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("Expected a single loader call, got %d", n)
+	}
+}
@@ -29,6 +29,15 @@ type WrapOptions struct {
 
 	// ErrorTTL is the TTL for cached errors (defaults to TTL if not set)
 	ErrorTTL time.Duration
+
+	// SetOptions are applied when results are stored, e.g. WithSkipCompression
+	SetOptions []SetOption
+
+	// MetricsLabel is the function label on this function's metrics
+	// Default: derived from the function's runtime name
+	MetricsLabel string
+
+	stats *wrapStats
 }
 
 // WrapOption is a function that configures WrapOptions
@@ -70,9 +79,28 @@ func WithErrorTTL(ttl time.Duration) WrapOption {
 	}
 }
 
+// WithSetOptions applies SetOptions when the wrapped function's results are stored
+func WithSetOptions(options ...SetOption) WrapOption {
+	return func(opts *WrapOptions) {
+		opts.SetOptions = append(opts.SetOptions, options...)
+	}
+}
+
+// WithMetricsLabel sets the function label on the wrapped function's metrics
+func WithMetricsLabel(name string) WrapOption {
+	return func(opts *WrapOptions) {
+		opts.MetricsLabel = name
+	}
+}
+
 // Wrap wraps any function with caching using Go generics
 // T must be a function type
 func Wrap[T any](cache *Cache, fn T, options ...WrapOption) T {
+	return wrapFunction(cache, fn, newWrapOptions(cache, options))
+}
+
+// newWrapOptions applies options over the cache's defaults
+func newWrapOptions(cache *Cache, options []WrapOption) *WrapOptions {
 	opts := &WrapOptions{
 		TTL:     cache.config.DefaultTTL,
 		KeyFunc: cache.getKeyGenFunc(),
@@ -82,7 +110,7 @@ func Wrap[T any](cache *Cache, fn T, options ...WrapOption) T {
 		opt(opts)
 	}
 
-	return wrapFunction(cache, fn, opts)
+	return opts
 }
 
 // wrapFunction performs the actual function wrapping using reflection
@@ -95,6 +123,11 @@ func wrapFunction[T any](cache *Cache, fn T, opts *WrapOptions) T {
 		panic("obcache.Wrap: argument must be a function")
 	}
 
+	if opts.MetricsLabel == "" {
+		opts.MetricsLabel = functionLabel(fnValue)
+	}
+	opts.stats = newWrapStats(cache, opts.MetricsLabel)
+
 	// Create the wrapper function
 	wrapper := reflect.MakeFunc(fnType, func(args []reflect.Value) []reflect.Value {
 		return executeWrappedFunction(cache, fnValue, fnType, opts, args)
@@ -116,7 +149,10 @@ func executeWrappedFunction(cache *Cache, fnValue reflect.Value, fnType reflect.
 	hasErrorReturn := hasErrorReturn(fnType)
 
 	// Try to get from cache first using context
-	if cachedValue, found := cache.GetContext(ctx, key); found {
+	start := time.Now()
+	cachedValue, found := cache.GetContext(ctx, key)
+	opts.stats.recordLookup(found, time.Since(start))
+	if found {
 		return convertCachedValue(cachedValue, fnType, hasErrorReturn)
 	}
 
@@ -158,8 +194,11 @@ func hasErrorReturn(fnType reflect.Type) bool {
 func executeFunctionWithSingleflight(cache *Cache, ctx context.Context, fnValue reflect.Value, fnType reflect.Type, opts *WrapOptions, args []reflect.Value, key string, hasErrorReturn bool) []reflect.Value {
 	// Use singleflight to prevent duplicate calls
 	compute := func() (any, error) {
+		start := time.Now()
 		results := fnValue.Call(args)
-		return processResults(results, hasErrorReturn)
+		value, err := processResults(results, hasErrorReturn)
+		opts.stats.recordCall(err, time.Since(start))
+		return value, err
 	}
 
 	// Execute with singleflight
@@ -175,7 +214,7 @@ func executeFunctionWithSingleflight(cache *Cache, ctx context.Context, fnValue
 			if errorTTL == 0 {
 				errorTTL = opts.TTL
 			}
-			_ = cache.SetContext(ctx, key, cachedError{Err: err}, errorTTL) // Cache error with context
+			_ = cache.SetContext(ctx, key, cachedError{Err: err}, errorTTL, opts.SetOptions...) // Cache error with context
 		}
 		// Return the error in the function's expected format
 		return createErrorReturn(fnType, err)
@@ -183,7 +222,7 @@ func executeFunctionWithSingleflight(cache *Cache, ctx context.Context, fnValue
 
 	// Store in cache if this wasn't a shared call
 	if !shared {
-		_ = cache.SetContext(ctx, key, value, opts.TTL) // Cache result with context
+		_ = cache.SetContext(ctx, key, value, opts.TTL, opts.SetOptions...) // Cache result with context
 	}
 
 	// Convert the result back to the expected format
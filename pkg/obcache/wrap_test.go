@@ -3,10 +3,13 @@ package obcache
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/compression"
 )
 
 func TestWrapSimpleFunction(t *testing.T) {
@@ -228,6 +231,31 @@ func TestWrapWithoutCache(t *testing.T) {
 	}
 }
 
+func TestWrapWithSetOptions(t *testing.T) {
+	config := NewDefaultConfig().WithCompression(compression.NewDefaultConfig().WithEnabled(true).WithMinSize(10))
+	cache, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	render := func(name string) string {
+		return strings.Repeat(name, 50)
+	}
+	keyFunc := func(args []any) string { return "page:" + args[0].(string) }
+	wrapped := Wrap(cache, render, WithKeyFunc(keyFunc), WithSetOptions(WithSkipCompression()))
+
+	if result := wrapped("home"); result != render("home") {
+		t.Fatalf("Expected the rendered page, got %q", result)
+	}
+	if _, info, found := cache.GetEntry("page:home"); !found || info.Compressed {
+		t.Errorf("Expected the result stored uncompressed, got %+v (found=%v)", info, found)
+	}
+	if result := wrapped("home"); result != render("home") {
+		t.Errorf("Expected the cached page, got %q", result)
+	}
+}
+
 func TestWrapSingleflight(t *testing.T) {
 	cache, err := New(NewDefaultConfig())
 	if err != nil {
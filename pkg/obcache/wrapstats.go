@@ -0,0 +1,149 @@
+package obcache
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/metrics"
+)
+
+// WrapStats is a snapshot of one wrapped function's cache activity
+type WrapStats struct {
+	// Function is the metrics label of the wrapped function
+	Function string
+
+	// Hits and Misses count lookups of the function's results in the cache
+	Hits   int64
+	Misses int64
+
+	// Calls counts executions of the underlying function and Errors those that
+	// returned an error
+	Calls  int64
+	Errors int64
+
+	// CallDuration is the total time spent executing the underlying function
+	CallDuration time.Duration
+}
+
+// HitRate returns the percentage of lookups served from the cache
+func (s WrapStats) HitRate() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total) * 100
+}
+
+// Wrapper is a cached function together with its per-function stats
+// Call it through the Call field
+type Wrapper[T any] struct {
+	// Call is the cached function, equivalent to the result of Wrap
+	Call T
+
+	stats *wrapStats
+}
+
+// Stats returns a snapshot of the wrapped function's cache activity
+func (w *Wrapper[T]) Stats() WrapStats {
+	return w.stats.snapshot()
+}
+
+// WrapWithStats wraps fn like Wrap and returns it with its per-function stats
+func WrapWithStats[T any](cache *Cache, fn T, options ...WrapOption) *Wrapper[T] {
+	opts := newWrapOptions(cache, options)
+	return &Wrapper[T]{Call: wrapFunction(cache, fn, opts), stats: opts.stats}
+}
+
+// wrapStats counts a wrapped function's activity and forwards it to the cache's
+// metrics exporter, labelled with the function
+type wrapStats struct {
+	function string
+
+	hits         atomic.Int64
+	misses       atomic.Int64
+	calls        atomic.Int64
+	errors       atomic.Int64
+	callDuration atomic.Int64
+
+	exporter metrics.Exporter
+	labels   metrics.Labels
+}
+
+func newWrapStats(cache *Cache, function string) *wrapStats {
+	s := &wrapStats{function: function, exporter: cache.metricsExporter}
+	if s.exporter != nil {
+		s.labels = make(metrics.Labels, len(cache.metricsLabels)+1)
+		for k, v := range cache.metricsLabels {
+			s.labels[k] = v
+		}
+		s.labels[metrics.LabelFunction] = function
+	}
+	return s
+}
+
+// recordLookup counts a cache lookup of the function's result
+func (s *wrapStats) recordLookup(hit bool, duration time.Duration) {
+	result := metrics.ResultMiss
+	if hit {
+		s.hits.Add(1)
+		result = metrics.ResultHit
+	} else {
+		s.misses.Add(1)
+	}
+	s.export(metrics.OperationGet, result, duration)
+}
+
+// recordCall counts an execution of the underlying function
+func (s *wrapStats) recordCall(err error, duration time.Duration) {
+	s.calls.Add(1)
+	s.callDuration.Add(int64(duration))
+	result := metrics.ResultSuccess
+	if err != nil {
+		s.errors.Add(1)
+		result = metrics.ResultError
+	}
+	s.export(metrics.OperationFunctionCall, result, duration)
+}
+
+func (s *wrapStats) export(operation metrics.Operation, result metrics.Result, duration time.Duration) {
+	if s.exporter == nil {
+		return
+	}
+	labels := make(metrics.Labels, len(s.labels)+1)
+	for k, v := range s.labels {
+		labels[k] = v
+	}
+	labels[metrics.LabelResult] = string(result)
+	_ = s.exporter.RecordCacheOperation(operation, duration, labels) //nolint:errcheck // Error handling done at higher level
+}
+
+func (s *wrapStats) snapshot() WrapStats {
+	return WrapStats{
+		Function:     s.function,
+		Hits:         s.hits.Load(),
+		Misses:       s.misses.Load(),
+		Calls:        s.calls.Load(),
+		Errors:       s.errors.Load(),
+		CallDuration: time.Duration(s.callDuration.Load()),
+	}
+}
+
+// functionLabel derives a metrics label from fn's runtime name, dropping the
+// package path and receiver, e.g. "fetchUser" or "(*Client).Fetch"
+func functionLabel(fn reflect.Value) string {
+	f := runtime.FuncForPC(fn.Pointer())
+	if f == nil {
+		return "unknown"
+	}
+	name := f.Name()
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.TrimSuffix(name, "-fm")
+}
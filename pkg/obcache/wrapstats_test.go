@@ -0,0 +1,151 @@
+package obcache
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func fetchUserForStats(id int) (string, error) {
+	if id < 0 {
+		return "", errors.New("invalid id")
+	}
+	return fmt.Sprintf("user-%d", id), nil
+}
+
+type statsClient struct{}
+
+func (*statsClient) Fetch(id int) string { return fmt.Sprint(id) }
+
+func TestWrapWithStats(t *testing.T) {
+	cache, err := New(NewDefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	fetchUser := WrapWithStats(cache, fetchUserForStats)
+
+	_, _ = fetchUser.Call(1)
+	_, _ = fetchUser.Call(1)
+	_, _ = fetchUser.Call(2)
+	if _, err := fetchUser.Call(-1); err == nil {
+		t.Fatal("Expected an error for a negative id")
+	}
+
+	stats := fetchUser.Stats()
+	if stats.Function != "fetchUserForStats" {
+		t.Errorf("Expected the label fetchUserForStats, got %q", stats.Function)
+	}
+	if stats.Hits != 1 || stats.Misses != 3 {
+		t.Errorf("Expected 1 hit and 3 misses, got %d and %d", stats.Hits, stats.Misses)
+	}
+	if stats.Calls != 3 || stats.Errors != 1 {
+		t.Errorf("Expected 3 calls and 1 error, got %d and %d", stats.Calls, stats.Errors)
+	}
+	if stats.HitRate() != 25 {
+		t.Errorf("Expected a 25%% hit rate, got %v", stats.HitRate())
+	}
+
+	labelled := WrapWithStats(cache, fetchUserForStats, WithMetricsLabel("fetchUser"), WithKeyFunc(func(args []any) string {
+		return fmt.Sprintf("labelled:%v", args[0])
+	}))
+	_, _ = labelled.Call(1)
+	if got := labelled.Stats(); got.Function != "fetchUser" || got.Misses != 1 {
+		t.Errorf("Expected one miss labelled fetchUser, got %+v", got)
+	}
+	if fetchUser.Stats().Misses != 3 {
+		t.Error("Expected wrapped functions to keep separate stats")
+	}
+}
+
+func TestFunctionLabel(t *testing.T) {
+	closure := func() int { return 1 }
+	client := &statsClient{}
+
+	tests := []struct {
+		fn   any
+		want string
+	}{
+		{fetchUserForStats, "fetchUserForStats"},
+		{client.Fetch, "(*statsClient).Fetch"},
+		{closure, "TestFunctionLabel.func1"},
+		{time.Now, "Now"},
+	}
+	for _, tt := range tests {
+		if got := functionLabel(reflect.ValueOf(tt.fn)); got != tt.want {
+			t.Errorf("Expected the label %q, got %q", tt.want, got)
+		}
+	}
+}
+
+func TestWrapMetricsLabelPrometheus(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	exporter, err := metrics.NewPrometheusExporter(nil, &metrics.PrometheusConfig{Registry: registry})
+	if err != nil {
+		t.Fatalf("Failed to create Prometheus exporter: %v", err)
+	}
+	cache, err := New(NewDefaultConfig().WithMetrics(&MetricsConfig{Exporter: exporter, Enabled: true, CacheName: "x"}))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	fetchUser := Wrap(cache, func(id int) string { return fmt.Sprint("user", id) },
+		WithMetricsLabel("fetchUser"), WithKeyFunc(func(args []any) string { return fmt.Sprint("user:", args[0]) }))
+	fetchOrders := Wrap(cache, func(id int) (string, error) { return "", errors.New("unavailable") },
+		WithMetricsLabel("fetchOrders"), WithKeyFunc(func(args []any) string { return fmt.Sprint("orders:", args[0]) }))
+
+	fetchUser(1)
+	fetchUser(1)
+	fetchUser(2)
+	_, _ = fetchOrders(1)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	operations := make(map[string]float64)
+	errorCounts := make(map[string]float64)
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["cache_name"] != "x" {
+				t.Errorf("Expected cache_name x, got %v", labels)
+			}
+			switch family.GetName() {
+			case "obcache_operations_total":
+				operations[labels["function"]+"/"+labels["operation"]+"/"+labels["result"]] = metric.GetCounter().GetValue()
+			case "obcache_errors_total":
+				errorCounts[labels["function"]+"/"+labels["operation"]] = metric.GetCounter().GetValue()
+			}
+		}
+	}
+
+	expected := map[string]float64{
+		"fetchUser/get/hit":                 1,
+		"fetchUser/get/miss":                2,
+		"fetchUser/function_call/success":   2,
+		"fetchOrders/get/miss":              1,
+		"fetchOrders/function_call/error":   1,
+		"fetchOrders/function_call/success": 0,
+		"fetchOrders/get/hit":               0,
+		"fetchUser/function_call/error":     0,
+	}
+	for series, want := range expected {
+		if got := operations[series]; got != want {
+			t.Errorf("Expected obcache_operations_total %s = %v, got %v", series, want, got)
+		}
+	}
+	if errorCounts["fetchOrders/function_call"] != 1 || len(errorCounts) != 1 {
+		t.Errorf("Expected one function_call error for fetchOrders, got %v", errorCounts)
+	}
+}
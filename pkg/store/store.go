@@ -0,0 +1,487 @@
+// Package store defines the storage backend interfaces used by obcache.
+//
+// The built-in backends (memory, Redis, Bolt, SQLite, tiered) implement these
+// interfaces, and so can any user-provided backend passed to
+// obcache.Config.WithCustomStore. A backend implements Store and may implement
+// any of the optional capability interfaces; the cache detects them with type
+// assertions and falls back to Store methods where it can.
+//
+// # Concurrency contract
+//
+// A Store must be safe for concurrent use. The cache calls Get, Peek, Keys and
+// Len concurrently with each other and with writes, and it does not serialize
+// its writes: Set, Delete and the single-key capability writes may run
+// concurrently, whether they target different keys or the same key. Only Clear,
+// Resize, Pin, Cleanup and Evict are exclusive: the cache starts no other write
+// while one of them runs. Cleanup may also be called from a background goroutine.
+//
+// Entries passed to Set belong to the store afterwards and are not modified by
+// the cache. The cache reads entries returned by Get and Peek without holding
+// any store lock; a store that updates access times should use Entry.Touch,
+// which is synchronized.
+//
+// Callbacks registered with SetEvictCallback and SetCleanupCallback may be
+// called from inside Set or Cleanup, or from a background goroutine, with or
+// without the store's own locks held. Each removed entry is reported once.
+//
+// Get must report expired entries as not found. A store may delete them at that
+// point or leave them for Cleanup.
+package store
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/1mb-dev/obcache-go/v2/pkg/entry"
+)
+
+// Store defines the interface for cache storage backends
+// This abstraction allows for different implementations (memory, Redis, etc.)
+type Store interface {
+	// Get retrieves an entry by key
+	// Returns the entry and true if found, nil and false if not found
+	Get(key string) (*entry.Entry, bool)
+
+	// Peek retrieves an entry by key without side effects on eviction order,
+	// access frequency or access time. Expired entries are reported as not found
+	Peek(key string) (*entry.Entry, bool)
+
+	// Set stores an entry with the given key
+	// Returns an error if the operation fails
+	Set(key string, entry *entry.Entry) error
+
+	// Delete removes an entry by key
+	// Returns an error if the operation fails
+	Delete(key string) error
+
+	// Keys returns all keys currently in the store
+	Keys() []string
+
+	// Len returns the current number of entries in the store
+	Len() int
+
+	// Clear removes all entries from the store
+	// Returns an error if the operation fails
+	Clear() error
+
+	// Close closes the store and cleans up resources
+	// Should be called when the cache is no longer needed
+	Close() error
+}
+
+// EvictCallback is called when an entry is evicted from the store
+// This allows the cache to track evictions and invoke hooks
+type EvictCallback func(key string, value any)
+
+// EntryCallback is called with the whole entry removed from a store
+// The entry is nil when the store no longer has it, e.g. for expirations
+// reported by Redis keyspace notifications
+type EntryCallback func(key string, e *entry.Entry)
+
+// EntryCallbackStore extends Store with removal callbacks that receive whole
+// entries, so their metadata can be reported along with the value
+type EntryCallbackStore interface {
+	Store
+
+	// SetEntryCallbacks sets the callbacks for capacity evictions and TTL removals,
+	// replacing those set with SetEvictCallback and SetCleanupCallback. Either may be nil
+	SetEntryCallbacks(evict, cleanup EntryCallback)
+}
+
+// ValueCallback adapts an EvictCallback to an EntryCallback, passing it the
+// entry's value, or nil when the entry is nil. A nil callback stays nil
+func ValueCallback(callback EvictCallback) EntryCallback {
+	if callback == nil {
+		return nil
+	}
+	return func(key string, e *entry.Entry) {
+		var value any
+		if e != nil {
+			value = e.Value
+		}
+		callback(key, value)
+	}
+}
+
+// LRUStore extends Store with LRU-specific functionality
+type LRUStore interface {
+	Store
+
+	// SetEvictCallback sets a callback function that will be called
+	// when entries are evicted due to LRU policy
+	SetEvictCallback(callback EvictCallback)
+
+	// Capacity returns the maximum number of entries the store can hold
+	Capacity() int
+}
+
+// EvictStore extends Store with on-demand eviction
+type EvictStore interface {
+	Store
+
+	// Evict removes up to n entries in the order the eviction policy would choose
+	// them and calls fn for each removed entry after the store lock is released.
+	// Returns the number of entries removed
+	Evict(n int, fn EvictCallback) int
+
+	// Victims returns up to n keys in the order Evict would remove them,
+	// without removing them or changing the eviction order
+	Victims(n int) []string
+}
+
+// PinStore extends Store with eviction protection for individual keys
+type PinStore interface {
+	Store
+
+	// Pin protects key from capacity eviction; expiration still applies
+	// Returns false if the key is missing or expired
+	Pin(key string) bool
+
+	// Unpin removes the protection. Returns false if the key was not pinned
+	Unpin(key string) bool
+
+	// PinnedCount returns the number of pinned keys
+	PinnedCount() int
+}
+
+// ResizeStore extends Store with runtime capacity changes
+type ResizeStore interface {
+	Store
+
+	// Resize sets the maximum number of entries, evicting any overflow in
+	// policy order through the evict callback
+	Resize(capacity int) error
+}
+
+// AccessStore extends Store with the per-key usage data tracked by its eviction policy
+type AccessStore interface {
+	Store
+
+	// FrequencyReporter returns the policy's access counts, or nil if it does not count accesses
+	FrequencyReporter() FrequencyReporter
+
+	// RecencyReporter returns the policy's access ordering, or nil if it does not order keys by access
+	RecencyReporter() RecencyReporter
+}
+
+// FrequencyReporter reports the access counts of an eviction policy that counts accesses per key
+type FrequencyReporter interface {
+	// Frequency returns the access count of key, after any aging.
+	// Returns false if the key is not tracked
+	Frequency(key string) (int64, bool)
+
+	// ColdestKeys returns up to n keys starting from the least frequently used
+	ColdestKeys(n int) []string
+}
+
+// RecencyReporter reports the ordering of an eviction policy that orders keys by last access
+type RecencyReporter interface {
+	// Recency returns the number of keys used less recently than key, so the
+	// least recently used key has recency 0. Returns false if the key is not tracked
+	Recency(key string) (int, bool)
+
+	// ColdestKeys returns up to n keys starting from the least recently used
+	ColdestKeys(n int) []string
+}
+
+// ContextStore extends Store with key listing and clearing bounded by a context,
+// for backends that walk a large keyspace incrementally
+type ContextStore interface {
+	Store
+
+	// KeysContext returns all keys, or ctx.Err() if ctx is done first
+	KeysContext(ctx context.Context) ([]string, error)
+
+	// ClearContext removes all entries in batches and calls fn, if not nil, with
+	// the keys of each removed batch. If ctx is done it returns ctx.Err() and the
+	// remaining entries stay in place
+	ClearContext(ctx context.Context, fn func(keys []string)) error
+}
+
+// RequestContextStore extends Store with single-key operations bounded by the
+// caller's context, for backends where one request can take seconds
+type RequestContextStore interface {
+	Store
+
+	// GetContext retrieves an entry like Get, reporting a miss if ctx is done first
+	GetContext(ctx context.Context, key string) (*entry.Entry, bool)
+
+	// SetContext stores an entry like Set, or returns ctx.Err() if ctx is done first
+	SetContext(ctx context.Context, key string, e *entry.Entry) error
+
+	// DeleteContext removes an entry like Delete, or returns ctx.Err() if ctx is done first
+	DeleteContext(ctx context.Context, key string) error
+}
+
+// GetWithContext retrieves key from s, bounded by ctx when s is a RequestContextStore
+func GetWithContext(ctx context.Context, s Store, key string) (*entry.Entry, bool) {
+	if ctxStore, ok := s.(RequestContextStore); ok {
+		return ctxStore.GetContext(ctx, key)
+	}
+	return s.Get(key)
+}
+
+// SetWithContext stores e under key in s, bounded by ctx when s is a RequestContextStore
+func SetWithContext(ctx context.Context, s Store, key string, e *entry.Entry) error {
+	if ctxStore, ok := s.(RequestContextStore); ok {
+		return ctxStore.SetContext(ctx, key, e)
+	}
+	return s.Set(key, e)
+}
+
+// DeleteWithContext removes key from s, bounded by ctx when s is a RequestContextStore
+func DeleteWithContext(ctx context.Context, s Store, key string) error {
+	if ctxStore, ok := s.(RequestContextStore); ok {
+		return ctxStore.DeleteContext(ctx, key)
+	}
+	return s.Delete(key)
+}
+
+// HealthChecker extends Store with a liveness probe of the backend
+type HealthChecker interface {
+	Store
+
+	// Ping reports whether the backend can serve requests, returning an error
+	// if it cannot or if ctx is done first
+	Ping(ctx context.Context) error
+}
+
+// BatchStore extends Store with multi-key writes that save round trips on
+// networked backends
+type BatchStore interface {
+	Store
+
+	// SetBatch stores all entries. Keys that could not be stored are reported
+	// in a *BatchError; every other key was written
+	SetBatch(entries map[string]*entry.Entry) error
+
+	// DeleteBatch removes all keys. Keys that could not be removed are reported
+	// in a *BatchError; every other key was deleted
+	DeleteBatch(keys []string) error
+}
+
+// BatchGetStore extends Store with multi-key reads that save round trips on
+// networked backends
+type BatchGetStore interface {
+	Store
+
+	// GetBatch retrieves the entries for keys like Get, leaving missing and
+	// expired keys out of the result. An error means the batch could not be
+	// read; the entries found before it are still returned
+	GetBatch(keys []string) (map[string]*entry.Entry, error)
+}
+
+// GetMany retrieves the entries for keys from s, using GetBatch when s is a
+// BatchGetStore. Keys the batch could not read are retried with Get
+func GetMany(s Store, keys []string) map[string]*entry.Entry {
+	var found map[string]*entry.Entry
+	if batchStore, ok := s.(BatchGetStore); ok {
+		var err error
+		if found, err = batchStore.GetBatch(keys); err == nil {
+			return found
+		}
+	}
+	if found == nil {
+		found = make(map[string]*entry.Entry, len(keys))
+	}
+
+	for _, key := range keys {
+		if _, ok := found[key]; ok {
+			continue
+		}
+		if e, ok := s.Get(key); ok {
+			found[key] = e
+		}
+	}
+	return found
+}
+
+// BatchError reports the keys of a batch operation that failed
+type BatchError struct {
+	// Errors holds the error for each failed key
+	Errors map[string]error
+}
+
+// Error summarizes the failed keys
+func (e *BatchError) Error() string {
+	keys := e.Keys()
+	switch len(keys) {
+	case 0:
+		return "batch operation failed"
+	case 1:
+		return fmt.Sprintf("batch operation failed for key %q: %v", keys[0], e.Errors[keys[0]])
+	}
+	return fmt.Sprintf("batch operation failed for %d keys, first %q: %v", len(keys), keys[0], e.Errors[keys[0]])
+}
+
+// Keys returns the failed keys in sorted order
+func (e *BatchError) Keys() []string {
+	keys := make([]string, 0, len(e.Errors))
+	for key := range e.Errors {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// Unwrap returns the per-key errors so errors.Is and errors.As can match them
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, key := range e.Keys() {
+		errs = append(errs, e.Errors[key])
+	}
+	return errs
+}
+
+// JoinBatchErrors returns a *BatchError for the failed keys, or nil if there are none
+func JoinBatchErrors(failed map[string]error) error {
+	if len(failed) == 0 {
+		return nil
+	}
+	return &BatchError{Errors: failed}
+}
+
+// TieredStore extends Store with reporting for stores that layer a local tier
+// over a shared one
+type TieredStore interface {
+	Store
+
+	// SetTierHitCallback sets a callback called with the tier (1 for local,
+	// 2 for shared) that served each Get hit
+	SetTierHitCallback(callback func(tier int))
+
+	// InvalidateLocal drops key from the local tier only, leaving the shared tier
+	// untouched, e.g. when another instance reports a change
+	InvalidateLocal(key string)
+}
+
+// InvalidateCallback is called with keys changed outside this process
+// A nil keys slice means any key may have changed, e.g. after missed notifications
+type InvalidateCallback func(keys []string)
+
+// InvalidationStore extends Store with notifications of changes made by other
+// clients, which lets a local tier in front of it stay consistent
+type InvalidationStore interface {
+	Store
+
+	// SetInvalidateCallback sets the callback for changes made by other clients
+	SetInvalidateCallback(callback InvalidateCallback)
+}
+
+// SpillStore extends Store with reporting for stores that move entries evicted
+// from memory to a slower tier instead of dropping them
+type SpillStore interface {
+	Store
+
+	// SetSpillCallbacks sets callbacks called for each entry moved down to the
+	// slower tier and each entry moved back up on a hit
+	SetSpillCallbacks(onSpill, onRestore func())
+}
+
+// WriteBehindStore extends Store with reporting for stores that acknowledge
+// writes before applying them to their backend
+type WriteBehindStore interface {
+	Store
+
+	// QueueDepth returns the number of writes not yet applied to the backend
+	QueueDepth() int
+
+	// SetQueueDepthCallback sets a callback called with the queue depth whenever it changes
+	SetQueueDepthCallback(callback func(depth int))
+
+	// Flush blocks until every queued write has been applied or ctx is done
+	Flush(ctx context.Context) error
+}
+
+// TTLStore extends Store with TTL cleanup functionality
+type TTLStore interface {
+	Store
+
+	// Cleanup removes expired entries
+	// Returns the number of entries removed
+	Cleanup() int
+
+	// SetCleanupCallback sets a callback function that will be called
+	// when entries are removed during cleanup
+	SetCleanupCallback(callback EvictCallback)
+
+	// UpdateTTL changes the expiration of an existing entry in place
+	// A ttl <= 0 removes the expiration. Returns false if the key is missing or expired
+	UpdateTTL(key string, ttl time.Duration) bool
+}
+
+// ScanStore extends Store with cursor-based key listing
+type ScanStore interface {
+	Store
+
+	// Scan returns keys with the given prefix, starting after cursor
+	// An empty cursor starts a new iteration and an empty nextCursor means it is complete
+	// limit bounds the page size; backends that scan in batches may treat it as a hint
+	Scan(prefix string, cursor string, limit int) (keys []string, nextCursor string, err error)
+}
+
+// WeightStore extends Store with the total size of entries held under a byte budget
+type WeightStore interface {
+	Store
+
+	// Weight returns the total stored size in bytes of the entries, counting
+	// compressed values at their compressed length (0 without a byte budget)
+	Weight() int64
+}
+
+// MemoryStore extends Store with a running estimate of the memory its entries hold
+type MemoryStore interface {
+	Store
+
+	// MemoryBytes returns the approximate bytes held by the entries: keys, stored
+	// value sizes and a fixed per-entry overhead
+	MemoryBytes() int64
+}
+
+// CountStore extends Store with prefix cardinality queries
+type CountStore interface {
+	Store
+
+	// CountPrefix counts non-expired entries whose key starts with prefix
+	// and sums their stored sizes in bytes where the backend knows them
+	CountPrefix(prefix string) (count int, size int64)
+}
+
+// KeyCountStore extends CountStore with a count that skips the sizes, for
+// backends that need extra work per key to read them
+type KeyCountStore interface {
+	CountStore
+
+	// CountPrefixKeys counts non-expired entries whose key starts with prefix
+	CountPrefixKeys(prefix string) int
+}
+
+// CountPrefixKeys counts the entries of s whose key starts with prefix, without
+// reading their sizes when s is a KeyCountStore
+func CountPrefixKeys(s CountStore, prefix string) int {
+	if keyCounter, ok := s.(KeyCountStore); ok {
+		return keyCounter.CountPrefixKeys(prefix)
+	}
+	count, _ := s.CountPrefix(prefix)
+	return count
+}
+
+// SwapStore extends Store with an atomic replace operation
+type SwapStore interface {
+	Store
+
+	// Swap stores an entry and returns the entry it replaced in one atomic step
+	// Returns false if there was no live entry for the key
+	Swap(key string, entry *entry.Entry) (previous *entry.Entry, existed bool, err error)
+}
+
+// VersionedStore extends Store with version-conditional writes
+type VersionedStore interface {
+	Store
+
+	// SetIfNewer stores the entry only if the key is absent or holds an entry
+	// with a lower Version. Returns false if the write was skipped
+	SetIfNewer(key string, entry *entry.Entry) (bool, error)
+}